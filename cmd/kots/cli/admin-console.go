@@ -106,6 +106,7 @@ func AdminConsoleCmd() *cobra.Command {
 	cmd.AddCommand(AdminPushImagesCmd())
 	cmd.AddCommand(AdminCopyPublicImagesCmd())
 	cmd.AddCommand(GarbageCollectImagesCmd())
+	cmd.AddCommand(RotateEncryptionKeyCmd())
 	cmd.AddCommand(AdminGenerateManifestsCmd())
 
 	return cmd
@@ -39,6 +39,10 @@ func AppStatusCmd() *cobra.Command {
 				}
 			}
 
+			if v.GetBool("history") && v.GetBool("drift") {
+				return errors.New("--history and --drift cannot be used together")
+			}
+
 			log := logger.NewCLILogger(cmd.OutOrStdout())
 
 			stopCh := make(chan struct{})
@@ -75,6 +79,14 @@ func AppStatusCmd() *cobra.Command {
 			}()
 
 			url := fmt.Sprintf("http://localhost:%d/api/v1/app/%s/status", localPort, appSlug)
+			if v.GetBool("drift") {
+				url = fmt.Sprintf("http://localhost:%d/api/v1/app/%s/drift?refresh=true", localPort, appSlug)
+			} else if v.GetBool("history") {
+				url = fmt.Sprintf("%s/history", url)
+				if cmd.Flags().Changed("sequence") {
+					url = fmt.Sprintf("%s?sequence=%d", url, v.GetInt64("sequence"))
+				}
+			}
 
 			authSlug, err := auth.GetOrCreateAuthSlug(clientset, v.GetString("namespace"))
 			if err != nil {
@@ -105,6 +117,10 @@ func AppStatusCmd() *cobra.Command {
 				return errors.Wrap(err, "failed to read")
 			}
 
+			if resp.StatusCode != http.StatusOK {
+				return errors.Errorf("unexpected response from server %v: %s", resp.StatusCode, b)
+			}
+
 			fmt.Printf("%s\n", b)
 
 			return nil
@@ -113,6 +129,9 @@ func AppStatusCmd() *cobra.Command {
 
 	cmd.Flags().StringP("namespace", "n", "default", "namespace in which kots/kotsadm is installed")
 	cmd.Flags().String("slug", "", "the application slug to get the status of")
+	cmd.Flags().Bool("history", false, "return the history of resource state transitions instead of the current status")
+	cmd.Flags().Int64("sequence", 0, "only return the resource state transitions of this sequence (requires --history)")
+	cmd.Flags().Bool("drift", false, "compare the deployed version with the live resources in the cluster and return the resources that are missing or were modified")
 
 	return cmd
 }
@@ -0,0 +1,339 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	downstreamtypes "github.com/replicatedhq/kots/pkg/api/downstream/types"
+	apitypes "github.com/replicatedhq/kots/pkg/api/handlers/types"
+	appstatetypes "github.com/replicatedhq/kots/pkg/appstate/types"
+	"github.com/replicatedhq/kots/pkg/auth"
+	"github.com/replicatedhq/kots/pkg/handlers"
+	handlertypes "github.com/replicatedhq/kots/pkg/handlers/types"
+	"github.com/replicatedhq/kots/pkg/k8sutil"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/print"
+	storetypes "github.com/replicatedhq/kots/pkg/store/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func DeployCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "deploy [appSlug]",
+		Short: "Deploy a version of an application",
+		Long: `Deploy a version of an application, selected by its sequence or version label.
+The same checks as deploying from the Admin Console apply, e.g. required versions, strict preflights and whether rollback is supported.
+With --plan, nothing is deployed. Instead, the changes that deploying the version would make to the cluster are listed:
+resources that would be created, updated, deleted or recreated because of a change to an immutable field, and helm releases that would be installed, upgraded or uninstalled.`,
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+
+			if len(args) != 1 {
+				cmd.Help()
+				os.Exit(1)
+			}
+			appSlug := args[0]
+
+			versionLabel := v.GetString("version-label")
+			if cmd.Flags().Changed("sequence") == (versionLabel != "") {
+				return errors.New("exactly one of --sequence or --version-label is required")
+			}
+
+			isPlan := v.GetBool("plan")
+			if isPlan && (v.GetBool("wait") || v.GetBool("redeploy") || v.GetBool("skip-preflights")) {
+				return errors.New("--plan cannot be used with --wait, --redeploy or --skip-preflights")
+			}
+
+			output := v.GetString("output")
+			if output != "json" && output != "" {
+				return errors.Errorf("output format %s not supported (allowed formats are: json)", output)
+			}
+			if output != "" && !isPlan {
+				return errors.New("--output is only supported with --plan")
+			}
+
+			log := logger.NewCLILogger(cmd.OutOrStdout())
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			clientset, err := k8sutil.GetClientset()
+			if err != nil {
+				return errors.Wrap(err, "failed to get clientset")
+			}
+
+			namespace, err := getNamespaceOrDefault(v.GetString("namespace"))
+			if err != nil {
+				return errors.Wrap(err, "failed to get namespace")
+			}
+
+			getPodName := func() (string, error) {
+				return k8sutil.FindKotsadm(clientset, namespace)
+			}
+
+			localPort, errChan, err := k8sutil.PortForward(0, 3000, namespace, getPodName, false, stopCh, log)
+			if err != nil {
+				log.FinishSpinnerWithError()
+				return errors.Wrap(err, "failed to start port forwarding")
+			}
+
+			go func() {
+				select {
+				case err := <-errChan:
+					if err != nil {
+						log.Error(err)
+					}
+				case <-stopCh:
+				}
+			}()
+
+			authSlug, err := auth.GetOrCreateAuthSlug(clientset, namespace)
+			if err != nil {
+				log.FinishSpinnerWithError()
+				log.Info("Unable to authenticate to the Admin Console running in the %s namespace. Ensure you have read access to secrets in this namespace and try again.", namespace)
+				if v.GetBool("debug") {
+					return errors.Wrap(err, "failed to get kotsadm auth slug")
+				}
+				os.Exit(2) // not returning error here as we don't want to show the entire stack trace to normal users
+			}
+
+			sequence := v.GetInt64("sequence")
+			if versionLabel != "" {
+				appVersion, err := findAppVersion(localPort, appSlug, authSlug, func(v *downstreamtypes.DownstreamVersion) bool {
+					return v.VersionLabel == versionLabel
+				})
+				if err != nil {
+					return errors.Wrap(err, "failed to find version")
+				}
+				if appVersion == nil {
+					return errors.Errorf("version with label %s could not be found", versionLabel)
+				}
+				sequence = appVersion.Sequence
+			}
+
+			if isPlan {
+				url := fmt.Sprintf("http://localhost:%d/api/v1/app/%s/sequence/%d/plan", localPort, url.PathEscape(appSlug), sequence)
+
+				plan, err := getAppVersionDeployPlan(url, authSlug)
+				if err != nil {
+					return errors.Wrap(err, "failed to get deploy plan")
+				}
+
+				print.DeployPlan(plan, output, v.GetBool("summary"))
+
+				return nil
+			}
+
+			if v.GetBool("redeploy") {
+				log.ActionWithSpinner("Redeploying sequence %d", sequence)
+				url := fmt.Sprintf("http://localhost:%d/api/v1/app/%s/sequence/%d/redeploy", localPort, url.PathEscape(appSlug), sequence)
+				err = redeployAppVersion(url, authSlug)
+			} else {
+				log.ActionWithSpinner("Deploying sequence %d", sequence)
+				url := fmt.Sprintf("http://localhost:%d/api/v1/app/%s/sequence/%d/deploy", localPort, url.PathEscape(appSlug), sequence)
+				err = deployAppVersion(url, authSlug, v.GetBool("skip-preflights"))
+			}
+			if err != nil {
+				log.FinishSpinnerWithError()
+				return err
+			}
+			log.FinishSpinner()
+
+			if v.GetBool("wait") {
+				if err := waitForAppVersionReady(log, localPort, appSlug, authSlug, sequence, v.GetDuration("timeout")); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().Int64("sequence", 0, "the sequence to deploy")
+	cmd.Flags().String("version-label", "", "the version label of the version to deploy. if more than one version has this label, the most recent one is deployed")
+	cmd.Flags().Bool("redeploy", false, "deploy the version again, even if it is the currently deployed version")
+	cmd.Flags().Bool("skip-preflights", false, "set to true to report that preflight checks were skipped for this version")
+	cmd.Flags().Bool("wait", false, "wait for the version to be deployed and for the application to be ready. requires the application to have status informers")
+	cmd.Flags().Duration("timeout", 10*time.Minute, "how long to wait for the application to be ready (requires --wait)")
+	cmd.Flags().Bool("plan", false, "list the changes that deploying the version would make to the cluster, without deploying it")
+	cmd.Flags().Bool("summary", false, "with --plan, only list the changed resources, without the diff of each resource")
+	cmd.Flags().StringP("output", "o", "", "output format for --plan (currently supported: json)")
+
+	return cmd
+}
+
+func deployAppVersion(url string, authSlug string, skipPreflights bool) error {
+	requestPayload := handlers.DeployAppVersionRequest{
+		IsSkipPreflights: skipPreflights,
+		IsCLI:            true,
+	}
+	requestBody, err := json.Marshal(requestPayload)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal request")
+	}
+
+	newReq, err := http.NewRequest("POST", url, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	newReq.Header.Add("Content-Type", "application/json")
+	newReq.Header.Add("Authorization", authSlug)
+
+	resp, err := http.DefaultClient.Do(newReq)
+	if err != nil {
+		return errors.Wrap(err, "failed to execute request")
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read")
+	}
+
+	response := handlers.DeployAppVersionResponse{}
+	if err := json.Unmarshal(b, &response); err != nil {
+		return errors.Errorf("unexpected status code %d: %s", resp.StatusCode, string(b))
+	}
+
+	if resp.StatusCode != http.StatusOK || !response.Success {
+		return errors.Errorf("failed to deploy: %s", response.Error)
+	}
+
+	return nil
+}
+
+func redeployAppVersion(url string, authSlug string) error {
+	newReq, err := http.NewRequest("POST", url, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	newReq.Header.Add("Content-Type", "application/json")
+	newReq.Header.Add("Authorization", authSlug)
+
+	resp, err := http.DefaultClient.Do(newReq)
+	if err != nil {
+		return errors.Wrap(err, "failed to execute request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// findAppVersion returns the most recent version of an app that matches, or nil if no version matches
+func findAppVersion(localPort int, appSlug string, authSlug string, match func(*downstreamtypes.DownstreamVersion) bool) (*downstreamtypes.DownstreamVersion, error) {
+	pageSize := 100
+	for currentPage := 0; ; currentPage++ {
+		urlVals := url.Values{}
+		urlVals.Set("currentPage", fmt.Sprintf("%d", currentPage))
+		urlVals.Set("pageSize", fmt.Sprintf("%d", pageSize))
+		url := fmt.Sprintf("http://localhost:%d/api/v1/app/%s/versions?%s", localPort, url.PathEscape(appSlug), urlVals.Encode())
+
+		appVersions, err := getAppVersions(url, authSlug)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get app versions")
+		}
+
+		for _, v := range appVersions.VersionHistory {
+			if match(v) {
+				return v, nil
+			}
+		}
+
+		if len(appVersions.VersionHistory) == 0 || (currentPage+1)*pageSize >= appVersions.TotalCount {
+			return nil, nil
+		}
+	}
+}
+
+// waitForAppVersionReady waits until a sequence has been deployed and the status informers of
+// that sequence report that the application is ready
+func waitForAppVersionReady(log *logger.CLILogger, localPort int, appSlug string, authSlug string, sequence int64, timeout time.Duration) error {
+	log.ActionWithSpinner("Waiting for the application to be ready")
+
+	start := time.Now()
+	for {
+		appVersion, err := findAppVersion(localPort, appSlug, authSlug, func(v *downstreamtypes.DownstreamVersion) bool {
+			return v.Sequence == sequence
+		})
+		if err != nil {
+			log.FinishSpinnerWithError()
+			return errors.Wrap(err, "failed to get app version")
+		}
+		if appVersion == nil {
+			log.FinishSpinnerWithError()
+			return errors.Errorf("sequence %d not found", sequence)
+		}
+		if appVersion.Status == storetypes.VersionFailed {
+			log.FinishSpinnerWithError()
+			return errors.Errorf("failed to deploy sequence %d", sequence)
+		}
+
+		if appVersion.Status == storetypes.VersionDeployed {
+			url := fmt.Sprintf("http://localhost:%d/api/v1/app/%s/status", localPort, url.PathEscape(appSlug))
+			appStatus, err := getAppStatus(url, authSlug)
+			if err != nil {
+				log.FinishSpinnerWithError()
+				return errors.Wrap(err, "failed to get app status")
+			}
+			if appStatus.AppStatus != nil && appStatus.AppStatus.Sequence == sequence && appStatus.AppStatus.State == appstatetypes.StateReady {
+				log.FinishSpinner()
+				return nil
+			}
+		}
+
+		if time.Since(start) > timeout {
+			log.FinishSpinnerWithError()
+			return errors.Errorf("timed out waiting for sequence %d to be ready", sequence)
+		}
+
+		time.Sleep(2 * time.Second)
+	}
+}
+
+func getAppVersionDeployPlan(url string, authSlug string) (*apitypes.AppVersionDeployPlanResponse, error) {
+	newReq, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+	newReq.Header.Add("Content-Type", "application/json")
+	newReq.Header.Add("Authorization", authSlug)
+
+	resp, err := http.DefaultClient.Do(newReq)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to execute request")
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status code %d: %s", resp.StatusCode, handlertypes.ErrorFromResponse(b))
+	}
+
+	plan := apitypes.AppVersionDeployPlanResponse{}
+	if err := json.Unmarshal(b, &plan); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal deploy plan")
+	}
+
+	return &plan, nil
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	downstreamtypes "github.com/replicatedhq/kots/pkg/api/downstream/types"
+	"github.com/replicatedhq/kots/pkg/handlers"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_findAppVersion(t *testing.T) {
+	req := require.New(t)
+
+	// 150 versions, newest first, so the versions are split across two pages.
+	// labels v0 to v29 are used twice, by sequences 0 to 29 and 120 to 149
+	versions := []*downstreamtypes.DownstreamVersion{}
+	for sequence := int64(149); sequence >= 0; sequence-- {
+		versions = append(versions, &downstreamtypes.DownstreamVersion{
+			Sequence:     sequence,
+			VersionLabel: "v" + strconv.FormatInt(sequence%120, 10),
+		})
+	}
+
+	requestedPages := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req.Equal("/api/v1/app/my-app/versions", r.URL.Path)
+		req.Equal("auth-slug", r.Header.Get("Authorization"))
+
+		currentPage, _ := strconv.Atoi(r.URL.Query().Get("currentPage"))
+		pageSize, _ := strconv.Atoi(r.URL.Query().Get("pageSize"))
+		requestedPages = append(requestedPages, r.URL.Query().Get("currentPage"))
+
+		start, end := currentPage*pageSize, (currentPage+1)*pageSize
+		if start > len(versions) {
+			start = len(versions)
+		}
+		if end > len(versions) {
+			end = len(versions)
+		}
+
+		response := handlers.GetAppVersionHistoryResponse{}
+		response.VersionHistory = versions[start:end]
+		response.TotalCount = len(versions)
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	req.NoError(err)
+	localPort, err := strconv.Atoi(serverURL.Port())
+	req.NoError(err)
+
+	byLabel := func(label string) func(*downstreamtypes.DownstreamVersion) bool {
+		return func(v *downstreamtypes.DownstreamVersion) bool {
+			return v.VersionLabel == label
+		}
+	}
+
+	// the most recent version with the label is returned
+	got, err := findAppVersion(localPort, "my-app", "auth-slug", byLabel("v10"))
+	req.NoError(err)
+	req.Equal(int64(130), got.Sequence)
+	req.Equal([]string{"0"}, requestedPages)
+
+	requestedPages = []string{}
+	got, err = findAppVersion(localPort, "my-app", "auth-slug", byLabel("v40"))
+	req.NoError(err)
+	req.Equal(int64(40), got.Sequence)
+	req.Equal([]string{"0", "1"}, requestedPages)
+
+	requestedPages = []string{}
+	got, err = findAppVersion(localPort, "my-app", "auth-slug", byLabel("missing"))
+	req.NoError(err)
+	req.Nil(got)
+	req.Equal([]string{"0", "1"}, requestedPages)
+}
+
+func Test_deployAppVersion(t *testing.T) {
+	req := require.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := handlers.DeployAppVersionRequest{}
+		req.NoError(json.NewDecoder(r.Body).Decode(&request))
+		req.True(request.IsCLI)
+
+		if !request.IsSkipPreflights {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(handlers.DeployAppVersionResponse{Error: "Rollback is not supported."})
+			return
+		}
+		json.NewEncoder(w).Encode(handlers.DeployAppVersionResponse{Success: true})
+	}))
+	defer server.Close()
+
+	err := deployAppVersion(server.URL, "auth-slug", true)
+	req.NoError(err)
+
+	err = deployAppVersion(server.URL, "auth-slug", false)
+	req.EqualError(err, "failed to deploy: Rollback is not supported.")
+}
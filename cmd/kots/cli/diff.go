@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/pkg/errors"
+	apitypes "github.com/replicatedhq/kots/pkg/api/handlers/types"
+	"github.com/replicatedhq/kots/pkg/auth"
+	handlertypes "github.com/replicatedhq/kots/pkg/handlers/types"
+	"github.com/replicatedhq/kots/pkg/k8sutil"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/print"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func DiffCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff [appSlug]",
+		Short: "Show the changes to the rendered manifests between two versions of an application",
+		Long: `Compare the rendered manifests of two versions of an application resource by resource.
+Resources are reported as added, removed or modified, and changes to the values of helm charts are listed separately.
+Key order and formatting are ignored when comparing resources.`,
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+
+			if len(args) != 1 {
+				cmd.Help()
+				os.Exit(1)
+			}
+			appSlug := args[0]
+
+			if !cmd.Flags().Changed("from") || !cmd.Flags().Changed("to") {
+				return errors.New("--from and --to are required")
+			}
+
+			output := v.GetString("output")
+			if output != "json" && output != "" {
+				return errors.Errorf("output format %s not supported (allowed formats are: json)", output)
+			}
+
+			log := logger.NewCLILogger(cmd.OutOrStdout())
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			clientset, err := k8sutil.GetClientset()
+			if err != nil {
+				return errors.Wrap(err, "failed to get clientset")
+			}
+
+			namespace, err := getNamespaceOrDefault(v.GetString("namespace"))
+			if err != nil {
+				return errors.Wrap(err, "failed to get namespace")
+			}
+
+			getPodName := func() (string, error) {
+				return k8sutil.FindKotsadm(clientset, namespace)
+			}
+
+			localPort, errChan, err := k8sutil.PortForward(0, 3000, namespace, getPodName, false, stopCh, log)
+			if err != nil {
+				log.FinishSpinnerWithError()
+				return errors.Wrap(err, "failed to start port forwarding")
+			}
+
+			go func() {
+				select {
+				case err := <-errChan:
+					if err != nil {
+						log.Error(err)
+					}
+				case <-stopCh:
+				}
+			}()
+
+			authSlug, err := auth.GetOrCreateAuthSlug(clientset, namespace)
+			if err != nil {
+				log.FinishSpinnerWithError()
+				log.Info("Unable to authenticate to the Admin Console running in the %s namespace. Ensure you have read access to secrets in this namespace and try again.", namespace)
+				if v.GetBool("debug") {
+					return errors.Wrap(err, "failed to get kotsadm auth slug")
+				}
+				os.Exit(2) // not returning error here as we don't want to show the entire stack trace to normal users
+			}
+
+			urlVals := url.Values{}
+			urlVals.Set("from", fmt.Sprintf("%d", v.GetInt64("from")))
+			urlVals.Set("to", fmt.Sprintf("%d", v.GetInt64("to")))
+			url := fmt.Sprintf("http://localhost:%d/api/v1/app/%s/diff?%s", localPort, url.PathEscape(appSlug), urlVals.Encode())
+
+			diff, err := getAppVersionsDiff(url, authSlug)
+			if err != nil {
+				return errors.Wrap(err, "failed to get app versions diff")
+			}
+
+			print.VersionsDiff(diff, output, v.GetBool("summary"))
+
+			return nil
+		},
+	}
+
+	cmd.Flags().Int64("from", 0, "the sequence to compare from")
+	cmd.Flags().Int64("to", 0, "the sequence to compare to")
+	cmd.Flags().Bool("summary", false, "only list the changed resources, without the diff of each resource")
+	cmd.Flags().StringP("output", "o", "", "output format (currently supported: json)")
+
+	return cmd
+}
+
+func getAppVersionsDiff(url string, authSlug string) (*apitypes.AppVersionsDiffResponse, error) {
+	newReq, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+	newReq.Header.Add("Content-Type", "application/json")
+	newReq.Header.Add("Authorization", authSlug)
+
+	resp, err := http.DefaultClient.Do(newReq)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to execute request")
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status code %d: %s", resp.StatusCode, handlertypes.ErrorFromResponse(b))
+	}
+
+	diff := apitypes.AppVersionsDiffResponse{}
+	if err := json.Unmarshal(b, &diff); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal app versions diff")
+	}
+
+	return &diff, nil
+}
@@ -0,0 +1,214 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/api/handlers/types"
+	"github.com/replicatedhq/kots/pkg/auth"
+	"github.com/replicatedhq/kots/pkg/k8sutil"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/print"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func GetAuditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Get the audit log of admin console actions",
+		Long: `Get the audit log of mutating admin console actions, newest first.
+
+Use "-o ndjson" to export all matching events as newline delimited json, e.g. for ingestion by a SIEM.`,
+		SilenceUsage:  false,
+		SilenceErrors: false,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: getAuditCmd,
+	}
+
+	cmd.Flags().Int("current-page", 0, "offset by page size at which to start retrieving events")
+	cmd.Flags().Int("page-size", 20, "number of events to return (defaults to 20, ignored for ndjson output)")
+	cmd.Flags().String("since", "", "only return events after this time, either RFC3339 or a duration such as 24h")
+	cmd.Flags().String("until", "", "only return events before this time, either RFC3339 or a duration such as 1h")
+	cmd.Flags().String("user", "", "only return events performed by this user id")
+	cmd.Flags().String("action", "", "only return events for this action, e.g. DeployAppVersion")
+	cmd.Flags().StringP("output", "o", "", "output format (currently supported: json, ndjson)")
+
+	return cmd
+}
+
+func getAuditCmd(cmd *cobra.Command, args []string) error {
+	v := viper.GetViper()
+
+	output := v.GetString("output")
+	if output != "json" && output != "ndjson" && output != "" {
+		return errors.Errorf("output format %s not supported (allowed formats are: json, ndjson)", output)
+	}
+
+	urlVals := url.Values{}
+	if val := v.GetString("since"); val != "" {
+		since, err := parseAuditTime(val, time.Now())
+		if err != nil {
+			return errors.Wrap(err, "failed to parse since")
+		}
+		urlVals.Set("since", since.Format(time.RFC3339))
+	}
+	if val := v.GetString("until"); val != "" {
+		until, err := parseAuditTime(val, time.Now())
+		if err != nil {
+			return errors.Wrap(err, "failed to parse until")
+		}
+		urlVals.Set("until", until.Format(time.RFC3339))
+	}
+	if val := v.GetString("user"); val != "" {
+		urlVals.Set("userId", val)
+	}
+	if val := v.GetString("action"); val != "" {
+		urlVals.Set("action", val)
+	}
+
+	log := logger.NewCLILogger(cmd.OutOrStdout())
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	clientset, err := k8sutil.GetClientset()
+	if err != nil {
+		return errors.Wrap(err, "failed to get clientset")
+	}
+
+	namespace, err := getNamespaceOrDefault(v.GetString("namespace"))
+	if err != nil {
+		return errors.Wrap(err, "failed to get namespace")
+	}
+
+	getPodName := func() (string, error) {
+		return k8sutil.FindKotsadm(clientset, namespace)
+	}
+
+	localPort, errChan, err := k8sutil.PortForward(0, 3000, namespace, getPodName, false, stopCh, log)
+	if err != nil {
+		log.FinishSpinnerWithError()
+		return errors.Wrap(err, "failed to start port forwarding")
+	}
+
+	go func() {
+		select {
+		case err := <-errChan:
+			if err != nil {
+				log.Error(err)
+			}
+		case <-stopCh:
+		}
+	}()
+
+	authSlug, err := auth.GetOrCreateAuthSlug(clientset, namespace)
+	if err != nil {
+		log.FinishSpinnerWithError()
+		log.Info("Unable to authenticate to the Admin Console running in the %s namespace. Ensure you have read access to secrets in this namespace and try again.", namespace)
+		if v.GetBool("debug") {
+			return errors.Wrap(err, "failed to get kotsadm auth slug")
+		}
+		os.Exit(2) // not returning error here as we don't want to show the entire stack trace to normal users
+	}
+
+	if output == "ndjson" {
+		url := fmt.Sprintf("http://localhost:%d/api/v1/audit/export?%s", localPort, urlVals.Encode())
+		if err := exportAuditEvents(url, authSlug, cmd.OutOrStdout()); err != nil {
+			return errors.Wrap(err, "failed to export audit events")
+		}
+		return nil
+	}
+
+	urlVals.Set("currentPage", fmt.Sprintf("%d", v.GetInt("current-page")))
+	urlVals.Set("pageSize", fmt.Sprintf("%d", v.GetInt("page-size")))
+
+	url := fmt.Sprintf("http://localhost:%d/api/v1/audit?%s", localPort, urlVals.Encode())
+	response, err := getAuditEvents(url, authSlug)
+	if err != nil {
+		return errors.Wrap(err, "failed to get audit events")
+	}
+
+	print.AuditEvents(response.Events, output)
+
+	return nil
+}
+
+// parseAuditTime parses either an RFC3339 timestamp or a duration relative to now
+func parseAuditTime(val string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(val); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		return time.Time{}, errors.Errorf("%q is neither an RFC3339 time nor a duration", val)
+	}
+	return t, nil
+}
+
+func getAuditEvents(url string, authSlug string) (*types.ListAuditEventsResponse, error) {
+	newReq, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+	newReq.Header.Add("Content-Type", "application/json")
+	newReq.Header.Add("Authorization", authSlug)
+
+	resp, err := http.DefaultClient.Do(newReq)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to execute request")
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read")
+	}
+
+	response := types.ListAuditEventsResponse{}
+	if resp.StatusCode != http.StatusOK {
+		if err := json.Unmarshal(b, &response); err == nil && response.Error != "" {
+			return nil, errors.New(response.Error)
+		}
+		return nil, errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(b, &response); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal audit events")
+	}
+
+	return &response, nil
+}
+
+func exportAuditEvents(url string, authSlug string, w io.Writer) error {
+	newReq, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	newReq.Header.Add("Authorization", authSlug)
+
+	resp, err := http.DefaultClient.Do(newReq)
+	if err != nil {
+		return errors.Wrap(err, "failed to execute request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return errors.Wrap(err, "failed to copy response")
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseAuditTime(t *testing.T) {
+	now := time.Date(2023, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		val     string
+		want    time.Time
+		wantErr bool
+	}{
+		{
+			name: "duration",
+			val:  "24h",
+			want: time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "rfc3339",
+			val:  "2022-12-31T08:30:00Z",
+			want: time.Date(2022, 12, 31, 8, 30, 0, 0, time.UTC),
+		},
+		{
+			name:    "invalid",
+			val:     "yesterday",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAuditTime(tt.val, now)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, tt.want.Equal(got), "want %s, got %s", tt.want, got)
+		})
+	}
+}
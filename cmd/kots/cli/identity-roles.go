@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/api/handlers/types"
+	"github.com/replicatedhq/kots/pkg/print"
+	rbactypes "github.com/replicatedhq/kots/pkg/rbac/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"sigs.k8s.io/yaml"
+)
+
+func IdentityServiceRolesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "roles",
+		Short: "Manage custom RBAC roles",
+		Long: `Manage custom RBAC roles that can be granted to identity provider groups.
+
+Roles are defined in yaml files, for example:
+
+  id: my-app-deployer
+  name: My App Deployer
+  allow:
+  - action: read
+    resource: app.my-app
+  - action: read
+    resource: app.my-app.**
+  - action: "**"
+    resource: app.my-app.downstream.deploy.*`,
+	}
+
+	cmd.AddCommand(IdentityServiceRolesListCmd())
+	cmd.AddCommand(IdentityServiceRolesCreateCmd())
+	cmd.AddCommand(IdentityServiceRolesUpdateCmd())
+	cmd.AddCommand(IdentityServiceRolesRemoveCmd())
+
+	return cmd
+}
+
+func IdentityServiceRolesListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "ls",
+		Aliases:       []string{"list"},
+		Short:         "List the default and custom roles",
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+
+			b, err := adminConsoleRequest(cmd, "GET", "/api/v1/rbac/roles", nil, http.StatusOK)
+			if err != nil {
+				return err
+			}
+
+			response := types.ListRBACRolesResponse{}
+			if err := json.Unmarshal(b, &response); err != nil {
+				return errors.Wrap(err, "failed to unmarshal roles")
+			}
+
+			print.Roles(response.Roles, v.GetString("output"))
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("output", "o", "", "output format. supported values: json")
+
+	return cmd
+}
+
+func IdentityServiceRolesCreateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "create",
+		Short:         "Create a custom role from a yaml file",
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+
+			role, err := loadRoleFile(v.GetString("file"))
+			if err != nil {
+				return err
+			}
+
+			body, err := json.Marshal(role)
+			if err != nil {
+				return errors.Wrap(err, "failed to marshal role")
+			}
+
+			if _, err := adminConsoleRequest(cmd, "POST", "/api/v1/rbac/roles", body, http.StatusCreated); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Role %s created\n", role.ID)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("file", "f", "", "path to a yaml file containing the role")
+	cmd.MarkFlagRequired("file")
+
+	return cmd
+}
+
+func IdentityServiceRolesUpdateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "update",
+		Short:         "Update a custom role from a yaml file",
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+
+			role, err := loadRoleFile(v.GetString("file"))
+			if err != nil {
+				return err
+			}
+
+			body, err := json.Marshal(role)
+			if err != nil {
+				return errors.Wrap(err, "failed to marshal role")
+			}
+
+			if _, err := adminConsoleRequest(cmd, "PUT", fmt.Sprintf("/api/v1/rbac/role/%s", role.ID), body, http.StatusOK); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Role %s updated\n", role.ID)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("file", "f", "", "path to a yaml file containing the role")
+	cmd.MarkFlagRequired("file")
+
+	return cmd
+}
+
+func IdentityServiceRolesRemoveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "rm [id]",
+		Aliases:       []string{"delete"},
+		Short:         "Remove a custom role",
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Args:          cobra.ExactArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			roleID := args[0]
+
+			if _, err := adminConsoleRequest(cmd, "DELETE", fmt.Sprintf("/api/v1/rbac/role/%s", roleID), nil, http.StatusNoContent); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Role %s removed\n", roleID)
+
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func loadRoleFile(filename string) (*rbactypes.Role, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read role file")
+	}
+
+	role := rbactypes.Role{}
+	if err := yaml.Unmarshal(content, &role); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal role file")
+	}
+
+	if role.ID == "" {
+		return nil, errors.New("role id is required")
+	}
+
+	return &role, nil
+}
@@ -30,6 +30,7 @@ func IdentityServiceCmd() *cobra.Command {
 	cmd.AddCommand(IdentityServiceUninstallCmd())
 	cmd.AddCommand(IdentityServiceEnableSharedPasswordCmd())
 	cmd.AddCommand(IdentityServiceOIDCCallbackURLCmd())
+	cmd.AddCommand(IdentityServiceRolesCmd())
 
 	return cmd
 }
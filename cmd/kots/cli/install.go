@@ -11,6 +11,7 @@ import (
 	"net/url"
 	"os"
 	"os/signal"
+	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -21,6 +22,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/replicatedhq/kots/pkg/auth"
 	"github.com/replicatedhq/kots/pkg/automation"
+	"github.com/replicatedhq/kots/pkg/crypto"
 	dockerregistry "github.com/replicatedhq/kots/pkg/docker/registry"
 	"github.com/replicatedhq/kots/pkg/handlers"
 	"github.com/replicatedhq/kots/pkg/identity"
@@ -37,6 +39,8 @@ import (
 	"github.com/replicatedhq/kots/pkg/replicatedapp"
 	"github.com/replicatedhq/kots/pkg/store/kotsstore"
 	storetypes "github.com/replicatedhq/kots/pkg/store/types"
+	"github.com/replicatedhq/kots/pkg/upload"
+	kotsupstream "github.com/replicatedhq/kots/pkg/upstream"
 	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
 	"github.com/replicatedhq/troubleshoot/pkg/preflight"
 	"github.com/spf13/cobra"
@@ -55,7 +59,7 @@ func InstallCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:           "install [upstream uri]",
 		Short:         "Install an application to a cluster",
-		Long:          `Pull Kubernetes manifests from the remote upstream, deploy them to the specified cluster, then setup port forwarding to make the kotsadm admin console accessible.`,
+		Long:          `Pull Kubernetes manifests from the remote upstream, deploy them to the specified cluster, then setup port forwarding to make the kotsadm admin console accessible. The upstream can be an app slug or a git repository (git+https://, with optional ref and path query parameters).`,
 		SilenceUsage:  true,
 		SilenceErrors: false,
 		PreRun: func(cmd *cobra.Command, args []string) {
@@ -160,6 +164,12 @@ func InstallCmd() *cobra.Command {
 			}()
 
 			upstream := pull.RewriteUpstream(args[0])
+			isReplicatedUpstream := strings.HasPrefix(upstream, "replicated://")
+			if !isReplicatedUpstream && v.GetString("license-file") == "" {
+				if err := kotsupstream.ValidateInClusterUpstream(upstream); err != nil {
+					return err
+				}
+			}
 
 			namespace := v.GetString("namespace")
 
@@ -181,7 +191,7 @@ func InstallCmd() *cobra.Command {
 				if err != nil {
 					return errors.Wrapf(err, "failed to get metadata from %s", airgapBundle)
 				}
-			} else if !v.GetBool("airgap") {
+			} else if !v.GetBool("airgap") && isReplicatedUpstream {
 				applicationMetadata, err = pull.PullApplicationMetadata(upstream, v.GetString("app-version-label"))
 				if err != nil {
 					log.Info("Unable to pull application metadata. This can be ignored, but custom branding will not be available in the Admin Console until a license is installed. This may also cause the Admin Console to run without minimal role-based-access-control (RBAC) privileges, which may be required by the application.")
@@ -246,14 +256,22 @@ func InstallCmd() *cobra.Command {
 				}
 			}
 
+			encryptionKeyProvider, encryptionKEK, err := getEncryptionKeyProvider(v)
+			if err != nil {
+				return errors.Wrap(err, "failed to get encryption key provider")
+			}
+
 			simultaneousUploads, _ := strconv.Atoi(v.GetString("airgap-upload-parallelism"))
 
+			// the upstream uri is stored in the application metadata, which must not contain git credentials
+			redactedUpstream, _ := kotsupstream.SplitGitCredentials(upstream)
+
 			deployOptions := kotsadmtypes.DeployOptions{
 				Namespace:              namespace,
 				Context:                v.GetString("context"),
 				SharedPassword:         sharedPassword,
 				ApplicationMetadata:    applicationMetadata.Manifest,
-				UpstreamURI:            upstream,
+				UpstreamURI:            redactedUpstream,
 				License:                license,
 				ConfigValues:           configValues,
 				Airgap:                 isAirgap,
@@ -280,6 +298,9 @@ func InstallCmd() *cobra.Command {
 
 				IdentityConfig: *identityConfig,
 				IngressConfig:  *ingressConfig,
+
+				EncryptionKeyProvider: encryptionKeyProvider,
+				EncryptionKEK:         encryptionKEK,
 			}
 
 			deployOptions.IsOpenShift = k8sutil.IsOpenShift(clientset)
@@ -437,6 +458,13 @@ func InstallCmd() *cobra.Command {
 				}
 			}()
 
+			// apps from git repositories and local directories are not licensed, so they are pulled here and uploaded
+			if !isReplicatedUpstream && deployOptions.License == nil {
+				if err := pullAndUploadApp(upstream, deployOptions, v, adminConsolePort); err != nil {
+					return errors.Wrap(err, "failed to install app")
+				}
+			}
+
 			if deployOptions.License != nil {
 				log.ActionWithSpinner("Waiting for installation to complete")
 				status, err := ValidateAutomatedInstall(deployOptions, authSlug, apiEndpoint)
@@ -564,6 +592,20 @@ func InstallCmd() *cobra.Command {
 	cmd.Flags().String("ingress-config", "", "path to a kots.Ingress resource file")
 	cmd.Flags().MarkHidden("ingress-config")
 
+	cmd.Flags().String("encryption-key-provider", "", "wrap the Admin Console encryption key with an external key provider (vault, aws-kms, or file)")
+	cmd.Flags().String("vault-address", "", "the address of the Vault server when --encryption-key-provider=vault")
+	cmd.Flags().String("vault-namespace", "", "the Vault enterprise namespace when --encryption-key-provider=vault")
+	cmd.Flags().String("vault-transit-mount", "transit", "the mount path of the Vault transit secrets engine when --encryption-key-provider=vault")
+	cmd.Flags().String("vault-transit-key", "", "the name of the Vault transit key when --encryption-key-provider=vault")
+	cmd.Flags().String("vault-auth-mount", "kubernetes", "the mount path of the Vault Kubernetes auth method that the Admin Console logs in with when --encryption-key-provider=vault")
+	cmd.Flags().String("vault-role", "", "the Vault Kubernetes auth role bound to the kotsadm service account when --encryption-key-provider=vault")
+	cmd.Flags().String("vault-token", os.Getenv("VAULT_TOKEN"), "the token used to wrap the encryption key during the install when --encryption-key-provider=vault, it is not stored in the cluster (defaults to VAULT_TOKEN)")
+	cmd.Flags().String("aws-kms-key-id", "", "the id, arn, or alias of the AWS KMS key when --encryption-key-provider=aws-kms. the Admin Console uses the default AWS credential chain, e.g. IAM roles for service accounts")
+	cmd.Flags().String("aws-kms-region", "", "the region of the AWS KMS key when --encryption-key-provider=aws-kms")
+	cmd.Flags().String("encryption-kek-file", "", "path to a local file containing the 32 byte key encryption key used to wrap the encryption key during the install when --encryption-key-provider=file, it is not stored in the cluster")
+	cmd.Flags().String("encryption-kek-secret-provider-class", "", "the SecretProviderClass of the Secrets Store CSI driver that mounts the key encryption key into the Admin Console when --encryption-key-provider=file")
+	cmd.Flags().String("encryption-kek-object-name", "key", "the file name of the key encryption key in the Secrets Store CSI volume when --encryption-key-provider=file")
+
 	// option to check if the user has cluster-wide previliges to install application
 	cmd.Flags().Bool("skip-rbac-check", false, "set to true to bypass rbac check")
 	return cmd
@@ -575,6 +617,11 @@ func promptForNamespace(upstreamURI string) (string, error) {
 		return "", errors.Wrap(err, "failed to parse uri")
 	}
 
+	defaultNamespace := u.Hostname()
+	if u.Scheme != "replicated" {
+		defaultNamespace = kotsupstream.AppNameFromURI(upstreamURI)
+	}
+
 	templates := &promptui.PromptTemplates{
 		Prompt:  "{{ . | bold }} ",
 		Valid:   "{{ . | green }} ",
@@ -585,7 +632,7 @@ func promptForNamespace(upstreamURI string) (string, error) {
 	prompt := promptui.Prompt{
 		Label:     "Enter the namespace to deploy to:",
 		Templates: templates,
-		Default:   u.Hostname(),
+		Default:   defaultNamespace,
 		Validate:  validateNamespace,
 		AllowEdit: true,
 	}
@@ -603,6 +650,52 @@ func promptForNamespace(upstreamURI string) (string, error) {
 	}
 }
 
+// pullAndUploadApp pulls an app from a git repository or a local directory and creates it in the admin console
+func pullAndUploadApp(upstreamURI string, deployOptions kotsadmtypes.DeployOptions, v *viper.Viper, adminConsolePort int) error {
+	rootDir, err := os.MkdirTemp("", "kots-install")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temp dir")
+	}
+	defer os.RemoveAll(rootDir)
+
+	appName := v.GetString("name")
+	if appName == "" {
+		appName = kotsupstream.AppNameFromURI(upstreamURI)
+	}
+
+	pullOptions := pull.PullOptions{
+		Downstreams:            []string{"this-cluster"},
+		Namespace:              deployOptions.Namespace,
+		ExcludeKotsKinds:       true,
+		RootDir:                rootDir,
+		ExcludeAdminConsole:    true,
+		CreateAppDir:           false,
+		ConfigFile:             ExpandDir(v.GetString("config-values")),
+		AppSlug:                appName,
+		SkipHelmChartCheck:     true,
+		SkipCompatibilityCheck: v.GetBool("skip-compatibility-check"),
+	}
+	if _, err := pull.Pull(upstreamURI, pullOptions); err != nil {
+		if errors.Cause(err) != pull.ErrConfigNeeded {
+			return errors.Wrap(err, "failed to pull app")
+		}
+	}
+
+	uploadOptions := upload.UploadOptions{
+		Namespace:      deployOptions.Namespace,
+		UpstreamURI:    upstreamURI,
+		NewAppName:     appName,
+		Endpoint:       fmt.Sprintf("http://localhost:%d", adminConsolePort),
+		Deploy:         true,
+		SkipPreflights: deployOptions.SkipPreflights,
+	}
+	if _, err := upload.Upload(rootDir, uploadOptions); err != nil {
+		return errors.Wrap(err, "failed to upload app")
+	}
+
+	return nil
+}
+
 func uploadBrandingArchive(authSlug string, apiEndpoint string, data []byte) (bool, error) {
 	body := &bytes.Buffer{}
 	bodyWriter := multipart.NewWriter(body)
@@ -722,6 +815,60 @@ func getIngressConfig(v *viper.Viper) (*kotsv1beta1.IngressConfig, error) {
 	return &ingressConfig, nil
 }
 
+func getEncryptionKeyProvider(v *viper.Viper) (*crypto.KeyProviderConfig, []byte, error) {
+	providerType := v.GetString("encryption-key-provider")
+	if providerType == "" {
+		return nil, nil, nil
+	}
+
+	config := crypto.KeyProviderConfig{
+		Type:              providerType,
+		VaultAddress:      v.GetString("vault-address"),
+		VaultNamespace:    v.GetString("vault-namespace"),
+		VaultTransitMount: v.GetString("vault-transit-mount"),
+		VaultTransitKey:   v.GetString("vault-transit-key"),
+		VaultAuthMount:    v.GetString("vault-auth-mount"),
+		VaultRole:         v.GetString("vault-role"),
+		VaultToken:        v.GetString("vault-token"),
+		AWSKMSKeyID:       v.GetString("aws-kms-key-id"),
+		AWSRegion:         v.GetString("aws-kms-region"),
+	}
+
+	// the key encryption key is read from a local file to wrap the encryption key during the install,
+	// the admin console reads it from the secrets store csi volume that the user provides
+	var kek []byte
+	if providerType == crypto.KeyProviderFile {
+		kekFile := v.GetString("encryption-kek-file")
+		if kekFile == "" {
+			return nil, nil, errors.New("--encryption-kek-file is required")
+		}
+		objectName := v.GetString("encryption-kek-object-name")
+		if objectName == "" || strings.Contains(objectName, "/") {
+			return nil, nil, errors.Errorf("invalid --encryption-kek-object-name %q", objectName)
+		}
+		config.KeyFile = path.Join(kotsadmtypes.EncryptionKEKMountPath, objectName)
+		config.KeyFileSecretProviderClass = v.GetString("encryption-kek-secret-provider-class")
+
+		b, err := os.ReadFile(kekFile)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to read key encryption key file")
+		}
+		if _, err := crypto.NewFileKeyProvider(b); err != nil {
+			return nil, nil, errors.Wrap(err, "invalid key encryption key")
+		}
+		kek = b
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, nil, err
+	}
+	if config.Type == crypto.KeyProviderVault && config.VaultToken == "" {
+		return nil, nil, errors.New("--vault-token is required to wrap the encryption key during the install")
+	}
+
+	return &config, kek, nil
+}
+
 func getIdentityConfig(v *viper.Viper) (*kotsv1beta1.IdentityConfig, error) {
 	identityConfigPath := v.GetString("identity-config")
 	enableIdentityService := v.GetBool("enable-identity-service") || identityConfigPath != ""
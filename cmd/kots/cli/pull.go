@@ -11,6 +11,7 @@ import (
 	"github.com/replicatedhq/kots/pkg/logger"
 	"github.com/replicatedhq/kots/pkg/pull"
 	registrytypes "github.com/replicatedhq/kots/pkg/registry/types"
+	"github.com/replicatedhq/kots/pkg/upstream"
 	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
 	"github.com/replicatedhq/kotskinds/client/kotsclientset/scheme"
 	"github.com/spf13/cobra"
@@ -21,7 +22,7 @@ func PullCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:           "pull [upstream uri]",
 		Short:         "Pull Kubernetes manifests from remote upstream to the local filesystem",
-		Long:          `Pull Kubernetes manifests from the remote upstream and save them to the local filesystem, so they can be edited before deploying them to a cluster.`,
+		Long:          `Pull Kubernetes manifests from the remote upstream and save them to the local filesystem, so they can be edited before deploying them to a cluster. The upstream can be an app slug, a git repository (git+https:// or git+ssh://, with optional ref and path query parameters) or a local directory.`,
 		SilenceUsage:  true,
 		SilenceErrors: false,
 		PreRun: func(cmd *cobra.Command, args []string) {
@@ -147,6 +148,9 @@ func PullCmd() *cobra.Command {
 
 func getAppSlugForPull(uri string, licenseFile string) (string, error) {
 	appSlug := strings.Split(uri, "/")[0]
+	if upstreamURI := pull.RewriteUpstream(uri); !strings.HasPrefix(upstreamURI, "replicated://") {
+		appSlug = upstream.AppNameFromURI(upstreamURI)
+	}
 	if licenseFile == "" {
 		return appSlug, nil
 	}
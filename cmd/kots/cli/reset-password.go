@@ -45,14 +45,46 @@ func ResetPasswordCmd() *cobra.Command {
 				os.Exit(1)
 			}
 
-			log.ActionWithoutSpinner("Reset the admin console password for %s", namespace)
-			newPassword, err := util.PromptForNewPassword()
+			clientset, err := k8sutil.GetClientset()
 			if err != nil {
-				os.Exit(1)
+				return errors.Wrap(err, "failed to create k8s client")
+			}
+
+			if v.GetBool("unlock") {
+				log.ActionWithoutSpinner("Unlock the admin console for %s", namespace)
+				if err := password.Unlock(clientset, namespace); err != nil {
+					return errors.Wrap(err, "failed to unlock admin console")
+				}
+
+				log.ActionWithoutSpinner("The admin console has been unlocked")
+				return nil
+			}
+
+			passwordPolicy, err := password.GetPasswordPolicy(clientset, namespace)
+			if err != nil {
+				return errors.Wrap(err, "failed to get password policy")
 			}
 
-			if err := setKotsadmPassword(newPassword, namespace); err != nil {
-				return errors.Wrap(err, "failed to set new password")
+			log.ActionWithoutSpinner("Reset the admin console password for %s", namespace)
+			for {
+				newPassword, err := util.PromptForNewPassword()
+				if err != nil {
+					os.Exit(1)
+				}
+
+				if err := passwordPolicy.Validate(newPassword); err != nil {
+					log.Error(err)
+					continue
+				}
+
+				if err := password.ChangePassword(clientset, namespace, newPassword); err != nil {
+					if errors.Is(err, password.ErrPasswordReused) {
+						log.Error(err)
+						continue
+					}
+					return errors.Wrap(err, "failed to set new password")
+				}
+				break
 			}
 
 			log.ActionWithoutSpinner("The admin console password has been reset")
@@ -60,6 +92,8 @@ func ResetPasswordCmd() *cobra.Command {
 		},
 	}
 
+	cmd.Flags().Bool("unlock", false, "unlock the admin console after too many failed login attempts without changing the password")
+
 	return cmd
 }
 
@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/auth"
+	"github.com/replicatedhq/kots/pkg/handlers"
+	"github.com/replicatedhq/kots/pkg/k8sutil"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func RollbackCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rollback [appSlug]",
+		Short: "Roll back an application to the previously deployed version",
+		Long: `Deploy the version of an application that was deployed before the currently deployed version.
+The application must support rollback. Automatic deployments are disabled when rolling back to a past version.`,
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+
+			if len(args) != 1 {
+				cmd.Help()
+				os.Exit(1)
+			}
+			appSlug := args[0]
+
+			log := logger.NewCLILogger(cmd.OutOrStdout())
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			clientset, err := k8sutil.GetClientset()
+			if err != nil {
+				return errors.Wrap(err, "failed to get clientset")
+			}
+
+			namespace, err := getNamespaceOrDefault(v.GetString("namespace"))
+			if err != nil {
+				return errors.Wrap(err, "failed to get namespace")
+			}
+
+			getPodName := func() (string, error) {
+				return k8sutil.FindKotsadm(clientset, namespace)
+			}
+
+			localPort, errChan, err := k8sutil.PortForward(0, 3000, namespace, getPodName, false, stopCh, log)
+			if err != nil {
+				log.FinishSpinnerWithError()
+				return errors.Wrap(err, "failed to start port forwarding")
+			}
+
+			go func() {
+				select {
+				case err := <-errChan:
+					if err != nil {
+						log.Error(err)
+					}
+				case <-stopCh:
+				}
+			}()
+
+			authSlug, err := auth.GetOrCreateAuthSlug(clientset, namespace)
+			if err != nil {
+				log.FinishSpinnerWithError()
+				log.Info("Unable to authenticate to the Admin Console running in the %s namespace. Ensure you have read access to secrets in this namespace and try again.", namespace)
+				if v.GetBool("debug") {
+					return errors.Wrap(err, "failed to get kotsadm auth slug")
+				}
+				os.Exit(2) // not returning error here as we don't want to show the entire stack trace to normal users
+			}
+
+			log.ActionWithSpinner("Rolling back")
+			url := fmt.Sprintf("http://localhost:%d/api/v1/app/%s/rollback", localPort, url.PathEscape(appSlug))
+			response, err := rollbackAppVersion(url, authSlug)
+			if err != nil {
+				log.FinishSpinnerWithError()
+				return err
+			}
+			log.FinishSpinner()
+			log.ActionWithoutSpinner("Deploying sequence %d (version %s)", response.Sequence, response.VersionLabel)
+
+			if v.GetBool("wait") {
+				if err := waitForAppVersionReady(log, localPort, appSlug, authSlug, response.Sequence, v.GetDuration("timeout")); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("wait", false, "wait for the version to be deployed and for the application to be ready. requires the application to have status informers")
+	cmd.Flags().Duration("timeout", 10*time.Minute, "how long to wait for the application to be ready (requires --wait)")
+
+	return cmd
+}
+
+func rollbackAppVersion(url string, authSlug string) (*handlers.RollbackAppVersionResponse, error) {
+	newReq, err := http.NewRequest("POST", url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+	newReq.Header.Add("Content-Type", "application/json")
+	newReq.Header.Add("Authorization", authSlug)
+
+	resp, err := http.DefaultClient.Do(newReq)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to execute request")
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read")
+	}
+
+	response := handlers.RollbackAppVersionResponse{}
+	if err := json.Unmarshal(b, &response); err != nil {
+		return nil, errors.Errorf("unexpected status code %d: %s", resp.StatusCode, string(b))
+	}
+
+	if resp.StatusCode != http.StatusOK || !response.Success {
+		return nil, errors.Errorf("failed to roll back: %s", response.Error)
+	}
+
+	return &response, nil
+}
@@ -49,7 +49,12 @@ func RootCmd() *cobra.Command {
 	cmd.AddCommand(RestoreCmd())
 	cmd.AddCommand(IngressCmd())
 	cmd.AddCommand(IdentityServiceCmd())
+	cmd.AddCommand(TokenCmd())
+	cmd.AddCommand(UserCmd())
 	cmd.AddCommand(AppStatusCmd())
+	cmd.AddCommand(DiffCmd())
+	cmd.AddCommand(DeployCmd())
+	cmd.AddCommand(RollbackCmd())
 	cmd.AddCommand(GetCmd())
 	cmd.AddCommand(SetCmd())
 	cmd.AddCommand(CompletionCmd())
@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/auth"
+	"github.com/replicatedhq/kots/pkg/handlers"
+	"github.com/replicatedhq/kots/pkg/k8sutil"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func RotateEncryptionKeyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rotate-encryption-key [namespace]",
+		Short: "Rotate the encryption key of the admin console",
+		Long: `Generates a new encryption key for the admin console and re-encrypts config values, registry passwords,
+notification settings, GitOps keys and identity secrets with it. The previous key is kept for decryption only.`,
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+
+			log := logger.NewCLILogger(cmd.OutOrStdout())
+
+			// use namespace-as-arg if provided, else use namespace from -n/--namespace
+			namespace, err := getNamespaceOrDefault(v.GetString("namespace"))
+			if err != nil {
+				return errors.Wrap(err, "failed to get namespace")
+			}
+			if len(args) == 1 {
+				namespace = args[0]
+			} else if len(args) > 1 {
+				fmt.Printf("more than one argument supplied: %+v\n", args)
+				os.Exit(1)
+			}
+
+			if err := validateNamespace(namespace); err != nil {
+				return errors.Wrap(err, "failed to validate namespace")
+			}
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			clientset, err := k8sutil.GetClientset()
+			if err != nil {
+				return errors.Wrap(err, "failed to get clientset")
+			}
+
+			getPodName := func() (string, error) {
+				return k8sutil.FindKotsadm(clientset, namespace)
+			}
+
+			localPort, errChan, err := k8sutil.PortForward(0, 3000, namespace, getPodName, false, stopCh, log)
+			if err != nil {
+				return errors.Wrap(err, "failed to start port forwarding")
+			}
+
+			go func() {
+				select {
+				case err := <-errChan:
+					if err != nil {
+						log.Error(err)
+					}
+				case <-stopCh:
+				}
+			}()
+
+			authSlug, err := auth.GetOrCreateAuthSlug(clientset, namespace)
+			if err != nil {
+				log.Info("Unable to authenticate to the Admin Console running in the %s namespace. Ensure you have read access to secrets in this namespace and try again.", namespace)
+				if v.GetBool("debug") {
+					return errors.Wrap(err, "failed to get kotsadm auth slug")
+				}
+				os.Exit(2) // not returning error here as we don't want to show the entire stack trace to normal users
+			}
+
+			log.ActionWithSpinner("Rotating encryption key")
+
+			url := fmt.Sprintf("http://localhost:%d/api/v1/kotsadm/encryption-key/rotate", localPort)
+			response, err := rotateEncryptionKey(url, authSlug)
+			if err != nil {
+				log.FinishSpinnerWithError()
+				return err
+			}
+
+			log.FinishSpinner()
+
+			result := response.Result
+			log.ActionWithoutSpinner("Encryption key rotated, the new key id is %s", result.KeyID)
+			log.Info("Re-encrypted %d app version(s), %d registry password(s), %d notification sink(s) and %d GitOps secret value(s)",
+				result.AppVersions, result.RegistryPasswords, result.NotificationSinks, result.GitOpsSecrets)
+
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func rotateEncryptionKey(url string, authSlug string) (*handlers.RotateEncryptionKeyResponse, error) {
+	newReq, err := http.NewRequest("POST", url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+	newReq.Header.Add("Content-Type", "application/json")
+	newReq.Header.Add("Authorization", authSlug)
+
+	resp, err := http.DefaultClient.Do(newReq)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to rotate encryption key")
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read")
+	}
+
+	response := handlers.RotateEncryptionKeyResponse{}
+	if err = json.Unmarshal(b, &response); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal server response: %s", b)
+	}
+
+	if response.Error != "" {
+		return nil, errors.New(response.Error)
+	}
+
+	if resp.StatusCode != http.StatusOK || response.Result == nil {
+		return nil, errors.Errorf("unexpected response from server %v: %s", resp.StatusCode, b)
+	}
+
+	return &response, nil
+}
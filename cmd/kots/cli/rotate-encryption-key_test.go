@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/replicatedhq/kots/pkg/encryptionkey"
+	"github.com/replicatedhq/kots/pkg/handlers"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_rotateEncryptionKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		response   handlers.RotateEncryptionKeyResponse
+		wantErr    string
+	}{
+		{
+			name:       "success",
+			statusCode: http.StatusOK,
+			response: handlers.RotateEncryptionKeyResponse{
+				Success: true,
+				Result: &encryptionkey.RotateResult{
+					KeyID:       "0123456789abcdef",
+					AppVersions: 3,
+				},
+			},
+		},
+		{
+			name:       "server error",
+			statusCode: http.StatusInternalServerError,
+			response: handlers.RotateEncryptionKeyResponse{
+				Error: "failed to rotate encryption key",
+			},
+			wantErr: "failed to rotate encryption key",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := require.New(t)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				req.Equal("POST", r.Method)
+				req.Equal("/api/v1/kotsadm/encryption-key/rotate", r.URL.Path)
+				req.Equal("auth-slug", r.Header.Get("Authorization"))
+
+				w.WriteHeader(tt.statusCode)
+				json.NewEncoder(w).Encode(tt.response)
+			}))
+			defer server.Close()
+
+			response, err := rotateEncryptionKey(server.URL+"/api/v1/kotsadm/encryption-key/rotate", "auth-slug")
+			if tt.wantErr != "" {
+				req.EqualError(err, tt.wantErr)
+				return
+			}
+			req.NoError(err)
+			req.Equal(tt.response.Result, response.Result)
+		})
+	}
+}
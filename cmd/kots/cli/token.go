@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/api/handlers/types"
+	"github.com/replicatedhq/kots/pkg/print"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func TokenCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "token",
+		Short: "Manage personal API tokens",
+		Long: `Manage personal API tokens that automation can use to call the Admin Console API.
+
+Tokens carry RBAC roles and expire. Pass them in the authorization header, for example:
+
+  curl -H "Authorization: Token kots_..." https://admin-console.example.com/api/v1/apps`,
+	}
+
+	cmd.AddCommand(TokenListCmd())
+	cmd.AddCommand(TokenCreateCmd())
+	cmd.AddCommand(TokenRemoveCmd())
+
+	return cmd
+}
+
+func TokenListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "ls",
+		Aliases:       []string{"list"},
+		Short:         "List API tokens",
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+
+			b, err := adminConsoleRequest(cmd, "GET", "/api/v1/tokens", nil, http.StatusOK)
+			if err != nil {
+				return err
+			}
+
+			response := types.ListAPITokensResponse{}
+			if err := json.Unmarshal(b, &response); err != nil {
+				return errors.Wrap(err, "failed to unmarshal tokens")
+			}
+
+			print.APITokens(response.Tokens, v.GetString("output"))
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("output", "o", "", "output format. supported values: json")
+
+	return cmd
+}
+
+func TokenCreateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "create",
+		Short:         "Create an API token",
+		Long:          "Create an API token. The token is only printed once and cannot be retrieved later.",
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+
+			request := types.CreateAPITokenRequest{
+				Name:      v.GetString("name"),
+				Roles:     v.GetStringSlice("role"),
+				ExpiresIn: v.GetString("expires-in"),
+			}
+
+			body, err := json.Marshal(request)
+			if err != nil {
+				return errors.Wrap(err, "failed to marshal request")
+			}
+
+			b, err := adminConsoleRequest(cmd, "POST", "/api/v1/tokens", body, http.StatusCreated)
+			if err != nil {
+				return err
+			}
+
+			response := types.CreateAPITokenResponse{}
+			if err := json.Unmarshal(b, &response); err != nil {
+				return errors.Wrap(err, "failed to unmarshal token")
+			}
+
+			if v.GetString("output") == "json" {
+				str, _ := json.MarshalIndent(response, "", "    ")
+				fmt.Fprintln(cmd.OutOrStdout(), string(str))
+				return nil
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Token %s created, it expires at %s\n", response.APIToken.ID, response.APIToken.ExpiresAt.Format("2006-01-02 15:04:05 MST"))
+			fmt.Fprintf(cmd.OutOrStdout(), "Store it now, it will not be shown again:\n\n%s\n", response.Token)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().String("name", "", "name of the token, e.g. the pipeline that uses it")
+	cmd.Flags().StringSlice("role", []string{}, "id of a role to grant to the token, can be specified multiple times (defaults to the roles of the caller)")
+	cmd.Flags().String("expires-in", "", "lifetime of the token, e.g. 720h (defaults to 30 days)")
+	cmd.Flags().StringP("output", "o", "", "output format. supported values: json")
+	cmd.MarkFlagRequired("name")
+
+	return cmd
+}
+
+func TokenRemoveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "rm [id]",
+		Aliases:       []string{"revoke"},
+		Short:         "Revoke an API token",
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Args:          cobra.ExactArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tokenID := args[0]
+
+			if _, err := adminConsoleRequest(cmd, "DELETE", fmt.Sprintf("/api/v1/token/%s", tokenID), nil, http.StatusNoContent); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Token %s revoked\n", tokenID)
+
+			return nil
+		},
+	}
+
+	return cmd
+}
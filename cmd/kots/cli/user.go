@@ -0,0 +1,284 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/api/handlers/types"
+	"github.com/replicatedhq/kots/pkg/print"
+	"github.com/replicatedhq/kots/pkg/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func UserCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "user",
+		Short: "Manage local Admin Console users",
+		Long: `Manage local Admin Console users.
+
+Local users log in with their own username and password instead of the shared password, and carry RBAC roles.
+Their username is recorded in the audit log.`,
+	}
+
+	cmd.AddCommand(UserListCmd())
+	cmd.AddCommand(UserCreateCmd())
+	cmd.AddCommand(UserUpdateCmd())
+	cmd.AddCommand(UserDisableCmd())
+	cmd.AddCommand(UserEnableCmd())
+	cmd.AddCommand(UserSetPasswordCmd())
+	cmd.AddCommand(UserRemoveCmd())
+
+	return cmd
+}
+
+func UserListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "ls",
+		Aliases:       []string{"list"},
+		Short:         "List local users",
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+
+			b, err := adminConsoleRequest(cmd, "GET", "/api/v1/users", nil, http.StatusOK)
+			if err != nil {
+				return err
+			}
+
+			response := types.ListLocalUsersResponse{}
+			if err := json.Unmarshal(b, &response); err != nil {
+				return errors.Wrap(err, "failed to unmarshal users")
+			}
+
+			print.LocalUsers(response.Users, v.GetString("output"))
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("output", "o", "", "output format. supported values: json")
+
+	return cmd
+}
+
+func UserCreateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "create [username]",
+		Short:         "Create a local user",
+		Long:          "Create a local user. The password is prompted for unless --password-stdin is set.",
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Args:          cobra.ExactArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+
+			password, err := readUserPassword(v.GetBool("password-stdin"))
+			if err != nil {
+				return err
+			}
+
+			request := types.CreateLocalUserRequest{
+				Username: args[0],
+				Password: password,
+				Roles:    v.GetStringSlice("role"),
+			}
+
+			body, err := json.Marshal(request)
+			if err != nil {
+				return errors.Wrap(err, "failed to marshal request")
+			}
+
+			if _, err := adminConsoleRequest(cmd, "POST", "/api/v1/users", body, http.StatusCreated); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "User %s created\n", args[0])
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSlice("role", []string{}, "id of a role to grant to the user, can be specified multiple times")
+	cmd.Flags().Bool("password-stdin", false, "read the password from stdin")
+	cmd.MarkFlagRequired("role")
+
+	return cmd
+}
+
+func UserUpdateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "update [username]",
+		Short:         "Update the roles of a local user",
+		Long:          "Update the roles of a local user. The sessions of the user are revoked so that the new roles apply at the next login.",
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Args:          cobra.ExactArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+
+			request := types.UpdateLocalUserRequest{
+				Roles: v.GetStringSlice("role"),
+			}
+			if err := updateLocalUser(cmd, args[0], request); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "User %s updated\n", args[0])
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSlice("role", []string{}, "id of a role to grant to the user, can be specified multiple times. replaces the current roles of the user")
+	cmd.MarkFlagRequired("role")
+
+	return cmd
+}
+
+func UserDisableCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "disable [username]",
+		Short:         "Disable a local user and revoke its sessions and API tokens",
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Args:          cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			isDisabled := true
+			if err := updateLocalUser(cmd, args[0], types.UpdateLocalUserRequest{IsDisabled: &isDisabled}); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "User %s disabled\n", args[0])
+
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func UserEnableCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "enable [username]",
+		Short:         "Enable a disabled local user",
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Args:          cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			isDisabled := false
+			if err := updateLocalUser(cmd, args[0], types.UpdateLocalUserRequest{IsDisabled: &isDisabled}); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "User %s enabled\n", args[0])
+
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func UserSetPasswordCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "set-password [username]",
+		Short:         "Set the password of a local user",
+		Long:          "Set the password of a local user. This also unlocks the user after too many failed logins and revokes its sessions.",
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Args:          cobra.ExactArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v := viper.GetViper()
+
+			password, err := readUserPassword(v.GetBool("password-stdin"))
+			if err != nil {
+				return err
+			}
+
+			body, err := json.Marshal(types.SetLocalUserPasswordRequest{Password: password})
+			if err != nil {
+				return errors.Wrap(err, "failed to marshal request")
+			}
+
+			if _, err := adminConsoleRequest(cmd, "PUT", fmt.Sprintf("/api/v1/user/%s/password", args[0]), body, http.StatusNoContent); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Password of user %s set\n", args[0])
+
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("password-stdin", false, "read the password from stdin")
+
+	return cmd
+}
+
+func UserRemoveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "rm [username]",
+		Aliases:       []string{"delete"},
+		Short:         "Delete a local user and revoke its sessions and API tokens",
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Args:          cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := adminConsoleRequest(cmd, "DELETE", fmt.Sprintf("/api/v1/user/%s", args[0]), nil, http.StatusNoContent); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "User %s deleted\n", args[0])
+
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func updateLocalUser(cmd *cobra.Command, username string, request types.UpdateLocalUserRequest) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal request")
+	}
+
+	if _, err := adminConsoleRequest(cmd, "PUT", fmt.Sprintf("/api/v1/user/%s", username), body, http.StatusOK); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// readUserPassword reads the first line of stdin, or prompts for the password
+func readUserPassword(fromStdin bool) (string, error) {
+	if !fromStdin {
+		return util.PromptForNewPassword()
+	}
+
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && password == "" {
+		return "", errors.Wrap(err, "failed to read password from stdin")
+	}
+
+	return strings.TrimRight(password, "\r\n"), nil
+}
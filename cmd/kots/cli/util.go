@@ -1,13 +1,24 @@
 package cli
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
 	"net/url"
+	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/auth"
+	"github.com/replicatedhq/kots/pkg/k8sutil"
+	"github.com/replicatedhq/kots/pkg/logger"
 	"github.com/replicatedhq/kots/pkg/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 func ExpandDir(input string) string {
@@ -40,3 +51,88 @@ func getHostnameFromEndpoint(endpoint string) (string, error) {
 
 	return parsed.Hostname(), nil
 }
+
+// adminConsoleRequest sends a request to the admin console api and returns the response body if the status code matches
+func adminConsoleRequest(cmd *cobra.Command, method string, path string, body []byte, expectStatus int) ([]byte, error) {
+	v := viper.GetViper()
+
+	log := logger.NewCLILogger(cmd.OutOrStdout())
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	clientset, err := k8sutil.GetClientset()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get clientset")
+	}
+
+	namespace, err := getNamespaceOrDefault(v.GetString("namespace"))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get namespace")
+	}
+
+	getPodName := func() (string, error) {
+		return k8sutil.FindKotsadm(clientset, namespace)
+	}
+
+	localPort, errChan, err := k8sutil.PortForward(0, 3000, namespace, getPodName, false, stopCh, log)
+	if err != nil {
+		log.FinishSpinnerWithError()
+		return nil, errors.Wrap(err, "failed to start port forwarding")
+	}
+
+	go func() {
+		select {
+		case err := <-errChan:
+			if err != nil {
+				log.Error(err)
+			}
+		case <-stopCh:
+		}
+	}()
+
+	authSlug, err := auth.GetOrCreateAuthSlug(clientset, namespace)
+	if err != nil {
+		log.FinishSpinnerWithError()
+		log.Info("Unable to authenticate to the Admin Console running in the %s namespace. Ensure you have read access to secrets in this namespace and try again.", namespace)
+		if v.GetBool("debug") {
+			return nil, errors.Wrap(err, "failed to get kotsadm auth slug")
+		}
+		os.Exit(2) // not returning error here as we don't want to show the entire stack trace to normal users
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+
+	newReq, err := http.NewRequest(method, fmt.Sprintf("http://localhost:%d%s", localPort, path), reqBody)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+	newReq.Header.Add("Content-Type", "application/json")
+	newReq.Header.Add("Authorization", authSlug)
+
+	resp, err := http.DefaultClient.Do(newReq)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to execute request")
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read")
+	}
+
+	if resp.StatusCode != expectStatus {
+		response := struct {
+			Error string `json:"error"`
+		}{}
+		if err := json.Unmarshal(b, &response); err == nil && response.Error != "" {
+			return nil, errors.New(response.Error)
+		}
+		return nil, errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return b, nil
+}
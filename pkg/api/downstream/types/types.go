@@ -36,6 +36,9 @@ type DownstreamVersion struct {
 	Source             string                             `json:"source"`
 	PreflightSkipped   bool                               `json:"preflightSkipped"`
 	CommitURL          string                             `json:"commitUrl,omitempty"`
+	PullRequestURL     string                             `json:"pullRequestUrl,omitempty"`
+	PullRequestNumber  int64                              `json:"pullRequestNumber,omitempty"`
+	PullRequestState   string                             `json:"pullRequestState,omitempty"`
 	GitDeployable      bool                               `json:"gitDeployable,omitempty"`
 	UpstreamReleasedAt *time.Time                         `json:"upstreamReleasedAt,omitempty"`
 
@@ -159,3 +162,13 @@ type DownstreamOutput struct {
 	HelmStderr   string `json:"helmStderr"`
 	RenderError  string `json:"renderError"`
 }
+
+// DownstreamPullRequest is a gitops pull request opened for a downstream version
+type DownstreamPullRequest struct {
+	AppID     string `json:"appId"`
+	ClusterID string `json:"clusterId"`
+	Sequence  int64  `json:"sequence"`
+	Number    int64  `json:"number"`
+	URL       string `json:"url"`
+	State     string `json:"state"`
+}
@@ -5,8 +5,15 @@ import (
 
 	downstreamtypes "github.com/replicatedhq/kots/pkg/api/downstream/types"
 	versiontypes "github.com/replicatedhq/kots/pkg/api/version/types"
+	apitokentypes "github.com/replicatedhq/kots/pkg/apitoken/types"
 	apptypes "github.com/replicatedhq/kots/pkg/app/types"
+	"github.com/replicatedhq/kots/pkg/apparchive"
 	appstatetypes "github.com/replicatedhq/kots/pkg/appstate/types"
+	audittypes "github.com/replicatedhq/kots/pkg/audit/types"
+	drifttypes "github.com/replicatedhq/kots/pkg/drift/types"
+	operatortypes "github.com/replicatedhq/kots/pkg/operator/types"
+	rbactypes "github.com/replicatedhq/kots/pkg/rbac/types"
+	usertypes "github.com/replicatedhq/kots/pkg/user/types"
 )
 
 type ListAppsResponse struct {
@@ -21,6 +28,93 @@ type AppStatusResponse struct {
 	AppStatus *appstatetypes.AppStatus `json:"appstatus"`
 }
 
+type AppStatusHistoryResponse struct {
+	Transitions []appstatetypes.ResourceStateTransition `json:"transitions"`
+}
+
+type AppDriftResponse struct {
+	// Report is nil if drift has not been checked since the current version was deployed
+	Report *drifttypes.DriftReport `json:"report"`
+}
+
+type AppVersionsDiffResponse struct {
+	FromSequence int64 `json:"fromSequence"`
+	ToSequence   int64 `json:"toSequence"`
+	apparchive.VersionsDiff
+}
+
+type AppVersionDeployPlanResponse struct {
+	Plan *operatortypes.DeployPlan `json:"plan"`
+}
+
+type RBACRole struct {
+	rbactypes.Role
+	IsDefault bool `json:"isDefault"`
+}
+
+type ListRBACRolesResponse struct {
+	Roles []RBACRole `json:"roles"`
+	Error string     `json:"error,omitempty"`
+}
+
+type RBACRoleResponse struct {
+	Role  *rbactypes.Role `json:"role,omitempty"`
+	Error string          `json:"error,omitempty"`
+}
+
+type ListAPITokensResponse struct {
+	Tokens []apitokentypes.APIToken `json:"tokens"`
+	Error  string                   `json:"error,omitempty"`
+}
+
+type CreateAPITokenRequest struct {
+	Name string `json:"name"`
+	// Roles defaults to the roles of the session creating the token
+	Roles []string `json:"roles,omitempty"`
+	// ExpiresIn is a duration such as "720h", defaults to 30 days
+	ExpiresIn string `json:"expiresIn,omitempty"`
+}
+
+type CreateAPITokenResponse struct {
+	// Token is only returned when the token is created
+	Token    string                  `json:"token,omitempty"`
+	APIToken *apitokentypes.APIToken `json:"apiToken,omitempty"`
+	Error    string                  `json:"error,omitempty"`
+}
+
+type ListLocalUsersResponse struct {
+	Users []usertypes.LocalUser `json:"users"`
+	Error string                `json:"error,omitempty"`
+}
+
+type CreateLocalUserRequest struct {
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	Roles    []string `json:"roles"`
+}
+
+type UpdateLocalUserRequest struct {
+	// Roles are left unchanged when not set
+	Roles []string `json:"roles,omitempty"`
+	// IsDisabled is left unchanged when not set
+	IsDisabled *bool `json:"isDisabled,omitempty"`
+}
+
+type SetLocalUserPasswordRequest struct {
+	Password string `json:"password"`
+}
+
+type LocalUserResponse struct {
+	User  *usertypes.LocalUser `json:"user,omitempty"`
+	Error string               `json:"error,omitempty"`
+}
+
+type ListAuditEventsResponse struct {
+	Events     []audittypes.Event `json:"events"`
+	TotalCount int64              `json:"totalCount"`
+	Error      string             `json:"error,omitempty"`
+}
+
 type ResponseApp struct {
 	ID                string              `json:"id"`
 	Slug              string              `json:"slug"`
@@ -84,6 +178,7 @@ type ResponseGitOps struct {
 	Branch      string `json:"branch"`
 	Format      string `json:"format"`
 	Action      string `json:"action"`
+	Controller  string `json:"controller,omitempty"`
 	DeployKey   string `json:"deployKey"`
 	IsConnected bool   `json:"isConnected"`
 }
@@ -1,15 +1,20 @@
 package apiserver
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 
 	"github.com/pkg/errors"
 	"github.com/replicatedhq/kots/pkg/crypto"
+	"github.com/replicatedhq/kots/pkg/encryptionkey"
+	"github.com/replicatedhq/kots/pkg/k8sutil"
 	"github.com/replicatedhq/kots/pkg/kotsutil"
 	"github.com/replicatedhq/kots/pkg/store"
 	"github.com/replicatedhq/kots/pkg/util"
+	kuberneteserrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type BootstrapParams struct {
@@ -56,6 +61,21 @@ func bootstrapClusterToken(autoCreateClusterToken string) error {
 }
 
 func loadEncryptionKeys() error {
+	resourceVersion, err := loadSecretEncryptionKeys()
+	if err != nil {
+		return errors.Wrap(err, "failed to load encryption keys from secret")
+	}
+
+	// the key can be rotated by another replica, the keys are reloaded when the secret changes or data was encrypted with an unknown key
+	clientset, err := k8sutil.GetClientset()
+	if err != nil {
+		return errors.Wrap(err, "failed to get clientset")
+	}
+	crypto.SetKeyReloader(func() error {
+		return encryptionkey.Reload(clientset, util.PodNamespace)
+	})
+	go encryptionkey.WatchSecret(context.Background(), clientset, util.PodNamespace, resourceVersion)
+
 	apps, err := store.GetStore().ListInstalledApps()
 	if err != nil {
 		return errors.Wrap(err, "failed to list apps")
@@ -92,3 +112,46 @@ func loadEncryptionKeys() error {
 
 	return nil
 }
+
+// loadSecretEncryptionKeys loads the keys in the encryption secret. The current key is only set to be used for encryption
+// when it was not provided in the environment, which is the case when it is wrapped by a key provider.
+// Keys that were used before the encryption key was rotated are added to the list of decryption ciphers.
+// The resource version of the secret is returned, or an empty string if it doesn't exist.
+func loadSecretEncryptionKeys() (string, error) {
+	clientset, err := k8sutil.GetClientset()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get clientset")
+	}
+
+	secret, err := clientset.CoreV1().Secrets(util.PodNamespace).Get(context.TODO(), crypto.EncryptionSecretName, metav1.GetOptions{})
+	if err != nil {
+		if kuberneteserrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", errors.Wrap(err, "failed to get encryption secret")
+	}
+
+	currentKey, previousKeys, err := crypto.KeysFromSecret(secret)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read encryption secret")
+	}
+
+	for _, key := range previousKeys {
+		if err := crypto.InitFromString(key); err != nil {
+			return "", errors.Wrap(err, "failed to load encryption cipher")
+		}
+	}
+
+	if crypto.ToString() == "" {
+		if err := crypto.SetEncryptionKey(currentKey); err != nil {
+			return "", errors.Wrap(err, "failed to set encryption key")
+		}
+	}
+
+	// identity config values in archives are encrypted with the kotskinds implementation
+	if err := crypto.SetKotsKindsKeys(crypto.ToString(), previousKeys); err != nil {
+		return "", errors.Wrap(err, "failed to set kotskinds encryption keys")
+	}
+
+	return secret.ResourceVersion, nil
+}
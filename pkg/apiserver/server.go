@@ -11,13 +11,18 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/replicatedhq/kots/pkg/audit"
 	"github.com/replicatedhq/kots/pkg/automation"
 	"github.com/replicatedhq/kots/pkg/binaries"
+	"github.com/replicatedhq/kots/pkg/drift"
 	"github.com/replicatedhq/kots/pkg/handlers"
 	"github.com/replicatedhq/kots/pkg/helm"
 	identitymigrate "github.com/replicatedhq/kots/pkg/identity/migrate"
 	"github.com/replicatedhq/kots/pkg/informers"
 	"github.com/replicatedhq/kots/pkg/k8sutil"
+	"github.com/replicatedhq/kots/pkg/leader"
+	"github.com/replicatedhq/kots/pkg/metrics"
+	"github.com/replicatedhq/kots/pkg/notifications"
 	"github.com/replicatedhq/kots/pkg/operator"
 	"github.com/replicatedhq/kots/pkg/operator/client"
 	"github.com/replicatedhq/kots/pkg/persistence"
@@ -28,8 +33,10 @@ import (
 	"github.com/replicatedhq/kots/pkg/snapshotscheduler"
 	"github.com/replicatedhq/kots/pkg/store"
 	"github.com/replicatedhq/kots/pkg/supportbundle"
+	"github.com/replicatedhq/kots/pkg/tracing"
 	"github.com/replicatedhq/kots/pkg/updatechecker"
 	"github.com/replicatedhq/kots/pkg/util"
+	"github.com/replicatedhq/kots/pkg/version"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -43,6 +50,12 @@ type APIServerParams struct {
 func Start(params *APIServerParams) {
 	log.Printf("kotsadm version %s\n", params.Version)
 
+	shutdownTracing, err := tracing.Init(context.Background(), "kotsadm")
+	if err != nil {
+		log.Println("error initializing tracing")
+		panic(err)
+	}
+
 	if !util.IsHelmManaged() {
 		// set some persistence variables
 		persistence.InitDB(params.RqliteURI)
@@ -87,6 +100,7 @@ func Start(params *APIServerParams) {
 		panic(err)
 	}
 
+	var op *operator.Operator
 	if !util.IsHelmManaged() {
 		client := &client.Client{
 			TargetNamespace:       util.AppNamespace(),
@@ -94,17 +108,19 @@ func Start(params *APIServerParams) {
 			HookStopChans:         []chan struct{}{},
 		}
 		store := store.GetStore()
+		notifications.Init(store)
+		audit.Init(store)
 		k8sClientset, err := k8sutil.GetClientset()
 		if err != nil {
 			log.Println("error getting k8s clientset")
 			panic(err)
 		}
-		op := operator.Init(client, store, params.AutocreateClusterToken, k8sClientset)
-		if err := op.Start(); err != nil {
-			log.Println("error starting the operator")
+		op = operator.Init(client, store, params.AutocreateClusterToken, k8sClientset)
+		defer op.Shutdown()
+		if err := op.InitCluster(); err != nil {
+			log.Println("error initializing the operator cluster")
 			panic(err)
 		}
-		defer op.Shutdown()
 	}
 
 	if params.SharedPassword != "" {
@@ -127,30 +143,27 @@ func Start(params *APIServerParams) {
 
 	supportbundle.StartServer()
 
-	if err := informers.Start(); err != nil {
-		log.Println("Failed to start informers:", err)
+	identity, err := os.Hostname()
+	if err != nil {
+		log.Println("error getting hostname")
+		panic(err)
 	}
 
-	if !util.IsHelmManaged() {
-		if err := updatechecker.Start(); err != nil {
-			log.Println("Failed to start update checker:", err)
-		}
-		if err := snapshotscheduler.Start(); err != nil {
-			log.Println("Failed to start snapshot scheduler:", err)
+	if util.IsHelmManaged() {
+		leader.StartWithoutElection(identity, func() {
+			startLeaderSubsystems(op)
+		})
+	} else {
+		k8sClientset, err := k8sutil.GetClientset()
+		if err != nil {
+			log.Println("error getting k8s clientset")
+			panic(err)
 		}
-	}
-
-	if err := session.StartSessionPurgeCronJob(); err != nil {
-		log.Println("Failed to start session purge cron job:", err)
-	}
-
-	waitForAirgap, err := automation.NeedToWaitForAirgapApp()
-	if err != nil {
-		log.Println("Failed to check if airgap install is in progress:", err)
-	} else if !waitForAirgap {
-		opts := automation.AutomateInstallOptions{}
-		if err := automation.AutomateInstall(opts); err != nil {
-			log.Println("Failed to run automated installs:", err)
+		if err := leader.Start(context.Background(), k8sClientset, util.PodNamespace, identity, func() {
+			startLeaderSubsystems(op)
+		}); err != nil {
+			log.Println("error starting leader election")
+			panic(err)
 		}
 	}
 
@@ -163,6 +176,8 @@ func Start(params *APIServerParams) {
 	r := mux.NewRouter()
 
 	r.Use(handlers.CorsMiddleware)
+	r.Use(handlers.MetricsMiddleware)
+	r.Use(handlers.TracingMiddleware)
 	r.Methods("OPTIONS").HandlerFunc(handlers.CORS)
 
 	debugRouter := r.NewRoute().Subrouter()
@@ -226,6 +241,13 @@ func Start(params *APIServerParams) {
 		r.PathPrefix("/").HandlerFunc(webProxy)
 	}
 
+	go func() {
+		fmt.Printf("Starting metrics server on port %d...\n", metrics.Port)
+		if err := metrics.ListenAndServe(); err != nil {
+			log.Println("metrics server failed: ", err)
+		}
+	}()
+
 	srv := &http.Server{
 		Handler: r,
 		Addr:    ":3000",
@@ -233,5 +255,52 @@ func Start(params *APIServerParams) {
 
 	fmt.Printf("Starting Admin Console API on port %d...\n", 3000)
 
-	log.Fatal(srv.ListenAndServe())
+	err = srv.ListenAndServe()
+	if err := shutdownTracing(context.Background()); err != nil {
+		log.Println("failed to shutdown tracing: ", err)
+	}
+	log.Fatal(err)
+}
+
+// startLeaderSubsystems starts the background subsystems that must only run in one kotsadm replica
+func startLeaderSubsystems(op *operator.Operator) {
+	if op != nil {
+		if err := op.Start(); err != nil {
+			log.Println("error starting the operator")
+			panic(err)
+		}
+	}
+
+	if err := informers.Start(); err != nil {
+		log.Println("Failed to start informers:", err)
+	}
+
+	if !util.IsHelmManaged() {
+		if err := updatechecker.Start(); err != nil {
+			log.Println("Failed to start update checker:", err)
+		}
+		if err := snapshotscheduler.Start(); err != nil {
+			log.Println("Failed to start snapshot scheduler:", err)
+		}
+		if err := version.StartGitOpsPullRequestsCronJob(); err != nil {
+			log.Println("Failed to start gitops pull requests cron job:", err)
+		}
+		if err := drift.Start(); err != nil {
+			log.Println("Failed to start drift detection:", err)
+		}
+	}
+
+	if err := session.StartSessionPurgeCronJob(); err != nil {
+		log.Println("Failed to start session purge cron job:", err)
+	}
+
+	waitForAirgap, err := automation.NeedToWaitForAirgapApp()
+	if err != nil {
+		log.Println("Failed to check if airgap install is in progress:", err)
+	} else if !waitForAirgap {
+		opts := automation.AutomateInstallOptions{}
+		if err := automation.AutomateInstall(opts); err != nil {
+			log.Println("Failed to run automated installs:", err)
+		}
+	}
 }
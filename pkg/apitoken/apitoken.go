@@ -0,0 +1,57 @@
+package apitoken
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// Prefix identifies api tokens so they can be told apart from session jwts in a bearer authorization header
+	Prefix = "kots_"
+
+	DefaultExpiration = 30 * 24 * time.Hour
+	MaxExpiration     = 365 * 24 * time.Hour
+)
+
+// Generate returns a new random token
+func Generate() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "failed to read random bytes")
+	}
+	return Prefix + hex.EncodeToString(b), nil
+}
+
+// Hash returns the hash of the token that is persisted and used to look it up.
+// Tokens are random and long enough that a fast, unsalted hash is sufficient.
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, Prefix)
+}
+
+// ParseExpiresIn parses the requested lifetime of a token, defaulting to DefaultExpiration
+func ParseExpiresIn(expiresIn string) (time.Duration, error) {
+	if expiresIn == "" {
+		return DefaultExpiration, nil
+	}
+	d, err := time.ParseDuration(expiresIn)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid expiration %q", expiresIn)
+	}
+	if d <= 0 {
+		return 0, errors.Errorf("expiration %q must be positive", expiresIn)
+	}
+	if d > MaxExpiration {
+		return 0, errors.Errorf("expiration %q cannot be longer than %s", expiresIn, MaxExpiration)
+	}
+	return d, nil
+}
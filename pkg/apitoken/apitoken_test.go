@@ -0,0 +1,69 @@
+package apitoken
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	req := require.New(t)
+
+	token, err := Generate()
+	req.NoError(err)
+	req.True(IsAPIToken(token))
+	req.Len(token, len(Prefix)+64)
+
+	other, err := Generate()
+	req.NoError(err)
+	req.NotEqual(token, other)
+	req.NotEqual(Hash(token), Hash(other))
+	req.Equal(Hash(token), Hash(token))
+}
+
+func TestParseExpiresIn(t *testing.T) {
+	tests := []struct {
+		name      string
+		expiresIn string
+		want      time.Duration
+		wantErr   bool
+	}{
+		{
+			name:      "default",
+			expiresIn: "",
+			want:      DefaultExpiration,
+		},
+		{
+			name:      "duration",
+			expiresIn: "24h",
+			want:      24 * time.Hour,
+		},
+		{
+			name:      "negative",
+			expiresIn: "-1h",
+			wantErr:   true,
+		},
+		{
+			name:      "too long",
+			expiresIn: "9000h",
+			wantErr:   true,
+		},
+		{
+			name:      "invalid",
+			expiresIn: "1 month",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseExpiresIn(tt.expiresIn)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package types
+
+import (
+	"time"
+)
+
+// APIToken is a personal access token used by automation to call the admin console api.
+// The token itself is only returned when it is created, only its hash is persisted.
+type APIToken struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Roles      []string   `json:"roles"`
+	CreatedBy  string     `json:"createdBy"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+func (t APIToken) IsExpired(now time.Time) bool {
+	return now.After(t.ExpiresAt)
+}
@@ -1,35 +1,56 @@
 package types
 
 import (
+	"strings"
 	"time"
 
 	"github.com/replicatedhq/kots/pkg/util"
 )
 
 type App struct {
-	ID                    string         `json:"id"`
-	Slug                  string         `json:"slug"`
-	Name                  string         `json:"name"`
-	License               string         `json:"license"`
-	IsAirgap              bool           `json:"isAirgap"`
-	CurrentSequence       int64          `json:"currentSequence"`
-	UpstreamURI           string         `json:"upstreamUri"`
-	IconURI               string         `json:"iconUri"`
-	UpdatedAt             *time.Time     `json:"updatedAt"`
-	CreatedAt             time.Time      `json:"createdAt"`
-	LastUpdateCheckAt     *time.Time     `json:"lastUpdateCheckAt"`
-	HasPreflight          bool           `json:"hasPreflight"`
-	IsConfigurable        bool           `json:"isConfigurable"`
-	SnapshotTTL           string         `json:"snapshotTtl"`
-	SnapshotSchedule      string         `json:"snapshotSchedule"`
-	RestoreInProgressName string         `json:"restoreInProgressName"`
-	RestoreUndeployStatus UndeployStatus `json:"restoreUndeloyStatus"`
-	UpdateCheckerSpec     string         `json:"updateCheckerSpec"`
-	AutoDeploy            AutoDeploy     `json:"autoDeploy"`
-	IsGitOps              bool           `json:"isGitOps"`
-	InstallState          string         `json:"installState"`
-	LastLicenseSync       string         `json:"lastLicenseSync"`
-	ChannelChanged        bool           `json:"channelChanged"`
+	ID                    string             `json:"id"`
+	Slug                  string             `json:"slug"`
+	Name                  string             `json:"name"`
+	License               string             `json:"license"`
+	IsAirgap              bool               `json:"isAirgap"`
+	CurrentSequence       int64              `json:"currentSequence"`
+	UpstreamURI           string             `json:"upstreamUri"`
+	IconURI               string             `json:"iconUri"`
+	UpdatedAt             *time.Time         `json:"updatedAt"`
+	CreatedAt             time.Time          `json:"createdAt"`
+	LastUpdateCheckAt     *time.Time         `json:"lastUpdateCheckAt"`
+	HasPreflight          bool               `json:"hasPreflight"`
+	IsConfigurable        bool               `json:"isConfigurable"`
+	SnapshotTTL           string             `json:"snapshotTtl"`
+	SnapshotSchedule      string             `json:"snapshotSchedule"`
+	RestoreInProgressName string             `json:"restoreInProgressName"`
+	RestoreUndeployStatus UndeployStatus     `json:"restoreUndeloyStatus"`
+	UpdateCheckerSpec     string             `json:"updateCheckerSpec"`
+	AutoDeploy            AutoDeploy         `json:"autoDeploy"`
+	IsGitOps              bool               `json:"isGitOps"`
+	InstallState          string             `json:"installState"`
+	LastLicenseSync       string             `json:"lastLicenseSync"`
+	ChannelChanged        bool               `json:"channelChanged"`
+	DeployStrategy        DeployStrategy     `json:"deployStrategy"`
+	VerificationWindow    string             `json:"verificationWindow"`
+	MaintenanceWindow     *MaintenanceWindow `json:"maintenanceWindow,omitempty"`
+	// PendingAutoDeploySequence is the sequence queued by auto deploy until the maintenance window opens
+	PendingAutoDeploySequence *int64 `json:"pendingAutoDeploySequence,omitempty"`
+}
+
+// GetVerificationWindow returns the parsed verification window for progressive deploys
+func (a *App) GetVerificationWindow() (time.Duration, error) {
+	window := a.VerificationWindow
+	if window == "" {
+		window = DefaultDeployVerificationWindow
+	}
+	return time.ParseDuration(window)
+}
+
+// IsReplicatedUpstream returns true when the app is pulled from the replicated app service with a license.
+// Other apps, e.g. from git repositories, are uploaded by the CLI and cannot check for updates from the cluster.
+func (a *App) IsReplicatedUpstream() bool {
+	return strings.HasPrefix(a.UpstreamURI, "replicated://")
 }
 
 func (a *App) GetID() string {
@@ -0,0 +1,220 @@
+package types
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	cron "github.com/robfig/cron/v3"
+)
+
+// MaintenanceWindow restricts when automatic deploys can happen.
+// A window is either a cron schedule that opens the window for a duration, or a list of weekday/time ranges.
+// Times are evaluated in the window's timezone, which defaults to UTC.
+type MaintenanceWindow struct {
+	Timezone string                   `json:"timezone,omitempty"`
+	Schedule string                   `json:"schedule,omitempty"`
+	Duration string                   `json:"duration,omitempty"`
+	Ranges   []MaintenanceWindowRange `json:"ranges,omitempty"`
+}
+
+// MaintenanceWindowRange is open between Start and End ("15:04") on the listed days ("mon", "tue", ...).
+// An empty list of days means every day. Ranges that end before they start continue past midnight into the next day,
+// and ranges that end when they start are open for the whole day.
+type MaintenanceWindowRange struct {
+	Days  []string `json:"days,omitempty"`
+	Start string   `json:"start"`
+	End   string   `json:"end"`
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+func (w *MaintenanceWindow) Validate() error {
+	if w == nil {
+		return nil
+	}
+
+	if _, err := w.location(); err != nil {
+		return errors.Wrapf(err, "invalid timezone %q", w.Timezone)
+	}
+
+	if w.Schedule == "" && len(w.Ranges) == 0 {
+		return errors.New("either a schedule or ranges must be specified")
+	}
+	if w.Schedule != "" && len(w.Ranges) > 0 {
+		return errors.New("schedule and ranges cannot both be specified")
+	}
+
+	if w.Schedule != "" {
+		if _, err := cron.ParseStandard(w.Schedule); err != nil {
+			return errors.Wrapf(err, "invalid schedule %q", w.Schedule)
+		}
+		d, err := time.ParseDuration(w.Duration)
+		if err != nil {
+			return errors.Wrapf(err, "invalid duration %q", w.Duration)
+		}
+		if d <= 0 {
+			return errors.Errorf("duration %q must be positive", w.Duration)
+		}
+		return nil
+	}
+
+	for _, r := range w.Ranges {
+		for _, day := range r.Days {
+			if _, ok := weekdays[strings.ToLower(day)]; !ok {
+				return errors.Errorf("invalid day %q", day)
+			}
+		}
+		if _, err := parseTimeOfDay(r.Start); err != nil {
+			return errors.Wrapf(err, "invalid start %q", r.Start)
+		}
+		if _, err := parseTimeOfDay(r.End); err != nil {
+			return errors.Wrapf(err, "invalid end %q", r.End)
+		}
+	}
+
+	return nil
+}
+
+// IsOpen returns true if automatic deploys are allowed at the given time.
+// A nil window is always open.
+func (w *MaintenanceWindow) IsOpen(now time.Time) (bool, error) {
+	if w == nil {
+		return true, nil
+	}
+
+	loc, err := w.location()
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to load timezone %q", w.Timezone)
+	}
+	now = now.In(loc)
+
+	if w.Schedule != "" {
+		schedule, err := cron.ParseStandard(w.Schedule)
+		if err != nil {
+			return false, errors.Wrap(err, "failed to parse schedule")
+		}
+		duration, err := time.ParseDuration(w.Duration)
+		if err != nil {
+			return false, errors.Wrap(err, "failed to parse duration")
+		}
+		// the window is open if it was opened by the schedule within the last duration
+		lastOpened := schedule.Next(now.Add(-duration))
+		return !lastOpened.After(now), nil
+	}
+
+	minute := now.Hour()*60 + now.Minute()
+	for _, r := range w.Ranges {
+		start, err := parseTimeOfDay(r.Start)
+		if err != nil {
+			return false, errors.Wrap(err, "failed to parse start")
+		}
+		end, err := parseTimeOfDay(r.End)
+		if err != nil {
+			return false, errors.Wrap(err, "failed to parse end")
+		}
+
+		if start == end {
+			if r.includesDay(now.Weekday()) {
+				return true, nil
+			}
+		} else if start < end {
+			if r.includesDay(now.Weekday()) && minute >= start && minute < end {
+				return true, nil
+			}
+		} else {
+			if r.includesDay(now.Weekday()) && minute >= start {
+				return true, nil
+			}
+			if r.includesDay(now.AddDate(0, 0, -1).Weekday()) && minute < end {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// NextOpen returns the next time the window opens, or the given time if the window is already open
+func (w *MaintenanceWindow) NextOpen(now time.Time) (time.Time, error) {
+	isOpen, err := w.IsOpen(now)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "failed to check if window is open")
+	}
+	if isOpen {
+		return now, nil
+	}
+
+	loc, err := w.location()
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to load timezone %q", w.Timezone)
+	}
+	now = now.In(loc)
+
+	if w.Schedule != "" {
+		schedule, err := cron.ParseStandard(w.Schedule)
+		if err != nil {
+			return time.Time{}, errors.Wrap(err, "failed to parse schedule")
+		}
+		return schedule.Next(now), nil
+	}
+
+	var next time.Time
+	for i := 0; i <= 7; i++ {
+		day := now.AddDate(0, 0, i)
+		for _, r := range w.Ranges {
+			if !r.includesDay(day.Weekday()) {
+				continue
+			}
+			start, err := parseTimeOfDay(r.Start)
+			if err != nil {
+				return time.Time{}, errors.Wrap(err, "failed to parse start")
+			}
+			candidate := time.Date(day.Year(), day.Month(), day.Day(), start/60, start%60, 0, 0, loc)
+			if candidate.After(now) && (next.IsZero() || candidate.Before(next)) {
+				next = candidate
+			}
+		}
+		if !next.IsZero() {
+			return next, nil
+		}
+	}
+
+	return time.Time{}, errors.New("window never opens")
+}
+
+func (w *MaintenanceWindow) location() (*time.Location, error) {
+	if w.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(w.Timezone)
+}
+
+func (r MaintenanceWindowRange) includesDay(weekday time.Weekday) bool {
+	if len(r.Days) == 0 {
+		return true
+	}
+	for _, day := range r.Days {
+		if d, ok := weekdays[strings.ToLower(day)]; ok && d == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTimeOfDay returns the number of minutes since midnight
+func parseTimeOfDay(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
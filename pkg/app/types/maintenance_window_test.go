@@ -0,0 +1,208 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenanceWindow_IsOpen(t *testing.T) {
+	// 2023-01-02 is a monday
+	monday := func(hour, minute int) time.Time {
+		return time.Date(2023, 1, 2, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name   string
+		window *MaintenanceWindow
+		now    time.Time
+		want   bool
+	}{
+		{
+			name:   "nil window is always open",
+			window: nil,
+			now:    monday(12, 0),
+			want:   true,
+		},
+		{
+			name:   "inside range",
+			window: &MaintenanceWindow{Ranges: []MaintenanceWindowRange{{Days: []string{"mon"}, Start: "10:00", End: "14:00"}}},
+			now:    monday(12, 0),
+			want:   true,
+		},
+		{
+			name:   "range end is exclusive",
+			window: &MaintenanceWindow{Ranges: []MaintenanceWindowRange{{Days: []string{"mon"}, Start: "10:00", End: "14:00"}}},
+			now:    monday(14, 0),
+			want:   false,
+		},
+		{
+			name:   "wrong day",
+			window: &MaintenanceWindow{Ranges: []MaintenanceWindowRange{{Days: []string{"tue", "wed"}, Start: "10:00", End: "14:00"}}},
+			now:    monday(12, 0),
+			want:   false,
+		},
+		{
+			name:   "range past midnight is open the next morning",
+			window: &MaintenanceWindow{Ranges: []MaintenanceWindowRange{{Days: []string{"sun"}, Start: "22:00", End: "02:00"}}},
+			now:    monday(1, 30),
+			want:   true,
+		},
+		{
+			name:   "range past midnight is closed after it ends",
+			window: &MaintenanceWindow{Ranges: []MaintenanceWindowRange{{Days: []string{"sun"}, Start: "22:00", End: "02:00"}}},
+			now:    monday(2, 30),
+			want:   false,
+		},
+		{
+			name:   "range is evaluated in timezone",
+			window: &MaintenanceWindow{Timezone: "America/New_York", Ranges: []MaintenanceWindowRange{{Days: []string{"mon"}, Start: "02:00", End: "04:00"}}},
+			now:    monday(8, 0), // 03:00 in New York
+			want:   true,
+		},
+		{
+			name:   "whole day range",
+			window: &MaintenanceWindow{Ranges: []MaintenanceWindowRange{{Days: []string{"mon"}, Start: "00:00", End: "00:00"}}},
+			now:    monday(23, 59),
+			want:   true,
+		},
+		{
+			name:   "inside schedule",
+			window: &MaintenanceWindow{Schedule: "0 22 * * *", Duration: "4h"},
+			now:    monday(1, 0),
+			want:   true,
+		},
+		{
+			name:   "outside schedule",
+			window: &MaintenanceWindow{Schedule: "0 22 * * *", Duration: "4h"},
+			now:    monday(2, 0),
+			want:   false,
+		},
+		{
+			name:   "schedule is evaluated in timezone",
+			window: &MaintenanceWindow{Timezone: "America/New_York", Schedule: "0 2 * * 1", Duration: "1h"},
+			now:    monday(7, 30), // 02:30 in New York
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.window.IsOpen(tt.now)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMaintenanceWindow_NextOpen(t *testing.T) {
+	now := time.Date(2023, 1, 2, 12, 0, 0, 0, time.UTC) // monday
+
+	tests := []struct {
+		name   string
+		window *MaintenanceWindow
+		want   time.Time
+	}{
+		{
+			name:   "open window returns now",
+			window: &MaintenanceWindow{Ranges: []MaintenanceWindowRange{{Start: "10:00", End: "14:00"}}},
+			want:   now,
+		},
+		{
+			name:   "later the same day",
+			window: &MaintenanceWindow{Ranges: []MaintenanceWindowRange{{Days: []string{"mon"}, Start: "22:00", End: "02:00"}}},
+			want:   time.Date(2023, 1, 2, 22, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "earliest of several ranges",
+			window: &MaintenanceWindow{Ranges: []MaintenanceWindowRange{
+				{Days: []string{"sat"}, Start: "01:00", End: "05:00"},
+				{Days: []string{"wed"}, Start: "03:00", End: "05:00"},
+			}},
+			want: time.Date(2023, 1, 4, 3, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "next week",
+			window: &MaintenanceWindow{Ranges: []MaintenanceWindowRange{{Days: []string{"mon"}, Start: "08:00", End: "10:00"}}},
+			want:   time.Date(2023, 1, 9, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "schedule",
+			window: &MaintenanceWindow{Schedule: "0 22 * * *", Duration: "4h"},
+			want:   time.Date(2023, 1, 2, 22, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.window.NextOpen(now)
+			require.NoError(t, err)
+			require.True(t, tt.want.Equal(got), "want %s, got %s", tt.want, got)
+		})
+	}
+}
+
+func TestMaintenanceWindow_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		window  *MaintenanceWindow
+		wantErr bool
+	}{
+		{
+			name:   "nil window",
+			window: nil,
+		},
+		{
+			name:   "valid ranges",
+			window: &MaintenanceWindow{Timezone: "Europe/Berlin", Ranges: []MaintenanceWindowRange{{Days: []string{"Sat", "sun"}, Start: "01:00", End: "05:00"}}},
+		},
+		{
+			name:   "valid schedule",
+			window: &MaintenanceWindow{Schedule: "0 2 * * 6", Duration: "3h"},
+		},
+		{
+			name:    "empty window",
+			window:  &MaintenanceWindow{},
+			wantErr: true,
+		},
+		{
+			name:    "schedule and ranges",
+			window:  &MaintenanceWindow{Schedule: "0 2 * * 6", Duration: "3h", Ranges: []MaintenanceWindowRange{{Start: "01:00", End: "05:00"}}},
+			wantErr: true,
+		},
+		{
+			name:    "schedule without duration",
+			window:  &MaintenanceWindow{Schedule: "0 2 * * 6"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid schedule",
+			window:  &MaintenanceWindow{Schedule: "every night", Duration: "3h"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid timezone",
+			window:  &MaintenanceWindow{Timezone: "Mars/Olympus_Mons", Ranges: []MaintenanceWindowRange{{Start: "01:00", End: "05:00"}}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid day",
+			window:  &MaintenanceWindow{Ranges: []MaintenanceWindowRange{{Days: []string{"monday"}, Start: "01:00", End: "05:00"}}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid time",
+			window:  &MaintenanceWindow{Ranges: []MaintenanceWindowRange{{Start: "1am", End: "05:00"}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.window.Validate()
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -19,6 +19,18 @@ const (
 	AutoDeploySequence              AutoDeploy = "sequence"
 )
 
+type DeployStrategy string
+
+const (
+	// DeployStrategyDirect deploys a version and only reports its status
+	DeployStrategyDirect DeployStrategy = "direct"
+	// DeployStrategyProgressive watches the app status for a verification window after deploying a version,
+	// and rolls back to the previously deployed version if the app becomes degraded or unavailable
+	DeployStrategyProgressive DeployStrategy = "progressive"
+
+	DefaultDeployVerificationWindow = "10m"
+)
+
 type AppType interface {
 	GetID() string
 	GetSlug() string
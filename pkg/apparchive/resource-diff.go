@@ -0,0 +1,318 @@
+package apparchive
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/replicatedhq/kots/pkg/util"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeModified = "modified"
+)
+
+// VersionsDiff is a per-resource diff of the rendered yaml between two app versions
+type VersionsDiff struct {
+	Resources  []ResourceDiff   `json:"resources"`
+	HelmValues []HelmValuesDiff `json:"helmValues"`
+}
+
+// ResourceDiff describes a resource that was added, removed or modified between two versions.
+// Documents that are not kubernetes resources (e.g. a chart's Chart.yaml) are identified by their file only.
+type ResourceDiff struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name,omitempty"`
+	// Chart is the name of the v1beta1 helm chart the resource is rendered from, if any
+	Chart  string `json:"chart,omitempty"`
+	File   string `json:"file"`
+	Change string `json:"change"`
+	// Diff is a unified diff of the resource with keys sorted, so it does not include key order changes
+	Diff string `json:"diff,omitempty"`
+}
+
+// HelmValuesDiff describes a change to the rendered values of a v1beta2 helm chart
+type HelmValuesDiff struct {
+	Chart  string `json:"chart"`
+	Change string `json:"change"`
+	Diff   string `json:"diff,omitempty"`
+}
+
+// HasChanges returns true if any resource or helm chart values differ between the versions
+func (d *VersionsDiff) HasChanges() bool {
+	return len(d.Resources) > 0 || len(d.HelmValues) > 0
+}
+
+type renderedDoc struct {
+	apiVersion string
+	kind       string
+	namespace  string
+	name       string
+	chart      string
+	file       string
+	content    string
+}
+
+// DiffAppVersionResources compares the rendered yaml of two archive dirs resource by resource. Resources
+// are matched by group, kind, namespace and name, so moving a resource to another file or changing its
+// api version is reported as a modification rather than a removal and an addition.
+func DiffAppVersionResources(downstreamName string, fromArchive string, toArchive string, kustomizeBinPath string) (*VersionsDiff, error) {
+	fromDocs, err := getRenderedDocs(fromArchive, downstreamName, kustomizeBinPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get rendered docs of from archive")
+	}
+
+	toDocs, err := getRenderedDocs(toArchive, downstreamName, kustomizeBinPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get rendered docs of to archive")
+	}
+
+	resources, err := diffRenderedDocs(fromDocs, toDocs)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to diff resources")
+	}
+
+	fromValues, err := getRenderedV1Beta2Values(fromArchive, downstreamName)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get helm values of from archive")
+	}
+
+	toValues, err := getRenderedV1Beta2Values(toArchive, downstreamName)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get helm values of to archive")
+	}
+
+	helmValues, err := diffHelmValues(fromValues, toValues)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to diff helm values")
+	}
+
+	return &VersionsDiff{
+		Resources:  resources,
+		HelmValues: helmValues,
+	}, nil
+}
+
+func getRenderedDocs(archive string, downstreamName string, kustomizeBinPath string) (map[string]renderedDoc, error) {
+	docs := map[string]renderedDoc{}
+
+	_, appFiles, err := GetRenderedApp(archive, downstreamName, kustomizeBinPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get rendered app")
+	}
+	for filename, content := range appFiles {
+		if err := addRenderedDocs(docs, "", filename, content); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %s", filename)
+		}
+	}
+
+	_, chartFiles, err := GetRenderedV1Beta1ChartsArchive(archive, downstreamName, kustomizeBinPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get rendered charts files")
+	}
+	for filename, content := range chartFiles {
+		chart := strings.Split(filename, string(os.PathSeparator))[0]
+		if err := addRenderedDocs(docs, chart, filename, content); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %s", filename)
+		}
+	}
+
+	return docs, nil
+}
+
+func addRenderedDocs(docs map[string]renderedDoc, chart string, filename string, content []byte) error {
+	for i, single := range util.ConvertToSingleDocs(content) {
+		normalized, err := normalizeYAML(single)
+		if err != nil {
+			return errors.Wrapf(err, "failed to normalize document %d", i)
+		}
+
+		doc := renderedDoc{
+			chart:   chart,
+			file:    filename,
+			content: normalized,
+		}
+
+		o := struct {
+			APIVersion string `json:"apiVersion"`
+			Kind       string `json:"kind"`
+			Metadata   struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"metadata"`
+		}{}
+		key := fmt.Sprintf("file:%s#%d", filename, i)
+		if err := yaml.Unmarshal(single, &o); err == nil && o.Kind != "" && o.Metadata.Name != "" {
+			doc.apiVersion = o.APIVersion
+			doc.kind = o.Kind
+			doc.namespace = o.Metadata.Namespace
+			doc.name = o.Metadata.Name
+			key = strings.Join([]string{chart, apiGroup(o.APIVersion), o.Kind, o.Metadata.Namespace, o.Metadata.Name}, "/")
+		}
+
+		if _, ok := docs[key]; ok {
+			// a resource that is defined more than once is compared by its first definition, like kubectl diff
+			continue
+		}
+		docs[key] = doc
+	}
+
+	return nil
+}
+
+// normalizeYAML re-marshals a yaml document so that key order and formatting do not show up in diffs.
+// Documents that cannot be parsed are compared as is.
+func normalizeYAML(content []byte) (string, error) {
+	var parsed interface{}
+	if err := yaml.Unmarshal(content, &parsed); err != nil || parsed == nil {
+		return string(content), nil
+	}
+
+	normalized, err := yaml.Marshal(parsed)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal")
+	}
+	return string(normalized), nil
+}
+
+func apiGroup(apiVersion string) string {
+	if i := strings.LastIndex(apiVersion, "/"); i >= 0 {
+		return apiVersion[:i]
+	}
+	return ""
+}
+
+func diffRenderedDocs(from map[string]renderedDoc, to map[string]renderedDoc) ([]ResourceDiff, error) {
+	resources := []ResourceDiff{}
+
+	for key, toDoc := range to {
+		fromDoc, ok := from[key]
+		if !ok {
+			resources = append(resources, newResourceDiff(toDoc, ChangeAdded, ""))
+			continue
+		}
+		if fromDoc.content == toDoc.content {
+			continue
+		}
+
+		diff, err := unifiedDiff(fromDoc.file, toDoc.file, fromDoc.content, toDoc.content)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to diff %s", key)
+		}
+		resources = append(resources, newResourceDiff(toDoc, ChangeModified, diff))
+	}
+
+	for key, fromDoc := range from {
+		if _, ok := to[key]; !ok {
+			resources = append(resources, newResourceDiff(fromDoc, ChangeRemoved, ""))
+		}
+	}
+
+	sort.Slice(resources, func(i, j int) bool {
+		a, b := resources[i], resources[j]
+		if a.Chart != b.Chart {
+			return a.Chart < b.Chart
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.File < b.File
+	})
+
+	return resources, nil
+}
+
+func newResourceDiff(doc renderedDoc, change string, diff string) ResourceDiff {
+	return ResourceDiff{
+		APIVersion: doc.apiVersion,
+		Kind:       doc.kind,
+		Namespace:  doc.namespace,
+		Name:       doc.name,
+		Chart:      doc.chart,
+		File:       doc.file,
+		Change:     change,
+		Diff:       diff,
+	}
+}
+
+// getRenderedV1Beta2Values returns the normalized values.yaml of each v1beta2 helm chart, keyed by chart dir
+func getRenderedV1Beta2Values(archive string, downstreamName string) (map[string]string, error) {
+	filesMap, err := GetRenderedV1Beta2FileMap(archive, downstreamName)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get rendered v1beta2 files")
+	}
+
+	values := map[string]string{}
+	for filename, content := range filesMap {
+		if filepath.Base(filename) != "values.yaml" {
+			continue
+		}
+		normalized, err := normalizeYAML(content)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to normalize %s", filename)
+		}
+		values[filepath.Dir(filename)] = normalized
+	}
+
+	return values, nil
+}
+
+func diffHelmValues(from map[string]string, to map[string]string) ([]HelmValuesDiff, error) {
+	helmValues := []HelmValuesDiff{}
+
+	for chart, toValues := range to {
+		fromValues, ok := from[chart]
+		if !ok {
+			helmValues = append(helmValues, HelmValuesDiff{Chart: chart, Change: ChangeAdded})
+			continue
+		}
+		if fromValues == toValues {
+			continue
+		}
+
+		filename := filepath.Join(chart, "values.yaml")
+		diff, err := unifiedDiff(filename, filename, fromValues, toValues)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to diff values of chart %s", chart)
+		}
+		helmValues = append(helmValues, HelmValuesDiff{Chart: chart, Change: ChangeModified, Diff: diff})
+	}
+
+	for chart := range from {
+		if _, ok := to[chart]; !ok {
+			helmValues = append(helmValues, HelmValuesDiff{Chart: chart, Change: ChangeRemoved})
+		}
+	}
+
+	sort.Slice(helmValues, func(i, j int) bool {
+		return helmValues[i].Chart < helmValues[j].Chart
+	})
+
+	return helmValues, nil
+}
+
+func unifiedDiff(fromFile string, toFile string, fromContent string, toContent string) (string, error) {
+	// SplitLines terminates the last line itself, so trailing newlines would show up as an extra empty line
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(strings.TrimSuffix(fromContent, "\n")),
+		B:        difflib.SplitLines(strings.TrimSuffix(toContent, "\n")),
+		FromFile: fmt.Sprintf("a/%s", fromFile),
+		ToFile:   fmt.Sprintf("b/%s", toFile),
+		Context:  3,
+	})
+}
@@ -0,0 +1,221 @@
+package apparchive
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_diffRenderedDocs(t *testing.T) {
+	tests := []struct {
+		name string
+		from map[string][]byte
+		to   map[string][]byte
+		want []ResourceDiff
+	}{
+		{
+			name: "key order and formatting are ignored",
+			from: map[string][]byte{
+				"deployment.yaml": []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels: {app: web, tier: frontend}
+spec:
+  replicas: 1`),
+			},
+			to: map[string][]byte{
+				"deployment.yaml": []byte(`kind: Deployment
+apiVersion: apps/v1
+spec:
+  replicas: 1
+metadata:
+  labels:
+    tier: frontend
+    app: web
+  name: web`),
+			},
+			want: []ResourceDiff{},
+		},
+		{
+			name: "resources are matched across files and api versions",
+			from: map[string][]byte{
+				"all.yaml": []byte(`apiVersion: batch/v1beta1
+kind: CronJob
+metadata:
+  name: cleanup
+spec:
+  schedule: "0 * * * *"
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: removed
+data:
+  key: value`),
+			},
+			to: map[string][]byte{
+				"cronjob.yaml": []byte(`apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: cleanup
+spec:
+  schedule: "0 * * * *"`),
+				"service.yaml": []byte(`apiVersion: v1
+kind: Service
+metadata:
+  name: added
+  namespace: other`),
+			},
+			want: []ResourceDiff{
+				{
+					APIVersion: "v1",
+					Kind:       "ConfigMap",
+					Name:       "removed",
+					File:       "all.yaml",
+					Change:     ChangeRemoved,
+				},
+				{
+					APIVersion: "batch/v1",
+					Kind:       "CronJob",
+					Name:       "cleanup",
+					File:       "cronjob.yaml",
+					Change:     ChangeModified,
+					Diff: `--- a/all.yaml
++++ b/cronjob.yaml
+@@ -1,4 +1,4 @@
+-apiVersion: batch/v1beta1
++apiVersion: batch/v1
+ kind: CronJob
+ metadata:
+   name: cleanup
+`,
+				},
+				{
+					APIVersion: "v1",
+					Kind:       "Service",
+					Namespace:  "other",
+					Name:       "added",
+					File:       "service.yaml",
+					Change:     ChangeAdded,
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := require.New(t)
+
+			from := map[string]renderedDoc{}
+			for filename, content := range test.from {
+				req.NoError(addRenderedDocs(from, "", filename, content))
+			}
+			to := map[string]renderedDoc{}
+			for filename, content := range test.to {
+				req.NoError(addRenderedDocs(to, "", filename, content))
+			}
+
+			got, err := diffRenderedDocs(from, to)
+			req.NoError(err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestDiffAppVersionResources(t *testing.T) {
+	req := require.New(t)
+
+	writeFiles := func(archive string, files map[string]string) {
+		for filename, content := range files {
+			path := filepath.Join(archive, "rendered", "this-cluster", filename)
+			req.NoError(os.MkdirAll(filepath.Dir(path), 0755))
+			req.NoError(os.WriteFile(path, []byte(content), 0644))
+		}
+	}
+
+	fromArchive := t.TempDir()
+	writeFiles(fromArchive, map[string]string{
+		"secret.yaml": `apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+stringData:
+  password: abc`,
+		"charts/redis/templates/statefulset.yaml": `apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: redis
+spec:
+  replicas: 1`,
+		"helm/nginx/nginx-1.0.0.tgz": "archive",
+		"helm/nginx/values.yaml": `image:
+  tag: "1.0"
+replicaCount: 1`,
+		"helm/postgres/values.yaml": `auth: {}`,
+	})
+
+	toArchive := t.TempDir()
+	writeFiles(toArchive, map[string]string{
+		"secret.yaml": `apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+stringData:
+  password: abc`,
+		"charts/redis/templates/statefulset.yaml": `apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: redis
+spec:
+  replicas: 3`,
+		"helm/nginx/nginx-1.0.1.tgz": "archive",
+		"helm/nginx/values.yaml": `replicaCount: 1
+image:
+  tag: "1.1"`,
+	})
+
+	got, err := DiffAppVersionResources("this-cluster", fromArchive, toArchive, "kustomize")
+	req.NoError(err)
+
+	assert.True(t, got.HasChanges())
+	assert.Equal(t, []ResourceDiff{
+		{
+			APIVersion: "apps/v1",
+			Kind:       "StatefulSet",
+			Name:       "redis",
+			Chart:      "redis",
+			File:       "redis/templates/statefulset.yaml",
+			Change:     ChangeModified,
+			Diff: `--- a/redis/templates/statefulset.yaml
++++ b/redis/templates/statefulset.yaml
+@@ -3,4 +3,4 @@
+ metadata:
+   name: redis
+ spec:
+-  replicas: 1
++  replicas: 3
+`,
+		},
+	}, got.Resources)
+	assert.Equal(t, []HelmValuesDiff{
+		{
+			Chart:  "nginx",
+			Change: ChangeModified,
+			Diff: `--- a/nginx/values.yaml
++++ b/nginx/values.yaml
+@@ -1,3 +1,3 @@
+ image:
+-  tag: "1.0"
++  tag: "1.1"
+ replicaCount: 1
+`,
+		},
+		{
+			Chart:  "postgres",
+			Change: ChangeRemoved,
+		},
+	}, got.HelmValues)
+}
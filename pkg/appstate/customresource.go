@@ -0,0 +1,166 @@
+package appstate
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/replicatedhq/kots/pkg/appstate/types"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// ReadyConditionType is the status condition used to calculate the state of a custom resource
+	// when its status informer has no ready rules
+	ReadyConditionType = "Ready"
+)
+
+func newCustomResourceController(dynamicClient dynamic.Interface, gvr schema.GroupVersionResource) runControllerFunc {
+	return func(
+		ctx context.Context, _ kubernetes.Interface, targetNamespace string,
+		informers []types.StatusInformer, resourceStateCh chan<- types.ResourceState,
+	) {
+		runCustomResourceController(ctx, dynamicClient, gvr, targetNamespace, informers, resourceStateCh)
+	}
+}
+
+func runCustomResourceController(
+	ctx context.Context, dynamicClient dynamic.Interface, gvr schema.GroupVersionResource, targetNamespace string,
+	informers []types.StatusInformer, resourceStateCh chan<- types.ResourceState,
+) {
+	informer := dynamicinformer.NewFilteredDynamicInformer(
+		dynamicClient,
+		gvr,
+		targetNamespace,
+		time.Minute,
+		cache.Indexers{},
+		nil,
+	).Informer()
+
+	eventHandler := NewCustomResourceEventHandler(informers, resourceStateCh)
+
+	runInformer(ctx, informer, eventHandler)
+	return
+}
+
+type customResourceEventHandler struct {
+	informers       []types.StatusInformer
+	resourceStateCh chan<- types.ResourceState
+}
+
+func NewCustomResourceEventHandler(informers []types.StatusInformer, resourceStateCh chan<- types.ResourceState) *customResourceEventHandler {
+	return &customResourceEventHandler{
+		informers:       informers,
+		resourceStateCh: resourceStateCh,
+	}
+}
+
+func (h *customResourceEventHandler) ObjectCreated(obj interface{}) {
+	r := h.cast(obj)
+	informer, ok := h.getInformer(r)
+	if !ok {
+		return
+	}
+	h.resourceStateCh <- makeCustomResourceResourceState(r, informer, CalculateCustomResourceState(r, informer))
+}
+
+func (h *customResourceEventHandler) ObjectUpdated(obj interface{}) {
+	r := h.cast(obj)
+	informer, ok := h.getInformer(r)
+	if !ok {
+		return
+	}
+	h.resourceStateCh <- makeCustomResourceResourceState(r, informer, CalculateCustomResourceState(r, informer))
+}
+
+func (h *customResourceEventHandler) ObjectDeleted(obj interface{}) {
+	r := h.cast(obj)
+	informer, ok := h.getInformer(r)
+	if !ok {
+		return
+	}
+	h.resourceStateCh <- makeCustomResourceResourceState(r, informer, types.StateMissing)
+}
+
+func (h *customResourceEventHandler) cast(obj interface{}) *unstructured.Unstructured {
+	r, _ := obj.(*unstructured.Unstructured)
+	return r
+}
+
+func (h *customResourceEventHandler) getInformer(r *unstructured.Unstructured) (types.StatusInformer, bool) {
+	if r != nil {
+		for _, informer := range h.informers {
+			if r.GetNamespace() == informer.Namespace && r.GetName() == informer.Name {
+				return informer, true
+			}
+		}
+	}
+	return types.StatusInformer{}, false
+}
+
+func makeCustomResourceResourceState(r *unstructured.Unstructured, informer types.StatusInformer, state types.State) types.ResourceState {
+	return types.ResourceState{
+		Kind:      informer.Kind,
+		Name:      r.GetName(),
+		Namespace: r.GetNamespace(),
+		State:     state,
+	}
+}
+
+// CalculateCustomResourceState calculates the state of a resource from the rules of its status informer.
+// The resource is degraded if any degraded rule matches, and ready if all ready rules match.
+// Without ready rules, the resource is ready once it reports a Ready condition, or once it exists
+// if it does not have a Ready condition at all.
+func CalculateCustomResourceState(r *unstructured.Unstructured, informer types.StatusInformer) types.State {
+	for _, rule := range informer.DegradedRules {
+		if statusRuleMatches(r, rule) {
+			return types.StateDegraded
+		}
+	}
+
+	readyRules := informer.ReadyRules
+	if len(readyRules) == 0 {
+		if !hasStatusCondition(r, ReadyConditionType) {
+			return types.StateReady
+		}
+		readyRules = []types.StatusRule{{Condition: ReadyConditionType, Value: "True"}}
+	}
+
+	for _, rule := range readyRules {
+		if !statusRuleMatches(r, rule) {
+			return types.StateUpdating
+		}
+	}
+	return types.StateReady
+}
+
+func statusRuleMatches(r *unstructured.Unstructured, rule types.StatusRule) bool {
+	path := rule.JSONPath
+	if rule.Condition != "" {
+		path = fmt.Sprintf(`.status.conditions[?(@.type=="%s")].status`, rule.Condition)
+	}
+
+	matches, err := resourcePropertyMatchesValue(r, path, rule.Value)
+	if err != nil {
+		log.Printf("Failed to match status rule for %s/%s: %v", r.GetNamespace(), r.GetName(), err)
+		return false
+	}
+	return matches
+}
+
+func hasStatusCondition(r *unstructured.Unstructured, conditionType string) bool {
+	conditions, _, _ := unstructured.NestedSlice(r.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if ok && condition["type"] == conditionType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package appstate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/replicatedhq/kots/pkg/appstate/types"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func testCertificate(conditions ...map[string]interface{}) *unstructured.Unstructured {
+	r := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "cert-manager.io/v1",
+			"kind":       "Certificate",
+			"metadata": map[string]interface{}{
+				"name":      "my-cert",
+				"namespace": "default",
+			},
+		},
+	}
+	if len(conditions) > 0 {
+		c := []interface{}{}
+		for _, condition := range conditions {
+			c = append(c, condition)
+		}
+		r.Object["status"] = map[string]interface{}{
+			"conditions": c,
+			"phase":      "Issued",
+		}
+	}
+	return r
+}
+
+func TestCalculateCustomResourceState(t *testing.T) {
+	tests := []struct {
+		name     string
+		r        *unstructured.Unstructured
+		informer types.StatusInformer
+		want     types.State
+	}{
+		{
+			name:     "no rules and no conditions",
+			r:        testCertificate(),
+			informer: types.StatusInformer{},
+			want:     types.StateReady,
+		},
+		{
+			name:     "no rules and ready condition is false",
+			r:        testCertificate(map[string]interface{}{"type": "Ready", "status": "False"}),
+			informer: types.StatusInformer{},
+			want:     types.StateUpdating,
+		},
+		{
+			name:     "no rules and ready condition is true",
+			r:        testCertificate(map[string]interface{}{"type": "Ready", "status": "True"}),
+			informer: types.StatusInformer{},
+			want:     types.StateReady,
+		},
+		{
+			name: "condition rule does not match",
+			r:    testCertificate(map[string]interface{}{"type": "Issuing", "status": "True"}),
+			informer: types.StatusInformer{
+				ReadyRules: []types.StatusRule{{Condition: "Issuing", Value: "False"}},
+			},
+			want: types.StateUpdating,
+		},
+		{
+			name: "all ready rules match",
+			r:    testCertificate(map[string]interface{}{"type": "Issuing", "status": "False"}),
+			informer: types.StatusInformer{
+				ReadyRules: []types.StatusRule{
+					{Condition: "Issuing", Value: "False"},
+					{JSONPath: ".status.phase", Value: "Issued"},
+				},
+			},
+			want: types.StateReady,
+		},
+		{
+			name: "degraded rule matches",
+			r: testCertificate(
+				map[string]interface{}{"type": "Ready", "status": "True"},
+				map[string]interface{}{"type": "Failed", "status": "True"},
+			),
+			informer: types.StatusInformer{
+				ReadyRules:    []types.StatusRule{{Condition: "Ready", Value: "True"}},
+				DegradedRules: []types.StatusRule{{Condition: "Failed", Value: "True"}},
+			},
+			want: types.StateDegraded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CalculateCustomResourceState(tt.r, tt.informer)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_runCustomResourceController(t *testing.T) {
+	req := require.New(t)
+
+	gvr := schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(
+		runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "CertificateList"},
+		testCertificate(map[string]interface{}{"type": "Ready", "status": "True"}),
+	)
+
+	informers := []types.StatusInformer{
+		{
+			Kind:                 "certificates.v1.cert-manager.io",
+			Name:                 "my-cert",
+			Namespace:            "default",
+			GroupVersionResource: &gvr,
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resourceStateCh := make(chan types.ResourceState)
+	go newCustomResourceController(dynamicClient, gvr)(ctx, nil, "default", informers, resourceStateCh)
+
+	select {
+	case resourceState := <-resourceStateCh:
+		req.Equal(types.ResourceState{
+			Kind:      "certificates.v1.cert-manager.io",
+			Name:      "my-cert",
+			Namespace: "default",
+			State:     types.StateReady,
+		}, resourceState)
+	case <-time.After(10 * time.Second):
+		req.Fail("timed out waiting for resource state")
+	}
+}
@@ -2,8 +2,12 @@ package types
 
 import (
 	"errors"
+	"fmt"
 	"regexp"
+	"strings"
 	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 var (
@@ -22,20 +26,113 @@ type StatusInformer struct {
 	Kind      string
 	Name      string
 	Namespace string
+
+	// GroupVersionResource is set when the kind is written as resource.version.group (e.g.
+	// certificates.v1.cert-manager.io). The state of such resources is calculated from the rules below.
+	GroupVersionResource *schema.GroupVersionResource
+	ReadyRules           []StatusRule
+	DegradedRules        []StatusRule
+}
+
+// StatusRule matches either a JSONPath or the status of a status.conditions entry against a value.
+type StatusRule struct {
+	JSONPath  string
+	Condition string
+	Value     string
 }
 
+// Parse parses status informers of the form [namespace/]kind/name. Informers for arbitrary resources
+// can be followed by ready and degraded rules separated by semicolons, for example:
+//
+//	certificates.v1.cert-manager.io/my-cert;ready=condition:Ready;degraded=condition:Issuing=False
+//	jobs.v1.batch/migrate;ready=.status.succeeded=1;degraded=condition:Failed
 func (s StatusInformerString) Parse() (i StatusInformer, err error) {
-	matches := StatusInformerRegexp.FindStringSubmatch(string(s))
+	parts := strings.Split(string(s), ";")
+	matches := StatusInformerRegexp.FindStringSubmatch(parts[0])
 	if len(matches) != 4 {
 		err = errors.New("status informer format string incorrect")
 		return
 	}
-	i.Namespace = matches[1]
-	i.Kind = matches[2]
-	i.Name = matches[3]
+
+	next := StatusInformer{
+		Namespace: matches[1],
+		Kind:      matches[2],
+		Name:      matches[3],
+	}
+
+	if strings.Contains(next.Kind, ".") {
+		next.GroupVersionResource, err = parseGroupVersionResource(next.Kind)
+		if err != nil {
+			return
+		}
+		next.Kind = strings.ToLower(next.Kind)
+	}
+
+	for _, part := range parts[1:] {
+		if part == "" {
+			continue
+		}
+		if next.GroupVersionResource == nil {
+			err = errors.New("status rules are only supported for kinds of the form resource.version.group")
+			return
+		}
+		key, value, _ := strings.Cut(part, "=")
+		rule, ruleErr := parseStatusRule(value)
+		if ruleErr != nil {
+			err = fmt.Errorf("failed to parse %s rule: %w", key, ruleErr)
+			return
+		}
+		switch key {
+		case "ready":
+			next.ReadyRules = append(next.ReadyRules, rule)
+		case "degraded":
+			next.DegradedRules = append(next.DegradedRules, rule)
+		default:
+			err = fmt.Errorf("unknown status rule %q", key)
+			return
+		}
+	}
+
+	i = next
 	return
 }
 
+func parseGroupVersionResource(kind string) (*schema.GroupVersionResource, error) {
+	parts := strings.SplitN(strings.ToLower(kind), ".", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("kind %q is not of the form resource.version.group", kind)
+	}
+	gvr := &schema.GroupVersionResource{
+		Resource: parts[0],
+		Version:  parts[1],
+	}
+	if len(parts) == 3 {
+		gvr.Group = parts[2]
+	}
+	return gvr, nil
+}
+
+// parseStatusRule parses rules of the form condition:<type>[=<status>] or <jsonpath>=<value>.
+// Condition rules match a status of "True" if no status is given.
+func parseStatusRule(s string) (StatusRule, error) {
+	if condition, ok := strings.CutPrefix(s, "condition:"); ok {
+		conditionType, status, found := strings.Cut(condition, "=")
+		if conditionType == "" {
+			return StatusRule{}, errors.New("condition type cannot be empty")
+		}
+		if !found {
+			status = "True"
+		}
+		return StatusRule{Condition: conditionType, Value: status}, nil
+	}
+
+	idx := strings.LastIndex(s, "=")
+	if idx <= 0 {
+		return StatusRule{}, fmt.Errorf("rule %q is not of the form <jsonpath>=<value>", s)
+	}
+	return StatusRule{JSONPath: s[:idx], Value: s[idx+1:]}, nil
+}
+
 type AppStatus struct {
 	AppID          string         `json:"appId"`
 	ResourceStates ResourceStates `json:"resourceStates" hash:"set"`
@@ -55,6 +152,19 @@ type ResourceState struct {
 
 type State string
 
+// ResourceStateTransition records a change in the state of a single resource. EndedAt is set when the
+// resource has transitioned to another state since.
+type ResourceStateTransition struct {
+	Kind          string     `json:"kind"`
+	Name          string     `json:"name"`
+	Namespace     string     `json:"namespace"`
+	PreviousState State      `json:"previousState"`
+	State         State      `json:"state"`
+	Sequence      int64      `json:"sequence"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	EndedAt       *time.Time `json:"endedAt,omitempty"`
+}
+
 func GetState(resourceStates []ResourceState) State {
 	if len(resourceStates) == 0 {
 		return StateMissing
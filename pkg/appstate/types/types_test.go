@@ -3,6 +3,8 @@ package types
 import (
 	"reflect"
 	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 func TestStatusInformerString_Parse(t *testing.T) {
@@ -34,6 +36,68 @@ func TestStatusInformerString_Parse(t *testing.T) {
 			str:     "sentry-web",
 			wantErr: true,
 		},
+		{
+			name: "custom resource without rules",
+			str:  "cassandra/CassandraDatacenters.v1beta1.cassandra.datastax.com/dc1",
+			want: StatusInformer{
+				Namespace:            "cassandra",
+				Kind:                 "cassandradatacenters.v1beta1.cassandra.datastax.com",
+				Name:                 "dc1",
+				GroupVersionResource: &schema.GroupVersionResource{Group: "cassandra.datastax.com", Version: "v1beta1", Resource: "cassandradatacenters"},
+			},
+		},
+		{
+			name: "core group resource",
+			str:  "pods.v1/my-pod",
+			want: StatusInformer{
+				Kind:                 "pods.v1",
+				Name:                 "my-pod",
+				GroupVersionResource: &schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+			},
+		},
+		{
+			name: "custom resource with rules",
+			str:  `jobs.v1.batch/migrate;ready=.status.conditions[?(@.type=="Complete")].status=True;degraded=condition:Failed;degraded=condition:Suspended=True`,
+			want: StatusInformer{
+				Kind:                 "jobs.v1.batch",
+				Name:                 "migrate",
+				GroupVersionResource: &schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"},
+				ReadyRules: []StatusRule{
+					{JSONPath: `.status.conditions[?(@.type=="Complete")].status`, Value: "True"},
+				},
+				DegradedRules: []StatusRule{
+					{Condition: "Failed", Value: "True"},
+					{Condition: "Suspended", Value: "True"},
+				},
+			},
+		},
+		{
+			name: "condition rule with status",
+			str:  "certificates.v1.cert-manager.io/my-cert;ready=condition:Issuing=False",
+			want: StatusInformer{
+				Kind:                 "certificates.v1.cert-manager.io",
+				Name:                 "my-cert",
+				GroupVersionResource: &schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"},
+				ReadyRules: []StatusRule{
+					{Condition: "Issuing", Value: "False"},
+				},
+			},
+		},
+		{
+			name:    "rules on a built-in kind",
+			str:     "deploy/sentry-web;ready=condition:Available",
+			wantErr: true,
+		},
+		{
+			name:    "unknown rule",
+			str:     "certificates.v1.cert-manager.io/my-cert;healthy=condition:Ready",
+			wantErr: true,
+		},
+		{
+			name:    "jsonpath rule without value",
+			str:     "certificates.v1.cert-manager.io/my-cert;ready=.status.phase",
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,110 @@
+package audit
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/audit/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	sessiontypes "github.com/replicatedhq/kots/pkg/session/types"
+	"github.com/replicatedhq/kots/pkg/store"
+	"github.com/replicatedhq/kots/pkg/util"
+)
+
+var auditStore store.Store
+
+// Init enables the audit log. Until it is called, Record is a no-op.
+func Init(s store.Store) {
+	auditStore = s
+}
+
+// Record persists the event. Failing to record an event is logged and does not fail the request.
+func Record(event types.Event) {
+	if auditStore == nil {
+		return
+	}
+
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	if err := auditStore.CreateAuditEvent(event); err != nil {
+		logger.Error(errors.Wrapf(err, "failed to record audit event for %s", event.Action))
+	}
+}
+
+// NewEvent builds an event for the request made by the session
+func NewEvent(r *http.Request, sess *sessiontypes.Session, action string, resource string, statusCode int) types.Event {
+	event := types.Event{
+		CreatedAt:  time.Now(),
+		Action:     action,
+		Resource:   resource,
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		Details:    requestDetails(r),
+		StatusCode: statusCode,
+		Outcome:    types.OutcomeFromStatusCode(statusCode),
+		Actor: types.Actor{
+			RemoteAddr: util.ClientIP(r),
+		},
+	}
+
+	if sess != nil {
+		event.Actor.UserID = sess.UserID
+		event.Actor.SessionID = sess.ID
+		event.Actor.Roles = sess.Roles
+	}
+
+	return event
+}
+
+// Middleware records an event for each request to a route that is not authorized with a session, e.g. the routes
+// that the kots CLI calls with the kotsadm token
+func Middleware(action string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rr := NewResponseRecorder(w)
+		handler(rr, r)
+
+		Record(NewEvent(r, nil, action, "", rr.StatusCode))
+	}
+}
+
+// RouteName returns the name of the route that matched the request
+func RouteName(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		return route.GetName()
+	}
+	return ""
+}
+
+func requestDetails(r *http.Request) map[string]string {
+	details := map[string]string{}
+	for key, val := range mux.Vars(r) {
+		details[key] = val
+	}
+	for key, vals := range r.URL.Query() {
+		details[key] = strings.Join(vals, ",")
+	}
+	if len(details) == 0 {
+		return nil
+	}
+	return details
+}
+
+// ResponseRecorder captures the status code written by a handler
+type ResponseRecorder struct {
+	http.ResponseWriter
+	StatusCode int
+}
+
+func NewResponseRecorder(w http.ResponseWriter) *ResponseRecorder {
+	return &ResponseRecorder{w, http.StatusOK}
+}
+
+func (rr *ResponseRecorder) WriteHeader(code int) {
+	rr.StatusCode = code
+	rr.ResponseWriter.WriteHeader(code)
+}
@@ -0,0 +1,100 @@
+package audit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/replicatedhq/kots/pkg/audit/types"
+	sessiontypes "github.com/replicatedhq/kots/pkg/session/types"
+	mock_store "github.com/replicatedhq/kots/pkg/store/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEvent(t *testing.T) {
+	req := require.New(t)
+
+	r := httptest.NewRequest("POST", "/api/v1/app/my-app/sequence/3/deploy?isSkipPreflights=true", nil)
+	r.RemoteAddr = "10.0.0.1:54321"
+	r = mux.SetURLVars(r, map[string]string{"appSlug": "my-app", "sequence": "3"})
+
+	sess := &sessiontypes.Session{ID: "session-id", UserID: "kots-user", Roles: []string{"cluster-admin"}}
+
+	event := NewEvent(r, sess, "DeployAppVersion", "app.my-app.downstream.deploy.", http.StatusNoContent)
+	req.Equal("DeployAppVersion", event.Action)
+	req.Equal("app.my-app.downstream.deploy.", event.Resource)
+	req.Equal("POST", event.Method)
+	req.Equal("/api/v1/app/my-app/sequence/3/deploy", event.Path)
+	req.Equal(map[string]string{"appSlug": "my-app", "sequence": "3", "isSkipPreflights": "true"}, event.Details)
+	req.Equal(types.OutcomeSuccess, event.Outcome)
+	req.Equal(types.Actor{UserID: "kots-user", SessionID: "session-id", Roles: []string{"cluster-admin"}, RemoteAddr: "10.0.0.1"}, event.Actor)
+	req.False(event.CreatedAt.IsZero())
+
+	// the header can be set by the client, it's only used when the peer is a trusted proxy
+	r.Header.Set("X-Forwarded-For", "192.168.1.1")
+	event = NewEvent(r, nil, "Login", "", http.StatusUnauthorized)
+	req.Equal(types.OutcomeFailure, event.Outcome)
+	req.Equal(types.Actor{RemoteAddr: "10.0.0.1"}, event.Actor)
+}
+
+func TestRecord(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	defer Init(nil)
+
+	// not initialized, no store calls are expected
+	Record(types.Event{Action: "DeployAppVersion"})
+
+	mockStore := mock_store.NewMockStore(ctrl)
+	Init(mockStore)
+
+	mockStore.EXPECT().CreateAuditEvent(gomock.Any()).DoAndReturn(func(event types.Event) error {
+		require.Equal(t, "DeployAppVersion", event.Action)
+		require.False(t, event.CreatedAt.IsZero())
+		return nil
+	})
+	Record(types.Event{Action: "DeployAppVersion"})
+}
+
+func TestResponseRecorder(t *testing.T) {
+	w := httptest.NewRecorder()
+	rr := NewResponseRecorder(w)
+	require.Equal(t, http.StatusOK, rr.StatusCode)
+
+	rr.WriteHeader(http.StatusForbidden)
+	require.Equal(t, http.StatusForbidden, rr.StatusCode)
+	require.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestMiddleware(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	defer Init(nil)
+
+	mockStore := mock_store.NewMockStore(ctrl)
+	Init(mockStore)
+
+	mockStore.EXPECT().CreateAuditEvent(gomock.Any()).DoAndReturn(func(event types.Event) error {
+		require.Equal(t, "UploadExistingApp", event.Action)
+		require.Equal(t, "PUT", event.Method)
+		require.Equal(t, "/api/v1/upload", event.Path)
+		require.Equal(t, http.StatusUnauthorized, event.StatusCode)
+		require.Equal(t, types.OutcomeFailure, event.Outcome)
+		require.Equal(t, types.Actor{RemoteAddr: "10.0.0.1"}, event.Actor)
+		return nil
+	})
+
+	r := httptest.NewRequest("PUT", "/api/v1/upload", nil)
+	r.RemoteAddr = "10.0.0.1:54321"
+	w := httptest.NewRecorder()
+
+	Middleware("UploadExistingApp", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})(w, r)
+
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,55 @@
+package types
+
+import (
+	"time"
+)
+
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+	OutcomeDenied  Outcome = "denied"
+)
+
+// Event records a mutating action performed in the admin console
+type Event struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	Actor     Actor     `json:"actor"`
+	// Action is the name of the route that was called, e.g. DeployAppVersion
+	Action string `json:"action"`
+	// Resource is the rbac resource the action was performed on, e.g. app.my-app.downstream.deploy.
+	Resource string `json:"resource"`
+	Method   string `json:"method"`
+	Path     string `json:"path"`
+	// Details summarizes the request with its route variables and query parameters.
+	// Request bodies are not recorded since they can contain secrets.
+	Details    map[string]string `json:"details,omitempty"`
+	StatusCode int               `json:"statusCode"`
+	Outcome    Outcome           `json:"outcome"`
+}
+
+type Actor struct {
+	UserID     string   `json:"userId,omitempty"`
+	SessionID  string   `json:"sessionId,omitempty"`
+	Roles      []string `json:"roles,omitempty"`
+	RemoteAddr string   `json:"remoteAddr,omitempty"`
+}
+
+type ListOptions struct {
+	// CurrentPage and PageSize paginate the events, newest first. A PageSize of 0 returns all events.
+	CurrentPage int
+	PageSize    int
+	Since       *time.Time
+	Until       *time.Time
+	UserID      string
+	Action      string
+}
+
+func OutcomeFromStatusCode(statusCode int) Outcome {
+	if statusCode >= 400 {
+		return OutcomeFailure
+	}
+	return OutcomeSuccess
+}
@@ -6,9 +6,14 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -18,14 +23,48 @@ import (
 type aesCipher struct {
 	key    []byte
 	cipher cipher.AEAD
-	nonce  []byte
+	// nonce is part of the key string, it is only used for the legacy format where every message was sealed with the same nonce
+	nonce []byte
+	id    []byte
 }
 
 const keyLength = 24 // 192 bit
+const keyIDLength = 8
+
+// Data encrypted by Encrypt starts with a header that identifies the format and the key that encrypted it,
+// followed by a nonce that is generated for every message:
+//
+//	"kenc" | version (1 byte) | key id (8 bytes) | nonce (12 bytes) | sealed data
+//
+// The header is authenticated as additional data. Data without the header is in the legacy format,
+// sealed with the nonce of the key string, and can still be decrypted.
+var envelopeMagic = []byte("kenc")
+
+const envelopeVersion1 byte = 1
+
+const (
+	EncryptionSecretName = "kotsadm-encryption"
+	// EncryptionKeySecretKey is the member of the secret that holds the key that is used for encryption
+	EncryptionKeySecretKey = "encryptionKey"
+	// PreviousEncryptionKeysSecretKey is the member of the secret that holds the keys that were used before the
+	// key was rotated, one per line. They are only used for decryption, e.g. of data restored from a backup.
+	PreviousEncryptionKeysSecretKey = "previousEncryptionKeys"
+)
 
+// cipherMutex guards the ciphers, which are reloaded in the background when the key is rotated by another replica
+var cipherMutex sync.RWMutex
 var decryptionCiphers []*aesCipher // used to decrypt data
 var encryptionCipher *aesCipher    // used to encrypt data
 
+// minKeyReloadInterval limits how often the keys are reloaded when data was encrypted with a key that is not loaded
+const minKeyReloadInterval = 10 * time.Second
+
+var (
+	keyReloadMutex sync.Mutex
+	keyReloader    func() error
+	lastKeyReload  time.Time
+)
+
 // add cipher from API_ENCRYPTION_KEY environment variable if it is present (and set that key to be used for encryption)
 func init() {
 	decryptionCiphers = []*aesCipher{}
@@ -41,28 +80,56 @@ func init() {
 }
 
 // InitFromSecret reads the encryption key from kubernetes and adds it to the list of decryptionCiphers, and sets this key to be used for encryption.
+// Keys that were used before the key was rotated are added to the list of decryptionCiphers as well.
+// If the keys in the secret are wrapped by a key provider, they are unwrapped first.
 func InitFromSecret(clientset kubernetes.Interface, namespace string) error {
-	sec, err := clientset.CoreV1().Secrets(namespace).Get(context.Background(), "kotsadm-encryption", metav1.GetOptions{})
+	sec, err := clientset.CoreV1().Secrets(namespace).Get(context.Background(), EncryptionSecretName, metav1.GetOptions{})
 	if err != nil {
 		return errors.Wrap(err, "get kotsadm-encryption secret")
 	}
 
-	secData, ok := sec.Data["encryptionKey"]
-	if !ok {
-		return fmt.Errorf("kotsadm-encryption secret in %s does not have member encryptionKey", namespace)
+	currentKey, previousKeys, err := KeysFromSecret(sec)
+	if err != nil {
+		return errors.Wrap(err, "read kotsadm-encryption secret")
 	}
 
-	secCipher, err := aesCipherFromString(string(secData))
+	secCipher, err := aesCipherFromString(currentKey)
 	if err != nil {
 		return errors.Wrap(err, "parse kotsadm-encryption secret")
 	}
 
+	previousCiphers := []*aesCipher{}
+	for _, previousKey := range previousKeys {
+		previousCipher, err := aesCipherFromString(previousKey)
+		if err != nil {
+			return errors.Wrap(err, "parse previous key in kotsadm-encryption secret")
+		}
+		previousCiphers = append(previousCiphers, previousCipher)
+	}
+
+	cipherMutex.Lock()
+	defer cipherMutex.Unlock()
+
+	for _, previousCipher := range previousCiphers {
+		addCipher(previousCipher)
+	}
 	addCipher(secCipher)
 	encryptionCipher = secCipher
 
 	return nil
 }
 
+// SplitKeys returns the keys in a list of keys with one key per line
+func SplitKeys(data string) []string {
+	keys := []string{}
+	for _, key := range strings.Split(data, "\n") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
 // InitFromString parses the encryption key from the provided string and adds it to the list of decryptionCiphers
 func InitFromString(data string) error {
 	if data == "" {
@@ -73,11 +140,15 @@ func InitFromString(data string) error {
 	if err != nil {
 		return err
 	}
+
+	cipherMutex.Lock()
+	defer cipherMutex.Unlock()
+
 	addCipher(newCipher)
 	return nil
 }
 
-// check if a cipher exists in the array, if it does not then add it
+// check if a cipher exists in the array, if it does not then add it. cipherMutex must be held.
 func addCipher(aesCipher *aesCipher) {
 	foundMatch := false
 	for _, existingCipher := range decryptionCiphers {
@@ -93,39 +164,84 @@ func addCipher(aesCipher *aesCipher) {
 
 // NewAESCipher creates a new AES cipher to be used for encryption and decryption. If one already exists, it is used instead.
 func NewAESCipher() error {
+	cipherMutex.Lock()
+	defer cipherMutex.Unlock()
+
+	return newAESCipher()
+}
+
+func newAESCipher() error {
 	if encryptionCipher != nil && len(decryptionCiphers) >= 1 {
 		return nil
 	}
 
+	newCipher, err := generateAESCipher()
+	if err != nil {
+		return err
+	}
+
+	addCipher(newCipher)
+	encryptionCipher = newCipher
+	return nil
+}
+
+// GenerateKey returns the string representation of a new random key without loading it
+func GenerateKey() (string, error) {
+	newCipher, err := generateAESCipher()
+	if err != nil {
+		return "", err
+	}
+	return newCipher.toString(), nil
+}
+
+// SetEncryptionKey parses the encryption key from the provided string, adds it to the list of decryptionCiphers
+// and sets it to be used for encryption. Previously loaded keys can still be used for decryption.
+func SetEncryptionKey(data string) error {
+	newCipher, err := aesCipherFromString(data)
+	if err != nil {
+		return err
+	}
+
+	cipherMutex.Lock()
+	defer cipherMutex.Unlock()
+
+	addCipher(newCipher)
+	encryptionCipher = newCipher
+	return nil
+}
+
+func generateAESCipher() (*aesCipher, error) {
 	key := make([]byte, keyLength)
 	if _, err := rand.Read(key); err != nil {
-		return errors.Wrap(err, "failed to read key")
+		return nil, errors.Wrap(err, "failed to read key")
 	}
 
 	block, err := aes.NewCipher(key)
 	if err != nil {
-		return errors.Wrap(err, "failed to create new cipher")
+		return nil, errors.Wrap(err, "failed to create new cipher")
 	}
 
 	gcm, err := cipher.NewGCM(block)
 	if err != nil {
-		return errors.Wrap(err, "failed to wrap cipher gcm")
+		return nil, errors.Wrap(err, "failed to wrap cipher gcm")
 	}
 
 	nonce := make([]byte, gcm.NonceSize())
 	if _, err := rand.Read(nonce); err != nil {
-		return errors.Wrap(err, "failed to read nonce")
+		return nil, errors.Wrap(err, "failed to read nonce")
 	}
 
-	newCipher := &aesCipher{
+	return &aesCipher{
 		key:    key,
 		cipher: gcm,
 		nonce:  nonce,
-	}
+		id:     keyID(key),
+	}, nil
+}
 
-	addCipher(newCipher)
-	encryptionCipher = newCipher
-	return nil
+func keyID(key []byte) []byte {
+	sum := sha256.Sum256(key)
+	return sum[:keyIDLength]
 }
 
 func aesCipherFromString(data string) (newCipher *aesCipher, initErr error) {
@@ -169,6 +285,7 @@ func aesCipherFromString(data string) (newCipher *aesCipher, initErr error) {
 		key:    key,
 		cipher: gcm,
 		nonce:  decoded[keyLength:],
+		id:     keyID(key),
 	}
 
 	return
@@ -176,10 +293,47 @@ func aesCipherFromString(data string) (newCipher *aesCipher, initErr error) {
 
 // ToString returns a string representation of the global encryption key
 func ToString() string {
+	cipherMutex.RLock()
+	defer cipherMutex.RUnlock()
+
 	if encryptionCipher == nil {
 		return ""
 	}
-	return base64.StdEncoding.EncodeToString(append(encryptionCipher.key, encryptionCipher.nonce...))
+	return encryptionCipher.toString()
+}
+
+// KeyID returns the id of the global encryption key that is included in encrypted data
+func KeyID() string {
+	cipherMutex.RLock()
+	defer cipherMutex.RUnlock()
+
+	if encryptionCipher == nil {
+		return ""
+	}
+	return hex.EncodeToString(encryptionCipher.id)
+}
+
+func (c *aesCipher) toString() string {
+	return base64.StdEncoding.EncodeToString(append(append([]byte{}, c.key...), c.nonce...))
+}
+
+func (c *aesCipher) envelopeHeader() []byte {
+	header := append([]byte{}, envelopeMagic...)
+	header = append(header, envelopeVersion1)
+	return append(header, c.id...)
+}
+
+func (c *aesCipher) encrypt(in []byte) []byte {
+	nonce := make([]byte, c.cipher.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		panic(errors.Wrap(err, "failed to read nonce"))
+	}
+
+	header := c.envelopeHeader()
+	out := make([]byte, 0, len(header)+len(nonce)+len(in)+c.cipher.Overhead())
+	out = append(out, header...)
+	out = append(out, nonce...)
+	return c.cipher.Seal(out, nonce, in, header)
 }
 
 func (c *aesCipher) decrypt(in []byte) (result []byte, err error) {
@@ -193,21 +347,104 @@ func (c *aesCipher) decrypt(in []byte) (result []byte, err error) {
 	return
 }
 
+func (c *aesCipher) decryptEnvelope(in []byte) (result []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("decrypt recovered from panic: %v", r)
+		}
+	}()
+
+	header := in[:len(envelopeMagic)+1+keyIDLength]
+	data := in[len(header):]
+	if len(data) < c.cipher.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+
+	result, err = c.cipher.Open(nil, data[:c.cipher.NonceSize()], data[c.cipher.NonceSize():], header)
+	return
+}
+
+// envelopeKeyID returns the id of the key that encrypted the data, or nil if the data is in the legacy format
+func envelopeKeyID(in []byte) []byte {
+	headerLength := len(envelopeMagic) + 1 + keyIDLength
+	if len(in) < headerLength || !bytes.Equal(in[:len(envelopeMagic)], envelopeMagic) || in[len(envelopeMagic)] != envelopeVersion1 {
+		return nil
+	}
+	return in[len(envelopeMagic)+1 : headerLength]
+}
+
 // Encrypt encrypts the data with the registered encryption key
 func Encrypt(in []byte) []byte {
+	cipherMutex.Lock()
 	if encryptionCipher == nil {
-		_ = NewAESCipher()
+		_ = newAESCipher()
 	}
+	encryptCipher := encryptionCipher
+	cipherMutex.Unlock()
+
+	return encryptCipher.encrypt(in)
+}
+
+// SetKeyReloader sets the function that reloads the keys when data was encrypted with a key that is not loaded,
+// e.g. because the key was rotated by another replica
+func SetKeyReloader(reload func() error) {
+	keyReloadMutex.Lock()
+	defer keyReloadMutex.Unlock()
+
+	keyReloader = reload
+	lastKeyReload = time.Time{}
+}
+
+// reloadKeys reloads the keys at most once every minKeyReloadInterval, and returns whether they were reloaded
+func reloadKeys() bool {
+	keyReloadMutex.Lock()
+	defer keyReloadMutex.Unlock()
 
-	return encryptionCipher.cipher.Seal(nil, encryptionCipher.nonce, in, nil)
+	if keyReloader == nil || time.Since(lastKeyReload) < minKeyReloadInterval {
+		return false
+	}
+	lastKeyReload = time.Now()
+
+	if err := keyReloader(); err != nil {
+		return false
+	}
+	return true
+}
+
+// Decrypt attempts to decrypt the provided data with the key that encrypted it, and falls back to trying all registered keys
+// for data in the legacy format. If the key that encrypted the data is not loaded, the keys are reloaded once.
+func Decrypt(in []byte) ([]byte, error) {
+	result, err := decrypt(in)
+	if _, ok := err.(NoDecryptionKeyErr); ok && reloadKeys() {
+		return decrypt(in)
+	}
+	return result, err
 }
 
-// Decrypt attempts to decrypt the provided data with all registered keys
-func Decrypt(in []byte) (result []byte, err error) {
+func decrypt(in []byte) (result []byte, err error) {
+	cipherMutex.RLock()
+	defer cipherMutex.RUnlock()
+
 	if len(decryptionCiphers) == 0 {
 		return nil, NoDecryptionKeysErr{}
 	}
 
+	var envelopeErr error
+	if id := envelopeKeyID(in); id != nil {
+		envelopeErr = NoDecryptionKeyErr{KeyID: hex.EncodeToString(id)}
+		for _, decryptCipher := range decryptionCiphers {
+			if !bytes.Equal(decryptCipher.id, id) {
+				continue
+			}
+			result, envelopeErr = decryptCipher.decryptEnvelope(in)
+			if envelopeErr == nil {
+				return result, nil
+			}
+			break
+		}
+		// legacy data can start with the header by chance, so it is tried as well
+	}
+
 	for _, decryptCipher := range decryptionCiphers {
 		result, err = decryptCipher.decrypt(in)
 		if err != nil {
@@ -216,11 +453,31 @@ func Decrypt(in []byte) (result []byte, err error) {
 			return result, nil
 		}
 	}
+	if envelopeErr != nil {
+		return nil, envelopeErr
+	}
 	return nil, err
 }
 
+// ReEncrypt decrypts the data with any of the registered keys and encrypts it with the registered encryption key
+func ReEncrypt(in []byte) ([]byte, error) {
+	decrypted, err := Decrypt(in)
+	if err != nil {
+		return nil, err
+	}
+	return Encrypt(decrypted), nil
+}
+
 type NoDecryptionKeysErr struct{}
 
 func (e NoDecryptionKeysErr) Error() string {
 	return "no decryption ciphers loaded"
 }
+
+type NoDecryptionKeyErr struct {
+	KeyID string
+}
+
+func (e NoDecryptionKeyErr) Error() string {
+	return fmt.Sprintf("decryption key %s is not loaded", e.KeyID)
+}
@@ -2,6 +2,7 @@ package crypto
 
 import (
 	"encoding/base64"
+	"encoding/hex"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -69,10 +70,149 @@ func Test_General(t *testing.T) {
 
 	// ensure that after adding a new key, the original key is still used for encryption and decryption
 	testReEncrypted := Encrypt([]byte(testValue))
-	req.Equal(string(testEncrypted), string(testReEncrypted))
+	req.NotEqual(string(testEncrypted), string(testReEncrypted)) // every message gets a new nonce
+	req.Equal(envelopeKeyID(testEncrypted), envelopeKeyID(testReEncrypted))
 	testDecrypted, err = Decrypt(testEncrypted)
 	req.NoError(err)
 	req.Equal(testValue, string(testDecrypted))
+	testDecrypted, err = Decrypt(testReEncrypted)
+	req.NoError(err)
+	req.Equal(testValue, string(testDecrypted))
+}
+
+func Test_Envelope(t *testing.T) {
+	req := require.New(t)
+
+	encryptionCipher = nil
+	decryptionCiphers = nil
+
+	req.NoError(NewAESCipher())
+
+	encrypted := Encrypt([]byte("this is a test"))
+	req.Equal([]byte("kenc"), encrypted[:4])
+	req.Equal(envelopeVersion1, encrypted[4])
+	req.Equal(KeyID(), hex.EncodeToString(envelopeKeyID(encrypted)))
+
+	// the header is authenticated
+	tampered := append([]byte{}, encrypted...)
+	tampered[len(envelopeMagic)+1] ^= 0xff
+	_, err := Decrypt(tampered)
+	req.ErrorIs(err, NoDecryptionKeyErr{KeyID: hex.EncodeToString(envelopeKeyID(tampered))})
+
+	// data encrypted with a key that is not loaded cannot be decrypted
+	otherKey, err := GenerateKey()
+	req.NoError(err)
+	req.NoError(SetEncryptionKey(otherKey))
+	otherEncrypted := Encrypt([]byte("this is a test"))
+	encryptionCipher = nil
+	decryptionCiphers = nil
+	req.NoError(NewAESCipher())
+	_, err = Decrypt(otherEncrypted)
+	req.ErrorIs(err, NoDecryptionKeyErr{KeyID: hex.EncodeToString(envelopeKeyID(otherEncrypted))})
+}
+
+func Test_DecryptLegacy(t *testing.T) {
+	req := require.New(t)
+
+	encryptionCipher = nil
+	decryptionCiphers = nil
+
+	req.NoError(NewAESCipher())
+
+	// data written before the envelope format was introduced was sealed with the nonce of the key
+	legacyEncrypted := encryptionCipher.cipher.Seal(nil, encryptionCipher.nonce, []byte("this is a test"), nil)
+	decrypted, err := Decrypt(legacyEncrypted)
+	req.NoError(err)
+	req.Equal("this is a test", string(decrypted))
+
+	reEncrypted, err := ReEncrypt(legacyEncrypted)
+	req.NoError(err)
+	req.NotNil(envelopeKeyID(reEncrypted))
+	decrypted, err = Decrypt(reEncrypted)
+	req.NoError(err)
+	req.Equal("this is a test", string(decrypted))
+}
+
+func Test_RotateKey(t *testing.T) {
+	req := require.New(t)
+
+	encryptionCipher = nil
+	decryptionCiphers = nil
+
+	req.NoError(NewAESCipher())
+	oldKey := ToString()
+	oldKeyID := KeyID()
+	oldEncrypted := Encrypt([]byte("this is a test"))
+
+	newKey, err := GenerateKey()
+	req.NoError(err)
+	req.Equal(oldKey, ToString()) // generating a key does not load it
+
+	req.NoError(SetEncryptionKey(newKey))
+	req.Equal(newKey, ToString())
+	req.NotEqual(oldKeyID, KeyID())
+
+	// data encrypted with the old key can still be decrypted
+	decrypted, err := Decrypt(oldEncrypted)
+	req.NoError(err)
+	req.Equal("this is a test", string(decrypted))
+
+	// and is encrypted with the new key after re-encrypting it
+	reEncrypted, err := ReEncrypt(oldEncrypted)
+	req.NoError(err)
+	req.Equal(KeyID(), hex.EncodeToString(envelopeKeyID(reEncrypted)))
+
+	// the new key alone can decrypt the re-encrypted data
+	encryptionCipher = nil
+	decryptionCiphers = nil
+	req.NoError(InitFromString(newKey))
+	decrypted, err = Decrypt(reEncrypted)
+	req.NoError(err)
+	req.Equal("this is a test", string(decrypted))
+	_, err = Decrypt(oldEncrypted)
+	req.ErrorIs(err, NoDecryptionKeyErr{KeyID: oldKeyID})
+}
+
+func Test_DecryptReloadsKeys(t *testing.T) {
+	req := require.New(t)
+
+	encryptionCipher = nil
+	decryptionCiphers = nil
+	defer SetKeyReloader(nil)
+
+	req.NoError(NewAESCipher())
+	oldKey := ToString()
+
+	// another replica rotates the key and encrypts data with it
+	newKey, err := GenerateKey()
+	req.NoError(err)
+	req.NoError(SetEncryptionKey(newKey))
+	newEncrypted := Encrypt([]byte("this is a test"))
+
+	encryptionCipher = nil
+	decryptionCiphers = nil
+	req.NoError(SetEncryptionKey(oldKey))
+
+	reloads := 0
+	SetKeyReloader(func() error {
+		reloads++
+		return SetEncryptionKey(newKey)
+	})
+
+	decrypted, err := Decrypt(newEncrypted)
+	req.NoError(err)
+	req.Equal("this is a test", string(decrypted))
+	req.Equal(newKey, ToString())
+	req.Equal(1, reloads)
+
+	// the keys are not reloaded again right away for data encrypted with an unknown key
+	unknownKey, err := GenerateKey()
+	req.NoError(err)
+	unknownCipher, err := aesCipherFromString(unknownKey)
+	req.NoError(err)
+	_, err = Decrypt(unknownCipher.encrypt([]byte("this is a test")))
+	req.ErrorIs(err, NoDecryptionKeyErr{KeyID: hex.EncodeToString(unknownCipher.id)})
+	req.Equal(1, reloads)
 }
 
 func Test_NoDecrypt(t *testing.T) {
@@ -160,3 +300,56 @@ func Test_InitFromSecret(t *testing.T) {
 	req.NoError(err)
 	req.Equal(testString, string(decryptedData))
 }
+
+func Test_InitFromSecretWithPreviousKeys(t *testing.T) {
+	req := require.New(t)
+
+	encryptionCipher = nil
+	decryptionCiphers = nil
+
+	// encrypt data with two keys that were rotated out
+	req.NoError(NewAESCipher())
+	firstKey := ToString()
+	firstEncrypted := Encrypt([]byte("first"))
+
+	secondKey, err := GenerateKey()
+	req.NoError(err)
+	req.NoError(SetEncryptionKey(secondKey))
+	secondEncrypted := Encrypt([]byte("second"))
+
+	currentKey, err := GenerateKey()
+	req.NoError(err)
+
+	encryptionCipher = nil
+	decryptionCiphers = nil
+
+	clientset := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "kotsadm-encryption",
+				Namespace: "testns",
+			},
+			Data: map[string][]byte{
+				"encryptionKey":          []byte(currentKey),
+				"previousEncryptionKeys": []byte(firstKey + "\n" + secondKey + "\n"),
+			},
+		})
+
+	req.NoError(InitFromSecret(clientset, "testns"))
+	req.Equal(currentKey, ToString())
+
+	decrypted, err := Decrypt(firstEncrypted)
+	req.NoError(err)
+	req.Equal("first", string(decrypted))
+	decrypted, err = Decrypt(secondEncrypted)
+	req.NoError(err)
+	req.Equal("second", string(decrypted))
+}
+
+func Test_SplitKeys(t *testing.T) {
+	req := require.New(t)
+
+	req.Equal([]string{}, SplitKeys(""))
+	req.Equal([]string{"a"}, SplitKeys("a"))
+	req.Equal([]string{"a", "b"}, SplitKeys("a\n\n b \n"))
+}
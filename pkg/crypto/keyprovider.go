@@ -0,0 +1,243 @@
+package crypto
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	KeyProviderVault  = "vault"
+	KeyProviderAWSKMS = "aws-kms"
+	KeyProviderFile   = "file"
+)
+
+const (
+	// KeyProviderSecretKey is the member of the encryption secret that holds the key provider config.
+	// When it is set, the keys in the secret are wrapped by the key provider and are not stored in plain text.
+	KeyProviderSecretKey                   = "keyProvider"
+	WrappedEncryptionKeySecretKey          = "encryptionKeyWrapped"
+	WrappedPreviousEncryptionKeysSecretKey = "previousEncryptionKeysWrapped"
+)
+
+// KeyProvider wraps the encryption key with a key encryption key that is held outside of the cluster
+type KeyProvider interface {
+	WrapKey(key []byte) ([]byte, error)
+	UnwrapKey(wrapped []byte) ([]byte, error)
+}
+
+// KeyProviderConfig is stored in the encryption secret, credentials are not included.
+// Kotsadm authenticates to Vault with its service account using the Vault Kubernetes auth method,
+// and to AWS KMS with the default credential chain, e.g. IAM roles for service accounts or instance credentials.
+type KeyProviderConfig struct {
+	Type string `json:"type"`
+
+	VaultAddress      string `json:"vaultAddress,omitempty"`
+	VaultNamespace    string `json:"vaultNamespace,omitempty"`
+	VaultTransitMount string `json:"vaultTransitMount,omitempty"`
+	VaultTransitKey   string `json:"vaultTransitKey,omitempty"`
+	VaultAuthMount    string `json:"vaultAuthMount,omitempty"`
+	VaultRole         string `json:"vaultRole,omitempty"`
+	// VaultToken is only used by the kots cli to wrap the key at install time, it is never stored
+	VaultToken string `json:"-"`
+
+	AWSKMSKeyID string `json:"awsKmsKeyId,omitempty"`
+	AWSRegion   string `json:"awsRegion,omitempty"`
+
+	KeyFile string `json:"keyFile,omitempty"`
+	// KeyFileSecretProviderClass is the SecretProviderClass of the Secrets Store CSI volume that the key file is mounted from
+	KeyFileSecretProviderClass string `json:"keyFileSecretProviderClass,omitempty"`
+}
+
+func (c KeyProviderConfig) Validate() error {
+	switch c.Type {
+	case KeyProviderVault:
+		if c.VaultAddress == "" {
+			return errors.New("vault address is required")
+		}
+		if c.VaultTransitKey == "" {
+			return errors.New("vault transit key is required")
+		}
+		if c.VaultRole == "" {
+			return errors.New("vault kubernetes auth role is required")
+		}
+	case KeyProviderAWSKMS:
+		if c.AWSKMSKeyID == "" {
+			return errors.New("aws kms key id is required")
+		}
+	case KeyProviderFile:
+		if c.KeyFile == "" {
+			return errors.New("key file is required")
+		}
+		if c.KeyFileSecretProviderClass == "" {
+			return errors.New("secret provider class of the key file is required")
+		}
+	default:
+		return errors.Errorf("unknown key provider %q", c.Type)
+	}
+	return nil
+}
+
+// NewKeyProvider returns the key provider for the config
+func NewKeyProvider(config KeyProviderConfig) (KeyProvider, error) {
+	if err := config.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid key provider config")
+	}
+
+	switch config.Type {
+	case KeyProviderVault:
+		return newVaultKeyProvider(config), nil
+	case KeyProviderAWSKMS:
+		return newAWSKMSKeyProvider(config)
+	case KeyProviderFile:
+		kek, err := os.ReadFile(config.KeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read key file")
+		}
+		return NewFileKeyProvider(kek)
+	}
+
+	return nil, errors.Errorf("unknown key provider %q", config.Type)
+}
+
+// KeyProviderConfigFromSecret returns the key provider config of the encryption secret, or nil if the keys in the secret are not wrapped
+func KeyProviderConfigFromSecret(secret *corev1.Secret) (*KeyProviderConfig, error) {
+	data, ok := secret.Data[KeyProviderSecretKey]
+	if !ok || len(data) == 0 {
+		return nil, nil
+	}
+
+	config := KeyProviderConfig{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal key provider config")
+	}
+
+	return &config, nil
+}
+
+// KeysFromSecret returns the current and previous keys in the encryption secret, unwrapping them if a key provider is configured
+func KeysFromSecret(secret *corev1.Secret) (string, []string, error) {
+	config, err := KeyProviderConfigFromSecret(secret)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if config == nil {
+		current, ok := secret.Data[EncryptionKeySecretKey]
+		if !ok {
+			return "", nil, fmt.Errorf("kotsadm-encryption secret in %s does not have member encryptionKey", secret.Namespace)
+		}
+		return string(current), SplitKeys(string(secret.Data[PreviousEncryptionKeysSecretKey])), nil
+	}
+
+	provider, err := NewKeyProvider(*config)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "failed to create %s key provider", config.Type)
+	}
+
+	wrappedCurrent, ok := secret.Data[WrappedEncryptionKeySecretKey]
+	if !ok {
+		return "", nil, fmt.Errorf("kotsadm-encryption secret in %s does not have member %s", secret.Namespace, WrappedEncryptionKeySecretKey)
+	}
+	current, err := unwrapKey(provider, string(wrappedCurrent))
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to unwrap encryption key")
+	}
+
+	previous := []string{}
+	for _, wrapped := range SplitKeys(string(secret.Data[WrappedPreviousEncryptionKeysSecretKey])) {
+		key, err := unwrapKey(provider, wrapped)
+		if err != nil {
+			return "", nil, errors.Wrap(err, "failed to unwrap previous encryption key")
+		}
+		previous = append(previous, key)
+	}
+
+	return current, previous, nil
+}
+
+// SetKeysInSecret stores the current and previous keys in the encryption secret, wrapping them if a key provider is configured
+func SetKeysInSecret(secret *corev1.Secret, current string, previous []string) error {
+	config, err := KeyProviderConfigFromSecret(secret)
+	if err != nil {
+		return err
+	}
+
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+
+	if config == nil {
+		secret.Data[EncryptionKeySecretKey] = []byte(current)
+		secret.Data[PreviousEncryptionKeysSecretKey] = []byte(joinKeys(previous))
+		return nil
+	}
+
+	provider, err := NewKeyProvider(*config)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s key provider", config.Type)
+	}
+
+	return WrapKeysInSecret(secret, provider, *config, current, previous)
+}
+
+// WrapKeysInSecret wraps the current and previous keys with the key provider and stores them in the encryption secret together with
+// the key provider config. Keys that are stored in plain text are removed from the secret.
+func WrapKeysInSecret(secret *corev1.Secret, provider KeyProvider, config KeyProviderConfig, current string, previous []string) error {
+	configData, err := json.Marshal(config)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal key provider config")
+	}
+
+	wrappedCurrent, err := provider.WrapKey([]byte(current))
+	if err != nil {
+		return errors.Wrap(err, "failed to wrap encryption key")
+	}
+
+	wrappedPrevious := []string{}
+	for _, key := range previous {
+		wrapped, err := provider.WrapKey([]byte(key))
+		if err != nil {
+			return errors.Wrap(err, "failed to wrap previous encryption key")
+		}
+		wrappedPrevious = append(wrappedPrevious, base64.StdEncoding.EncodeToString(wrapped))
+	}
+
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	delete(secret.Data, EncryptionKeySecretKey)
+	delete(secret.Data, PreviousEncryptionKeysSecretKey)
+
+	secret.Data[KeyProviderSecretKey] = configData
+	secret.Data[WrappedEncryptionKeySecretKey] = []byte(base64.StdEncoding.EncodeToString(wrappedCurrent))
+	secret.Data[WrappedPreviousEncryptionKeysSecretKey] = []byte(joinKeys(wrappedPrevious))
+
+	return nil
+}
+
+func unwrapKey(provider KeyProvider, wrapped string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to decode wrapped key")
+	}
+	key, err := provider.UnwrapKey(decoded)
+	if err != nil {
+		return "", err
+	}
+	return string(key), nil
+}
+
+func joinKeys(keys []string) string {
+	var b strings.Builder
+	for _, key := range keys {
+		b.WriteString(key)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
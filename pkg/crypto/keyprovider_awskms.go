@@ -0,0 +1,64 @@
+package crypto
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/pkg/errors"
+)
+
+// the encryption context binds wrapped keys to the admin console, they can only be unwrapped with the same context
+var awsKMSEncryptionContext = map[string]*string{
+	"kots.io/secret": aws.String(EncryptionSecretName),
+}
+
+// awsKMSKeyProvider wraps keys with an AWS KMS key. Credentials are read from the default credential chain,
+// e.g. from IAM roles for service accounts.
+type awsKMSKeyProvider struct {
+	keyID  string
+	client kmsiface.KMSAPI
+}
+
+func newAWSKMSKeyProvider(config KeyProviderConfig) (*awsKMSKeyProvider, error) {
+	awsConfig := aws.NewConfig()
+	if config.AWSRegion != "" {
+		awsConfig = awsConfig.WithRegion(config.AWSRegion)
+	}
+
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create aws session")
+	}
+
+	return &awsKMSKeyProvider{
+		keyID:  config.AWSKMSKeyID,
+		client: kms.New(sess),
+	}, nil
+}
+
+func (p *awsKMSKeyProvider) WrapKey(key []byte) ([]byte, error) {
+	output, err := p.client.Encrypt(&kms.EncryptInput{
+		KeyId:             aws.String(p.keyID),
+		Plaintext:         key,
+		EncryptionContext: awsKMSEncryptionContext,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encrypt with aws kms")
+	}
+
+	return output.CiphertextBlob, nil
+}
+
+func (p *awsKMSKeyProvider) UnwrapKey(wrapped []byte) ([]byte, error) {
+	output, err := p.client.Decrypt(&kms.DecryptInput{
+		KeyId:             aws.String(p.keyID),
+		CiphertextBlob:    wrapped,
+		EncryptionContext: awsKMSEncryptionContext,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decrypt with aws kms")
+	}
+
+	return output.Plaintext, nil
+}
@@ -0,0 +1,65 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+
+	"github.com/pkg/errors"
+)
+
+const fileKeyLength = 32 // 256 bit
+
+// fileKeyProvider is a software key provider that wraps keys with a key encryption key read from a file,
+// e.g. a file mounted from a CSI secrets store volume
+type fileKeyProvider struct {
+	cipher cipher.AEAD
+}
+
+// NewFileKeyProvider returns a key provider for a key encryption key of 32 bytes, either raw or base64 encoded
+func NewFileKeyProvider(kek []byte) (KeyProvider, error) {
+	if len(kek) != fileKeyLength {
+		decoded, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(kek)))
+		if err != nil || len(decoded) != fileKeyLength {
+			return nil, errors.Errorf("key encryption key must be %d bytes, raw or base64 encoded", fileKeyLength)
+		}
+		kek = decoded
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create cipher")
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to wrap cipher gcm")
+	}
+
+	return &fileKeyProvider{cipher: gcm}, nil
+}
+
+func (p *fileKeyProvider) WrapKey(key []byte) ([]byte, error) {
+	nonce := make([]byte, p.cipher.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, errors.Wrap(err, "failed to read nonce")
+	}
+
+	return p.cipher.Seal(nonce, nonce, key, nil), nil
+}
+
+func (p *fileKeyProvider) UnwrapKey(wrapped []byte) ([]byte, error) {
+	if len(wrapped) < p.cipher.NonceSize() {
+		return nil, errors.New("wrapped key is too short")
+	}
+
+	nonce := wrapped[:p.cipher.NonceSize()]
+	key, err := p.cipher.Open(nil, nonce, wrapped[p.cipher.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unwrap key")
+	}
+
+	return key, nil
+}
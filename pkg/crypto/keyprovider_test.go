@@ -0,0 +1,424 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// vaultStub is a minimal implementation of the Vault Kubernetes auth method and of the encrypt and decrypt endpoints
+// of the transit secrets engine
+type vaultStub struct {
+	mu     sync.Mutex
+	token  string
+	logins int
+}
+
+func (s *vaultStub) setToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = token
+}
+
+func (s *vaultStub) server() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if r.URL.Path == "/v1/auth/kubernetes/login" {
+			request := vaultLoginRequest{}
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Role != "kotsadm" || request.JWT != "service-account-jwt" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"errors":["invalid role or service account token"]}`))
+				return
+			}
+			s.logins++
+			w.Write([]byte(fmt.Sprintf(`{"auth":{"client_token":%q}}`, s.token)))
+			return
+		}
+
+		if r.Header.Get("X-Vault-Token") != s.token {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+
+		request := vaultTransitRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		response := vaultTransitResponse{}
+		switch r.URL.Path {
+		case "/v1/transit/encrypt/kotsadm":
+			response.Data.Ciphertext = "vault:v1:" + request.Plaintext
+		case "/v1/transit/decrypt/kotsadm":
+			response.Data.Plaintext = strings.TrimPrefix(request.Ciphertext, "vault:v1:")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+			return
+		}
+
+		json.NewEncoder(w).Encode(response)
+	}))
+}
+
+// setVaultServiceAccountToken points the vault key provider to a service account token file for the test
+func setVaultServiceAccountToken(t *testing.T, jwt string) {
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenPath, []byte(jwt+"\n"), 0600))
+
+	previous := vaultServiceAccountTokenPath
+	vaultServiceAccountTokenPath = tokenPath
+	t.Cleanup(func() {
+		vaultServiceAccountTokenPath = previous
+	})
+}
+
+func Test_vaultKeyProvider(t *testing.T) {
+	req := require.New(t)
+
+	stub := &vaultStub{token: "s.token"}
+	server := stub.server()
+	defer server.Close()
+
+	// the token passed by the kots cli at install time
+	provider, err := NewKeyProvider(KeyProviderConfig{
+		Type:            KeyProviderVault,
+		VaultAddress:    server.URL,
+		VaultTransitKey: "kotsadm",
+		VaultRole:       "kotsadm",
+		VaultToken:      "s.token",
+	})
+	req.NoError(err)
+
+	wrapped, err := provider.WrapKey([]byte("encryption-key"))
+	req.NoError(err)
+	req.True(strings.HasPrefix(string(wrapped), "vault:v1:"))
+
+	key, err := provider.UnwrapKey(wrapped)
+	req.NoError(err)
+	req.Equal("encryption-key", string(key))
+	req.Equal(0, stub.logins)
+
+	// a token that is not accepted by vault
+	provider, err = NewKeyProvider(KeyProviderConfig{
+		Type:            KeyProviderVault,
+		VaultAddress:    server.URL,
+		VaultTransitKey: "kotsadm",
+		VaultRole:       "kotsadm",
+		VaultToken:      "s.other",
+	})
+	req.NoError(err)
+
+	_, err = provider.WrapKey([]byte("encryption-key"))
+	req.Error(err)
+	req.Contains(err.Error(), "permission denied")
+}
+
+func Test_vaultKeyProviderKubernetesAuth(t *testing.T) {
+	req := require.New(t)
+
+	stub := &vaultStub{token: "s.token"}
+	server := stub.server()
+	defer server.Close()
+
+	setVaultServiceAccountToken(t, "service-account-jwt")
+
+	provider, err := NewKeyProvider(KeyProviderConfig{
+		Type:            KeyProviderVault,
+		VaultAddress:    server.URL,
+		VaultTransitKey: "kotsadm",
+		VaultRole:       "kotsadm",
+	})
+	req.NoError(err)
+
+	wrapped, err := provider.WrapKey([]byte("encryption-key"))
+	req.NoError(err)
+
+	key, err := provider.UnwrapKey(wrapped)
+	req.NoError(err)
+	req.Equal("encryption-key", string(key))
+	req.Equal(1, stub.logins)
+
+	// the token expired, the provider logs in again
+	stub.setToken("s.renewed")
+	key, err = provider.UnwrapKey(wrapped)
+	req.NoError(err)
+	req.Equal("encryption-key", string(key))
+	req.Equal(2, stub.logins)
+
+	// a role that is not bound to the service account
+	provider, err = NewKeyProvider(KeyProviderConfig{
+		Type:            KeyProviderVault,
+		VaultAddress:    server.URL,
+		VaultTransitKey: "kotsadm",
+		VaultRole:       "other",
+	})
+	req.NoError(err)
+
+	_, err = provider.WrapKey([]byte("encryption-key"))
+	req.Error(err)
+	req.Contains(err.Error(), "invalid role or service account token")
+}
+
+type awsKMSStub struct {
+	kmsiface.KMSAPI
+	kek []byte
+}
+
+func (s *awsKMSStub) Encrypt(input *kms.EncryptInput) (*kms.EncryptOutput, error) {
+	if aws.StringValue(input.EncryptionContext["kots.io/secret"]) != EncryptionSecretName {
+		return nil, errors.New("invalid encryption context")
+	}
+	return &kms.EncryptOutput{CiphertextBlob: append(append([]byte{}, s.kek...), input.Plaintext...)}, nil
+}
+
+func (s *awsKMSStub) Decrypt(input *kms.DecryptInput) (*kms.DecryptOutput, error) {
+	if aws.StringValue(input.EncryptionContext["kots.io/secret"]) != EncryptionSecretName {
+		return nil, errors.New("invalid encryption context")
+	}
+	if !bytes.HasPrefix(input.CiphertextBlob, s.kek) {
+		return nil, errors.New("invalid ciphertext")
+	}
+	return &kms.DecryptOutput{Plaintext: bytes.TrimPrefix(input.CiphertextBlob, s.kek)}, nil
+}
+
+func Test_awsKMSKeyProvider(t *testing.T) {
+	req := require.New(t)
+
+	provider := &awsKMSKeyProvider{
+		keyID:  "alias/kotsadm",
+		client: &awsKMSStub{kek: []byte("kek:")},
+	}
+
+	wrapped, err := provider.WrapKey([]byte("encryption-key"))
+	req.NoError(err)
+	req.NotEqual("encryption-key", string(wrapped))
+
+	key, err := provider.UnwrapKey(wrapped)
+	req.NoError(err)
+	req.Equal("encryption-key", string(key))
+
+	_, err = provider.UnwrapKey([]byte("encryption-key"))
+	req.Error(err)
+}
+
+func Test_fileKeyProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		kek     []byte
+		wantErr bool
+	}{
+		{
+			name: "raw",
+			kek:  bytes.Repeat([]byte("k"), 32),
+		},
+		{
+			name: "base64 with trailing newline",
+			kek:  []byte(base64.StdEncoding.EncodeToString(bytes.Repeat([]byte("k"), 32)) + "\n"),
+		},
+		{
+			name:    "too short",
+			kek:     []byte("short"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := require.New(t)
+
+			provider, err := NewFileKeyProvider(tt.kek)
+			if tt.wantErr {
+				req.Error(err)
+				return
+			}
+			req.NoError(err)
+
+			wrapped, err := provider.WrapKey([]byte("encryption-key"))
+			req.NoError(err)
+			req.NotContains(string(wrapped), "encryption-key")
+
+			key, err := provider.UnwrapKey(wrapped)
+			req.NoError(err)
+			req.Equal("encryption-key", string(key))
+
+			other, err := NewFileKeyProvider(bytes.Repeat([]byte("o"), 32))
+			req.NoError(err)
+			_, err = other.UnwrapKey(wrapped)
+			req.Error(err)
+		})
+	}
+}
+
+func Test_KeyProviderConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  KeyProviderConfig
+		wantErr bool
+	}{
+		{
+			name:   "vault",
+			config: KeyProviderConfig{Type: KeyProviderVault, VaultAddress: "https://vault:8200", VaultTransitKey: "kotsadm", VaultRole: "kotsadm"},
+		},
+		{
+			name:    "vault without kubernetes auth role",
+			config:  KeyProviderConfig{Type: KeyProviderVault, VaultAddress: "https://vault:8200", VaultTransitKey: "kotsadm", VaultToken: "s.token"},
+			wantErr: true,
+		},
+		{
+			name:   "aws kms",
+			config: KeyProviderConfig{Type: KeyProviderAWSKMS, AWSKMSKeyID: "alias/kotsadm"},
+		},
+		{
+			name:   "file",
+			config: KeyProviderConfig{Type: KeyProviderFile, KeyFile: "/etc/kotsadm/encryption-kek/key", KeyFileSecretProviderClass: "kotsadm-kek"},
+		},
+		{
+			name:    "file without key file",
+			config:  KeyProviderConfig{Type: KeyProviderFile, KeyFileSecretProviderClass: "kotsadm-kek"},
+			wantErr: true,
+		},
+		{
+			name:    "file without secret provider class",
+			config:  KeyProviderConfig{Type: KeyProviderFile, KeyFile: "/etc/kotsadm/encryption-kek/key"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown",
+			config:  KeyProviderConfig{Type: "gcp-kms"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_KeysFromSecret(t *testing.T) {
+	req := require.New(t)
+
+	// plain text keys
+	secret := &corev1.Secret{
+		Data: map[string][]byte{
+			"encryptionKey":          []byte("key-2"),
+			"previousEncryptionKeys": []byte("key-1\n"),
+		},
+	}
+	current, previous, err := KeysFromSecret(secret)
+	req.NoError(err)
+	req.Equal("key-2", current)
+	req.Equal([]string{"key-1"}, previous)
+
+	req.NoError(SetKeysInSecret(secret, "key-3", []string{"key-1", "key-2"}))
+	req.Equal("key-3", string(secret.Data["encryptionKey"]))
+	req.Equal("key-1\nkey-2\n", string(secret.Data["previousEncryptionKeys"]))
+
+	// keys wrapped by the file key provider
+	keyFile := filepath.Join(t.TempDir(), "key")
+	req.NoError(os.WriteFile(keyFile, bytes.Repeat([]byte("k"), 32), 0600))
+	config := KeyProviderConfig{Type: KeyProviderFile, KeyFile: keyFile, KeyFileSecretProviderClass: "kotsadm-kek"}
+	provider, err := NewKeyProvider(config)
+	req.NoError(err)
+
+	req.NoError(WrapKeysInSecret(secret, provider, config, "key-3", []string{"key-1", "key-2"}))
+	req.NotContains(secret.Data, "encryptionKey")
+	req.NotContains(secret.Data, "previousEncryptionKeys")
+
+	current, previous, err = KeysFromSecret(secret)
+	req.NoError(err)
+	req.Equal("key-3", current)
+	req.Equal([]string{"key-1", "key-2"}, previous)
+
+	req.NoError(SetKeysInSecret(secret, "key-4", []string{"key-1", "key-2", "key-3"}))
+	req.NotContains(secret.Data, "encryptionKey")
+
+	current, previous, err = KeysFromSecret(secret)
+	req.NoError(err)
+	req.Equal("key-4", current)
+	req.Equal([]string{"key-1", "key-2", "key-3"}, previous)
+}
+
+func Test_KeysFromSecretVault(t *testing.T) {
+	req := require.New(t)
+
+	stub := &vaultStub{token: "s.token"}
+	server := stub.server()
+	defer server.Close()
+
+	// the key is wrapped with the token of the kots cli at install time
+	config := KeyProviderConfig{
+		Type:            KeyProviderVault,
+		VaultAddress:    server.URL,
+		VaultTransitKey: "kotsadm",
+		VaultRole:       "kotsadm",
+		VaultToken:      "s.token",
+	}
+	provider, err := NewKeyProvider(config)
+	req.NoError(err)
+
+	secret := &corev1.Secret{}
+	req.NoError(WrapKeysInSecret(secret, provider, config, "key-1", nil))
+	for key, value := range secret.Data {
+		req.NotContains(string(value), "s.token", "secret member %s contains the vault token", key)
+	}
+
+	// and unwrapped by kotsadm with the kubernetes auth method
+	setVaultServiceAccountToken(t, "service-account-jwt")
+
+	current, previous, err := KeysFromSecret(secret)
+	req.NoError(err)
+	req.Equal("key-1", current)
+	req.Empty(previous)
+	req.Equal(1, stub.logins)
+}
+
+func Test_InitFromSecretWrapped(t *testing.T) {
+	req := require.New(t)
+
+	key, err := GenerateKey()
+	req.NoError(err)
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	req.NoError(os.WriteFile(keyFile, bytes.Repeat([]byte("k"), 32), 0600))
+	config := KeyProviderConfig{Type: KeyProviderFile, KeyFile: keyFile, KeyFileSecretProviderClass: "kotsadm-kek"}
+	provider, err := NewKeyProvider(config)
+	req.NoError(err)
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      EncryptionSecretName,
+			Namespace: "default",
+		},
+	}
+	req.NoError(WrapKeysInSecret(secret, provider, config, key, nil))
+
+	req.NoError(InitFromSecret(fake.NewSimpleClientset(secret), "default"))
+	req.Equal(key, ToString())
+}
@@ -0,0 +1,235 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultVaultTransitMount = "transit"
+	defaultVaultAuthMount    = "kubernetes"
+)
+
+// vaultServiceAccountTokenPath is the token of the kotsadm service account that is used to log in with the Vault Kubernetes auth method
+var vaultServiceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// vaultKeyProvider wraps keys with the encrypt and decrypt endpoints of the Vault transit secrets engine.
+// When no token is configured, a token is requested from the Vault Kubernetes auth method with the service account
+// token of the pod, so no Vault credentials have to be stored in the cluster.
+type vaultKeyProvider struct {
+	address   string
+	namespace string
+	mount     string
+	key       string
+	authMount string
+	role      string
+	client    *http.Client
+
+	// staticToken is set by the kots cli at install time
+	staticToken string
+
+	mu    sync.Mutex
+	token string
+}
+
+type vaultLoginRequest struct {
+	Role string `json:"role"`
+	JWT  string `json:"jwt"`
+}
+
+type vaultLoginResponse struct {
+	Auth struct {
+		ClientToken string `json:"client_token"`
+	} `json:"auth"`
+}
+
+// vaultStatusError is returned when vault responds with an unexpected status code
+type vaultStatusError struct {
+	StatusCode int
+	Errors     []string
+}
+
+func (e *vaultStatusError) Error() string {
+	if len(e.Errors) > 0 {
+		return fmt.Sprintf("unexpected status code %d: %s", e.StatusCode, strings.Join(e.Errors, ", "))
+	}
+	return fmt.Sprintf("unexpected status code %d", e.StatusCode)
+}
+
+type vaultTransitRequest struct {
+	Plaintext  string `json:"plaintext,omitempty"`
+	Ciphertext string `json:"ciphertext,omitempty"`
+}
+
+type vaultTransitResponse struct {
+	Data struct {
+		Plaintext  string `json:"plaintext"`
+		Ciphertext string `json:"ciphertext"`
+	} `json:"data"`
+}
+
+func newVaultKeyProvider(config KeyProviderConfig) *vaultKeyProvider {
+	mount := strings.Trim(config.VaultTransitMount, "/")
+	if mount == "" {
+		mount = defaultVaultTransitMount
+	}
+
+	authMount := strings.Trim(config.VaultAuthMount, "/")
+	if authMount == "" {
+		authMount = defaultVaultAuthMount
+	}
+
+	return &vaultKeyProvider{
+		address:     strings.TrimSuffix(config.VaultAddress, "/"),
+		namespace:   config.VaultNamespace,
+		mount:       mount,
+		key:         config.VaultTransitKey,
+		authMount:   authMount,
+		role:        config.VaultRole,
+		client:      &http.Client{Timeout: 30 * time.Second},
+		staticToken: config.VaultToken,
+	}
+}
+
+func (p *vaultKeyProvider) WrapKey(key []byte) ([]byte, error) {
+	response, err := p.do("encrypt", vaultTransitRequest{
+		Plaintext: base64.StdEncoding.EncodeToString(key),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encrypt with vault")
+	}
+	if response.Data.Ciphertext == "" {
+		return nil, errors.New("vault did not return a ciphertext")
+	}
+
+	return []byte(response.Data.Ciphertext), nil
+}
+
+func (p *vaultKeyProvider) UnwrapKey(wrapped []byte) ([]byte, error) {
+	response, err := p.do("decrypt", vaultTransitRequest{
+		Ciphertext: string(wrapped),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decrypt with vault")
+	}
+
+	key, err := base64.StdEncoding.DecodeString(response.Data.Plaintext)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode plaintext")
+	}
+
+	return key, nil
+}
+
+// do calls the transit secrets engine. A token from the Kubernetes auth method is requested again once if it was rejected,
+// e.g. because it expired.
+func (p *vaultKeyProvider) do(operation string, request vaultTransitRequest) (*vaultTransitResponse, error) {
+	url := fmt.Sprintf("%s/v1/%s/%s/%s", p.address, p.mount, operation, p.key)
+
+	for attempt := 0; ; attempt++ {
+		token, err := p.getToken(attempt > 0)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get vault token")
+		}
+
+		response := vaultTransitResponse{}
+		err = p.post(url, token, request, &response)
+		if err == nil {
+			return &response, nil
+		}
+
+		var statusErr *vaultStatusError
+		if p.staticToken == "" && attempt == 0 && errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusForbidden {
+			continue
+		}
+		return nil, err
+	}
+}
+
+// getToken returns the token used to access the transit secrets engine, logging in with the Kubernetes auth method if needed
+func (p *vaultKeyProvider) getToken(renew bool) (string, error) {
+	if p.staticToken != "" {
+		return p.staticToken, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token != "" && !renew {
+		return p.token, nil
+	}
+
+	jwt, err := os.ReadFile(vaultServiceAccountTokenPath)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read service account token")
+	}
+
+	response := vaultLoginResponse{}
+	url := fmt.Sprintf("%s/v1/auth/%s/login", p.address, p.authMount)
+	if err := p.post(url, "", vaultLoginRequest{Role: p.role, JWT: strings.TrimSpace(string(jwt))}, &response); err != nil {
+		return "", errors.Wrap(err, "failed to log in with kubernetes auth")
+	}
+	if response.Auth.ClientToken == "" {
+		return "", errors.New("vault did not return a client token")
+	}
+
+	p.token = response.Auth.ClientToken
+	return p.token, nil
+}
+
+func (p *vaultKeyProvider) post(url string, token string, request interface{}, response interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal request")
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if p.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.namespace)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to execute request")
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read response body")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		statusErr := &vaultStatusError{StatusCode: resp.StatusCode}
+		vaultErrors := struct {
+			Errors []string `json:"errors"`
+		}{}
+		if err := json.Unmarshal(b, &vaultErrors); err == nil {
+			statusErr.Errors = vaultErrors.Errors
+		}
+		return statusErr
+	}
+
+	if err := json.Unmarshal(b, response); err != nil {
+		return errors.Wrap(err, "failed to unmarshal response")
+	}
+
+	return nil
+}
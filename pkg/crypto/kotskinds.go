@@ -0,0 +1,34 @@
+package crypto
+
+import (
+	"github.com/pkg/errors"
+	kotskindscrypto "github.com/replicatedhq/kotskinds/pkg/crypto"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// SetKotsKindsKeys loads the keys into the kotskinds crypto package, which encrypts identity config values, and sets the current key
+// to be used for encryption. That package can only set the encryption key from a secret, so the key is passed in an in-memory secret.
+func SetKotsKindsKeys(currentKey string, previousKeys []string) error {
+	for _, key := range previousKeys {
+		if err := kotskindscrypto.InitFromString(key); err != nil {
+			return errors.Wrap(err, "failed to load previous key")
+		}
+	}
+
+	clientset := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      EncryptionSecretName,
+			Namespace: metav1.NamespaceDefault,
+		},
+		Data: map[string][]byte{
+			EncryptionKeySecretKey: []byte(currentKey),
+		},
+	})
+	if err := kotskindscrypto.InitFromSecret(clientset, metav1.NamespaceDefault); err != nil {
+		return errors.Wrap(err, "failed to set encryption key")
+	}
+
+	return nil
+}
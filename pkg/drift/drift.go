@@ -0,0 +1,244 @@
+package drift
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	apptypes "github.com/replicatedhq/kots/pkg/app/types"
+	"github.com/replicatedhq/kots/pkg/apparchive"
+	"github.com/replicatedhq/kots/pkg/drift/types"
+	"github.com/replicatedhq/kots/pkg/k8sutil"
+	"github.com/replicatedhq/kots/pkg/kotsutil"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/metrics"
+	"github.com/replicatedhq/kots/pkg/operator/applier"
+	"github.com/replicatedhq/kots/pkg/store"
+	storetypes "github.com/replicatedhq/kots/pkg/store/types"
+	"github.com/replicatedhq/kots/pkg/util"
+	"github.com/replicatedhq/kots/pkg/version"
+	cron "github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+const (
+	// IntervalEnv is how often drift is checked, as a duration. Set it to 0 to disable periodic checks.
+	IntervalEnv = "KOTSADM_DRIFT_DETECTION_INTERVAL"
+	// AutoRemediateEnv redeploys the current version of an app when drift is detected if set to true
+	AutoRemediateEnv = "KOTSADM_DRIFT_AUTO_REMEDIATE"
+
+	defaultInterval = 15 * time.Minute
+)
+
+// ErrNotDeployed is returned when an app does not have a deployed version to compare against
+var ErrNotDeployed = errors.New("app does not have a deployed version")
+
+// Start periodically checks all installed apps for drift. It runs on the kotsadm leader only.
+func Start() error {
+	interval, err := getInterval()
+	if err != nil {
+		return errors.Wrap(err, "failed to get drift detection interval")
+	}
+	if interval == 0 {
+		logger.Info("drift detection is disabled")
+		return nil
+	}
+
+	logger.Debug("starting drift detection cron job", zap.Duration("interval", interval))
+
+	cronJob := cron.New(cron.WithChain(
+		cron.Recover(cron.DefaultLogger),
+		cron.SkipIfStillRunning(cron.DefaultLogger),
+	))
+
+	_, err = cronJob.AddFunc(fmt.Sprintf("@every %s", interval), func() {
+		logger.Debug("running drift detection job")
+		if err := checkInstalledApps(); err != nil {
+			logger.Error(errors.Wrap(err, "failed to check apps for drift"))
+		}
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to add cron job")
+	}
+	cronJob.Start()
+	return nil
+}
+
+func getInterval() (time.Duration, error) {
+	value := os.Getenv(IntervalEnv)
+	if value == "" {
+		return defaultInterval, nil
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to parse %s", IntervalEnv)
+	}
+	if interval < 0 {
+		return 0, errors.Errorf("%s must not be negative", IntervalEnv)
+	}
+	return interval, nil
+}
+
+func autoRemediate() bool {
+	autoRemediate, _ := strconv.ParseBool(os.Getenv(AutoRemediateEnv))
+	return autoRemediate
+}
+
+func checkInstalledApps() error {
+	apps, err := store.GetStore().ListInstalledApps()
+	if err != nil {
+		return errors.Wrap(err, "failed to list installed apps")
+	}
+
+	for _, a := range apps {
+		if a.IsGitOps || a.RestoreInProgressName != "" {
+			continue
+		}
+
+		report, err := Detect(a.ID)
+		if errors.Cause(err) == ErrNotDeployed {
+			continue
+		} else if err != nil {
+			logger.Error(errors.Wrapf(err, "failed to detect drift for app %s", a.Slug))
+			continue
+		}
+		if !report.HasDrift() {
+			continue
+		}
+
+		logger.Infof("detected drift in %d resources of app %s sequence %d", len(report.Resources), a.Slug, report.Sequence)
+
+		if !autoRemediate() {
+			continue
+		}
+		if err := remediate(a, report); err != nil {
+			logger.Error(errors.Wrapf(err, "failed to remediate drift for app %s", a.Slug))
+		}
+	}
+
+	return nil
+}
+
+// Detect compares the rendered manifests of the currently deployed sequence of an app with the live
+// objects in the cluster, saves the result as the latest drift report of the app and returns it
+func Detect(appID string) (*types.DriftReport, error) {
+	a, err := store.GetStore().GetApp(appID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get app")
+	}
+
+	downstreams, err := store.GetStore().ListDownstreamsForApp(a.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list downstreams for app")
+	}
+	if len(downstreams) == 0 {
+		return nil, ErrNotDeployed
+	}
+	d := downstreams[0]
+
+	deployedVersion, err := store.GetStore().GetCurrentDownstreamVersion(a.ID, d.ClusterID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get current downstream version")
+	}
+	if deployedVersion == nil || deployedVersion.Status != storetypes.VersionDeployed {
+		return nil, ErrNotDeployed
+	}
+
+	manifests, err := getRenderedManifests(a, d.Name, deployedVersion.ParentSequence)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get rendered manifests")
+	}
+
+	clusterConfig, err := k8sutil.GetClusterConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get cluster config")
+	}
+	serverSide, err := applier.NewServerSideForConfig(clusterConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create server-side applier")
+	}
+
+	resources, checked, err := serverSide.DetectDrift(util.AppNamespace(), a.Slug, manifests, os.Getenv("ANNOTATE_SLUG") != "")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to detect drift")
+	}
+
+	report := &types.DriftReport{
+		AppID:            a.ID,
+		Sequence:         deployedVersion.ParentSequence,
+		CheckedAt:        time.Now(),
+		CheckedResources: checked,
+		Resources:        resources,
+	}
+	if err := store.GetStore().SetAppDriftReport(report); err != nil {
+		return nil, errors.Wrap(err, "failed to save drift report")
+	}
+
+	metrics.SetAppDriftedResources(a.ID, countDrifted(report))
+
+	return report, nil
+}
+
+func getRenderedManifests(a *apptypes.App, downstreamName string, sequence int64) ([]byte, error) {
+	archiveDir, err := os.MkdirTemp("", "kotsadm")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create temp dir")
+	}
+	defer os.RemoveAll(archiveDir)
+
+	if err := store.GetStore().GetAppVersionArchive(a.ID, sequence, archiveDir); err != nil {
+		return nil, errors.Wrap(err, "failed to get app version archive")
+	}
+
+	kotsKinds, err := kotsutil.LoadKotsKindsFromPath(filepath.Join(archiveDir, "upstream"))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load kotskinds")
+	}
+
+	manifests, _, err := apparchive.GetRenderedApp(archiveDir, downstreamName, kotsKinds.GetKustomizeBinaryPath())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get rendered app")
+	}
+
+	return manifests, nil
+}
+
+// remediate redeploys the sequence the drift report was created for
+func remediate(a *apptypes.App, report *types.DriftReport) error {
+	downstreams, err := store.GetStore().ListDownstreamsForApp(a.ID)
+	if err != nil {
+		return errors.Wrap(err, "failed to list downstreams for app")
+	}
+	if len(downstreams) == 0 {
+		return errors.New("no downstreams for app")
+	}
+
+	logger.Info("redeploying app to remediate drift", zap.String("appId", a.ID), zap.Int64("sequence", report.Sequence))
+
+	if err := store.GetStore().DeleteDownstreamDeployStatus(a.ID, downstreams[0].ClusterID, report.Sequence); err != nil {
+		return errors.Wrap(err, "failed to delete downstream deploy status")
+	}
+	if err := version.DeployVersion(a.ID, report.Sequence); err != nil {
+		return errors.Wrap(err, "failed to deploy version")
+	}
+
+	report.Remediated = true
+	if err := store.GetStore().SetAppDriftReport(report); err != nil {
+		return errors.Wrap(err, "failed to save drift report")
+	}
+
+	return nil
+}
+
+func countDrifted(report *types.DriftReport) int {
+	count := 0
+	for _, resource := range report.Resources {
+		if resource.Drift == types.DriftMissing || resource.Drift == types.DriftModified {
+			count++
+		}
+	}
+	return count
+}
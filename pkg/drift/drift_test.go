@@ -0,0 +1,53 @@
+package drift
+
+import (
+	"testing"
+	"time"
+
+	"github.com/replicatedhq/kots/pkg/drift/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_getInterval(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "", want: defaultInterval},
+		{value: "5m", want: 5 * time.Minute},
+		{value: "0", want: 0},
+		{value: "-1m", wantErr: true},
+		{value: "often", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			t.Setenv(IntervalEnv, test.value)
+
+			got, err := getInterval()
+			if test.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func Test_countDrifted(t *testing.T) {
+	report := &types.DriftReport{
+		Resources: []types.ResourceDrift{
+			{Kind: "Deployment", Name: "web", Drift: types.DriftModified},
+			{Kind: "Service", Name: "web", Drift: types.DriftMissing},
+			{Kind: "Widget", Name: "web", Drift: types.DriftUnknown, Error: "forbidden"},
+		},
+	}
+	assert.Equal(t, 2, countDrifted(report))
+	assert.True(t, report.HasDrift())
+
+	report.Resources = report.Resources[2:]
+	assert.Equal(t, 0, countDrifted(report))
+	assert.False(t, report.HasDrift())
+}
@@ -0,0 +1,47 @@
+package types
+
+import "time"
+
+const (
+	// DriftMissing means the resource was deleted from the cluster
+	DriftMissing = "missing"
+	// DriftModified means the live resource no longer matches the rendered manifest
+	DriftModified = "modified"
+	// DriftUnknown means the resource could not be compared, see the error
+	DriftUnknown = "unknown"
+)
+
+// ResourceDrift describes how a single resource differs from the deployed version
+type ResourceDrift struct {
+	Group     string `json:"group"`
+	Version   string `json:"version"`
+	Kind      string `json:"kind"`
+	Resource  string `json:"resource"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Drift     string `json:"drift"`
+	// Diff is a unified diff from the live resource to the resource as it would be after redeploying
+	Diff  string `json:"diff,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// DriftReport is the result of comparing the deployed sequence of an app with the live cluster state
+type DriftReport struct {
+	AppID            string          `json:"appId"`
+	Sequence         int64           `json:"sequence"`
+	CheckedAt        time.Time       `json:"checkedAt"`
+	CheckedResources int             `json:"checkedResources"`
+	Resources        []ResourceDrift `json:"resources"`
+	// Remediated is true if the version was redeployed to correct the drift
+	Remediated bool `json:"remediated"`
+}
+
+// HasDrift returns true if any resource is missing or modified
+func (r DriftReport) HasDrift() bool {
+	for _, resource := range r.Resources {
+		if resource.Drift == DriftMissing || resource.Drift == DriftModified {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package encryptionkey
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/crypto"
+	"github.com/replicatedhq/kots/pkg/logger"
+	corev1 "k8s.io/api/core/v1"
+	kuberneteserrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	loadedMutex           sync.Mutex
+	loadedResourceVersion string
+)
+
+// Reload loads the keys in the encryption secret and sets its current key to be used for encryption.
+// It is used when the key was rotated by another replica.
+func Reload(clientset kubernetes.Interface, namespace string) error {
+	secret, err := clientset.CoreV1().Secrets(namespace).Get(context.TODO(), crypto.EncryptionSecretName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrap(err, "failed to get encryption secret")
+	}
+
+	return loadKeys(secret)
+}
+
+// WatchSecret reloads the keys whenever the encryption secret changes, so that all replicas encrypt with the current key after it
+// was rotated. resourceVersion is the version of the secret that the keys were loaded from at startup.
+func WatchSecret(ctx context.Context, clientset kubernetes.Interface, namespace string, resourceVersion string) {
+	loadedMutex.Lock()
+	loadedResourceVersion = resourceVersion
+	loadedMutex.Unlock()
+
+	secrets := clientset.CoreV1().Secrets(namespace)
+	for ctx.Err() == nil {
+		// the watch starts at the last loaded version, changes that were missed while it was not running are loaded first
+		secret, err := secrets.Get(ctx, crypto.EncryptionSecretName, metav1.GetOptions{})
+		if err != nil {
+			if !kuberneteserrors.IsNotFound(err) {
+				logger.Warnf("failed to get encryption secret: %v", err)
+			}
+			sleepContext(ctx, 20*time.Second)
+			continue
+		}
+		if err := loadKeys(secret); err != nil {
+			logger.Error(errors.Wrap(err, "failed to reload encryption keys"))
+			sleepContext(ctx, 20*time.Second)
+			continue
+		}
+
+		w, err := secrets.Watch(ctx, metav1.ListOptions{
+			FieldSelector:   fields.OneTermEqualSelector("metadata.name", crypto.EncryptionSecretName).String(),
+			ResourceVersion: secret.ResourceVersion,
+		})
+		if err != nil {
+			logger.Warnf("failed to watch encryption secret: %v", err)
+			sleepContext(ctx, 20*time.Second)
+			continue
+		}
+
+		for e := range w.ResultChan() {
+			if e.Type != watch.Added && e.Type != watch.Modified {
+				continue
+			}
+			secret, ok := e.Object.(*corev1.Secret)
+			if !ok {
+				continue
+			}
+			if err := loadKeys(secret); err != nil {
+				logger.Error(errors.Wrap(err, "failed to reload encryption keys"))
+			}
+		}
+		w.Stop()
+	}
+}
+
+// loadKeys loads the keys in the secret unless they were already loaded from the same version of the secret
+func loadKeys(secret *corev1.Secret) error {
+	loadedMutex.Lock()
+	defer loadedMutex.Unlock()
+
+	if secret.ResourceVersion != "" && secret.ResourceVersion == loadedResourceVersion {
+		return nil
+	}
+
+	currentKey, previousKeys, err := crypto.KeysFromSecret(secret)
+	if err != nil {
+		return errors.Wrap(err, "failed to read keys from secret")
+	}
+
+	for _, key := range previousKeys {
+		if err := crypto.InitFromString(key); err != nil {
+			return errors.Wrap(err, "failed to load previous key")
+		}
+	}
+	if err := crypto.SetEncryptionKey(currentKey); err != nil {
+		return errors.Wrap(err, "failed to set encryption key")
+	}
+	// identity config values in archives are encrypted with the kotskinds implementation
+	if err := crypto.SetKotsKindsKeys(currentKey, previousKeys); err != nil {
+		return errors.Wrap(err, "failed to set kotskinds encryption keys")
+	}
+
+	if currentKey != "" {
+		logger.Infof("loaded encryption key %s from secret version %s", crypto.KeyID(), secret.ResourceVersion)
+	}
+	loadedResourceVersion = secret.ResourceVersion
+
+	return nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
@@ -0,0 +1,47 @@
+package encryptionkey
+
+import (
+	"testing"
+
+	"github.com/replicatedhq/kots/pkg/crypto"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_Reload(t *testing.T) {
+	req := require.New(t)
+
+	oldKey, err := crypto.GenerateKey()
+	req.NoError(err)
+	newKey, err := crypto.GenerateKey()
+	req.NoError(err)
+
+	req.NoError(crypto.SetEncryptionKey(oldKey))
+	oldEncrypted := crypto.Encrypt([]byte("this is a test"))
+
+	clientset := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "kotsadm-encryption",
+			Namespace:       "default",
+			ResourceVersion: "2",
+		},
+		Data: map[string][]byte{
+			"encryptionKey":          []byte(newKey),
+			"previousEncryptionKeys": []byte(oldKey + "\n"),
+		},
+	})
+
+	req.NoError(Reload(clientset, "default"))
+	req.Equal(newKey, crypto.ToString())
+
+	decrypted, err := crypto.Decrypt(oldEncrypted)
+	req.NoError(err)
+	req.Equal("this is a test", string(decrypted))
+
+	// the same version of the secret is not loaded again
+	req.NoError(crypto.SetEncryptionKey(oldKey))
+	req.NoError(Reload(clientset, "default"))
+	req.Equal(oldKey, crypto.ToString())
+}
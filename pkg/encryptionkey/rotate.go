@@ -0,0 +1,348 @@
+package encryptionkey
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/apparchive"
+	"github.com/replicatedhq/kots/pkg/crypto"
+	"github.com/replicatedhq/kots/pkg/gitops"
+	"github.com/replicatedhq/kots/pkg/kotsutil"
+	"github.com/replicatedhq/kots/pkg/logger"
+	registrytypes "github.com/replicatedhq/kots/pkg/registry/types"
+	"github.com/replicatedhq/kots/pkg/store"
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	serializer "k8s.io/apimachinery/pkg/runtime/serializer/json"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+var rotateMutex sync.Mutex
+
+type RotateResult struct {
+	KeyID             string `json:"keyId"`
+	RegistryPasswords int    `json:"registryPasswords"`
+	NotificationSinks int    `json:"notificationSinks"`
+	GitOpsSecrets     int    `json:"gitOpsSecrets"`
+	AppVersions       int    `json:"appVersions"`
+}
+
+// Rotate generates a new encryption key and re-encrypts all data that is stored in the database and in app version archives with it.
+// The previous key is kept in the encryption secret so that data that was not re-encrypted, e.g. in a backup, can still be decrypted.
+func Rotate(clientset kubernetes.Interface, namespace string) (*RotateResult, error) {
+	rotateMutex.Lock()
+	defer rotateMutex.Unlock()
+
+	// load the current and previous keys so that all existing data can be decrypted
+	if err := crypto.InitFromSecret(clientset, namespace); err != nil {
+		return nil, errors.Wrap(err, "failed to load encryption keys")
+	}
+
+	newKey, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate encryption key")
+	}
+
+	// the secret is updated first, so that the new key is not lost if re-encryption fails part way through
+	previousKeys, err := updateEncryptionSecret(clientset, namespace, newKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to update encryption secret")
+	}
+
+	if err := crypto.SetEncryptionKey(newKey); err != nil {
+		return nil, errors.Wrap(err, "failed to set encryption key")
+	}
+	// identity config values in archives are encrypted with the kotskinds implementation
+	if err := crypto.SetKotsKindsKeys(newKey, previousKeys); err != nil {
+		return nil, errors.Wrap(err, "failed to set kotskinds encryption key")
+	}
+
+	result := &RotateResult{
+		KeyID: crypto.KeyID(),
+	}
+
+	result.RegistryPasswords, err = reEncryptRegistryPasswords()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to re-encrypt registry passwords")
+	}
+
+	result.NotificationSinks, err = reEncryptNotificationSinks()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to re-encrypt notification sinks")
+	}
+
+	result.GitOpsSecrets, err = gitops.ReEncryptGitOpsSecret()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to re-encrypt gitops secret")
+	}
+
+	result.AppVersions, err = reEncryptAppVersions(newKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to re-encrypt app versions")
+	}
+
+	return result, nil
+}
+
+// updateEncryptionSecret sets the new key in the encryption secret and moves the current key to the list of previous keys, which is returned
+func updateEncryptionSecret(clientset kubernetes.Interface, namespace string, newKey string) ([]string, error) {
+	secret, err := clientset.CoreV1().Secrets(namespace).Get(context.TODO(), crypto.EncryptionSecretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get secret")
+	}
+
+	currentKey, previousKeys, err := crypto.KeysFromSecret(secret)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read keys from secret")
+	}
+
+	found := false
+	for _, key := range previousKeys {
+		if key == currentKey {
+			found = true
+			break
+		}
+	}
+	if !found {
+		previousKeys = append(previousKeys, currentKey)
+	}
+
+	// keys are wrapped again if the secret uses a key provider
+	if err := crypto.SetKeysInSecret(secret, newKey, previousKeys); err != nil {
+		return nil, errors.Wrap(err, "failed to set keys in secret")
+	}
+
+	if _, err := clientset.CoreV1().Secrets(namespace).Update(context.TODO(), secret, metav1.UpdateOptions{}); err != nil {
+		return nil, errors.Wrap(err, "failed to update secret")
+	}
+
+	return previousKeys, nil
+}
+
+func reEncryptRegistryPasswords() (int, error) {
+	apps, err := store.GetStore().ListInstalledApps()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to list apps")
+	}
+
+	count := 0
+	for _, app := range apps {
+		registrySettings, err := store.GetStore().GetRegistryDetailsForApp(app.ID)
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to get registry details for app %s", app.Slug)
+		}
+		if registrySettings.Password == "" || registrySettings.Password == registrytypes.PasswordMask {
+			continue
+		}
+
+		// the password is encrypted with the current key when it is updated
+		err = store.GetStore().UpdateRegistry(app.ID, registrySettings.Hostname, registrySettings.Username, registrySettings.Password, registrySettings.Namespace, registrySettings.IsReadOnly)
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to update registry details for app %s", app.Slug)
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+func reEncryptNotificationSinks() (int, error) {
+	sinks, err := store.GetStore().ListNotificationSinks()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to list notification sinks")
+	}
+
+	for _, sink := range sinks {
+		// the config is encrypted with the current key when it is updated
+		if err := store.GetStore().UpdateNotificationSink(*sink); err != nil {
+			return 0, errors.Wrapf(err, "failed to update notification sink %s", sink.ID)
+		}
+	}
+
+	return len(sinks), nil
+}
+
+func reEncryptAppVersions(newKey string) (int, error) {
+	apps, err := store.GetStore().ListInstalledApps()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to list apps")
+	}
+
+	count := 0
+	for _, app := range apps {
+		versions, err := store.GetStore().FindDownstreamVersions(app.ID, true)
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to find versions for app %s", app.Slug)
+		}
+
+		for _, version := range versions.AllVersions {
+			updated, err := reEncryptAppVersion(app.ID, version.Sequence, newKey)
+			if err != nil {
+				return 0, errors.Wrapf(err, "failed to re-encrypt app %s sequence %d", app.Slug, version.Sequence)
+			}
+			if updated {
+				count++
+			}
+		}
+	}
+
+	return count, nil
+}
+
+func reEncryptAppVersion(appID string, sequence int64, newKey string) (bool, error) {
+	archiveDir, err := ioutil.TempDir("", "kotsadm")
+	if err != nil {
+		return false, errors.Wrap(err, "failed to create temp dir")
+	}
+	defer os.RemoveAll(archiveDir)
+
+	if err := store.GetStore().GetAppVersionArchive(appID, sequence, archiveDir); err != nil {
+		return false, errors.Wrap(err, "failed to get app version archive")
+	}
+
+	updated, configValues, installation, err := reEncryptArchive(archiveDir, newKey)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to re-encrypt archive")
+	}
+	if !updated {
+		return false, nil
+	}
+
+	if err := store.GetStore().CreateAppVersionArchive(appID, sequence, archiveDir); err != nil {
+		return false, errors.Wrap(err, "failed to update app version archive")
+	}
+
+	if err := store.GetStore().UpdateAppVersionEncryptedData(appID, sequence, configValues, *installation); err != nil {
+		return false, errors.Wrap(err, "failed to update app version")
+	}
+
+	logger.Debugf("re-encrypted app %s sequence %d", appID, sequence)
+
+	return true, nil
+}
+
+// reEncryptArchive re-encrypts the config values and identity config in an app version archive with the current key,
+// and replaces the encryption key of the installation if it is set
+func reEncryptArchive(archiveDir string, newKey string) (bool, *kotsv1beta1.ConfigValues, *kotsv1beta1.Installation, error) {
+	userdataDir := filepath.Join(archiveDir, "upstream", "userdata")
+	s := serializer.NewYAMLSerializer(serializer.DefaultMetaFactory, scheme.Scheme, scheme.Scheme)
+
+	updated := false
+
+	var configValues *kotsv1beta1.ConfigValues
+	configValuesPath := filepath.Join(userdataDir, "config.yaml")
+	if _, err := os.Stat(configValuesPath); err == nil {
+		configValues, err = kotsutil.LoadConfigValuesFromFile(configValuesPath)
+		if err != nil {
+			return false, nil, nil, errors.Wrap(err, "failed to load config values")
+		}
+
+		if reEncryptConfigValues(configValues) > 0 {
+			var b bytes.Buffer
+			if err := s.Encode(configValues, &b); err != nil {
+				return false, nil, nil, errors.Wrap(err, "failed to encode config values")
+			}
+			if err := ioutil.WriteFile(configValuesPath, b.Bytes(), 0644); err != nil {
+				return false, nil, nil, errors.Wrap(err, "failed to write config values")
+			}
+			updated = true
+		}
+	}
+
+	identityConfigPath := filepath.Join(userdataDir, "identityconfig.yaml")
+	if content, err := ioutil.ReadFile(identityConfigPath); err == nil {
+		identityConfig, err := kotsutil.LoadIdentityConfigFromContents(content)
+		if err != nil {
+			return false, nil, nil, errors.Wrap(err, "failed to load identity config")
+		}
+
+		identityConfigUpdated, err := reEncryptIdentityConfig(identityConfig)
+		if err != nil {
+			return false, nil, nil, errors.Wrap(err, "failed to re-encrypt identity config")
+		}
+		if identityConfigUpdated {
+			b, err := kotsutil.EncodeIdentityConfig(*identityConfig)
+			if err != nil {
+				return false, nil, nil, errors.Wrap(err, "failed to encode identity config")
+			}
+			if err := ioutil.WriteFile(identityConfigPath, b, 0644); err != nil {
+				return false, nil, nil, errors.Wrap(err, "failed to write identity config")
+			}
+			updated = true
+		}
+	}
+
+	installation, err := kotsutil.LoadInstallationFromPath(filepath.Join(userdataDir, "installation.yaml"))
+	if err != nil {
+		return false, nil, nil, errors.Wrap(err, "failed to load installation")
+	}
+	if installation.Spec.EncryptionKey != "" && installation.Spec.EncryptionKey != newKey {
+		installation.Spec.EncryptionKey = newKey
+		if err := apparchive.SaveInstallation(installation, filepath.Join(archiveDir, "upstream")); err != nil {
+			return false, nil, nil, errors.Wrap(err, "failed to save installation")
+		}
+		updated = true
+	}
+
+	return updated, configValues, installation, nil
+}
+
+// reEncryptConfigValues re-encrypts the values that can be decrypted and returns how many were updated.
+// Config values do not include the item type, so values that cannot be decrypted are left as they are.
+func reEncryptConfigValues(configValues *kotsv1beta1.ConfigValues) int {
+	count := 0
+	for name, configValue := range configValues.Spec.Values {
+		if configValue.Value == "" {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(configValue.Value)
+		if err != nil {
+			continue
+		}
+		reEncrypted, err := crypto.ReEncrypt(decoded)
+		if err != nil {
+			continue
+		}
+		configValue.Value = base64.StdEncoding.EncodeToString(reEncrypted)
+		configValues.Spec.Values[name] = configValue
+		count++
+	}
+	return count
+}
+
+func reEncryptIdentityConfig(identityConfig *kotsv1beta1.IdentityConfig) (bool, error) {
+	updated := false
+
+	if identityConfig.Spec.ClientSecret != nil && identityConfig.Spec.ClientSecret.ValueEncrypted != "" {
+		value, err := identityConfig.Spec.ClientSecret.GetValue()
+		if err != nil {
+			return false, errors.Wrap(err, "failed to get client secret")
+		}
+		identityConfig.Spec.ClientSecret.Value = value
+		identityConfig.Spec.ClientSecret.ValueEncrypted = ""
+		identityConfig.Spec.ClientSecret.EncryptValue()
+		updated = true
+	}
+
+	if identityConfig.Spec.DexConnectors.ValueEncrypted != "" {
+		value, err := identityConfig.Spec.DexConnectors.GetValue()
+		if err != nil {
+			return false, errors.Wrap(err, "failed to get dex connectors")
+		}
+		identityConfig.Spec.DexConnectors.Value = value
+		identityConfig.Spec.DexConnectors.ValueEncrypted = ""
+		if err := identityConfig.Spec.DexConnectors.EncryptValue(); err != nil {
+			return false, errors.Wrap(err, "failed to encrypt dex connectors")
+		}
+		updated = true
+	}
+
+	return updated, nil
+}
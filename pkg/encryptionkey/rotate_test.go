@@ -0,0 +1,183 @@
+package encryptionkey
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/replicatedhq/kots/pkg/crypto"
+	"github.com/replicatedhq/kots/pkg/kotsutil"
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	kotskindscrypto "github.com/replicatedhq/kotskinds/pkg/crypto"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_updateEncryptionSecret(t *testing.T) {
+	tests := []struct {
+		name             string
+		data             map[string][]byte
+		wantPreviousKeys []string
+	}{
+		{
+			name: "first rotation",
+			data: map[string][]byte{
+				"encryptionKey": []byte("key-1"),
+			},
+			wantPreviousKeys: []string{"key-1"},
+		},
+		{
+			name: "previous keys are kept",
+			data: map[string][]byte{
+				"encryptionKey":          []byte("key-2"),
+				"previousEncryptionKeys": []byte("key-1\n"),
+			},
+			wantPreviousKeys: []string{"key-1", "key-2"},
+		},
+		{
+			name: "current key is not added twice",
+			data: map[string][]byte{
+				"encryptionKey":          []byte("key-2"),
+				"previousEncryptionKeys": []byte("key-1\nkey-2\n"),
+			},
+			wantPreviousKeys: []string{"key-1", "key-2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := require.New(t)
+
+			clientset := fake.NewSimpleClientset(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "kotsadm-encryption",
+					Namespace: "default",
+				},
+				Data: tt.data,
+			})
+
+			previousKeys, err := updateEncryptionSecret(clientset, "default", "new-key")
+			req.NoError(err)
+			req.Equal(tt.wantPreviousKeys, previousKeys)
+
+			secret, err := clientset.CoreV1().Secrets("default").Get(context.TODO(), "kotsadm-encryption", metav1.GetOptions{})
+			req.NoError(err)
+			req.Equal("new-key", string(secret.Data["encryptionKey"]))
+			req.Equal(tt.wantPreviousKeys, crypto.SplitKeys(string(secret.Data["previousEncryptionKeys"])))
+		})
+	}
+}
+
+func Test_reEncryptArchive(t *testing.T) {
+	req := require.New(t)
+
+	oldKey, err := crypto.GenerateKey()
+	req.NoError(err)
+	req.NoError(crypto.SetEncryptionKey(oldKey))
+	req.NoError(kotskindscrypto.InitFromSecret(encryptionSecretClientset(oldKey), "default"))
+
+	encryptedPassword := base64.StdEncoding.EncodeToString(crypto.Encrypt([]byte("hunter2")))
+	identityConfig := kotsv1beta1.IdentityConfig{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "kots.io/v1beta1",
+			Kind:       "IdentityConfig",
+		},
+		Spec: kotsv1beta1.IdentityConfigSpec{
+			ClientSecret: &kotsv1beta1.StringValueOrEncrypted{
+				ValueEncrypted: base64.StdEncoding.EncodeToString(kotskindscrypto.Encrypt([]byte("client-secret"))),
+			},
+		},
+	}
+
+	archiveDir := t.TempDir()
+	userdataDir := filepath.Join(archiveDir, "upstream", "userdata")
+	req.NoError(os.MkdirAll(userdataDir, 0755))
+
+	req.NoError(os.WriteFile(filepath.Join(userdataDir, "config.yaml"), []byte(`apiVersion: kots.io/v1beta1
+kind: ConfigValues
+spec:
+  values:
+    password:
+      value: `+encryptedPassword+`
+    hostname:
+      value: example.com
+`), 0644))
+	identityConfigData, err := kotsutil.EncodeIdentityConfig(identityConfig)
+	req.NoError(err)
+	req.NoError(os.WriteFile(filepath.Join(userdataDir, "identityconfig.yaml"), identityConfigData, 0644))
+	req.NoError(os.WriteFile(filepath.Join(userdataDir, "installation.yaml"), []byte(`apiVersion: kots.io/v1beta1
+kind: Installation
+spec:
+  encryptionKey: `+oldKey+`
+`), 0644))
+
+	newKey, err := crypto.GenerateKey()
+	req.NoError(err)
+	req.NoError(crypto.SetEncryptionKey(newKey))
+	req.NoError(kotskindscrypto.InitFromSecret(encryptionSecretClientset(newKey), "default"))
+
+	updated, configValues, installation, err := reEncryptArchive(archiveDir, newKey)
+	req.NoError(err)
+	req.True(updated)
+	req.Equal(newKey, installation.Spec.EncryptionKey)
+	req.Equal("example.com", configValues.Spec.Values["hostname"].Value)
+	req.NotEqual(encryptedPassword, configValues.Spec.Values["password"].Value)
+
+	// the archive is updated on disk
+	loadedConfigValues, err := kotsutil.LoadConfigValuesFromFile(filepath.Join(userdataDir, "config.yaml"))
+	req.NoError(err)
+	req.Equal(configValues.Spec.Values, loadedConfigValues.Spec.Values)
+
+	decoded, err := base64.StdEncoding.DecodeString(loadedConfigValues.Spec.Values["password"].Value)
+	req.NoError(err)
+	decrypted, err := crypto.Decrypt(decoded)
+	req.NoError(err)
+	req.Equal("hunter2", string(decrypted))
+
+	loadedInstallation, err := kotsutil.LoadInstallationFromPath(filepath.Join(userdataDir, "installation.yaml"))
+	req.NoError(err)
+	req.Equal(newKey, loadedInstallation.Spec.EncryptionKey)
+
+	identityConfigData, err = os.ReadFile(filepath.Join(userdataDir, "identityconfig.yaml"))
+	req.NoError(err)
+	loadedIdentityConfig, err := kotsutil.LoadIdentityConfigFromContents(identityConfigData)
+	req.NoError(err)
+	req.NotEqual(identityConfig.Spec.ClientSecret.ValueEncrypted, loadedIdentityConfig.Spec.ClientSecret.ValueEncrypted)
+	clientSecret, err := loadedIdentityConfig.Spec.ClientSecret.GetValue()
+	req.NoError(err)
+	req.Equal("client-secret", clientSecret)
+}
+
+func Test_reEncryptArchiveNoEncryptedData(t *testing.T) {
+	req := require.New(t)
+
+	archiveDir := t.TempDir()
+	userdataDir := filepath.Join(archiveDir, "upstream", "userdata")
+	req.NoError(os.MkdirAll(userdataDir, 0755))
+	req.NoError(os.WriteFile(filepath.Join(userdataDir, "installation.yaml"), []byte(`apiVersion: kots.io/v1beta1
+kind: Installation
+spec:
+  versionLabel: "1.0.0"
+`), 0644))
+
+	updated, configValues, installation, err := reEncryptArchive(archiveDir, "new-key")
+	req.NoError(err)
+	req.False(updated)
+	req.Nil(configValues)
+	req.Equal("", installation.Spec.EncryptionKey)
+}
+
+func encryptionSecretClientset(key string) *fake.Clientset {
+	return fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kotsadm-encryption",
+			Namespace: "default",
+		},
+		Data: map[string][]byte{
+			"encryptionKey": []byte(key),
+		},
+	})
+}
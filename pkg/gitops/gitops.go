@@ -15,6 +15,8 @@ import (
 	"time"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/transport"
 	go_git_ssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
@@ -22,6 +24,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/replicatedhq/kots/pkg/apparchive"
 	"github.com/replicatedhq/kots/pkg/crypto"
+	gitopstypes "github.com/replicatedhq/kots/pkg/gitops/types"
 	"github.com/replicatedhq/kots/pkg/k8sutil"
 	"github.com/replicatedhq/kots/pkg/kotsadm/types"
 	"github.com/replicatedhq/kots/pkg/kotsutil"
@@ -34,20 +37,33 @@ import (
 )
 
 type GitOpsConfig struct {
-	Provider    string `json:"provider"`
-	RepoURI     string `json:"repoUri"`
-	Hostname    string `json:"hostname"`
-	HTTPPort    string `json:"httpPort"`
-	SSHPort     string `json:"sshPort"`
-	Path        string `json:"path"`
-	Branch      string `json:"branch"`
-	Format      string `json:"format"`
-	Action      string `json:"action"`
+	Provider string `json:"provider"`
+	RepoURI  string `json:"repoUri"`
+	Hostname string `json:"hostname"`
+	HTTPPort string `json:"httpPort"`
+	SSHPort  string `json:"sshPort"`
+	Path     string `json:"path"`
+	Branch   string `json:"branch"`
+	Format   string `json:"format"`
+	Action   string `json:"action"`
+	// Controller optionally generates an Argo CD Application or Flux Kustomization for the app, only for the per-resource and kustomize formats
+	Controller  string `json:"controller,omitempty"`
 	PublicKey   string `json:"publicKey"`
 	PrivateKey  string `json:"-"`
 	IsConnected bool   `json:"isConnected"`
+	// APIURL overrides the provider api used to open pull requests, e.g. for self-hosted instances on a non-standard path
+	APIURL string `json:"apiUrl,omitempty"`
+	// APIToken is used to open pull requests. Deploy keys can push branches but cannot call the provider api.
+	APIToken string `json:"-"`
 }
 
+const (
+	// ActionCommit pushes the rendered manifests directly to the configured branch
+	ActionCommit = "commit"
+	// ActionPullRequest pushes the rendered manifests to a branch per version and opens a pull request against the configured branch
+	ActionPullRequest = "pull-request"
+)
+
 type GlobalGitOpsConfig struct {
 	Enabled  bool   `json:"enabled"`
 	Hostname string `json:"hostname"`
@@ -78,12 +94,17 @@ func (g *GitOpsConfig) CommitURL(hash string) string {
 	}
 }
 
-func (g *GitOpsConfig) CloneURL() (string, error) {
+func (g *GitOpsConfig) IsPullRequestMode() bool {
+	return g.Action == ActionPullRequest
+}
+
+// repoOwnerAndName returns the owner (project for bitbucket server) and name of the repo
+func (g *GitOpsConfig) repoOwnerAndName() (string, string, error) {
 	// copied this logic from node js api
 	uriParts := strings.Split(g.RepoURI, "/")
 
 	if len(uriParts) < 5 {
-		return "", errors.Errorf("unexpected url format: %s", g.RepoURI)
+		return "", "", errors.Errorf("unexpected url format: %s", g.RepoURI)
 	}
 
 	owner := uriParts[3]
@@ -91,12 +112,21 @@ func (g *GitOpsConfig) CloneURL() (string, error) {
 
 	if g.Provider == "bitbucket_server" {
 		if len(uriParts) < 7 {
-			return "", errors.Errorf("unexpected bitbucket server url format: %s", g.RepoURI)
+			return "", "", errors.Errorf("unexpected bitbucket server url format: %s", g.RepoURI)
 		}
 		owner = uriParts[4]
 		repo = uriParts[6]
 	}
 
+	return owner, repo, nil
+}
+
+func (g *GitOpsConfig) CloneURL() (string, error) {
+	owner, repo, err := g.repoOwnerAndName()
+	if err != nil {
+		return "", err
+	}
+
 	switch g.Provider {
 	case "github":
 		return fmt.Sprintf("git@github.com:%s/%s.git", owner, repo), nil
@@ -108,11 +138,52 @@ func (g *GitOpsConfig) CloneURL() (string, error) {
 		return fmt.Sprintf("git@%s:%s/%s/%s.git", g.Hostname, g.SSHPort, owner, repo), nil
 	case "github_enterprise", "gitlab_enterprise":
 		return fmt.Sprintf("git@%s:%s/%s.git", g.Hostname, owner, repo), nil
+	case "gitea":
+		if g.SSHPort != "" {
+			return fmt.Sprintf("ssh://git@%s:%s/%s/%s.git", g.Hostname, g.SSHPort, owner, repo), nil
+		}
+		return fmt.Sprintf("git@%s:%s/%s.git", g.Hostname, owner, repo), nil
+	}
+
+	return "", errors.Errorf("unsupported provider type: %s", g.Provider)
+}
+
+// APIBaseURL returns the url of the provider api used to open pull requests
+func (g *GitOpsConfig) APIBaseURL() (string, error) {
+	if g.APIURL != "" {
+		return g.APIURL, nil
+	}
+
+	host := g.Hostname
+	if g.HTTPPort != "" {
+		host = fmt.Sprintf("%s:%s", g.Hostname, g.HTTPPort)
+	}
+
+	switch g.Provider {
+	case "github":
+		return "https://api.github.com", nil
+	case "github_enterprise":
+		return fmt.Sprintf("https://%s/api/v3", host), nil
+	case "gitlab":
+		return "https://gitlab.com/api/v4", nil
+	case "gitlab_enterprise":
+		return fmt.Sprintf("https://%s/api/v4", host), nil
+	case "bitbucket":
+		return "https://api.bitbucket.org/2.0", nil
+	case "bitbucket_server":
+		return fmt.Sprintf("https://%s/rest/api/1.0", host), nil
+	case "gitea":
+		return fmt.Sprintf("https://%s/api/v1", host), nil
 	}
 
 	return "", errors.Errorf("unsupported provider type: %s", g.Provider)
 }
 
+// PullRequestBranchName returns the branch the manifests of a version are pushed to in pull request mode
+func PullRequestBranchName(appSlug string, sequence int) string {
+	return fmt.Sprintf("kots/%s/%d", appSlug, sequence)
+}
+
 // GetDownstreamGitOps will return the gitops config for a downstream,
 // This implementation copies how it works in typescript.
 func GetDownstreamGitOps(appID string, clusterID string) (*GitOpsConfig, error) {
@@ -190,6 +261,21 @@ func GetDownstreamGitOpsConfig(clientset kubernetes.Interface, appID string, clu
 					Path:       configMapData["path"],
 					Format:     configMapData["format"],
 					Action:     configMapData["action"],
+					Controller: configMapData["controller"],
+				}
+
+				apiToken, apiURL := gitOpsAPIConfigFromSecretData(idx, secret.Data)
+				gitOpsConfig.APIURL = apiURL
+				if apiToken != "" {
+					decodedAPIToken, err := base64.StdEncoding.DecodeString(apiToken)
+					if err != nil {
+						return nil, errors.Wrap(err, "failed to decode api token")
+					}
+					decryptedAPIToken, err := crypto.Decrypt(decodedAPIToken)
+					if err != nil {
+						return nil, errors.Wrap(err, "failed to decrypt api token")
+					}
+					gitOpsConfig.APIToken = string(decryptedAPIToken)
 				}
 
 				if lastError, ok := configMapData["lastError"]; ok && lastError == "" {
@@ -315,17 +401,17 @@ func deleteKeysFromGitOpsSecret(clientset kubernetes.Interface, repoURL string)
 	return nil
 }
 
-func UpdateDownstreamGitOps(appID, clusterID, uri, branch, path, format, action string) error {
+func UpdateDownstreamGitOps(appID, clusterID, uri, branch, path, format, action, controller string) error {
 	clientset, err := k8sutil.GetClientset()
 	if err != nil {
 		return errors.Wrap(err, "failed to get k8s client set")
 	}
 
-	err = updateDownstreamGitOps(clientset, appID, clusterID, uri, branch, path, format, action)
+	err = updateDownstreamGitOps(clientset, appID, clusterID, uri, branch, path, format, action, controller)
 	return errors.Wrap(err, "failed to update downstream gitops config")
 }
 
-func updateDownstreamGitOps(clientset kubernetes.Interface, appID, clusterID, uri, branch, path, format, action string) error {
+func updateDownstreamGitOps(clientset kubernetes.Interface, appID, clusterID, uri, branch, path, format, action, controller string) error {
 	configMap, err := clientset.CoreV1().ConfigMaps(util.PodNamespace).Get(context.TODO(), "kotsadm-gitops", metav1.GetOptions{})
 	if err != nil && !kuberneteserrors.IsNotFound(err) {
 		return errors.Wrap(err, "failed to get configmap")
@@ -343,11 +429,12 @@ func updateDownstreamGitOps(clientset kubernetes.Interface, appID, clusterID, ur
 
 	appKey := fmt.Sprintf("%s-%s", appID, clusterID)
 	newAppData := map[string]string{
-		"repoUri": uri,
-		"branch":  branch,
-		"path":    path,
-		"format":  format,
-		"action":  action,
+		"repoUri":    uri,
+		"branch":     branch,
+		"path":       path,
+		"format":     format,
+		"action":     action,
+		"controller": controller,
 	}
 
 	// check if to reset or keep last error
@@ -484,17 +571,19 @@ func TestGitOpsConnection(gitOpsConfig *GitOpsConfig) (string, error) {
 	return ref.Name().Short(), nil
 }
 
-func CreateGitOps(provider string, repoURI string, hostname string, httpPort string, sshPort string) error {
+// CreateGitOps creates or updates the provider for the repo.
+// The api token is only needed for pull request mode, and an empty token keeps the existing one.
+func CreateGitOps(provider string, repoURI string, hostname string, httpPort string, sshPort string, apiURL string, apiToken string) error {
 	clientset, err := k8sutil.GetClientset()
 	if err != nil {
 		return errors.Wrap(err, "failed to get k8s client set")
 	}
 
-	err = createGitOps(clientset, provider, repoURI, hostname, httpPort, sshPort)
+	err = createGitOps(clientset, provider, repoURI, hostname, httpPort, sshPort, apiURL, apiToken)
 	return errors.Wrap(err, "failed to create gitops")
 }
 
-func createGitOps(clientset kubernetes.Interface, provider string, repoURI string, hostname string, httpPort string, sshPort string) error {
+func createGitOps(clientset kubernetes.Interface, provider string, repoURI string, hostname string, httpPort string, sshPort string, apiURL string, apiToken string) error {
 	secret, err := clientset.CoreV1().Secrets(util.PodNamespace).Get(context.TODO(), "kotsadm-gitops", metav1.GetOptions{})
 	if err != nil && !kuberneteserrors.IsNotFound(err) {
 		return errors.Wrap(err, "failed to get secret")
@@ -586,6 +675,17 @@ func createGitOps(clientset kubernetes.Interface, provider string, repoURI strin
 		secretData[sshPortKey] = []byte(sshPort)
 	}
 
+	apiURLKey := fmt.Sprintf("provider.%d.apiUrl", repoIdx)
+	delete(secretData, apiURLKey)
+	if apiURL != "" {
+		secretData[apiURLKey] = []byte(apiURL)
+	}
+
+	if apiToken != "" {
+		encryptedAPIToken := crypto.Encrypt([]byte(apiToken))
+		secretData[fmt.Sprintf("provider.%d.apiToken", repoIdx)] = []byte(base64.StdEncoding.EncodeToString(encryptedAPIToken))
+	}
+
 	if secretExists {
 		secret.Data = secretData
 		_, err = clientset.CoreV1().Secrets(util.PodNamespace).Update(context.TODO(), secret, metav1.UpdateOptions{})
@@ -629,6 +729,62 @@ func ResetGitOps() error {
 	return nil
 }
 
+// ReEncryptGitOpsSecret encrypts the private keys and api tokens in the gitops secret with the current encryption key
+// and returns the number of values that were re-encrypted
+func ReEncryptGitOpsSecret() (int, error) {
+	clientset, err := k8sutil.GetClientset()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get k8s client set")
+	}
+
+	return reEncryptGitOpsSecret(clientset)
+}
+
+func reEncryptGitOpsSecret(clientset kubernetes.Interface) (int, error) {
+	secret, err := clientset.CoreV1().Secrets(util.PodNamespace).Get(context.TODO(), "kotsadm-gitops", metav1.GetOptions{})
+	if err != nil {
+		if kuberneteserrors.IsNotFound(err) {
+			return 0, nil
+		}
+		return 0, errors.Wrap(err, "failed to get secret")
+	}
+
+	count := 0
+	for key, val := range secret.Data {
+		splitKey := strings.Split(key, ".")
+		if len(splitKey) != 3 || splitKey[0] != "provider" {
+			continue
+		}
+		if splitKey[2] != "privateKey" && splitKey[2] != "apiToken" {
+			continue
+		}
+		if len(val) == 0 {
+			continue
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(string(val))
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to decode %s", key)
+		}
+		reEncrypted, err := crypto.ReEncrypt(decoded)
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to re-encrypt %s", key)
+		}
+		secret.Data[key] = []byte(base64.StdEncoding.EncodeToString(reEncrypted))
+		count++
+	}
+
+	if count == 0 {
+		return 0, nil
+	}
+
+	if _, err := clientset.CoreV1().Secrets(util.PodNamespace).Update(context.TODO(), secret, metav1.UpdateOptions{}); err != nil {
+		return 0, errors.Wrap(err, "failed to update secret")
+	}
+
+	return count, nil
+}
+
 func GetGitOps() (GlobalGitOpsConfig, error) {
 	clientset, err := k8sutil.GetClientset()
 	if err != nil {
@@ -701,6 +857,23 @@ func gitOpsConfigFromSecretData(idx int64, secretData map[string][]byte) (string
 	return provider, publicKey, privateKey, repoURI, hostname, httpPort, sshPort
 }
 
+func gitOpsAPIConfigFromSecretData(idx int64, secretData map[string][]byte) (string, string) {
+	apiToken := ""
+	apiURL := ""
+
+	apiTokenDecoded, ok := secretData[fmt.Sprintf("provider.%d.apiToken", idx)]
+	if ok {
+		apiToken = string(apiTokenDecoded)
+	}
+
+	apiURLDecoded, ok := secretData[fmt.Sprintf("provider.%d.apiUrl", idx)]
+	if ok {
+		apiURL = string(apiURLDecoded)
+	}
+
+	return apiToken, apiURL
+}
+
 func getAuth(privateKey string) (transport.AuthMethod, error) {
 	var auth transport.AuthMethod
 	signer, err := ssh.ParsePrivateKey([]byte(privateKey))
@@ -712,33 +885,54 @@ func getAuth(privateKey string) (transport.AuthMethod, error) {
 	return auth, nil
 }
 
-func CreateGitOpsCommit(gitOpsConfig *GitOpsConfig, appSlug string, appName string, newSequence int, archiveDir string, downstreamName string) (string, error) {
+func CreateGitOpsCommit(gitOpsConfig *GitOpsConfig, appSlug string, appName string, newSequence int, archiveDir string, downstreamName string) (*gitopstypes.DownstreamCommit, error) {
 	kotsKinds, err := kotsutil.LoadKotsKindsFromPath(filepath.Join(archiveDir, "upstream"))
 	if err != nil {
-		return "", errors.Wrap(err, "failed to load kots kinds")
+		return nil, errors.Wrap(err, "failed to load kots kinds")
 	}
 
 	out, _, err := apparchive.GetRenderedApp(archiveDir, downstreamName, kotsKinds.GetKustomizeBinaryPath())
 	if err != nil {
-		return "", errors.Wrap(err, "failed to get rendered app")
+		return nil, errors.Wrap(err, "failed to get rendered app")
+	}
+
+	files, err := getGitOpsFiles(gitOpsConfig, appSlug, downstreamName, archiveDir, out)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get files for %s format", gitOpsConfig.Format)
 	}
 
 	// using the deploy key, create the commit in a new branch
 	auth, err := getAuth(gitOpsConfig.PrivateKey)
 	if err != nil {
-		return "", errors.Wrap(err, "failed to get auth")
+		return nil, errors.Wrap(err, "failed to get auth")
 	}
 
-	workDir, err := ioutil.TempDir("", "kotsadm")
+	cloneURL, err := gitOpsConfig.CloneURL()
 	if err != nil {
-		return "", errors.Wrap(err, "failed to create temp dir")
+		return nil, errors.Wrap(err, "failed to get clone url")
 	}
-	defer os.RemoveAll(workDir)
 
-	cloneURL, err := gitOpsConfig.CloneURL()
+	return commitRenderedApp(gitOpsConfig, cloneURL, auth, appSlug, appName, newSequence, files)
+}
+
+// commitRenderedApp writes the app files, keyed by repo path, to the repo and pushes them.
+// In pull request mode, the commit is pushed to a branch for the sequence and a pull request is opened against the configured branch.
+// A nil commit is returned if the rendered app has not changed.
+func commitRenderedApp(gitOpsConfig *GitOpsConfig, cloneURL string, auth transport.AuthMethod, appSlug string, appName string, newSequence int, files map[string][]byte) (*gitopstypes.DownstreamCommit, error) {
+	var prClient pullRequestClient
+	if gitOpsConfig.IsPullRequestMode() {
+		c, err := newPullRequestClient(gitOpsConfig)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create pull request client")
+		}
+		prClient = c
+	}
+
+	workDir, err := ioutil.TempDir("", "kotsadm")
 	if err != nil {
-		return "", errors.Wrap(err, "failed to get clone url")
+		return nil, errors.Wrap(err, "failed to create temp dir")
 	}
+	defer os.RemoveAll(workDir)
 
 	cloneOptions := &git.CloneOptions{
 		RemoteName:        git.DefaultRemoteName,
@@ -748,45 +942,42 @@ func CreateGitOpsCommit(gitOpsConfig *GitOpsConfig, appSlug string, appName stri
 	}
 	cloned, workTree, err := CloneAndCheckout(workDir, cloneOptions, gitOpsConfig.Branch)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	dirPath := filepath.Join(workDir, gitOpsConfig.Path)
-	_, err = os.Stat(dirPath)
-	if os.IsNotExist(err) {
-		// create subdirectory if not exist
-		err := os.MkdirAll(dirPath, 0755)
-		if err != nil {
-			return "", errors.Wrap(err, "failed to mkdir")
-		}
-	} // ignore error here and let the stat of the file below handle any errors
-
-	filePath := filepath.Join(dirPath, fmt.Sprintf("%s.yaml", appSlug))
-	_, err = os.Stat(filePath)
-	if err == nil { // if the file has not changed, end now
-		currentRevision, err := ioutil.ReadFile(filePath)
-		if err != nil {
-			return "", errors.Wrap(err, "failed to read current app yaml")
-		}
-		if string(currentRevision) == string(out) {
-			return "", nil
-		}
-	} else if !os.IsNotExist(err) {
-		return "", errors.Wrap(err, "failed to stat current app yaml")
+	changed, err := writeAppFiles(gitOpsConfig, workDir, workTree, appSlug, files)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to write app files")
+	}
+	if !changed { // if the app has not changed, end now
+		return nil, nil
 	}
 
-	err = ioutil.WriteFile(filePath, out, 0644)
-	if err != nil {
-		return "", errors.Wrap(err, "failed to write updated app yaml")
+	pushOptions := &git.PushOptions{
+		RemoteName: cloneOptions.RemoteName,
+		Auth:       auth,
 	}
 
-	_, err = workTree.Add(strings.TrimPrefix(filepath.Join(gitOpsConfig.Path, fmt.Sprintf("%s.yaml", appSlug)), "/"))
-	if err != nil {
-		return "", errors.Wrap(err, "failed to add to worktree")
+	prBranch := PullRequestBranchName(appSlug, newSequence)
+	if prClient != nil {
+		// keep the staged app files when switching to the new branch
+		err := workTree.Checkout(&git.CheckoutOptions{
+			Branch: plumbing.NewBranchReferenceName(prBranch),
+			Create: true,
+			Keep:   true,
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create branch %s", prBranch)
+		}
+		// the branch is owned by kots, overwrite it if the version is pushed again
+		pushOptions.RefSpecs = []config.RefSpec{
+			config.RefSpec(fmt.Sprintf("+refs/heads/%s:refs/heads/%s", prBranch, prBranch)),
+		}
 	}
 
 	// commit it
-	updatedHash, err := workTree.Commit(fmt.Sprintf("Updating %s to version %d", appName, newSequence), &git.CommitOptions{
+	commitMessage := fmt.Sprintf("Updating %s to version %d", appName, newSequence)
+	updatedHash, err := workTree.Commit(commitMessage, &git.CommitOptions{
 		Author: &object.Signature{
 			Name:  "KOTS Admin Console",
 			Email: "help@replicated.com",
@@ -794,18 +985,94 @@ func CreateGitOpsCommit(gitOpsConfig *GitOpsConfig, appSlug string, appName stri
 		},
 	})
 	if err != nil {
-		return "", errors.Wrap(err, "failed to commit")
+		return nil, errors.Wrap(err, "failed to commit")
 	}
 
-	err = cloned.Push(&git.PushOptions{
-		RemoteName: cloneOptions.RemoteName,
-		Auth:       auth,
+	err = cloned.Push(pushOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to push")
+	}
+
+	commit := &gitopstypes.DownstreamCommit{
+		CommitURL: gitOpsConfig.CommitURL(updatedHash.String()),
+	}
+
+	if prClient == nil {
+		return commit, nil
+	}
+
+	pr, err := prClient.CreatePullRequest(PullRequestOptions{
+		Title:        commitMessage,
+		Description:  fmt.Sprintf("This pull request was opened by the KOTS Admin Console to update %s to version %d.", appName, newSequence),
+		SourceBranch: prBranch,
+		TargetBranch: gitOpsConfig.Branch,
 	})
 	if err != nil {
-		return "", errors.Wrap(err, "failed to push")
+		return nil, errors.Wrap(err, "failed to create pull request")
 	}
 
-	return gitOpsConfig.CommitURL(updatedHash.String()), nil
+	commit.PullRequestURL = pr.URL
+	commit.PullRequestNumber = pr.Number
+	commit.PullRequestState = pr.State
+
+	return commit, nil
+}
+
+// writeAppFiles replaces the files owned by the app in the work tree and stages the changes.
+// It returns false if the app files have not changed.
+func writeAppFiles(gitOpsConfig *GitOpsConfig, workDir string, workTree *git.Worktree, appSlug string, files map[string][]byte) (bool, error) {
+	appPaths := appLayoutPaths(gitOpsConfig, appSlug)
+	for _, appPath := range appPaths {
+		if err := os.RemoveAll(filepath.Join(workDir, filepath.FromSlash(appPath))); err != nil {
+			return false, errors.Wrapf(err, "failed to remove %s", appPath)
+		}
+	}
+
+	for _, filename := range sortedKeys(files) {
+		filePath := filepath.Join(workDir, filepath.FromSlash(filename))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			return false, errors.Wrap(err, "failed to mkdir")
+		}
+		if err := ioutil.WriteFile(filePath, files[filename], 0644); err != nil {
+			return false, errors.Wrapf(err, "failed to write %s", filename)
+		}
+	}
+
+	status, err := workTree.Status()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get worktree status")
+	}
+
+	changed := false
+	for filename, fileStatus := range status {
+		if !isAppPath(appPaths, filename) {
+			continue
+		}
+
+		switch fileStatus.Worktree {
+		case git.Unmodified:
+			continue
+		case git.Deleted:
+			_, err = workTree.Remove(filename)
+		default:
+			_, err = workTree.Add(filename)
+		}
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to stage %s", filename)
+		}
+		changed = true
+	}
+
+	return changed, nil
+}
+
+func isAppPath(appPaths []string, filename string) bool {
+	for _, appPath := range appPaths {
+		if filename == appPath || strings.HasPrefix(filename, appPath+"/") {
+			return true
+		}
+	}
+	return false
 }
 
 func generatePrivateKey_ed25519() (*KeyPair, error) {
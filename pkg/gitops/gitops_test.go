@@ -1,13 +1,29 @@
 package gitops
 
 import (
+	"context"
 	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/replicatedhq/kots/pkg/crypto"
+	gitopstypes "github.com/replicatedhq/kots/pkg/gitops/types"
+	"github.com/replicatedhq/kots/pkg/util"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/ssh"
 	corev1 "k8s.io/api/core/v1"
 	kuberneteserrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
@@ -24,6 +40,8 @@ func Test_createGitOps(t *testing.T) {
 		hostname    string
 		httpPort    string
 		sshPort     string
+		apiURL      string
+		apiToken    string
 		configIndex int64
 		action      string
 		branch      string
@@ -73,15 +91,31 @@ func Test_createGitOps(t *testing.T) {
 			path:        "/test/path/2",
 			wantKeyType: "ssh-ed25519",
 		},
+		{
+			name:        "gitea provider in pull request mode",
+			provider:    "gitea",
+			repoURI:     "https://1.2.3.7:3000/test_org/test_repo",
+			hostname:    "1.2.3.7",
+			httpPort:    "3000",
+			sshPort:     "2222",
+			apiURL:      "https://1.2.3.7:3000/api/v1",
+			apiToken:    "test-api-token",
+			configIndex: 2,
+			action:      "pull-request",
+			branch:      "main",
+			format:      "single",
+			path:        "/test/path/3",
+			wantKeyType: "ssh-ed25519",
+		},
 	}
 
 	clientset := fake.NewSimpleClientset()
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			err := createGitOps(clientset, test.provider, test.repoURI, test.hostname, test.httpPort, test.sshPort)
+			err := createGitOps(clientset, test.provider, test.repoURI, test.hostname, test.httpPort, test.sshPort, test.apiURL, test.apiToken)
 			assert.NoError(t, err)
 
-			err = updateDownstreamGitOps(clientset, test.appID, test.clusterID, test.repoURI, test.branch, test.path, test.format, test.action)
+			err = updateDownstreamGitOps(clientset, test.appID, test.clusterID, test.repoURI, test.branch, test.path, test.format, test.action, "")
 			assert.NoError(t, err)
 
 			config, err := GetDownstreamGitOpsConfig(clientset, test.appID, test.clusterID)
@@ -96,6 +130,8 @@ func Test_createGitOps(t *testing.T) {
 			assert.Equal(t, test.branch, config.Branch)
 			assert.Equal(t, test.format, config.Format)
 			assert.Equal(t, test.path, config.Path)
+			assert.Equal(t, test.apiURL, config.APIURL)
+			assert.Equal(t, test.apiToken, config.APIToken)
 
 			publicKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(config.PublicKey))
 			assert.NoError(t, err)
@@ -278,6 +314,60 @@ func Test_deleteKeysFromGitOpsSecret(t *testing.T) {
 	}
 }
 
+func Test_reEncryptGitOpsSecret(t *testing.T) {
+	req := require.New(t)
+
+	oldKey, err := crypto.GenerateKey()
+	req.NoError(err)
+	req.NoError(crypto.SetEncryptionKey(oldKey))
+
+	encryptedPrivateKey := base64.StdEncoding.EncodeToString(crypto.Encrypt([]byte("private-key")))
+	encryptedAPIToken := base64.StdEncoding.EncodeToString(crypto.Encrypt([]byte("api-token")))
+
+	clientset := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kotsadm-gitops",
+			Namespace: util.PodNamespace,
+		},
+		Data: map[string][]byte{
+			"provider.0.type":       []byte("github"),
+			"provider.0.repoUri":    []byte("https://github.com/replicatedhq/test"),
+			"provider.0.privateKey": []byte(encryptedPrivateKey),
+			"provider.0.publicKey":  []byte("public-key"),
+			"provider.1.type":       []byte("github"),
+			"provider.1.apiToken":   []byte(encryptedAPIToken),
+		},
+	})
+
+	newKey, err := crypto.GenerateKey()
+	req.NoError(err)
+	req.NoError(crypto.SetEncryptionKey(newKey))
+
+	count, err := reEncryptGitOpsSecret(clientset)
+	req.NoError(err)
+	req.Equal(2, count)
+
+	secret, err := clientset.CoreV1().Secrets(util.PodNamespace).Get(context.TODO(), "kotsadm-gitops", metav1.GetOptions{})
+	req.NoError(err)
+	req.Equal("public-key", string(secret.Data["provider.0.publicKey"]))
+	req.NotEqual(encryptedPrivateKey, string(secret.Data["provider.0.privateKey"]))
+	req.NotEqual(encryptedAPIToken, string(secret.Data["provider.1.apiToken"]))
+
+	// the values can still be decrypted
+	for key, want := range map[string]string{"provider.0.privateKey": "private-key", "provider.1.apiToken": "api-token"} {
+		decoded, err := base64.StdEncoding.DecodeString(string(secret.Data[key]))
+		req.NoError(err)
+		decrypted, err := crypto.Decrypt(decoded)
+		req.NoError(err)
+		req.Equal(want, string(decrypted))
+	}
+
+	// a missing secret is not an error
+	count, err = reEncryptGitOpsSecret(fake.NewSimpleClientset())
+	req.NoError(err)
+	req.Equal(0, count)
+}
+
 func Test_isGitOpsRepoConfiguredForMultipleApps(t *testing.T) {
 	type args struct {
 		gitOpsEncodedMap map[string]string
@@ -335,3 +425,191 @@ func Test_isGitOpsRepoConfiguredForMultipleApps(t *testing.T) {
 		})
 	}
 }
+
+// initTestRemote creates a bare repo with a single commit on the main branch to act as the gitops remote
+func initTestRemote(t *testing.T) string {
+	req := require.New(t)
+
+	remoteDir := t.TempDir()
+	remote, err := git.PlainInit(remoteDir, true)
+	req.NoError(err)
+	err = remote.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName("main")))
+	req.NoError(err)
+
+	seedDir := t.TempDir()
+	seed, err := git.PlainInit(seedDir, false)
+	req.NoError(err)
+	req.NoError(ioutil.WriteFile(filepath.Join(seedDir, "README.md"), []byte("gitops"), 0644))
+
+	workTree, err := seed.Worktree()
+	req.NoError(err)
+	_, err = workTree.Add("README.md")
+	req.NoError(err)
+	_, err = workTree.Commit("initial commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	req.NoError(err)
+
+	_, err = seed.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{remoteDir}})
+	req.NoError(err)
+	err = seed.Push(&git.PushOptions{
+		RemoteName: "origin",
+		RefSpecs:   []config.RefSpec{"refs/heads/master:refs/heads/main"},
+	})
+	req.NoError(err)
+
+	return remoteDir
+}
+
+func remoteBranchFile(t *testing.T, remoteDir string, branch string, path string) (string, string) {
+	req := require.New(t)
+
+	remote, err := git.PlainOpen(remoteDir)
+	req.NoError(err)
+	ref, err := remote.Reference(plumbing.NewBranchReferenceName(branch), true)
+	req.NoError(err)
+	commit, err := remote.CommitObject(ref.Hash())
+	req.NoError(err)
+	file, err := commit.File(path)
+	if err == object.ErrFileNotFound {
+		return commit.Message, ""
+	}
+	req.NoError(err)
+	contents, err := file.Contents()
+	req.NoError(err)
+
+	return commit.Message, contents
+}
+
+func Test_commitRenderedApp(t *testing.T) {
+	req := require.New(t)
+	remoteDir := initTestRemote(t)
+
+	gitOpsConfig := &GitOpsConfig{
+		Provider: "github",
+		RepoURI:  "https://github.com/test_org/test_repo",
+		Branch:   "main",
+		Path:     "/apps",
+		Action:   ActionCommit,
+	}
+
+	commit, err := commitRenderedApp(gitOpsConfig, remoteDir, nil, "my-app", "My App", 1, map[string][]byte{"apps/my-app.yaml": []byte("kind: ConfigMap\n")})
+	req.NoError(err)
+	req.NotNil(commit)
+	req.Contains(commit.CommitURL, "https://github.com/test_org/test_repo/commit/")
+	req.Empty(commit.PullRequestURL)
+
+	message, contents := remoteBranchFile(t, remoteDir, "main", "apps/my-app.yaml")
+	req.Equal("Updating My App to version 1", message)
+	req.Equal("kind: ConfigMap\n", contents)
+
+	// nothing to commit when the rendered app has not changed
+	commit, err = commitRenderedApp(gitOpsConfig, remoteDir, nil, "my-app", "My App", 2, map[string][]byte{"apps/my-app.yaml": []byte("kind: ConfigMap\n")})
+	req.NoError(err)
+	req.Nil(commit)
+}
+
+func Test_commitRenderedApp_structuredFormat(t *testing.T) {
+	req := require.New(t)
+	remoteDir := initTestRemote(t)
+
+	gitOpsConfig := &GitOpsConfig{
+		Provider: "github",
+		RepoURI:  "https://github.com/test_org/test_repo",
+		Branch:   "main",
+		Path:     "/apps",
+		Format:   FormatSingle,
+		Action:   ActionCommit,
+	}
+
+	_, err := commitRenderedApp(gitOpsConfig, remoteDir, nil, "my-app", "My App", 1, map[string][]byte{"apps/my-app.yaml": []byte("kind: ConfigMap\n")})
+	req.NoError(err)
+
+	// switching formats removes the single file
+	gitOpsConfig.Format = FormatPerResource
+	commit, err := commitRenderedApp(gitOpsConfig, remoteDir, nil, "my-app", "My App", 2, map[string][]byte{
+		"apps/my-app/manifests/_default/configmap/a.yaml": []byte("kind: ConfigMap\n"),
+		"apps/my-app/manifests/_default/secret/b.yaml":    []byte("kind: Secret\n"),
+	})
+	req.NoError(err)
+	req.NotNil(commit)
+
+	_, contents := remoteBranchFile(t, remoteDir, "main", "apps/my-app.yaml")
+	req.Empty(contents)
+	_, contents = remoteBranchFile(t, remoteDir, "main", "apps/my-app/manifests/_default/secret/b.yaml")
+	req.Equal("kind: Secret\n", contents)
+
+	// resources removed from the app are removed from the repo
+	commit, err = commitRenderedApp(gitOpsConfig, remoteDir, nil, "my-app", "My App", 3, map[string][]byte{
+		"apps/my-app/manifests/_default/configmap/a.yaml": []byte("kind: ConfigMap\n"),
+	})
+	req.NoError(err)
+	req.NotNil(commit)
+
+	_, contents = remoteBranchFile(t, remoteDir, "main", "apps/my-app/manifests/_default/secret/b.yaml")
+	req.Empty(contents)
+	message, contents := remoteBranchFile(t, remoteDir, "main", "apps/my-app/manifests/_default/configmap/a.yaml")
+	req.Equal("Updating My App to version 3", message)
+	req.Equal("kind: ConfigMap\n", contents)
+}
+
+func Test_commitRenderedApp_pullRequest(t *testing.T) {
+	req := require.New(t)
+	remoteDir := initTestRemote(t)
+
+	var createRequest map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/repos/test_org/test_repo/pulls", r.URL.Path)
+		assert.Equal(t, "token test-token", r.Header.Get("Authorization"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&createRequest))
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"number": 3, "html_url": "https://gitea.example.com/test_org/test_repo/pulls/3", "state": "open"}`))
+	}))
+	defer server.Close()
+
+	gitOpsConfig := &GitOpsConfig{
+		Provider: "gitea",
+		RepoURI:  "https://gitea.example.com/test_org/test_repo",
+		Hostname: "gitea.example.com",
+		Branch:   "main",
+		Path:     "/apps",
+		Action:   ActionPullRequest,
+		APIURL:   server.URL,
+		APIToken: "test-token",
+	}
+
+	commit, err := commitRenderedApp(gitOpsConfig, remoteDir, nil, "my-app", "My App", 4, map[string][]byte{"apps/my-app.yaml": []byte("kind: ConfigMap\n")})
+	req.NoError(err)
+	req.Equal(&gitopstypes.DownstreamCommit{
+		CommitURL:         commit.CommitURL,
+		PullRequestURL:    "https://gitea.example.com/test_org/test_repo/pulls/3",
+		PullRequestNumber: 3,
+		PullRequestState:  gitopstypes.PullRequestStateOpen,
+	}, commit)
+	req.Contains(commit.CommitURL, "https://gitea.example.com/test_org/test_repo/commit/")
+
+	req.Equal("kots/my-app/4", createRequest["head"])
+	req.Equal("main", createRequest["base"])
+	req.Equal("Updating My App to version 4", createRequest["title"])
+
+	// the target branch is left alone until the pull request is merged
+	_, contents := remoteBranchFile(t, remoteDir, "main", "apps/my-app.yaml")
+	req.Empty(contents)
+
+	message, contents := remoteBranchFile(t, remoteDir, "kots/my-app/4", "apps/my-app.yaml")
+	req.Equal("Updating My App to version 4", message)
+	req.Equal("kind: ConfigMap\n", contents)
+}
+
+func Test_commitRenderedApp_pullRequestRequiresToken(t *testing.T) {
+	gitOpsConfig := &GitOpsConfig{
+		Provider: "github",
+		RepoURI:  "https://github.com/test_org/test_repo",
+		Branch:   "main",
+		Action:   ActionPullRequest,
+	}
+
+	_, err := commitRenderedApp(gitOpsConfig, t.TempDir(), nil, "my-app", "My App", 1, map[string][]byte{"apps/my-app.yaml": []byte("kind: ConfigMap\n")})
+	assert.Error(t, err)
+}
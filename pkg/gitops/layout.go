@@ -0,0 +1,382 @@
+package gitops
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/util"
+	yaml "github.com/replicatedhq/yaml/v3"
+	k8syaml "sigs.k8s.io/yaml"
+)
+
+const (
+	// FormatSingle writes the rendered app as a single <appSlug>.yaml file in the configured path
+	FormatSingle = "single"
+	// FormatPerResource writes one file per rendered resource, grouped by namespace and kind
+	FormatPerResource = "per-resource"
+	// FormatKustomize writes the kustomize base and overlays tree so that the app can be built with kustomize
+	FormatKustomize = "kustomize"
+
+	// ControllerArgoCD generates an Argo CD Application that syncs the app from the repo
+	ControllerArgoCD = "argocd"
+	// ControllerFlux generates a Flux Kustomization that syncs the app from the repo
+	ControllerFlux = "flux"
+
+	// clusterScopedDir is the namespace directory used for cluster scoped resources in the per-resource format
+	clusterScopedDir = "_cluster"
+	// appNamespaceDir is the namespace directory used for resources without a namespace, which are deployed to the app namespace
+	appNamespaceDir = "_default"
+)
+
+// clusterScopedKinds are the common cluster scoped kinds.
+// the rendered manifests do not include a namespace for namespaced resources that are deployed to the app namespace,
+// so a missing namespace alone does not tell them apart.
+var clusterScopedKinds = map[string]bool{
+	"APIService":                     true,
+	"ClusterIssuer":                  true,
+	"ClusterRole":                    true,
+	"ClusterRoleBinding":             true,
+	"CSIDriver":                      true,
+	"CustomResourceDefinition":       true,
+	"IngressClass":                   true,
+	"MutatingWebhookConfiguration":   true,
+	"Namespace":                      true,
+	"PersistentVolume":               true,
+	"PodSecurityPolicy":              true,
+	"PriorityClass":                  true,
+	"RuntimeClass":                   true,
+	"StorageClass":                   true,
+	"ValidatingWebhookConfiguration": true,
+	"VolumeSnapshotClass":            true,
+}
+
+func IsValidFormat(format string) bool {
+	switch format {
+	case "", FormatSingle, FormatPerResource, FormatKustomize:
+		return true
+	}
+	return false
+}
+
+func IsValidController(controller string) bool {
+	switch controller {
+	case "", ControllerArgoCD, ControllerFlux:
+		return true
+	}
+	return false
+}
+
+func (g *GitOpsConfig) isStructuredFormat() bool {
+	return g.Format == FormatPerResource || g.Format == FormatKustomize
+}
+
+// appFilePath returns the repo path of the file written in the single format
+func appFilePath(g *GitOpsConfig, appSlug string) string {
+	return repoPath(g.Path, fmt.Sprintf("%s.yaml", appSlug))
+}
+
+// appDirPath returns the repo path of the directory written in the structured formats
+func appDirPath(g *GitOpsConfig, appSlug string) string {
+	return repoPath(g.Path, appSlug)
+}
+
+// manifestsDirPath returns the repo path that a gitops controller should sync the app from
+func manifestsDirPath(g *GitOpsConfig, appSlug string, downstreamName string) string {
+	if g.Format == FormatKustomize {
+		return path.Join(appDirPath(g, appSlug), "manifests", "overlays", "downstreams", downstreamName)
+	}
+	return path.Join(appDirPath(g, appSlug), "manifests")
+}
+
+func repoPath(elem ...string) string {
+	return strings.TrimPrefix(path.Join(elem...), "/")
+}
+
+// appLayoutPaths returns the repo paths owned by the app in any format, so that switching formats
+// or removing resources does not leave stale files behind
+func appLayoutPaths(g *GitOpsConfig, appSlug string) []string {
+	return []string{
+		appFilePath(g, appSlug),
+		appDirPath(g, appSlug),
+	}
+}
+
+// getGitOpsFiles returns the files to write to the repo for the app in the configured format, keyed by repo path
+func getGitOpsFiles(g *GitOpsConfig, appSlug string, downstreamName string, archiveDir string, rendered []byte) (map[string][]byte, error) {
+	if !g.isStructuredFormat() {
+		return map[string][]byte{
+			appFilePath(g, appSlug): rendered,
+		}, nil
+	}
+
+	var manifests map[string][]byte
+	var err error
+	if g.Format == FormatKustomize {
+		manifests, err = kustomizeManifests(archiveDir, downstreamName)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get kustomize manifests")
+		}
+	} else {
+		manifests, err = perResourceManifests(rendered)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to split rendered app")
+		}
+	}
+
+	files := map[string][]byte{}
+	for filename, content := range manifests {
+		files[path.Join(appDirPath(g, appSlug), "manifests", filename)] = content
+	}
+
+	charts, err := helmChartFiles(archiveDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get helm charts")
+	}
+	for filename, content := range charts {
+		files[path.Join(appDirPath(g, appSlug), "helm", filename)] = content
+	}
+
+	if g.Controller != "" {
+		filename, content, err := controllerManifest(g, appSlug, downstreamName)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to generate controller manifest")
+		}
+		files[path.Join(appDirPath(g, appSlug), filename)] = content
+	}
+
+	return files, nil
+}
+
+// perResourceManifests splits the rendered app into one file per resource at <namespace>/<kind>/<name>.yaml
+func perResourceManifests(rendered []byte) (map[string][]byte, error) {
+	files := map[string][]byte{}
+
+	for _, doc := range util.ConvertToSingleDocs(rendered) {
+		doc = []byte(strings.TrimPrefix(string(doc), "---\n"))
+		if len(strings.TrimSpace(string(doc))) == 0 {
+			continue
+		}
+
+		o := util.OverlySimpleGVK{}
+		if err := yaml.Unmarshal(doc, &o); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal yaml")
+		}
+		if o.Kind == "" || o.Metadata.Name == "" {
+			continue
+		}
+
+		namespaceDir := o.Metadata.Namespace
+		if clusterScopedKinds[o.Kind] {
+			namespaceDir = clusterScopedDir
+		} else if namespaceDir == "" {
+			namespaceDir = appNamespaceDir
+		}
+
+		dir := path.Join(namespaceDir, strings.ToLower(o.Kind))
+		filename := path.Join(dir, fmt.Sprintf("%s.yaml", o.Metadata.Name))
+		// the same kind and name can exist in different api groups
+		for i := 1; files[filename] != nil; i++ {
+			filename = path.Join(dir, fmt.Sprintf("%s-%d.yaml", o.Metadata.Name, i))
+		}
+
+		files[filename] = doc
+	}
+
+	return files, nil
+}
+
+// kustomizeManifests returns the base, midstream and downstream kustomize trees from the version archive.
+// the overlays reference each other with relative paths, so the tree is kept as is.
+func kustomizeManifests(archiveDir string, downstreamName string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+
+	dirs := []string{
+		"base",
+		filepath.Join("overlays", "midstream"),
+		filepath.Join("overlays", "downstreams", downstreamName),
+	}
+	for _, dir := range dirs {
+		dirFiles, err := util.GetFilesMap(filepath.Join(archiveDir, dir))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", dir)
+		}
+		for filename, content := range dirFiles {
+			files[filepath.ToSlash(filepath.Join(dir, filename))] = content
+		}
+	}
+
+	return files, nil
+}
+
+// helmChartFiles returns the v1beta2 helm charts in the version archive as the extracted chart and its values,
+// at <chart dir>/<chart name>/... and <chart dir>/values.yaml
+func helmChartFiles(archiveDir string) (map[string][]byte, error) {
+	helmDir := filepath.Join(archiveDir, "helm")
+	entries, err := ioutil.ReadDir(helmDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string][]byte{}, nil
+		}
+		return nil, errors.Wrap(err, "failed to read helm dir")
+	}
+
+	files := map[string][]byte{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		chartDir := filepath.Join(helmDir, entry.Name())
+		chartFiles, err := ioutil.ReadDir(chartDir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read chart dir %s", entry.Name())
+		}
+
+		for _, chartFile := range chartFiles {
+			if chartFile.IsDir() {
+				continue
+			}
+
+			filePath := filepath.Join(chartDir, chartFile.Name())
+			if !strings.HasSuffix(chartFile.Name(), ".tgz") {
+				content, err := ioutil.ReadFile(filePath)
+				if err != nil {
+					return nil, errors.Wrapf(err, "failed to read %s", chartFile.Name())
+				}
+				files[path.Join(entry.Name(), chartFile.Name())] = content
+				continue
+			}
+
+			extracted, err := extractChartArchive(filePath)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to extract chart archive %s", chartFile.Name())
+			}
+			for filename, content := range extracted {
+				files[path.Join(entry.Name(), filename)] = content
+			}
+		}
+	}
+
+	return files, nil
+}
+
+func extractChartArchive(archivePath string) (map[string][]byte, error) {
+	tmpDir, err := ioutil.TempDir("", "kots-gitops-chart")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create temp dir")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := util.ExtractTGZArchive(archivePath, tmpDir); err != nil {
+		return nil, errors.Wrap(err, "failed to extract archive")
+	}
+
+	files, err := util.GetFilesMap(tmpDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read extracted chart")
+	}
+
+	slashFiles := map[string][]byte{}
+	for filename, content := range files {
+		slashFiles[filepath.ToSlash(filename)] = content
+	}
+
+	return slashFiles, nil
+}
+
+// controllerManifest returns the filename and content of the Argo CD Application or Flux Kustomization
+// that syncs the app manifests from the repo. It is written next to the manifests directory, not inside it,
+// so that the controller does not try to manage its own definition.
+func controllerManifest(g *GitOpsConfig, appSlug string, downstreamName string) (string, []byte, error) {
+	manifestsPath := manifestsDirPath(g, appSlug, downstreamName)
+
+	var filename string
+	var obj map[string]interface{}
+
+	switch g.Controller {
+	case ControllerArgoCD:
+		source := map[string]interface{}{
+			"repoURL":        g.RepoURI,
+			"targetRevision": g.Branch,
+			"path":           manifestsPath,
+		}
+		if g.Format == FormatPerResource {
+			source["directory"] = map[string]interface{}{
+				"recurse": true,
+			}
+		}
+
+		filename = "argocd-application.yaml"
+		obj = map[string]interface{}{
+			"apiVersion": "argoproj.io/v1alpha1",
+			"kind":       "Application",
+			"metadata": map[string]interface{}{
+				"name":      appSlug,
+				"namespace": "argocd",
+			},
+			"spec": map[string]interface{}{
+				"project": "default",
+				"source":  source,
+				"destination": map[string]interface{}{
+					"server":    "https://kubernetes.default.svc",
+					"namespace": util.AppNamespace(),
+				},
+				"syncPolicy": map[string]interface{}{
+					"automated": map[string]interface{}{
+						"prune":    true,
+						"selfHeal": true,
+					},
+				},
+			},
+		}
+
+	case ControllerFlux:
+		filename = "flux-kustomization.yaml"
+		// flux-system is the GitRepository created by flux bootstrap for the repo.
+		// the target namespace matches how kots deploys the app, into the app namespace.
+		obj = map[string]interface{}{
+			"apiVersion": "kustomize.toolkit.fluxcd.io/v1",
+			"kind":       "Kustomization",
+			"metadata": map[string]interface{}{
+				"name":      appSlug,
+				"namespace": "flux-system",
+			},
+			"spec": map[string]interface{}{
+				"interval":        "5m",
+				"path":            fmt.Sprintf("./%s", manifestsPath),
+				"prune":           true,
+				"targetNamespace": util.AppNamespace(),
+				"sourceRef": map[string]interface{}{
+					"kind": "GitRepository",
+					"name": "flux-system",
+				},
+			},
+		}
+
+	default:
+		return "", nil, errors.Errorf("unsupported gitops controller: %s", g.Controller)
+	}
+
+	content, err := k8syaml.Marshal(obj)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to marshal")
+	}
+
+	return filename, content, nil
+}
+
+// sortedKeys returns the keys of the files map in a stable order for writing
+func sortedKeys(files map[string][]byte) []string {
+	keys := make([]string, 0, len(files))
+	for key := range files {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,195 @@
+package gitops
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/replicatedhq/kots/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_perResourceManifests(t *testing.T) {
+	rendered := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: other
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: reader
+---
+apiVersion: example.com/v1
+kind: ConfigMap
+metadata:
+  name: config
+`
+
+	files, err := perResourceManifests([]byte(rendered))
+	require.NoError(t, err)
+
+	assert.Len(t, files, 4)
+	assert.Contains(t, string(files["_default/configmap/config.yaml"]), "apiVersion: v1\n")
+	assert.Contains(t, string(files["_default/configmap/config-1.yaml"]), "apiVersion: example.com/v1\n")
+	assert.Contains(t, string(files["other/deployment/web.yaml"]), "name: web")
+	assert.Contains(t, string(files["_cluster/clusterrole/reader.yaml"]), "name: reader")
+}
+
+func Test_kustomizeManifests(t *testing.T) {
+	archiveDir := t.TempDir()
+	writeTestFiles(t, archiveDir, map[string]string{
+		"base/kustomization.yaml":                                 "resources:\n- deployment.yaml\n",
+		"base/deployment.yaml":                                    "kind: Deployment\n",
+		"overlays/midstream/kustomization.yaml":                   "bases:\n- ../../base\n",
+		"overlays/downstreams/this-cluster/kustomization.yaml":    "bases:\n- ../../midstream\n",
+		"overlays/downstreams/other-cluster/kustomization.yaml":   "bases:\n- ../../midstream\n",
+		"upstream/userdata/installation.yaml":                     "kind: Installation\n",
+		"rendered/this-cluster/deployment.yaml":                   "kind: Deployment\n",
+		"overlays/downstreams/this-cluster/pullsecrets/pull.yaml": "kind: Secret\n",
+	})
+
+	files, err := kustomizeManifests(archiveDir, "this-cluster")
+	require.NoError(t, err)
+
+	keys := sortedKeys(files)
+	assert.Equal(t, []string{
+		"base/deployment.yaml",
+		"base/kustomization.yaml",
+		"overlays/downstreams/this-cluster/kustomization.yaml",
+		"overlays/downstreams/this-cluster/pullsecrets/pull.yaml",
+		"overlays/midstream/kustomization.yaml",
+	}, keys)
+}
+
+func Test_helmChartFiles(t *testing.T) {
+	req := require.New(t)
+
+	chartSrc := filepath.Join(t.TempDir(), "mychart")
+	writeTestFiles(t, chartSrc, map[string]string{
+		"Chart.yaml":               "name: mychart\n",
+		"templates/configmap.yaml": "kind: ConfigMap\n",
+	})
+	archive, err := util.TGZArchive(chartSrc)
+	req.NoError(err)
+
+	archiveDir := t.TempDir()
+	writeTestFiles(t, archiveDir, map[string]string{
+		"helm/mychart/values.yaml":       "replicas: 2\n",
+		"helm/mychart/mychart-1.0.0.tgz": string(archive),
+	})
+
+	files, err := helmChartFiles(archiveDir)
+	req.NoError(err)
+
+	req.Equal("replicas: 2\n", string(files["mychart/values.yaml"]))
+	req.Equal("name: mychart\n", string(files["mychart/mychart/Chart.yaml"]))
+	req.Equal("kind: ConfigMap\n", string(files["mychart/mychart/templates/configmap.yaml"]))
+	req.NotContains(files, "mychart/mychart-1.0.0.tgz")
+
+	files, err = helmChartFiles(t.TempDir())
+	req.NoError(err)
+	req.Empty(files)
+}
+
+func Test_getGitOpsFiles(t *testing.T) {
+	rendered := []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n")
+
+	tests := []struct {
+		name      string
+		config    GitOpsConfig
+		wantFiles []string
+		contains  map[string]string
+	}{
+		{
+			name:      "single",
+			config:    GitOpsConfig{Path: "/apps", Format: FormatSingle},
+			wantFiles: []string{"apps/my-app.yaml"},
+		},
+		{
+			name:      "empty format is single",
+			config:    GitOpsConfig{Path: ""},
+			wantFiles: []string{"my-app.yaml"},
+		},
+		{
+			name:   "per-resource with argo cd",
+			config: GitOpsConfig{Path: "/apps", Format: FormatPerResource, Controller: ControllerArgoCD, RepoURI: "https://github.com/test_org/test_repo", Branch: "main"},
+			wantFiles: []string{
+				"apps/my-app/argocd-application.yaml",
+				"apps/my-app/manifests/_default/configmap/config.yaml",
+			},
+			contains: map[string]string{
+				"apps/my-app/argocd-application.yaml": "path: apps/my-app/manifests\n",
+			},
+		},
+		{
+			name:   "kustomize with flux",
+			config: GitOpsConfig{Path: "clusters/prod", Format: FormatKustomize, Controller: ControllerFlux},
+			wantFiles: []string{
+				"clusters/prod/my-app/flux-kustomization.yaml",
+				"clusters/prod/my-app/manifests/base/kustomization.yaml",
+				"clusters/prod/my-app/manifests/overlays/downstreams/this-cluster/kustomization.yaml",
+				"clusters/prod/my-app/manifests/overlays/midstream/kustomization.yaml",
+			},
+			contains: map[string]string{
+				"clusters/prod/my-app/flux-kustomization.yaml": "path: ./clusters/prod/my-app/manifests/overlays/downstreams/this-cluster\n",
+			},
+		},
+	}
+
+	archiveDir := t.TempDir()
+	writeTestFiles(t, archiveDir, map[string]string{
+		"base/kustomization.yaml":                              "resources: []\n",
+		"overlays/midstream/kustomization.yaml":                "bases:\n- ../../base\n",
+		"overlays/downstreams/this-cluster/kustomization.yaml": "bases:\n- ../../midstream\n",
+	})
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			files, err := getGitOpsFiles(&test.config, "my-app", "this-cluster", archiveDir, rendered)
+			require.NoError(t, err)
+			assert.Equal(t, test.wantFiles, sortedKeys(files))
+			for filename, want := range test.contains {
+				assert.Contains(t, string(files[filename]), want)
+			}
+		})
+	}
+}
+
+func Test_controllerManifest(t *testing.T) {
+	gitOpsConfig := &GitOpsConfig{
+		RepoURI:    "https://github.com/test_org/test_repo",
+		Branch:     "main",
+		Path:       "apps",
+		Format:     FormatPerResource,
+		Controller: ControllerArgoCD,
+	}
+
+	filename, content, err := controllerManifest(gitOpsConfig, "my-app", "this-cluster")
+	require.NoError(t, err)
+	assert.Equal(t, "argocd-application.yaml", filename)
+	assert.Contains(t, string(content), "kind: Application\n")
+	assert.Contains(t, string(content), "repoURL: https://github.com/test_org/test_repo\n")
+	assert.Contains(t, string(content), "targetRevision: main\n")
+	assert.Contains(t, string(content), "recurse: true\n")
+
+	gitOpsConfig.Controller = "jenkins"
+	_, _, err = controllerManifest(gitOpsConfig, "my-app", "this-cluster")
+	assert.Error(t, err)
+}
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	for filename, content := range files {
+		filePath := filepath.Join(dir, filename)
+		require.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0755))
+		require.NoError(t, ioutil.WriteFile(filePath, []byte(content), 0644))
+	}
+}
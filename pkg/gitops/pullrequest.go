@@ -0,0 +1,321 @@
+package gitops
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	gitopstypes "github.com/replicatedhq/kots/pkg/gitops/types"
+)
+
+type PullRequestOptions struct {
+	Title        string
+	Description  string
+	SourceBranch string
+	TargetBranch string
+}
+
+type PullRequest struct {
+	Number int64
+	URL    string
+	State  gitopstypes.PullRequestState
+}
+
+// pullRequestClient opens and inspects pull requests (merge requests on gitlab) using the provider's api
+type pullRequestClient interface {
+	CreatePullRequest(opts PullRequestOptions) (*PullRequest, error)
+	GetPullRequest(number int64) (*PullRequest, error)
+}
+
+var pullRequestHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+func newPullRequestClient(g *GitOpsConfig) (pullRequestClient, error) {
+	if g.APIToken == "" {
+		return nil, errors.New("an api token is required to open pull requests")
+	}
+
+	baseURL, err := g.APIBaseURL()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get api url")
+	}
+
+	owner, repo, err := g.repoOwnerAndName()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get repo owner and name")
+	}
+
+	api := apiClient{baseURL: strings.TrimSuffix(baseURL, "/")}
+
+	switch g.Provider {
+	case "github", "github_enterprise":
+		api.headers = map[string]string{"Authorization": fmt.Sprintf("Bearer %s", g.APIToken), "Accept": "application/vnd.github+json"}
+		return &githubClient{api: api, owner: owner, repo: repo}, nil
+	case "gitea":
+		api.headers = map[string]string{"Authorization": fmt.Sprintf("token %s", g.APIToken)}
+		return &githubClient{api: api, owner: owner, repo: repo}, nil
+	case "gitlab", "gitlab_enterprise":
+		api.headers = map[string]string{"PRIVATE-TOKEN": g.APIToken}
+		return &gitlabClient{api: api, project: fmt.Sprintf("%s/%s", owner, repo)}, nil
+	case "bitbucket":
+		api.headers = map[string]string{"Authorization": fmt.Sprintf("Bearer %s", g.APIToken)}
+		return &bitbucketClient{api: api, workspace: owner, repo: repo}, nil
+	case "bitbucket_server":
+		api.headers = map[string]string{"Authorization": fmt.Sprintf("Bearer %s", g.APIToken)}
+		return &bitbucketServerClient{api: api, project: owner, repo: repo}, nil
+	}
+
+	return nil, errors.Errorf("pull requests are not supported for provider type: %s", g.Provider)
+}
+
+type apiClient struct {
+	baseURL string
+	headers map[string]string
+}
+
+func (c apiClient) do(method string, path string, body interface{}, out interface{}) error {
+	var reqBody *bytes.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal request")
+		}
+		reqBody = bytes.NewReader(b)
+	} else {
+		reqBody = bytes.NewReader(nil)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reqBody)
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, val := range c.headers {
+		req.Header.Set(key, val)
+	}
+
+	resp, err := pullRequestHTTPClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to execute request")
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read response")
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("unexpected status code %d: %s", resp.StatusCode, string(b))
+	}
+
+	if err := json.Unmarshal(b, out); err != nil {
+		return errors.Wrap(err, "failed to unmarshal response")
+	}
+
+	return nil
+}
+
+// githubClient works with github and gitea, which has a compatible pull request api
+type githubClient struct {
+	api   apiClient
+	owner string
+	repo  string
+}
+
+type githubPullRequest struct {
+	Number  int64  `json:"number"`
+	HTMLURL string `json:"html_url"`
+	State   string `json:"state"`
+	Merged  bool   `json:"merged"`
+}
+
+func (c *githubClient) CreatePullRequest(opts PullRequestOptions) (*PullRequest, error) {
+	body := map[string]string{
+		"title": opts.Title,
+		"body":  opts.Description,
+		"head":  opts.SourceBranch,
+		"base":  opts.TargetBranch,
+	}
+	pr := githubPullRequest{}
+	if err := c.api.do("POST", fmt.Sprintf("/repos/%s/%s/pulls", url.PathEscape(c.owner), url.PathEscape(c.repo)), body, &pr); err != nil {
+		return nil, err
+	}
+	return pr.toPullRequest(), nil
+}
+
+func (c *githubClient) GetPullRequest(number int64) (*PullRequest, error) {
+	pr := githubPullRequest{}
+	if err := c.api.do("GET", fmt.Sprintf("/repos/%s/%s/pulls/%d", url.PathEscape(c.owner), url.PathEscape(c.repo), number), nil, &pr); err != nil {
+		return nil, err
+	}
+	return pr.toPullRequest(), nil
+}
+
+func (pr githubPullRequest) toPullRequest() *PullRequest {
+	state := gitopstypes.PullRequestStateOpen
+	if pr.Merged {
+		state = gitopstypes.PullRequestStateMerged
+	} else if pr.State == "closed" {
+		state = gitopstypes.PullRequestStateClosed
+	}
+	return &PullRequest{Number: pr.Number, URL: pr.HTMLURL, State: state}
+}
+
+type gitlabClient struct {
+	api     apiClient
+	project string
+}
+
+type gitlabMergeRequest struct {
+	IID    int64  `json:"iid"`
+	WebURL string `json:"web_url"`
+	State  string `json:"state"`
+}
+
+func (c *gitlabClient) CreatePullRequest(opts PullRequestOptions) (*PullRequest, error) {
+	body := map[string]string{
+		"title":         opts.Title,
+		"description":   opts.Description,
+		"source_branch": opts.SourceBranch,
+		"target_branch": opts.TargetBranch,
+	}
+	mr := gitlabMergeRequest{}
+	if err := c.api.do("POST", fmt.Sprintf("/projects/%s/merge_requests", url.PathEscape(c.project)), body, &mr); err != nil {
+		return nil, err
+	}
+	return mr.toPullRequest(), nil
+}
+
+func (c *gitlabClient) GetPullRequest(number int64) (*PullRequest, error) {
+	mr := gitlabMergeRequest{}
+	if err := c.api.do("GET", fmt.Sprintf("/projects/%s/merge_requests/%d", url.PathEscape(c.project), number), nil, &mr); err != nil {
+		return nil, err
+	}
+	return mr.toPullRequest(), nil
+}
+
+func (mr gitlabMergeRequest) toPullRequest() *PullRequest {
+	state := gitopstypes.PullRequestStateOpen
+	switch mr.State {
+	case "merged":
+		state = gitopstypes.PullRequestStateMerged
+	case "closed":
+		state = gitopstypes.PullRequestStateClosed
+	}
+	return &PullRequest{Number: mr.IID, URL: mr.WebURL, State: state}
+}
+
+type bitbucketClient struct {
+	api       apiClient
+	workspace string
+	repo      string
+}
+
+type bitbucketPullRequest struct {
+	ID    int64  `json:"id"`
+	State string `json:"state"`
+	Links struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links"`
+}
+
+func (c *bitbucketClient) CreatePullRequest(opts PullRequestOptions) (*PullRequest, error) {
+	body := map[string]interface{}{
+		"title":       opts.Title,
+		"description": opts.Description,
+		"source":      map[string]interface{}{"branch": map[string]string{"name": opts.SourceBranch}},
+		"destination": map[string]interface{}{"branch": map[string]string{"name": opts.TargetBranch}},
+	}
+	pr := bitbucketPullRequest{}
+	if err := c.api.do("POST", fmt.Sprintf("/repositories/%s/%s/pullrequests", url.PathEscape(c.workspace), url.PathEscape(c.repo)), body, &pr); err != nil {
+		return nil, err
+	}
+	return &PullRequest{Number: pr.ID, URL: pr.Links.HTML.Href, State: bitbucketPullRequestState(pr.State)}, nil
+}
+
+func (c *bitbucketClient) GetPullRequest(number int64) (*PullRequest, error) {
+	pr := bitbucketPullRequest{}
+	if err := c.api.do("GET", fmt.Sprintf("/repositories/%s/%s/pullrequests/%d", url.PathEscape(c.workspace), url.PathEscape(c.repo), number), nil, &pr); err != nil {
+		return nil, err
+	}
+	return &PullRequest{Number: pr.ID, URL: pr.Links.HTML.Href, State: bitbucketPullRequestState(pr.State)}, nil
+}
+
+type bitbucketServerClient struct {
+	api     apiClient
+	project string
+	repo    string
+}
+
+type bitbucketServerPullRequest struct {
+	ID    int64  `json:"id"`
+	State string `json:"state"`
+	Links struct {
+		Self []struct {
+			Href string `json:"href"`
+		} `json:"self"`
+	} `json:"links"`
+}
+
+func (c *bitbucketServerClient) CreatePullRequest(opts PullRequestOptions) (*PullRequest, error) {
+	body := map[string]interface{}{
+		"title":       opts.Title,
+		"description": opts.Description,
+		"fromRef":     map[string]string{"id": fmt.Sprintf("refs/heads/%s", opts.SourceBranch)},
+		"toRef":       map[string]string{"id": fmt.Sprintf("refs/heads/%s", opts.TargetBranch)},
+	}
+	pr := bitbucketServerPullRequest{}
+	if err := c.api.do("POST", fmt.Sprintf("/projects/%s/repos/%s/pull-requests", url.PathEscape(c.project), url.PathEscape(c.repo)), body, &pr); err != nil {
+		return nil, err
+	}
+	return pr.toPullRequest(), nil
+}
+
+func (c *bitbucketServerClient) GetPullRequest(number int64) (*PullRequest, error) {
+	pr := bitbucketServerPullRequest{}
+	if err := c.api.do("GET", fmt.Sprintf("/projects/%s/repos/%s/pull-requests/%d", url.PathEscape(c.project), url.PathEscape(c.repo), number), nil, &pr); err != nil {
+		return nil, err
+	}
+	return pr.toPullRequest(), nil
+}
+
+func (pr bitbucketServerPullRequest) toPullRequest() *PullRequest {
+	href := ""
+	if len(pr.Links.Self) > 0 {
+		href = pr.Links.Self[0].Href
+	}
+	return &PullRequest{Number: pr.ID, URL: href, State: bitbucketPullRequestState(pr.State)}
+}
+
+func bitbucketPullRequestState(state string) gitopstypes.PullRequestState {
+	switch state {
+	case "MERGED":
+		return gitopstypes.PullRequestStateMerged
+	case "DECLINED", "SUPERSEDED":
+		return gitopstypes.PullRequestStateClosed
+	}
+	return gitopstypes.PullRequestStateOpen
+}
+
+// GetPullRequestState returns the current state of a pull request opened for a downstream version
+func GetPullRequestState(gitOpsConfig *GitOpsConfig, number int64) (gitopstypes.PullRequestState, error) {
+	client, err := newPullRequestClient(gitOpsConfig)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create pull request client")
+	}
+
+	pr, err := client.GetPullRequest(number)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get pull request %d", number)
+	}
+
+	return pr.State, nil
+}
@@ -0,0 +1,219 @@
+package gitops
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gitopstypes "github.com/replicatedhq/kots/pkg/gitops/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_pullRequestClient(t *testing.T) {
+	tests := []struct {
+		name          string
+		provider      string
+		repoURI       string
+		wantAuthKey   string
+		wantAuthValue string
+		wantCreate    string
+		wantGet       string
+		createResp    string
+		getResp       string
+		wantURL       string
+		wantState     gitopstypes.PullRequestState
+	}{
+		{
+			name:          "github",
+			provider:      "github",
+			repoURI:       "https://github.com/test_org/test_repo",
+			wantAuthKey:   "Authorization",
+			wantAuthValue: "Bearer test-token",
+			wantCreate:    "/repos/test_org/test_repo/pulls",
+			wantGet:       "/repos/test_org/test_repo/pulls/7",
+			createResp:    `{"number": 7, "html_url": "https://github.com/test_org/test_repo/pull/7", "state": "open"}`,
+			getResp:       `{"number": 7, "html_url": "https://github.com/test_org/test_repo/pull/7", "state": "closed", "merged": true}`,
+			wantURL:       "https://github.com/test_org/test_repo/pull/7",
+			wantState:     gitopstypes.PullRequestStateMerged,
+		},
+		{
+			name:          "gitea",
+			provider:      "gitea",
+			repoURI:       "https://gitea.example.com/test_org/test_repo",
+			wantAuthKey:   "Authorization",
+			wantAuthValue: "token test-token",
+			wantCreate:    "/repos/test_org/test_repo/pulls",
+			wantGet:       "/repos/test_org/test_repo/pulls/7",
+			createResp:    `{"number": 7, "html_url": "https://gitea.example.com/test_org/test_repo/pulls/7", "state": "open"}`,
+			getResp:       `{"number": 7, "html_url": "https://gitea.example.com/test_org/test_repo/pulls/7", "state": "closed", "merged": false}`,
+			wantURL:       "https://gitea.example.com/test_org/test_repo/pulls/7",
+			wantState:     gitopstypes.PullRequestStateClosed,
+		},
+		{
+			name:          "gitlab",
+			provider:      "gitlab",
+			repoURI:       "https://gitlab.com/test_org/test_repo",
+			wantAuthKey:   "PRIVATE-TOKEN",
+			wantAuthValue: "test-token",
+			wantCreate:    "/projects/test_org%2Ftest_repo/merge_requests",
+			wantGet:       "/projects/test_org%2Ftest_repo/merge_requests/7",
+			createResp:    `{"iid": 7, "web_url": "https://gitlab.com/test_org/test_repo/-/merge_requests/7", "state": "opened"}`,
+			getResp:       `{"iid": 7, "web_url": "https://gitlab.com/test_org/test_repo/-/merge_requests/7", "state": "merged"}`,
+			wantURL:       "https://gitlab.com/test_org/test_repo/-/merge_requests/7",
+			wantState:     gitopstypes.PullRequestStateMerged,
+		},
+		{
+			name:          "bitbucket",
+			provider:      "bitbucket",
+			repoURI:       "https://bitbucket.org/test_org/test_repo",
+			wantAuthKey:   "Authorization",
+			wantAuthValue: "Bearer test-token",
+			wantCreate:    "/repositories/test_org/test_repo/pullrequests",
+			wantGet:       "/repositories/test_org/test_repo/pullrequests/7",
+			createResp:    `{"id": 7, "state": "OPEN", "links": {"html": {"href": "https://bitbucket.org/test_org/test_repo/pull-requests/7"}}}`,
+			getResp:       `{"id": 7, "state": "DECLINED", "links": {"html": {"href": "https://bitbucket.org/test_org/test_repo/pull-requests/7"}}}`,
+			wantURL:       "https://bitbucket.org/test_org/test_repo/pull-requests/7",
+			wantState:     gitopstypes.PullRequestStateClosed,
+		},
+		{
+			name:          "bitbucket server",
+			provider:      "bitbucket_server",
+			repoURI:       "https://bitbucket.example.com/projects/PROJ/repos/test_repo",
+			wantAuthKey:   "Authorization",
+			wantAuthValue: "Bearer test-token",
+			wantCreate:    "/projects/PROJ/repos/test_repo/pull-requests",
+			wantGet:       "/projects/PROJ/repos/test_repo/pull-requests/7",
+			createResp:    `{"id": 7, "state": "OPEN", "links": {"self": [{"href": "https://bitbucket.example.com/projects/PROJ/repos/test_repo/pull-requests/7"}]}}`,
+			getResp:       `{"id": 7, "state": "MERGED", "links": {"self": [{"href": "https://bitbucket.example.com/projects/PROJ/repos/test_repo/pull-requests/7"}]}}`,
+			wantURL:       "https://bitbucket.example.com/projects/PROJ/repos/test_repo/pull-requests/7",
+			wantState:     gitopstypes.PullRequestStateMerged,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := require.New(t)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, test.wantAuthValue, r.Header.Get(test.wantAuthKey))
+
+				switch r.Method {
+				case "POST":
+					assert.Equal(t, test.wantCreate, r.URL.EscapedPath())
+					body := map[string]interface{}{}
+					assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+					assert.Equal(t, "Update test", body["title"])
+					w.WriteHeader(http.StatusCreated)
+					w.Write([]byte(test.createResp))
+				case "GET":
+					assert.Equal(t, test.wantGet, r.URL.EscapedPath())
+					w.Write([]byte(test.getResp))
+				default:
+					w.WriteHeader(http.StatusMethodNotAllowed)
+				}
+			}))
+			defer server.Close()
+
+			gitOpsConfig := &GitOpsConfig{
+				Provider: test.provider,
+				RepoURI:  test.repoURI,
+				APIURL:   server.URL,
+				APIToken: "test-token",
+			}
+
+			client, err := newPullRequestClient(gitOpsConfig)
+			req.NoError(err)
+
+			created, err := client.CreatePullRequest(PullRequestOptions{
+				Title:        "Update test",
+				SourceBranch: "kots/test/1",
+				TargetBranch: "main",
+			})
+			req.NoError(err)
+			req.Equal(int64(7), created.Number)
+			req.Equal(test.wantURL, created.URL)
+			req.Equal(gitopstypes.PullRequestStateOpen, created.State)
+
+			state, err := GetPullRequestState(gitOpsConfig, 7)
+			req.NoError(err)
+			req.Equal(test.wantState, state)
+		})
+	}
+}
+
+func Test_newPullRequestClient_requiresToken(t *testing.T) {
+	_, err := newPullRequestClient(&GitOpsConfig{
+		Provider: "github",
+		RepoURI:  "https://github.com/test_org/test_repo",
+	})
+	assert.Error(t, err)
+}
+
+func Test_pullRequestClient_errorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"message": "A pull request already exists"}`))
+	}))
+	defer server.Close()
+
+	client, err := newPullRequestClient(&GitOpsConfig{
+		Provider: "github",
+		RepoURI:  "https://github.com/test_org/test_repo",
+		APIURL:   server.URL,
+		APIToken: "test-token",
+	})
+	require.NoError(t, err)
+
+	_, err = client.CreatePullRequest(PullRequestOptions{Title: "Update test", SourceBranch: "kots/test/1", TargetBranch: "main"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "A pull request already exists")
+}
+
+func TestGitOpsConfig_APIBaseURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		config GitOpsConfig
+		want   string
+	}{
+		{
+			name:   "github",
+			config: GitOpsConfig{Provider: "github"},
+			want:   "https://api.github.com",
+		},
+		{
+			name:   "github enterprise",
+			config: GitOpsConfig{Provider: "github_enterprise", Hostname: "github.example.com"},
+			want:   "https://github.example.com/api/v3",
+		},
+		{
+			name:   "gitlab enterprise with port",
+			config: GitOpsConfig{Provider: "gitlab_enterprise", Hostname: "gitlab.example.com", HTTPPort: "8443"},
+			want:   "https://gitlab.example.com:8443/api/v4",
+		},
+		{
+			name:   "bitbucket server",
+			config: GitOpsConfig{Provider: "bitbucket_server", Hostname: "bitbucket.example.com"},
+			want:   "https://bitbucket.example.com/rest/api/1.0",
+		},
+		{
+			name:   "gitea",
+			config: GitOpsConfig{Provider: "gitea", Hostname: "gitea.example.com", HTTPPort: "3000"},
+			want:   "https://gitea.example.com:3000/api/v1",
+		},
+		{
+			name:   "override",
+			config: GitOpsConfig{Provider: "github", APIURL: "http://localhost:8080"},
+			want:   "http://localhost:8080",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.config.APIBaseURL()
+			require.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
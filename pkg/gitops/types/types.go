@@ -0,0 +1,22 @@
+package types
+
+type PullRequestState string
+
+const (
+	PullRequestStateOpen   PullRequestState = "open"
+	PullRequestStateMerged PullRequestState = "merged"
+	PullRequestStateClosed PullRequestState = "closed"
+)
+
+// DownstreamCommit describes where the manifests of a downstream version were pushed.
+// In pull request mode, the commit is pushed to a separate branch and a pull request is opened against the configured branch.
+type DownstreamCommit struct {
+	CommitURL         string           `json:"commitUrl,omitempty"`
+	PullRequestURL    string           `json:"pullRequestUrl,omitempty"`
+	PullRequestNumber int64            `json:"pullRequestNumber,omitempty"`
+	PullRequestState  PullRequestState `json:"pullRequestState,omitempty"`
+}
+
+func (c *DownstreamCommit) IsEmpty() bool {
+	return c == nil || (c.CommitURL == "" && c.PullRequestURL == "")
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/api/handlers/types"
+	"github.com/replicatedhq/kots/pkg/apitoken"
+	apitokentypes "github.com/replicatedhq/kots/pkg/apitoken/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/rbac"
+	"github.com/replicatedhq/kots/pkg/session"
+	sessiontypes "github.com/replicatedhq/kots/pkg/session/types"
+	"github.com/replicatedhq/kots/pkg/store"
+	"github.com/segmentio/ksuid"
+)
+
+func (h *Handler) ListAPITokens(w http.ResponseWriter, r *http.Request) {
+	listAPITokensResponse := types.ListAPITokensResponse{}
+
+	sess := session.ContextGetSession(r)
+	if sess == nil {
+		listAPITokensResponse.Error = "no session"
+		JSON(w, http.StatusUnauthorized, listAPITokensResponse)
+		return
+	}
+
+	tokens, err := store.GetStore().ListAPITokens()
+	if err != nil {
+		listAPITokensResponse.Error = "failed to list api tokens"
+		logger.Error(errors.Wrap(err, listAPITokensResponse.Error))
+		JSON(w, http.StatusInternalServerError, listAPITokensResponse)
+		return
+	}
+
+	listAPITokensResponse.Tokens = []apitokentypes.APIToken{}
+	for _, token := range tokens {
+		if canManageAPIToken(token, sess) {
+			listAPITokensResponse.Tokens = append(listAPITokensResponse.Tokens, token)
+		}
+	}
+
+	JSON(w, http.StatusOK, listAPITokensResponse)
+}
+
+func (h *Handler) CreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	createAPITokenResponse := types.CreateAPITokenResponse{}
+
+	sess := session.ContextGetSession(r)
+	if sess == nil {
+		createAPITokenResponse.Error = "no session"
+		JSON(w, http.StatusUnauthorized, createAPITokenResponse)
+		return
+	}
+	if sess.APITokenID != "" {
+		createAPITokenResponse.Error = "api tokens cannot be used to create api tokens"
+		JSON(w, http.StatusForbidden, createAPITokenResponse)
+		return
+	}
+
+	createAPITokenRequest := types.CreateAPITokenRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&createAPITokenRequest); err != nil {
+		createAPITokenResponse.Error = "failed to decode request body"
+		logger.Error(errors.Wrap(err, createAPITokenResponse.Error))
+		JSON(w, http.StatusBadRequest, createAPITokenResponse)
+		return
+	}
+
+	name := strings.TrimSpace(createAPITokenRequest.Name)
+	if name == "" {
+		createAPITokenResponse.Error = "name is required"
+		JSON(w, http.StatusBadRequest, createAPITokenResponse)
+		return
+	}
+
+	expiresIn, err := apitoken.ParseExpiresIn(createAPITokenRequest.ExpiresIn)
+	if err != nil {
+		createAPITokenResponse.Error = err.Error()
+		JSON(w, http.StatusBadRequest, createAPITokenResponse)
+		return
+	}
+
+	roles := createAPITokenRequest.Roles
+	if len(roles) == 0 {
+		roles = sess.Roles
+	}
+	if err := validateGrantedRoles(store.GetStore(), roles, sess.Roles); err != nil {
+		createAPITokenResponse.Error = err.Error()
+		JSON(w, http.StatusBadRequest, createAPITokenResponse)
+		return
+	}
+
+	token, err := apitoken.Generate()
+	if err != nil {
+		createAPITokenResponse.Error = "failed to generate api token"
+		logger.Error(errors.Wrap(err, createAPITokenResponse.Error))
+		JSON(w, http.StatusInternalServerError, createAPITokenResponse)
+		return
+	}
+
+	now := time.Now()
+	apiToken := apitokentypes.APIToken{
+		ID:        ksuid.New().String(),
+		Name:      name,
+		Roles:     roles,
+		CreatedBy: sess.UserID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(expiresIn),
+	}
+
+	if err := store.GetStore().CreateAPIToken(apiToken, apitoken.Hash(token)); err != nil {
+		createAPITokenResponse.Error = "failed to create api token"
+		logger.Error(errors.Wrap(err, createAPITokenResponse.Error))
+		JSON(w, http.StatusInternalServerError, createAPITokenResponse)
+		return
+	}
+
+	createAPITokenResponse.Token = token
+	createAPITokenResponse.APIToken = &apiToken
+
+	JSON(w, http.StatusCreated, createAPITokenResponse)
+}
+
+func (h *Handler) DeleteAPIToken(w http.ResponseWriter, r *http.Request) {
+	sess := session.ContextGetSession(r)
+	if sess == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	tokenID := mux.Vars(r)["tokenId"]
+
+	tokens, err := store.GetStore().ListAPITokens()
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to list api tokens"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// tokens of other users are reported as not found, unless the session is a cluster admin
+	found := false
+	for _, token := range tokens {
+		if token.ID == tokenID && canManageAPIToken(token, sess) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if err := store.GetStore().DeleteAPIToken(tokenID); err != nil {
+		logger.Error(errors.Wrapf(err, "failed to delete api token %s", tokenID))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// canManageAPIToken returns true if the session created the token. Cluster admins can manage all tokens.
+func canManageAPIToken(token apitokentypes.APIToken, sess *sessiontypes.Session) bool {
+	for _, roleID := range sess.Roles {
+		if roleID == rbac.ClusterAdminRoleID {
+			return true
+		}
+	}
+	return token.CreatedBy == sess.UserID
+}
+
+// validateGrantedRoles ensures that the roles exist and that the session granting them holds them,
+// so that a token or a local user can never get more access than its creator has
+func validateGrantedRoles(lister rbac.RoleLister, roles []string, sessionRoles []string) error {
+	if len(roles) == 0 {
+		return errors.New("at least one role is required")
+	}
+
+	allRoles, err := rbac.GetRoles(lister, rbac.DefaultRoles(), roles)
+	if err != nil {
+		return errors.Wrap(err, "failed to get roles")
+	}
+
+	for _, roleID := range roles {
+		found := false
+		for _, role := range allRoles {
+			if role.ID == roleID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.Errorf("role %q does not exist", roleID)
+		}
+	}
+
+	if roleID := missingSessionRole(roles, sessionRoles); roleID != "" {
+		return errors.Errorf("cannot grant role %q that the session does not have", roleID)
+	}
+
+	return nil
+}
+
+// missingSessionRole returns the first of the roles that the session does not hold, or an empty string if it holds
+// all of them. Cluster admins hold all roles.
+func missingSessionRole(roles []string, sessionRoles []string) string {
+	for _, roleID := range sessionRoles {
+		if roleID == rbac.ClusterAdminRoleID {
+			return ""
+		}
+	}
+
+	for _, roleID := range roles {
+		held := false
+		for _, sessionRoleID := range sessionRoles {
+			if sessionRoleID == roleID {
+				held = true
+				break
+			}
+		}
+		if !held {
+			return roleID
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	apitokentypes "github.com/replicatedhq/kots/pkg/apitoken/types"
+	rbactypes "github.com/replicatedhq/kots/pkg/rbac/types"
+	sessiontypes "github.com/replicatedhq/kots/pkg/session/types"
+	mock_store "github.com/replicatedhq/kots/pkg/store/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_validateGrantedRoles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockStore := mock_store.NewMockStore(ctrl)
+
+	mockStore.EXPECT().ListRBACRoles().Return([]rbactypes.Role{{ID: "deployer"}}, nil).AnyTimes()
+
+	tests := []struct {
+		name         string
+		roles        []string
+		sessionRoles []string
+		wantErr      bool
+	}{
+		{
+			name:         "cluster admin can grant default roles",
+			roles:        []string{"support"},
+			sessionRoles: []string{"cluster-admin"},
+		},
+		{
+			name:         "cluster admin can grant custom roles",
+			roles:        []string{"deployer"},
+			sessionRoles: []string{"cluster-admin"},
+		},
+		{
+			name:         "roles held by the session can be granted",
+			roles:        []string{"deployer"},
+			sessionRoles: []string{"deployer"},
+		},
+		{
+			name:         "roles not held by the session cannot be granted",
+			roles:        []string{"cluster-admin"},
+			sessionRoles: []string{"deployer"},
+			wantErr:      true,
+		},
+		{
+			name:         "unknown role",
+			roles:        []string{"missing"},
+			sessionRoles: []string{"cluster-admin"},
+			wantErr:      true,
+		},
+		{
+			name:         "no roles",
+			roles:        nil,
+			sessionRoles: []string{"cluster-admin"},
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateGrantedRoles(mockStore, tt.roles, tt.sessionRoles)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_canManageAPIToken(t *testing.T) {
+	token := apitokentypes.APIToken{ID: "token-id", CreatedBy: "alice"}
+
+	require.True(t, canManageAPIToken(token, &sessiontypes.Session{UserID: "alice", Roles: []string{"deployer"}}))
+	require.False(t, canManageAPIToken(token, &sessiontypes.Session{UserID: "bob", Roles: []string{"deployer", "user-admin"}}))
+	require.True(t, canManageAPIToken(token, &sessiontypes.Session{UserID: "bob", Roles: []string{"cluster-admin"}}))
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
@@ -17,6 +18,7 @@ import (
 	"github.com/replicatedhq/kots/pkg/helm"
 	"github.com/replicatedhq/kots/pkg/kotsutil"
 	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/metrics"
 	"github.com/replicatedhq/kots/pkg/operator"
 	"github.com/replicatedhq/kots/pkg/rbac"
 	"github.com/replicatedhq/kots/pkg/registry"
@@ -55,10 +57,15 @@ func (h *Handler) GetPendingApp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	defaultRoles := rbac.DefaultRoles() // TODO (ethan): this should be set in the handler
-
 	if sess.HasRBAC { // handle pre-rbac sessions
-		allow, err := rbac.CheckAccess(r.Context(), defaultRoles, "read", fmt.Sprintf("app.%s", papp.Slug), sess.Roles)
+		roles, err := rbac.GetRoles(store.GetStore(), rbac.DefaultRoles(), sess.Roles) // TODO (ethan): this should be set in the handler
+		if err != nil {
+			logger.Error(errors.Wrap(err, "failed to get roles"))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		allow, err := rbac.CheckAccess(r.Context(), roles, "read", fmt.Sprintf("app.%s", papp.Slug), sess.Roles)
 		if err != nil {
 			logger.Error(errors.Wrapf(err, "failed to check access for pending app %s", papp.Slug))
 			w.WriteHeader(http.StatusInternalServerError)
@@ -132,11 +139,19 @@ func (h *Handler) ListApps(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	defaultRoles := rbac.DefaultRoles() // TODO (ethan): this should be set in the handler
+	roles := rbac.DefaultRoles() // TODO (ethan): this should be set in the handler
+	if sess.HasRBAC { // handle pre-rbac sessions
+		roles, err = rbac.GetRoles(store.GetStore(), roles, sess.Roles)
+		if err != nil {
+			logger.Error(errors.Wrap(err, "failed to get roles"))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
 
 	for _, a := range apps {
 		if sess.HasRBAC { // handle pre-rbac sessions
-			allow, err := rbac.CheckAccess(r.Context(), defaultRoles, "read", fmt.Sprintf("app.%s", a.Slug), sess.Roles)
+			allow, err := rbac.CheckAccess(r.Context(), roles, "read", fmt.Sprintf("app.%s", a.Slug), sess.Roles)
 			if err != nil {
 				logger.Error(errors.Wrapf(err, "failed to check access for app %s", a.Slug))
 				w.WriteHeader(http.StatusInternalServerError)
@@ -184,6 +199,39 @@ func (h *Handler) GetAppStatus(w http.ResponseWriter, r *http.Request) {
 	JSON(w, http.StatusOK, appStatusResponse)
 }
 
+func (h *Handler) GetAppStatusHistory(w http.ResponseWriter, r *http.Request) {
+	appSlug := mux.Vars(r)["appSlug"]
+	a, err := store.GetStore().GetAppFromSlug(appSlug)
+	if err != nil {
+		logger.Error(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	var sequence *int64
+	if sequenceStr := r.URL.Query().Get("sequence"); sequenceStr != "" {
+		s, err := strconv.ParseInt(sequenceStr, 10, 64)
+		if err != nil {
+			logger.Error(errors.Wrap(err, "failed to parse sequence"))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		sequence = &s
+	}
+
+	transitions, err := store.GetStore().GetAppStatusHistory(a.ID, sequence)
+	if err != nil {
+		logger.Error(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	appStatusHistoryResponse := types.AppStatusHistoryResponse{
+		Transitions: transitions,
+	}
+	JSON(w, http.StatusOK, appStatusHistoryResponse)
+}
+
 func (h *Handler) GetApp(w http.ResponseWriter, r *http.Request) {
 	appSlug := mux.Vars(r)["appSlug"]
 	responseApp := new(types.ResponseApp)
@@ -304,6 +352,7 @@ func responseAppFromApp(a *apptypes.App) (*types.ResponseApp, error) {
 			Branch:      downstreamGitOps.Branch,
 			Format:      downstreamGitOps.Format,
 			Action:      downstreamGitOps.Action,
+			Controller:  downstreamGitOps.Controller,
 			DeployKey:   downstreamGitOps.PublicKey,
 			IsConnected: downstreamGitOps.IsConnected,
 		}
@@ -358,6 +407,14 @@ func responseAppFromApp(a *apptypes.App) (*types.ResponseApp, error) {
 
 type GetAppVersionHistoryResponse struct {
 	downstreamtypes.DownstreamVersionHistory `json:",inline"`
+	PendingAutoDeploy                        *PendingAutoDeploy `json:"pendingAutoDeploy,omitempty"`
+}
+
+// PendingAutoDeploy is a version queued by auto deploy until the app's maintenance window opens
+type PendingAutoDeploy struct {
+	Sequence     int64     `json:"sequence"`
+	VersionLabel string    `json:"versionLabel"`
+	DeployAfter  time.Time `json:"deployAfter"`
 }
 
 func (h *Handler) GetAppVersionHistory(w http.ResponseWriter, r *http.Request) {
@@ -389,6 +446,7 @@ func (h *Handler) GetAppVersionHistory(w http.ResponseWriter, r *http.Request) {
 
 	appSlug := mux.Vars(r)["appSlug"]
 	history := new(downstreamtypes.DownstreamVersionHistory)
+	var pendingAutoDeploy *PendingAutoDeploy
 	if util.IsHelmManaged() {
 		release := helm.GetHelmApp(appSlug)
 		if release == nil {
@@ -468,14 +526,45 @@ func (h *Handler) GetAppVersionHistory(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
+
+		pendingAutoDeploy, err = getPendingAutoDeploy(foundApp)
+		if err != nil {
+			err = errors.Wrap(err, "failed to get pending auto deploy")
+			logger.Error(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
 	}
 	response := GetAppVersionHistoryResponse{
 		DownstreamVersionHistory: *history,
+		PendingAutoDeploy:        pendingAutoDeploy,
 	}
 
 	JSON(w, http.StatusOK, response)
 }
 
+func getPendingAutoDeploy(a *apptypes.App) (*PendingAutoDeploy, error) {
+	if a.PendingAutoDeploySequence == nil {
+		return nil, nil
+	}
+
+	appVersion, err := store.GetStore().GetAppVersion(a.ID, *a.PendingAutoDeploySequence)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get app version")
+	}
+
+	deployAfter, err := a.MaintenanceWindow.NextOpen(time.Now())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get next maintenance window")
+	}
+
+	return &PendingAutoDeploy{
+		Sequence:     appVersion.Sequence,
+		VersionLabel: appVersion.VersionLabel,
+		DeployAfter:  deployAfter,
+	}, nil
+}
+
 type RemoveAppRequest struct {
 	Undeploy bool `json:"undeploy"`
 	Force    bool `json:"force"`
@@ -560,6 +649,7 @@ func (h *Handler) RemoveApp(w http.ResponseWriter, r *http.Request) {
 		JSON(w, http.StatusInternalServerError, response)
 		return
 	}
+	metrics.DeleteAppState(app.ID)
 
 	JSON(w, http.StatusOK, response)
 }
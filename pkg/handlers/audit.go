@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/api/handlers/types"
+	audittypes "github.com/replicatedhq/kots/pkg/audit/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/store"
+)
+
+func (h *Handler) ListAuditEvents(w http.ResponseWriter, r *http.Request) {
+	listAuditEventsResponse := types.ListAuditEventsResponse{
+		Events: []audittypes.Event{},
+	}
+
+	opts, err := getAuditListOptions(r, 20)
+	if err != nil {
+		listAuditEventsResponse.Error = err.Error()
+		logger.Error(err)
+		JSON(w, http.StatusBadRequest, listAuditEventsResponse)
+		return
+	}
+
+	events, totalCount, err := store.GetStore().ListAuditEvents(*opts)
+	if err != nil {
+		listAuditEventsResponse.Error = "failed to list audit events"
+		logger.Error(errors.Wrap(err, listAuditEventsResponse.Error))
+		JSON(w, http.StatusInternalServerError, listAuditEventsResponse)
+		return
+	}
+
+	listAuditEventsResponse.Events = events
+	listAuditEventsResponse.TotalCount = totalCount
+
+	JSON(w, http.StatusOK, listAuditEventsResponse)
+}
+
+// ExportAuditEvents streams the matching events as newline delimited json, which can be ingested by a SIEM.
+// Unlike ListAuditEvents, all matching events are returned unless a page size is specified.
+func (h *Handler) ExportAuditEvents(w http.ResponseWriter, r *http.Request) {
+	opts, err := getAuditListOptions(r, 0)
+	if err != nil {
+		logger.Error(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	events, _, err := store.GetStore().ListAuditEvents(*opts)
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to list audit events"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("kots-audit-%s.ndjson", time.Now().UTC().Format("20060102T150405Z"))
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			logger.Error(errors.Wrap(err, "failed to encode audit event"))
+			return
+		}
+	}
+}
+
+func getAuditListOptions(r *http.Request, defaultPageSize int) (*audittypes.ListOptions, error) {
+	opts := audittypes.ListOptions{
+		PageSize: defaultPageSize,
+		UserID:   r.URL.Query().Get("userId"),
+		Action:   r.URL.Query().Get("action"),
+	}
+
+	if val := r.URL.Query().Get("pageSize"); val != "" {
+		ps, err := strconv.Atoi(val)
+		if err != nil || ps < 0 {
+			return nil, errors.Errorf("invalid page size %q", val)
+		}
+		opts.PageSize = ps
+	}
+	if val := r.URL.Query().Get("currentPage"); val != "" {
+		cp, err := strconv.Atoi(val)
+		if err != nil || cp < 0 {
+			return nil, errors.Errorf("invalid current page %q", val)
+		}
+		opts.CurrentPage = cp
+	}
+	if val := r.URL.Query().Get("since"); val != "" {
+		since, err := time.Parse(time.RFC3339, val)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse since")
+		}
+		opts.Since = &since
+	}
+	if val := r.URL.Query().Get("until"); val != "" {
+		until, err := time.Parse(time.RFC3339, val)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse until")
+		}
+		opts.Until = &until
+	}
+
+	return &opts, nil
+}
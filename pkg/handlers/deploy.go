@@ -9,6 +9,7 @@ import (
 	"github.com/blang/semver"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
+	downstreamtypes "github.com/replicatedhq/kots/pkg/api/downstream/types"
 	apptypes "github.com/replicatedhq/kots/pkg/app/types"
 	"github.com/replicatedhq/kots/pkg/buildversion"
 	"github.com/replicatedhq/kots/pkg/kotsutil"
@@ -121,14 +122,27 @@ func (h *Handler) DeployAppVersion(w http.ResponseWriter, r *http.Request) {
 		JSON(w, http.StatusInternalServerError, deployAppVersionResponse)
 		return
 	}
-	for _, v := range versions.PastVersions {
-		if int64(sequence) == v.Sequence {
-			// a past version is being deployed/rolled back to, disable automatic deployments so that it doesn't undo this action later
-			logger.Infof("disabling automatic deployments because a past version is being deployed for app %s", a.Slug)
-			if err := store.GetStore().SetAutoDeploy(a.ID, apptypes.AutoDeployDisabled); err != nil {
-				logger.Error(errors.Wrap(err, "failed to set versioning auto deploy"))
-			}
-			break
+
+	nonDeployableCause, err := getNonDeployableCause(store.GetStore(), a.ID, int64(sequence), versions)
+	if err != nil {
+		errMsg := "failed to check if version is deployable"
+		logger.Error(errors.Wrap(err, errMsg))
+		deployAppVersionResponse.Error = errMsg
+		JSON(w, http.StatusInternalServerError, deployAppVersionResponse)
+		return
+	}
+	if nonDeployableCause != "" {
+		logger.Error(errors.Errorf("not deploying version %d: %s", int64(sequence), nonDeployableCause))
+		deployAppVersionResponse.Error = nonDeployableCause
+		JSON(w, http.StatusBadRequest, deployAppVersionResponse)
+		return
+	}
+
+	if isPastVersion(versions, int64(sequence)) {
+		// a past version is being deployed/rolled back to, disable automatic deployments so that it doesn't undo this action later
+		logger.Infof("disabling automatic deployments because a past version is being deployed for app %s", a.Slug)
+		if err := store.GetStore().SetAutoDeploy(a.ID, apptypes.AutoDeployDisabled); err != nil {
+			logger.Error(errors.Wrap(err, "failed to set versioning auto deploy"))
 		}
 	}
 
@@ -167,6 +181,53 @@ func (h *Handler) DeployAppVersion(w http.ResponseWriter, r *http.Request) {
 	JSON(w, http.StatusOK, deployAppVersionResponse)
 }
 
+// getNonDeployableCause returns the reason that a version cannot be deployed, or an empty string if it can be deployed.
+// Past versions can only be deployed if the latest downloaded version supports rollbacks.
+func getNonDeployableCause(kotsStore store.Store, appID string, sequence int64, versions *downstreamtypes.DownstreamVersions) (string, error) {
+	isDeployable, nonDeployableCause, err := kotsStore.IsAppVersionDeployable(appID, sequence)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to check if version is deployable")
+	}
+	if !isDeployable {
+		return nonDeployableCause, nil
+	}
+
+	if !isPastVersion(versions, sequence) {
+		return "", nil
+	}
+
+	// rollback support is based off of the latest downloaded version
+	var latestVersion *downstreamtypes.DownstreamVersion
+	for _, v := range versions.AllVersions {
+		if v.Status != storetypes.VersionPendingDownload {
+			latestVersion = v
+			break
+		}
+	}
+	if latestVersion == nil {
+		return "Rollback is not supported.", nil
+	}
+
+	allowRollback, err := kotsStore.IsRollbackSupportedForVersion(appID, latestVersion.ParentSequence)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to check if rollback is supported")
+	}
+	if !allowRollback {
+		return "Rollback is not supported.", nil
+	}
+
+	return "", nil
+}
+
+func isPastVersion(versions *downstreamtypes.DownstreamVersions, sequence int64) bool {
+	for _, v := range versions.PastVersions {
+		if v.Sequence == sequence {
+			return true
+		}
+	}
+	return false
+}
+
 type AdminConsoleUpgradeError struct {
 	IsCritical bool
 	Message    string
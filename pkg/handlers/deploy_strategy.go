@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	apptypes "github.com/replicatedhq/kots/pkg/app/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/store"
+)
+
+type SetDeployStrategyConfigRequest struct {
+	DeployStrategy     apptypes.DeployStrategy `json:"deployStrategy"`
+	VerificationWindow string                  `json:"verificationWindow"`
+}
+
+type SetDeployStrategyConfigResponse struct {
+	Error string `json:"error"`
+}
+
+type GetDeployStrategyConfigResponse struct {
+	DeployStrategy     apptypes.DeployStrategy `json:"deployStrategy"`
+	VerificationWindow string                  `json:"verificationWindow"`
+	Error              string                  `json:"error"`
+}
+
+func (h *Handler) SetDeployStrategyConfig(w http.ResponseWriter, r *http.Request) {
+	setDeployStrategyResponse := &SetDeployStrategyConfigResponse{}
+
+	setDeployStrategyRequest := SetDeployStrategyConfigRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&setDeployStrategyRequest); err != nil {
+		setDeployStrategyResponse.Error = "failed to decode request body"
+		logger.Error(errors.Wrap(err, setDeployStrategyResponse.Error))
+		JSON(w, http.StatusBadRequest, setDeployStrategyResponse)
+		return
+	}
+
+	switch setDeployStrategyRequest.DeployStrategy {
+	case apptypes.DeployStrategyDirect, apptypes.DeployStrategyProgressive:
+	default:
+		setDeployStrategyResponse.Error = "unsupported deploy strategy"
+		logger.Error(errors.Errorf("%s %q", setDeployStrategyResponse.Error, setDeployStrategyRequest.DeployStrategy))
+		JSON(w, http.StatusBadRequest, setDeployStrategyResponse)
+		return
+	}
+
+	if setDeployStrategyRequest.VerificationWindow != "" {
+		window, err := time.ParseDuration(setDeployStrategyRequest.VerificationWindow)
+		if err != nil {
+			setDeployStrategyResponse.Error = "failed to parse verification window"
+			logger.Error(errors.Wrap(err, setDeployStrategyResponse.Error))
+			JSON(w, http.StatusBadRequest, setDeployStrategyResponse)
+			return
+		}
+		if window <= 0 {
+			setDeployStrategyResponse.Error = "verification window must be positive"
+			logger.Error(errors.New(setDeployStrategyResponse.Error))
+			JSON(w, http.StatusBadRequest, setDeployStrategyResponse)
+			return
+		}
+	}
+
+	foundApp, err := store.GetStore().GetAppFromSlug(mux.Vars(r)["appSlug"])
+	if err != nil {
+		setDeployStrategyResponse.Error = "failed to get app from slug"
+		logger.Error(errors.Wrap(err, setDeployStrategyResponse.Error))
+		JSON(w, http.StatusInternalServerError, setDeployStrategyResponse)
+		return
+	}
+
+	if err := store.GetStore().SetDeployStrategy(foundApp.ID, setDeployStrategyRequest.DeployStrategy, setDeployStrategyRequest.VerificationWindow); err != nil {
+		setDeployStrategyResponse.Error = "failed to set deploy strategy"
+		logger.Error(errors.Wrap(err, setDeployStrategyResponse.Error))
+		JSON(w, http.StatusInternalServerError, setDeployStrategyResponse)
+		return
+	}
+
+	JSON(w, http.StatusNoContent, "")
+}
+
+func (h *Handler) GetDeployStrategyConfig(w http.ResponseWriter, r *http.Request) {
+	getDeployStrategyResponse := &GetDeployStrategyConfigResponse{}
+
+	foundApp, err := store.GetStore().GetAppFromSlug(mux.Vars(r)["appSlug"])
+	if err != nil {
+		getDeployStrategyResponse.Error = "failed to get app from slug"
+		logger.Error(errors.Wrap(err, getDeployStrategyResponse.Error))
+		JSON(w, http.StatusInternalServerError, getDeployStrategyResponse)
+		return
+	}
+
+	getDeployStrategyResponse.DeployStrategy = foundApp.DeployStrategy
+	getDeployStrategyResponse.VerificationWindow = foundApp.VerificationWindow
+	if getDeployStrategyResponse.VerificationWindow == "" {
+		getDeployStrategyResponse.VerificationWindow = apptypes.DefaultDeployVerificationWindow
+	}
+
+	JSON(w, http.StatusOK, getDeployStrategyResponse)
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	downstreamtypes "github.com/replicatedhq/kots/pkg/api/downstream/types"
+	mock_store "github.com/replicatedhq/kots/pkg/store/mock"
+	storetypes "github.com/replicatedhq/kots/pkg/store/types"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_getNonDeployableCause(t *testing.T) {
+	versions := &downstreamtypes.DownstreamVersions{
+		AllVersions: []*downstreamtypes.DownstreamVersion{
+			{Sequence: 4, ParentSequence: 4, Status: storetypes.VersionPendingDownload},
+			{Sequence: 3, ParentSequence: 3, Status: storetypes.VersionPending},
+			{Sequence: 2, ParentSequence: 2, Status: storetypes.VersionDeployed},
+			{Sequence: 1, ParentSequence: 1, Status: storetypes.VersionDeployed},
+		},
+		PendingVersions: []*downstreamtypes.DownstreamVersion{
+			{Sequence: 3, ParentSequence: 3},
+		},
+		PastVersions: []*downstreamtypes.DownstreamVersion{
+			{Sequence: 1, ParentSequence: 1},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		sequence  int64
+		mockStore func(mockStore *mock_store.MockStore)
+		wantCause string
+		wantErr   bool
+	}{
+		{
+			name:     "pending version is deployable",
+			sequence: 3,
+			mockStore: func(mockStore *mock_store.MockStore) {
+				mockStore.EXPECT().IsAppVersionDeployable("app-id", int64(3)).Return(true, "", nil)
+			},
+		},
+		{
+			name:     "version is not deployable",
+			sequence: 3,
+			mockStore: func(mockStore *mock_store.MockStore) {
+				mockStore.EXPECT().IsAppVersionDeployable("app-id", int64(3)).Return(false, "Deployment is disabled as a strict analyzer in this version's preflight checks has failed or has not been run.", nil)
+			},
+			wantCause: "Deployment is disabled as a strict analyzer in this version's preflight checks has failed or has not been run.",
+		},
+		{
+			name:     "past version when rollback is supported",
+			sequence: 1,
+			mockStore: func(mockStore *mock_store.MockStore) {
+				mockStore.EXPECT().IsAppVersionDeployable("app-id", int64(1)).Return(true, "", nil)
+				mockStore.EXPECT().IsRollbackSupportedForVersion("app-id", int64(3)).Return(true, nil)
+			},
+		},
+		{
+			name:     "past version when rollback is not supported",
+			sequence: 1,
+			mockStore: func(mockStore *mock_store.MockStore) {
+				mockStore.EXPECT().IsAppVersionDeployable("app-id", int64(1)).Return(true, "", nil)
+				mockStore.EXPECT().IsRollbackSupportedForVersion("app-id", int64(3)).Return(false, nil)
+			},
+			wantCause: "Rollback is not supported.",
+		},
+		{
+			name:     "failed to check if version is deployable",
+			sequence: 3,
+			mockStore: func(mockStore *mock_store.MockStore) {
+				mockStore.EXPECT().IsAppVersionDeployable("app-id", int64(3)).Return(false, "", errors.New("version 3 not found"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mock_store.NewMockStore(ctrl)
+			test.mockStore(mockStore)
+
+			cause, err := getNonDeployableCause(mockStore, "app-id", test.sequence, versions)
+			if test.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.wantCause, cause)
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	apitypes "github.com/replicatedhq/kots/pkg/api/handlers/types"
+	"github.com/replicatedhq/kots/pkg/apparchive"
+	"github.com/replicatedhq/kots/pkg/handlers/types"
+	"github.com/replicatedhq/kots/pkg/kotsutil"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/store"
+	storetypes "github.com/replicatedhq/kots/pkg/store/types"
+)
+
+// GetAppVersionsDiff returns the resources and helm chart values that changed between the
+// rendered manifests of the "from" and "to" sequences of an app
+func (h *Handler) GetAppVersionsDiff(w http.ResponseWriter, r *http.Request) {
+	appSlug := mux.Vars(r)["appSlug"]
+
+	fromSequence, err := strconv.ParseInt(r.URL.Query().Get("from"), 10, 64)
+	if err != nil {
+		JSON(w, http.StatusBadRequest, types.NewErrorResponse(errors.Errorf("invalid from sequence %q", r.URL.Query().Get("from"))))
+		return
+	}
+	toSequence, err := strconv.ParseInt(r.URL.Query().Get("to"), 10, 64)
+	if err != nil {
+		JSON(w, http.StatusBadRequest, types.NewErrorResponse(errors.Errorf("invalid to sequence %q", r.URL.Query().Get("to"))))
+		return
+	}
+
+	a, err := store.GetStore().GetAppFromSlug(appSlug)
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get app from slug"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	downstreams, err := store.GetStore().ListDownstreamsForApp(a.ID)
+	if err != nil {
+		logger.Error(errors.Wrapf(err, "failed to list downstreams for app %q", a.Slug))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if len(downstreams) == 0 {
+		logger.Error(errors.Errorf("no downstreams found for app %q", a.Slug))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	d := downstreams[0]
+
+	for _, sequence := range []int64{fromSequence, toSequence} {
+		status, err := store.GetStore().GetDownstreamVersionStatus(a.ID, sequence)
+		if err != nil {
+			logger.Error(errors.Wrapf(err, "failed to get status of version %d", sequence))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if status == "" {
+			JSON(w, http.StatusNotFound, types.NewErrorResponse(errors.Errorf("version %d not found", sequence)))
+			return
+		}
+		if status == storetypes.VersionPendingDownload {
+			JSON(w, http.StatusBadRequest, types.NewErrorResponse(errors.Errorf("version %d has not been downloaded", sequence)))
+			return
+		}
+	}
+
+	fromArchive, err := os.MkdirTemp("", "kotsadm")
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to create temp dir"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer os.RemoveAll(fromArchive)
+
+	if err := store.GetStore().GetAppVersionArchive(a.ID, fromSequence, fromArchive); err != nil {
+		logger.Error(errors.Wrapf(err, "failed to get archive of version %d", fromSequence))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	toArchive, err := os.MkdirTemp("", "kotsadm")
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to create temp dir"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer os.RemoveAll(toArchive)
+
+	if err := store.GetStore().GetAppVersionArchive(a.ID, toSequence, toArchive); err != nil {
+		logger.Error(errors.Wrapf(err, "failed to get archive of version %d", toSequence))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	kotsKinds, err := kotsutil.LoadKotsKindsFromPath(filepath.Join(toArchive, "upstream"))
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to load kots kinds from path"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	diff, err := apparchive.DiffAppVersionResources(d.Name, fromArchive, toArchive, kotsKinds.GetKustomizeBinaryPath())
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to diff app versions"))
+		JSON(w, http.StatusInternalServerError, types.NewErrorResponse(err))
+		return
+	}
+
+	JSON(w, http.StatusOK, apitypes.AppVersionsDiffResponse{
+		FromSequence: fromSequence,
+		ToSequence:   toSequence,
+		VersionsDiff: *diff,
+	})
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -18,6 +19,7 @@ import (
 	"github.com/replicatedhq/kots/pkg/logger"
 	"github.com/replicatedhq/kots/pkg/store"
 	storetypes "github.com/replicatedhq/kots/pkg/store/types"
+	"github.com/replicatedhq/kots/pkg/tracing"
 	upstreamtypes "github.com/replicatedhq/kots/pkg/upstream/types"
 	"github.com/replicatedhq/kots/pkg/util"
 )
@@ -263,7 +265,7 @@ func (h *Handler) DownloadAppVersion(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	downloadFn := func(appID string, version *versiontypes.AppVersion, skipPreflights bool, skipCompatibilityCheck bool) error {
+	downloadFn := func(ctx context.Context, appID string, version *versiontypes.AppVersion, skipPreflights bool, skipCompatibilityCheck bool) error {
 		appSequence := version.Sequence
 		update := upstreamtypes.Update{
 			ChannelID:    version.KOTSKinds.Installation.Spec.ChannelID,
@@ -273,7 +275,7 @@ func (h *Handler) DownloadAppVersion(w http.ResponseWriter, r *http.Request) {
 			IsRequired:   version.KOTSKinds.Installation.Spec.IsRequired,
 			AppSequence:  &appSequence,
 		}
-		_, err := upstream.DownloadUpdate(appID, update, skipPreflights, skipCompatibilityCheck)
+		_, err := upstream.DownloadUpdate(ctx, appID, update, skipPreflights, skipCompatibilityCheck)
 		if err != nil {
 			return errors.Wrapf(err, "failed to download update %s", update.VersionLabel)
 		}
@@ -281,7 +283,7 @@ func (h *Handler) DownloadAppVersion(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if wait {
-		if err := downloadFn(a.ID, version, skipPreflights, skipCompatibilityCheck); err != nil {
+		if err := downloadFn(r.Context(), a.ID, version, skipPreflights, skipCompatibilityCheck); err != nil {
 			cause := errors.Cause(err)
 			if _, ok := cause.(util.ActionableError); ok {
 				downloadUpstreamVersionResponse.Error = cause.Error()
@@ -294,7 +296,7 @@ func (h *Handler) DownloadAppVersion(w http.ResponseWriter, r *http.Request) {
 		}
 	} else {
 		go func() {
-			if err := downloadFn(a.ID, version, skipPreflights, skipCompatibilityCheck); err != nil {
+			if err := downloadFn(tracing.Detach(r.Context()), a.ID, version, skipPreflights, skipCompatibilityCheck); err != nil {
 				logger.Error(errors.Wrap(err, "failed asynchronously"))
 			}
 		}()
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	apitypes "github.com/replicatedhq/kots/pkg/api/handlers/types"
+	"github.com/replicatedhq/kots/pkg/drift"
+	"github.com/replicatedhq/kots/pkg/handlers/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/store"
+)
+
+// GetAppDrift returns the last drift report of an app. When the "refresh" query param is true,
+// drift is checked again before returning the report.
+func (h *Handler) GetAppDrift(w http.ResponseWriter, r *http.Request) {
+	appSlug := mux.Vars(r)["appSlug"]
+	a, err := store.GetStore().GetAppFromSlug(appSlug)
+	if err != nil {
+		logger.Error(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	refresh, _ := strconv.ParseBool(r.URL.Query().Get("refresh"))
+	if refresh {
+		report, err := drift.Detect(a.ID)
+		if errors.Cause(err) == drift.ErrNotDeployed {
+			JSON(w, http.StatusBadRequest, types.NewErrorResponse(err))
+			return
+		} else if err != nil {
+			logger.Error(errors.Wrap(err, "failed to detect drift"))
+			JSON(w, http.StatusInternalServerError, types.NewErrorResponse(err))
+			return
+		}
+		JSON(w, http.StatusOK, apitypes.AppDriftResponse{Report: report})
+		return
+	}
+
+	report, err := store.GetStore().GetAppDriftReport(a.ID)
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get drift report"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	JSON(w, http.StatusOK, apitypes.AppDriftResponse{Report: report})
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/encryptionkey"
+	"github.com/replicatedhq/kots/pkg/k8sutil"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/util"
+)
+
+type RotateEncryptionKeyResponse struct {
+	Success bool                        `json:"success"`
+	Result  *encryptionkey.RotateResult `json:"result,omitempty"`
+	Error   string                      `json:"error,omitempty"`
+}
+
+func (h *Handler) RotateEncryptionKey(w http.ResponseWriter, r *http.Request) {
+	response := RotateEncryptionKeyResponse{}
+
+	clientset, err := k8sutil.GetClientset()
+	if err != nil {
+		response.Error = "failed to get k8s clientset"
+		logger.Error(errors.Wrap(err, response.Error))
+		JSON(w, http.StatusInternalServerError, response)
+		return
+	}
+
+	result, err := encryptionkey.Rotate(clientset, util.PodNamespace)
+	if err != nil {
+		response.Error = "failed to rotate encryption key"
+		logger.Error(errors.Wrap(err, response.Error))
+		JSON(w, http.StatusInternalServerError, response)
+		return
+	}
+
+	logger.Infof("rotated encryption key to %s", result.KeyID)
+
+	response.Success = true
+	response.Result = result
+
+	JSON(w, http.StatusOK, response)
+}
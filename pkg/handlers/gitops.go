@@ -19,11 +19,12 @@ type UpdateAppGitOpsRequest struct {
 	GitOpsInput UpdateAppGitOpsInput `json:"gitOpsInput"`
 }
 type UpdateAppGitOpsInput struct {
-	URI    string `json:"uri"`
-	Branch string `json:"branch"`
-	Path   string `json:"path"`
-	Format string `json:"format"`
-	Action string `json:"action"`
+	URI        string `json:"uri"`
+	Branch     string `json:"branch"`
+	Path       string `json:"path"`
+	Format     string `json:"format"`
+	Action     string `json:"action"`
+	Controller string `json:"controller"`
 }
 
 type CreateGitOpsRequest struct {
@@ -35,6 +36,8 @@ type CreateGitOpsInput struct {
 	Hostname string `json:"hostname"`
 	HTTPPort string `json:"httpPort"`
 	SSHPort  string `json:"sshPort"`
+	APIURL   string `json:"apiUrl"`
+	APIToken string `json:"apiToken"`
 }
 
 func (h *Handler) UpdateAppGitOps(w http.ResponseWriter, r *http.Request) {
@@ -48,6 +51,30 @@ func (h *Handler) UpdateAppGitOps(w http.ResponseWriter, r *http.Request) {
 	appID := mux.Vars(r)["appId"]
 	clusterID := mux.Vars(r)["clusterId"]
 
+	gitOpsInput := updateAppGitOpsRequest.GitOpsInput
+	switch gitOpsInput.Action {
+	case "", gitops.ActionCommit, gitops.ActionPullRequest:
+	default:
+		logger.Errorf("unsupported gitops action %q", gitOpsInput.Action)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if !gitops.IsValidFormat(gitOpsInput.Format) {
+		logger.Errorf("unsupported gitops format %q", gitOpsInput.Format)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if !gitops.IsValidController(gitOpsInput.Controller) {
+		logger.Errorf("unsupported gitops controller %q", gitOpsInput.Controller)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if gitOpsInput.Controller != "" && gitOpsInput.Format != gitops.FormatPerResource && gitOpsInput.Format != gitops.FormatKustomize {
+		logger.Errorf("gitops controller %q requires the %s or %s format", gitOpsInput.Controller, gitops.FormatPerResource, gitops.FormatKustomize)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
 	a, err := store.GetStore().GetApp(appID)
 	if err != nil {
 		logger.Error(err)
@@ -55,8 +82,7 @@ func (h *Handler) UpdateAppGitOps(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	gitOpsInput := updateAppGitOpsRequest.GitOpsInput
-	if err := gitops.UpdateDownstreamGitOps(a.ID, clusterID, gitOpsInput.URI, gitOpsInput.Branch, gitOpsInput.Path, gitOpsInput.Format, gitOpsInput.Action); err != nil {
+	if err := gitops.UpdateDownstreamGitOps(a.ID, clusterID, gitOpsInput.URI, gitOpsInput.Branch, gitOpsInput.Path, gitOpsInput.Format, gitOpsInput.Action, gitOpsInput.Controller); err != nil {
 		logger.Error(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -167,7 +193,7 @@ func (h *Handler) InitGitOpsConnection(w http.ResponseWriter, r *http.Request) {
 	// If a branch is not provided, use the default branch
 	if downstreamGitOps.Branch == "" {
 		err := gitops.UpdateDownstreamGitOps(a.ID, d.ClusterID, downstreamGitOps.RepoURI, defaultBranchName,
-			downstreamGitOps.Path, downstreamGitOps.Format, downstreamGitOps.Action)
+			downstreamGitOps.Path, downstreamGitOps.Format, downstreamGitOps.Action, downstreamGitOps.Controller)
 		if err != nil {
 			logger.Infof("Failed to update the gitops configmap with the default branch: %v", err)
 
@@ -310,7 +336,7 @@ func (h *Handler) CreateGitOps(w http.ResponseWriter, r *http.Request) {
 	}
 
 	gitOpsInput := createGitOpsRequest.GitOpsInput
-	if err := gitops.CreateGitOps(gitOpsInput.Provider, gitOpsInput.URI, gitOpsInput.Hostname, gitOpsInput.HTTPPort, gitOpsInput.SSHPort); err != nil {
+	if err := gitops.CreateGitOps(gitOpsInput.Provider, gitOpsInput.URI, gitOpsInput.Hostname, gitOpsInput.HTTPPort, gitOpsInput.SSHPort, gitOpsInput.APIURL, gitOpsInput.APIToken); err != nil {
 		logger.Error(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -6,6 +6,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
+	"github.com/replicatedhq/kots/pkg/audit"
 	"github.com/replicatedhq/kots/pkg/logger"
 	"github.com/replicatedhq/kots/pkg/policy"
 	"github.com/replicatedhq/kots/pkg/store"
@@ -90,6 +91,42 @@ func RegisterSessionAuthRoutes(r *mux.Router, kotsStore store.Store, handler KOT
 	r.Name("GetIdentityServiceConfig").Path("/api/v1/identity/config").Methods("GET").
 		HandlerFunc(middleware.EnforceAccess(policy.IdentityServiceRead, handler.GetIdentityServiceConfig))
 
+	// RBAC roles
+	r.Name("ListRBACRoles").Path("/api/v1/rbac/roles").Methods("GET").
+		HandlerFunc(middleware.EnforceAccess(policy.RBACRolesRead, handler.ListRBACRoles))
+	r.Name("CreateRBACRole").Path("/api/v1/rbac/roles").Methods("POST").
+		HandlerFunc(middleware.EnforceAccess(policy.RBACRolesWrite, handler.CreateRBACRole))
+	r.Name("UpdateRBACRole").Path("/api/v1/rbac/role/{roleId}").Methods("PUT").
+		HandlerFunc(middleware.EnforceAccess(policy.RBACRolesWrite, handler.UpdateRBACRole))
+	r.Name("DeleteRBACRole").Path("/api/v1/rbac/role/{roleId}").Methods("DELETE").
+		HandlerFunc(middleware.EnforceAccess(policy.RBACRolesWrite, handler.DeleteRBACRole))
+
+	// API tokens
+	r.Name("ListAPITokens").Path("/api/v1/tokens").Methods("GET").
+		HandlerFunc(middleware.EnforceAccess(policy.APITokensRead, handler.ListAPITokens))
+	r.Name("CreateAPIToken").Path("/api/v1/tokens").Methods("POST").
+		HandlerFunc(middleware.EnforceAccess(policy.APITokensWrite, handler.CreateAPIToken))
+	r.Name("DeleteAPIToken").Path("/api/v1/token/{tokenId}").Methods("DELETE").
+		HandlerFunc(middleware.EnforceAccess(policy.APITokensWrite, handler.DeleteAPIToken))
+
+	// Local users
+	r.Name("ListLocalUsers").Path("/api/v1/users").Methods("GET").
+		HandlerFunc(middleware.EnforceAccess(policy.LocalUsersRead, handler.ListLocalUsers))
+	r.Name("CreateLocalUser").Path("/api/v1/users").Methods("POST").
+		HandlerFunc(middleware.EnforceAccess(policy.LocalUsersWrite, handler.CreateLocalUser))
+	r.Name("UpdateLocalUser").Path("/api/v1/user/{username}").Methods("PUT").
+		HandlerFunc(middleware.EnforceAccess(policy.LocalUsersWrite, handler.UpdateLocalUser))
+	r.Name("SetLocalUserPassword").Path("/api/v1/user/{username}/password").Methods("PUT").
+		HandlerFunc(middleware.EnforceAccess(policy.LocalUsersWrite, handler.SetLocalUserPassword))
+	r.Name("DeleteLocalUser").Path("/api/v1/user/{username}").Methods("DELETE").
+		HandlerFunc(middleware.EnforceAccess(policy.LocalUsersWrite, handler.DeleteLocalUser))
+
+	// Audit log
+	r.Name("ListAuditEvents").Path("/api/v1/audit").Methods("GET").
+		HandlerFunc(middleware.EnforceAccess(policy.AuditRead, handler.ListAuditEvents))
+	r.Name("ExportAuditEvents").Path("/api/v1/audit/export").Methods("GET").
+		HandlerFunc(middleware.EnforceAccess(policy.AuditRead, handler.ExportAuditEvents))
+
 	// App Identity Service
 	r.Name("ConfigureAppIdentityService").Path("/api/v1/app/{appSlug}/identity/config").Methods("POST").
 		HandlerFunc(middleware.EnforceAccess(policy.AppIdentityServiceWrite, handler.ConfigureAppIdentityService))
@@ -105,6 +142,10 @@ func RegisterSessionAuthRoutes(r *mux.Router, kotsStore store.Store, handler KOT
 		HandlerFunc(middleware.EnforceAccess(policy.AppRead, handler.GetApp))
 	r.Name("GetAppStatus").Path("/api/v1/app/{appSlug}/status").Methods("GET").
 		HandlerFunc(middleware.EnforceAccess(policy.AppStatusRead, handler.GetAppStatus))
+	r.Name("GetAppStatusHistory").Path("/api/v1/app/{appSlug}/status/history").Methods("GET").
+		HandlerFunc(middleware.EnforceAccess(policy.AppStatusRead, handler.GetAppStatusHistory))
+	r.Name("GetAppDrift").Path("/api/v1/app/{appSlug}/drift").Methods("GET").
+		HandlerFunc(middleware.EnforceAccess(policy.AppStatusRead, handler.GetAppDrift))
 	r.Name("GetAppVersionHistory").Path("/api/v1/app/{appSlug}/versions").Methods("GET").
 		HandlerFunc(middleware.EnforceAccess(policy.AppDownstreamRead, handler.GetAppVersionHistory))
 	r.Name("GetLatestDeployableVersion").Path("/api/v1/app/{appSlug}/next-app-version").Methods("GET").
@@ -155,11 +196,17 @@ func RegisterSessionAuthRoutes(r *mux.Router, kotsStore store.Store, handler KOT
 	r.Name("GetAppVersionDownloadStatus").Path("/api/v1/app/{appSlug}/sequence/{sequence}/task/updatedownload").Methods("GET").
 		HandlerFunc(middleware.EnforceAccess(policy.AppRead, handler.GetAppVersionDownloadStatus)) // NOTE: appSlug is unused
 	r.Name("DeployAppVersion").Path("/api/v1/app/{appSlug}/sequence/{sequence}/deploy").Methods("POST").
-		HandlerFunc(middleware.EnforceAccess(policy.AppDownstreamWrite, handler.DeployAppVersion))
+		HandlerFunc(middleware.EnforceAccess(policy.AppDownstreamDeploy, handler.DeployAppVersion))
 	r.Name("RedeployAppVersion").Path("/api/v1/app/{appSlug}/sequence/{sequence}/redeploy").Methods("POST").
-		HandlerFunc(middleware.EnforceAccess(policy.AppDownstreamWrite, handler.RedeployAppVersion))
+		HandlerFunc(middleware.EnforceAccess(policy.AppDownstreamDeploy, handler.RedeployAppVersion))
+	r.Name("RollbackAppVersion").Path("/api/v1/app/{appSlug}/rollback").Methods("POST").
+		HandlerFunc(middleware.EnforceAccess(policy.AppDownstreamDeploy, handler.RollbackAppVersion))
 	r.Name("GetAppRenderedContents").Path("/api/v1/app/{appSlug}/sequence/{sequence}/renderedcontents").Methods("GET").
 		HandlerFunc(middleware.EnforceAccess(policy.AppDownstreamFiletreeRead, handler.GetAppRenderedContents))
+	r.Name("GetAppVersionDeployPlan").Path("/api/v1/app/{appSlug}/sequence/{sequence}/plan").Methods("GET").
+		HandlerFunc(middleware.EnforceAccess(policy.AppDownstreamFiletreeRead, handler.GetAppVersionDeployPlan))
+	r.Name("GetAppVersionsDiff").Path("/api/v1/app/{appSlug}/diff").Methods("GET").
+		HandlerFunc(middleware.EnforceAccess(policy.AppDownstreamFiletreeRead, handler.GetAppVersionsDiff))
 	r.Name("GetAppContents").Path("/api/v1/app/{appSlug}/sequence/{sequence}/contents").Methods("GET").
 		HandlerFunc(middleware.EnforceAccess(policy.AppDownstreamFiletreeRead, handler.GetAppContents))
 	r.Name("GetAppDashboard").Path("/api/v1/app/{appSlug}/cluster/{clusterId}/dashboard").Methods("GET").
@@ -175,6 +222,8 @@ func RegisterSessionAuthRoutes(r *mux.Router, kotsStore store.Store, handler KOT
 		HandlerFunc(middleware.EnforceAccess(policy.AppCreate, handler.GarbageCollectImages))
 	r.Name("DockerHubSecretUpdated").Path("/api/v1/docker/secret-updated").Methods("POST").
 		HandlerFunc(middleware.EnforceAccess(policy.AppCreate, handler.DockerHubSecretUpdated))
+	r.Name("RotateEncryptionKey").Path("/api/v1/kotsadm/encryption-key/rotate").Methods("POST").
+		HandlerFunc(middleware.EnforceAccess(policy.ClusterWrite, handler.RotateEncryptionKey))
 
 	r.Name("UpdateAppRegistry").Path("/api/v1/app/{appSlug}/registry").Methods("PUT").
 		HandlerFunc(middleware.EnforceAccess(policy.AppRegistryWrite, handler.UpdateAppRegistry))
@@ -209,6 +258,10 @@ func RegisterSessionAuthRoutes(r *mux.Router, kotsStore store.Store, handler KOT
 		HandlerFunc(middleware.EnforceAccess(policy.AppDownstreamWrite, handler.SetAutomaticUpdatesConfig))
 	r.Name("GetAutomaticUpdatesConfig").Path("/api/v1/app/{appSlug}/automaticupdates").Methods("GET").
 		HandlerFunc(middleware.EnforceAccess(policy.AppDownstreamWrite, handler.GetAutomaticUpdatesConfig))
+	r.Name("SetDeployStrategyConfig").Path("/api/v1/app/{appSlug}/deploy-strategy").Methods("PUT").
+		HandlerFunc(middleware.EnforceAccess(policy.AppDownstreamWrite, handler.SetDeployStrategyConfig))
+	r.Name("GetDeployStrategyConfig").Path("/api/v1/app/{appSlug}/deploy-strategy").Methods("GET").
+		HandlerFunc(middleware.EnforceAccess(policy.AppDownstreamRead, handler.GetDeployStrategyConfig))
 	r.Name("RemoveApp").Path("/api/v1/app/{appSlug}/remove").Methods("POST").
 		HandlerFunc(middleware.EnforceAccess(policy.AppUpdate, handler.RemoveApp))
 
@@ -292,6 +345,18 @@ func RegisterSessionAuthRoutes(r *mux.Router, kotsStore store.Store, handler KOT
 	r.Name("SetPrometheusAddress").Path("/api/v1/prometheus").Methods("POST").
 		HandlerFunc(middleware.EnforceAccess(policy.PrometheussettingsWrite, handler.SetPrometheusAddress))
 
+	// Notifications
+	r.Name("ListNotificationSinks").Path("/api/v1/notifications/sinks").Methods("GET").
+		HandlerFunc(middleware.EnforceAccess(policy.NotificationsRead, handler.ListNotificationSinks))
+	r.Name("CreateNotificationSink").Path("/api/v1/notifications/sinks").Methods("POST").
+		HandlerFunc(middleware.EnforceAccess(policy.NotificationsWrite, handler.CreateNotificationSink))
+	r.Name("UpdateNotificationSink").Path("/api/v1/notifications/sink/{sinkId}").Methods("PUT").
+		HandlerFunc(middleware.EnforceAccess(policy.NotificationsWrite, handler.UpdateNotificationSink))
+	r.Name("DeleteNotificationSink").Path("/api/v1/notifications/sink/{sinkId}").Methods("DELETE").
+		HandlerFunc(middleware.EnforceAccess(policy.NotificationsWrite, handler.DeleteNotificationSink))
+	r.Name("TestNotificationSink").Path("/api/v1/notifications/sink/{sinkId}/test").Methods("POST").
+		HandlerFunc(middleware.EnforceAccess(policy.NotificationsWrite, handler.TestNotificationSink))
+
 	// GitOps
 	r.Name("UpdateAppGitOps").Path("/api/v1/gitops/app/{appId}/cluster/{clusterId}/update").Methods("PUT").
 		HandlerFunc(middleware.EnforceAccess(policy.AppGitopsWrite, handler.UpdateAppGitOps))
@@ -332,20 +397,21 @@ func JSON(w http.ResponseWriter, code int, payload interface{}) {
 
 func RegisterTokenAuthRoutes(handler *Handler, debugRouter *mux.Router, loggingRouter *mux.Router) {
 	debugRouter.Path("/api/v1/kots/ports").Methods("GET").HandlerFunc(handler.GetApplicationPorts)
-	loggingRouter.Path("/api/v1/upload").Methods("PUT").HandlerFunc(handler.UploadExistingApp)
+	loggingRouter.Path("/api/v1/upload").Methods("PUT").HandlerFunc(audit.Middleware("UploadExistingApp", handler.UploadExistingApp))
+	loggingRouter.Path("/api/v1/upload").Methods("POST").HandlerFunc(audit.Middleware("UploadNewApp", handler.UploadNewApp))
 	loggingRouter.Path("/api/v1/download").Methods("GET").HandlerFunc(handler.DownloadApp)
-	loggingRouter.Path("/api/v1/airgap/install").Methods("POST").HandlerFunc(handler.UploadInitialAirgapApp)
-	loggingRouter.Path("/api/v1/branding/install").Methods("POST").HandlerFunc(handler.UploadInitialBranding)
+	loggingRouter.Path("/api/v1/airgap/install").Methods("POST").HandlerFunc(audit.Middleware("UploadInitialAirgapApp", handler.UploadInitialAirgapApp))
+	loggingRouter.Path("/api/v1/branding/install").Methods("POST").HandlerFunc(audit.Middleware("UploadInitialBranding", handler.UploadInitialBranding))
 }
 
 func RegisterUnauthenticatedRoutes(handler *Handler, kotsStore store.Store, debugRouter *mux.Router, loggingRouter *mux.Router) {
 	debugRouter.HandleFunc("/healthz", handler.Healthz)
-	loggingRouter.HandleFunc("/api/v1/login", handler.Login)
+	loggingRouter.HandleFunc("/api/v1/login", LoginRateLimitMiddleware(handler.Login))
 	loggingRouter.HandleFunc("/api/v1/login/info", handler.GetLoginInfo)
 	loggingRouter.HandleFunc("/api/v1/logout", handler.Logout) // this route uses its own auth
 	loggingRouter.Path("/api/v1/metadata").Methods("GET").HandlerFunc(GetMetadataHandler(GetMetaDataConfig, kotsStore))
 
-	loggingRouter.HandleFunc("/api/v1/oidc/login", handler.OIDCLogin)
+	loggingRouter.HandleFunc("/api/v1/oidc/login", LoginRateLimitMiddleware(handler.OIDCLogin))
 	loggingRouter.HandleFunc("/api/v1/oidc/login/callback", handler.OIDCLoginCallback)
 
 	loggingRouter.Path("/api/v1/troubleshoot/{appId}/{bundleId}").Methods("PUT").HandlerFunc(handler.UploadSupportBundle)
@@ -4,12 +4,14 @@ import (
 	"net/http"
 
 	"github.com/replicatedhq/kots/pkg/buildversion"
+	"github.com/replicatedhq/kots/pkg/leader"
 )
 
 type HealthzResponse struct {
 	Version string         `json:"version"`
 	GitSHA  string         `json:"gitSha"`
 	Status  StatusResponse `json:"status"`
+	Leader  leader.Status  `json:"leader"`
 }
 
 type StatusResponse struct {
@@ -42,6 +44,7 @@ func (h *Handler) Healthz(w http.ResponseWriter, r *http.Request) {
 				Available: isStorageAvailable,
 			},
 		},
+		Leader: leader.GetStatus(),
 	}
 
 	statusCode := 200
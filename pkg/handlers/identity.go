@@ -602,13 +602,19 @@ func (h *Handler) GetIdentityServiceConfig(w http.ResponseWriter, r *http.Reques
 		Groups:                 identityConfig.Spec.Groups,
 	}
 
+	customRoles, err := store.GetStore().ListRBACRoles()
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to list custom roles"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
 	roles := []kotsv1beta1.IdentityRole{}
-	defaultRoles := rbac.DefaultRoles()
-	for _, defaultRole := range defaultRoles {
+	for _, rbacRole := range rbac.AllRoles(customRoles) {
 		role := kotsv1beta1.IdentityRole{
-			ID:          defaultRole.ID,
-			Name:        defaultRole.Name,
-			Description: defaultRole.Description,
+			ID:          rbacRole.ID,
+			Name:        rbacRole.Name,
+			Description: rbacRole.Description,
 		}
 		roles = append(roles, role)
 	}
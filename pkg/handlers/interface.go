@@ -46,6 +46,8 @@ type KOTSHandler interface {
 	ListApps(w http.ResponseWriter, r *http.Request)
 	GetApp(w http.ResponseWriter, r *http.Request)
 	GetAppStatus(w http.ResponseWriter, r *http.Request)
+	GetAppStatusHistory(w http.ResponseWriter, r *http.Request)
+	GetAppDrift(w http.ResponseWriter, r *http.Request)
 	GetAppVersionHistory(w http.ResponseWriter, r *http.Request)
 	GetLatestDeployableVersion(w http.ResponseWriter, r *http.Request)
 	GetUpdateDownloadStatus(w http.ResponseWriter, r *http.Request) // NOTE: appSlug is unused
@@ -76,7 +78,10 @@ type KOTSHandler interface {
 	GetAppVersionDownloadStatus(w http.ResponseWriter, r *http.Request)
 	DeployAppVersion(w http.ResponseWriter, r *http.Request)
 	RedeployAppVersion(w http.ResponseWriter, r *http.Request)
+	RollbackAppVersion(w http.ResponseWriter, r *http.Request)
 	GetAppRenderedContents(w http.ResponseWriter, r *http.Request)
+	GetAppVersionDeployPlan(w http.ResponseWriter, r *http.Request)
+	GetAppVersionsDiff(w http.ResponseWriter, r *http.Request)
 	GetAppContents(w http.ResponseWriter, r *http.Request)
 	GetAppDashboard(w http.ResponseWriter, r *http.Request)
 	GetDownstreamOutput(w http.ResponseWriter, r *http.Request)
@@ -88,6 +93,7 @@ type KOTSHandler interface {
 	GetAppRegistry(w http.ResponseWriter, r *http.Request)
 	ValidateAppRegistry(w http.ResponseWriter, r *http.Request)
 	GarbageCollectImages(w http.ResponseWriter, r *http.Request)
+	RotateEncryptionKey(w http.ResponseWriter, r *http.Request)
 
 	UpdateAppConfig(w http.ResponseWriter, r *http.Request)
 	CurrentAppConfig(w http.ResponseWriter, r *http.Request)
@@ -102,6 +108,8 @@ type KOTSHandler interface {
 	AppUpdateCheck(w http.ResponseWriter, r *http.Request)
 	SetAutomaticUpdatesConfig(w http.ResponseWriter, r *http.Request)
 	GetAutomaticUpdatesConfig(w http.ResponseWriter, r *http.Request)
+	SetDeployStrategyConfig(w http.ResponseWriter, r *http.Request)
+	GetDeployStrategyConfig(w http.ResponseWriter, r *http.Request)
 	RemoveApp(w http.ResponseWriter, r *http.Request)
 
 	// App snapshot routes
@@ -148,6 +156,35 @@ type KOTSHandler interface {
 	// Prometheus
 	SetPrometheusAddress(w http.ResponseWriter, r *http.Request)
 
+	// Notifications
+	ListNotificationSinks(w http.ResponseWriter, r *http.Request)
+	CreateNotificationSink(w http.ResponseWriter, r *http.Request)
+	UpdateNotificationSink(w http.ResponseWriter, r *http.Request)
+	DeleteNotificationSink(w http.ResponseWriter, r *http.Request)
+	TestNotificationSink(w http.ResponseWriter, r *http.Request)
+
+	// RBAC roles
+	ListRBACRoles(w http.ResponseWriter, r *http.Request)
+	CreateRBACRole(w http.ResponseWriter, r *http.Request)
+	UpdateRBACRole(w http.ResponseWriter, r *http.Request)
+	DeleteRBACRole(w http.ResponseWriter, r *http.Request)
+
+	// API tokens
+	ListAPITokens(w http.ResponseWriter, r *http.Request)
+	CreateAPIToken(w http.ResponseWriter, r *http.Request)
+	DeleteAPIToken(w http.ResponseWriter, r *http.Request)
+
+	// Local users
+	ListLocalUsers(w http.ResponseWriter, r *http.Request)
+	CreateLocalUser(w http.ResponseWriter, r *http.Request)
+	UpdateLocalUser(w http.ResponseWriter, r *http.Request)
+	SetLocalUserPassword(w http.ResponseWriter, r *http.Request)
+	DeleteLocalUser(w http.ResponseWriter, r *http.Request)
+
+	// Audit log
+	ListAuditEvents(w http.ResponseWriter, r *http.Request)
+	ExportAuditEvents(w http.ResponseWriter, r *http.Request)
+
 	// GitOps
 	UpdateAppGitOps(w http.ResponseWriter, r *http.Request)
 	DisableAppGitOps(w http.ResponseWriter, r *http.Request)
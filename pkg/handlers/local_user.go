@@ -0,0 +1,391 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/api/handlers/types"
+	"github.com/replicatedhq/kots/pkg/k8sutil"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/password"
+	"github.com/replicatedhq/kots/pkg/session"
+	"github.com/replicatedhq/kots/pkg/store"
+	"github.com/replicatedhq/kots/pkg/user"
+	usertypes "github.com/replicatedhq/kots/pkg/user/types"
+	"github.com/replicatedhq/kots/pkg/util"
+)
+
+func (h *Handler) ListLocalUsers(w http.ResponseWriter, r *http.Request) {
+	listLocalUsersResponse := types.ListLocalUsersResponse{}
+
+	users, err := store.GetStore().ListLocalUsers()
+	if err != nil {
+		listLocalUsersResponse.Error = "failed to list users"
+		logger.Error(errors.Wrap(err, listLocalUsersResponse.Error))
+		JSON(w, http.StatusInternalServerError, listLocalUsersResponse)
+		return
+	}
+
+	listLocalUsersResponse.Users = users
+
+	JSON(w, http.StatusOK, listLocalUsersResponse)
+}
+
+func (h *Handler) CreateLocalUser(w http.ResponseWriter, r *http.Request) {
+	localUserResponse := types.LocalUserResponse{}
+
+	sess := session.ContextGetSession(r)
+	if sess == nil {
+		localUserResponse.Error = "no session"
+		JSON(w, http.StatusUnauthorized, localUserResponse)
+		return
+	}
+
+	createLocalUserRequest := types.CreateLocalUserRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&createLocalUserRequest); err != nil {
+		localUserResponse.Error = "failed to decode request body"
+		logger.Error(errors.Wrap(err, localUserResponse.Error))
+		JSON(w, http.StatusBadRequest, localUserResponse)
+		return
+	}
+
+	if err := user.ValidateUsername(createLocalUserRequest.Username); err != nil {
+		localUserResponse.Error = err.Error()
+		JSON(w, http.StatusBadRequest, localUserResponse)
+		return
+	}
+
+	if err := validateGrantedRoles(store.GetStore(), createLocalUserRequest.Roles, sess.Roles); err != nil {
+		localUserResponse.Error = err.Error()
+		JSON(w, http.StatusBadRequest, localUserResponse)
+		return
+	}
+
+	passwordPolicy, err := getPasswordPolicy()
+	if err != nil {
+		localUserResponse.Error = "failed to get password policy"
+		logger.Error(errors.Wrap(err, localUserResponse.Error))
+		JSON(w, http.StatusInternalServerError, localUserResponse)
+		return
+	}
+	if err := passwordPolicy.Validate(createLocalUserRequest.Password); err != nil {
+		localUserResponse.Error = err.Error()
+		JSON(w, http.StatusBadRequest, localUserResponse)
+		return
+	}
+
+	_, err = store.GetStore().GetLocalUser(createLocalUserRequest.Username)
+	if err == nil {
+		localUserResponse.Error = "user already exists"
+		JSON(w, http.StatusConflict, localUserResponse)
+		return
+	} else if !store.GetStore().IsNotFound(err) {
+		localUserResponse.Error = "failed to get user"
+		logger.Error(errors.Wrap(err, localUserResponse.Error))
+		JSON(w, http.StatusInternalServerError, localUserResponse)
+		return
+	}
+
+	passwordBcrypt, err := user.HashPassword(createLocalUserRequest.Password)
+	if err != nil {
+		localUserResponse.Error = "failed to hash password"
+		logger.Error(errors.Wrap(err, localUserResponse.Error))
+		JSON(w, http.StatusInternalServerError, localUserResponse)
+		return
+	}
+
+	now := time.Now()
+	localUser := usertypes.LocalUser{
+		Username:  createLocalUserRequest.Username,
+		Roles:     createLocalUserRequest.Roles,
+		CreatedBy: sess.UserID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := store.GetStore().CreateLocalUser(localUser, passwordBcrypt); err != nil {
+		localUserResponse.Error = "failed to create user"
+		logger.Error(errors.Wrap(err, localUserResponse.Error))
+		JSON(w, http.StatusInternalServerError, localUserResponse)
+		return
+	}
+
+	localUserResponse.User = &localUser
+
+	JSON(w, http.StatusCreated, localUserResponse)
+}
+
+func (h *Handler) UpdateLocalUser(w http.ResponseWriter, r *http.Request) {
+	localUserResponse := types.LocalUserResponse{}
+
+	sess := session.ContextGetSession(r)
+	if sess == nil {
+		localUserResponse.Error = "no session"
+		JSON(w, http.StatusUnauthorized, localUserResponse)
+		return
+	}
+
+	username := mux.Vars(r)["username"]
+
+	updateLocalUserRequest := types.UpdateLocalUserRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&updateLocalUserRequest); err != nil {
+		localUserResponse.Error = "failed to decode request body"
+		logger.Error(errors.Wrap(err, localUserResponse.Error))
+		JSON(w, http.StatusBadRequest, localUserResponse)
+		return
+	}
+
+	localUser, err := store.GetStore().GetLocalUser(username)
+	if err != nil {
+		if store.GetStore().IsNotFound(err) {
+			localUserResponse.Error = "user not found"
+			JSON(w, http.StatusNotFound, localUserResponse)
+			return
+		}
+		localUserResponse.Error = "failed to get user"
+		logger.Error(errors.Wrap(err, localUserResponse.Error))
+		JSON(w, http.StatusInternalServerError, localUserResponse)
+		return
+	}
+
+	if err := validateManagedUser(localUser, sess.Roles); err != nil {
+		localUserResponse.Error = err.Error()
+		JSON(w, http.StatusForbidden, localUserResponse)
+		return
+	}
+
+	rolesRemoved := false
+	if updateLocalUserRequest.Roles != nil {
+		if err := validateGrantedRoles(store.GetStore(), updateLocalUserRequest.Roles, sess.Roles); err != nil {
+			localUserResponse.Error = err.Error()
+			JSON(w, http.StatusBadRequest, localUserResponse)
+			return
+		}
+		rolesRemoved = hasRemovedRoles(localUser.Roles, updateLocalUserRequest.Roles)
+		localUser.Roles = updateLocalUserRequest.Roles
+	}
+
+	if updateLocalUserRequest.IsDisabled != nil {
+		if *updateLocalUserRequest.IsDisabled && username == sess.UserID {
+			localUserResponse.Error = "cannot disable the current user"
+			JSON(w, http.StatusBadRequest, localUserResponse)
+			return
+		}
+		localUser.IsDisabled = *updateLocalUserRequest.IsDisabled
+	}
+
+	if err := store.GetStore().UpdateLocalUser(username, localUser.Roles, localUser.IsDisabled); err != nil {
+		localUserResponse.Error = "failed to update user"
+		logger.Error(errors.Wrap(err, localUserResponse.Error))
+		JSON(w, http.StatusInternalServerError, localUserResponse)
+		return
+	}
+
+	// sessions carry the roles of the user when they were created, so they are revoked to apply the change
+	if err := store.GetStore().DeleteUserSessions(username); err != nil {
+		logger.Error(errors.Wrapf(err, "failed to delete sessions of user %s", username))
+	}
+
+	// api tokens are granted a subset of the roles of the user that created them, so they are revoked when the user loses a role
+	if localUser.IsDisabled || rolesRemoved {
+		if err := store.GetStore().DeleteAPITokensCreatedBy(username); err != nil {
+			localUserResponse.Error = "failed to revoke api tokens of user"
+			logger.Error(errors.Wrap(err, localUserResponse.Error))
+			JSON(w, http.StatusInternalServerError, localUserResponse)
+			return
+		}
+	}
+
+	localUser.UpdatedAt = time.Now()
+	localUserResponse.User = localUser
+
+	JSON(w, http.StatusOK, localUserResponse)
+}
+
+// hasRemovedRoles returns true if any of the current roles is not in the updated roles
+func hasRemovedRoles(currentRoles []string, updatedRoles []string) bool {
+	for _, role := range currentRoles {
+		found := false
+		for _, updatedRole := range updatedRoles {
+			if role == updatedRole {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Handler) SetLocalUserPassword(w http.ResponseWriter, r *http.Request) {
+	localUserResponse := types.LocalUserResponse{}
+
+	sess := session.ContextGetSession(r)
+	if sess == nil {
+		localUserResponse.Error = "no session"
+		JSON(w, http.StatusUnauthorized, localUserResponse)
+		return
+	}
+
+	username := mux.Vars(r)["username"]
+
+	setLocalUserPasswordRequest := types.SetLocalUserPasswordRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&setLocalUserPasswordRequest); err != nil {
+		localUserResponse.Error = "failed to decode request body"
+		logger.Error(errors.Wrap(err, localUserResponse.Error))
+		JSON(w, http.StatusBadRequest, localUserResponse)
+		return
+	}
+
+	localUser, err := store.GetStore().GetLocalUser(username)
+	if err != nil {
+		if store.GetStore().IsNotFound(err) {
+			localUserResponse.Error = "user not found"
+			JSON(w, http.StatusNotFound, localUserResponse)
+			return
+		}
+		localUserResponse.Error = "failed to get user"
+		logger.Error(errors.Wrap(err, localUserResponse.Error))
+		JSON(w, http.StatusInternalServerError, localUserResponse)
+		return
+	}
+
+	if err := validateManagedUser(localUser, sess.Roles); err != nil {
+		localUserResponse.Error = err.Error()
+		JSON(w, http.StatusForbidden, localUserResponse)
+		return
+	}
+
+	passwordPolicy, err := getPasswordPolicy()
+	if err != nil {
+		localUserResponse.Error = "failed to get password policy"
+		logger.Error(errors.Wrap(err, localUserResponse.Error))
+		JSON(w, http.StatusInternalServerError, localUserResponse)
+		return
+	}
+	if err := passwordPolicy.Validate(setLocalUserPasswordRequest.Password); err != nil {
+		localUserResponse.Error = err.Error()
+		JSON(w, http.StatusBadRequest, localUserResponse)
+		return
+	}
+
+	if err := setLocalUserPassword(username, setLocalUserPasswordRequest.Password, passwordPolicy); err != nil {
+		if errors.Is(err, password.ErrPasswordReused) {
+			localUserResponse.Error = err.Error()
+			JSON(w, http.StatusBadRequest, localUserResponse)
+			return
+		}
+		localUserResponse.Error = "failed to set password"
+		logger.Error(errors.Wrap(err, localUserResponse.Error))
+		JSON(w, http.StatusInternalServerError, localUserResponse)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) DeleteLocalUser(w http.ResponseWriter, r *http.Request) {
+	sess := session.ContextGetSession(r)
+	if sess == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	username := mux.Vars(r)["username"]
+	if username == sess.UserID {
+		JSON(w, http.StatusBadRequest, types.LocalUserResponse{Error: "cannot delete the current user"})
+		return
+	}
+
+	localUser, err := store.GetStore().GetLocalUser(username)
+	if err != nil {
+		if store.GetStore().IsNotFound(err) {
+			JSON(w, http.StatusNotFound, types.LocalUserResponse{Error: "user not found"})
+			return
+		}
+		logger.Error(errors.Wrapf(err, "failed to get user %s", username))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := validateManagedUser(localUser, sess.Roles); err != nil {
+		JSON(w, http.StatusForbidden, types.LocalUserResponse{Error: err.Error()})
+		return
+	}
+
+	// api tokens outlive sessions, so they are revoked before the user is deleted
+	if err := store.GetStore().DeleteAPITokensCreatedBy(username); err != nil {
+		logger.Error(errors.Wrapf(err, "failed to delete api tokens of user %s", username))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := store.GetStore().DeleteLocalUser(username); err != nil {
+		logger.Error(errors.Wrapf(err, "failed to delete user %s", username))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := store.GetStore().DeleteUserSessions(username); err != nil {
+		logger.Error(errors.Wrapf(err, "failed to delete sessions of user %s", username))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateManagedUser ensures that the session holds all roles of the user it changes, so that it can't take over
+// a user with more access, e.g. by setting its password and logging in as that user
+func validateManagedUser(localUser *usertypes.LocalUser, sessionRoles []string) error {
+	if roleID := missingSessionRole(localUser.Roles, sessionRoles); roleID != "" {
+		return errors.Errorf("cannot change user %q with role %q that the session does not have", localUser.Username, roleID)
+	}
+	return nil
+}
+
+// getPasswordPolicy returns the password policy of the admin console, which applies to local users too
+func getPasswordPolicy() (password.PasswordPolicy, error) {
+	clientset, err := k8sutil.GetClientset()
+	if err != nil {
+		return password.PasswordPolicy{}, errors.Wrap(err, "failed to get k8s clientset")
+	}
+
+	return password.GetPasswordPolicy(clientset, util.PodNamespace)
+}
+
+// setLocalUserPassword sets the password of the user and revokes its sessions.
+// password.ErrPasswordReused is returned if the password is in the recent password history of the user.
+func setLocalUserPassword(username string, newPassword string, passwordPolicy password.PasswordPolicy) error {
+	if passwordPolicy.History > 0 {
+		currentBcrypt, err := store.GetStore().GetLocalUserPasswordBcrypt(username)
+		if err != nil {
+			return errors.Wrap(err, "failed to get current password")
+		}
+		history, err := store.GetStore().GetLocalUserPasswordHistory(username)
+		if err != nil {
+			return errors.Wrap(err, "failed to get password history")
+		}
+		if err := password.CheckPasswordReuse(append([][]byte{currentBcrypt}, history...), passwordPolicy.History, newPassword); err != nil {
+			return err
+		}
+	}
+
+	passwordBcrypt, err := user.HashPassword(newPassword)
+	if err != nil {
+		return errors.Wrap(err, "failed to hash password")
+	}
+
+	if err := store.GetStore().SetLocalUserPasswordBcrypt(username, passwordBcrypt); err != nil {
+		return errors.Wrap(err, "failed to set password")
+	}
+
+	if err := store.GetStore().DeleteUserSessions(username); err != nil {
+		logger.Error(errors.Wrapf(err, "failed to delete sessions of user %s", username))
+	}
+
+	return nil
+}
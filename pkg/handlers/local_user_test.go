@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"testing"
+
+	usertypes "github.com/replicatedhq/kots/pkg/user/types"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_validateManagedUser(t *testing.T) {
+	tests := []struct {
+		name         string
+		userRoles    []string
+		sessionRoles []string
+		wantErr      bool
+	}{
+		{
+			name:         "cluster admin can change any user",
+			userRoles:    []string{"cluster-admin"},
+			sessionRoles: []string{"cluster-admin"},
+		},
+		{
+			name:         "user with the same roles",
+			userRoles:    []string{"deployer"},
+			sessionRoles: []string{"deployer", "user-admin"},
+		},
+		{
+			name:         "user without roles",
+			userRoles:    []string{},
+			sessionRoles: []string{"user-admin"},
+		},
+		{
+			name:         "user with more roles than the session",
+			userRoles:    []string{"deployer", "cluster-admin"},
+			sessionRoles: []string{"deployer", "user-admin"},
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateManagedUser(&usertypes.LocalUser{Username: "user", Roles: tt.userRoles}, tt.sessionRoles)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_hasRemovedRoles(t *testing.T) {
+	require.False(t, hasRemovedRoles([]string{"deployer"}, []string{"deployer"}))
+	require.False(t, hasRemovedRoles([]string{"deployer"}, []string{"viewer", "deployer"}))
+	require.False(t, hasRemovedRoles([]string{}, []string{"deployer"}))
+	require.True(t, hasRemovedRoles([]string{"deployer", "user-admin"}, []string{"deployer"}))
+	require.True(t, hasRemovedRoles([]string{"deployer"}, []string{}))
+}
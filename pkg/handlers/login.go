@@ -4,13 +4,16 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	oidc "github.com/coreos/go-oidc"
 	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/audit"
 	"github.com/replicatedhq/kots/pkg/handlers/types"
 	"github.com/replicatedhq/kots/pkg/identity"
 	identityclient "github.com/replicatedhq/kots/pkg/identity/client"
@@ -27,6 +30,8 @@ import (
 )
 
 type LoginRequest struct {
+	// Username is set to log in as a local user, the shared password is used otherwise
+	Username string `json:"username,omitempty"`
 	Password string `json:"password"`
 }
 
@@ -54,6 +59,13 @@ func getRedirectOnErrorURL(redirectURL string, errorMsg string) string {
 	return fmt.Sprintf("%s/secure-console?%s", redirectURL, v.Encode())
 }
 
+// recordFailedLogin records a failed login in the audit log, with the username that was used for local users
+func recordFailedLogin(r *http.Request, username string) {
+	event := audit.NewEvent(r, nil, "Login", "", http.StatusUnauthorized)
+	event.Actor.UserID = username
+	audit.Record(event)
+}
+
 func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	identityConfig, err := identity.GetConfig(r.Context(), util.PodNamespace)
 	if err != nil {
@@ -76,13 +88,54 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	foundUser, err := user.LogIn(loginRequest.Password)
+	var foundUser *usertypes.User
+	var roles []string
+	if loginRequest.Username != "" {
+		var localUser *usertypes.LocalUser
+		localUser, err = user.LogInLocalUser(loginRequest.Username, loginRequest.Password, util.ClientIP(r))
+		if err == nil {
+			foundUser = &usertypes.User{
+				ID: localUser.Username,
+			}
+			roles = localUser.Roles
+		}
+	} else {
+		foundUser, err = user.LogIn(loginRequest.Password)
+		// TODO: super user permissions
+		roles = session.GetSessionRolesFromRBAC(nil, identity.DefaultGroups)
+	}
+
+	var lockedOutErr *user.LockedOutError
+	if loginRequest.Username != "" {
+		// local user logins get the same errors whether the user exists or not, so that they don't reveal which users exist
+		if err == user.ErrInvalidPassword {
+			recordFailedLogin(r, loginRequest.Username)
+			loginResponse.Error = "Invalid username or password. Please try again."
+			JSON(w, http.StatusUnauthorized, loginResponse)
+			return
+		} else if err == user.ErrTooManyAttempts || errors.As(err, &lockedOutErr) {
+			recordFailedLogin(r, loginRequest.Username)
+			loginResponse.Error = "Too many failed login attempts. Please try again later, or ask an administrator to reset the password using the \"kubectl kots user set-password\" command."
+			JSON(w, http.StatusUnauthorized, loginResponse)
+			return
+		}
+	}
+
 	if err == user.ErrInvalidPassword {
+		recordFailedLogin(r, loginRequest.Username)
 		loginResponse.Error = "Invalid password. Please try again."
 		JSON(w, http.StatusUnauthorized, loginResponse)
 		return
 	} else if err == user.ErrTooManyAttempts {
-		loginResponse.Error = "Admin Console has been locked.  Please reset password using the \"kubectl kots reset-password\" command."
+		recordFailedLogin(r, loginRequest.Username)
+		loginResponse.Error = "Admin Console has been locked.  Please reset password using the \"kubectl kots reset-password\" command, or unlock it using the \"kubectl kots reset-password --unlock\" command."
+		JSON(w, http.StatusUnauthorized, loginResponse)
+		return
+	} else if errors.As(err, &lockedOutErr) {
+		recordFailedLogin(r, loginRequest.Username)
+		retryAfter := int(math.Ceil(time.Until(lockedOutErr.Until).Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		loginResponse.Error = fmt.Sprintf("Admin Console has been locked due to too many failed login attempts.  Please try again after %s.", lockedOutErr.Until.Format(time.RFC1123))
 		JSON(w, http.StatusUnauthorized, loginResponse)
 		return
 	} else if err != nil {
@@ -91,9 +144,6 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// TODO: super user permissions
-	roles := session.GetSessionRolesFromRBAC(nil, identity.DefaultGroups)
-
 	issuedAt, expiresAt := time.Now(), time.Now().Add(SessionTimeout)
 	createdSession, err := store.GetStore().CreateSession(foundUser, issuedAt, expiresAt, roles)
 	if err != nil {
@@ -122,6 +172,8 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 
 	http.SetCookie(w, tokenCookie)
 
+	audit.Record(audit.NewEvent(r, createdSession, "Login", "", http.StatusOK))
+
 	JSON(w, http.StatusOK, loginResponse)
 }
 
@@ -343,6 +395,7 @@ func (h *Handler) OIDCLoginCallback(w http.ResponseWriter, r *http.Request) {
 	roles := session.GetSessionRolesFromRBAC(claims.Groups, groups)
 
 	if len(roles) == 0 {
+		recordFailedLogin(r, claims.Email)
 		redirectURL = getRedirectOnErrorURL(redirectURL, "user must be a part of at least 1 group with roles")
 		http.Redirect(w, r, redirectURL, http.StatusSeeOther)
 		return
@@ -405,6 +458,8 @@ func (h *Handler) OIDCLoginCallback(w http.ResponseWriter, r *http.Request) {
 	}
 	http.SetCookie(w, &sessionRolesCookie)
 
+	audit.Record(audit.NewEvent(r, createdSession, "Login", "", http.StatusSeeOther))
+
 	http.Redirect(w, r, redirectURL, http.StatusSeeOther)
 }
 
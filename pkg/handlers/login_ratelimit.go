@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/handlers/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/user"
+	"github.com/replicatedhq/kots/pkg/util"
+	"golang.org/x/time/rate"
+)
+
+// loginRateLimiterIdleTimeout is how long a client's limiter is kept after its last request
+const loginRateLimiterIdleTimeout = 10 * time.Minute
+
+var defaultLoginRateLimiter = newLoginRateLimiter(func() int {
+	policy, err := user.GetCurrentLoginPolicy()
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get login policy, using the default"))
+		return user.DefaultLoginRateLimit
+	}
+	return policy.RateLimit
+})
+
+// LoginRateLimitMiddleware limits the number of login requests per minute from a single client IP.
+// The X-Forwarded-For header is only used when kotsadm is behind a proxy listed in KOTSADM_TRUSTED_PROXIES.
+func LoginRateLimitMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return defaultLoginRateLimiter.middleware(next)
+}
+
+type loginRateLimiter struct {
+	// perMinute returns the number of requests per minute allowed for each client, 0 disables the limit
+	perMinute func() int
+
+	mu        sync.Mutex
+	limit     int
+	clients   map[string]*loginRateLimiterClient
+	lastPrune time.Time
+}
+
+type loginRateLimiterClient struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func newLoginRateLimiter(perMinute func() int) *loginRateLimiter {
+	return &loginRateLimiter{
+		perMinute: perMinute,
+		clients:   map[string]*loginRateLimiterClient{},
+	}
+}
+
+func (l *loginRateLimiter) middleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		allowed, retryAfter := l.allow(util.ClientIP(r), time.Now())
+		if !allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			JSON(w, http.StatusTooManyRequests, types.NewErrorResponse(errors.New("Too many login attempts. Please try again later.")))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// allow returns whether a request from the client is allowed, and if not, how long the client has to wait
+func (l *loginRateLimiter) allow(ip string, now time.Time) (bool, time.Duration) {
+	perMinute := l.perMinute()
+	if perMinute <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if perMinute != l.limit {
+		l.limit = perMinute
+		l.clients = map[string]*loginRateLimiterClient{}
+	}
+
+	if now.Sub(l.lastPrune) > loginRateLimiterIdleTimeout {
+		for key, client := range l.clients {
+			if now.Sub(client.lastSeen) > loginRateLimiterIdleTimeout {
+				delete(l.clients, key)
+			}
+		}
+		l.lastPrune = now
+	}
+
+	client, ok := l.clients[ip]
+	if !ok {
+		client = &loginRateLimiterClient{
+			limiter: rate.NewLimiter(rate.Every(time.Minute/time.Duration(perMinute)), perMinute),
+		}
+		l.clients[ip] = client
+	}
+	client.lastSeen = now
+
+	reservation := client.limiter.ReserveN(now, 1)
+	delay := reservation.DelayFrom(now)
+	if delay == 0 {
+		return true, 0
+	}
+	reservation.CancelAt(now)
+
+	return false, delay
+}
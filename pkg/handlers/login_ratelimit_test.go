@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_loginRateLimiter_allow(t *testing.T) {
+	req := require.New(t)
+
+	perMinute := 2
+	limiter := newLoginRateLimiter(func() int { return perMinute })
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		allowed, _ := limiter.allow("10.0.0.1", now)
+		req.True(allowed)
+	}
+
+	allowed, retryAfter := limiter.allow("10.0.0.1", now)
+	req.False(allowed)
+	req.Equal(30*time.Second, retryAfter)
+
+	// other clients have their own limit
+	allowed, _ = limiter.allow("10.0.0.2", now)
+	req.True(allowed)
+
+	allowed, _ = limiter.allow("10.0.0.1", now.Add(30*time.Second))
+	req.True(allowed)
+
+	// a limit of 0 disables rate limiting
+	perMinute = 0
+	for i := 0; i < 10; i++ {
+		allowed, _ = limiter.allow("10.0.0.1", now)
+		req.True(allowed)
+	}
+}
+
+func Test_loginRateLimiter_middleware(t *testing.T) {
+	req := require.New(t)
+
+	limiter := newLoginRateLimiter(func() int { return 1 })
+	handler := limiter.middleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	request := httptest.NewRequest("POST", "/api/v1/login", nil)
+	request.RemoteAddr = "10.0.0.1:1234"
+
+	recorder := httptest.NewRecorder()
+	handler(recorder, request)
+	req.Equal(http.StatusOK, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	handler(recorder, request)
+	req.Equal(http.StatusTooManyRequests, recorder.Code)
+	req.Equal("60", recorder.Header().Get("Retry-After"))
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/replicatedhq/kots/pkg/kotsadm"
 	"github.com/replicatedhq/kots/pkg/kotsadm/types"
 	"github.com/replicatedhq/kots/pkg/kotsutil"
+	"github.com/replicatedhq/kots/pkg/leader"
 	"github.com/replicatedhq/kots/pkg/logger"
 	"github.com/replicatedhq/kots/pkg/store"
 	"github.com/replicatedhq/kots/pkg/util"
@@ -53,6 +54,8 @@ type AdminConsoleMetadata struct {
 	IsAirgap bool `json:"isAirgap"`
 	IsKurl   bool `json:"isKurl"`
 	IsHelmVM bool `json:"isHelmVM"`
+	// Leader is the kotsadm replica that runs the background subsystems
+	Leader string `json:"leader"`
 }
 
 // GetMetadataHandler helper function that returns a http handler func that returns metadata. It takes a function that
@@ -74,6 +77,7 @@ func GetMetadataHandler(getK8sInfoFn MetadataK8sFn, kotsStore store.Store) http.
 				metadataResponse.AdminConsoleMetadata.IsAirgap = kotsadmMetadata.IsAirgap
 				metadataResponse.AdminConsoleMetadata.IsKurl = kotsadmMetadata.IsKurl
 				metadataResponse.AdminConsoleMetadata.IsHelmVM = kotsadmMetadata.IsHelmVM
+				metadataResponse.AdminConsoleMetadata.Leader = leader.GetStatus().Leader
 
 				logger.Info(fmt.Sprintf("config map %q not found", metadataConfigMapName))
 				JSON(w, http.StatusOK, &metadataResponse)
@@ -117,6 +121,7 @@ func GetMetadataHandler(getK8sInfoFn MetadataK8sFn, kotsStore store.Store) http.
 			IsAirgap: kotsadmMetadata.IsAirgap,
 			IsKurl:   kotsadmMetadata.IsKurl,
 			IsHelmVM: kotsadmMetadata.IsHelmVM,
+			Leader:   leader.GetStatus().Leader,
 		}
 
 		JSON(w, http.StatusOK, metadataResponse)
@@ -8,8 +8,14 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
 	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/metrics"
 	"github.com/replicatedhq/kots/pkg/session"
 	"github.com/replicatedhq/kots/pkg/store"
+	"github.com/replicatedhq/kots/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
 )
 
 type loggingResponseWriter struct {
@@ -77,6 +83,52 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// MetricsMiddleware records the latency of requests by route name, or by path template for routes without a name
+func MetricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		startTime := time.Now()
+
+		lrw := NewLoggingResponseWriter(w)
+		next.ServeHTTP(lrw, r)
+
+		metrics.ObserveHTTPRequest(routeName(r), r.Method, lrw.StatusCode, time.Since(startTime))
+	})
+}
+
+func TracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.StartSpan(ctx, routeName(r),
+			attribute.String("http.method", r.Method),
+			attribute.String("http.target", r.URL.Path),
+		)
+		defer span.End()
+
+		lrw := NewLoggingResponseWriter(w)
+		next.ServeHTTP(lrw, r.WithContext(ctx))
+
+		span.SetAttributes(attribute.Int("http.status_code", lrw.StatusCode))
+		if lrw.StatusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(lrw.StatusCode))
+		}
+	})
+}
+
+// routeName returns the name of the matched route, or its path template if the route has no name
+func routeName(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return "unknown"
+	}
+	if name := route.GetName(); name != "" {
+		return name
+	}
+	if pathTemplate, err := route.GetPathTemplate(); err == nil {
+		return pathTemplate
+	}
+	return "unknown"
+}
+
 func RequireValidSessionMiddleware(kotsStore store.Store) mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -178,6 +178,18 @@ func (mr *MockKOTSHandlerMockRecorder) ConfigureIdentityService(w, r interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfigureIdentityService", reflect.TypeOf((*MockKOTSHandler)(nil).ConfigureIdentityService), w, r)
 }
 
+// CreateAPIToken mocks base method.
+func (m *MockKOTSHandler) CreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "CreateAPIToken", w, r)
+}
+
+// CreateAPIToken indicates an expected call of CreateAPIToken.
+func (mr *MockKOTSHandlerMockRecorder) CreateAPIToken(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIToken", reflect.TypeOf((*MockKOTSHandler)(nil).CreateAPIToken), w, r)
+}
+
 // CreateAppFromAirgap mocks base method.
 func (m *MockKOTSHandler) CreateAppFromAirgap(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -238,6 +250,42 @@ func (mr *MockKOTSHandlerMockRecorder) CreateInstanceBackup(w, r interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInstanceBackup", reflect.TypeOf((*MockKOTSHandler)(nil).CreateInstanceBackup), w, r)
 }
 
+// CreateLocalUser mocks base method.
+func (m *MockKOTSHandler) CreateLocalUser(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "CreateLocalUser", w, r)
+}
+
+// CreateLocalUser indicates an expected call of CreateLocalUser.
+func (mr *MockKOTSHandlerMockRecorder) CreateLocalUser(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLocalUser", reflect.TypeOf((*MockKOTSHandler)(nil).CreateLocalUser), w, r)
+}
+
+// CreateNotificationSink mocks base method.
+func (m *MockKOTSHandler) CreateNotificationSink(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "CreateNotificationSink", w, r)
+}
+
+// CreateNotificationSink indicates an expected call of CreateNotificationSink.
+func (mr *MockKOTSHandlerMockRecorder) CreateNotificationSink(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNotificationSink", reflect.TypeOf((*MockKOTSHandler)(nil).CreateNotificationSink), w, r)
+}
+
+// CreateRBACRole mocks base method.
+func (m *MockKOTSHandler) CreateRBACRole(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "CreateRBACRole", w, r)
+}
+
+// CreateRBACRole indicates an expected call of CreateRBACRole.
+func (mr *MockKOTSHandlerMockRecorder) CreateRBACRole(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRBACRole", reflect.TypeOf((*MockKOTSHandler)(nil).CreateRBACRole), w, r)
+}
+
 // CurrentAppConfig mocks base method.
 func (m *MockKOTSHandler) CurrentAppConfig(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -250,6 +298,18 @@ func (mr *MockKOTSHandlerMockRecorder) CurrentAppConfig(w, r interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CurrentAppConfig", reflect.TypeOf((*MockKOTSHandler)(nil).CurrentAppConfig), w, r)
 }
 
+// DeleteAPIToken mocks base method.
+func (m *MockKOTSHandler) DeleteAPIToken(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "DeleteAPIToken", w, r)
+}
+
+// DeleteAPIToken indicates an expected call of DeleteAPIToken.
+func (mr *MockKOTSHandlerMockRecorder) DeleteAPIToken(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAPIToken", reflect.TypeOf((*MockKOTSHandler)(nil).DeleteAPIToken), w, r)
+}
+
 // DeleteBackup mocks base method.
 func (m *MockKOTSHandler) DeleteBackup(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -286,6 +346,42 @@ func (mr *MockKOTSHandlerMockRecorder) DeleteKurlNode(w, r interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteKurlNode", reflect.TypeOf((*MockKOTSHandler)(nil).DeleteKurlNode), w, r)
 }
 
+// DeleteLocalUser mocks base method.
+func (m *MockKOTSHandler) DeleteLocalUser(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "DeleteLocalUser", w, r)
+}
+
+// DeleteLocalUser indicates an expected call of DeleteLocalUser.
+func (mr *MockKOTSHandlerMockRecorder) DeleteLocalUser(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLocalUser", reflect.TypeOf((*MockKOTSHandler)(nil).DeleteLocalUser), w, r)
+}
+
+// DeleteNotificationSink mocks base method.
+func (m *MockKOTSHandler) DeleteNotificationSink(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "DeleteNotificationSink", w, r)
+}
+
+// DeleteNotificationSink indicates an expected call of DeleteNotificationSink.
+func (mr *MockKOTSHandlerMockRecorder) DeleteNotificationSink(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteNotificationSink", reflect.TypeOf((*MockKOTSHandler)(nil).DeleteNotificationSink), w, r)
+}
+
+// DeleteRBACRole mocks base method.
+func (m *MockKOTSHandler) DeleteRBACRole(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "DeleteRBACRole", w, r)
+}
+
+// DeleteRBACRole indicates an expected call of DeleteRBACRole.
+func (mr *MockKOTSHandlerMockRecorder) DeleteRBACRole(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRBACRole", reflect.TypeOf((*MockKOTSHandler)(nil).DeleteRBACRole), w, r)
+}
+
 // DeleteRedact mocks base method.
 func (m *MockKOTSHandler) DeleteRedact(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -430,6 +526,18 @@ func (mr *MockKOTSHandlerMockRecorder) ExchangePlatformLicense(w, r interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExchangePlatformLicense", reflect.TypeOf((*MockKOTSHandler)(nil).ExchangePlatformLicense), w, r)
 }
 
+// ExportAuditEvents mocks base method.
+func (m *MockKOTSHandler) ExportAuditEvents(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ExportAuditEvents", w, r)
+}
+
+// ExportAuditEvents indicates an expected call of ExportAuditEvents.
+func (mr *MockKOTSHandlerMockRecorder) ExportAuditEvents(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportAuditEvents", reflect.TypeOf((*MockKOTSHandler)(nil).ExportAuditEvents), w, r)
+}
+
 // GarbageCollectImages mocks base method.
 func (m *MockKOTSHandler) GarbageCollectImages(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -586,6 +694,18 @@ func (mr *MockKOTSHandlerMockRecorder) GetAppDashboard(w, r interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAppDashboard", reflect.TypeOf((*MockKOTSHandler)(nil).GetAppDashboard), w, r)
 }
 
+// GetAppDrift mocks base method.
+func (m *MockKOTSHandler) GetAppDrift(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetAppDrift", w, r)
+}
+
+// GetAppDrift indicates an expected call of GetAppDrift.
+func (mr *MockKOTSHandlerMockRecorder) GetAppDrift(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAppDrift", reflect.TypeOf((*MockKOTSHandler)(nil).GetAppDrift), w, r)
+}
+
 // GetAppIdentityServiceConfig mocks base method.
 func (m *MockKOTSHandler) GetAppIdentityServiceConfig(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -634,6 +754,18 @@ func (mr *MockKOTSHandlerMockRecorder) GetAppStatus(w, r interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAppStatus", reflect.TypeOf((*MockKOTSHandler)(nil).GetAppStatus), w, r)
 }
 
+// GetAppStatusHistory mocks base method.
+func (m *MockKOTSHandler) GetAppStatusHistory(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetAppStatusHistory", w, r)
+}
+
+// GetAppStatusHistory indicates an expected call of GetAppStatusHistory.
+func (mr *MockKOTSHandlerMockRecorder) GetAppStatusHistory(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAppStatusHistory", reflect.TypeOf((*MockKOTSHandler)(nil).GetAppStatusHistory), w, r)
+}
+
 // GetAppValuesFile mocks base method.
 func (m *MockKOTSHandler) GetAppValuesFile(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -646,6 +778,18 @@ func (mr *MockKOTSHandlerMockRecorder) GetAppValuesFile(w, r interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAppValuesFile", reflect.TypeOf((*MockKOTSHandler)(nil).GetAppValuesFile), w, r)
 }
 
+// GetAppVersionDeployPlan mocks base method.
+func (m *MockKOTSHandler) GetAppVersionDeployPlan(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetAppVersionDeployPlan", w, r)
+}
+
+// GetAppVersionDeployPlan indicates an expected call of GetAppVersionDeployPlan.
+func (mr *MockKOTSHandlerMockRecorder) GetAppVersionDeployPlan(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAppVersionDeployPlan", reflect.TypeOf((*MockKOTSHandler)(nil).GetAppVersionDeployPlan), w, r)
+}
+
 // GetAppVersionDownloadStatus mocks base method.
 func (m *MockKOTSHandler) GetAppVersionDownloadStatus(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -670,6 +814,18 @@ func (mr *MockKOTSHandlerMockRecorder) GetAppVersionHistory(w, r interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAppVersionHistory", reflect.TypeOf((*MockKOTSHandler)(nil).GetAppVersionHistory), w, r)
 }
 
+// GetAppVersionsDiff mocks base method.
+func (m *MockKOTSHandler) GetAppVersionsDiff(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetAppVersionsDiff", w, r)
+}
+
+// GetAppVersionsDiff indicates an expected call of GetAppVersionsDiff.
+func (mr *MockKOTSHandlerMockRecorder) GetAppVersionsDiff(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAppVersionsDiff", reflect.TypeOf((*MockKOTSHandler)(nil).GetAppVersionsDiff), w, r)
+}
+
 // GetAutomatedInstallStatus mocks base method.
 func (m *MockKOTSHandler) GetAutomatedInstallStatus(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -706,6 +862,18 @@ func (mr *MockKOTSHandlerMockRecorder) GetBackup(w, r interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBackup", reflect.TypeOf((*MockKOTSHandler)(nil).GetBackup), w, r)
 }
 
+// GetDeployStrategyConfig mocks base method.
+func (m *MockKOTSHandler) GetDeployStrategyConfig(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetDeployStrategyConfig", w, r)
+}
+
+// GetDeployStrategyConfig indicates an expected call of GetDeployStrategyConfig.
+func (mr *MockKOTSHandlerMockRecorder) GetDeployStrategyConfig(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeployStrategyConfig", reflect.TypeOf((*MockKOTSHandler)(nil).GetDeployStrategyConfig), w, r)
+}
+
 // GetDownstreamOutput mocks base method.
 func (m *MockKOTSHandler) GetDownstreamOutput(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -1102,6 +1270,18 @@ func (mr *MockKOTSHandlerMockRecorder) IsHelmManaged(w, r interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsHelmManaged", reflect.TypeOf((*MockKOTSHandler)(nil).IsHelmManaged), w, r)
 }
 
+// ListAPITokens mocks base method.
+func (m *MockKOTSHandler) ListAPITokens(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ListAPITokens", w, r)
+}
+
+// ListAPITokens indicates an expected call of ListAPITokens.
+func (mr *MockKOTSHandlerMockRecorder) ListAPITokens(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAPITokens", reflect.TypeOf((*MockKOTSHandler)(nil).ListAPITokens), w, r)
+}
+
 // ListApps mocks base method.
 func (m *MockKOTSHandler) ListApps(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -1114,6 +1294,18 @@ func (mr *MockKOTSHandlerMockRecorder) ListApps(w, r interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListApps", reflect.TypeOf((*MockKOTSHandler)(nil).ListApps), w, r)
 }
 
+// ListAuditEvents mocks base method.
+func (m *MockKOTSHandler) ListAuditEvents(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ListAuditEvents", w, r)
+}
+
+// ListAuditEvents indicates an expected call of ListAuditEvents.
+func (mr *MockKOTSHandlerMockRecorder) ListAuditEvents(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditEvents", reflect.TypeOf((*MockKOTSHandler)(nil).ListAuditEvents), w, r)
+}
+
 // ListBackups mocks base method.
 func (m *MockKOTSHandler) ListBackups(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -1138,6 +1330,42 @@ func (mr *MockKOTSHandlerMockRecorder) ListInstanceBackups(w, r interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListInstanceBackups", reflect.TypeOf((*MockKOTSHandler)(nil).ListInstanceBackups), w, r)
 }
 
+// ListLocalUsers mocks base method.
+func (m *MockKOTSHandler) ListLocalUsers(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ListLocalUsers", w, r)
+}
+
+// ListLocalUsers indicates an expected call of ListLocalUsers.
+func (mr *MockKOTSHandlerMockRecorder) ListLocalUsers(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLocalUsers", reflect.TypeOf((*MockKOTSHandler)(nil).ListLocalUsers), w, r)
+}
+
+// ListNotificationSinks mocks base method.
+func (m *MockKOTSHandler) ListNotificationSinks(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ListNotificationSinks", w, r)
+}
+
+// ListNotificationSinks indicates an expected call of ListNotificationSinks.
+func (mr *MockKOTSHandlerMockRecorder) ListNotificationSinks(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNotificationSinks", reflect.TypeOf((*MockKOTSHandler)(nil).ListNotificationSinks), w, r)
+}
+
+// ListRBACRoles mocks base method.
+func (m *MockKOTSHandler) ListRBACRoles(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ListRBACRoles", w, r)
+}
+
+// ListRBACRoles indicates an expected call of ListRBACRoles.
+func (mr *MockKOTSHandlerMockRecorder) ListRBACRoles(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRBACRoles", reflect.TypeOf((*MockKOTSHandler)(nil).ListRBACRoles), w, r)
+}
+
 // ListRedactors mocks base method.
 func (m *MockKOTSHandler) ListRedactors(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -1270,6 +1498,30 @@ func (mr *MockKOTSHandlerMockRecorder) ResumeInstallOnline(w, r interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResumeInstallOnline", reflect.TypeOf((*MockKOTSHandler)(nil).ResumeInstallOnline), w, r)
 }
 
+// RollbackAppVersion mocks base method.
+func (m *MockKOTSHandler) RollbackAppVersion(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RollbackAppVersion", w, r)
+}
+
+// RollbackAppVersion indicates an expected call of RollbackAppVersion.
+func (mr *MockKOTSHandlerMockRecorder) RollbackAppVersion(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RollbackAppVersion", reflect.TypeOf((*MockKOTSHandler)(nil).RollbackAppVersion), w, r)
+}
+
+// RotateEncryptionKey mocks base method.
+func (m *MockKOTSHandler) RotateEncryptionKey(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RotateEncryptionKey", w, r)
+}
+
+// RotateEncryptionKey indicates an expected call of RotateEncryptionKey.
+func (mr *MockKOTSHandlerMockRecorder) RotateEncryptionKey(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateEncryptionKey", reflect.TypeOf((*MockKOTSHandler)(nil).RotateEncryptionKey), w, r)
+}
+
 // SaveInstanceSnapshotConfig mocks base method.
 func (m *MockKOTSHandler) SaveInstanceSnapshotConfig(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -1318,6 +1570,30 @@ func (mr *MockKOTSHandlerMockRecorder) SetAutomaticUpdatesConfig(w, r interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAutomaticUpdatesConfig", reflect.TypeOf((*MockKOTSHandler)(nil).SetAutomaticUpdatesConfig), w, r)
 }
 
+// SetDeployStrategyConfig mocks base method.
+func (m *MockKOTSHandler) SetDeployStrategyConfig(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetDeployStrategyConfig", w, r)
+}
+
+// SetDeployStrategyConfig indicates an expected call of SetDeployStrategyConfig.
+func (mr *MockKOTSHandlerMockRecorder) SetDeployStrategyConfig(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDeployStrategyConfig", reflect.TypeOf((*MockKOTSHandler)(nil).SetDeployStrategyConfig), w, r)
+}
+
+// SetLocalUserPassword mocks base method.
+func (m *MockKOTSHandler) SetLocalUserPassword(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetLocalUserPassword", w, r)
+}
+
+// SetLocalUserPassword indicates an expected call of SetLocalUserPassword.
+func (mr *MockKOTSHandlerMockRecorder) SetLocalUserPassword(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLocalUserPassword", reflect.TypeOf((*MockKOTSHandler)(nil).SetLocalUserPassword), w, r)
+}
+
 // SetPrometheusAddress mocks base method.
 func (m *MockKOTSHandler) SetPrometheusAddress(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -1390,6 +1666,18 @@ func (mr *MockKOTSHandlerMockRecorder) SyncLicense(w, r interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncLicense", reflect.TypeOf((*MockKOTSHandler)(nil).SyncLicense), w, r)
 }
 
+// TestNotificationSink mocks base method.
+func (m *MockKOTSHandler) TestNotificationSink(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "TestNotificationSink", w, r)
+}
+
+// TestNotificationSink indicates an expected call of TestNotificationSink.
+func (mr *MockKOTSHandlerMockRecorder) TestNotificationSink(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TestNotificationSink", reflect.TypeOf((*MockKOTSHandler)(nil).TestNotificationSink), w, r)
+}
+
 // UpdateAdminConsole mocks base method.
 func (m *MockKOTSHandler) UpdateAdminConsole(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
@@ -1462,6 +1750,42 @@ func (mr *MockKOTSHandlerMockRecorder) UpdateGlobalSnapshotSettings(w, r interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateGlobalSnapshotSettings", reflect.TypeOf((*MockKOTSHandler)(nil).UpdateGlobalSnapshotSettings), w, r)
 }
 
+// UpdateLocalUser mocks base method.
+func (m *MockKOTSHandler) UpdateLocalUser(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UpdateLocalUser", w, r)
+}
+
+// UpdateLocalUser indicates an expected call of UpdateLocalUser.
+func (mr *MockKOTSHandlerMockRecorder) UpdateLocalUser(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLocalUser", reflect.TypeOf((*MockKOTSHandler)(nil).UpdateLocalUser), w, r)
+}
+
+// UpdateNotificationSink mocks base method.
+func (m *MockKOTSHandler) UpdateNotificationSink(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UpdateNotificationSink", w, r)
+}
+
+// UpdateNotificationSink indicates an expected call of UpdateNotificationSink.
+func (mr *MockKOTSHandlerMockRecorder) UpdateNotificationSink(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateNotificationSink", reflect.TypeOf((*MockKOTSHandler)(nil).UpdateNotificationSink), w, r)
+}
+
+// UpdateRBACRole mocks base method.
+func (m *MockKOTSHandler) UpdateRBACRole(w http.ResponseWriter, r *http.Request) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UpdateRBACRole", w, r)
+}
+
+// UpdateRBACRole indicates an expected call of UpdateRBACRole.
+func (mr *MockKOTSHandlerMockRecorder) UpdateRBACRole(w, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRBACRole", reflect.TypeOf((*MockKOTSHandler)(nil).UpdateRBACRole), w, r)
+}
+
 // UpdateRedact mocks base method.
 func (m *MockKOTSHandler) UpdateRedact(w http.ResponseWriter, r *http.Request) {
 	m.ctrl.T.Helper()
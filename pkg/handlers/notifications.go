@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/notifications"
+	notificationtypes "github.com/replicatedhq/kots/pkg/notifications/types"
+	"github.com/replicatedhq/kots/pkg/store"
+)
+
+type ListNotificationSinksResponse struct {
+	Sinks []notificationtypes.Sink `json:"sinks"`
+	Error string                   `json:"error,omitempty"`
+}
+
+type NotificationSinkResponse struct {
+	Sink  *notificationtypes.Sink `json:"sink,omitempty"`
+	Error string                  `json:"error,omitempty"`
+}
+
+type TestNotificationSinkResponse struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+func (h *Handler) ListNotificationSinks(w http.ResponseWriter, r *http.Request) {
+	listNotificationSinksResponse := ListNotificationSinksResponse{
+		Sinks: []notificationtypes.Sink{},
+	}
+
+	sinks, err := store.GetStore().ListNotificationSinks()
+	if err != nil {
+		listNotificationSinksResponse.Error = "failed to list notification sinks"
+		logger.Error(errors.Wrap(err, listNotificationSinksResponse.Error))
+		JSON(w, http.StatusInternalServerError, listNotificationSinksResponse)
+		return
+	}
+
+	for _, sink := range sinks {
+		listNotificationSinksResponse.Sinks = append(listNotificationSinksResponse.Sinks, sink.Redacted())
+	}
+
+	JSON(w, http.StatusOK, listNotificationSinksResponse)
+}
+
+func (h *Handler) CreateNotificationSink(w http.ResponseWriter, r *http.Request) {
+	createNotificationSinkResponse := NotificationSinkResponse{}
+
+	sink := notificationtypes.Sink{}
+	if err := json.NewDecoder(r.Body).Decode(&sink); err != nil {
+		createNotificationSinkResponse.Error = "failed to decode request body"
+		logger.Error(errors.Wrap(err, createNotificationSinkResponse.Error))
+		JSON(w, http.StatusBadRequest, createNotificationSinkResponse)
+		return
+	}
+
+	if err := notifications.Validate(sink); err != nil {
+		createNotificationSinkResponse.Error = err.Error()
+		logger.Error(errors.Wrap(err, "invalid notification sink"))
+		JSON(w, http.StatusBadRequest, createNotificationSinkResponse)
+		return
+	}
+
+	createdSink, err := store.GetStore().CreateNotificationSink(sink)
+	if err != nil {
+		createNotificationSinkResponse.Error = "failed to create notification sink"
+		logger.Error(errors.Wrap(err, createNotificationSinkResponse.Error))
+		JSON(w, http.StatusInternalServerError, createNotificationSinkResponse)
+		return
+	}
+
+	redacted := createdSink.Redacted()
+	createNotificationSinkResponse.Sink = &redacted
+
+	JSON(w, http.StatusCreated, createNotificationSinkResponse)
+}
+
+func (h *Handler) UpdateNotificationSink(w http.ResponseWriter, r *http.Request) {
+	updateNotificationSinkResponse := NotificationSinkResponse{}
+
+	sinkID := mux.Vars(r)["sinkId"]
+
+	currentSink, err := store.GetStore().GetNotificationSink(sinkID)
+	if err != nil {
+		if store.GetStore().IsNotFound(err) {
+			updateNotificationSinkResponse.Error = "notification sink not found"
+			JSON(w, http.StatusNotFound, updateNotificationSinkResponse)
+			return
+		}
+		updateNotificationSinkResponse.Error = "failed to get notification sink"
+		logger.Error(errors.Wrap(err, updateNotificationSinkResponse.Error))
+		JSON(w, http.StatusInternalServerError, updateNotificationSinkResponse)
+		return
+	}
+
+	sink := notificationtypes.Sink{}
+	if err := json.NewDecoder(r.Body).Decode(&sink); err != nil {
+		updateNotificationSinkResponse.Error = "failed to decode request body"
+		logger.Error(errors.Wrap(err, updateNotificationSinkResponse.Error))
+		JSON(w, http.StatusBadRequest, updateNotificationSinkResponse)
+		return
+	}
+	sink.ID = currentSink.ID
+	sink.CreatedAt = currentSink.CreatedAt
+	sink.KeepMaskedSecrets(*currentSink)
+
+	if err := notifications.Validate(sink); err != nil {
+		updateNotificationSinkResponse.Error = err.Error()
+		logger.Error(errors.Wrap(err, "invalid notification sink"))
+		JSON(w, http.StatusBadRequest, updateNotificationSinkResponse)
+		return
+	}
+
+	if err := store.GetStore().UpdateNotificationSink(sink); err != nil {
+		updateNotificationSinkResponse.Error = "failed to update notification sink"
+		logger.Error(errors.Wrap(err, updateNotificationSinkResponse.Error))
+		JSON(w, http.StatusInternalServerError, updateNotificationSinkResponse)
+		return
+	}
+
+	redacted := sink.Redacted()
+	updateNotificationSinkResponse.Sink = &redacted
+
+	JSON(w, http.StatusOK, updateNotificationSinkResponse)
+}
+
+func (h *Handler) DeleteNotificationSink(w http.ResponseWriter, r *http.Request) {
+	if err := store.GetStore().DeleteNotificationSink(mux.Vars(r)["sinkId"]); err != nil {
+		logger.Error(errors.Wrap(err, "failed to delete notification sink"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) TestNotificationSink(w http.ResponseWriter, r *http.Request) {
+	testNotificationSinkResponse := TestNotificationSinkResponse{}
+
+	sink, err := store.GetStore().GetNotificationSink(mux.Vars(r)["sinkId"])
+	if err != nil {
+		if store.GetStore().IsNotFound(err) {
+			testNotificationSinkResponse.Error = "notification sink not found"
+			JSON(w, http.StatusNotFound, testNotificationSinkResponse)
+			return
+		}
+		testNotificationSinkResponse.Error = "failed to get notification sink"
+		logger.Error(errors.Wrap(err, testNotificationSinkResponse.Error))
+		JSON(w, http.StatusInternalServerError, testNotificationSinkResponse)
+		return
+	}
+
+	if err := notifications.SendTest(store.GetStore(), *sink); err != nil {
+		testNotificationSinkResponse.Error = err.Error()
+		logger.Error(errors.Wrap(err, "failed to send test notification"))
+		JSON(w, http.StatusOK, testNotificationSinkResponse)
+		return
+	}
+
+	testNotificationSinkResponse.Success = true
+	JSON(w, http.StatusOK, testNotificationSinkResponse)
+}
@@ -10,8 +10,10 @@ import (
 	"github.com/replicatedhq/kots/pkg/k8sutil"
 	"github.com/replicatedhq/kots/pkg/logger"
 	"github.com/replicatedhq/kots/pkg/password"
+	"github.com/replicatedhq/kots/pkg/session"
 	"github.com/replicatedhq/kots/pkg/store"
 	"github.com/replicatedhq/kots/pkg/util"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // PasswordChangeRequest - request body for the password change endpoint
@@ -34,12 +36,6 @@ func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := password.ValidatePasswordInput(passwordChangeRequest.CurrentPassword, passwordChangeRequest.NewPassword); err != nil {
-		logger.Error(err)
-		JSON(w, http.StatusBadRequest, types.NewErrorResponse(err))
-		return
-	}
-
 	identityConfig, err := identity.GetConfig(r.Context(), util.PodNamespace)
 	if err != nil {
 		logger.Error(err)
@@ -59,6 +55,32 @@ func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	passwordPolicy, err := password.GetPasswordPolicy(clientset, util.PodNamespace)
+	if err != nil {
+		logger.Error(err)
+		JSON(w, http.StatusInternalServerError, types.NewErrorResponse(err))
+		return
+	}
+
+	if err := password.ValidatePasswordInput(passwordPolicy, passwordChangeRequest.CurrentPassword, passwordChangeRequest.NewPassword); err != nil {
+		logger.Error(err)
+		JSON(w, http.StatusBadRequest, types.NewErrorResponse(err))
+		return
+	}
+
+	// local users change their own password rather than the shared password
+	if sess := session.ContextGetSession(r); sess != nil {
+		_, err := store.GetStore().GetLocalUser(sess.UserID)
+		if err == nil {
+			changeLocalUserPassword(w, sess.UserID, passwordPolicy, passwordChangeRequest)
+			return
+		} else if !store.GetStore().IsNotFound(err) {
+			logger.Error(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
 	if err := password.ValidateCurrentPassword(store.GetStore(), passwordChangeRequest.CurrentPassword); err != nil {
 		logger.Error(err)
 		if errors.Is(err, password.ErrCurrentPasswordDoesNotMatch) {
@@ -72,6 +94,10 @@ func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 	// change password
 	if err := password.ChangePassword(clientset, util.PodNamespace, passwordChangeRequest.NewPassword); err != nil {
 		logger.Error(err)
+		if errors.Is(err, password.ErrPasswordReused) {
+			JSON(w, http.StatusBadRequest, types.NewErrorResponse(err))
+			return
+		}
 		JSON(w, http.StatusInternalServerError, types.NewErrorResponse(err))
 		return
 	}
@@ -83,3 +109,37 @@ func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 	logger.Info("password changed successfully")
 	JSON(w, http.StatusOK, passwordChangeResponse)
 }
+
+func changeLocalUserPassword(w http.ResponseWriter, username string, passwordPolicy password.PasswordPolicy, passwordChangeRequest PasswordChangeRequest) {
+	passwordBcrypt, err := store.GetStore().GetLocalUserPasswordBcrypt(username)
+	if err != nil {
+		logger.Error(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword(passwordBcrypt, []byte(passwordChangeRequest.CurrentPassword)); err != nil {
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			JSON(w, http.StatusBadRequest, types.NewErrorResponse(password.ErrCurrentPasswordDoesNotMatch))
+			return
+		}
+		logger.Error(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := setLocalUserPassword(username, passwordChangeRequest.NewPassword, passwordPolicy); err != nil {
+		if errors.Is(err, password.ErrPasswordReused) {
+			JSON(w, http.StatusBadRequest, types.NewErrorResponse(err))
+			return
+		}
+		logger.Error(err)
+		JSON(w, http.StatusInternalServerError, types.NewErrorResponse(err))
+		return
+	}
+
+	logger.Infof("password of user %s changed successfully", username)
+	JSON(w, http.StatusOK, PasswordChangeResponse{
+		Success: true,
+	})
+}
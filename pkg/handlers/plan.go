@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	apitypes "github.com/replicatedhq/kots/pkg/api/handlers/types"
+	"github.com/replicatedhq/kots/pkg/handlers/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/operator"
+	"github.com/replicatedhq/kots/pkg/store"
+	storetypes "github.com/replicatedhq/kots/pkg/store/types"
+)
+
+// GetAppVersionDeployPlan returns the changes that deploying a sequence would make to the cluster,
+// compared to the currently deployed sequence. Nothing is deployed.
+func (h *Handler) GetAppVersionDeployPlan(w http.ResponseWriter, r *http.Request) {
+	appSlug := mux.Vars(r)["appSlug"]
+
+	sequence, err := strconv.ParseInt(mux.Vars(r)["sequence"], 10, 64)
+	if err != nil {
+		JSON(w, http.StatusBadRequest, types.NewErrorResponse(errors.Errorf("invalid sequence %q", mux.Vars(r)["sequence"])))
+		return
+	}
+
+	a, err := store.GetStore().GetAppFromSlug(appSlug)
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get app from slug"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	status, err := store.GetStore().GetDownstreamVersionStatus(a.ID, sequence)
+	if err != nil {
+		logger.Error(errors.Wrapf(err, "failed to get status of version %d", sequence))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if status == "" {
+		JSON(w, http.StatusNotFound, types.NewErrorResponse(errors.Errorf("version %d not found", sequence)))
+		return
+	}
+	if status == storetypes.VersionPendingDownload {
+		JSON(w, http.StatusBadRequest, types.NewErrorResponse(errors.Errorf("version %d has not been downloaded", sequence)))
+		return
+	}
+
+	plan, err := operator.MustGetOperator().PlanDeploy(a.ID, sequence)
+	if err != nil {
+		logger.Error(errors.Wrapf(err, "failed to plan deploy of version %d", sequence))
+		JSON(w, http.StatusInternalServerError, types.NewErrorResponse(err))
+		return
+	}
+
+	JSON(w, http.StatusOK, apitypes.AppVersionDeployPlanResponse{Plan: plan})
+}
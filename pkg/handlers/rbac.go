@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/api/handlers/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/rbac"
+	rbactypes "github.com/replicatedhq/kots/pkg/rbac/types"
+	"github.com/replicatedhq/kots/pkg/store"
+)
+
+func (h *Handler) ListRBACRoles(w http.ResponseWriter, r *http.Request) {
+	listRBACRolesResponse := types.ListRBACRolesResponse{
+		Roles: []types.RBACRole{},
+	}
+
+	for _, role := range rbac.DefaultRoles() {
+		listRBACRolesResponse.Roles = append(listRBACRolesResponse.Roles, types.RBACRole{Role: role, IsDefault: true})
+	}
+
+	customRoles, err := store.GetStore().ListRBACRoles()
+	if err != nil {
+		listRBACRolesResponse.Error = "failed to list roles"
+		logger.Error(errors.Wrap(err, listRBACRolesResponse.Error))
+		JSON(w, http.StatusInternalServerError, listRBACRolesResponse)
+		return
+	}
+
+	for _, role := range customRoles {
+		listRBACRolesResponse.Roles = append(listRBACRolesResponse.Roles, types.RBACRole{Role: role})
+	}
+
+	JSON(w, http.StatusOK, listRBACRolesResponse)
+}
+
+func (h *Handler) CreateRBACRole(w http.ResponseWriter, r *http.Request) {
+	createRBACRoleResponse := types.RBACRoleResponse{}
+
+	role := rbactypes.Role{}
+	if err := json.NewDecoder(r.Body).Decode(&role); err != nil {
+		createRBACRoleResponse.Error = "failed to decode request body"
+		logger.Error(errors.Wrap(err, createRBACRoleResponse.Error))
+		JSON(w, http.StatusBadRequest, createRBACRoleResponse)
+		return
+	}
+
+	if err := rbac.ValidateRole(r.Context(), role); err != nil {
+		createRBACRoleResponse.Error = err.Error()
+		logger.Error(errors.Wrap(err, "invalid role"))
+		JSON(w, http.StatusBadRequest, createRBACRoleResponse)
+		return
+	}
+
+	_, err := store.GetStore().GetRBACRole(role.ID)
+	if err == nil {
+		createRBACRoleResponse.Error = "role already exists"
+		JSON(w, http.StatusConflict, createRBACRoleResponse)
+		return
+	} else if !store.GetStore().IsNotFound(err) {
+		createRBACRoleResponse.Error = "failed to get role"
+		logger.Error(errors.Wrap(err, createRBACRoleResponse.Error))
+		JSON(w, http.StatusInternalServerError, createRBACRoleResponse)
+		return
+	}
+
+	if err := store.GetStore().CreateRBACRole(role); err != nil {
+		createRBACRoleResponse.Error = "failed to create role"
+		logger.Error(errors.Wrap(err, createRBACRoleResponse.Error))
+		JSON(w, http.StatusInternalServerError, createRBACRoleResponse)
+		return
+	}
+
+	createRBACRoleResponse.Role = &role
+
+	JSON(w, http.StatusCreated, createRBACRoleResponse)
+}
+
+func (h *Handler) UpdateRBACRole(w http.ResponseWriter, r *http.Request) {
+	updateRBACRoleResponse := types.RBACRoleResponse{}
+
+	roleID := mux.Vars(r)["roleId"]
+
+	if rbac.IsDefaultRole(roleID) {
+		updateRBACRoleResponse.Error = "default roles cannot be modified"
+		JSON(w, http.StatusForbidden, updateRBACRoleResponse)
+		return
+	}
+
+	if _, err := store.GetStore().GetRBACRole(roleID); err != nil {
+		if store.GetStore().IsNotFound(err) {
+			updateRBACRoleResponse.Error = "role not found"
+			JSON(w, http.StatusNotFound, updateRBACRoleResponse)
+			return
+		}
+		updateRBACRoleResponse.Error = "failed to get role"
+		logger.Error(errors.Wrap(err, updateRBACRoleResponse.Error))
+		JSON(w, http.StatusInternalServerError, updateRBACRoleResponse)
+		return
+	}
+
+	role := rbactypes.Role{}
+	if err := json.NewDecoder(r.Body).Decode(&role); err != nil {
+		updateRBACRoleResponse.Error = "failed to decode request body"
+		logger.Error(errors.Wrap(err, updateRBACRoleResponse.Error))
+		JSON(w, http.StatusBadRequest, updateRBACRoleResponse)
+		return
+	}
+	role.ID = roleID
+
+	if err := rbac.ValidateRole(r.Context(), role); err != nil {
+		updateRBACRoleResponse.Error = err.Error()
+		logger.Error(errors.Wrap(err, "invalid role"))
+		JSON(w, http.StatusBadRequest, updateRBACRoleResponse)
+		return
+	}
+
+	if err := store.GetStore().UpdateRBACRole(role); err != nil {
+		updateRBACRoleResponse.Error = "failed to update role"
+		logger.Error(errors.Wrap(err, updateRBACRoleResponse.Error))
+		JSON(w, http.StatusInternalServerError, updateRBACRoleResponse)
+		return
+	}
+
+	updateRBACRoleResponse.Role = &role
+
+	JSON(w, http.StatusOK, updateRBACRoleResponse)
+}
+
+func (h *Handler) DeleteRBACRole(w http.ResponseWriter, r *http.Request) {
+	roleID := mux.Vars(r)["roleId"]
+
+	if rbac.IsDefaultRole(roleID) {
+		logger.Error(errors.Errorf("default role %s cannot be deleted", roleID))
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	if err := store.GetStore().DeleteRBACRole(roleID); err != nil {
+		logger.Error(errors.Wrap(err, "failed to delete role"))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
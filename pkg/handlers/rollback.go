@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	downstreamtypes "github.com/replicatedhq/kots/pkg/api/downstream/types"
+	apptypes "github.com/replicatedhq/kots/pkg/app/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/store"
+	"github.com/replicatedhq/kots/pkg/util"
+	"github.com/replicatedhq/kots/pkg/version"
+)
+
+type RollbackAppVersionResponse struct {
+	Success      bool   `json:"success"`
+	Sequence     int64  `json:"sequence"`
+	VersionLabel string `json:"versionLabel"`
+	Error        string `json:"error,omitempty"`
+}
+
+// RollbackAppVersion deploys the version that was deployed before the currently deployed version
+func (h *Handler) RollbackAppVersion(w http.ResponseWriter, r *http.Request) {
+	rollbackAppVersionResponse := RollbackAppVersionResponse{
+		Success: false,
+	}
+
+	appSlug := mux.Vars(r)["appSlug"]
+
+	a, err := store.GetStore().GetAppFromSlug(appSlug)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to get app for slug %s", appSlug)
+		logger.Error(errors.Wrap(err, errMsg))
+		rollbackAppVersionResponse.Error = errMsg
+		JSON(w, http.StatusInternalServerError, rollbackAppVersionResponse)
+		return
+	}
+
+	downstreams, err := store.GetStore().ListDownstreamsForApp(a.ID)
+	if err != nil {
+		errMsg := "failed to list downstreams for app"
+		logger.Error(errors.Wrap(err, errMsg))
+		rollbackAppVersionResponse.Error = errMsg
+		JSON(w, http.StatusInternalServerError, rollbackAppVersionResponse)
+		return
+	} else if len(downstreams) == 0 {
+		errMsg := fmt.Sprintf("no downstreams for app %s", appSlug)
+		logger.Error(errors.New(errMsg))
+		rollbackAppVersionResponse.Error = errMsg
+		JSON(w, http.StatusInternalServerError, rollbackAppVersionResponse)
+		return
+	}
+	clusterID := downstreams[0].ClusterID
+
+	previousSequence, err := store.GetStore().GetPreviouslyDeployedSequence(a.ID, clusterID)
+	if err != nil {
+		errMsg := "failed to get previously deployed sequence"
+		logger.Error(errors.Wrap(err, errMsg))
+		rollbackAppVersionResponse.Error = errMsg
+		JSON(w, http.StatusInternalServerError, rollbackAppVersionResponse)
+		return
+	}
+	if previousSequence == -1 {
+		rollbackAppVersionResponse.Error = "there is no previously deployed version to roll back to"
+		JSON(w, http.StatusBadRequest, rollbackAppVersionResponse)
+		return
+	}
+
+	versions, err := store.GetStore().GetDownstreamVersions(a.ID, clusterID, true)
+	if err != nil {
+		errMsg := "failed to get app versions"
+		logger.Error(errors.Wrap(err, errMsg))
+		rollbackAppVersionResponse.Error = errMsg
+		JSON(w, http.StatusInternalServerError, rollbackAppVersionResponse)
+		return
+	}
+
+	var previousVersion *downstreamtypes.DownstreamVersion
+	for _, v := range versions.AllVersions {
+		if v.Sequence == previousSequence {
+			previousVersion = v
+			break
+		}
+	}
+	if previousVersion == nil {
+		errMsg := fmt.Sprintf("previously deployed version %d not found", previousSequence)
+		logger.Error(errors.New(errMsg))
+		rollbackAppVersionResponse.Error = errMsg
+		JSON(w, http.StatusInternalServerError, rollbackAppVersionResponse)
+		return
+	}
+	rollbackAppVersionResponse.Sequence = previousVersion.Sequence
+	rollbackAppVersionResponse.VersionLabel = previousVersion.VersionLabel
+
+	nonDeployableCause, err := getNonDeployableCause(store.GetStore(), a.ID, previousVersion.Sequence, versions)
+	if err != nil {
+		errMsg := "failed to check if version is deployable"
+		logger.Error(errors.Wrap(err, errMsg))
+		rollbackAppVersionResponse.Error = errMsg
+		JSON(w, http.StatusInternalServerError, rollbackAppVersionResponse)
+		return
+	}
+	if nonDeployableCause != "" {
+		rollbackAppVersionResponse.Error = nonDeployableCause
+		JSON(w, http.StatusBadRequest, rollbackAppVersionResponse)
+		return
+	}
+
+	if isPastVersion(versions, previousVersion.Sequence) {
+		// disable automatic deployments so that they don't undo the rollback later
+		logger.Infof("disabling automatic deployments because a past version is being deployed for app %s", a.Slug)
+		if err := store.GetStore().SetAutoDeploy(a.ID, apptypes.AutoDeployDisabled); err != nil {
+			logger.Error(errors.Wrap(err, "failed to set versioning auto deploy"))
+		}
+	}
+
+	if err := store.GetStore().DeleteDownstreamDeployStatus(a.ID, clusterID, previousVersion.Sequence); err != nil {
+		errMsg := "failed to delete downstream deploy status"
+		logger.Error(errors.Wrap(err, errMsg))
+		rollbackAppVersionResponse.Error = errMsg
+		JSON(w, http.StatusInternalServerError, rollbackAppVersionResponse)
+		return
+	}
+
+	if err := version.DeployVersion(a.ID, previousVersion.Sequence); err != nil {
+		cause := errors.Cause(err)
+		if _, ok := cause.(util.ActionableError); ok {
+			rollbackAppVersionResponse.Error = cause.Error()
+		} else {
+			rollbackAppVersionResponse.Error = "failed to queue version for deployment"
+		}
+		logger.Error(errors.Wrap(err, "failed to queue version for deployment"))
+		JSON(w, http.StatusInternalServerError, rollbackAppVersionResponse)
+		return
+	}
+
+	rollbackAppVersionResponse.Success = true
+
+	JSON(w, http.StatusOK, rollbackAppVersionResponse)
+}
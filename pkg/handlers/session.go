@@ -82,6 +82,17 @@ func requireValidSession(kotsStore store.Store, w http.ResponseWriter, r *http.R
 		return nil, err
 	}
 
+	// api tokens are not tied to the password and are not extended when used
+	if sess.APITokenID != "" {
+		if time.Now().After(sess.ExpiresAt) {
+			err := errors.New("api token expired")
+			response := types.ErrorResponse{Error: util.StrPointer(err.Error())}
+			JSON(w, http.StatusUnauthorized, response)
+			return nil, err
+		}
+		return sess, nil
+	}
+
 	if time.Now().After(sess.ExpiresAt) {
 		if err := kotsStore.DeleteSession(sess.ID); err != nil {
 			logger.Error(errors.Wrapf(err, "session expired. failed to delete expired session %s", sess.ID))
@@ -9,9 +9,12 @@ import (
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/replicatedhq/kots/pkg/apitoken"
+	apitokentypes "github.com/replicatedhq/kots/pkg/apitoken/types"
 	"github.com/replicatedhq/kots/pkg/session"
 	"github.com/replicatedhq/kots/pkg/session/types"
 	"github.com/replicatedhq/kots/pkg/store"
+	"github.com/replicatedhq/kots/pkg/store/kotsstore"
 	mock_store "github.com/replicatedhq/kots/pkg/store/mock"
 	"github.com/stretchr/testify/require"
 )
@@ -478,3 +481,78 @@ func Test_requireValidSession_PasswordUpdated_AfterSessionIssuedErr_logDeleteSes
 	req.Equal(want, got)
 	req.Equal(401, w.Code)
 }
+
+func Test_requireValidSession_apiToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockStore := mock_store.NewMockStore(ctrl)
+
+	lastUsedAt := time.Now()
+	validToken := &apitokentypes.APIToken{
+		ID:         "valid-token-id",
+		Roles:      []string{"cluster-admin"},
+		CreatedAt:  time.Now().Add(-time.Hour),
+		ExpiresAt:  time.Now().Add(time.Hour),
+		LastUsedAt: &lastUsedAt,
+	}
+	expiredToken := &apitokentypes.APIToken{
+		ID:        "expired-token-id",
+		Roles:     []string{"cluster-admin"},
+		CreatedAt: time.Now().Add(-2 * time.Hour),
+		ExpiresAt: time.Now().Add(-time.Hour),
+	}
+
+	mockStore.EXPECT().GetAPITokenByHash(apitoken.Hash("kots_valid")).Return(validToken, nil).Times(2)
+	mockStore.EXPECT().GetAPITokenByHash(apitoken.Hash("kots_expired")).Return(expiredToken, nil)
+	mockStore.EXPECT().UpdateAPITokenLastUsedAt(expiredToken.ID, gomock.Any()).Return(nil)
+	mockStore.EXPECT().GetAPITokenByHash(apitoken.Hash("kots_unknown")).Return(nil, kotsstore.ErrNotFound)
+	mockStore.EXPECT().IsNotFound(kotsstore.ErrNotFound).Return(true)
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantID        string
+		wantErr       bool
+	}{
+		{
+			name:          "valid token",
+			authorization: "Token kots_valid",
+			wantID:        validToken.ID,
+		},
+		{
+			name:          "valid token as bearer",
+			authorization: "Bearer kots_valid",
+			wantID:        validToken.ID,
+		},
+		{
+			name:          "expired token",
+			authorization: "Token kots_expired",
+			wantErr:       true,
+		},
+		{
+			name:          "unknown token",
+			authorization: "Token kots_unknown",
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &http.Request{
+				Header: http.Header{
+					"Authorization": []string{tt.authorization},
+				},
+			}
+			w := httptest.NewRecorder()
+
+			got, err := requireValidSession(mockStore, w, r)
+			if tt.wantErr {
+				require.Error(t, err)
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantID, got.APITokenID)
+			require.Equal(t, []string{"cluster-admin"}, got.Roles)
+		})
+	}
+}
@@ -17,8 +17,9 @@ import (
 )
 
 type SetAutomaticUpdatesConfigRequest struct {
-	UpdateCheckerSpec string              `json:"updateCheckerSpec"`
-	AutoDeploy        apptypes.AutoDeploy `json:"autoDeploy"`
+	UpdateCheckerSpec string                      `json:"updateCheckerSpec"`
+	AutoDeploy        apptypes.AutoDeploy         `json:"autoDeploy"`
+	MaintenanceWindow *apptypes.MaintenanceWindow `json:"maintenanceWindow,omitempty"`
 }
 
 type SetAutomaticUpdatesConfigResponse struct {
@@ -26,9 +27,10 @@ type SetAutomaticUpdatesConfigResponse struct {
 }
 
 type GetAutomaticUpdatesConfigResponse struct {
-	UpdateCheckerSpec string              `json:"updateCheckerSpec"`
-	AutoDeploy        apptypes.AutoDeploy `json:"autoDeploy"`
-	Error             string              `json:"error"`
+	UpdateCheckerSpec string                      `json:"updateCheckerSpec"`
+	AutoDeploy        apptypes.AutoDeploy         `json:"autoDeploy"`
+	MaintenanceWindow *apptypes.MaintenanceWindow `json:"maintenanceWindow,omitempty"`
+	Error             string                      `json:"error"`
 }
 
 func (h *Handler) SetAutomaticUpdatesConfig(w http.ResponseWriter, r *http.Request) {
@@ -119,6 +121,12 @@ func (h *Handler) SetAutomaticUpdatesConfig(w http.ResponseWriter, r *http.Reque
 		}
 	}
 
+	if err := configureAutomaticUpdatesRequest.MaintenanceWindow.Validate(); err != nil {
+		updateCheckerSpecResponse.Error = errors.Wrap(err, "invalid maintenance window").Error()
+		JSON(w, http.StatusUnprocessableEntity, updateCheckerSpecResponse)
+		return
+	}
+
 	if foundApp.IsAirgap {
 		updateCheckerSpecResponse.Error = "airgap scheduled update checks are not supported"
 		logger.Error(errors.New(updateCheckerSpecResponse.Error))
@@ -152,6 +160,13 @@ func (h *Handler) SetAutomaticUpdatesConfig(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if err := store.GetStore().SetMaintenanceWindow(foundApp.ID, configureAutomaticUpdatesRequest.MaintenanceWindow); err != nil {
+		updateCheckerSpecResponse.Error = "failed to set maintenance window"
+		logger.Error(errors.Wrap(err, updateCheckerSpecResponse.Error))
+		JSON(w, http.StatusInternalServerError, updateCheckerSpecResponse)
+		return
+	}
+
 	// reconfigure update checker for the app
 	if err := updatechecker.Configure(foundApp, cronSpec); err != nil {
 		updateCheckerSpecResponse.Error = "failed to reconfigure update checker cron job"
@@ -192,6 +207,7 @@ func (h *Handler) GetAutomaticUpdatesConfig(w http.ResponseWriter, r *http.Reque
 		}
 		getCheckerSpecResponse.UpdateCheckerSpec = foundApp.UpdateCheckerSpec
 		getCheckerSpecResponse.AutoDeploy = foundApp.AutoDeploy
+		getCheckerSpecResponse.MaintenanceWindow = foundApp.MaintenanceWindow
 	}
 
 	JSON(w, http.StatusOK, getCheckerSpecResponse)
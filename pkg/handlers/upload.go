@@ -12,12 +12,14 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/replicatedhq/kots/pkg/handlers/types"
+	kotsadmtypes "github.com/replicatedhq/kots/pkg/kotsadm/types"
 	"github.com/replicatedhq/kots/pkg/kotsutil"
 	"github.com/replicatedhq/kots/pkg/logger"
 	"github.com/replicatedhq/kots/pkg/preflight"
 	"github.com/replicatedhq/kots/pkg/render"
 	"github.com/replicatedhq/kots/pkg/store"
 	storetypes "github.com/replicatedhq/kots/pkg/store/types"
+	"github.com/replicatedhq/kots/pkg/upstream"
 	"github.com/replicatedhq/kots/pkg/util"
 	"github.com/replicatedhq/kots/pkg/version"
 )
@@ -248,3 +250,268 @@ func (h *Handler) UploadExistingApp(w http.ResponseWriter, r *http.Request) {
 
 	JSON(w, http.StatusOK, uploadResponse)
 }
+
+// UploadNewAppRequest is the metadata sent by the KOTS CLI when uploading an app that does not exist yet.
+// Boolean fields are sent as strings.
+type UploadNewAppRequest struct {
+	Name           string `json:"name"`
+	VersionLabel   string `json:"versionLabel,omitempty"`
+	UpstreamURI    string `json:"upstreamURI"`
+	UpdateCursor   string `json:"updateCursor,omitempty"`
+	License        string `json:"license,omitempty"`
+	Deploy         string `json:"deploy"`
+	SkipPreflights string `json:"skipPreflights"`
+}
+
+// UploadNewApp can be used to upload a multipart form file to create a new app
+// This is used in the KOTS CLI when installing apps from git or local directory upstreams
+// NOTE: this uses special kots token authorization
+func (h *Handler) UploadNewApp(w http.ResponseWriter, r *http.Request) {
+	uploadResponse := UploadResponse{}
+
+	if err := requireValidKOTSToken(w, r); err != nil {
+		logger.Error(errors.Wrap(err, "failed to get valid token"))
+		return
+	}
+
+	metadata := r.FormValue("metadata")
+	uploadNewAppRequest := UploadNewAppRequest{}
+	if err := json.NewDecoder(strings.NewReader(metadata)).Decode(&uploadNewAppRequest); err != nil {
+		uploadResponse.Error = util.StrPointer("failed to decode request")
+		logger.Error(errors.Wrap(err, *uploadResponse.Error))
+		JSON(w, http.StatusInternalServerError, uploadResponse)
+		return
+	}
+
+	if uploadNewAppRequest.Name == "" || uploadNewAppRequest.UpstreamURI == "" {
+		uploadResponse.Error = util.StrPointer("name and upstream uri are required")
+		logger.Error(errors.New(*uploadResponse.Error))
+		JSON(w, http.StatusBadRequest, uploadResponse)
+		return
+	}
+
+	if err := upstream.ValidateInClusterUpstream(uploadNewAppRequest.UpstreamURI); err != nil {
+		uploadResponse.Error = util.StrPointer(err.Error())
+		logger.Error(err)
+		JSON(w, http.StatusBadRequest, uploadResponse)
+		return
+	}
+
+	deploy := uploadNewAppRequest.Deploy == "true"
+	skipPreflights := uploadNewAppRequest.SkipPreflights == "true"
+
+	archive, _, err := r.FormFile("file")
+	if err != nil {
+		uploadResponse.Error = util.StrPointer("failed to read file from request")
+		logger.Error(errors.Wrap(err, *uploadResponse.Error))
+		JSON(w, http.StatusInternalServerError, uploadResponse)
+		return
+	}
+
+	tmpFile, err := ioutil.TempFile("", "kotsadm")
+	if err != nil {
+		uploadResponse.Error = util.StrPointer("failed to create temp file")
+		logger.Error(errors.Wrap(err, *uploadResponse.Error))
+		JSON(w, http.StatusInternalServerError, uploadResponse)
+		return
+	}
+	_, err = io.Copy(tmpFile, archive)
+	if err != nil {
+		uploadResponse.Error = util.StrPointer("failed to copy file from request to temp file")
+		logger.Error(errors.Wrap(err, *uploadResponse.Error))
+		JSON(w, http.StatusInternalServerError, uploadResponse)
+		return
+	}
+	defer os.RemoveAll(tmpFile.Name())
+
+	archiveDir, err := version.ExtractArchiveToTempDirectory(tmpFile.Name())
+	if err != nil {
+		uploadResponse.Error = util.StrPointer("failed to extract file")
+		logger.Error(errors.Wrap(err, *uploadResponse.Error))
+		JSON(w, http.StatusInternalServerError, uploadResponse)
+		return
+	}
+	defer os.RemoveAll(archiveDir)
+
+	// encrypt any plain text values
+	kotsKinds, err := kotsutil.LoadKotsKindsFromPath(filepath.Join(archiveDir, "upstream"))
+	if err != nil {
+		uploadResponse.Error = util.StrPointer("failed to load kotskinds")
+		logger.Error(errors.Wrap(err, *uploadResponse.Error))
+		JSON(w, http.StatusInternalServerError, uploadResponse)
+		return
+	}
+
+	if kotsKinds.ConfigValues != nil {
+		if err := kotsKinds.EncryptConfigValues(); err != nil {
+			uploadResponse.Error = util.StrPointer("failed to encrypt config values")
+			logger.Error(errors.Wrap(err, *uploadResponse.Error))
+			JSON(w, http.StatusInternalServerError, uploadResponse)
+			return
+		}
+		updated, err := kotsKinds.Marshal("kots.io", "v1beta1", "ConfigValues")
+		if err != nil {
+			uploadResponse.Error = util.StrPointer("failed to marshal config values")
+			logger.Error(errors.Wrap(err, *uploadResponse.Error))
+			JSON(w, http.StatusInternalServerError, uploadResponse)
+			return
+		}
+
+		if err := ioutil.WriteFile(filepath.Join(archiveDir, "upstream", "userdata", "config.yaml"), []byte(updated), 0644); err != nil {
+			uploadResponse.Error = util.StrPointer("failed to write config values")
+			logger.Error(errors.Wrap(err, *uploadResponse.Error))
+			JSON(w, http.StatusInternalServerError, uploadResponse)
+			return
+		}
+	}
+
+	instParams, err := kotsutil.GetInstallationParams(kotsadmtypes.KotsadmConfigMap)
+	if err != nil {
+		uploadResponse.Error = util.StrPointer("failed to get installation params")
+		logger.Error(errors.Wrap(err, *uploadResponse.Error))
+		JSON(w, http.StatusInternalServerError, uploadResponse)
+		return
+	}
+
+	// credentials are stored encrypted and not as part of the upstream uri
+	upstreamURI, upstreamCredentials := upstream.SplitGitCredentials(uploadNewAppRequest.UpstreamURI)
+
+	a, err := store.GetStore().CreateApp(uploadNewAppRequest.Name, upstreamURI, uploadNewAppRequest.License, false, instParams.SkipImagePush, instParams.RegistryIsReadOnly)
+	if err != nil {
+		uploadResponse.Error = util.StrPointer("failed to create app")
+		logger.Error(errors.Wrap(err, *uploadResponse.Error))
+		JSON(w, http.StatusInternalServerError, uploadResponse)
+		return
+	}
+
+	if upstreamCredentials != nil {
+		password, _ := upstreamCredentials.Password()
+		if err := store.GetStore().SetAppUpstreamCredentials(a.ID, upstreamCredentials.Username(), password); err != nil {
+			uploadResponse.Error = util.StrPointer("failed to set app upstream credentials")
+			logger.Error(errors.Wrap(err, *uploadResponse.Error))
+			JSON(w, http.StatusInternalServerError, uploadResponse)
+			return
+		}
+	}
+
+	if err := store.GetStore().AddAppToAllDownstreams(a.ID); err != nil {
+		uploadResponse.Error = util.StrPointer("failed to add app to all downstreams")
+		logger.Error(errors.Wrap(err, *uploadResponse.Error))
+		JSON(w, http.StatusInternalServerError, uploadResponse)
+		return
+	}
+
+	if err := store.GetStore().SetAppIsAirgap(a.ID, false); err != nil {
+		uploadResponse.Error = util.StrPointer("failed to set app is not airgap")
+		logger.Error(errors.Wrap(err, *uploadResponse.Error))
+		JSON(w, http.StatusInternalServerError, uploadResponse)
+		return
+	}
+
+	registrySettings, err := store.GetStore().GetRegistryDetailsForApp(a.ID)
+	if err != nil {
+		uploadResponse.Error = util.StrPointer("failed to get registry settings")
+		logger.Error(errors.Wrap(err, *uploadResponse.Error))
+		JSON(w, http.StatusInternalServerError, uploadResponse)
+		return
+	}
+
+	downstreams, err := store.GetStore().ListDownstreamsForApp(a.ID)
+	if err != nil {
+		uploadResponse.Error = util.StrPointer("failed to list downstreams")
+		logger.Error(errors.Wrap(err, *uploadResponse.Error))
+		JSON(w, http.StatusInternalServerError, uploadResponse)
+		return
+	}
+
+	if len(downstreams) == 0 {
+		uploadResponse.Error = util.StrPointer(fmt.Sprintf("no downstreams found for deploying %s", a.Slug))
+		logger.Error(errors.New(*uploadResponse.Error))
+		JSON(w, http.StatusInternalServerError, uploadResponse)
+		return
+	}
+
+	err = render.RenderDir(archiveDir, a, downstreams, registrySettings, 0)
+	if err != nil {
+		cause := errors.Cause(err)
+		if _, ok := cause.(util.ActionableError); ok {
+			uploadResponse.Error = util.StrPointer(cause.Error())
+			logger.Error(errors.Wrap(err, *uploadResponse.Error))
+			JSON(w, http.StatusInternalServerError, uploadResponse)
+		} else {
+			uploadResponse.Error = util.StrPointer("failed to render app version")
+			logger.Error(errors.Wrap(err, *uploadResponse.Error))
+			JSON(w, http.StatusInternalServerError, uploadResponse)
+		}
+		return
+	}
+
+	newSequence, err := store.GetStore().CreateAppVersion(a.ID, nil, archiveDir, "KOTS Upload", skipPreflights, &version.DownstreamGitOps{}, render.Renderer{})
+	if err != nil {
+		uploadResponse.Error = util.StrPointer("failed to create app version")
+		logger.Error(errors.Wrap(err, *uploadResponse.Error))
+		JSON(w, http.StatusInternalServerError, uploadResponse)
+		return
+	}
+
+	if err := store.GetStore().SetAppInstallState(a.ID, "installed"); err != nil {
+		uploadResponse.Error = util.StrPointer("failed to set app install state")
+		logger.Error(errors.Wrap(err, *uploadResponse.Error))
+		JSON(w, http.StatusInternalServerError, uploadResponse)
+		return
+	}
+
+	hasStrictPreflights, err := store.GetStore().HasStrictPreflights(a.ID, newSequence)
+	if err != nil {
+		uploadResponse.Error = util.StrPointer("failed to check if app preflight has strict analyzers")
+		logger.Error(errors.Wrap(err, *uploadResponse.Error))
+		JSON(w, http.StatusInternalServerError, uploadResponse)
+		return
+	}
+
+	if hasStrictPreflights && skipPreflights {
+		logger.Warnf("preflights will not be skipped, strict preflights are set to %t", hasStrictPreflights)
+	}
+
+	if !skipPreflights || hasStrictPreflights {
+		if err := preflight.Run(a.ID, a.Slug, newSequence, a.IsAirgap, archiveDir); err != nil {
+			uploadResponse.Error = util.StrPointer("failed to get run preflights")
+			logger.Error(errors.Wrap(err, *uploadResponse.Error))
+			JSON(w, http.StatusInternalServerError, uploadResponse)
+			return
+		}
+	}
+
+	if deploy {
+		status, err := store.GetStore().GetStatusForVersion(a.ID, downstreams[0].ClusterID, newSequence)
+		if err != nil {
+			uploadResponse.Error = util.StrPointer("failed to get update downstream status")
+			logger.Error(errors.Wrap(err, *uploadResponse.Error))
+			JSON(w, http.StatusInternalServerError, uploadResponse)
+			return
+		}
+
+		// the app was created, so the version can still be configured and deployed from the admin console
+		if status != storetypes.VersionPendingConfig {
+			if err := version.DeployVersion(a.ID, newSequence); err != nil {
+				cause := errors.Cause(err)
+				if _, ok := cause.(util.ActionableError); ok {
+					uploadResponse.Error = util.StrPointer(cause.Error())
+					logger.Error(errors.Wrap(err, *uploadResponse.Error))
+					JSON(w, http.StatusInternalServerError, uploadResponse)
+				} else {
+					uploadResponse.Error = util.StrPointer("failed to deploy latest version")
+					logger.Error(errors.Wrap(err, *uploadResponse.Error))
+					JSON(w, http.StatusInternalServerError, uploadResponse)
+				}
+				return
+			}
+		}
+	}
+
+	uploadResponse.Slug = util.StrPointer(a.Slug)
+	uploadResponse.Success = true
+	uploadResponse.Error = nil
+
+	JSON(w, http.StatusOK, uploadResponse)
+}
@@ -43,6 +43,11 @@ func Init(ctx context.Context) error {
 		return errors.Wrap(err, "failed to get clientset")
 	}
 
+	dynamicClient, err := k8sutil.GetDynamicClient()
+	if err != nil {
+		return errors.Wrap(err, "failed to get dynamic client")
+	}
+
 	namespacesToWatch := []string{}
 	if k8sutil.IsKotsadmClusterScoped(ctx, clientSet, util.PodNamespace) {
 		namespaces, err := clientSet.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
@@ -68,7 +73,7 @@ func Init(ctx context.Context) error {
 			continue
 		}
 
-		initMonitor(clientSet, namespace)
+		initMonitor(clientSet, dynamicClient, namespace)
 		for _, s := range secrets.Items {
 			if s.Labels == nil || s.Labels["status"] != helmrelease.StatusDeployed.String() {
 				continue
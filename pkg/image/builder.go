@@ -29,6 +29,7 @@ import (
 	"github.com/replicatedhq/kots/pkg/k8sdoc"
 	"github.com/replicatedhq/kots/pkg/kotsutil"
 	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/metrics"
 	regsitrytypes "github.com/replicatedhq/kots/pkg/registry/types"
 	"github.com/replicatedhq/kots/pkg/util"
 	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
@@ -445,6 +446,20 @@ func CopyImage(opts types.CopyImageOptions) error {
 		imageListSelection = copy.CopyAllImages
 	}
 
+	// count the bytes of the layers that were copied, layers that already exist in the destination are skipped
+	progress := make(chan containerstypes.ProgressProperties)
+	copiedBytes := make(chan uint64)
+	go func() {
+		var total uint64
+		for p := range progress {
+			if p.Event == containerstypes.ProgressEventDone {
+				total += p.Offset
+			}
+		}
+		copiedBytes <- total
+	}()
+
+	startTime := time.Now()
 	_, err := CopyImageWithGC(context.Background(), opts.DestRef, opts.SrcRef, &copy.Options{
 		RemoveSignatures:      true,
 		SignBy:                "",
@@ -453,7 +468,11 @@ func CopyImage(opts types.CopyImageOptions) error {
 		DestinationCtx:        destCtx,
 		ForceManifestMIMEType: "",
 		ImageListSelection:    imageListSelection,
+		Progress:              progress,
+		ProgressInterval:      time.Second,
 	})
+	close(progress)
+	metrics.ObserveImagePush(<-copiedBytes, err, time.Since(startTime))
 	if err != nil {
 		return errors.Wrap(err, "failed to copy image")
 	}
@@ -8,6 +8,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/replicatedhq/kots/pkg/k8sutil"
 	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/metrics"
 	kotssnapshot "github.com/replicatedhq/kots/pkg/snapshot"
 	"github.com/replicatedhq/kots/pkg/supportbundle"
 	"github.com/replicatedhq/kots/pkg/util"
@@ -15,10 +16,15 @@ import (
 	veleroclientv1 "github.com/vmware-tanzu/velero/pkg/generated/clientset/versioned/typed/velero/v1"
 	kuberneteserrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 )
 
+// completedBackups are the uids of the backups whose final phase was already counted,
+// since completed backups are still modified, e.g. when a support bundle is requested
+var completedBackups = map[types.UID]bool{}
+
 // Start will start the kots informers
 // These are not the application level informers, but they are the general purpose KOTS
 // informers. For example, we want to watch Velero Backup
@@ -69,6 +75,8 @@ func Start() error {
 					logger.Errorf("failed to cast obj to backup")
 				}
 
+				recordCompletedBackup(backup)
+
 				if backup.Status.Phase == velerov1.BackupPhaseFailed || backup.Status.Phase == velerov1.BackupPhasePartiallyFailed {
 					if backup.Annotations == nil {
 						backup.Annotations = map[string]string{}
@@ -116,3 +124,22 @@ func Start() error {
 
 	return nil
 }
+
+func recordCompletedBackup(backup *velerov1.Backup) {
+	switch backup.Status.Phase {
+	case velerov1.BackupPhaseCompleted, velerov1.BackupPhasePartiallyFailed, velerov1.BackupPhaseFailed, velerov1.BackupPhaseFailedValidation:
+	default:
+		return
+	}
+
+	if completedBackups[backup.UID] {
+		return
+	}
+	completedBackups[backup.UID] = true
+
+	snapshotType := "app"
+	if backup.Annotations["kots.io/instance"] == "true" {
+		snapshotType = "instance"
+	}
+	metrics.IncSnapshotCompleted(snapshotType, string(backup.Status.Phase))
+}
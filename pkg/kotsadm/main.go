@@ -12,6 +12,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/crypto"
 	"github.com/replicatedhq/kots/pkg/docker/registry"
 	registrytypes "github.com/replicatedhq/kots/pkg/docker/registry/types"
 	"github.com/replicatedhq/kots/pkg/identity"
@@ -1078,6 +1079,13 @@ func ReadDeployOptionsFromCluster(namespace string, clientset *kubernetes.Client
 		if ok {
 			deployOptions.APIEncryptionKey = string(key)
 		}
+
+		// a wrapped key stays in the cluster, the key provider is needed to render the kotsadm pod
+		keyProvider, err := crypto.KeyProviderConfigFromSecret(encyptionSecret)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get api encryption key provider")
+		}
+		deployOptions.EncryptionKeyProvider = keyProvider
 	}
 
 	// AutoCreateClusterToken
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/crypto"
 	"github.com/replicatedhq/kots/pkg/ingress"
 	"github.com/replicatedhq/kots/pkg/k8sutil"
 	"github.com/replicatedhq/kots/pkg/kotsadm/types"
@@ -272,12 +273,7 @@ func KotsadmDeployment(deployOptions types.DeployOptions) (*appsv1.Deployment, e
 		{
 			Name: "API_ENCRYPTION_KEY",
 			ValueFrom: &corev1.EnvVarSource{
-				SecretKeyRef: &corev1.SecretKeySelector{
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: "kotsadm-encryption",
-					},
-					Key: "encryptionKey",
-				},
+				SecretKeyRef: apiEncryptionKeySelector(deployOptions),
 			},
 		},
 		{
@@ -608,6 +604,10 @@ func KotsadmDeployment(deployOptions types.DeployOptions) (*appsv1.Deployment, e
 									Name:          "http",
 									ContainerPort: 3000,
 								},
+								{
+									Name:          "metrics",
+									ContainerPort: 3001,
+								},
 							},
 							ReadinessProbe: &corev1.Probe{
 								FailureThreshold:    3,
@@ -650,6 +650,11 @@ func KotsadmDeployment(deployOptions types.DeployOptions) (*appsv1.Deployment, e
 		},
 	}
 
+	if deployOptions.EncryptionKeyProvider != nil && deployOptions.EncryptionKeyProvider.Type == crypto.KeyProviderFile {
+		deployment.Spec.Template.Spec.Volumes = append(deployment.Spec.Template.Spec.Volumes, encryptionKEKVolume(*deployOptions.EncryptionKeyProvider))
+		deployment.Spec.Template.Spec.Containers[0].VolumeMounts = append(deployment.Spec.Template.Spec.Containers[0].VolumeMounts, encryptionKEKVolumeMount())
+	}
+
 	return deployment, nil
 }
 
@@ -795,12 +800,7 @@ func KotsadmStatefulSet(deployOptions types.DeployOptions, size resource.Quantit
 		{
 			Name: "API_ENCRYPTION_KEY",
 			ValueFrom: &corev1.EnvVarSource{
-				SecretKeyRef: &corev1.SecretKeySelector{
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: "kotsadm-encryption",
-					},
-					Key: "encryptionKey",
-				},
+				SecretKeyRef: apiEncryptionKeySelector(deployOptions),
 			},
 		},
 		{
@@ -1115,6 +1115,10 @@ func KotsadmStatefulSet(deployOptions types.DeployOptions, size resource.Quantit
 									Name:          "http",
 									ContainerPort: 3000,
 								},
+								{
+									Name:          "metrics",
+									ContainerPort: 3001,
+								},
 							},
 							ReadinessProbe: &corev1.Probe{
 								FailureThreshold:    3,
@@ -1179,9 +1183,54 @@ func KotsadmStatefulSet(deployOptions types.DeployOptions, size resource.Quantit
 		},
 	}
 
+	if deployOptions.EncryptionKeyProvider != nil && deployOptions.EncryptionKeyProvider.Type == crypto.KeyProviderFile {
+		statefulset.Spec.Template.Spec.Volumes = append(statefulset.Spec.Template.Spec.Volumes, encryptionKEKVolume(*deployOptions.EncryptionKeyProvider))
+		statefulset.Spec.Template.Spec.Containers[0].VolumeMounts = append(statefulset.Spec.Template.Spec.Containers[0].VolumeMounts, encryptionKEKVolumeMount())
+	}
+
 	return statefulset, nil
 }
 
+// apiEncryptionKeySelector is optional when the key is wrapped by a key provider. The key is not stored in plain text then,
+// and is unwrapped from the secret at startup.
+func apiEncryptionKeySelector(deployOptions types.DeployOptions) *corev1.SecretKeySelector {
+	selector := &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{
+			Name: "kotsadm-encryption",
+		},
+		Key: "encryptionKey",
+	}
+	if deployOptions.EncryptionKeyProvider != nil {
+		selector.Optional = pointer.Bool(true)
+	}
+	return selector
+}
+
+// encryptionKEKVolume mounts the key encryption key of the file key provider from an external secrets store,
+// using the SecretProviderClass that the user created for the Secrets Store CSI driver
+func encryptionKEKVolume(keyProvider crypto.KeyProviderConfig) corev1.Volume {
+	return corev1.Volume{
+		Name: "encryption-kek",
+		VolumeSource: corev1.VolumeSource{
+			CSI: &corev1.CSIVolumeSource{
+				Driver:   "secrets-store.csi.k8s.io",
+				ReadOnly: pointer.Bool(true),
+				VolumeAttributes: map[string]string{
+					"secretProviderClass": keyProvider.KeyFileSecretProviderClass,
+				},
+			},
+		},
+	}
+}
+
+func encryptionKEKVolumeMount() corev1.VolumeMount {
+	return corev1.VolumeMount{
+		Name:      "encryption-kek",
+		MountPath: types.EncryptionKEKMountPath,
+		ReadOnly:  true,
+	}
+}
+
 func KotsadmService(namespace string, nodePort int32) *corev1.Service {
 	port := corev1.ServicePort{
 		Name:       "http",
@@ -48,18 +48,21 @@ func getSecretsYAML(deployOptions *types.DeployOptions) (map[string][]byte, erro
 	}
 	docs["secret-shared-password.yaml"] = sharedPassword.Bytes()
 
-	if deployOptions.APIEncryptionKey == "" {
-		err := crypto.NewAESCipher()
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to create new API encryption key")
+	// a key that is wrapped by a key provider is managed in the cluster and cannot be rendered
+	if deployOptions.EncryptionKeyProvider == nil {
+		if deployOptions.APIEncryptionKey == "" {
+			err := crypto.NewAESCipher()
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to create new API encryption key")
+			}
+			deployOptions.APIEncryptionKey = crypto.ToString()
 		}
-		deployOptions.APIEncryptionKey = crypto.ToString()
-	}
-	var apiEncryptionBuffer bytes.Buffer
-	if err := s.Encode(kotsadmobjects.ApiEncryptionKeySecret(deployOptions.Namespace, deployOptions.APIEncryptionKey), &apiEncryptionBuffer); err != nil {
-		return nil, errors.Wrap(err, "failed to marshal shared password secret")
+		var apiEncryptionBuffer bytes.Buffer
+		if err := s.Encode(kotsadmobjects.ApiEncryptionKeySecret(deployOptions.Namespace, deployOptions.APIEncryptionKey), &apiEncryptionBuffer); err != nil {
+			return nil, errors.Wrap(err, "failed to marshal shared password secret")
+		}
+		docs["secret-api-encryption.yaml"] = apiEncryptionBuffer.Bytes()
 	}
-	docs["secret-api-encryption.yaml"] = apiEncryptionBuffer.Bytes()
 
 	if deployOptions.IncludeMinio {
 		var s3 bytes.Buffer
@@ -262,7 +265,7 @@ func ensureAPIEncryptionSecret(deployOptions *types.DeployOptions, clientset *ku
 	}
 
 	if secret != nil {
-		if key, _ := secret.Data["encryptionKey"]; len(key) > 0 {
+		if len(secret.Data[crypto.EncryptionKeySecretKey]) > 0 || len(secret.Data[crypto.WrappedEncryptionKeySecretKey]) > 0 {
 			return nil
 		}
 	}
@@ -275,7 +278,14 @@ func ensureAPIEncryptionSecret(deployOptions *types.DeployOptions, clientset *ku
 		deployOptions.APIEncryptionKey = crypto.ToString()
 	}
 
-	_, err = clientset.CoreV1().Secrets(deployOptions.Namespace).Create(context.TODO(), kotsadmobjects.ApiEncryptionKeySecret(deployOptions.Namespace, deployOptions.APIEncryptionKey), metav1.CreateOptions{})
+	apiEncryptionSecret := kotsadmobjects.ApiEncryptionKeySecret(deployOptions.Namespace, deployOptions.APIEncryptionKey)
+	if deployOptions.EncryptionKeyProvider != nil {
+		if err := wrapAPIEncryptionSecret(deployOptions, apiEncryptionSecret); err != nil {
+			return errors.Wrap(err, "failed to wrap API encryption key")
+		}
+	}
+
+	_, err = clientset.CoreV1().Secrets(deployOptions.Namespace).Create(context.TODO(), apiEncryptionSecret, metav1.CreateOptions{})
 	if err != nil {
 		return errors.Wrap(err, "failed to create API encryption secret")
 	}
@@ -283,6 +293,36 @@ func ensureAPIEncryptionSecret(deployOptions *types.DeployOptions, clientset *ku
 	return nil
 }
 
+// wrapAPIEncryptionSecret wraps the key in the API encryption secret with the key provider. The key encryption key of the file
+// key provider is not stored in the cluster, the kotsadm pod reads it from the secrets store csi volume provided by the user.
+func wrapAPIEncryptionSecret(deployOptions *types.DeployOptions, apiEncryptionSecret *corev1.Secret) error {
+	providerConfig := *deployOptions.EncryptionKeyProvider
+
+	var provider crypto.KeyProvider
+	if providerConfig.Type == crypto.KeyProviderFile {
+		if len(deployOptions.EncryptionKEK) == 0 {
+			return errors.New("key encryption key is required for the file key provider")
+		}
+		p, err := crypto.NewFileKeyProvider(deployOptions.EncryptionKEK)
+		if err != nil {
+			return errors.Wrap(err, "failed to create file key provider")
+		}
+		provider = p
+	} else {
+		p, err := crypto.NewKeyProvider(providerConfig)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create %s key provider", providerConfig.Type)
+		}
+		provider = p
+	}
+
+	if err := crypto.WrapKeysInSecret(apiEncryptionSecret, provider, providerConfig, deployOptions.APIEncryptionKey, nil); err != nil {
+		return errors.Wrap(err, "failed to wrap keys")
+	}
+
+	return nil
+}
+
 func getAPIEncryptionSecret(namespace string, clientset *kubernetes.Clientset) (*corev1.Secret, error) {
 	apiSecret, err := clientset.CoreV1().Secrets(namespace).Get(context.TODO(), "kotsadm-encryption", metav1.GetOptions{})
 	if err != nil {
@@ -7,6 +7,9 @@ const ClusterTokenSecret = "kotsadm-cluster-token"
 const PrivateKotsadmRegistrySecret = "kotsadm-private-registry"
 const KotsadmConfigMap = "kotsadm-confg"
 
+// EncryptionKEKMountPath is where the secrets store csi volume with the key encryption key of the file key provider is mounted
+const EncryptionKEKMountPath = "/etc/kotsadm/encryption-kek"
+
 const ExcludeKey = "velero.io/exclude-from-backup"
 const ExcludeValue = "true"
 
@@ -4,6 +4,7 @@ import (
 	"io"
 	"time"
 
+	"github.com/replicatedhq/kots/pkg/crypto"
 	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 )
@@ -61,4 +62,9 @@ type DeployOptions struct {
 	IngressConfig  kotsv1beta1.IngressConfig
 
 	RegistryConfig RegistryConfig
+
+	// EncryptionKeyProvider wraps the API encryption key when it is set
+	EncryptionKeyProvider *crypto.KeyProviderConfig
+	// EncryptionKEK is the key encryption key of the file key provider, it is only set at install time
+	EncryptionKEK []byte
 }
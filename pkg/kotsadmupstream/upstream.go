@@ -2,6 +2,7 @@ package upstream
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -17,14 +18,28 @@ import (
 	"github.com/replicatedhq/kots/pkg/render"
 	"github.com/replicatedhq/kots/pkg/reporting"
 	"github.com/replicatedhq/kots/pkg/store"
+	"github.com/replicatedhq/kots/pkg/tracing"
 	"github.com/replicatedhq/kots/pkg/upstream"
 	"github.com/replicatedhq/kots/pkg/upstream/types"
 	"github.com/replicatedhq/kots/pkg/util"
 	"github.com/replicatedhq/kots/pkg/version"
 	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-func DownloadUpdate(appID string, update types.Update, skipPreflights bool, skipCompatibilityCheck bool) (finalSequence *int64, finalError error) {
+func DownloadUpdate(ctx context.Context, appID string, update types.Update, skipPreflights bool, skipCompatibilityCheck bool) (finalSequence *int64, finalError error) {
+	ctx, span := tracing.StartSpan(ctx, "download_update",
+		attribute.String("app_id", appID),
+		attribute.String("version_label", update.VersionLabel),
+		attribute.String("cursor", update.Cursor),
+	)
+	defer func() {
+		if finalSequence != nil {
+			span.SetAttributes(attribute.Int64("sequence", *finalSequence))
+		}
+		tracing.EndSpan(span, finalError)
+	}()
+
 	taskID := "update-download"
 	var finishedCh chan struct{}
 	if update.AppSequence != nil {
@@ -232,7 +247,7 @@ func DownloadUpdate(appID string, update types.Update, skipPreflights bool, skip
 		SkipCompatibilityCheck: skipCompatibilityCheck,
 	}
 
-	_, err = pull.Pull(fmt.Sprintf("replicated://%s", beforeKotsKinds.License.Spec.AppSlug), pullOptions)
+	_, err = pull.PullContext(ctx, fmt.Sprintf("replicated://%s", beforeKotsKinds.License.Spec.AppSlug), pullOptions)
 	if err != nil {
 		if errors.Cause(err) != pull.ErrConfigNeeded {
 			finalError = errors.Wrap(err, "failed to pull")
@@ -249,14 +264,18 @@ func DownloadUpdate(appID string, update types.Update, skipPreflights bool, skip
 		if afterKotsKinds.Installation.Spec.UpdateCursor == beforeInstallation.UpdateCursor && afterKotsKinds.Installation.Spec.ChannelID == beforeInstallation.ChannelID {
 			return
 		}
+		_, createSpan := tracing.StartSpan(ctx, "create_app_version")
 		newSequence, err := store.GetStore().CreateAppVersion(a.ID, &baseSequence, archiveDir, "Upstream Update", skipPreflights, &version.DownstreamGitOps{}, render.Renderer{})
+		tracing.EndSpan(createSpan, err)
 		if err != nil {
 			finalError = errors.Wrap(err, "failed to create version")
 			return
 		}
 		finalSequence = &newSequence
 	} else {
+		_, updateSpan := tracing.StartSpan(ctx, "update_app_version")
 		err := store.GetStore().UpdateAppVersion(a.ID, *update.AppSequence, &baseSequence, archiveDir, "Upstream Update", skipPreflights, &version.DownstreamGitOps{}, render.Renderer{})
+		tracing.EndSpan(updateSpan, err)
 		if err != nil {
 			finalError = errors.Wrap(err, "failed to create version")
 			return
@@ -271,11 +290,11 @@ func DownloadUpdate(appID string, update types.Update, skipPreflights bool, skip
 	}
 
 	if hasStrictPreflights && skipPreflights {
-		logger.Warnf("preflights will not be skipped, strict preflights are set to %t", hasStrictPreflights)
+		logger.WithContext(ctx).Warnf("preflights will not be skipped, strict preflights are set to %t", hasStrictPreflights)
 	}
 
 	if !skipPreflights || hasStrictPreflights {
-		if err := preflight.Run(appID, a.Slug, *finalSequence, a.IsAirgap, archiveDir); err != nil {
+		if err := preflight.RunContext(ctx, appID, a.Slug, *finalSequence, a.IsAirgap, archiveDir); err != nil {
 			finalError = errors.Wrap(err, "failed to run preflights")
 			return
 		}
@@ -0,0 +1,150 @@
+package leader
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+const (
+	// LeaseName is the name of the lease that kotsadm replicas campaign for
+	LeaseName = "kotsadm-leader"
+
+	leaseDuration = 15 * time.Second
+	renewDeadline = 10 * time.Second
+	retryPeriod   = 2 * time.Second
+)
+
+// Status describes this replica's view of the leader election
+type Status struct {
+	// Enabled is false when the process runs without leader election and is always the leader
+	Enabled  bool   `json:"enabled"`
+	Identity string `json:"identity"`
+	Leader   string `json:"leader"`
+	IsLeader bool   `json:"isLeader"`
+}
+
+var (
+	mtx    sync.RWMutex
+	status Status
+)
+
+// Start campaigns for the kotsadm leader lease in the given namespace and calls onStartedLeading
+// once this replica is elected. All replicas keep serving the API, only the leader runs the
+// background subsystems (operator, update checker, snapshot scheduler, etc). Those cannot be stopped
+// once started, so losing the lease exits the process and the replica restarts as a follower.
+func Start(ctx context.Context, clientset kubernetes.Interface, namespace string, identity string, onStartedLeading func()) error {
+	elector, err := newElector(clientset, namespace, identity, onStartedLeading, func() {
+		logger.Error(errors.New("lost the kotsadm leader lease, exiting"))
+		os.Exit(1)
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to create leader elector")
+	}
+
+	setStatus(Status{
+		Enabled:  true,
+		Identity: identity,
+	})
+
+	logger.Info("starting leader election", zap.String("identity", identity), zap.String("lease", LeaseName))
+
+	go elector.Run(ctx)
+
+	return nil
+}
+
+// StartWithoutElection makes this process the leader without campaigning for the lease. This is
+// used when there can only be one instance, e.g. when the admin console is managed by helm.
+func StartWithoutElection(identity string, onStartedLeading func()) {
+	setStatus(Status{
+		Enabled:  false,
+		Identity: identity,
+		Leader:   identity,
+		IsLeader: true,
+	})
+
+	go onStartedLeading()
+}
+
+// IsLeader returns true if this replica currently holds the leader lease
+func IsLeader() bool {
+	mtx.RLock()
+	defer mtx.RUnlock()
+
+	return status.IsLeader
+}
+
+// GetStatus returns the identity of this replica and the current leader
+func GetStatus() Status {
+	mtx.RLock()
+	defer mtx.RUnlock()
+
+	return status
+}
+
+func setStatus(s Status) {
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	status = s
+}
+
+func newElector(clientset kubernetes.Interface, namespace string, identity string, onStartedLeading func(), onStoppedLeading func()) (*leaderelection.LeaderElector, error) {
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      LeaseName,
+			Namespace: namespace,
+		},
+		Client: clientset.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: identity,
+		},
+	}
+
+	return leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:          lock,
+		LeaseDuration: leaseDuration,
+		RenewDeadline: renewDeadline,
+		RetryPeriod:   retryPeriod,
+		Name:          LeaseName,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				logger.Info("elected as the kotsadm leader", zap.String("identity", identity))
+
+				mtx.Lock()
+				status.Leader = identity
+				status.IsLeader = true
+				mtx.Unlock()
+
+				onStartedLeading()
+			},
+			OnStoppedLeading: func() {
+				mtx.Lock()
+				wasLeader := status.IsLeader
+				status.IsLeader = false
+				mtx.Unlock()
+
+				if wasLeader {
+					onStoppedLeading()
+				}
+			},
+			OnNewLeader: func(newLeader string) {
+				logger.Info("kotsadm leader changed", zap.String("leader", newLeader))
+
+				mtx.Lock()
+				status.Leader = newLeader
+				mtx.Unlock()
+			},
+		},
+	})
+}
@@ -0,0 +1,86 @@
+package leader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/pointer"
+)
+
+func TestStart(t *testing.T) {
+	req := require.New(t)
+
+	namespace := "default"
+	clientset := fake.NewSimpleClientset()
+
+	// another replica holds the lease
+	now := metav1.NewMicroTime(time.Now())
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      LeaseName,
+			Namespace: namespace,
+		},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       pointer.String("kotsadm-other"),
+			LeaseDurationSeconds: pointer.Int32(3600),
+			AcquireTime:          &now,
+			RenewTime:            &now,
+		},
+	}
+	_, err := clientset.CoordinationV1().Leases(namespace).Create(context.TODO(), lease, metav1.CreateOptions{})
+	req.NoError(err)
+
+	followerCtx, cancelFollower := context.WithCancel(context.Background())
+	err = Start(followerCtx, clientset, namespace, "kotsadm-follower", func() {
+		t.Error("follower should not be elected")
+	})
+	req.NoError(err)
+
+	req.Eventually(func() bool {
+		return GetStatus().Leader == "kotsadm-other"
+	}, 5*time.Second, 100*time.Millisecond)
+	req.Equal(Status{Enabled: true, Identity: "kotsadm-follower", Leader: "kotsadm-other"}, GetStatus())
+	req.False(IsLeader())
+	cancelFollower()
+
+	// the lease is released
+	err = clientset.CoordinationV1().Leases(namespace).Delete(context.TODO(), LeaseName, metav1.DeleteOptions{})
+	req.NoError(err)
+
+	elected := make(chan struct{})
+	err = Start(context.Background(), clientset, namespace, "kotsadm-leader", func() {
+		close(elected)
+	})
+	req.NoError(err)
+
+	select {
+	case <-elected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting to be elected")
+	}
+	req.Equal(Status{Enabled: true, Identity: "kotsadm-leader", Leader: "kotsadm-leader", IsLeader: true}, GetStatus())
+	req.True(IsLeader())
+
+	lease, err = clientset.CoordinationV1().Leases(namespace).Get(context.TODO(), LeaseName, metav1.GetOptions{})
+	req.NoError(err)
+	req.Equal("kotsadm-leader", *lease.Spec.HolderIdentity)
+}
+
+func TestStartWithoutElection(t *testing.T) {
+	started := make(chan struct{})
+	StartWithoutElection("kotsadm", func() {
+		close(started)
+	})
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for subsystems to start")
+	}
+	require.Equal(t, Status{Enabled: false, Identity: "kotsadm", Leader: "kotsadm", IsLeader: true}, GetStatus())
+}
@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// ContextLogger logs with the trace and span ids of the span in a context,
+// so that log lines can be matched with the trace they were written in
+type ContextLogger struct {
+	sugar *zap.SugaredLogger
+}
+
+// WithContext returns a logger that includes the trace_id and span_id of the
+// active span in ctx. When there is no span the logger behaves like the package logger.
+func WithContext(ctx context.Context) *ContextLogger {
+	l := log
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		l = l.With(
+			zap.String("trace_id", spanContext.TraceID().String()),
+			zap.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+	return &ContextLogger{sugar: l.Sugar()}
+}
+
+func (l *ContextLogger) Error(err error) {
+	defer l.sugar.Sync()
+	l.sugar.Error(err)
+}
+
+func (l *ContextLogger) Errorf(template string, args ...interface{}) {
+	defer l.sugar.Sync()
+	l.sugar.Errorf(template, args...)
+}
+
+func (l *ContextLogger) Info(msg string, fields ...zap.Field) {
+	defer l.sugar.Sync()
+	l.sugar.Desugar().Info(msg, fields...)
+}
+
+func (l *ContextLogger) Infof(template string, args ...interface{}) {
+	defer l.sugar.Sync()
+	l.sugar.Infof(template, args...)
+}
+
+func (l *ContextLogger) Debugf(template string, args ...interface{}) {
+	defer l.sugar.Sync()
+	l.sugar.Debugf(template, args...)
+}
+
+func (l *ContextLogger) Warnf(template string, args ...interface{}) {
+	defer l.sugar.Sync()
+	l.sugar.Warnf(template, args...)
+}
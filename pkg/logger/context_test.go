@@ -0,0 +1,46 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWithContext(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	prev := log
+	log = zap.New(core)
+	defer func() {
+		log = prev
+	}()
+
+	WithContext(context.Background()).Infof("no span")
+
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NoError(t, err)
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	require.NoError(t, err)
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	WithContext(ctx).Info("with span", zap.String("app_id", "app"))
+
+	entries := logs.All()
+	require.Len(t, entries, 2)
+
+	assert.Empty(t, entries[0].ContextMap())
+	assert.Equal(t, map[string]interface{}{
+		"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":  "00f067aa0ba902b7",
+		"app_id":   "app",
+	}, entries[1].ContextMap())
+}
@@ -0,0 +1,235 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	appstatetypes "github.com/replicatedhq/kots/pkg/appstate/types"
+)
+
+const namespace = "kotsadm"
+
+// Port is the port that the metrics are served on, separately from the API
+const Port = 3001
+
+var (
+	registry = prometheus.NewRegistry()
+
+	deploysTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "deploys_total",
+		Help:      "Number of app version deploys by outcome.",
+	}, []string{"app_id", "result"})
+
+	deployDurationSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "deploy_duration_seconds",
+		Help:      "Time taken to deploy an app version.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+	}, []string{"app_id"})
+
+	updateChecksTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "update_checks_total",
+		Help:      "Number of update checks by result.",
+	}, []string{"app_id", "result"})
+
+	updateCheckDurationSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "update_check_duration_seconds",
+		Help:      "Time taken to check for updates.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"app_id"})
+
+	preflightDurationSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "preflight_duration_seconds",
+		Help:      "Time taken to run preflight checks by resulting state.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 10),
+	}, []string{"app_id", "state"})
+
+	scheduledSnapshotsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "scheduled_snapshots_total",
+		Help:      "Number of scheduled snapshots started by the snapshot scheduler.",
+	}, []string{"type", "result"})
+
+	snapshotsCompletedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "snapshots_completed_total",
+		Help:      "Number of velero backups that finished, by phase.",
+	}, []string{"type", "phase"})
+
+	imagePushesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "image_pushes_total",
+		Help:      "Number of images copied to a registry by result.",
+	}, []string{"result"})
+
+	imagePushBytesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "image_push_bytes_total",
+		Help:      "Number of image layer bytes copied to a registry.",
+	})
+
+	imagePushDurationSeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "image_push_duration_seconds",
+		Help:      "Time taken to copy an image to a registry.",
+		Buckets:   prometheus.ExponentialBuckets(0.5, 2, 12),
+	})
+
+	httpRequestDurationSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of admin console API requests by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "code"})
+
+	appState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "app_state",
+		Help:      "Current state of each app, 1 for the state the app is in and 0 for the others.",
+	}, []string{"app_id", "state"})
+
+	appDriftedResources = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "app_drifted_resources",
+		Help:      "Number of resources of each app that are missing or modified in the cluster.",
+	}, []string{"app_id"})
+
+	appStates = []appstatetypes.State{
+		appstatetypes.StateReady,
+		appstatetypes.StateUpdating,
+		appstatetypes.StateDegraded,
+		appstatetypes.StateUnavailable,
+		appstatetypes.StateMissing,
+	}
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		deploysTotal,
+		deployDurationSeconds,
+		updateChecksTotal,
+		updateCheckDurationSeconds,
+		preflightDurationSeconds,
+		scheduledSnapshotsTotal,
+		snapshotsCompletedTotal,
+		imagePushesTotal,
+		imagePushBytesTotal,
+		imagePushDurationSeconds,
+		httpRequestDurationSeconds,
+		appState,
+		appDriftedResources,
+	)
+}
+
+// Handler serves the metrics in the prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// ListenAndServe serves the metrics on the metrics port. The metrics are not served by the API server since they
+// must not be reachable without authentication through the kotsadm service.
+func ListenAndServe() error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+
+	srv := &http.Server{
+		Handler: mux,
+		Addr:    fmt.Sprintf(":%d", Port),
+	}
+	return srv.ListenAndServe()
+}
+
+// ObserveDeploy records the outcome of deploying an app version
+func ObserveDeploy(appID string, deployed bool, err error, duration time.Duration) {
+	result := "deployed"
+	if err != nil {
+		result = "failed"
+	} else if !deployed {
+		result = "skipped"
+	}
+
+	deploysTotal.WithLabelValues(appID, result).Inc()
+	deployDurationSeconds.WithLabelValues(appID).Observe(duration.Seconds())
+}
+
+// ObserveUpdateCheck records the result of checking for updates
+func ObserveUpdateCheck(appID string, availableUpdates int64, err error, duration time.Duration) {
+	result := "no_updates"
+	if err != nil {
+		result = "error"
+	} else if availableUpdates > 0 {
+		result = "updates_available"
+	}
+
+	updateChecksTotal.WithLabelValues(appID, result).Inc()
+	updateCheckDurationSeconds.WithLabelValues(appID).Observe(duration.Seconds())
+}
+
+// ObservePreflight records how long preflight checks took. state is pass, warn, fail or error.
+func ObservePreflight(appID string, state string, duration time.Duration) {
+	preflightDurationSeconds.WithLabelValues(appID, state).Observe(duration.Seconds())
+}
+
+// IncScheduledSnapshot counts a scheduled snapshot of the given type (app or instance)
+func IncScheduledSnapshot(snapshotType string, err error) {
+	result := "created"
+	if err != nil {
+		result = "failed"
+	}
+	scheduledSnapshotsTotal.WithLabelValues(snapshotType, result).Inc()
+}
+
+// IncSnapshotCompleted counts a backup of the given type (app or instance) that reached a final phase
+func IncSnapshotCompleted(snapshotType string, phase string) {
+	snapshotsCompletedTotal.WithLabelValues(snapshotType, phase).Inc()
+}
+
+// ObserveImagePush records an image copied to a registry
+func ObserveImagePush(bytes uint64, err error, duration time.Duration) {
+	result := "success"
+	if err != nil {
+		result = "failed"
+	}
+
+	imagePushesTotal.WithLabelValues(result).Inc()
+	imagePushBytesTotal.Add(float64(bytes))
+	imagePushDurationSeconds.Observe(duration.Seconds())
+}
+
+// ObserveHTTPRequest records the latency of an api request
+func ObserveHTTPRequest(route string, method string, code int, duration time.Duration) {
+	httpRequestDurationSeconds.WithLabelValues(route, method, strconv.Itoa(code)).Observe(duration.Seconds())
+}
+
+// SetAppState sets the current state of an app
+func SetAppState(appID string, state appstatetypes.State) {
+	for _, s := range appStates {
+		value := 0.0
+		if s == state {
+			value = 1
+		}
+		appState.WithLabelValues(appID, string(s)).Set(value)
+	}
+}
+
+// DeleteAppState removes the state and drift of an app that is no longer installed
+func DeleteAppState(appID string) {
+	appState.DeletePartialMatch(prometheus.Labels{"app_id": appID})
+	appDriftedResources.DeletePartialMatch(prometheus.Labels{"app_id": appID})
+}
+
+// SetAppDriftedResources sets the number of resources of an app that drifted from the deployed version
+func SetAppDriftedResources(appID string, count int) {
+	appDriftedResources.WithLabelValues(appID).Set(float64(count))
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	appstatetypes "github.com/replicatedhq/kots/pkg/appstate/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserveDeploy(t *testing.T) {
+	ObserveDeploy("deploy-app", true, nil, time.Second)
+	ObserveDeploy("deploy-app", false, nil, time.Second)
+	ObserveDeploy("deploy-app", false, errors.New("failed"), time.Second)
+	ObserveDeploy("deploy-app", true, nil, time.Second)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(deploysTotal.WithLabelValues("deploy-app", "deployed")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(deploysTotal.WithLabelValues("deploy-app", "skipped")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(deploysTotal.WithLabelValues("deploy-app", "failed")))
+}
+
+func TestObserveUpdateCheck(t *testing.T) {
+	ObserveUpdateCheck("update-app", 0, nil, time.Second)
+	ObserveUpdateCheck("update-app", 2, nil, time.Second)
+	ObserveUpdateCheck("update-app", 0, errors.New("failed"), time.Second)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(updateChecksTotal.WithLabelValues("update-app", "no_updates")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(updateChecksTotal.WithLabelValues("update-app", "updates_available")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(updateChecksTotal.WithLabelValues("update-app", "error")))
+}
+
+func TestSetAppState(t *testing.T) {
+	SetAppState("state-app", appstatetypes.StateDegraded)
+	assert.Equal(t, 1.0, testutil.ToFloat64(appState.WithLabelValues("state-app", "degraded")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(appState.WithLabelValues("state-app", "ready")))
+
+	SetAppState("state-app", appstatetypes.StateReady)
+	assert.Equal(t, 0.0, testutil.ToFloat64(appState.WithLabelValues("state-app", "degraded")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(appState.WithLabelValues("state-app", "ready")))
+
+	DeleteAppState("state-app")
+	assert.Equal(t, 0, testutil.CollectAndCount(appState))
+}
+
+func TestHandler(t *testing.T) {
+	ObserveImagePush(1024, nil, time.Second)
+	ObserveHTTPRequest("GetApp", http.MethodGet, http.StatusOK, time.Millisecond)
+
+	server := httptest.NewServer(Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `kotsadm_image_push_bytes_total 1024`)
+	assert.Contains(t, string(body), `kotsadm_http_request_duration_seconds_count{code="200",method="GET",route="GetApp"} 1`)
+	assert.Contains(t, string(body), `go_goroutines`)
+}
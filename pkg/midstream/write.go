@@ -21,9 +21,11 @@ import (
 	"github.com/replicatedhq/kots/pkg/kotsutil"
 	"github.com/replicatedhq/kots/pkg/logger"
 	"github.com/replicatedhq/kots/pkg/template"
+	"github.com/replicatedhq/kots/pkg/tracing"
 	"github.com/replicatedhq/kots/pkg/upstream"
 	"github.com/replicatedhq/kots/pkg/util"
 	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	"go.opentelemetry.io/otel/attribute"
 	yaml "gopkg.in/yaml.v2"
 	corev1 "k8s.io/api/core/v1"
 	kustomizetypes "sigs.k8s.io/kustomize/api/types"
@@ -50,7 +52,12 @@ type WriteOptions struct {
 	NewHelmCharts      []*kotsv1beta1.HelmChart
 }
 
-func WriteMidstream(writeMidstreamOptions WriteOptions, processImageOptions image.ProcessImageOptions, b *base.Base, license *kotsv1beta1.License, identityConfig *kotsv1beta1.IdentityConfig, upstreamDir string, log *logger.CLILogger) (*Midstream, error) {
+func WriteMidstream(ctx context.Context, writeMidstreamOptions WriteOptions, processImageOptions image.ProcessImageOptions, b *base.Base, license *kotsv1beta1.License, identityConfig *kotsv1beta1.IdentityConfig, upstreamDir string, log *logger.CLILogger) (_ *Midstream, finalErr error) {
+	ctx, span := tracing.StartSpan(ctx, "midstream.write", attribute.String("base", b.Path))
+	defer func() {
+		tracing.EndSpan(span, finalErr)
+	}()
+
 	var images []kustomizetypes.Image
 	var objects []k8sdoc.K8sDoc
 	var pullSecretRegistries []string
@@ -89,9 +96,14 @@ func WriteMidstream(writeMidstreamOptions WriteOptions, processImageOptions imag
 			io.WriteString(processImageOptions.ReportWriter, "Copying images\n")
 		}
 
+		_, imagesSpan := tracing.StartSpan(ctx, "midstream.rewrite_images",
+			attribute.Bool("airgap", processImageOptions.AirgapRoot != ""),
+			attribute.Bool("copy_images", processImageOptions.CopyImages),
+		)
 		if processImageOptions.AirgapRoot == "" {
 			// This is an online installation. Pull and rewrite images from online and copy them (if necessary) to the configured registry.
 			rewriteResult, err := RewriteBaseImages(processImageOptions, writeMidstreamOptions.BaseDir, newKotsKinds, license, dockerHubRegistryCreds, log)
+			tracing.EndSpan(imagesSpan, err)
 			if err != nil {
 				return nil, errors.Wrap(err, "failed to rewrite base images")
 			}
@@ -100,6 +112,7 @@ func WriteMidstream(writeMidstreamOptions WriteOptions, processImageOptions imag
 		} else {
 			// This is an airgapped installation. Copy and rewrite images from the airgap bundle to the configured registry.
 			result, err := ProcessAirgapImages(processImageOptions, newKotsKinds, license, log)
+			tracing.EndSpan(imagesSpan, err)
 			if err != nil {
 				return nil, errors.Wrap(err, "failed to process airgap images")
 			}
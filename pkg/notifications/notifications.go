@@ -0,0 +1,151 @@
+package notifications
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/notifications/types"
+	"github.com/replicatedhq/kots/pkg/store"
+)
+
+var dispatcher *Dispatcher
+
+// Dispatcher sends events to the notification sinks configured in the store
+type Dispatcher struct {
+	store      store.Store
+	httpClient *http.Client
+	sendMail   sendMailFunc
+}
+
+func NewDispatcher(s store.Store) *Dispatcher {
+	return &Dispatcher{
+		store:      s,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		sendMail:   smtpSendMail,
+	}
+}
+
+// Init enables notifications. Until it is called, Notify is a no-op.
+func Init(s store.Store) {
+	dispatcher = NewDispatcher(s)
+}
+
+// Notify sends the event to all matching notification sinks in the background
+func Notify(event types.Event) {
+	if dispatcher == nil {
+		return
+	}
+
+	go func() {
+		if err := dispatcher.Dispatch(event); err != nil {
+			logger.Error(errors.Wrapf(err, "failed to dispatch %s notification", event.Type))
+		}
+	}()
+}
+
+// SendTest sends a test event to a single sink, regardless of its filter
+func SendTest(s store.Store, sink types.Sink) error {
+	d := NewDispatcher(s)
+	event := types.Event{
+		Type:      types.EventTypeTest,
+		Message:   fmt.Sprintf("Test notification for %q", sink.Name),
+		CreatedAt: time.Now(),
+	}
+	return d.Send(sink, event)
+}
+
+// Dispatch sends the event to all enabled sinks whose filter matches the event.
+// Failing to send to one sink does not prevent sending to the others.
+func (d *Dispatcher) Dispatch(event types.Event) error {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	if event.AppID != "" && event.AppSlug == "" {
+		a, err := d.store.GetApp(event.AppID)
+		if err != nil {
+			return errors.Wrap(err, "failed to get app")
+		}
+		event.AppSlug = a.Slug
+	}
+
+	sinks, err := d.store.ListNotificationSinks()
+	if err != nil {
+		return errors.Wrap(err, "failed to list notification sinks")
+	}
+
+	for _, sink := range sinks {
+		if !sink.Matches(event) {
+			continue
+		}
+		if err := d.Send(*sink, event); err != nil {
+			logger.Error(errors.Wrapf(err, "failed to send %s notification to sink %s", event.Type, sink.Name))
+		}
+	}
+
+	return nil
+}
+
+func (d *Dispatcher) Send(sink types.Sink, event types.Event) error {
+	switch sink.Type {
+	case types.SinkTypeWebhook:
+		if sink.Webhook == nil {
+			return errors.New("webhook sink is missing webhook config")
+		}
+		return d.sendWebhook(*sink.Webhook, event)
+	case types.SinkTypeSlack:
+		if sink.Slack == nil {
+			return errors.New("slack sink is missing slack config")
+		}
+		return d.sendSlack(*sink.Slack, event)
+	case types.SinkTypeSMTP:
+		if sink.SMTP == nil {
+			return errors.New("smtp sink is missing smtp config")
+		}
+		return d.sendSMTP(*sink.SMTP, event)
+	default:
+		return errors.Errorf("unsupported sink type %q", sink.Type)
+	}
+}
+
+// Validate checks that the sink has the configuration required by its type
+func Validate(sink types.Sink) error {
+	if sink.Name == "" {
+		return errors.New("name is required")
+	}
+
+	for _, eventType := range sink.Filter.Events {
+		if !eventType.IsValid() {
+			return errors.Errorf("unknown event type %q", eventType)
+		}
+	}
+
+	switch sink.Type {
+	case types.SinkTypeWebhook:
+		if sink.Webhook == nil || sink.Webhook.URL == "" {
+			return errors.New("webhook url is required")
+		}
+	case types.SinkTypeSlack:
+		if sink.Slack == nil || sink.Slack.WebhookURL == "" {
+			return errors.New("slack webhook url is required")
+		}
+	case types.SinkTypeSMTP:
+		if sink.SMTP == nil || sink.SMTP.Host == "" || sink.SMTP.From == "" || len(sink.SMTP.To) == 0 {
+			return errors.New("smtp host, from and to are required")
+		}
+	default:
+		return errors.Errorf("unsupported sink type %q", sink.Type)
+	}
+
+	return nil
+}
+
+func eventSubject(event types.Event) string {
+	if event.AppSlug != "" {
+		return fmt.Sprintf("[%s] %s", event.AppSlug, event.Type)
+	}
+	return string(event.Type)
+}
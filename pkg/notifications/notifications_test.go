@@ -0,0 +1,274 @@
+package notifications
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	apptypes "github.com/replicatedhq/kots/pkg/app/types"
+	appstatetypes "github.com/replicatedhq/kots/pkg/appstate/types"
+	"github.com/replicatedhq/kots/pkg/notifications/types"
+	mock_store "github.com/replicatedhq/kots/pkg/store/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type receivedRequest struct {
+	headers http.Header
+	body    []byte
+}
+
+func testServer(t *testing.T, statusCode int) (*httptest.Server, chan receivedRequest) {
+	received := make(chan receivedRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		received <- receivedRequest{headers: r.Header, body: body}
+		w.WriteHeader(statusCode)
+	}))
+	t.Cleanup(server.Close)
+	return server, received
+}
+
+func TestFilter_Matches(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter types.Filter
+		event  types.Event
+		want   bool
+	}{
+		{
+			name:   "empty filter matches everything",
+			filter: types.Filter{},
+			event:  types.Event{Type: types.EventTypeDeployFailed, AppSlug: "my-app"},
+			want:   true,
+		},
+		{
+			name:   "event type does not match",
+			filter: types.Filter{Events: []types.EventType{types.EventTypePreflightFailed}},
+			event:  types.Event{Type: types.EventTypeDeployFailed, AppSlug: "my-app"},
+			want:   false,
+		},
+		{
+			name:   "app slug does not match",
+			filter: types.Filter{AppSlugs: []string{"other-app"}},
+			event:  types.Event{Type: types.EventTypeDeployFailed, AppSlug: "my-app"},
+			want:   false,
+		},
+		{
+			name:   "app state matches",
+			filter: types.Filter{AppStates: []appstatetypes.State{appstatetypes.StateDegraded, appstatetypes.StateUnavailable}},
+			event:  types.Event{Type: types.EventTypeAppStateChanged, State: appstatetypes.StateDegraded},
+			want:   true,
+		},
+		{
+			name:   "app state does not match",
+			filter: types.Filter{AppStates: []appstatetypes.State{appstatetypes.StateDegraded}},
+			event:  types.Event{Type: types.EventTypeAppStateChanged, State: appstatetypes.StateReady},
+			want:   false,
+		},
+		{
+			name:   "app states are ignored for other events",
+			filter: types.Filter{AppStates: []appstatetypes.State{appstatetypes.StateDegraded}},
+			event:  types.Event{Type: types.EventTypeUpdateAvailable},
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.filter.Matches(tt.event))
+		})
+	}
+}
+
+func TestDispatcher_sendWebhook(t *testing.T) {
+	req := require.New(t)
+
+	server, received := testServer(t, http.StatusOK)
+
+	d := NewDispatcher(nil)
+	event := types.Event{
+		Type:      types.EventTypeDeployFailed,
+		AppSlug:   "my-app",
+		Message:   "Failed to deploy sequence 1",
+		CreatedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	err := d.Send(types.Sink{
+		Type:    types.SinkTypeWebhook,
+		Webhook: &types.WebhookConfig{URL: server.URL, Secret: "s3cr3t"},
+	}, event)
+	req.NoError(err)
+
+	r := <-received
+	req.Equal("deploy-failed", r.headers.Get(EventHeader))
+	req.Equal(Sign("s3cr3t", r.body), r.headers.Get(SignatureHeader))
+
+	gotEvent := types.Event{}
+	req.NoError(json.Unmarshal(r.body, &gotEvent))
+	req.Equal(event, gotEvent)
+}
+
+func TestDispatcher_sendWebhookError(t *testing.T) {
+	server, _ := testServer(t, http.StatusInternalServerError)
+
+	d := NewDispatcher(nil)
+	err := d.Send(types.Sink{
+		Type:    types.SinkTypeWebhook,
+		Webhook: &types.WebhookConfig{URL: server.URL},
+	}, types.Event{Type: types.EventTypeDeployFailed})
+	require.Error(t, err)
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '{"type":"test"}' | openssl dgst -sha256 -hmac secret
+	require.Equal(t, "sha256=e0c6dc0edbeee535e9560c6876404637e75d912703f2cf36863b2220daa18af8", Sign("secret", []byte(`{"type":"test"}`)))
+}
+
+func TestDispatcher_sendSlack(t *testing.T) {
+	req := require.New(t)
+
+	server, received := testServer(t, http.StatusOK)
+
+	d := NewDispatcher(nil)
+	err := d.Send(types.Sink{
+		Type:  types.SinkTypeSlack,
+		Slack: &types.SlackConfig{WebhookURL: server.URL},
+	}, types.Event{Type: types.EventTypePreflightFailed, AppSlug: "my-app", Message: "Preflight checks failed for sequence 2"})
+	req.NoError(err)
+
+	r := <-received
+	req.JSONEq(`{"text":"*[my-app] preflight-failed*\nPreflight checks failed for sequence 2"}`, string(r.body))
+}
+
+func TestDispatcher_sendSMTP(t *testing.T) {
+	req := require.New(t)
+
+	var gotAddr, gotFrom string
+	var gotTo []string
+	var gotMsg []byte
+
+	d := NewDispatcher(nil)
+	d.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr, gotFrom, gotTo, gotMsg = addr, from, to, msg
+		return nil
+	}
+
+	err := d.Send(types.Sink{
+		Type: types.SinkTypeSMTP,
+		SMTP: &types.SMTPConfig{Host: "smtp.example.com", From: "kots@example.com", To: []string{"ops@example.com", "dev@example.com"}},
+	}, types.Event{Type: types.EventTypeSnapshotFailed, AppSlug: "my-app", Message: "Scheduled application snapshot failed"})
+	req.NoError(err)
+
+	req.Equal("smtp.example.com:587", gotAddr)
+	req.Equal("kots@example.com", gotFrom)
+	req.Equal([]string{"ops@example.com", "dev@example.com"}, gotTo)
+	req.Contains(string(gotMsg), "To: ops@example.com, dev@example.com\r\n")
+	req.Contains(string(gotMsg), "Subject: [my-app] snapshot-failed\r\n")
+	req.Contains(string(gotMsg), "\r\n\r\nScheduled application snapshot failed\r\n")
+}
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	req := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	matchingServer, matchingReceived := testServer(t, http.StatusOK)
+	failingServer, _ := testServer(t, http.StatusInternalServerError)
+	filteredServer, filteredReceived := testServer(t, http.StatusOK)
+
+	mockStore := mock_store.NewMockStore(ctrl)
+	mockStore.EXPECT().GetApp("app-id").Return(&apptypes.App{ID: "app-id", Slug: "my-app"}, nil)
+	mockStore.EXPECT().ListNotificationSinks().Return([]*types.Sink{
+		{
+			Name:    "failing",
+			Type:    types.SinkTypeWebhook,
+			Enabled: true,
+			Webhook: &types.WebhookConfig{URL: failingServer.URL},
+		},
+		{
+			Name:    "matching",
+			Type:    types.SinkTypeWebhook,
+			Enabled: true,
+			Filter:  types.Filter{Events: []types.EventType{types.EventTypeDeployFailed}, AppSlugs: []string{"my-app"}},
+			Webhook: &types.WebhookConfig{URL: matchingServer.URL},
+		},
+		{
+			Name:    "filtered",
+			Type:    types.SinkTypeWebhook,
+			Enabled: true,
+			Filter:  types.Filter{Events: []types.EventType{types.EventTypeUpdateAvailable}},
+			Webhook: &types.WebhookConfig{URL: filteredServer.URL},
+		},
+		{
+			Name:    "disabled",
+			Type:    types.SinkTypeWebhook,
+			Enabled: false,
+			Webhook: &types.WebhookConfig{URL: filteredServer.URL},
+		},
+	}, nil)
+
+	d := NewDispatcher(mockStore)
+	err := d.Dispatch(types.Event{Type: types.EventTypeDeployFailed, AppID: "app-id", Message: "Failed to deploy sequence 1"})
+	req.NoError(err)
+
+	r := <-matchingReceived
+	gotEvent := types.Event{}
+	req.NoError(json.Unmarshal(r.body, &gotEvent))
+	req.Equal("my-app", gotEvent.AppSlug)
+	req.False(gotEvent.CreatedAt.IsZero())
+
+	req.Empty(filteredReceived)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		sink    types.Sink
+		wantErr bool
+	}{
+		{
+			name: "valid webhook",
+			sink: types.Sink{Name: "webhook", Type: types.SinkTypeWebhook, Webhook: &types.WebhookConfig{URL: "https://example.com"}},
+		},
+		{
+			name:    "missing name",
+			sink:    types.Sink{Type: types.SinkTypeWebhook, Webhook: &types.WebhookConfig{URL: "https://example.com"}},
+			wantErr: true,
+		},
+		{
+			name:    "missing slack url",
+			sink:    types.Sink{Name: "slack", Type: types.SinkTypeSlack, Slack: &types.SlackConfig{}},
+			wantErr: true,
+		},
+		{
+			name:    "missing smtp recipients",
+			sink:    types.Sink{Name: "smtp", Type: types.SinkTypeSMTP, SMTP: &types.SMTPConfig{Host: "smtp.example.com", From: "kots@example.com"}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown event type",
+			sink:    types.Sink{Name: "webhook", Type: types.SinkTypeWebhook, Filter: types.Filter{Events: []types.EventType{"unknown"}}, Webhook: &types.WebhookConfig{URL: "https://example.com"}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown sink type",
+			sink:    types.Sink{Name: "pager", Type: "pager"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.sink)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
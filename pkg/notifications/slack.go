@@ -0,0 +1,24 @@
+package notifications
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/notifications/types"
+)
+
+type slackPayload struct {
+	Text string `json:"text"`
+}
+
+func (d *Dispatcher) sendSlack(config types.SlackConfig, event types.Event) error {
+	payload, err := json.Marshal(slackPayload{
+		Text: fmt.Sprintf("*%s*\n%s", eventSubject(event), event.Message),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal slack payload")
+	}
+
+	return d.postJSON(config.WebhookURL, payload, nil)
+}
@@ -0,0 +1,49 @@
+package notifications
+
+import (
+	"bytes"
+	"fmt"
+	"net/smtp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/notifications/types"
+)
+
+const (
+	defaultSMTPPort = 587
+)
+
+type sendMailFunc func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+var smtpSendMail sendMailFunc = smtp.SendMail
+
+func (d *Dispatcher) sendSMTP(config types.SMTPConfig, event types.Event) error {
+	port := config.Port
+	if port == 0 {
+		port = defaultSMTPPort
+	}
+
+	var auth smtp.Auth
+	if config.Username != "" {
+		auth = smtp.PlainAuth("", config.Username, config.Password, config.Host)
+	}
+
+	msg := buildMailMessage(config.From, config.To, eventSubject(event), event.Message)
+	if err := d.sendMail(fmt.Sprintf("%s:%d", config.Host, port), auth, config.From, config.To, msg); err != nil {
+		return errors.Wrap(err, "failed to send mail")
+	}
+
+	return nil
+}
+
+func buildMailMessage(from string, to []string, subject string, body string) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	fmt.Fprintf(&msg, "\r\n%s\r\n", body)
+	return msg.Bytes()
+}
@@ -0,0 +1,188 @@
+package types
+
+import (
+	"time"
+
+	appstatetypes "github.com/replicatedhq/kots/pkg/appstate/types"
+)
+
+const (
+	SecretMask = "***HIDDEN***"
+)
+
+type EventType string
+
+const (
+	EventTypeUpdateAvailable EventType = "update-available"
+	EventTypePreflightFailed EventType = "preflight-failed"
+	EventTypeDeployFailed    EventType = "deploy-failed"
+	EventTypeSnapshotFailed  EventType = "snapshot-failed"
+	EventTypeAppStateChanged EventType = "app-state-changed"
+
+	// EventTypeTest is only sent when testing a sink and cannot be used in filters
+	EventTypeTest EventType = "test"
+)
+
+var AllEventTypes = []EventType{
+	EventTypeUpdateAvailable,
+	EventTypePreflightFailed,
+	EventTypeDeployFailed,
+	EventTypeSnapshotFailed,
+	EventTypeAppStateChanged,
+}
+
+func (t EventType) IsValid() bool {
+	for _, eventType := range AllEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type Event struct {
+	Type      EventType           `json:"type"`
+	AppID     string              `json:"appId,omitempty"`
+	AppSlug   string              `json:"appSlug,omitempty"`
+	Sequence  *int64              `json:"sequence,omitempty"`
+	State     appstatetypes.State `json:"state,omitempty"`
+	Message   string              `json:"message"`
+	CreatedAt time.Time           `json:"createdAt"`
+}
+
+type SinkType string
+
+const (
+	SinkTypeWebhook SinkType = "webhook"
+	SinkTypeSlack   SinkType = "slack"
+	SinkTypeSMTP    SinkType = "smtp"
+)
+
+type Sink struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	Type      SinkType       `json:"type"`
+	Enabled   bool           `json:"enabled"`
+	Filter    Filter         `json:"filter"`
+	Webhook   *WebhookConfig `json:"webhook,omitempty"`
+	Slack     *SlackConfig   `json:"slack,omitempty"`
+	SMTP      *SMTPConfig    `json:"smtp,omitempty"`
+	CreatedAt time.Time      `json:"createdAt"`
+}
+
+// Filter restricts the events sent to a sink. Empty lists match everything.
+type Filter struct {
+	Events    []EventType           `json:"events,omitempty"`
+	AppSlugs  []string              `json:"appSlugs,omitempty"`
+	AppStates []appstatetypes.State `json:"appStates,omitempty"` // only applies to app-state-changed events
+}
+
+type WebhookConfig struct {
+	URL string `json:"url"`
+	// Secret is used to sign the payload with HMAC-SHA256. The signature is sent in the X-Kots-Signature header.
+	Secret string `json:"secret,omitempty"`
+}
+
+type SlackConfig struct {
+	WebhookURL string `json:"webhookUrl"`
+}
+
+type SMTPConfig struct {
+	Host     string   `json:"host"`
+	Port     int      `json:"port"`
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+}
+
+// SinkConfig holds the sink type specific configuration, which is stored encrypted since it contains secrets
+type SinkConfig struct {
+	Webhook *WebhookConfig `json:"webhook,omitempty"`
+	Slack   *SlackConfig   `json:"slack,omitempty"`
+	SMTP    *SMTPConfig    `json:"smtp,omitempty"`
+}
+
+func (s Sink) Matches(event Event) bool {
+	if !s.Enabled {
+		return false
+	}
+	return s.Filter.Matches(event)
+}
+
+func (f Filter) Matches(event Event) bool {
+	if len(f.Events) > 0 && !containsEventType(f.Events, event.Type) {
+		return false
+	}
+	if len(f.AppSlugs) > 0 && !containsString(f.AppSlugs, event.AppSlug) {
+		return false
+	}
+	if event.Type == EventTypeAppStateChanged && len(f.AppStates) > 0 && !containsState(f.AppStates, event.State) {
+		return false
+	}
+	return true
+}
+
+// Redacted returns a copy of the sink with secrets masked
+func (s Sink) Redacted() Sink {
+	if s.Webhook != nil {
+		webhook := *s.Webhook
+		if webhook.Secret != "" {
+			webhook.Secret = SecretMask
+		}
+		s.Webhook = &webhook
+	}
+	if s.Slack != nil {
+		slack := *s.Slack
+		slack.WebhookURL = SecretMask
+		s.Slack = &slack
+	}
+	if s.SMTP != nil {
+		smtp := *s.SMTP
+		if smtp.Password != "" {
+			smtp.Password = SecretMask
+		}
+		s.SMTP = &smtp
+	}
+	return s
+}
+
+// KeepMaskedSecrets replaces masked secrets with the secrets of the current sink
+func (s *Sink) KeepMaskedSecrets(current Sink) {
+	if s.Webhook != nil && s.Webhook.Secret == SecretMask && current.Webhook != nil {
+		s.Webhook.Secret = current.Webhook.Secret
+	}
+	if s.Slack != nil && s.Slack.WebhookURL == SecretMask && current.Slack != nil {
+		s.Slack.WebhookURL = current.Slack.WebhookURL
+	}
+	if s.SMTP != nil && s.SMTP.Password == SecretMask && current.SMTP != nil {
+		s.SMTP.Password = current.SMTP.Password
+	}
+}
+
+func containsEventType(eventTypes []EventType, eventType EventType) bool {
+	for _, t := range eventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsState(states []appstatetypes.State, state appstatetypes.State) bool {
+	for _, s := range states {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
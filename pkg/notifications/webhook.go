@@ -0,0 +1,67 @@
+package notifications
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/notifications/types"
+)
+
+const (
+	EventHeader     = "X-Kots-Event"
+	SignatureHeader = "X-Kots-Signature"
+)
+
+func (d *Dispatcher) sendWebhook(config types.WebhookConfig, event types.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal event")
+	}
+
+	headers := map[string]string{
+		EventHeader: string(event.Type),
+	}
+	if config.Secret != "" {
+		headers[SignatureHeader] = Sign(config.Secret, payload)
+	}
+
+	return d.postJSON(config.URL, payload, headers)
+}
+
+// Sign returns the HMAC-SHA256 signature of the payload in the form sha256=<hex digest>
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return fmt.Sprintf("sha256=%s", hex.EncodeToString(mac.Sum(nil)))
+}
+
+func (d *Dispatcher) postJSON(url string, payload []byte, headers map[string]string) error {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(payload))
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to send request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return errors.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
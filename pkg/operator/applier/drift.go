@@ -0,0 +1,210 @@
+package applier
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+	drifttypes "github.com/replicatedhq/kots/pkg/drift/types"
+	corev1 "k8s.io/api/core/v1"
+	kuberneteserrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	redactedValue        = "<redacted>"
+	redactedChangedValue = "<redacted, changed>"
+)
+
+var secretGroupKind = schema.GroupKind{Kind: "Secret"}
+
+// DetectDrift compares every document in yamlDoc with the live resource in the cluster and returns the
+// resources that drifted, along with the number of resources that were checked. The expected state of a
+// resource is what a forced server-side apply dry run returns, so fields that are defaulted by the API
+// server or only set by other field managers are not reported as drift.
+func (s *ServerSide) DetectDrift(targetNamespace string, slug string, yamlDoc []byte, annotateSlug bool) ([]drifttypes.ResourceDrift, int, error) {
+	objs, err := decodeDocs(yamlDoc)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to decode documents")
+	}
+
+	drifted := []drifttypes.ResourceDrift{}
+	checked := 0
+	for _, obj := range objs {
+		if _, ok := obj.GetAnnotations()["kots.io/hook-delete-policy"]; ok {
+			// hooks are deleted by kots once they complete
+			continue
+		}
+
+		if annotateSlug {
+			annotations := obj.GetAnnotations()
+			if annotations == nil {
+				annotations = map[string]string{}
+			}
+			annotations["kots.io/app-slug"] = slug
+			obj.SetAnnotations(annotations)
+		}
+
+		checked++
+		resourceDrift := s.detectResourceDrift(targetNamespace, obj)
+		if resourceDrift.Drift != "" {
+			drifted = append(drifted, resourceDrift)
+		}
+	}
+
+	return drifted, checked, nil
+}
+
+func (s *ServerSide) detectResourceDrift(targetNamespace string, obj *unstructured.Unstructured) drifttypes.ResourceDrift {
+	gvk := obj.GroupVersionKind()
+	result := drifttypes.ResourceDrift{
+		Group:   gvk.Group,
+		Version: gvk.Version,
+		Kind:    gvk.Kind,
+		Name:    obj.GetName(),
+	}
+
+	dr, mapping, err := s.resourceInterface(gvk, obj.GetNamespace(), targetNamespace)
+	if err != nil {
+		result.Resource = strings.ToLower(gvk.Kind)
+		if meta.IsNoMatchError(err) {
+			// the kind no longer exists in the cluster, e.g. the crd was deleted
+			result.Drift = drifttypes.DriftMissing
+			return result
+		}
+		result.Drift = drifttypes.DriftUnknown
+		result.Error = err.Error()
+		return result
+	}
+	result.Resource = mapping.Resource.Resource
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		result.Namespace = resourceNamespace(obj.GetNamespace(), targetNamespace)
+		obj.SetNamespace(result.Namespace)
+	}
+
+	live, err := dr.Get(context.TODO(), obj.GetName(), metav1.GetOptions{})
+	if kuberneteserrors.IsNotFound(err) {
+		result.Drift = drifttypes.DriftMissing
+		return result
+	} else if err != nil {
+		result.Drift = drifttypes.DriftUnknown
+		result.Error = errors.Wrap(err, "failed to get live resource").Error()
+		return result
+	}
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		result.Drift = drifttypes.DriftUnknown
+		result.Error = errors.Wrap(err, "failed to marshal resource").Error()
+		return result
+	}
+
+	expected, err := dr.Patch(context.TODO(), obj.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{
+		FieldManager: FieldManager,
+		Force:        pointer.Bool(true),
+		DryRun:       []string{metav1.DryRunAll},
+	})
+	if err != nil {
+		result.Drift = drifttypes.DriftUnknown
+		result.Error = errors.Wrap(err, "failed to server-side apply (server dry run)").Error()
+		return result
+	}
+
+	diff, err := diffResources(live, expected)
+	if err != nil {
+		result.Drift = drifttypes.DriftUnknown
+		result.Error = err.Error()
+		return result
+	}
+	if diff != "" {
+		result.Drift = drifttypes.DriftModified
+		result.Diff = diff
+	}
+
+	return result
+}
+
+// diffResources returns a unified diff from the live resource to the expected resource, ignoring
+// status and the metadata that the api server maintains. The values of secrets are redacted.
+func diffResources(live *unstructured.Unstructured, expected *unstructured.Unstructured) (string, error) {
+	prunedLive, prunedExpected := pruneForDiff(live), pruneForDiff(expected)
+	if live.GroupVersionKind().GroupKind() == secretGroupKind {
+		redactSecretValues(prunedLive, prunedExpected)
+	}
+
+	liveYAML, err := yaml.Marshal(prunedLive.Object)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal live resource")
+	}
+	expectedYAML, err := yaml.Marshal(prunedExpected.Object)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal expected resource")
+	}
+	if string(liveYAML) == string(expectedYAML) {
+		return "", nil
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(liveYAML)),
+		B:        difflib.SplitLines(string(expectedYAML)),
+		FromFile: "live",
+		ToFile:   "expected",
+		Context:  3,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to diff resources")
+	}
+	return diff, nil
+}
+
+// redactSecretValues replaces the values of the secrets so that the diff, which is stored and returned to users
+// that cannot read the rendered app, only reports the keys that are added, removed or changed
+func redactSecretValues(live *unstructured.Unstructured, expected *unstructured.Unstructured) {
+	// the last applied configuration of kubectl client-side apply includes the values as well
+	for _, obj := range []*unstructured.Unstructured{live, expected} {
+		unstructured.RemoveNestedField(obj.Object, "metadata", "annotations", corev1.LastAppliedConfigAnnotation)
+		if len(obj.GetAnnotations()) == 0 {
+			unstructured.RemoveNestedField(obj.Object, "metadata", "annotations")
+		}
+	}
+
+	for _, field := range []string{"data", "stringData"} {
+		liveValues, _, _ := unstructured.NestedMap(live.Object, field)
+		expectedValues, _, _ := unstructured.NestedMap(expected.Object, field)
+
+		for key, value := range expectedValues {
+			if liveValue, ok := liveValues[key]; ok && liveValue != value {
+				expectedValues[key] = redactedChangedValue
+			} else {
+				expectedValues[key] = redactedValue
+			}
+		}
+		for key := range liveValues {
+			liveValues[key] = redactedValue
+		}
+
+		if liveValues != nil {
+			_ = unstructured.SetNestedMap(live.Object, liveValues, field)
+		}
+		if expectedValues != nil {
+			_ = unstructured.SetNestedMap(expected.Object, expectedValues, field)
+		}
+	}
+}
+
+func pruneForDiff(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	pruned := obj.DeepCopy()
+	unstructured.RemoveNestedField(pruned.Object, "status")
+	for _, field := range []string{"managedFields", "resourceVersion", "generation", "uid", "creationTimestamp", "selfLink"} {
+		unstructured.RemoveNestedField(pruned.Object, "metadata", field)
+	}
+	return pruned
+}
@@ -0,0 +1,152 @@
+package applier
+
+import (
+	"testing"
+
+	drifttypes "github.com/replicatedhq/kots/pkg/drift/types"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestServerSide_DetectDrift(t *testing.T) {
+	req := require.New(t)
+
+	unchanged := testConfigMap("app-ns", "unchanged")
+	unchanged.Object["data"] = map[string]interface{}{"key": "value"}
+
+	modified := testConfigMap("app-ns", "modified")
+	modified.Object["data"] = map[string]interface{}{"key": "edited"}
+	modified.SetManagedFields([]metav1.ManagedFieldsEntry{{Manager: "kubectl-edit"}})
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), unchanged, modified)
+
+	// the dry run returns the live resource with the applied data, like the api server would
+	dynamicClient.PrependReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patchAction := action.(k8stesting.PatchAction)
+		req.Equal(types.ApplyPatchType, patchAction.GetPatchType())
+
+		applied := &unstructured.Unstructured{}
+		req.NoError(applied.UnmarshalJSON(patchAction.GetPatch()))
+
+		var live *unstructured.Unstructured
+		switch patchAction.GetName() {
+		case "unchanged":
+			live = unchanged.DeepCopy()
+		case "modified":
+			live = modified.DeepCopy()
+		}
+		live.Object["data"] = applied.Object["data"]
+		live.SetManagedFields([]metav1.ManagedFieldsEntry{{Manager: FieldManager}})
+		return true, live, nil
+	})
+
+	yamlDoc := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unchanged
+data:
+  key: value
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: modified
+data:
+  key: value
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: deleted
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: hook
+  annotations:
+    kots.io/hook-delete-policy: hook-succeeded
+---
+apiVersion: example.com/v1
+kind: Unknown
+metadata:
+  name: crd-deleted`
+
+	s := NewServerSide(dynamicClient, testRESTMapper())
+	got, checked, err := s.DetectDrift("app-ns", "my-app", []byte(yamlDoc), false)
+	req.NoError(err)
+	req.Equal(4, checked)
+	req.Equal([]drifttypes.ResourceDrift{
+		{
+			Version:   "v1",
+			Kind:      "ConfigMap",
+			Resource:  "configmaps",
+			Namespace: "app-ns",
+			Name:      "modified",
+			Drift:     drifttypes.DriftModified,
+			Diff: `--- live
++++ expected
+@@ -1,6 +1,6 @@
+ apiVersion: v1
+ data:
+-  key: edited
++  key: value
+ kind: ConfigMap
+ metadata:
+   name: modified
+`,
+		},
+		{Version: "v1", Kind: "ConfigMap", Resource: "configmaps", Namespace: "app-ns", Name: "deleted", Drift: drifttypes.DriftMissing},
+		{Group: "example.com", Version: "v1", Kind: "Unknown", Resource: "unknown", Name: "crd-deleted", Drift: drifttypes.DriftMissing},
+	}, got)
+}
+
+func Test_diffResourcesRedactsSecrets(t *testing.T) {
+	req := require.New(t)
+
+	secret := func(data map[string]interface{}) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata": map[string]interface{}{
+				"name":      "credentials",
+				"namespace": "app-ns",
+				"annotations": map[string]interface{}{
+					"kubectl.kubernetes.io/last-applied-configuration": `{"data":{"password":"b2xk"}}`,
+				},
+			},
+			"data": data,
+		}}
+		return obj
+	}
+
+	live := secret(map[string]interface{}{"username": "YWRtaW4=", "password": "b2xk", "removed": "eA=="})
+	expected := secret(map[string]interface{}{"username": "YWRtaW4=", "password": "bmV3", "added": "eQ=="})
+
+	diff, err := diffResources(live, expected)
+	req.NoError(err)
+	req.Equal(`--- live
++++ expected
+@@ -1,7 +1,7 @@
+ apiVersion: v1
+ data:
+-  password: <redacted>
+-  removed: <redacted>
++  added: <redacted>
++  password: <redacted, changed>
+   username: <redacted>
+ kind: Secret
+ metadata:
+`, diff)
+
+	// only the values of secrets are redacted
+	req.Equal("b2xk", live.Object["data"].(map[string]interface{})["password"])
+
+	diff, err = diffResources(live, live)
+	req.NoError(err)
+	req.Empty(diff)
+}
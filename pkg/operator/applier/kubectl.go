@@ -12,6 +12,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/replicatedhq/kots/pkg/logger"
 	"github.com/replicatedhq/kots/pkg/util"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	rest "k8s.io/client-go/rest"
 )
 
@@ -27,7 +28,7 @@ type Kubectl struct {
 	config    *rest.Config
 }
 
-func NewKubectl(kubectl, kustomize string, config *rest.Config) KubectlInterface {
+func NewKubectl(kubectl, kustomize string, config *rest.Config) *Kubectl {
 	return &Kubectl{
 		kubectl:   kubectl,
 		kustomize: kustomize,
@@ -143,6 +144,48 @@ commonAnnotations:
 	return stdout, stderr, errors.Wrap(err, "failed to run kubectl apply")
 }
 
+// DryRunApply returns the resource in yamlDoc as it would be after applying it. Unlike Apply with dryRun,
+// the dry run is done by the api server, so the result includes the fields that it defaults.
+func (c *Kubectl) DryRunApply(targetNamespace string, yamlDoc []byte) (*unstructured.Unstructured, error) {
+	tmp, err := ioutil.TempDir("", "kots-apply-")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create temp directory")
+	}
+	defer os.RemoveAll(tmp)
+
+	yamlPath := filepath.Join(tmp, "doc.yaml")
+	if err := ioutil.WriteFile(yamlPath, yamlDoc, 0644); err != nil {
+		return nil, errors.Wrapf(err, "failed to write %s", yamlPath)
+	}
+
+	args := []string{
+		"apply",
+		"--dry-run=server",
+		"-o",
+		"json",
+		"-f",
+		yamlPath,
+	}
+	if targetNamespace != "" {
+		args = append(args, []string{
+			"-n",
+			targetNamespace,
+		}...)
+	}
+
+	stdout, stderr, err := Run(c.kubectlCommand(args...))
+	if err != nil {
+		// the error from the api server, e.g. a change to an immutable field, is only reported on stderr
+		return nil, errors.Wrap(errors.New(strings.TrimSpace(string(stderr))), "failed to run kubectl apply")
+	}
+
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(stdout); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal kubectl apply output")
+	}
+	return obj, nil
+}
+
 // ApplyCreateOrPatch attempts to run a `kubectl apply` on the yaml document. If it fails
 // it will try to split a multi-doc and try again. As a last resort it will try create and patch.
 // It's important to use patch as a last resort because it can trigger load balancer services
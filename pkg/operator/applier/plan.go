@@ -0,0 +1,208 @@
+package applier
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	operatortypes "github.com/replicatedhq/kots/pkg/operator/types"
+	kuberneteserrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/utils/pointer"
+)
+
+// dryRunApplyFunc returns the resource as it would be after applying obj
+type dryRunApplyFunc func(dr dynamic.ResourceInterface, obj *unstructured.Unstructured) (*unstructured.Unstructured, error)
+
+// PlanApply returns what applying every document in yamlDoc would do to the live resources. Existing
+// resources are compared with the result of a forced server-side apply dry run, so the plan does not
+// include fields that are defaulted by the API server.
+func (s *ServerSide) PlanApply(targetNamespace string, slug string, yamlDoc []byte, annotateSlug bool) ([]operatortypes.PlannedResource, error) {
+	return s.planApply(targetNamespace, slug, yamlDoc, annotateSlug, serverSideDryRunApply)
+}
+
+// PlanClientSideApply is the same as PlanApply for resources that are deployed with kubectl. Existing resources
+// are compared with the result of "kubectl apply --dry-run=server", which merges the document into the live
+// resource the same way the deployment will.
+func (s *ServerSide) PlanClientSideApply(kubectl *Kubectl, targetNamespace string, slug string, yamlDoc []byte, annotateSlug bool) ([]operatortypes.PlannedResource, error) {
+	return s.planApply(targetNamespace, slug, yamlDoc, annotateSlug, func(_ dynamic.ResourceInterface, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+		data, err := json.Marshal(obj)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to marshal resource")
+		}
+		return kubectl.DryRunApply(obj.GetNamespace(), data)
+	})
+}
+
+func (s *ServerSide) planApply(targetNamespace string, slug string, yamlDoc []byte, annotateSlug bool, dryRunApply dryRunApplyFunc) ([]operatortypes.PlannedResource, error) {
+	objs, err := decodeDocs(yamlDoc)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode documents")
+	}
+
+	planned := []operatortypes.PlannedResource{}
+	for _, obj := range objs {
+		if annotateSlug {
+			annotations := obj.GetAnnotations()
+			if annotations == nil {
+				annotations = map[string]string{}
+			}
+			annotations["kots.io/app-slug"] = slug
+			obj.SetAnnotations(annotations)
+		}
+
+		planned = append(planned, s.planApplyResource(targetNamespace, obj, dryRunApply))
+	}
+
+	return planned, nil
+}
+
+// PlanRemove returns the resources in yamlDoc that exist in the cluster and would be deleted
+func (s *ServerSide) PlanRemove(targetNamespace string, yamlDoc []byte) ([]operatortypes.PlannedResource, error) {
+	objs, err := decodeDocs(yamlDoc)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode documents")
+	}
+
+	planned := []operatortypes.PlannedResource{}
+	for _, obj := range objs {
+		result := newPlannedResource(obj)
+
+		dr, mapping, err := s.resourceInterface(obj.GroupVersionKind(), obj.GetNamespace(), targetNamespace)
+		if meta.IsNoMatchError(err) {
+			// the kind no longer exists in the cluster, so neither does the resource
+			continue
+		} else if err != nil {
+			result.Resource = strings.ToLower(result.Kind)
+			result.Action = operatortypes.PlanUnknown
+			result.Error = err.Error()
+			planned = append(planned, result)
+			continue
+		}
+		setPlannedResourceMapping(&result, mapping, obj, targetNamespace)
+
+		_, err = dr.Get(context.TODO(), obj.GetName(), metav1.GetOptions{})
+		if kuberneteserrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			result.Action = operatortypes.PlanUnknown
+			result.Error = errors.Wrap(err, "failed to get live resource").Error()
+		} else {
+			result.Action = operatortypes.PlanDelete
+		}
+		planned = append(planned, result)
+	}
+
+	return planned, nil
+}
+
+func (s *ServerSide) planApplyResource(targetNamespace string, obj *unstructured.Unstructured, dryRunApply dryRunApplyFunc) operatortypes.PlannedResource {
+	result := newPlannedResource(obj)
+
+	dr, mapping, err := s.resourceInterface(obj.GroupVersionKind(), obj.GetNamespace(), targetNamespace)
+	if err != nil {
+		result.Resource = strings.ToLower(result.Kind)
+		if meta.IsNoMatchError(err) {
+			// the kind is likely provided by a crd that is created by the same deployment
+			result.Action = operatortypes.PlanCreate
+			return result
+		}
+		result.Action = operatortypes.PlanUnknown
+		result.Error = err.Error()
+		return result
+	}
+	setPlannedResourceMapping(&result, mapping, obj, targetNamespace)
+
+	live, err := dr.Get(context.TODO(), obj.GetName(), metav1.GetOptions{})
+	if kuberneteserrors.IsNotFound(err) {
+		result.Action = operatortypes.PlanCreate
+		return result
+	} else if err != nil {
+		result.Action = operatortypes.PlanUnknown
+		result.Error = errors.Wrap(err, "failed to get live resource").Error()
+		return result
+	}
+
+	expected, err := dryRunApply(dr, obj)
+	if err != nil {
+		if isImmutableFieldError(err) {
+			result.Action = operatortypes.PlanRecreate
+		} else {
+			result.Action = operatortypes.PlanUnknown
+		}
+		result.Error = err.Error()
+		return result
+	}
+
+	diff, err := diffResources(live, expected)
+	if err != nil {
+		result.Action = operatortypes.PlanUnknown
+		result.Error = err.Error()
+		return result
+	}
+	if diff == "" {
+		result.Action = operatortypes.PlanUnchanged
+		return result
+	}
+
+	result.Action = operatortypes.PlanUpdate
+	result.Diff = diff
+	return result
+}
+
+func serverSideDryRunApply(dr dynamic.ResourceInterface, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal resource")
+	}
+
+	return dr.Patch(context.TODO(), obj.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{
+		FieldManager: FieldManager,
+		Force:        pointer.Bool(true),
+		DryRun:       []string{metav1.DryRunAll},
+	})
+}
+
+func newPlannedResource(obj *unstructured.Unstructured) operatortypes.PlannedResource {
+	gvk := obj.GroupVersionKind()
+	return operatortypes.PlannedResource{
+		Group:   gvk.Group,
+		Version: gvk.Version,
+		Kind:    gvk.Kind,
+		Name:    obj.GetName(),
+	}
+}
+
+func setPlannedResourceMapping(result *operatortypes.PlannedResource, mapping *meta.RESTMapping, obj *unstructured.Unstructured, targetNamespace string) {
+	result.Resource = mapping.Resource.Resource
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		result.Namespace = resourceNamespace(obj.GetNamespace(), targetNamespace)
+		obj.SetNamespace(result.Namespace)
+	}
+}
+
+// isImmutableFieldError returns true if the api server rejected a change to a field that
+// cannot be updated, e.g. the selector of a deployment or the spec of a job
+func isImmutableFieldError(err error) bool {
+	status, ok := errors.Cause(err).(kuberneteserrors.APIStatus)
+	if !ok {
+		// kubectl only reports the invalid response on stderr, e.g. `The ConfigMap "example" is invalid: data: Forbidden: field is immutable`
+		return strings.Contains(err.Error(), " is invalid: ") && strings.Contains(err.Error(), "immutable")
+	}
+	if !kuberneteserrors.IsInvalid(err) {
+		return false
+	}
+	if status.Status().Details != nil {
+		for _, cause := range status.Status().Details.Causes {
+			if strings.Contains(cause.Message, "immutable") {
+				return true
+			}
+		}
+	}
+	return strings.Contains(err.Error(), "immutable")
+}
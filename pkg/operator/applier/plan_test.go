@@ -0,0 +1,202 @@
+package applier
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	operatortypes "github.com/replicatedhq/kots/pkg/operator/types"
+	"github.com/stretchr/testify/require"
+	kuberneteserrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestServerSide_PlanApply(t *testing.T) {
+	req := require.New(t)
+
+	unchanged := testConfigMap("app-ns", "unchanged")
+	unchanged.Object["data"] = map[string]interface{}{"key": "value"}
+
+	updated := testConfigMap("app-ns", "updated")
+	updated.Object["data"] = map[string]interface{}{"key": "old"}
+
+	immutable := testConfigMap("app-ns", "immutable")
+	immutable.Object["immutable"] = true
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), unchanged, updated, immutable)
+
+	// the dry run returns the live resource with the applied data, like the api server would
+	dynamicClient.PrependReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patchAction := action.(k8stesting.PatchAction)
+		req.Equal(types.ApplyPatchType, patchAction.GetPatchType())
+
+		applied := &unstructured.Unstructured{}
+		req.NoError(applied.UnmarshalJSON(patchAction.GetPatch()))
+
+		var live *unstructured.Unstructured
+		switch patchAction.GetName() {
+		case "unchanged":
+			live = unchanged.DeepCopy()
+		case "updated":
+			live = updated.DeepCopy()
+		case "immutable":
+			return true, nil, kuberneteserrors.NewInvalid(schema.GroupKind{Kind: "ConfigMap"}, "immutable", field.ErrorList{
+				field.Forbidden(field.NewPath("data"), "field is immutable when `immutable` is set"),
+			})
+		}
+		live.Object["data"] = applied.Object["data"]
+		return true, live, nil
+	})
+
+	yamlDoc := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unchanged
+data:
+  key: value
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: updated
+data:
+  key: new
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: immutable
+data:
+  key: new
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: created
+  namespace: other-ns
+---
+apiVersion: example.com/v1
+kind: Custom
+metadata:
+  name: from-new-crd`
+
+	s := NewServerSide(dynamicClient, testRESTMapper())
+	got, err := s.PlanApply("app-ns", "my-app", []byte(yamlDoc), false)
+	req.NoError(err)
+
+	req.Len(got, 5)
+	req.Equal(operatortypes.PlannedResource{Version: "v1", Kind: "ConfigMap", Resource: "configmaps", Namespace: "app-ns", Name: "unchanged", Action: operatortypes.PlanUnchanged}, got[0])
+	req.Equal(operatortypes.PlannedResource{
+		Version:   "v1",
+		Kind:      "ConfigMap",
+		Resource:  "configmaps",
+		Namespace: "app-ns",
+		Name:      "updated",
+		Action:    operatortypes.PlanUpdate,
+		Diff: `--- live
++++ expected
+@@ -1,6 +1,6 @@
+ apiVersion: v1
+ data:
+-  key: old
++  key: new
+ kind: ConfigMap
+ metadata:
+   name: updated
+`,
+	}, got[1])
+	req.Equal(operatortypes.PlanRecreate, got[2].Action)
+	req.Contains(got[2].Error, "field is immutable")
+	req.Equal(operatortypes.PlannedResource{Version: "v1", Kind: "ConfigMap", Resource: "configmaps", Namespace: "other-ns", Name: "created", Action: operatortypes.PlanCreate}, got[3])
+	req.Equal(operatortypes.PlannedResource{Group: "example.com", Version: "v1", Kind: "Custom", Resource: "custom", Name: "from-new-crd", Action: operatortypes.PlanCreate}, got[4])
+}
+
+func TestServerSide_PlanClientSideApply(t *testing.T) {
+	req := require.New(t)
+
+	updated := testConfigMap("app-ns", "updated")
+	updated.Object["data"] = map[string]interface{}{"key": "old"}
+
+	immutable := testConfigMap("app-ns", "immutable")
+	immutable.Object["immutable"] = true
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), updated, immutable)
+	dynamicClient.PrependReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		req.Fail("resources must not be planned with server-side apply")
+		return true, nil, nil
+	})
+
+	// the dry run returns the applied document, or the error that kubectl reports for invalid changes
+	kubectlPath := filepath.Join(t.TempDir(), "kubectl")
+	req.NoError(os.WriteFile(kubectlPath, []byte(`#!/bin/sh
+if [ "$1 $2 $3 $4 $5" != "apply --dry-run=server -o json -f" ]; then
+  echo "unexpected arguments: $@" >&2
+  exit 1
+fi
+if grep -q '"name":"immutable"' "$6"; then
+  echo 'The ConfigMap "immutable" is invalid: data: Forbidden: field is immutable when `+"`immutable`"+` is set' >&2
+  exit 1
+fi
+cat "$6"
+`), 0755))
+	kubectl := NewKubectl(kubectlPath, "", &rest.Config{})
+
+	yamlDoc := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: updated
+data:
+  key: new
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: immutable
+data:
+  key: new`
+
+	s := NewServerSide(dynamicClient, testRESTMapper())
+	got, err := s.PlanClientSideApply(kubectl, "app-ns", "my-app", []byte(yamlDoc), false)
+	req.NoError(err)
+
+	req.Len(got, 2)
+	req.Equal(operatortypes.PlanUpdate, got[0].Action)
+	req.Contains(got[0].Diff, "-  key: old\n+  key: new\n")
+	req.Equal(operatortypes.PlanRecreate, got[1].Action)
+	req.Contains(got[1].Error, "field is immutable")
+}
+
+func TestServerSide_PlanRemove(t *testing.T) {
+	req := require.New(t)
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), testConfigMap("app-ns", "exists"))
+
+	yamlDoc := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: exists
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: already-deleted
+---
+apiVersion: example.com/v1
+kind: Custom
+metadata:
+  name: crd-deleted`
+
+	s := NewServerSide(dynamicClient, testRESTMapper())
+	got, err := s.PlanRemove("app-ns", []byte(yamlDoc))
+	req.NoError(err)
+	req.Equal([]operatortypes.PlannedResource{
+		{Version: "v1", Kind: "ConfigMap", Resource: "configmaps", Namespace: "app-ns", Name: "exists", Action: operatortypes.PlanDelete},
+	}, got)
+}
@@ -0,0 +1,481 @@
+package applier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+	kuberneteserrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/util/csaupgrade"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	lastAppliedAnnotationFieldPath = fieldpath.NewSet(fieldpath.MakePathOrDie("metadata", "annotations", corev1.LastAppliedConfigAnnotation))
+	crdGroupKind                   = schema.GroupKind{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}
+)
+
+const (
+	// FieldManager is the field manager that owns the fields applied by kots when using server-side apply
+	FieldManager = "kots"
+
+	ResultCreated    = "created"
+	ResultConfigured = "configured"
+	ResultUnchanged  = "unchanged"
+	ResultDeleted    = "deleted"
+	ResultNotFound   = "not found"
+	ResultConflict   = "conflict"
+	ResultFailed     = "failed"
+)
+
+// ResourceResult is the outcome of applying or removing a single resource
+type ResourceResult struct {
+	Group     string `json:"group"`
+	Version   string `json:"version"`
+	Kind      string `json:"kind"`
+	Resource  string `json:"resource"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Result    string `json:"result"`
+	DryRun    bool   `json:"dryRun"`
+	Error     string `json:"error,omitempty"`
+}
+
+// String formats the result the same way kubectl reports it, e.g. "deployment.apps/example configured"
+func (r ResourceResult) String() string {
+	resource := r.Resource
+	if r.Group != "" {
+		resource = fmt.Sprintf("%s.%s", r.Resource, r.Group)
+	}
+	s := fmt.Sprintf("%s/%s %s", resource, r.Name, r.Result)
+	if r.DryRun {
+		s += " (server dry run)"
+	}
+	return s
+}
+
+// ServerSide is a KubectlInterface implementation that uses the dynamic client and
+// server-side apply instead of shelling out to kubectl
+type ServerSide struct {
+	dynamicClient dynamic.Interface
+	mapper        meta.RESTMapper
+	pollInterval  time.Duration
+	pollTimeout   time.Duration
+}
+
+func NewServerSide(dynamicClient dynamic.Interface, mapper meta.RESTMapper) *ServerSide {
+	return &ServerSide{
+		dynamicClient: dynamicClient,
+		mapper:        mapper,
+		pollInterval:  time.Second,
+		pollTimeout:   10 * time.Minute,
+	}
+}
+
+func NewServerSideForConfig(config *rest.Config) (*ServerSide, error) {
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create dynamic client")
+	}
+
+	disc, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create discovery client")
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(disc))
+
+	return NewServerSide(dynamicClient, mapper), nil
+}
+
+func (s *ServerSide) Apply(targetNamespace string, slug string, yamlDoc []byte, dryRun bool, wait bool, annotateSlug bool) ([]byte, []byte, error) {
+	results, err := s.ApplyResources(targetNamespace, slug, yamlDoc, dryRun, wait, annotateSlug)
+	stdout, stderr := formatResults(results)
+	return stdout, stderr, err
+}
+
+// ApplyCreateOrPatch is the same as Apply. Server-side apply does not store the
+// last-applied-configuration annotation, so the "metadata.annotations: Too long" fallbacks
+// that the kubectl implementation needs are not necessary.
+func (s *ServerSide) ApplyCreateOrPatch(targetNamespace string, slug string, yamlDoc []byte, dryRun bool, wait bool, annotateSlug bool) ([]byte, []byte, error) {
+	return s.Apply(targetNamespace, slug, yamlDoc, dryRun, wait, annotateSlug)
+}
+
+func (s *ServerSide) Remove(targetNamespace string, yamlDoc []byte, wait bool) ([]byte, []byte, error) {
+	results, err := s.RemoveResources(targetNamespace, yamlDoc, wait)
+	stdout, stderr := formatResults(results)
+	return stdout, stderr, err
+}
+
+// ApplyResources server-side applies every document in yamlDoc and returns a result per resource.
+// Processing stops at the first failure. Field ownership conflicts with other managers are not
+// forced and are reported with the ResultConflict result. Fields that were previously applied
+// with kubectl client-side apply are taken over by kots. When wait is true, it waits for the
+// applied resources to be observed by their controllers, see waitForResource.
+func (s *ServerSide) ApplyResources(targetNamespace string, slug string, yamlDoc []byte, dryRun bool, wait bool, annotateSlug bool) ([]ResourceResult, error) {
+	objs, err := decodeDocs(yamlDoc)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode documents")
+	}
+
+	results := []ResourceResult{}
+	for _, obj := range objs {
+		if annotateSlug {
+			annotations := obj.GetAnnotations()
+			if annotations == nil {
+				annotations = map[string]string{}
+			}
+			annotations["kots.io/app-slug"] = slug
+			obj.SetAnnotations(annotations)
+		}
+
+		result, err := s.applyResource(targetNamespace, obj, dryRun)
+		results = append(results, result)
+		if err != nil {
+			return results, errors.Wrapf(err, "failed to apply %s", result.String())
+		}
+	}
+
+	if !wait || dryRun {
+		return results, nil
+	}
+
+	for i, obj := range objs {
+		if err := s.waitForResource(targetNamespace, obj); err != nil {
+			results[i].Result = ResultFailed
+			results[i].Error = err.Error()
+			return results, errors.Wrapf(err, "failed to wait for %s", results[i].String())
+		}
+	}
+
+	return results, nil
+}
+
+// RemoveResources deletes every document in yamlDoc and returns a result per resource.
+// Resources that do not exist are not considered an error.
+func (s *ServerSide) RemoveResources(targetNamespace string, yamlDoc []byte, wait bool) ([]ResourceResult, error) {
+	objs, err := decodeDocs(yamlDoc)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode documents")
+	}
+
+	results := []ResourceResult{}
+	for _, obj := range objs {
+		result, err := s.removeResource(targetNamespace, obj, wait)
+		results = append(results, result)
+		if err != nil {
+			return results, errors.Wrapf(err, "failed to delete %s", result.String())
+		}
+	}
+
+	return results, nil
+}
+
+func (s *ServerSide) applyResource(targetNamespace string, obj *unstructured.Unstructured, dryRun bool) (ResourceResult, error) {
+	gvk := obj.GroupVersionKind()
+	result := ResourceResult{
+		Group:   gvk.Group,
+		Version: gvk.Version,
+		Kind:    gvk.Kind,
+		Name:    obj.GetName(),
+		DryRun:  dryRun,
+	}
+
+	dr, mapping, err := s.resourceInterface(gvk, obj.GetNamespace(), targetNamespace)
+	if err != nil {
+		result.Resource = strings.ToLower(gvk.Kind)
+		result.Result = ResultFailed
+		result.Error = err.Error()
+		return result, errors.Wrap(err, "failed to get resource interface")
+	}
+	result.Resource = mapping.Resource.Resource
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		result.Namespace = resourceNamespace(obj.GetNamespace(), targetNamespace)
+		obj.SetNamespace(result.Namespace)
+	}
+
+	existing, err := dr.Get(context.TODO(), obj.GetName(), metav1.GetOptions{})
+	if err != nil && !kuberneteserrors.IsNotFound(err) {
+		result.Result = ResultFailed
+		result.Error = err.Error()
+		return result, errors.Wrap(err, "failed to get existing resource")
+	}
+	if kuberneteserrors.IsNotFound(err) {
+		existing = nil
+	}
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		result.Result = ResultFailed
+		result.Error = err.Error()
+		return result, errors.Wrap(err, "failed to marshal resource")
+	}
+
+	force := false
+	if existing != nil {
+		// resources deployed before server-side apply was enabled are owned by the kubectl client-side apply field manager
+		migrated, err := s.migrateClientSideApply(dr, existing, dryRun)
+		if err != nil {
+			result.Result = ResultFailed
+			result.Error = err.Error()
+			return result, errors.Wrap(err, "failed to migrate client-side apply managed fields")
+		}
+		// a dry run cannot persist the migration, so it forces the apply instead to report what the apply will do
+		force = migrated && dryRun
+	}
+
+	patchOptions := metav1.PatchOptions{
+		FieldManager: FieldManager,
+		Force:        pointer.Bool(force),
+	}
+	if dryRun {
+		patchOptions.DryRun = []string{metav1.DryRunAll}
+	}
+
+	applied, err := dr.Patch(context.TODO(), obj.GetName(), types.ApplyPatchType, data, patchOptions)
+	if err != nil {
+		result.Error = err.Error()
+		if kuberneteserrors.IsConflict(err) {
+			result.Result = ResultConflict
+			return result, errors.Wrap(err, "field manager conflict")
+		}
+		result.Result = ResultFailed
+		return result, errors.Wrap(err, "failed to server-side apply")
+	}
+
+	switch {
+	case existing == nil:
+		result.Result = ResultCreated
+	case existing.GetResourceVersion() == applied.GetResourceVersion() && !dryRun:
+		result.Result = ResultUnchanged
+	default:
+		result.Result = ResultConfigured
+	}
+
+	return result, nil
+}
+
+// migrateClientSideApply transfers the ownership of fields that were applied with kubectl client-side apply to
+// the kots field manager the same way "kubectl apply --server-side" does, so that they do not conflict and so that
+// fields removed from the manifest are removed from the resource. It returns whether there were fields to migrate.
+// When dryRun is true, the migration is only computed.
+func (s *ServerSide) migrateClientSideApply(dr dynamic.ResourceInterface, existing *unstructured.Unstructured, dryRun bool) (bool, error) {
+	// the client-side apply managers are the managers of update operations that own the last-applied-configuration annotation
+	csaManagers := csaupgrade.FindFieldsOwners(existing.GetManagedFields(), metav1.ManagedFieldsOperationUpdate, lastAppliedAnnotationFieldPath)
+	managerNames := sets.New[string]()
+	for _, entry := range csaManagers {
+		managerNames.Insert(entry.Manager)
+	}
+	if managerNames.Len() == 0 {
+		return false, nil
+	}
+
+	patch, err := csaupgrade.UpgradeManagedFieldsPatch(existing, managerNames, FieldManager)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to create managed fields patch")
+	}
+	if patch == nil {
+		return false, nil
+	}
+	if dryRun {
+		return true, nil
+	}
+
+	if _, err := dr.Patch(context.TODO(), existing.GetName(), types.JSONPatchType, patch, metav1.PatchOptions{}); err != nil {
+		return false, errors.Wrap(err, "failed to patch managed fields")
+	}
+	return true, nil
+}
+
+// waitForResource waits until the controller of the resource has observed its latest generation, and
+// until custom resource definitions are established so that custom resources can be applied after them.
+func (s *ServerSide) waitForResource(targetNamespace string, obj *unstructured.Unstructured) error {
+	dr, _, err := s.resourceInterface(obj.GroupVersionKind(), obj.GetNamespace(), targetNamespace)
+	if err != nil {
+		return errors.Wrap(err, "failed to get resource interface")
+	}
+
+	return wait.PollUntilContextTimeout(context.TODO(), s.pollInterval, s.pollTimeout, true, func(ctx context.Context) (bool, error) {
+		current, err := dr.Get(ctx, obj.GetName(), metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return isResourceObserved(current), nil
+	})
+}
+
+func isResourceObserved(obj *unstructured.Unstructured) bool {
+	observedGeneration, found, err := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	if err == nil && found && observedGeneration < obj.GetGeneration() {
+		return false
+	}
+
+	if obj.GroupVersionKind().GroupKind() == crdGroupKind {
+		conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+		for _, c := range conditions {
+			condition, ok := c.(map[string]interface{})
+			if ok && condition["type"] == "Established" && condition["status"] == "True" {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+func (s *ServerSide) removeResource(targetNamespace string, obj *unstructured.Unstructured, wait bool) (ResourceResult, error) {
+	gvk := obj.GroupVersionKind()
+	result := ResourceResult{
+		Group:   gvk.Group,
+		Version: gvk.Version,
+		Kind:    gvk.Kind,
+		Name:    obj.GetName(),
+	}
+
+	dr, mapping, err := s.resourceInterface(gvk, obj.GetNamespace(), targetNamespace)
+	if err != nil {
+		result.Resource = strings.ToLower(gvk.Kind)
+		if meta.IsNoMatchError(err) {
+			// the kind no longer exists in the cluster, so neither does the resource
+			result.Result = ResultNotFound
+			return result, nil
+		}
+		result.Result = ResultFailed
+		result.Error = err.Error()
+		return result, errors.Wrap(err, "failed to get resource interface")
+	}
+	result.Resource = mapping.Resource.Resource
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		result.Namespace = resourceNamespace(obj.GetNamespace(), targetNamespace)
+	}
+
+	propagation := metav1.DeletePropagationBackground
+	if wait {
+		propagation = metav1.DeletePropagationForeground
+	}
+	err = dr.Delete(context.TODO(), obj.GetName(), metav1.DeleteOptions{PropagationPolicy: &propagation})
+	if kuberneteserrors.IsNotFound(err) {
+		result.Result = ResultNotFound
+		return result, nil
+	} else if err != nil {
+		result.Result = ResultFailed
+		result.Error = err.Error()
+		return result, errors.Wrap(err, "failed to delete resource")
+	}
+
+	if wait {
+		err := s.waitForDeletion(dr, obj.GetName())
+		if err != nil {
+			result.Result = ResultFailed
+			result.Error = err.Error()
+			return result, errors.Wrap(err, "failed to wait for deletion")
+		}
+	}
+
+	result.Result = ResultDeleted
+	return result, nil
+}
+
+func (s *ServerSide) waitForDeletion(dr dynamic.ResourceInterface, name string) error {
+	return wait.PollUntilContextTimeout(context.TODO(), s.pollInterval, s.pollTimeout, true, func(ctx context.Context) (bool, error) {
+		_, err := dr.Get(ctx, name, metav1.GetOptions{})
+		if kuberneteserrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	})
+}
+
+// resourceInterface returns the dynamic resource interface for the gvk. If the kind is unknown,
+// the rest mapper cache is reset and the lookup is retried once since the kind may be provided by a
+// CRD that was created in an earlier phase of the same deployment.
+func (s *ServerSide) resourceInterface(gvk schema.GroupVersionKind, namespace string, targetNamespace string) (dynamic.ResourceInterface, *meta.RESTMapping, error) {
+	mapping, err := s.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if resettable, ok := s.mapper.(meta.ResettableRESTMapper); ok && meta.IsNoMatchError(err) {
+		resettable.Reset()
+		mapping, err = s.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	}
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to get rest mapping for %s", gvk.String())
+	}
+
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		return s.dynamicClient.Resource(mapping.Resource).Namespace(resourceNamespace(namespace, targetNamespace)), mapping, nil
+	}
+	return s.dynamicClient.Resource(mapping.Resource), mapping, nil
+}
+
+func resourceNamespace(namespace string, targetNamespace string) string {
+	if namespace != "" {
+		return namespace
+	}
+	if targetNamespace != "" {
+		return targetNamespace
+	}
+	return metav1.NamespaceDefault
+}
+
+func decodeDocs(yamlDoc []byte) ([]*unstructured.Unstructured, error) {
+	objs := []*unstructured.Unstructured{}
+	for _, doc := range util.ConvertToSingleDocs(yamlDoc) {
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		obj := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal(doc, &obj.Object); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal yaml")
+		}
+		if obj.Object == nil {
+			continue
+		}
+		if obj.GetKind() == "" || obj.GetAPIVersion() == "" {
+			return nil, errors.New("document is missing apiVersion or kind")
+		}
+		if obj.GetName() == "" {
+			return nil, errors.Errorf("%s document is missing metadata.name", obj.GetKind())
+		}
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
+
+func formatResults(results []ResourceResult) ([]byte, []byte) {
+	var stdout, stderr []string
+	for _, result := range results {
+		if result.Error != "" {
+			stderr = append(stderr, fmt.Sprintf("%s: %s", result.String(), result.Error))
+			continue
+		}
+		stdout = append(stdout, result.String())
+	}
+
+	var stdoutBytes, stderrBytes []byte
+	if len(stdout) > 0 {
+		stdoutBytes = []byte(strings.Join(stdout, "\n") + "\n")
+	}
+	if len(stderr) > 0 {
+		stderrBytes = []byte(strings.Join(stderr, "\n") + "\n")
+	}
+	return stdoutBytes, stderrBytes
+}
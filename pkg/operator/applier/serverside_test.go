@@ -0,0 +1,419 @@
+package applier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	kuberneteserrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var (
+	configMapGVR = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	configMapGVK = schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+	namespaceGVK = schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}
+	crdGVK       = schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"}
+)
+
+func testRESTMapper() meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(configMapGVK, meta.RESTScopeNamespace)
+	mapper.Add(namespaceGVK, meta.RESTScopeRoot)
+	mapper.Add(crdGVK, meta.RESTScopeRoot)
+	return mapper
+}
+
+func testConfigMap(namespace string, name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(configMapGVK)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.SetResourceVersion("1")
+	return obj
+}
+
+func TestServerSide_ApplyResources(t *testing.T) {
+	tests := []struct {
+		name            string
+		targetNamespace string
+		yamlDoc         string
+		dryRun          bool
+		annotateSlug    bool
+		existing        []runtime.Object
+		conflict        bool
+		want            []ResourceResult
+		wantErr         bool
+		wantPatch       map[string]interface{}
+	}{
+		{
+			name:            "creates a resource in the target namespace",
+			targetNamespace: "app-ns",
+			yamlDoc: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: example
+data:
+  key: value`,
+			want: []ResourceResult{
+				{Version: "v1", Kind: "ConfigMap", Resource: "configmaps", Namespace: "app-ns", Name: "example", Result: ResultCreated},
+			},
+			wantPatch: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata": map[string]interface{}{
+					"name":      "example",
+					"namespace": "app-ns",
+				},
+				"data": map[string]interface{}{
+					"key": "value",
+				},
+			},
+		},
+		{
+			name:            "configures an existing resource and annotates the slug",
+			targetNamespace: "app-ns",
+			yamlDoc: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: example
+  namespace: other-ns`,
+			annotateSlug: true,
+			existing:     []runtime.Object{testConfigMap("other-ns", "example")},
+			want: []ResourceResult{
+				{Version: "v1", Kind: "ConfigMap", Resource: "configmaps", Namespace: "other-ns", Name: "example", Result: ResultConfigured},
+			},
+			wantPatch: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata": map[string]interface{}{
+					"name":      "example",
+					"namespace": "other-ns",
+					"annotations": map[string]interface{}{
+						"kots.io/app-slug": "my-app",
+					},
+				},
+			},
+		},
+		{
+			name:            "does not set a namespace on cluster scoped resources",
+			targetNamespace: "app-ns",
+			yamlDoc: `apiVersion: v1
+kind: Namespace
+metadata:
+  name: example`,
+			dryRun: true,
+			want: []ResourceResult{
+				{Version: "v1", Kind: "Namespace", Resource: "namespaces", Name: "example", Result: ResultCreated, DryRun: true},
+			},
+			wantPatch: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Namespace",
+				"metadata": map[string]interface{}{
+					"name": "example",
+				},
+			},
+		},
+		{
+			name:            "reports field manager conflicts",
+			targetNamespace: "app-ns",
+			yamlDoc: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: example`,
+			existing: []runtime.Object{testConfigMap("app-ns", "example")},
+			conflict: true,
+			want: []ResourceResult{
+				{Version: "v1", Kind: "ConfigMap", Resource: "configmaps", Namespace: "app-ns", Name: "example", Result: ResultConflict, Error: `Operation cannot be fulfilled on configmaps "example": conflict with "kubectl-edit"`},
+			},
+			wantErr: true,
+		},
+		{
+			name:            "fails on unknown kinds",
+			targetNamespace: "app-ns",
+			yamlDoc: `apiVersion: example.com/v1
+kind: Unknown
+metadata:
+  name: example`,
+			want: []ResourceResult{
+				{Group: "example.com", Version: "v1", Kind: "Unknown", Resource: "unknown", Name: "example", Result: ResultFailed, Error: `failed to get rest mapping for example.com/v1, Kind=Unknown: no matches for kind "Unknown" in version "example.com/v1"`},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := require.New(t)
+
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), tt.existing...)
+
+			var gotPatch map[string]interface{}
+			dynamicClient.PrependReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				patchAction := action.(k8stesting.PatchAction)
+				req.Equal(types.ApplyPatchType, patchAction.GetPatchType())
+				req.NoError(json.Unmarshal(patchAction.GetPatch(), &gotPatch))
+
+				if tt.conflict {
+					return true, nil, kuberneteserrors.NewConflict(configMapGVR.GroupResource(), patchAction.GetName(), errors.New("conflict with \"kubectl-edit\""))
+				}
+
+				obj := &unstructured.Unstructured{}
+				req.NoError(obj.UnmarshalJSON(patchAction.GetPatch()))
+				obj.SetResourceVersion("2")
+				return true, obj, nil
+			})
+
+			s := NewServerSide(dynamicClient, testRESTMapper())
+			got, err := s.ApplyResources(tt.targetNamespace, "my-app", []byte(tt.yamlDoc), tt.dryRun, false, tt.annotateSlug)
+			if tt.wantErr {
+				req.Error(err)
+			} else {
+				req.NoError(err)
+				req.Equal(tt.wantPatch, gotPatch)
+			}
+			req.Equal(tt.want, got)
+		})
+	}
+}
+
+func TestServerSide_ApplyResourcesMigratesClientSideApply(t *testing.T) {
+	tests := []struct {
+		name          string
+		dryRun        bool
+		wantMigration bool
+	}{
+		{
+			name:          "migrates the client-side apply managed fields",
+			wantMigration: true,
+		},
+		{
+			name:   "does not migrate in a dry run",
+			dryRun: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := require.New(t)
+
+			// deployed with kubectl apply before server-side apply was enabled
+			existing := testConfigMap("app-ns", "example")
+			existing.SetAnnotations(map[string]string{
+				"kubectl.kubernetes.io/last-applied-configuration": `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"example"},"data":{"key":"value"}}`,
+			})
+			existing.SetManagedFields([]metav1.ManagedFieldsEntry{
+				{
+					Manager:    "kubectl-client-side-apply",
+					Operation:  metav1.ManagedFieldsOperationUpdate,
+					APIVersion: "v1",
+					FieldsType: "FieldsV1",
+					FieldsV1: &metav1.FieldsV1{
+						Raw: []byte(`{"f:data":{".":{},"f:key":{}},"f:metadata":{"f:annotations":{".":{},"f:kubectl.kubernetes.io/last-applied-configuration":{}}}}`),
+					},
+				},
+			})
+
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), existing)
+
+			patchTypes := []types.PatchType{}
+			dynamicClient.PrependReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				patchAction := action.(k8stesting.PatchAction)
+				patchTypes = append(patchTypes, patchAction.GetPatchType())
+				if patchAction.GetPatchType() != types.ApplyPatchType {
+					// the managed fields json patch is handled by the object tracker
+					return false, nil, nil
+				}
+
+				obj := &unstructured.Unstructured{}
+				req.NoError(obj.UnmarshalJSON(patchAction.GetPatch()))
+				obj.SetResourceVersion("2")
+				return true, obj, nil
+			})
+
+			s := NewServerSide(dynamicClient, testRESTMapper())
+			got, err := s.ApplyResources("app-ns", "my-app", []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: example
+data:
+  key: value`), tt.dryRun, false, false)
+			req.NoError(err)
+			req.Equal([]ResourceResult{
+				{Version: "v1", Kind: "ConfigMap", Resource: "configmaps", Namespace: "app-ns", Name: "example", Result: ResultConfigured, DryRun: tt.dryRun},
+			}, got)
+
+			migrated, err := dynamicClient.Resource(configMapGVR).Namespace("app-ns").Get(context.TODO(), "example", metav1.GetOptions{})
+			req.NoError(err)
+			managers := []string{}
+			for _, entry := range migrated.GetManagedFields() {
+				managers = append(managers, fmt.Sprintf("%s/%s", entry.Manager, entry.Operation))
+			}
+			if tt.wantMigration {
+				req.Equal([]types.PatchType{types.JSONPatchType, types.ApplyPatchType}, patchTypes)
+				req.Equal([]string{"kots/Apply"}, managers)
+			} else {
+				req.Equal([]types.PatchType{types.ApplyPatchType}, patchTypes)
+				req.Equal([]string{"kubectl-client-side-apply/Update"}, managers)
+			}
+		})
+	}
+}
+
+func TestServerSide_ApplyResourcesWait(t *testing.T) {
+	crd := func(established bool) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(crdGVK)
+		obj.SetName("examples.example.com")
+		obj.SetResourceVersion("1")
+		if established {
+			obj.Object["status"] = map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Established", "status": "True"},
+				},
+			}
+		}
+		return obj
+	}
+
+	tests := []struct {
+		name     string
+		existing *unstructured.Unstructured
+		want     ResourceResult
+		wantErr  bool
+	}{
+		{
+			name:     "waits for the crd to be established",
+			existing: crd(true),
+			want:     ResourceResult{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition", Resource: "customresourcedefinitions", Name: "examples.example.com", Result: ResultConfigured},
+		},
+		{
+			name:     "fails if the crd is not established",
+			existing: crd(false),
+			want:     ResourceResult{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition", Resource: "customresourcedefinitions", Name: "examples.example.com", Result: ResultFailed, Error: "context deadline exceeded"},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := require.New(t)
+
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), tt.existing)
+			dynamicClient.PrependReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				patchAction := action.(k8stesting.PatchAction)
+				obj := &unstructured.Unstructured{}
+				req.NoError(obj.UnmarshalJSON(patchAction.GetPatch()))
+				obj.SetResourceVersion("2")
+				return true, obj, nil
+			})
+
+			s := NewServerSide(dynamicClient, testRESTMapper())
+			s.pollInterval = time.Millisecond
+			s.pollTimeout = 50 * time.Millisecond
+
+			got, err := s.ApplyResources("app-ns", "my-app", []byte(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: examples.example.com`), false, true, false)
+			if tt.wantErr {
+				req.Error(err)
+			} else {
+				req.NoError(err)
+			}
+			req.Equal([]ResourceResult{tt.want}, got)
+		})
+	}
+}
+
+func Test_isResourceObserved(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  map[string]interface{}
+		want bool
+	}{
+		{
+			name: "no status",
+			obj: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+			},
+			want: true,
+		},
+		{
+			name: "latest generation observed",
+			obj: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata":   map[string]interface{}{"generation": int64(2)},
+				"status":     map[string]interface{}{"observedGeneration": int64(2)},
+			},
+			want: true,
+		},
+		{
+			name: "latest generation not observed",
+			obj: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata":   map[string]interface{}{"generation": int64(3)},
+				"status":     map[string]interface{}{"observedGeneration": int64(2)},
+			},
+			want: false,
+		},
+		{
+			name: "crd not established",
+			obj: map[string]interface{}{
+				"apiVersion": "apiextensions.k8s.io/v1",
+				"kind":       "CustomResourceDefinition",
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, isResourceObserved(&unstructured.Unstructured{Object: tt.obj}))
+		})
+	}
+}
+
+func TestServerSide_RemoveResources(t *testing.T) {
+	req := require.New(t)
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), testConfigMap("app-ns", "existing"))
+
+	s := NewServerSide(dynamicClient, testRESTMapper())
+	s.pollInterval = time.Millisecond
+	s.pollTimeout = time.Second
+
+	yamlDoc := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: existing
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: missing`
+
+	got, err := s.RemoveResources("app-ns", []byte(yamlDoc), true)
+	req.NoError(err)
+	req.Equal([]ResourceResult{
+		{Version: "v1", Kind: "ConfigMap", Resource: "configmaps", Namespace: "app-ns", Name: "existing", Result: ResultDeleted},
+		{Version: "v1", Kind: "ConfigMap", Resource: "configmaps", Namespace: "app-ns", Name: "missing", Result: ResultNotFound},
+	}, got)
+
+	_, err = dynamicClient.Resource(configMapGVR).Namespace("app-ns").Get(context.TODO(), "existing", metav1.GetOptions{})
+	req.True(kuberneteserrors.IsNotFound(err))
+
+	stdout, stderr := formatResults(got)
+	req.Equal("configmaps/existing deleted\nconfigmaps/missing not found\n", string(stdout))
+	req.Empty(stderr)
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
@@ -23,6 +24,9 @@ import (
 	"github.com/replicatedhq/kots/pkg/binaries"
 	"github.com/replicatedhq/kots/pkg/k8sutil"
 	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/metrics"
+	"github.com/replicatedhq/kots/pkg/notifications"
+	notificationtypes "github.com/replicatedhq/kots/pkg/notifications/types"
 	"github.com/replicatedhq/kots/pkg/operator/applier"
 	operatortypes "github.com/replicatedhq/kots/pkg/operator/types"
 	"github.com/replicatedhq/kots/pkg/registry"
@@ -33,6 +37,7 @@ import (
 	"github.com/replicatedhq/kots/pkg/util"
 	"github.com/replicatedhq/kotskinds/pkg/helmchart"
 	"go.uber.org/zap"
+	"k8s.io/client-go/rest"
 )
 
 type DeployResults struct {
@@ -77,7 +82,12 @@ func (c *Client) Init() error {
 		return errors.Wrap(err, "failed to get k8s clientset")
 	}
 
-	c.appStateMonitor = appstate.NewMonitor(clientset, c.TargetNamespace)
+	dynamicClient, err := k8sutil.GetDynamicClient()
+	if err != nil {
+		return errors.Wrap(err, "failed to get k8s dynamic client")
+	}
+
+	c.appStateMonitor = appstate.NewMonitor(clientset, dynamicClient, c.TargetNamespace)
 	go c.runAppStateMonitor()
 
 	return nil
@@ -240,6 +250,7 @@ func (c *Client) deployManifests(deployArgs operatortypes.DeployAppArgs) (*deplo
 			RestoreLabelSelector: deployArgs.RestoreLabelSelector,
 			KubectlVersion:       deployArgs.KubectlVersion,
 			KustomizeVersion:     deployArgs.KustomizeVersion,
+			ServerSideApply:      deployArgs.ServerSideApply,
 			Wait:                 deployArgs.Wait,
 		}
 		if err := c.diffAndDeleteManifests(opts); err != nil {
@@ -256,121 +267,167 @@ func (c *Client) deployManifests(deployArgs operatortypes.DeployAppArgs) (*deplo
 }
 
 func (c *Client) deployHelmCharts(deployArgs operatortypes.DeployAppArgs) (*commandResult, error) {
+	charts, err := extractHelmChartsToDeploy(deployArgs)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to extract helm charts")
+	}
+	defer charts.cleanup()
+
+	// find removed charts
+	removedCharts, err := charts.removedCharts()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find removed charts")
+	}
+
+	// uninstall removed charts
+	if len(removedCharts) > 0 {
+		v1Beta1ChartsDir, v1Beta2ChartsDir := charts.previousChartsDirs()
+		err := c.uninstallWithHelm(v1Beta1ChartsDir, v1Beta2ChartsDir, removedCharts)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to uninstall helm charts")
+		}
+	}
+
+	var installResult *commandResult
+	// deploy current helm charts
+	if len(deployArgs.V1Beta1ChartsArchive) > 0 || len(deployArgs.V1Beta2ChartsArchive) > 0 {
+		v1Beta1ChartsDir, v1Beta2ChartsDir := charts.currentChartsDirs()
+		installResult, err = c.installWithHelm(v1Beta1ChartsDir, v1Beta2ChartsDir, charts.currentKotsCharts())
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to install helm charts")
+		}
+	}
+
+	return installResult, nil
+}
+
+// helmChartsToDeploy are the extracted helm charts of the version being deployed and of the previously deployed version
+type helmChartsToDeploy struct {
+	prevV1Beta1HelmDir    string
+	curV1Beta1HelmDir     string
+	prevV1Beta2HelmDir    string
+	curV1Beta2HelmDir     string
+	prevKotsV1Beta1Charts []helmchart.HelmChartInterface
+	curKotsV1Beta1Charts  []helmchart.HelmChartInterface
+	prevKotsV1Beta2Charts []helmchart.HelmChartInterface
+	curKotsV1Beta2Charts  []helmchart.HelmChartInterface
+}
+
+func extractHelmChartsToDeploy(deployArgs operatortypes.DeployAppArgs) (_ *helmChartsToDeploy, finalErr error) {
+	charts := &helmChartsToDeploy{}
+	defer func() {
+		if finalErr != nil {
+			charts.cleanup()
+		}
+	}()
+
+	var err error
+
 	// extract previous v1beta1 helm charts
-	prevV1Beta1HelmDir, err := extractHelmCharts(deployArgs.PreviousV1Beta1ChartsArchive, "prev-v1beta1")
+	charts.prevV1Beta1HelmDir, err = extractHelmCharts(deployArgs.PreviousV1Beta1ChartsArchive, "prev-v1beta1")
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to extract previous helm charts")
 	}
-	defer os.RemoveAll(prevV1Beta1HelmDir)
 
 	// extract current v1beta1 helm charts
-	curV1Beta1HelmDir, err := extractHelmCharts(deployArgs.V1Beta1ChartsArchive, "curr-v1beta1")
+	charts.curV1Beta1HelmDir, err = extractHelmCharts(deployArgs.V1Beta1ChartsArchive, "curr-v1beta1")
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to extract current helm charts")
 	}
-	defer os.RemoveAll(curV1Beta1HelmDir)
 
 	// extract previous v1beta2 helm charts
-	prevV1Beta2HelmDir, err := extractHelmCharts(deployArgs.PreviousV1Beta2ChartsArchive, "prev-v1beta2")
+	charts.prevV1Beta2HelmDir, err = extractHelmCharts(deployArgs.PreviousV1Beta2ChartsArchive, "prev-v1beta2")
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to extract previous helm charts")
 	}
-	defer os.RemoveAll(prevV1Beta2HelmDir)
 
 	// extract current v1beta2 helm charts
-	curV1Beta2HelmDir, err := extractHelmCharts(deployArgs.V1Beta2ChartsArchive, "curr-v1beta2")
+	charts.curV1Beta2HelmDir, err = extractHelmCharts(deployArgs.V1Beta2ChartsArchive, "curr-v1beta2")
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to extract current helm charts")
 	}
-	defer os.RemoveAll(curV1Beta2HelmDir)
 
-	// find removed charts
-	prevKotsV1Beta1Charts := []helmchart.HelmChartInterface{}
+	charts.prevKotsV1Beta1Charts = []helmchart.HelmChartInterface{}
 	if deployArgs.PreviousKotsKinds != nil && deployArgs.PreviousKotsKinds.V1Beta1HelmCharts != nil {
 		for _, kotsChart := range deployArgs.PreviousKotsKinds.V1Beta1HelmCharts.Items {
 			kc := kotsChart
-			prevKotsV1Beta1Charts = append(prevKotsV1Beta1Charts, &kc)
+			charts.prevKotsV1Beta1Charts = append(charts.prevKotsV1Beta1Charts, &kc)
 		}
 	}
 
-	curV1Beta1KotsCharts := []helmchart.HelmChartInterface{}
+	charts.curKotsV1Beta1Charts = []helmchart.HelmChartInterface{}
 	if deployArgs.KotsKinds != nil && deployArgs.KotsKinds.V1Beta1HelmCharts != nil {
 		for _, kotsChart := range deployArgs.KotsKinds.V1Beta1HelmCharts.Items {
 			kc := kotsChart
-			curV1Beta1KotsCharts = append(curV1Beta1KotsCharts, &kc)
+			charts.curKotsV1Beta1Charts = append(charts.curKotsV1Beta1Charts, &kc)
 		}
 	}
 
-	prevKotsV1Beta2Charts := []helmchart.HelmChartInterface{}
+	charts.prevKotsV1Beta2Charts = []helmchart.HelmChartInterface{}
 	if deployArgs.PreviousKotsKinds != nil && deployArgs.PreviousKotsKinds.V1Beta2HelmCharts != nil {
 		for _, kotsChart := range deployArgs.PreviousKotsKinds.V1Beta2HelmCharts.Items {
 			kc := kotsChart
-			prevKotsV1Beta2Charts = append(prevKotsV1Beta2Charts, &kc)
+			charts.prevKotsV1Beta2Charts = append(charts.prevKotsV1Beta2Charts, &kc)
 		}
 	}
 
-	curV1Beta2KotsCharts := []helmchart.HelmChartInterface{}
+	charts.curKotsV1Beta2Charts = []helmchart.HelmChartInterface{}
 	if deployArgs.KotsKinds != nil && deployArgs.KotsKinds.V1Beta2HelmCharts != nil {
 		for _, kotsChart := range deployArgs.KotsKinds.V1Beta2HelmCharts.Items {
 			kc := kotsChart
-			curV1Beta2KotsCharts = append(curV1Beta2KotsCharts, &kc)
+			charts.curKotsV1Beta2Charts = append(charts.curKotsV1Beta2Charts, &kc)
 		}
 	}
 
-	opts := getRemovedChartsOptions{
-		prevV1Beta1Dir:            prevV1Beta1HelmDir,
-		curV1Beta1Dir:             curV1Beta1HelmDir,
-		previousV1Beta1KotsCharts: prevKotsV1Beta1Charts,
-		currentV1Beta1KotsCharts:  curV1Beta1KotsCharts,
-		prevV1Beta2Dir:            prevV1Beta2HelmDir,
-		curV1Beta2Dir:             curV1Beta2HelmDir,
-		previousV1Beta2KotsCharts: prevKotsV1Beta2Charts,
-		currentV1Beta2KotsCharts:  curV1Beta2KotsCharts,
-	}
-	removedCharts, err := getRemovedCharts(opts)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to find removed charts")
-	}
+	return charts, nil
+}
 
-	// uninstall removed charts
-	if len(removedCharts) > 0 {
-		v1Beta1ChartsDir := ""
-		if prevV1Beta1HelmDir != "" {
-			v1Beta1ChartsDir = filepath.Join(prevV1Beta1HelmDir, "charts")
-		}
-		v1Beta2ChartsDir := ""
-		if prevV1Beta2HelmDir != "" {
-			v1Beta2ChartsDir = filepath.Join(prevV1Beta2HelmDir, "helm")
-		}
-
-		err := c.uninstallWithHelm(v1Beta1ChartsDir, v1Beta2ChartsDir, removedCharts)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to uninstall helm charts")
+func (h *helmChartsToDeploy) cleanup() {
+	for _, dir := range []string{h.prevV1Beta1HelmDir, h.curV1Beta1HelmDir, h.prevV1Beta2HelmDir, h.curV1Beta2HelmDir} {
+		if dir != "" {
+			os.RemoveAll(dir)
 		}
 	}
+}
 
-	var installResult *commandResult
-	// deploy current helm charts
-	if len(deployArgs.V1Beta1ChartsArchive) > 0 || len(deployArgs.V1Beta2ChartsArchive) > 0 {
-		kotsCharts := append(curV1Beta1KotsCharts, curV1Beta2KotsCharts...)
+func (h *helmChartsToDeploy) removedCharts() ([]helmchart.HelmChartInterface, error) {
+	opts := getRemovedChartsOptions{
+		prevV1Beta1Dir:            h.prevV1Beta1HelmDir,
+		curV1Beta1Dir:             h.curV1Beta1HelmDir,
+		previousV1Beta1KotsCharts: h.prevKotsV1Beta1Charts,
+		currentV1Beta1KotsCharts:  h.curKotsV1Beta1Charts,
+		prevV1Beta2Dir:            h.prevV1Beta2HelmDir,
+		curV1Beta2Dir:             h.curV1Beta2HelmDir,
+		previousV1Beta2KotsCharts: h.prevKotsV1Beta2Charts,
+		currentV1Beta2KotsCharts:  h.curKotsV1Beta2Charts,
+	}
+	return getRemovedCharts(opts)
+}
 
-		v1Beta1ChartsDir := ""
-		if curV1Beta1HelmDir != "" {
-			v1Beta1ChartsDir = filepath.Join(curV1Beta1HelmDir, "charts")
-		}
+func (h *helmChartsToDeploy) currentKotsCharts() []helmchart.HelmChartInterface {
+	return append(append([]helmchart.HelmChartInterface{}, h.curKotsV1Beta1Charts...), h.curKotsV1Beta2Charts...)
+}
 
-		v1Beta2ChartsDir := ""
-		if curV1Beta2HelmDir != "" {
-			v1Beta2ChartsDir = filepath.Join(curV1Beta2HelmDir, "helm")
-		}
+// previousChartsDirs returns the v1beta1 and v1beta2 chart dirs of the previously deployed version
+func (h *helmChartsToDeploy) previousChartsDirs() (string, string) {
+	return chartsDirs(h.prevV1Beta1HelmDir, h.prevV1Beta2HelmDir)
+}
 
-		installResult, err = c.installWithHelm(v1Beta1ChartsDir, v1Beta2ChartsDir, kotsCharts)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to install helm charts")
-		}
-	}
+// currentChartsDirs returns the v1beta1 and v1beta2 chart dirs of the version being deployed
+func (h *helmChartsToDeploy) currentChartsDirs() (string, string) {
+	return chartsDirs(h.curV1Beta1HelmDir, h.curV1Beta2HelmDir)
+}
 
-	return installResult, nil
+func chartsDirs(v1Beta1HelmDir string, v1Beta2HelmDir string) (string, string) {
+	v1Beta1ChartsDir := ""
+	if v1Beta1HelmDir != "" {
+		v1Beta1ChartsDir = filepath.Join(v1Beta1HelmDir, "charts")
+	}
+	v1Beta2ChartsDir := ""
+	if v1Beta2HelmDir != "" {
+		v1Beta2ChartsDir = filepath.Join(v1Beta2HelmDir, "helm")
+	}
+	return v1Beta1ChartsDir, v1Beta2ChartsDir
 }
 
 // extractHelmCharts extracts the helm charts from the archive and returns the path to the directory.
@@ -418,6 +475,7 @@ func (c *Client) undeployManifests(undeployArgs operatortypes.UndeployAppArgs) e
 			RestoreLabelSelector: undeployArgs.RestoreLabelSelector,
 			KubectlVersion:       undeployArgs.KubectlVersion,
 			KustomizeVersion:     undeployArgs.KustomizeVersion,
+			ServerSideApply:      undeployArgs.ServerSideApply,
 			Wait:                 undeployArgs.Wait,
 		}
 		if err := c.diffAndDeleteManifests(opts); err != nil {
@@ -622,6 +680,8 @@ func (c *Client) setAppStatus(newAppStatus appstatetypes.AppStatus) error {
 	}
 
 	newAppState := appstatetypes.GetState(newAppStatus.ResourceStates)
+	metrics.SetAppState(newAppStatus.AppID, newAppState)
+
 	if currentAppStatus != nil && newAppState != currentAppStatus.State {
 		go func() {
 			err := reporting.GetReporter().SubmitAppInfo(newAppStatus.AppID)
@@ -629,12 +689,43 @@ func (c *Client) setAppStatus(newAppStatus appstatetypes.AppStatus) error {
 				logger.Debugf("failed to submit app info: %v", err)
 			}
 		}()
+		sequence := newAppStatus.Sequence
+		notifications.Notify(notificationtypes.Event{
+			Type:     notificationtypes.EventTypeAppStateChanged,
+			AppID:    newAppStatus.AppID,
+			Sequence: &sequence,
+			State:    newAppState,
+			Message:  fmt.Sprintf("App state changed from %s to %s", currentAppStatus.State, newAppState),
+		})
 	}
 
 	return nil
 }
 
-func (c *Client) getApplier(kubectlVersion, kustomizeVersion string) (applier.KubectlInterface, error) {
+// getApplier returns the applier used to create and delete resources. When serverSideApply is set,
+// resources are applied natively with the dynamic client and kubectl/kustomize are not required.
+func (c *Client) getApplier(serverSideApply bool, kubectlVersion, kustomizeVersion string) (applier.KubectlInterface, error) {
+	config, err := k8sutil.GetClusterConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get cluster config")
+	}
+
+	if serverSideApply {
+		serverSideApplier, err := applier.NewServerSideForConfig(config)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create server-side applier")
+		}
+		return serverSideApplier, nil
+	}
+
+	kubectl, err := getKubectlApplier(config, kubectlVersion, kustomizeVersion)
+	if err != nil {
+		return nil, err
+	}
+	return kubectl, nil
+}
+
+func getKubectlApplier(config *rest.Config, kubectlVersion, kustomizeVersion string) (*applier.Kubectl, error) {
 	kubectl, err := binaries.GetKubectlPathForVersion(kubectlVersion)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to find kubectl")
@@ -645,10 +736,5 @@ func (c *Client) getApplier(kubectlVersion, kustomizeVersion string) (applier.Ku
 		return nil, errors.Wrap(err, "failed to find kustomize")
 	}
 
-	config, err := k8sutil.GetClusterConfig()
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to get cluster config")
-	}
-
 	return applier.NewKubectl(kubectl, kustomize, config), nil
 }
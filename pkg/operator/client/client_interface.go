@@ -8,6 +8,7 @@ type ClientInterface interface {
 	Init() error
 	Shutdown()
 	DeployApp(deployArgs operatortypes.DeployAppArgs) (deployed bool, finalError error)
+	PlanDeployApp(deployArgs operatortypes.DeployAppArgs) (*operatortypes.DeployPlan, error)
 	UndeployApp(undeployArgs operatortypes.UndeployAppArgs) error
 	ApplyAppInformers(args operatortypes.AppInformersArgs)
 	ApplyNamespacesInformer(namespaces []string, imagePullSecrets []string)
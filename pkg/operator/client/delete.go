@@ -7,7 +7,6 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
-	"github.com/replicatedhq/kots/pkg/binaries"
 	"github.com/replicatedhq/kots/pkg/k8sutil"
 	"github.com/replicatedhq/kots/pkg/logger"
 	"github.com/replicatedhq/kots/pkg/operator/applier"
@@ -29,18 +28,37 @@ type DiffAndDeleteOptions struct {
 	RestoreLabelSelector *metav1.LabelSelector
 	KubectlVersion       string
 	KustomizeVersion     string
+	ServerSideApply      bool
 	Wait                 bool
 }
 
 func (c *Client) diffAndDeleteManifests(opts DiffAndDeleteOptions) error {
+	manifestsToDelete, err := c.getManifestsToDelete(opts)
+	if err != nil {
+		return errors.Wrap(err, "failed to get manifests to delete")
+	}
+
+	kubernetesApplier, err := c.getApplier(opts.ServerSideApply, opts.KubectlVersion, opts.KustomizeVersion)
+	if err != nil {
+		return errors.Wrap(err, "failed to get applier")
+	}
+
+	// TODO: return error here?
+	c.deleteManifests(manifestsToDelete, kubernetesApplier, opts.Wait)
+
+	return nil
+}
+
+// getManifestsToDelete returns the previous manifests that are not in the current manifests
+func (c *Client) getManifestsToDelete(opts DiffAndDeleteOptions) ([][]byte, error) {
 	decodedPrevious, err := base64.StdEncoding.DecodeString(opts.PreviousManifests)
 	if err != nil {
-		return errors.Wrap(err, "failed to base64 decode previous manifests")
+		return nil, errors.Wrap(err, "failed to base64 decode previous manifests")
 	}
 
 	decodedCurrent, err := base64.StdEncoding.DecodeString(opts.CurrentManifests)
 	if err != nil {
-		return errors.Wrap(err, "failed to base64 decode manifests")
+		return nil, errors.Wrap(err, "failed to base64 decode manifests")
 	}
 
 	// we need to find the gvk+names that are present in the previous, but not in the current and then remove them
@@ -74,7 +92,7 @@ func (c *Client) diffAndDeleteManifests(opts DiffAndDeleteOptions) error {
 			if opts.RestoreLabelSelector != nil {
 				s, err := metav1.LabelSelectorAsSelector(opts.RestoreLabelSelector)
 				if err != nil {
-					return errors.Wrap(err, "failed to convert label selector to a selector")
+					return nil, errors.Wrap(err, "failed to convert label selector to a selector")
 				}
 				if !s.Matches(labels.Set(o.Metadata.Labels)) {
 					delete = false
@@ -96,24 +114,7 @@ func (c *Client) diffAndDeleteManifests(opts DiffAndDeleteOptions) error {
 		decodedCurrentMap[k] = string(decodedCurrentDoc)
 	}
 
-	kubectl, err := binaries.GetKubectlPathForVersion(opts.KubectlVersion)
-	if err != nil {
-		return errors.Wrap(err, "failed to find kubectl")
-	}
-	kustomize, err := binaries.GetKustomizePathForVersion(opts.KustomizeVersion)
-	if err != nil {
-		return errors.Wrap(err, "failed to find kustomize")
-	}
-	config, err := k8sutil.GetClusterConfig()
-	if err != nil {
-		return errors.Wrap(err, "failed to get cluster config")
-	}
-
-	// this is pretty raw, and required kubectl...  we should
-	// consider some other options here?
-	kubernetesApplier := applier.NewKubectl(kubectl, kustomize, config)
-
-	// now remove anything that's in previous but not in current
+	// now find anything that's in previous but not in current
 	manifestsToDelete := [][]byte{}
 	for k, previous := range decodedPreviousMap {
 		if _, ok := decodedCurrentMap[k]; ok {
@@ -125,10 +126,7 @@ func (c *Client) diffAndDeleteManifests(opts DiffAndDeleteOptions) error {
 		manifestsToDelete = append(manifestsToDelete, []byte(previous.spec))
 	}
 
-	// TODO: return error here?
-	c.deleteManifests(manifestsToDelete, kubernetesApplier, opts.Wait)
-
-	return nil
+	return manifestsToDelete, nil
 }
 
 func (c *Client) deleteManifests(manifests [][]byte, kubernetesApplier applier.KubectlInterface, waitFlag bool) {
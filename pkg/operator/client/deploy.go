@@ -119,7 +119,7 @@ func (c *Client) ensureImagePullSecretsPresent(namespace string, imagePullSecret
 func (c *Client) ensureResourcesPresent(deployArgs operatortypes.DeployAppArgs) (*deployResult, error) {
 	var deployRes deployResult
 
-	kubernetesApplier, err := c.getApplier(deployArgs.KubectlVersion, deployArgs.KustomizeVersion)
+	kubernetesApplier, err := c.getApplier(deployArgs.ServerSideApply, deployArgs.KubectlVersion, deployArgs.KustomizeVersion)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get applier")
 	}
@@ -99,6 +99,21 @@ func (mr *MockClientInterfaceMockRecorder) Init() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Init", reflect.TypeOf((*MockClientInterface)(nil).Init))
 }
 
+// PlanDeployApp mocks base method.
+func (m *MockClientInterface) PlanDeployApp(deployArgs types.DeployAppArgs) (*types.DeployPlan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PlanDeployApp", deployArgs)
+	ret0, _ := ret[0].(*types.DeployPlan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PlanDeployApp indicates an expected call of PlanDeployApp.
+func (mr *MockClientInterfaceMockRecorder) PlanDeployApp(deployArgs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PlanDeployApp", reflect.TypeOf((*MockClientInterface)(nil).PlanDeployApp), deployArgs)
+}
+
 // Shutdown mocks base method.
 func (m *MockClientInterface) Shutdown() {
 	m.ctrl.T.Helper()
@@ -0,0 +1,217 @@
+package client
+
+import (
+	"encoding/base64"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/helm"
+	"github.com/replicatedhq/kots/pkg/k8sutil"
+	"github.com/replicatedhq/kots/pkg/operator/applier"
+	operatortypes "github.com/replicatedhq/kots/pkg/operator/types"
+	"github.com/replicatedhq/kots/pkg/util"
+	helmrelease "helm.sh/helm/v3/pkg/release"
+)
+
+// PlanDeployApp returns the changes that deploying deployArgs would make to the cluster without making them.
+// Resources are planned with a dry run of the applier that is used to deploy them.
+func (c *Client) PlanDeployApp(deployArgs operatortypes.DeployAppArgs) (*operatortypes.DeployPlan, error) {
+	config, err := k8sutil.GetClusterConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get cluster config")
+	}
+	serverSide, err := applier.NewServerSideForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create server-side applier")
+	}
+
+	plan := &operatortypes.DeployPlan{
+		AppID:        deployArgs.AppID,
+		Sequence:     deployArgs.Sequence,
+		Resources:    []operatortypes.PlannedResource{},
+		HelmReleases: []operatortypes.PlannedHelmRelease{},
+	}
+
+	deleted, err := c.planDeletedResources(serverSide, deployArgs)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to plan deleted resources")
+	}
+	plan.Resources = append(plan.Resources, deleted...)
+
+	// without server-side apply, the resources are deployed with kubectl client-side apply which merges them differently
+	var kubectl *applier.Kubectl
+	if !deployArgs.ServerSideApply {
+		kubectl, err = getKubectlApplier(config, deployArgs.KubectlVersion, deployArgs.KustomizeVersion)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get kubectl applier")
+		}
+	}
+
+	applied, err := c.planAppliedResources(serverSide, kubectl, deployArgs)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to plan applied resources")
+	}
+	plan.Resources = append(plan.Resources, applied...)
+
+	helmReleases, err := c.planHelmReleases(deployArgs)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to plan helm releases")
+	}
+	plan.HelmReleases = helmReleases
+
+	return plan, nil
+}
+
+func (c *Client) planDeletedResources(serverSide *applier.ServerSide, deployArgs operatortypes.DeployAppArgs) ([]operatortypes.PlannedResource, error) {
+	if deployArgs.PreviousManifests == "" {
+		return nil, nil
+	}
+
+	manifestsToDelete, err := c.getManifestsToDelete(DiffAndDeleteOptions{
+		PreviousManifests:    deployArgs.PreviousManifests,
+		CurrentManifests:     deployArgs.Manifests,
+		AdditionalNamespaces: deployArgs.AdditionalNamespaces,
+		IsRestore:            deployArgs.IsRestore,
+		RestoreLabelSelector: deployArgs.RestoreLabelSelector,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get manifests to delete")
+	}
+
+	planned := []operatortypes.PlannedResource{}
+	for _, phase := range groupAndSortResourcesForDeletion(decodeManifests(manifestsToDelete)) {
+		for _, resource := range phase.Resources {
+			if resource.DecodeErrMsg != "" {
+				// deleting a document that cannot be parsed fails, so it does not change anything
+				continue
+			}
+			resourcePlan, err := serverSide.PlanRemove(c.TargetNamespace, []byte(resource.Manifest))
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to plan delete of %s %s", resource.GetKind(), resource.GetName())
+			}
+			planned = append(planned, resourcePlan...)
+		}
+	}
+
+	return planned, nil
+}
+
+// planAppliedResources plans the resources with a client-side apply dry run if kubectl is not nil
+func (c *Client) planAppliedResources(serverSide *applier.ServerSide, kubectl *applier.Kubectl, deployArgs operatortypes.DeployAppArgs) ([]operatortypes.PlannedResource, error) {
+	decoded, err := base64.StdEncoding.DecodeString(deployArgs.Manifests)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode manifests")
+	}
+
+	planned := []operatortypes.PlannedResource{}
+	for _, phase := range groupAndSortResourcesForCreation(decodeManifests(util.ConvertToSingleDocs(decoded))) {
+		for _, resource := range phase.Resources {
+			if resource.DecodeErrMsg != "" {
+				planned = append(planned, operatortypes.PlannedResource{
+					Action: operatortypes.PlanUnknown,
+					Error:  resource.DecodeErrMsg,
+				})
+				continue
+			}
+			var resourcePlan []operatortypes.PlannedResource
+			if kubectl != nil {
+				resourcePlan, err = serverSide.PlanClientSideApply(kubectl, c.TargetNamespace, deployArgs.AppSlug, []byte(resource.Manifest), deployArgs.AnnotateSlug)
+			} else {
+				resourcePlan, err = serverSide.PlanApply(c.TargetNamespace, deployArgs.AppSlug, []byte(resource.Manifest), deployArgs.AnnotateSlug)
+			}
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to plan apply of %s %s", resource.GetKind(), resource.GetName())
+			}
+			planned = append(planned, resourcePlan...)
+		}
+	}
+
+	return planned, nil
+}
+
+func (c *Client) planHelmReleases(deployArgs operatortypes.DeployAppArgs) ([]operatortypes.PlannedHelmRelease, error) {
+	charts, err := extractHelmChartsToDeploy(deployArgs)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to extract helm charts")
+	}
+	defer charts.cleanup()
+
+	planned := []operatortypes.PlannedHelmRelease{}
+
+	removedCharts, err := charts.removedCharts()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find removed charts")
+	}
+	for _, chart := range removedCharts {
+		planned = append(planned, operatortypes.PlannedHelmRelease{
+			ReleaseName:          chart.GetReleaseName(),
+			Namespace:            chart.GetNamespace(),
+			ChartName:            chart.GetChartName(),
+			PreviousChartVersion: chart.GetChartVersion(),
+			Action:               operatortypes.PlanUninstall,
+		})
+	}
+
+	if len(deployArgs.V1Beta1ChartsArchive) == 0 && len(deployArgs.V1Beta2ChartsArchive) == 0 {
+		return planned, nil
+	}
+
+	v1Beta1ChartsDir, v1Beta2ChartsDir := charts.currentChartsDirs()
+	orderedDirs, err := getSortedCharts(v1Beta1ChartsDir, v1Beta2ChartsDir, charts.currentKotsCharts(), c.TargetNamespace, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get sorted charts")
+	}
+
+	for _, dir := range orderedDirs {
+		release := operatortypes.PlannedHelmRelease{
+			ReleaseName:  dir.ReleaseName,
+			Namespace:    dir.Namespace,
+			ChartName:    dir.ChartName,
+			ChartVersion: dir.ChartVersion,
+			Action:       operatortypes.PlanInstall,
+		}
+
+		installed, err := getInstalledHelmRelease(dir.ReleaseName, dir.Namespace)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get installed release %s", dir.ReleaseName)
+		}
+		if installed != nil {
+			release.Action = operatortypes.PlanUpgrade
+			release.PreviousChartVersion = installed.Version
+		}
+
+		planned = append(planned, release)
+	}
+
+	return planned, nil
+}
+
+// getInstalledHelmRelease returns the latest revision of a release, or nil if the release is not installed.
+// Release secrets created before kots v1.95.0 are in the kotsadm namespace, so it is checked as well.
+func getInstalledHelmRelease(releaseName string, namespace string) (*helm.InstalledRelease, error) {
+	namespaces := []string{util.AppNamespace()}
+	if namespace != "" && namespace != util.AppNamespace() {
+		namespaces = append([]string{namespace}, namespaces...)
+	}
+
+	for _, ns := range namespaces {
+		releases, err := helm.ListChartVersions(releaseName, ns)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list releases in namespace %s", ns)
+		}
+
+		var latest *helm.InstalledRelease
+		for i, r := range releases {
+			if r.Status == helmrelease.StatusUninstalled {
+				continue
+			}
+			if latest == nil || r.Revision > latest.Revision {
+				latest = &releases[i]
+			}
+		}
+		if latest != nil {
+			return latest, nil
+		}
+	}
+
+	return nil, nil
+}
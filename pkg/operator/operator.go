@@ -24,7 +24,10 @@ import (
 	snapshot "github.com/replicatedhq/kots/pkg/kotsadmsnapshot"
 	"github.com/replicatedhq/kots/pkg/kotsutil"
 	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/metrics"
 	"github.com/replicatedhq/kots/pkg/midstream"
+	"github.com/replicatedhq/kots/pkg/notifications"
+	notificationtypes "github.com/replicatedhq/kots/pkg/notifications/types"
 	"github.com/replicatedhq/kots/pkg/operator/client"
 	operatortypes "github.com/replicatedhq/kots/pkg/operator/types"
 	registrytypes "github.com/replicatedhq/kots/pkg/registry/types"
@@ -36,9 +39,11 @@ import (
 	"github.com/replicatedhq/kots/pkg/supportbundle"
 	supportbundletypes "github.com/replicatedhq/kots/pkg/supportbundle/types"
 	"github.com/replicatedhq/kots/pkg/template"
+	"github.com/replicatedhq/kots/pkg/tracing"
 	"github.com/replicatedhq/kots/pkg/util"
 	"github.com/replicatedhq/kotskinds/multitype"
 	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	"go.opentelemetry.io/otel/attribute"
 	corev1 "k8s.io/api/core/v1"
 	kuberneteserrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -57,7 +62,12 @@ type Operator struct {
 	clusterToken string
 	clusterID    string
 	deployMtxs   map[string]*sync.Mutex // key is app id
+	deployMtxsMu sync.Mutex             // guards deployMtxs
 	k8sClientset kubernetes.Interface
+
+	deployRequests chan struct{}
+	deployingMtx   sync.Mutex
+	deploying      map[string]bool // key is app id
 }
 
 func Init(client client.ClientInterface, store store.Store, clusterToken string, k8sClientset kubernetes.Interface) *Operator {
@@ -67,6 +77,9 @@ func Init(client client.ClientInterface, store store.Store, clusterToken string,
 		clusterToken: clusterToken,
 		deployMtxs:   map[string]*sync.Mutex{},
 		k8sClientset: k8sClientset,
+
+		deployRequests: make(chan struct{}, 1),
+		deploying:      map[string]bool{},
 	}
 	return operator
 }
@@ -78,6 +91,20 @@ func MustGetOperator() *Operator {
 	panic("operator not initialized")
 }
 
+// InitCluster resolves the cluster that apps are deployed to. It must be called on every replica before serving
+// requests, since requests that plan or undeploy apps can be served by any replica, not only the leader.
+func (o *Operator) InitCluster() error {
+	id, err := o.store.GetClusterIDFromDeployToken(o.clusterToken)
+	if err != nil {
+		return errors.Wrap(err, "failed to get cluster id from deploy token")
+	}
+	o.clusterID = id
+	return nil
+}
+
+// Start starts the deploy loop and the informers, which must only run on the leader.
+// The operator client is only initialized here since it runs the hook and app status informers,
+// the client methods that other replicas use, e.g. to undeploy an app, do not depend on them.
 func (o *Operator) Start() error {
 	logger.Debug("starting the operator")
 
@@ -85,19 +112,83 @@ func (o *Operator) Start() error {
 		return errors.Wrap(err, "failed to initialize the operator client")
 	}
 
-	id, err := o.store.GetClusterIDFromDeployToken(o.clusterToken)
-	if err != nil {
-		return errors.Wrap(err, "failed to get cluster id from deploy token")
-	}
-	o.clusterID = id
-
 	go o.resumeInformers()
-	go o.resumeDeployments()
+	go func() {
+		o.resumeDeployments()
+		o.deployLoop()
+	}()
 	startLoop(o.restoreLoop, 2)
 
 	return nil
 }
 
+// RequestDeploy wakes up the deploy loop to deploy versions that were marked as current and pending.
+// Deploy requests can be served by any kotsadm replica, but only the leader runs the operator.
+func (o *Operator) RequestDeploy() {
+	select {
+	case o.deployRequests <- struct{}{}:
+	default:
+		// a request is already queued
+	}
+}
+
+func (o *Operator) deployLoop() {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-o.deployRequests:
+		case <-ticker.C:
+		}
+		o.deployRequestedVersions()
+	}
+}
+
+// deployRequestedVersions deploys the current version of each app if it is pending and the app is not already being deployed
+func (o *Operator) deployRequestedVersions() {
+	apps, err := o.store.ListAppsForDownstream(o.clusterID)
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to list installed apps for downstream"))
+		return
+	}
+
+	for _, a := range apps {
+		if a.RestoreInProgressName != "" {
+			continue
+		}
+
+		currentVersion, err := o.store.GetCurrentDownstreamVersion(a.ID, o.clusterID)
+		if err != nil {
+			logger.Error(errors.Wrapf(err, "failed to get current downstream version for app %s", a.ID))
+			continue
+		}
+		if currentVersion == nil || currentVersion.Status != storetypes.VersionPending {
+			continue
+		}
+
+		o.deployingMtx.Lock()
+		if o.deploying[a.ID] {
+			o.deployingMtx.Unlock()
+			continue
+		}
+		o.deploying[a.ID] = true
+		o.deployingMtx.Unlock()
+
+		go func(appID string, sequence int64) {
+			defer func() {
+				o.deployingMtx.Lock()
+				delete(o.deploying, appID)
+				o.deployingMtx.Unlock()
+			}()
+
+			if _, err := o.DeployApp(appID, sequence); err != nil {
+				logger.Error(errors.Wrapf(err, "failed to deploy sequence %d for app %s", sequence, appID))
+			}
+		}(a.ID, currentVersion.ParentSequence)
+	}
+}
+
 func (o *Operator) Shutdown() {
 	if o.client == nil {
 		return
@@ -154,11 +245,38 @@ func (o *Operator) resumeDeployment(a *apptypes.App) (bool, error) {
 }
 
 func (o *Operator) DeployApp(appID string, sequence int64) (deployed bool, deployError error) {
+	startTime := time.Now()
+	defer func() {
+		metrics.ObserveDeploy(appID, deployed, deployError, time.Since(startTime))
+	}()
+
+	return o.deployApp(appID, sequence, false)
+}
+
+// getDeployMutex returns the mutex that serializes deploys of the given app, creating it if needed
+func (o *Operator) getDeployMutex(appID string) *sync.Mutex {
+	o.deployMtxsMu.Lock()
+	defer o.deployMtxsMu.Unlock()
+
 	if _, ok := o.deployMtxs[appID]; !ok {
 		o.deployMtxs[appID] = &sync.Mutex{}
 	}
-	o.deployMtxs[appID].Lock()
-	defer o.deployMtxs[appID].Unlock()
+	return o.deployMtxs[appID]
+}
+
+// deployApp deploys the given sequence. When isRollback is true, the deployment is not verified
+// even if the app uses the progressive deploy strategy.
+func (o *Operator) deployApp(appID string, sequence int64, isRollback bool) (deployed bool, deployError error) {
+	ctx, span := tracing.StartSpan(context.Background(), "deploy_app", tracing.AppAttributes(appID, sequence)...)
+	span.SetAttributes(attribute.Bool("rollback", isRollback))
+	defer func() {
+		span.SetAttributes(attribute.Bool("deployed", deployed))
+		tracing.EndSpan(span, deployError)
+	}()
+
+	deployMtx := o.getDeployMutex(appID)
+	deployMtx.Lock()
+	defer deployMtx.Unlock()
 
 	if err := o.store.SetDownstreamVersionStatus(appID, sequence, storetypes.VersionDeploying, ""); err != nil {
 		return false, errors.Wrap(err, "failed to update downstream status")
@@ -168,36 +286,42 @@ func (o *Operator) DeployApp(appID string, sequence int64) (deployed bool, deplo
 		go func() {
 			err := reporting.GetReporter().SubmitAppInfo(appID)
 			if err != nil {
-				logger.Debugf("failed to submit initial app info: %v", err)
+				logger.WithContext(ctx).Debugf("failed to submit initial app info: %v", err)
 			}
 		}()
 
 		defer func() {
 			err := reporting.GetReporter().SubmitAppInfo(appID)
 			if err != nil {
-				logger.Debugf("failed to submit final app info: %v", err)
+				logger.WithContext(ctx).Debugf("failed to submit final app info: %v", err)
 			}
 		}()
 	}
 
+	var progressiveDeploy *progressiveDeployArgs
 	defer func() {
 		if deployError != nil {
 			err := o.store.SetDownstreamVersionStatus(appID, sequence, storetypes.VersionFailed, deployError.Error())
 			if err != nil {
-				logger.Error(errors.Wrap(err, "failed to update downstream status"))
+				logger.WithContext(ctx).Error(errors.Wrap(err, "failed to update downstream status"))
 			}
+			notifyDeployFailed(appID, sequence, fmt.Sprintf("Failed to deploy sequence %d: %v", sequence, deployError))
 			return
 		}
 		if !deployed {
 			err := o.store.SetDownstreamVersionStatus(appID, sequence, storetypes.VersionFailed, "")
 			if err != nil {
-				logger.Error(errors.Wrap(err, "failed to update downstream status"))
+				logger.WithContext(ctx).Error(errors.Wrap(err, "failed to update downstream status"))
 			}
+			notifyDeployFailed(appID, sequence, fmt.Sprintf("Failed to deploy sequence %d", sequence))
 			return
 		}
 		err := o.store.SetDownstreamVersionStatus(appID, sequence, storetypes.VersionDeployed, "")
 		if err != nil {
-			logger.Error(errors.Wrap(err, "failed to update downstream status"))
+			logger.WithContext(ctx).Error(errors.Wrap(err, "failed to update downstream status"))
+		}
+		if progressiveDeploy != nil {
+			go o.watchProgressiveDeploy(*progressiveDeploy)
 		}
 	}()
 
@@ -210,20 +334,123 @@ func (o *Operator) DeployApp(appID string, sequence int64) (deployed bool, deplo
 		return false, errors.Errorf("failed to deploy version %d because app restore is already in progress", sequence)
 	}
 
-	downstreams, err := o.store.GetDownstream(o.clusterID)
+	previouslyDeployedSequence, err := o.store.GetPreviouslyDeployedSequence(app.ID, o.clusterID)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get previously deployed sequence")
+	}
+
+	input, err := o.getDeployInput(ctx, app, o.clusterID, sequence, previouslyDeployedSequence)
 	if err != nil {
-		return false, errors.Wrap(err, "failed to get downstream")
+		return false, err
+	}
+
+	if err := o.ensureKotsadmApplicationMetadataConfigMap(app, sequence, util.PodNamespace, input.kotsKinds, input.registrySettings); err != nil {
+		return false, errors.Wrap(err, "failed to ensure kotsadm application metadata configmap")
+	}
+
+	if err := o.applyStatusInformers(app, sequence, input.kotsKinds, input.builder); err != nil {
+		return false, errors.Wrap(err, "failed to apply status informers")
+	}
+
+	o.client.ApplyNamespacesInformer(input.kotsKinds.KotsApplication.Spec.AdditionalNamespaces, input.deployArgs.ImagePullSecrets)
+	o.client.ApplyHooksInformer(input.kotsKinds.KotsApplication.Spec.AdditionalNamespaces)
+
+	_, applySpan := tracing.StartSpan(ctx, "deploy_app.apply")
+	deployed, err = o.client.DeployApp(input.deployArgs)
+	tracing.EndSpan(applySpan, err)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to deploy app")
+	}
+
+	if deployed && !isRollback {
+		progressiveDeploy, err = getProgressiveDeployArgs(app, sequence, previouslyDeployedSequence)
+		if err != nil {
+			logger.WithContext(ctx).Error(errors.Wrapf(err, "failed to get progressive deploy args for app %s", app.Slug))
+		}
+	}
+
+	return deployed, nil
+}
+
+// PlanDeploy returns the changes that deploying a sequence would make to the cluster, compared to the
+// currently deployed sequence, without making them
+func (o *Operator) PlanDeploy(appID string, sequence int64) (*operatortypes.DeployPlan, error) {
+	ctx, span := tracing.StartSpan(context.Background(), "plan_deploy", tracing.AppAttributes(appID, sequence)...)
+	var planErr error
+	defer func() {
+		tracing.EndSpan(span, planErr)
+	}()
+
+	plan, planErr := o.planDeploy(ctx, appID, sequence)
+	return plan, planErr
+}
+
+func (o *Operator) planDeploy(ctx context.Context, appID string, sequence int64) (*operatortypes.DeployPlan, error) {
+	app, err := o.store.GetApp(appID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get app")
+	}
+
+	// the plan can be requested from any replica, so the cluster is looked up from the app like the deploy handlers do
+	downstreams, err := o.store.ListDownstreamsForApp(app.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list downstreams for app")
+	}
+	if len(downstreams) == 0 {
+		return nil, errors.Errorf("no downstreams for app %s", app.Slug)
+	}
+	clusterID := downstreams[0].ClusterID
+
+	currentVersion, err := o.store.GetCurrentDownstreamVersion(app.ID, clusterID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get current downstream version")
+	}
+	currentSequence := int64(-1)
+	if currentVersion != nil {
+		currentSequence = currentVersion.Sequence
+	}
+
+	input, err := o.getDeployInput(ctx, app, clusterID, sequence, currentSequence)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := o.client.PlanDeployApp(input.deployArgs)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to plan deploy")
+	}
+	plan.PreviousSequence = currentSequence
+
+	return plan, nil
+}
+
+// deployInput is what is needed to deploy a sequence of an app
+type deployInput struct {
+	deployArgs       operatortypes.DeployAppArgs
+	kotsKinds        *kotsutil.KotsKinds
+	registrySettings registrytypes.RegistrySettings
+	builder          *template.Builder
+}
+
+// getDeployInput renders the archive of a sequence and of the previously deployed sequence, if any,
+// into the args that are used to deploy it
+func (o *Operator) getDeployInput(ctx context.Context, app *apptypes.App, clusterID string, sequence int64, previouslyDeployedSequence int64) (*deployInput, error) {
+	downstreams, err := o.store.GetDownstream(clusterID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get downstream")
 	}
 
 	deployedVersionArchive, err := os.MkdirTemp("", "kotsadm")
 	if err != nil {
-		return false, errors.Wrap(err, "failed to create temp dir")
+		return nil, errors.Wrap(err, "failed to create temp dir")
 	}
 	defer os.RemoveAll(deployedVersionArchive)
 
+	_, archiveSpan := tracing.StartSpan(ctx, "deploy_app.get_archive")
 	err = o.store.GetAppVersionArchive(app.ID, sequence, deployedVersionArchive)
+	tracing.EndSpan(archiveSpan, err)
 	if err != nil {
-		return false, errors.Wrap(err, "failed to get app version archive")
+		return nil, errors.Wrap(err, "failed to get app version archive")
 	}
 
 	// ensure disaster recovery label transformer in midstream
@@ -231,40 +458,36 @@ func (o *Operator) DeployApp(appID string, sequence int64) (deployed bool, deplo
 		"kots.io/app-slug": app.Slug,
 	}
 	if err := midstream.EnsureDisasterRecoveryLabelTransformer(deployedVersionArchive, additionalLabels); err != nil {
-		return false, errors.Wrap(err, "failed to ensure disaster recovery label transformer")
+		return nil, errors.Wrap(err, "failed to ensure disaster recovery label transformer")
 	}
 
 	kotsKinds, err := kotsutil.LoadKotsKindsFromPath(filepath.Join(deployedVersionArchive, "upstream"))
 	if err != nil {
-		return false, errors.Wrap(err, "failed to load kotskinds")
+		return nil, errors.Wrap(err, "failed to load kotskinds")
 	}
 
 	registrySettings, err := o.store.GetRegistryDetailsForApp(app.ID)
 	if err != nil {
-		return false, errors.Wrap(err, "failed to get registry settings for app")
-	}
-
-	if err := o.ensureKotsadmApplicationMetadataConfigMap(app, sequence, util.PodNamespace, kotsKinds, registrySettings); err != nil {
-		return false, errors.Wrap(err, "failed to ensure kotsadm application metadata configmap")
+		return nil, errors.Wrap(err, "failed to get registry settings for app")
 	}
 
 	builder, err := render.NewBuilder(kotsKinds, registrySettings, app.Slug, sequence, app.IsAirgap, util.PodNamespace)
 	if err != nil {
-		return false, errors.Wrap(err, "failed to get template builder")
+		return nil, errors.Wrap(err, "failed to get template builder")
 	}
 
 	if kotsKinds.V1Beta1HelmCharts != nil {
 		for i, helmChart := range kotsKinds.V1Beta1HelmCharts.Items {
 			renderedNamespace, err := builder.String(helmChart.Spec.Namespace)
 			if err != nil {
-				return false, errors.Wrapf(err, "failed to render namespace %s for chart %s", helmChart.Spec.Namespace, helmChart.GetReleaseName())
+				return nil, errors.Wrapf(err, "failed to render namespace %s for chart %s", helmChart.Spec.Namespace, helmChart.GetReleaseName())
 			}
 			kotsKinds.V1Beta1HelmCharts.Items[i].Spec.Namespace = renderedNamespace
 
 			for j, upgradeFlag := range helmChart.Spec.HelmUpgradeFlags {
 				renderedUpgradeFlag, err := builder.String(upgradeFlag)
 				if err != nil {
-					return false, errors.Wrapf(err, "failed to render upgrade flag %s for chart %s", upgradeFlag, helmChart.GetReleaseName())
+					return nil, errors.Wrapf(err, "failed to render upgrade flag %s for chart %s", upgradeFlag, helmChart.GetReleaseName())
 				}
 				kotsKinds.V1Beta1HelmCharts.Items[i].Spec.HelmUpgradeFlags[j] = renderedUpgradeFlag
 			}
@@ -275,14 +498,14 @@ func (o *Operator) DeployApp(appID string, sequence int64) (deployed bool, deplo
 		for i, helmChart := range kotsKinds.V1Beta2HelmCharts.Items {
 			renderedNamespace, err := builder.String(helmChart.Spec.Namespace)
 			if err != nil {
-				return false, errors.Wrapf(err, "failed to render namespace %s for chart %s", helmChart.Spec.Namespace, helmChart.GetReleaseName())
+				return nil, errors.Wrapf(err, "failed to render namespace %s for chart %s", helmChart.Spec.Namespace, helmChart.GetReleaseName())
 			}
 			kotsKinds.V1Beta2HelmCharts.Items[i].Spec.Namespace = renderedNamespace
 
 			for j, upgradeFlag := range helmChart.Spec.HelmUpgradeFlags {
 				renderedUpgradeFlag, err := builder.String(upgradeFlag)
 				if err != nil {
-					return false, errors.Wrapf(err, "failed to render upgrade flag %s for chart %s", upgradeFlag, helmChart.GetReleaseName())
+					return nil, errors.Wrapf(err, "failed to render upgrade flag %s for chart %s", upgradeFlag, helmChart.GetReleaseName())
 				}
 				kotsKinds.V1Beta2HelmCharts.Items[i].Spec.HelmUpgradeFlags[j] = renderedUpgradeFlag
 			}
@@ -294,7 +517,7 @@ func (o *Operator) DeployApp(appID string, sequence int64) (deployed bool, deplo
 		if kotsKinds.Identity.Spec.RequireIdentityProvider.Type == multitype.String {
 			requireIdentityProvider, err = builder.Bool(kotsKinds.Identity.Spec.RequireIdentityProvider.StrVal, false)
 			if err != nil {
-				return false, errors.Wrap(err, "failed to build kotsv1beta1.Identity.spec.requireIdentityProvider")
+				return nil, errors.Wrap(err, "failed to build kotsv1beta1.Identity.spec.requireIdentityProvider")
 			}
 		} else {
 			requireIdentityProvider = kotsKinds.Identity.Spec.RequireIdentityProvider.BoolVal
@@ -302,30 +525,22 @@ func (o *Operator) DeployApp(appID string, sequence int64) (deployed bool, deplo
 	}
 
 	if requireIdentityProvider && !identitydeploy.IsEnabled(kotsKinds.Identity, kotsKinds.IdentityConfig) {
-		return false, errors.New("identity service is required but is not enabled")
+		return nil, errors.New("identity service is required but is not enabled")
 	}
 
 	kustomizeBinPath := kotsKinds.GetKustomizeBinaryPath()
 
-	renderedManifests, _, err := apparchive.GetRenderedApp(deployedVersionArchive, downstreams.Name, kustomizeBinPath)
+	_, renderSpan := tracing.StartSpan(ctx, "deploy_app.render")
+	renderedManifests, v1beta1ChartsArchive, v1beta2ChartsArchive, err := renderAppArchive(deployedVersionArchive, downstreams.Name, kustomizeBinPath)
+	tracing.EndSpan(renderSpan, err)
 	if err != nil {
-		return false, errors.Wrap(err, "failed to get rendered app")
+		return nil, err
 	}
 	base64EncodedManifests := base64.StdEncoding.EncodeToString(renderedManifests)
 
-	v1beta1ChartsArchive, _, err := apparchive.GetRenderedV1Beta1ChartsArchive(deployedVersionArchive, downstreams.Name, kustomizeBinPath)
-	if err != nil {
-		return false, errors.Wrap(err, "failed to get rendered charts archive")
-	}
-
-	v1beta2ChartsArchive, err := apparchive.GetV1Beta2ChartsArchive(deployedVersionArchive)
-	if err != nil {
-		return false, errors.Wrap(err, "failed to get v1beta2 charts archive")
-	}
-
 	imagePullSecrets, err := getImagePullSecrets(deployedVersionArchive)
 	if err != nil {
-		return false, errors.Wrap(err, "failed to get image pull secrets")
+		return nil, errors.Wrap(err, "failed to get image pull secrets")
 	}
 
 	// get previous manifests (if any)
@@ -333,62 +548,51 @@ func (o *Operator) DeployApp(appID string, sequence int64) (deployed bool, deplo
 	base64EncodedPreviousManifests := ""
 	previousV1beta1ChartsArchive := []byte{}
 	previousV1beta2ChartsArchive := []byte{}
-	previouslyDeployedSequence, err := o.store.GetPreviouslyDeployedSequence(app.ID, o.clusterID)
-	if err != nil {
-		return false, errors.Wrap(err, "failed to get previously deployed sequence")
-	}
 	if previouslyDeployedSequence != -1 {
-		previouslyDeployedParentSequence, err := o.store.GetParentSequenceForSequence(app.ID, o.clusterID, previouslyDeployedSequence)
+		previouslyDeployedParentSequence, err := o.store.GetParentSequenceForSequence(app.ID, clusterID, previouslyDeployedSequence)
 		if err != nil {
-			return false, errors.Wrap(err, "failed to get previously deployed parent sequence")
+			return nil, errors.Wrap(err, "failed to get previously deployed parent sequence")
 		}
 
 		if previouslyDeployedParentSequence != -1 {
 			previouslyDeployedVersionArchive, err := os.MkdirTemp("", "kotsadm")
 			if err != nil {
-				return false, errors.Wrap(err, "failed to create temp dir")
+				return nil, errors.Wrap(err, "failed to create temp dir")
 			}
 			defer os.RemoveAll(previouslyDeployedVersionArchive)
 
 			err = o.store.GetAppVersionArchive(app.ID, previouslyDeployedParentSequence, previouslyDeployedVersionArchive)
 			if err != nil {
-				return false, errors.Wrap(err, "failed to get previously deployed app version archive")
+				return nil, errors.Wrap(err, "failed to get previously deployed app version archive")
 			}
 
 			previousKotsKinds, err = kotsutil.LoadKotsKindsFromPath(filepath.Join(previouslyDeployedVersionArchive, "upstream"))
 			if err != nil {
-				return false, errors.Wrap(err, "failed to load kotskinds for previously deployed app version")
+				return nil, errors.Wrap(err, "failed to load kotskinds for previously deployed app version")
 			}
 
 			previousRenderedManifests, _, err := apparchive.GetRenderedApp(previouslyDeployedVersionArchive, downstreams.Name, kustomizeBinPath)
 			if err != nil {
-				logger.Error(errors.Wrap(err, "failed to get previously deployed rendered app"))
+				logger.WithContext(ctx).Error(errors.Wrap(err, "failed to get previously deployed rendered app"))
 			} else {
 				base64EncodedPreviousManifests = base64.StdEncoding.EncodeToString(previousRenderedManifests)
 				previousV1beta1ChartsArchive, _, err = apparchive.GetRenderedV1Beta1ChartsArchive(previouslyDeployedVersionArchive, downstreams.Name, kustomizeBinPath)
 				if err != nil {
-					return false, errors.Wrap(err, "failed to get previously deployed rendered charts archive")
+					return nil, errors.Wrap(err, "failed to get previously deployed rendered charts archive")
 				}
 
 				previousV1beta2ChartsArchive, err = apparchive.GetV1Beta2ChartsArchive(previouslyDeployedVersionArchive)
 				if err != nil {
-					return false, errors.Wrap(err, "failed to get previously deployed v1beta2 charts archive")
+					return nil, errors.Wrap(err, "failed to get previously deployed v1beta2 charts archive")
 				}
 			}
 		}
 	}
 
-	if err := o.applyStatusInformers(app, sequence, kotsKinds, builder); err != nil {
-		return false, errors.Wrap(err, "failed to apply status informers")
-	}
-
-	o.client.ApplyNamespacesInformer(kotsKinds.KotsApplication.Spec.AdditionalNamespaces, imagePullSecrets)
-	o.client.ApplyHooksInformer(kotsKinds.KotsApplication.Spec.AdditionalNamespaces)
-
 	deployArgs := operatortypes.DeployAppArgs{
 		AppID:                        app.ID,
 		AppSlug:                      app.Slug,
-		ClusterID:                    o.clusterID,
+		ClusterID:                    clusterID,
 		Sequence:                     sequence,
 		KubectlVersion:               kotsKinds.KotsApplication.Spec.KubectlVersion,
 		KustomizeVersion:             kotsKinds.KotsApplication.Spec.KustomizeVersion,
@@ -403,15 +607,17 @@ func (o *Operator) DeployApp(appID string, sequence int64) (deployed bool, deplo
 		Action:                       "deploy",
 		Wait:                         false,
 		AnnotateSlug:                 os.Getenv("ANNOTATE_SLUG") != "",
+		ServerSideApply:              os.Getenv("SERVER_SIDE_APPLY") != "",
 		KotsKinds:                    kotsKinds,
 		PreviousKotsKinds:            previousKotsKinds,
 	}
-	deployed, err = o.client.DeployApp(deployArgs)
-	if err != nil {
-		return false, errors.Wrap(err, "failed to deploy app")
-	}
 
-	return deployed, nil
+	return &deployInput{
+		deployArgs:       deployArgs,
+		kotsKinds:        kotsKinds,
+		registrySettings: registrySettings,
+		builder:          builder,
+	}, nil
 }
 
 func (o *Operator) applyStatusInformers(a *apptypes.App, sequence int64, kotsKinds *kotsutil.KotsKinds, builder *template.Builder) error {
@@ -703,11 +909,9 @@ func (o *Operator) checkRestoreComplete(a *apptypes.App, restore *velerov1.Resto
 }
 
 func (o *Operator) UndeployApp(a *apptypes.App, d *downstreamtypes.Downstream, isRestore bool) error {
-	if _, ok := o.deployMtxs[a.ID]; !ok {
-		o.deployMtxs[a.ID] = &sync.Mutex{}
-	}
-	o.deployMtxs[a.ID].Lock()
-	defer o.deployMtxs[a.ID].Unlock()
+	deployMtx := o.getDeployMutex(a.ID)
+	deployMtx.Lock()
+	defer deployMtx.Unlock()
 
 	deployedVersion, err := o.store.GetCurrentDownstreamVersion(a.ID, d.ClusterID)
 	if err != nil {
@@ -779,7 +983,7 @@ func (o *Operator) UndeployApp(a *apptypes.App, d *downstreamtypes.Downstream, i
 	undeployArgs := operatortypes.UndeployAppArgs{
 		AppID:                a.ID,
 		AppSlug:              a.Slug,
-		ClusterID:            o.clusterID,
+		ClusterID:            d.ClusterID,
 		KubectlVersion:       kotsKinds.KotsApplication.Spec.KubectlVersion,
 		KustomizeVersion:     kotsKinds.KotsApplication.Spec.KustomizeVersion,
 		AdditionalNamespaces: kotsKinds.KotsApplication.Spec.AdditionalNamespaces,
@@ -789,6 +993,7 @@ func (o *Operator) UndeployApp(a *apptypes.App, d *downstreamtypes.Downstream, i
 		Wait:                 true,
 		ClearNamespaces:      clearNamespaces,
 		ClearPVCs:            true,
+		ServerSideApply:      os.Getenv("SERVER_SIDE_APPLY") != "",
 		IsRestore:            isRestore,
 		RestoreLabelSelector: restoreLabelSelector,
 		KotsKinds:            kotsKinds,
@@ -887,3 +1092,32 @@ func (o *Operator) renderKotsApplicationSpec(app *apptypes.App, sequence int64,
 
 	return renderedKotsAppSpec, nil
 }
+
+// renderAppArchive renders the manifests and helm charts that are deployed for a version archive
+func renderAppArchive(versionArchive string, downstreamName string, kustomizeBinPath string) (manifests []byte, v1beta1ChartsArchive []byte, v1beta2ChartsArchive []byte, err error) {
+	manifests, _, err = apparchive.GetRenderedApp(versionArchive, downstreamName, kustomizeBinPath)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "failed to get rendered app")
+	}
+
+	v1beta1ChartsArchive, _, err = apparchive.GetRenderedV1Beta1ChartsArchive(versionArchive, downstreamName, kustomizeBinPath)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "failed to get rendered charts archive")
+	}
+
+	v1beta2ChartsArchive, err = apparchive.GetV1Beta2ChartsArchive(versionArchive)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "failed to get v1beta2 charts archive")
+	}
+
+	return manifests, v1beta1ChartsArchive, v1beta2ChartsArchive, nil
+}
+
+func notifyDeployFailed(appID string, sequence int64, message string) {
+	notifications.Notify(notificationtypes.Event{
+		Type:     notificationtypes.EventTypeDeployFailed,
+		AppID:    appID,
+		Sequence: &sequence,
+		Message:  message,
+	})
+}
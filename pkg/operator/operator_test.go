@@ -116,6 +116,7 @@ spec:
 					wg.Done()
 				})
 
+				Expect(testOperator.InitCluster()).To(Succeed())
 				err := testOperator.Start()
 				Expect(err).ToNot(HaveOccurred())
 
@@ -189,6 +190,7 @@ spec:
 					wg.Done()
 				})
 
+				Expect(testOperator.InitCluster()).To(Succeed())
 				err := testOperator.Start()
 				Expect(err).ToNot(HaveOccurred())
 
@@ -645,6 +647,87 @@ spec:
 
 		})
 	})
+
+	Describe("RequestDeploy()", func() {
+		When("the current version is pending", func() {
+			var (
+				mockStore    *mock_store.MockStore
+				mockClient   *mock_client.MockClientInterface
+				testOperator *operator.Operator
+				mockCtrl     *gomock.Controller
+				clusterToken       = "cluster-token"
+				appID              = "some-app-id"
+				sequence     int64 = 1
+			)
+
+			BeforeEach(func() {
+				mockCtrl = gomock.NewController(GinkgoT())
+				mockStore = mock_store.NewMockStore(mockCtrl)
+
+				mockClient = mock_client.NewMockClientInterface(mockCtrl)
+				mockK8sClientset := fake.NewSimpleClientset()
+				testOperator = operator.Init(mockClient, mockStore, clusterToken, mockK8sClientset)
+			})
+
+			AfterEach(func() {
+				mockCtrl.Finish()
+			})
+
+			It("deploys the version requested by another replica", func() {
+				mockClient.EXPECT().Init().Return(nil)
+
+				mockStore.EXPECT().GetClusterIDFromDeployToken(clusterToken).Return("", nil)
+
+				apps := []*apptypes.App{
+					{
+						ID:   appID,
+						Slug: "some-app-slug",
+					},
+				}
+				mockStore.EXPECT().ListAppsForDownstream("").AnyTimes().Return(apps, nil)
+
+				// there is no current version until a replica marks one as current
+				var mtx sync.Mutex
+				var currentVersion *downstreamtypes.DownstreamVersion
+				mockStore.EXPECT().GetCurrentDownstreamVersion(appID, "").AnyTimes().DoAndReturn(func(appID string, clusterID string) (*downstreamtypes.DownstreamVersion, error) {
+					mtx.Lock()
+					defer mtx.Unlock()
+					return currentVersion, nil
+				})
+
+				deploying := make(chan struct{})
+				var once sync.Once
+				mockStore.EXPECT().SetDownstreamVersionStatus(appID, sequence, storetypes.VersionDeploying, "").AnyTimes().DoAndReturn(func(appID string, sequence int64, status storetypes.DownstreamVersionStatus, statusInfo string) error {
+					once.Do(func() {
+						close(deploying)
+					})
+					return fmt.Errorf("stop the deploy")
+				})
+
+				Expect(testOperator.InitCluster()).To(Succeed())
+				err := testOperator.Start()
+				Expect(err).ToNot(HaveOccurred())
+
+				// let the operator resume informers and deployments before the version is marked as current
+				time.Sleep(500 * time.Millisecond)
+
+				mtx.Lock()
+				currentVersion = &downstreamtypes.DownstreamVersion{
+					ParentSequence: sequence,
+					Status:         storetypes.VersionPending,
+				}
+				mtx.Unlock()
+
+				testOperator.RequestDeploy()
+
+				select {
+				case <-deploying:
+				case <-time.After(2 * time.Second):
+					Fail("timed out waiting for the version to be deployed")
+				}
+			})
+		})
+	})
 })
 
 func writeArchiveFiles(archiveDir string, archiveFiles map[string]string) error {
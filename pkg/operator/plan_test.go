@@ -0,0 +1,32 @@
+package operator
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	downstreamtypes "github.com/replicatedhq/kots/pkg/api/downstream/types"
+	apptypes "github.com/replicatedhq/kots/pkg/app/types"
+	mock_store "github.com/replicatedhq/kots/pkg/store/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_PlanDeployUsesAppCluster(t *testing.T) {
+	req := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mock_store.NewMockStore(ctrl)
+
+	// the cluster id is not known on replicas that are not the leader
+	o := &Operator{store: mockStore}
+
+	mockStore.EXPECT().GetApp("app-id").Return(&apptypes.App{ID: "app-id", Slug: "app-slug"}, nil)
+	mockStore.EXPECT().ListDownstreamsForApp("app-id").Return([]downstreamtypes.Downstream{{ClusterID: "cluster-id", Name: "this-cluster"}}, nil)
+	mockStore.EXPECT().GetCurrentDownstreamVersion("app-id", "cluster-id").Return(&downstreamtypes.DownstreamVersion{Sequence: 1}, nil)
+	mockStore.EXPECT().GetDownstream("cluster-id").Return(nil, errors.New("stop"))
+
+	_, err := o.PlanDeploy("app-id", 2)
+	req.ErrorContains(err, "failed to get downstream: stop")
+}
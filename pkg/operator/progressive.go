@@ -0,0 +1,149 @@
+package operator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	apptypes "github.com/replicatedhq/kots/pkg/app/types"
+	appstatetypes "github.com/replicatedhq/kots/pkg/appstate/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	storetypes "github.com/replicatedhq/kots/pkg/store/types"
+)
+
+var (
+	progressiveDeployPollInterval = 5 * time.Second
+)
+
+type progressiveDeployArgs struct {
+	appID            string
+	appSlug          string
+	sequence         int64
+	previousSequence int64
+	window           time.Duration
+}
+
+// watchProgressiveDeploy watches the app status of a newly deployed sequence for the verification window
+// and rolls back to the previously deployed sequence if the app degrades.
+func (o *Operator) watchProgressiveDeploy(args progressiveDeployArgs) {
+	logger.Infof("verifying deployment of sequence %d for app %s for %s", args.sequence, args.appSlug, args.window)
+
+	reason, err := o.verifyProgressiveDeploy(args)
+	if err != nil {
+		logger.Error(errors.Wrapf(err, "failed to verify deployment of sequence %d for app %s", args.sequence, args.appSlug))
+		return
+	}
+	if reason == "" {
+		logger.Infof("deployment of sequence %d for app %s verified", args.sequence, args.appSlug)
+		return
+	}
+
+	logger.Infof("rolling back app %s from sequence %d to sequence %d: %s", args.appSlug, args.sequence, args.previousSequence, reason)
+
+	if err := o.rollbackProgressiveDeploy(args, reason); err != nil {
+		logger.Error(errors.Wrapf(err, "failed to roll back app %s to sequence %d", args.appSlug, args.previousSequence))
+	}
+}
+
+// verifyProgressiveDeploy polls the app status until the verification window ends. It returns a non-empty reason
+// if the deployment should be rolled back, which is the case if the app degrades after having become ready,
+// or if the app is still degraded or unavailable when the window ends.
+// An empty reason is returned if the deployment is healthy, or if it was superseded by another deployment.
+func (o *Operator) verifyProgressiveDeploy(args progressiveDeployArgs) (string, error) {
+	deadline := time.Now().Add(args.window)
+	reachedReady := false
+	lastState := appstatetypes.StateMissing
+
+	for {
+		time.Sleep(progressiveDeployPollInterval)
+
+		currentSequence, err := o.store.GetCurrentParentSequence(args.appID, o.clusterID)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to get current sequence")
+		}
+		if currentSequence != args.sequence {
+			logger.Infof("not verifying sequence %d for app %s because sequence %d has been deployed since", args.sequence, args.appSlug, currentSequence)
+			return "", nil
+		}
+
+		appStatus, err := o.store.GetAppStatus(args.appID)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to get app status")
+		}
+
+		// ignore statuses reported by the informers of the previous sequence
+		if appStatus != nil && appStatus.Sequence == args.sequence {
+			lastState = appstatetypes.GetState(appStatus.ResourceStates)
+			if lastState == appstatetypes.StateReady {
+				reachedReady = true
+			} else if reachedReady && isDegradedState(lastState) {
+				return fmt.Sprintf("app became %s during the %s verification window", lastState, args.window), nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			break
+		}
+	}
+
+	if isDegradedState(lastState) {
+		return fmt.Sprintf("app was %s at the end of the %s verification window", lastState, args.window), nil
+	}
+
+	return "", nil
+}
+
+// rollbackProgressiveDeploy marks the sequence as failed and redeploys the previously deployed sequence
+func (o *Operator) rollbackProgressiveDeploy(args progressiveDeployArgs, reason string) error {
+	statusInfo := fmt.Sprintf("Automatically rolled back to sequence %d: %s", args.previousSequence, reason)
+	if err := o.store.SetDownstreamVersionStatus(args.appID, args.sequence, storetypes.VersionFailed, statusInfo); err != nil {
+		return errors.Wrap(err, "failed to update downstream status")
+	}
+
+	previousParentSequence, err := o.store.GetParentSequenceForSequence(args.appID, o.clusterID, args.previousSequence)
+	if err != nil {
+		return errors.Wrap(err, "failed to get previous parent sequence")
+	}
+
+	if err := o.store.MarkAsCurrentDownstreamVersion(args.appID, args.previousSequence); err != nil {
+		return errors.Wrap(err, "failed to mark previous sequence as current downstream version")
+	}
+
+	deployed, err := o.deployApp(args.appID, previousParentSequence, true)
+	if err != nil {
+		return errors.Wrap(err, "failed to deploy previous sequence")
+	}
+	if !deployed {
+		return errors.Errorf("failed to deploy previous sequence %d", previousParentSequence)
+	}
+
+	return nil
+}
+
+func getProgressiveDeployArgs(a *apptypes.App, sequence int64, previousSequence int64) (*progressiveDeployArgs, error) {
+	if a.DeployStrategy != apptypes.DeployStrategyProgressive {
+		return nil, nil
+	}
+
+	if previousSequence == -1 {
+		logger.Infof("not verifying deployment of sequence %d for app %s because there is no previous sequence to roll back to", sequence, a.Slug)
+		return nil, nil
+	}
+
+	window, err := a.GetVerificationWindow()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse verification window")
+	}
+
+	return &progressiveDeployArgs{
+		appID:            a.ID,
+		appSlug:          a.Slug,
+		sequence:         sequence,
+		previousSequence: previousSequence,
+		window:           window,
+	}, nil
+}
+
+func isDegradedState(state appstatetypes.State) bool {
+	return state == appstatetypes.StateDegraded || state == appstatetypes.StateUnavailable
+}
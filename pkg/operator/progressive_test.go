@@ -0,0 +1,190 @@
+package operator
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	apptypes "github.com/replicatedhq/kots/pkg/app/types"
+	appstatetypes "github.com/replicatedhq/kots/pkg/appstate/types"
+	mock_store "github.com/replicatedhq/kots/pkg/store/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_verifyProgressiveDeploy(t *testing.T) {
+	progressiveDeployPollInterval = time.Millisecond
+
+	appID := "app-id"
+	clusterID := "cluster-id"
+
+	appStatus := func(sequence int64, states ...appstatetypes.State) *appstatetypes.AppStatus {
+		resourceStates := appstatetypes.ResourceStates{}
+		for _, state := range states {
+			resourceStates = append(resourceStates, appstatetypes.ResourceState{Kind: "deployment", Name: "app", Namespace: "default", State: state})
+		}
+		return &appstatetypes.AppStatus{AppID: appID, ResourceStates: resourceStates, Sequence: sequence}
+	}
+
+	tests := []struct {
+		name            string
+		currentSequence int64
+		statuses        []*appstatetypes.AppStatus
+		wantRollback    bool
+	}{
+		{
+			name:            "app stays ready",
+			currentSequence: 1,
+			statuses: []*appstatetypes.AppStatus{
+				appStatus(1, appstatetypes.StateUpdating),
+				appStatus(1, appstatetypes.StateReady),
+			},
+			wantRollback: false,
+		},
+		{
+			name:            "app degrades after becoming ready",
+			currentSequence: 1,
+			statuses: []*appstatetypes.AppStatus{
+				appStatus(1, appstatetypes.StateReady),
+				appStatus(1, appstatetypes.StateReady, appstatetypes.StateDegraded),
+			},
+			wantRollback: true,
+		},
+		{
+			name:            "app never becomes available",
+			currentSequence: 1,
+			statuses: []*appstatetypes.AppStatus{
+				appStatus(1, appstatetypes.StateUnavailable),
+			},
+			wantRollback: true,
+		},
+		{
+			name:            "status of the previous sequence is ignored",
+			currentSequence: 1,
+			statuses: []*appstatetypes.AppStatus{
+				appStatus(0, appstatetypes.StateUnavailable),
+			},
+			wantRollback: false,
+		},
+		{
+			name:            "another sequence was deployed",
+			currentSequence: 2,
+			statuses:        []*appstatetypes.AppStatus{},
+			wantRollback:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := require.New(t)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mock_store.NewMockStore(ctrl)
+			mockStore.EXPECT().GetCurrentParentSequence(appID, clusterID).Return(tt.currentSequence, nil).AnyTimes()
+
+			calls := 0
+			mockStore.EXPECT().GetAppStatus(appID).DoAndReturn(func(appID string) (*appstatetypes.AppStatus, error) {
+				status := tt.statuses[len(tt.statuses)-1]
+				if calls < len(tt.statuses) {
+					status = tt.statuses[calls]
+				}
+				calls++
+				return status, nil
+			}).AnyTimes()
+
+			o := &Operator{store: mockStore, clusterID: clusterID}
+			reason, err := o.verifyProgressiveDeploy(progressiveDeployArgs{
+				appID:            appID,
+				appSlug:          "app-slug",
+				sequence:         1,
+				previousSequence: 0,
+				window:           20 * time.Millisecond,
+			})
+			req.NoError(err)
+
+			if tt.wantRollback {
+				req.NotEmpty(reason)
+			} else {
+				req.Empty(reason)
+			}
+		})
+	}
+}
+
+func Test_getProgressiveDeployArgs(t *testing.T) {
+	tests := []struct {
+		name             string
+		app              *apptypes.App
+		previousSequence int64
+		want             *progressiveDeployArgs
+		wantErr          bool
+	}{
+		{
+			name:             "direct deploy strategy",
+			app:              &apptypes.App{ID: "app-id", Slug: "app-slug", DeployStrategy: apptypes.DeployStrategyDirect},
+			previousSequence: 0,
+			want:             nil,
+		},
+		{
+			name:             "no previous sequence",
+			app:              &apptypes.App{ID: "app-id", Slug: "app-slug", DeployStrategy: apptypes.DeployStrategyProgressive},
+			previousSequence: -1,
+			want:             nil,
+		},
+		{
+			name:             "default verification window",
+			app:              &apptypes.App{ID: "app-id", Slug: "app-slug", DeployStrategy: apptypes.DeployStrategyProgressive},
+			previousSequence: 0,
+			want:             &progressiveDeployArgs{appID: "app-id", appSlug: "app-slug", sequence: 1, previousSequence: 0, window: 10 * time.Minute},
+		},
+		{
+			name:             "custom verification window",
+			app:              &apptypes.App{ID: "app-id", Slug: "app-slug", DeployStrategy: apptypes.DeployStrategyProgressive, VerificationWindow: "90s"},
+			previousSequence: 0,
+			want:             &progressiveDeployArgs{appID: "app-id", appSlug: "app-slug", sequence: 1, previousSequence: 0, window: 90 * time.Second},
+		},
+		{
+			name:             "invalid verification window",
+			app:              &apptypes.App{ID: "app-id", Slug: "app-slug", DeployStrategy: apptypes.DeployStrategyProgressive, VerificationWindow: "soon"},
+			previousSequence: 0,
+			wantErr:          true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := require.New(t)
+
+			got, err := getProgressiveDeployArgs(tt.app, 1, tt.previousSequence)
+			if tt.wantErr {
+				req.Error(err)
+				return
+			}
+			req.NoError(err)
+			req.Equal(tt.want, got)
+		})
+	}
+}
+
+func Test_getDeployMutex(t *testing.T) {
+	req := require.New(t)
+
+	o := &Operator{deployMtxs: map[string]*sync.Mutex{}}
+
+	// a rollback of a progressive deploy gets the mutex from a separate goroutine
+	mtxs := make([]*sync.Mutex, 10)
+	var wg sync.WaitGroup
+	for i := range mtxs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			mtxs[i] = o.getDeployMutex("app-id")
+		}(i)
+	}
+	wg.Wait()
+
+	for _, mtx := range mtxs {
+		req.Same(mtxs[0], mtx)
+	}
+	req.NotSame(mtxs[0], o.getDeployMutex("other-app-id"))
+}
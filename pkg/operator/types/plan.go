@@ -0,0 +1,59 @@
+package types
+
+const (
+	PlanCreate    = "create"
+	PlanUpdate    = "update"
+	PlanUnchanged = "unchanged"
+	PlanDelete    = "delete"
+	// PlanRecreate is used for resources with a change to an immutable field. Applying them
+	// fails unless the existing resource is deleted first.
+	PlanRecreate = "recreate"
+	// PlanUnknown is used when the dry run failed, the error is included in the planned resource
+	PlanUnknown = "unknown"
+
+	PlanInstall   = "install"
+	PlanUpgrade   = "upgrade"
+	PlanUninstall = "uninstall"
+)
+
+// DeployPlan describes the changes to the cluster that deploying a version would make
+type DeployPlan struct {
+	AppID    string `json:"appId"`
+	Sequence int64  `json:"sequence"`
+	// PreviousSequence is the currently deployed sequence, or -1 if no version has been deployed
+	PreviousSequence int64                `json:"previousSequence"`
+	Resources        []PlannedResource    `json:"resources"`
+	HelmReleases     []PlannedHelmRelease `json:"helmReleases"`
+}
+
+type PlannedResource struct {
+	Group     string `json:"group"`
+	Version   string `json:"version"`
+	Kind      string `json:"kind"`
+	Resource  string `json:"resource,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Action    string `json:"action"`
+	// Diff is a unified diff from the live resource to the resource after the update
+	Diff  string `json:"diff,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+type PlannedHelmRelease struct {
+	ReleaseName          string `json:"releaseName"`
+	Namespace            string `json:"namespace,omitempty"`
+	ChartName            string `json:"chartName"`
+	ChartVersion         string `json:"chartVersion,omitempty"`
+	PreviousChartVersion string `json:"previousChartVersion,omitempty"`
+	Action               string `json:"action"`
+}
+
+// HasErrors returns true if the outcome of applying any of the resources could not be determined
+func (p *DeployPlan) HasErrors() bool {
+	for _, r := range p.Resources {
+		if r.Action == PlanUnknown {
+			return true
+		}
+	}
+	return false
+}
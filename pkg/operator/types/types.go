@@ -41,6 +41,7 @@ type DeployAppArgs struct {
 	ClearNamespaces              []string              `json:"clear_namespaces"`
 	ClearPVCs                    bool                  `json:"clear_pvcs"`
 	AnnotateSlug                 bool                  `json:"annotate_slug"`
+	ServerSideApply              bool                  `json:"server_side_apply"`
 	IsRestore                    bool                  `json:"is_restore"`
 	RestoreLabelSelector         *metav1.LabelSelector `json:"restore_label_selector"`
 	PreviousKotsKinds            *kotsutil.KotsKinds
@@ -60,6 +61,7 @@ type UndeployAppArgs struct {
 	Wait                 bool                  `json:"wait"`
 	ClearNamespaces      []string              `json:"clear_namespaces"`
 	ClearPVCs            bool                  `json:"clear_pvcs"`
+	ServerSideApply      bool                  `json:"server_side_apply"`
 	IsRestore            bool                  `json:"is_restore"`
 	RestoreLabelSelector *metav1.LabelSelector `json:"restore_label_selector"`
 	KotsKinds            *kotsutil.KotsKinds
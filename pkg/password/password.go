@@ -1,9 +1,13 @@
 package password
 
 import (
+	"bytes"
 	"context"
+	"strconv"
 	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/pkg/errors"
 	"github.com/replicatedhq/kots/pkg/kotsadm/types"
@@ -20,16 +24,118 @@ import (
 // passwordLock - mutex to prevent multiple password changes at the same time
 var passwordLock = sync.Mutex{}
 
+const (
+	DefaultPasswordMinLength = 6
+
+	// maxPasswordHistory is the number of previous password hashes kept in the kotsadm password secret
+	maxPasswordHistory = 24
+)
+
 var (
 	ErrCurrentPasswordDoesNotMatch  = errors.New("The current password provided is incorrect.")
-	ErrNewPasswordTooShort          = errors.New("The new password must be at least 6 characters.")
 	ErrNewPasswordShouldBeDifferent = errors.New("The new password must be different from the current password.")
+	ErrPasswordReused               = errors.New("The new password must be different from the recently used passwords.")
 )
 
+// PasswordPolicy configures the rules for new admin console passwords.
+// It is read from the kotsadm-confg configmap:
+//   - password-min-length: the minimum number of characters (default 6)
+//   - password-require-uppercase, password-require-lowercase, password-require-digit, password-require-symbol: require
+//     at least one character of the class (default false)
+//   - password-history: the number of recent passwords that can't be reused (default 0)
+type PasswordPolicy struct {
+	MinLength        int
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	History          int
+}
+
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength: DefaultPasswordMinLength,
+	}
+}
+
+// GetPasswordPolicy - will read the password policy from the kotsadm config map in the namespace
+func GetPasswordPolicy(clientset kubernetes.Interface, namespace string) (PasswordPolicy, error) {
+	policy := DefaultPasswordPolicy()
+
+	configMap, err := clientset.CoreV1().ConfigMaps(namespace).Get(context.TODO(), types.KotsadmConfigMap, metav1.GetOptions{})
+	if err != nil {
+		if kuberneteserrors.IsNotFound(err) {
+			return policy, nil
+		}
+		return policy, errors.Wrap(err, "failed to get kotsadm config map")
+	}
+
+	if value, ok := configMap.Data["password-min-length"]; ok {
+		if i, err := strconv.Atoi(value); err == nil && i > 0 {
+			policy.MinLength = i
+		} else {
+			logger.Infof("ignoring invalid password-min-length %q", value)
+		}
+	}
+	if value, ok := configMap.Data["password-history"]; ok {
+		if i, err := strconv.Atoi(value); err == nil && i >= 0 {
+			policy.History = i
+		} else {
+			logger.Infof("ignoring invalid password-history %q", value)
+		}
+	}
+	policy.RequireUppercase, _ = strconv.ParseBool(configMap.Data["password-require-uppercase"])
+	policy.RequireLowercase, _ = strconv.ParseBool(configMap.Data["password-require-lowercase"])
+	policy.RequireDigit, _ = strconv.ParseBool(configMap.Data["password-require-digit"])
+	policy.RequireSymbol, _ = strconv.ParseBool(configMap.Data["password-require-symbol"])
+
+	if policy.History > maxPasswordHistory {
+		policy.History = maxPasswordHistory
+	}
+
+	return policy, nil
+}
+
+// Validate - will validate the length and complexity of the password
+func (p PasswordPolicy) Validate(password string) error {
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return errors.Errorf("The new password must be at least %d characters.", p.MinLength)
+	}
+
+	var hasUppercase, hasLowercase, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUppercase = true
+		case unicode.IsLower(r):
+			hasLowercase = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	if p.RequireUppercase && !hasUppercase {
+		return errors.New("The new password must contain an uppercase letter.")
+	}
+	if p.RequireLowercase && !hasLowercase {
+		return errors.New("The new password must contain a lowercase letter.")
+	}
+	if p.RequireDigit && !hasDigit {
+		return errors.New("The new password must contain a digit.")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		return errors.New("The new password must contain a symbol.")
+	}
+
+	return nil
+}
+
 // ValidatePasswordInput - will validate length and complexity of new password and check if it is different from current password
-func ValidatePasswordInput(currentPassword string, newPassword string) error {
-	if len(newPassword) < 6 {
-		return ErrNewPasswordTooShort
+func ValidatePasswordInput(policy PasswordPolicy, currentPassword string, newPassword string) error {
+	if err := policy.Validate(newPassword); err != nil {
+		return err
 	}
 
 	if newPassword == currentPassword {
@@ -64,6 +170,15 @@ func ChangePassword(clientset *kubernetes.Clientset, namespace string, newPasswo
 	passwordLock.Lock()
 	defer passwordLock.Unlock()
 
+	policy, err := GetPasswordPolicy(clientset, namespace)
+	if err != nil {
+		return errors.Wrap(err, "failed to get password policy")
+	}
+
+	if err := checkPasswordHistory(clientset, namespace, policy.History, newPassword); err != nil {
+		return err
+	}
+
 	shaBytes, err := bcrypt.GenerateFromPassword([]byte(newPassword), 10)
 	if err != nil {
 		return errors.Wrap(err, "failed to generate new encrypted password")
@@ -76,6 +191,87 @@ func ChangePassword(clientset *kubernetes.Clientset, namespace string, newPasswo
 	return nil
 }
 
+// Unlock - will clear the failed logins in the kotsadm secret without changing the password
+func Unlock(clientset kubernetes.Interface, namespace string) error {
+	passwordLock.Lock()
+	defer passwordLock.Unlock()
+
+	for i := 0; ; i++ {
+		secret, err := clientset.CoreV1().Secrets(namespace).Get(context.TODO(), util.PasswordSecretName, metav1.GetOptions{})
+		if err != nil {
+			if kuberneteserrors.IsNotFound(err) {
+				return nil
+			}
+			return errors.Wrap(err, "failed to get secret")
+		}
+
+		delete(secret.Labels, "numAttempts")
+		delete(secret.Labels, "lastFailure")
+		delete(secret.Annotations, util.FailedLoginsAnnotation)
+
+		_, err = clientset.CoreV1().Secrets(namespace).Update(context.TODO(), secret, metav1.UpdateOptions{})
+		if err == nil {
+			return nil
+		}
+		if !kuberneteserrors.IsConflict(err) || i > 5 {
+			return errors.Wrap(err, "failed to update secret")
+		}
+	}
+}
+
+// checkPasswordHistory - will return ErrPasswordReused if the password matches the current or one of the recent passwords
+func checkPasswordHistory(clientset kubernetes.Interface, namespace string, history int, newPassword string) error {
+	if history <= 0 {
+		return nil
+	}
+
+	secret, err := clientset.CoreV1().Secrets(namespace).Get(context.TODO(), util.PasswordSecretName, metav1.GetOptions{})
+	if err != nil {
+		if kuberneteserrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrap(err, "failed to get secret")
+	}
+
+	hashes := [][]byte{}
+	if len(secret.Data["passwordBcrypt"]) > 0 {
+		hashes = append(hashes, secret.Data["passwordBcrypt"])
+	}
+	hashes = append(hashes, passwordHistory(secret)...)
+
+	return CheckPasswordReuse(hashes, history, newPassword)
+}
+
+// CheckPasswordReuse - will return ErrPasswordReused if the password matches one of the first history hashes.
+// The hashes are the current password hash followed by the previous ones, most recent first.
+func CheckPasswordReuse(hashes [][]byte, history int, newPassword string) error {
+	if history <= 0 {
+		return nil
+	}
+	if len(hashes) > history {
+		hashes = hashes[:history]
+	}
+
+	for _, hash := range hashes {
+		if err := bcrypt.CompareHashAndPassword(hash, []byte(newPassword)); err == nil {
+			return ErrPasswordReused
+		}
+	}
+
+	return nil
+}
+
+// passwordHistory - returns the previous password hashes in the secret, most recent first
+func passwordHistory(secret *corev1.Secret) [][]byte {
+	hashes := [][]byte{}
+	for _, hash := range bytes.Split(secret.Data["passwordHistory"], []byte("\n")) {
+		if len(hash) > 0 {
+			hashes = append(hashes, hash)
+		}
+	}
+	return hashes
+}
+
 // setSharedPasswordBcrypt - set the shared password bcrypt hash in the kotsadm secret
 func setSharedPasswordBcrypt(clientset kubernetes.Interface, namespace string, bcryptPassword []byte) error {
 	secretData := map[string][]byte{
@@ -106,10 +302,22 @@ func setSharedPasswordBcrypt(clientset kubernetes.Interface, namespace string, b
 			return errors.Wrap(err, "failed to create secret")
 		}
 	} else {
+		history := passwordHistory(existingPasswordSecret)
+		if len(existingPasswordSecret.Data["passwordBcrypt"]) > 0 {
+			history = append([][]byte{existingPasswordSecret.Data["passwordBcrypt"]}, history...)
+		}
+		if len(history) > maxPasswordHistory {
+			history = history[:maxPasswordHistory]
+		}
+		if len(history) > 0 {
+			secretData["passwordHistory"] = bytes.Join(history, []byte("\n"))
+		}
+
 		existingPasswordSecret.Data = secretData
 
 		delete(existingPasswordSecret.Labels, "numAttempts")
 		delete(existingPasswordSecret.Labels, "lastFailure")
+		delete(existingPasswordSecret.Annotations, util.FailedLoginsAnnotation)
 
 		_, err := clientset.CoreV1().Secrets(namespace).Update(context.TODO(), existingPasswordSecret, metav1.UpdateOptions{})
 		if err != nil {
@@ -1,14 +1,18 @@
 package password
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/replicatedhq/kots/pkg/kotsadm/types"
 	mock_store "github.com/replicatedhq/kots/pkg/store/mock"
+	"github.com/replicatedhq/kots/pkg/util"
 	"golang.org/x/crypto/bcrypt"
 	corev1 "k8s.io/api/core/v1"
 	kuberneteserrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
@@ -85,7 +89,7 @@ func TestValidateNewPassword(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := ValidatePasswordInput(tt.args.currentPassword, tt.args.newPassword); (err != nil) != tt.wantErr {
+			if err := ValidatePasswordInput(DefaultPasswordPolicy(), tt.args.currentPassword, tt.args.newPassword); (err != nil) != tt.wantErr {
 				t.Errorf("ValidatePasswordInput() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -307,3 +311,247 @@ func Test_setSharedPasswordBcrypt(t *testing.T) {
 		})
 	}
 }
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	strictPolicy := PasswordPolicy{
+		MinLength:        8,
+		RequireUppercase: true,
+		RequireLowercase: true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+	}
+	tests := []struct {
+		name     string
+		policy   PasswordPolicy
+		password string
+		wantErr  bool
+	}{
+		{
+			name:     "default policy accepts 6 characters",
+			policy:   DefaultPasswordPolicy(),
+			password: "abcdef",
+		},
+		{
+			name:     "min length counts characters, not bytes",
+			policy:   PasswordPolicy{MinLength: 4},
+			password: "ääé",
+			wantErr:  true,
+		},
+		{
+			name:     "strict policy accepts a complex password",
+			policy:   strictPolicy,
+			password: "Abcdef1!",
+		},
+		{
+			name:     "strict policy requires an uppercase letter",
+			policy:   strictPolicy,
+			password: "abcdef1!",
+			wantErr:  true,
+		},
+		{
+			name:     "strict policy requires a lowercase letter",
+			policy:   strictPolicy,
+			password: "ABCDEF1!",
+			wantErr:  true,
+		},
+		{
+			name:     "strict policy requires a digit",
+			policy:   strictPolicy,
+			password: "Abcdefg!",
+			wantErr:  true,
+		},
+		{
+			name:     "strict policy requires a symbol",
+			policy:   strictPolicy,
+			password: "Abcdefg1",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(tt.password); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetPasswordPolicy(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      types.KotsadmConfigMap,
+			Namespace: "test",
+		},
+		Data: map[string]string{
+			"password-min-length":        "12",
+			"password-require-uppercase": "true",
+			"password-require-digit":     "true",
+			"password-history":           "100",
+		},
+	})
+
+	policy, err := GetPasswordPolicy(clientset, "test")
+	if err != nil {
+		t.Fatalf("GetPasswordPolicy() error = %v", err)
+	}
+
+	want := PasswordPolicy{
+		MinLength:        12,
+		RequireUppercase: true,
+		RequireDigit:     true,
+		History:          maxPasswordHistory,
+	}
+	if policy != want {
+		t.Errorf("GetPasswordPolicy() = %+v, want %+v", policy, want)
+	}
+
+	policy, err = GetPasswordPolicy(fake.NewSimpleClientset(), "test")
+	if err != nil {
+		t.Fatalf("GetPasswordPolicy() error = %v", err)
+	}
+	if policy != DefaultPasswordPolicy() {
+		t.Errorf("GetPasswordPolicy() = %+v, want the default policy", policy)
+	}
+}
+
+func Test_checkPasswordHistory(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+
+	for _, password := range []string{"password1", "password2", "password3"} {
+		shaBytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := setSharedPasswordBcrypt(clientset, "test", shaBytes); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		history  int
+		password string
+		wantErr  error
+	}{
+		{
+			name:     "no history allows reuse",
+			history:  0,
+			password: "password1",
+		},
+		{
+			name:     "current password is in the history",
+			history:  1,
+			password: "password3",
+			wantErr:  ErrPasswordReused,
+		},
+		{
+			name:     "older password outside of the history",
+			history:  2,
+			password: "password1",
+		},
+		{
+			name:     "older password in the history",
+			history:  3,
+			password: "password1",
+			wantErr:  ErrPasswordReused,
+		},
+		{
+			name:     "new password",
+			history:  3,
+			password: "password4",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkPasswordHistory(clientset, "test", tt.history, tt.password); err != tt.wantErr {
+				t.Errorf("checkPasswordHistory() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckPasswordReuse(t *testing.T) {
+	hashes := [][]byte{}
+	for _, password := range []string{"password3", "password2", "password1"} {
+		shaBytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, shaBytes)
+	}
+
+	tests := []struct {
+		name     string
+		hashes   [][]byte
+		history  int
+		password string
+		wantErr  error
+	}{
+		{
+			name:     "no history allows reuse",
+			hashes:   hashes,
+			history:  0,
+			password: "password3",
+		},
+		{
+			name:     "no hashes",
+			hashes:   [][]byte{},
+			history:  3,
+			password: "password3",
+		},
+		{
+			name:     "older password outside of the history",
+			hashes:   hashes,
+			history:  2,
+			password: "password1",
+		},
+		{
+			name:     "older password in the history",
+			hashes:   hashes,
+			history:  3,
+			password: "password1",
+			wantErr:  ErrPasswordReused,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckPasswordReuse(tt.hashes, tt.history, tt.password); err != tt.wantErr {
+				t.Errorf("CheckPasswordReuse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUnlock(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      util.PasswordSecretName,
+			Namespace: "test",
+			Labels: map[string]string{
+				"numAttempts": "11",
+				"lastFailure": "1700000000",
+			},
+			Annotations: map[string]string{
+				util.FailedLoginsAnnotation: "1700000000",
+			},
+		},
+		Data: map[string][]byte{
+			"passwordBcrypt": []byte("hash"),
+		},
+	})
+
+	if err := Unlock(clientset, "test"); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+
+	secret, err := clientset.CoreV1().Secrets("test").Get(context.TODO(), util.PasswordSecretName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secret.Labels) != 0 || len(secret.Annotations) != 0 {
+		t.Errorf("Unlock() left labels %v and annotations %v", secret.Labels, secret.Annotations)
+	}
+	if string(secret.Data["passwordBcrypt"]) != "hash" {
+		t.Errorf("Unlock() changed the password")
+	}
+}
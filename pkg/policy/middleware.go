@@ -6,6 +6,8 @@ import (
 	"net/http"
 
 	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/audit"
+	audittypes "github.com/replicatedhq/kots/pkg/audit/types"
 	"github.com/replicatedhq/kots/pkg/logger"
 	"github.com/replicatedhq/kots/pkg/rbac"
 	rbactypes "github.com/replicatedhq/kots/pkg/rbac/types"
@@ -49,17 +51,26 @@ func (m *Middleware) EnforceAccess(p *Policy, handler http.HandlerFunc) http.Han
 			return
 		}
 
+		var resource string
 		if sess.HasRBAC { // handle pre-rbac sessions
-			action, resource, err := p.execute(r, m.KOTSStore)
+			action, executedResource, err := p.execute(r, m.KOTSStore)
 			if err != nil {
 				logger.Error(errors.Wrapf(err, "failed to execute policy template %q", p.resource))
 				w.WriteHeader(http.StatusInternalServerError)
 				return
 			}
+			resource = executedResource
 
 			rbacErr := NewRBACError(resource)
 
-			allow, err := rbac.CheckAccess(r.Context(), m.Roles, action, resource, sess.Roles)
+			roles, err := rbac.GetRoles(m.KOTSStore, m.Roles, sess.Roles)
+			if err != nil {
+				logger.Error(errors.Wrap(err, "failed to get roles"))
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			allow, err := rbac.CheckAccess(r.Context(), roles, action, resource, sess.Roles)
 			if err != nil {
 				logger.Error(errors.Wrapf(err, "failed to check access to resource %q", resource))
 				w.WriteHeader(http.StatusInternalServerError)
@@ -67,11 +78,28 @@ func (m *Middleware) EnforceAccess(p *Policy, handler http.HandlerFunc) http.Han
 			}
 			if !allow {
 				logger.Error(rbacErr.Abort(w))
+				if p.action == ActionWrite {
+					event := audit.NewEvent(r, sess, audit.RouteName(r), resource, http.StatusForbidden)
+					event.Outcome = audittypes.OutcomeDenied
+					audit.Record(event)
+				}
 				return
 			}
 		}
 
-		handler(w, r)
+		// only mutating actions are audited
+		if p.action != ActionWrite {
+			handler(w, r)
+			return
+		}
+
+		rr := audit.NewResponseRecorder(w)
+		handler(rr, r)
+
+		if resource == "" {
+			resource = p.resource
+		}
+		audit.Record(audit.NewEvent(r, sess, audit.RouteName(r), resource, rr.StatusCode))
 	}
 }
 
@@ -57,6 +57,13 @@ var (
 	PrometheussettingsWrite = Must(NewPolicy(ActionWrite, "prometheussettings."))
 )
 
+// Notifications
+
+var (
+	NotificationsRead  = Must(NewPolicy(ActionRead, "notifications."))
+	NotificationsWrite = Must(NewPolicy(ActionWrite, "notifications."))
+)
+
 // Password change
 
 var (
@@ -70,6 +77,33 @@ var (
 	IdentityServiceRead  = Must(NewPolicy(ActionRead, "identityservice."))
 )
 
+// RBAC roles
+
+var (
+	RBACRolesRead  = Must(NewPolicy(ActionRead, "rbacroles."))
+	RBACRolesWrite = Must(NewPolicy(ActionWrite, "rbacroles."))
+)
+
+// API tokens
+
+var (
+	APITokensRead  = Must(NewPolicy(ActionRead, "apitokens."))
+	APITokensWrite = Must(NewPolicy(ActionWrite, "apitokens."))
+)
+
+// Local users
+
+var (
+	LocalUsersRead  = Must(NewPolicy(ActionRead, "localusers."))
+	LocalUsersWrite = Must(NewPolicy(ActionWrite, "localusers."))
+)
+
+// Audit log
+
+var (
+	AuditRead = Must(NewPolicy(ActionRead, "audit."))
+)
+
 // App Identity Service
 var (
 	AppIdentityServiceWrite = Must(NewPolicy(ActionWrite, "app.{{.appSlug}}.identityservice."))
@@ -135,6 +169,7 @@ var (
 var (
 	AppDownstreamRead         = Must(NewPolicy(ActionRead, "app.{{.appSlug}}.downstream."))
 	AppDownstreamWrite        = Must(NewPolicy(ActionWrite, "app.{{.appSlug}}.downstream."))
+	AppDownstreamDeploy       = Must(NewPolicy(ActionWrite, "app.{{.appSlug}}.downstream.deploy."))
 	AppDownstreamLogsRead     = Must(NewPolicy(ActionRead, "app.{{.appSlug}}.downstream.logs."))
 	AppDownstreamFiletreeRead = Must(NewPolicy(ActionRead, "app.{{.appSlug}}.downstream.filetree."))
 )
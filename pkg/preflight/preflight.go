@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/pkg/errors"
 	apptypes "github.com/replicatedhq/kots/pkg/app/types"
@@ -14,6 +15,9 @@ import (
 	kotstypes "github.com/replicatedhq/kots/pkg/kotsadm/types"
 	"github.com/replicatedhq/kots/pkg/kotsutil"
 	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/metrics"
+	"github.com/replicatedhq/kots/pkg/notifications"
+	notificationtypes "github.com/replicatedhq/kots/pkg/notifications/types"
 	"github.com/replicatedhq/kots/pkg/preflight/types"
 	"github.com/replicatedhq/kots/pkg/registry"
 	registrytypes "github.com/replicatedhq/kots/pkg/registry/types"
@@ -22,6 +26,7 @@ import (
 	"github.com/replicatedhq/kots/pkg/reporting"
 	"github.com/replicatedhq/kots/pkg/store"
 	storetypes "github.com/replicatedhq/kots/pkg/store/types"
+	"github.com/replicatedhq/kots/pkg/tracing"
 	"github.com/replicatedhq/kots/pkg/util"
 	"github.com/replicatedhq/kots/pkg/version"
 	"github.com/replicatedhq/kotskinds/client/kotsclientset/scheme"
@@ -30,6 +35,7 @@ import (
 	troubleshootv1beta2 "github.com/replicatedhq/troubleshoot/pkg/apis/troubleshoot/v1beta2"
 	troubleshootcollect "github.com/replicatedhq/troubleshoot/pkg/collect"
 	troubleshootpreflight "github.com/replicatedhq/troubleshoot/pkg/preflight"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v2"
 	corev1 "k8s.io/api/core/v1"
@@ -44,6 +50,11 @@ const (
 )
 
 func Run(appID string, appSlug string, sequence int64, isAirgap bool, archiveDir string) error {
+	return RunContext(context.Background(), appID, appSlug, sequence, isAirgap, archiveDir)
+}
+
+// RunContext is like Run, but the preflight checks that run in the background continue the trace in ctx
+func RunContext(ctx context.Context, appID string, appSlug string, sequence int64, isAirgap bool, archiveDir string) error {
 	renderedKotsKinds, err := kotsutil.LoadKotsKindsFromPath(filepath.Join(archiveDir, "upstream"))
 	if err != nil {
 		return errors.Wrap(err, "failed to load rendered kots kinds")
@@ -160,38 +171,52 @@ func Run(appID string, appSlug string, sequence int64, isAirgap bool, archiveDir
 		preflight.Spec.Collectors = collectors
 
 		go func() {
-			logger.Info("preflight checks beginning")
+			ctx, span := tracing.StartSpan(tracing.Detach(ctx), "preflight.execute", tracing.AppAttributes(appID, sequence)...)
+			log := logger.WithContext(ctx)
+
+			log.Info("preflight checks beginning")
+			startTime := time.Now()
 			uploadPreflightResults, err := execute(appID, sequence, preflight, ignoreRBAC)
 			if err != nil {
-				logger.Error(errors.Wrap(err, "failed to run preflight checks"))
+				tracing.EndSpan(span, err)
+				metrics.ObservePreflight(appID, "error", time.Since(startTime))
+				log.Error(errors.Wrap(err, "failed to run preflight checks"))
+				notifyPreflightFailed(appID, appSlug, sequence, fmt.Sprintf("Failed to run preflight checks for sequence %d: %v", sequence, err))
 				return
 			}
 
+			span.SetAttributes(attribute.String("state", GetPreflightState(uploadPreflightResults)))
+			tracing.EndSpan(span, nil)
+			metrics.ObservePreflight(appID, GetPreflightState(uploadPreflightResults), time.Since(startTime))
+
 			// Log the preflight results if there are any warnings or errors
 			// The app may not get installed so we need to see this info for debugging
+			if GetPreflightState(uploadPreflightResults) == "fail" {
+				notifyPreflightFailed(appID, appSlug, sequence, fmt.Sprintf("Preflight checks failed for sequence %d", sequence))
+			}
 			if GetPreflightState(uploadPreflightResults) != "pass" {
-				logger.Warnf("Preflight checks completed with warnings or errors. The application will not get deployed")
+				log.Warnf("Preflight checks completed with warnings or errors. The application will not get deployed")
 				for _, result := range uploadPreflightResults.Results {
 					if result == nil {
 						continue
 					}
-					logger.Infof("preflight state=%s title=%q message=%q", GetPreflightCheckState(result), result.Title, result.Message)
+					log.Infof("preflight state=%s title=%q message=%q", GetPreflightCheckState(result), result.Title, result.Message)
 				}
 			} else {
-				logger.Info("preflight checks completed")
+				log.Info("preflight checks completed")
 			}
 
 			go func() {
 				err := reporting.GetReporter().SubmitAppInfo(appID) // send app and preflight info when preflights finish
 				if err != nil {
-					logger.Debugf("failed to submit app info: %v", err)
+					log.Debugf("failed to submit app info: %v", err)
 				}
 			}()
 
 			// status could've changed while preflights were running
 			status, err := store.GetStore().GetDownstreamVersionStatus(appID, sequence)
 			if err != nil {
-				logger.Error(errors.Wrapf(err, "failed to check downstream version %d status", sequence))
+				log.Error(errors.Wrapf(err, "failed to check downstream version %d status", sequence))
 				return
 			}
 			if status == storetypes.VersionDeployed || status == storetypes.VersionDeploying || status == storetypes.VersionFailed {
@@ -200,14 +225,14 @@ func Run(appID string, appSlug string, sequence int64, isAirgap bool, archiveDir
 
 			isDeployed, err := maybeDeployFirstVersion(appID, sequence, uploadPreflightResults)
 			if err != nil {
-				logger.Error(errors.Wrap(err, "failed to deploy first version"))
+				log.Error(errors.Wrap(err, "failed to deploy first version"))
 				return
 			}
 
 			// preflight reporting
 			if isDeployed {
 				if err := reporting.WaitAndReportPreflightChecks(appID, sequence, false, false); err != nil {
-					logger.Debugf("failed to send preflights data to replicated app: %v", err)
+					log.Debugf("failed to send preflights data to replicated app: %v", err)
 					return
 				}
 			}
@@ -249,6 +274,16 @@ func GetPreflightCheckState(p *troubleshootpreflight.UploadPreflightResult) stri
 	return "unknown"
 }
 
+func notifyPreflightFailed(appID string, appSlug string, sequence int64, message string) {
+	notifications.Notify(notificationtypes.Event{
+		Type:     notificationtypes.EventTypePreflightFailed,
+		AppID:    appID,
+		AppSlug:  appSlug,
+		Sequence: &sequence,
+		Message:  message,
+	})
+}
+
 // maybeDeployFirstVersion will deploy the first version if preflight checks pass
 func maybeDeployFirstVersion(appID string, sequence int64, preflightResults *types.PreflightResults) (bool, error) {
 	if sequence != 0 {
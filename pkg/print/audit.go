@@ -0,0 +1,41 @@
+package print
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	audittypes "github.com/replicatedhq/kots/pkg/audit/types"
+)
+
+func AuditEvents(events []audittypes.Event, format string) {
+	switch format {
+	case "json":
+		printAuditEventsJSON(events)
+	default:
+		printAuditEventsTable(events)
+	}
+}
+
+func printAuditEventsJSON(events []audittypes.Event) {
+	str, _ := json.MarshalIndent(events, "", "    ")
+	fmt.Println(string(str))
+}
+
+func printAuditEventsTable(events []audittypes.Event) {
+	w := NewTabWriter()
+	defer w.Flush()
+
+	fmtColumns := "%s\t%s\t%s\t%s\t%s\t%s\n"
+	fmt.Fprintf(w, fmtColumns, "TIME", "USER", "ACTION", "RESOURCE", "OUTCOME", "DETAILS")
+	for _, event := range events {
+		details := []string{}
+		for key, val := range event.Details {
+			details = append(details, fmt.Sprintf("%s=%s", key, val))
+		}
+		sort.Strings(details)
+		fmt.Fprintf(w, fmtColumns, event.CreatedAt.Format(time.RFC3339), event.Actor.UserID, event.Action, event.Resource, event.Outcome, strings.Join(details, ","))
+	}
+}
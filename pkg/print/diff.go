@@ -0,0 +1,72 @@
+package print
+
+import (
+	"encoding/json"
+	"fmt"
+
+	apitypes "github.com/replicatedhq/kots/pkg/api/handlers/types"
+)
+
+func VersionsDiff(diff *apitypes.AppVersionsDiffResponse, format string, summary bool) {
+	switch format {
+	case "json":
+		printVersionsDiffJSON(diff)
+	default:
+		printVersionsDiffTable(diff, summary)
+	}
+}
+
+func printVersionsDiffJSON(diff *apitypes.AppVersionsDiffResponse) {
+	str, _ := json.MarshalIndent(diff, "", "    ")
+	fmt.Println(string(str))
+}
+
+func printVersionsDiffTable(diff *apitypes.AppVersionsDiffResponse, summary bool) {
+	if !diff.HasChanges() {
+		fmt.Printf("No changes between sequence %d and sequence %d\n", diff.FromSequence, diff.ToSequence)
+		return
+	}
+
+	if len(diff.Resources) > 0 {
+		w := NewTabWriter()
+		fmtColumns := "%s\t%s\t%s\t%s\t%s\n"
+		fmt.Fprintf(w, fmtColumns, "CHANGE", "KIND", "NAMESPACE", "NAME", "CHART")
+		for _, r := range diff.Resources {
+			kind, name := r.Kind, r.Name
+			if kind == "" {
+				// not a kubernetes resource, so the file identifies it
+				name = r.File
+			}
+			fmt.Fprintf(w, fmtColumns, r.Change, kind, r.Namespace, name, r.Chart)
+		}
+		w.Flush()
+	}
+
+	if len(diff.HelmValues) > 0 {
+		if len(diff.Resources) > 0 {
+			fmt.Println()
+		}
+		w := NewTabWriter()
+		fmtColumns := "%s\t%s\n"
+		fmt.Fprintf(w, fmtColumns, "CHANGE", "CHART VALUES")
+		for _, v := range diff.HelmValues {
+			fmt.Fprintf(w, fmtColumns, v.Change, v.Chart)
+		}
+		w.Flush()
+	}
+
+	if summary {
+		return
+	}
+
+	for _, r := range diff.Resources {
+		if r.Diff != "" {
+			fmt.Printf("\n%s", r.Diff)
+		}
+	}
+	for _, v := range diff.HelmValues {
+		if v.Diff != "" {
+			fmt.Printf("\n%s", v.Diff)
+		}
+	}
+}
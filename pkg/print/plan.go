@@ -0,0 +1,87 @@
+package print
+
+import (
+	"encoding/json"
+	"fmt"
+
+	apitypes "github.com/replicatedhq/kots/pkg/api/handlers/types"
+	operatortypes "github.com/replicatedhq/kots/pkg/operator/types"
+)
+
+func DeployPlan(response *apitypes.AppVersionDeployPlanResponse, format string, summary bool) {
+	switch format {
+	case "json":
+		printDeployPlanJSON(response)
+	default:
+		printDeployPlanTable(response.Plan, summary)
+	}
+}
+
+func printDeployPlanJSON(response *apitypes.AppVersionDeployPlanResponse) {
+	str, _ := json.MarshalIndent(response, "", "    ")
+	fmt.Println(string(str))
+}
+
+func printDeployPlanTable(plan *operatortypes.DeployPlan, summary bool) {
+	if plan.PreviousSequence == -1 {
+		fmt.Printf("Plan to deploy sequence %d, no version is currently deployed\n\n", plan.Sequence)
+	} else {
+		fmt.Printf("Plan to deploy sequence %d, currently deployed sequence is %d\n\n", plan.Sequence, plan.PreviousSequence)
+	}
+
+	changed := []operatortypes.PlannedResource{}
+	unchanged := 0
+	for _, r := range plan.Resources {
+		if r.Action == operatortypes.PlanUnchanged {
+			unchanged++
+			continue
+		}
+		changed = append(changed, r)
+	}
+
+	if len(changed) == 0 && len(plan.HelmReleases) == 0 {
+		fmt.Printf("No changes, %d resources are unchanged\n", unchanged)
+		return
+	}
+
+	if len(changed) > 0 {
+		w := NewTabWriter()
+		fmtColumns := "%s\t%s\t%s\t%s\n"
+		fmt.Fprintf(w, fmtColumns, "ACTION", "KIND", "NAMESPACE", "NAME")
+		for _, r := range changed {
+			fmt.Fprintf(w, fmtColumns, r.Action, r.Kind, r.Namespace, r.Name)
+		}
+		w.Flush()
+	}
+
+	if len(plan.HelmReleases) > 0 {
+		if len(changed) > 0 {
+			fmt.Println()
+		}
+		w := NewTabWriter()
+		fmtColumns := "%s\t%s\t%s\t%s\t%s\t%s\n"
+		fmt.Fprintf(w, fmtColumns, "ACTION", "RELEASE", "NAMESPACE", "CHART", "CURRENT VERSION", "NEW VERSION")
+		for _, r := range plan.HelmReleases {
+			fmt.Fprintf(w, fmtColumns, r.Action, r.ReleaseName, r.Namespace, r.ChartName, r.PreviousChartVersion, r.ChartVersion)
+		}
+		w.Flush()
+	}
+
+	fmt.Printf("\n%d resources are unchanged\n", unchanged)
+
+	for _, r := range changed {
+		if r.Error != "" {
+			fmt.Printf("\n%s %s/%s: %s\n", r.Kind, r.Namespace, r.Name, r.Error)
+		}
+	}
+
+	if summary {
+		return
+	}
+
+	for _, r := range changed {
+		if r.Diff != "" {
+			fmt.Printf("\n# %s %s/%s\n%s", r.Kind, r.Namespace, r.Name, r.Diff)
+		}
+	}
+}
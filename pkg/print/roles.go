@@ -0,0 +1,42 @@
+package print
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/replicatedhq/kots/pkg/api/handlers/types"
+)
+
+func Roles(roles []types.RBACRole, format string) {
+	switch format {
+	case "json":
+		printRolesJSON(roles)
+	default:
+		printRolesTable(roles)
+	}
+}
+
+func printRolesJSON(roles []types.RBACRole) {
+	str, _ := json.MarshalIndent(roles, "", "    ")
+	fmt.Println(string(str))
+}
+
+func printRolesTable(roles []types.RBACRole) {
+	w := NewTabWriter()
+	defer w.Flush()
+
+	fmtColumns := "%s\t%s\t%s\t%s\t%s\n"
+	fmt.Fprintf(w, fmtColumns, "ID", "NAME", "DEFAULT", "ALLOW", "DENY")
+	for _, role := range roles {
+		allow := []string{}
+		for _, p := range role.Allow {
+			allow = append(allow, fmt.Sprintf("%s:%s", p.Action, p.Resource))
+		}
+		deny := []string{}
+		for _, p := range role.Deny {
+			deny = append(deny, fmt.Sprintf("%s:%s", p.Action, p.Resource))
+		}
+		fmt.Fprintf(w, fmtColumns, role.ID, role.Name, fmt.Sprintf("%t", role.IsDefault), strings.Join(allow, ","), strings.Join(deny, ","))
+	}
+}
@@ -0,0 +1,43 @@
+package print
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	usertypes "github.com/replicatedhq/kots/pkg/user/types"
+)
+
+func LocalUsers(users []usertypes.LocalUser, format string) {
+	switch format {
+	case "json":
+		printLocalUsersJSON(users)
+	default:
+		printLocalUsersTable(users)
+	}
+}
+
+func printLocalUsersJSON(users []usertypes.LocalUser) {
+	str, _ := json.MarshalIndent(users, "", "    ")
+	fmt.Println(string(str))
+}
+
+func printLocalUsersTable(users []usertypes.LocalUser) {
+	w := NewTabWriter()
+	defer w.Flush()
+
+	fmtColumns := "%s\t%s\t%s\t%s\t%s\t%s\n"
+	fmt.Fprintf(w, fmtColumns, "USERNAME", "ROLES", "STATUS", "CREATED BY", "CREATED", "LAST LOGIN")
+	for _, user := range users {
+		status := "enabled"
+		if user.IsDisabled {
+			status = "disabled"
+		}
+		lastLogin := ""
+		if user.LastLoginAt != nil {
+			lastLogin = user.LastLoginAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, fmtColumns, user.Username, strings.Join(user.Roles, ","), status, user.CreatedBy, user.CreatedAt.Format(time.RFC3339), lastLogin)
+	}
+}
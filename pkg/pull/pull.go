@@ -2,6 +2,7 @@ package pull
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -26,10 +27,12 @@ import (
 	registrytypes "github.com/replicatedhq/kots/pkg/registry/types"
 	"github.com/replicatedhq/kots/pkg/rendered"
 	"github.com/replicatedhq/kots/pkg/replicatedapp"
+	"github.com/replicatedhq/kots/pkg/tracing"
 	"github.com/replicatedhq/kots/pkg/upstream"
 	upstreamtypes "github.com/replicatedhq/kots/pkg/upstream/types"
 	"github.com/replicatedhq/kots/pkg/util"
 	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	"go.opentelemetry.io/otel/attribute"
 	k8sjson "k8s.io/apimachinery/pkg/runtime/serializer/json"
 	"k8s.io/client-go/kubernetes/scheme"
 )
@@ -98,6 +101,34 @@ func PullApplicationMetadata(upstreamURI string, versionLabel string) (*replicat
 // Pull will download the application specified in upstreamURI using the options
 // specified in pullOptions. It returns the directory that the app was pulled to
 func Pull(upstreamURI string, pullOptions PullOptions) (string, error) {
+	return PullContext(context.Background(), upstreamURI, pullOptions)
+}
+
+// PullContext is like Pull, but records each stage of the pull as a span of the trace in ctx
+func PullContext(ctx context.Context, upstreamURI string, pullOptions PullOptions) (string, error) {
+	ctx, span := tracing.StartSpan(ctx, "pull", tracing.AppAttributes(pullOptions.AppID, pullOptions.AppSequence)...)
+	span.SetAttributes(attribute.String("upstream_scheme", upstreamScheme(upstreamURI)))
+
+	renderDir, err := pullApp(ctx, upstreamURI, pullOptions)
+	if err == ErrConfigNeeded {
+		tracing.EndSpan(span, nil)
+	} else {
+		tracing.EndSpan(span, err)
+	}
+
+	return renderDir, err
+}
+
+// upstreamScheme returns the scheme of the upstream uri without any credentials that may be in it
+func upstreamScheme(upstreamURI string) string {
+	u, err := url.Parse(upstreamURI)
+	if err != nil {
+		return ""
+	}
+	return u.Scheme
+}
+
+func pullApp(ctx context.Context, upstreamURI string, pullOptions PullOptions) (string, error) {
 	log := logger.NewCLILogger(os.Stdout)
 
 	if pullOptions.Silent {
@@ -261,7 +292,9 @@ func Pull(upstreamURI string, pullOptions PullOptions) (string, error) {
 
 	log.ActionWithSpinner("Pulling upstream")
 	io.WriteString(pullOptions.ReportWriter, "Pulling upstream\n")
+	_, fetchSpan := tracing.StartSpan(ctx, "pull.fetch_upstream")
 	u, err := upstream.FetchUpstream(upstreamURI, &fetchOptions)
+	tracing.EndSpan(fetchSpan, err)
 	if err != nil {
 		log.FinishSpinnerWithError()
 		return "", errors.Wrap(err, "failed to fetch upstream")
@@ -291,7 +324,10 @@ func Pull(upstreamURI string, pullOptions PullOptions) (string, error) {
 		KotsadmID:           k8sutil.GetKotsadmID(clientset),
 		AppID:               pullOptions.AppID,
 	}
-	if err := upstream.WriteUpstream(u, writeUpstreamOptions); err != nil {
+	_, writeUpstreamSpan := tracing.StartSpan(ctx, "pull.write_upstream")
+	err = upstream.WriteUpstream(u, writeUpstreamOptions)
+	tracing.EndSpan(writeUpstreamSpan, err)
+	if err != nil {
 		log.FinishSpinnerWithError()
 		return "", errors.Wrap(err, "failed to write upstream")
 	}
@@ -333,7 +369,10 @@ func Pull(upstreamURI string, pullOptions PullOptions) (string, error) {
 	if needsConfig {
 		if processImageOptions.RewriteImages && processImageOptions.AirgapRoot != "" {
 			// if this is an airgap install, we still need to process the images
-			if _, err = midstream.ProcessAirgapImages(processImageOptions, kotsKinds, fetchOptions.License, log); err != nil {
+			_, imagesSpan := tracing.StartSpan(ctx, "pull.process_airgap_images")
+			_, err = midstream.ProcessAirgapImages(processImageOptions, kotsKinds, fetchOptions.License, log)
+			tracing.EndSpan(imagesSpan, err)
+			if err != nil {
 				return "", errors.Wrap(err, "failed to process airgap images")
 			}
 		}
@@ -403,7 +442,9 @@ func Pull(upstreamURI string, pullOptions PullOptions) (string, error) {
 	log.ActionWithSpinner("Creating base")
 	io.WriteString(pullOptions.ReportWriter, "Creating base\n")
 
+	_, renderSpan := tracing.StartSpan(ctx, "pull.render_base")
 	commonBase, helmBases, renderedKotsKindsMap, err := base.RenderUpstream(u, &renderOptions)
+	tracing.EndSpan(renderSpan, err)
 	if err != nil {
 		log.FinishSpinnerWithError()
 		return "", errors.Wrap(err, "failed to render upstream")
@@ -440,33 +481,12 @@ func Pull(upstreamURI string, pullOptions PullOptions) (string, error) {
 		}
 	}
 
-	writeBaseOptions := base.WriteOptions{
-		BaseDir:          u.GetBaseDir(writeUpstreamOptions),
-		SkippedDir:       u.GetSkippedDir(writeUpstreamOptions),
-		Overwrite:        true,
-		ExcludeKotsKinds: pullOptions.ExcludeKotsKinds,
-		IsHelmBase:       false,
-	}
-	if err := commonBase.WriteBase(writeBaseOptions); err != nil {
+	_, writeBaseSpan := tracing.StartSpan(ctx, "pull.write_base")
+	err = writeBases(u, writeUpstreamOptions, pullOptions.ExcludeKotsKinds, commonBase, helmBases)
+	tracing.EndSpan(writeBaseSpan, err)
+	if err != nil {
 		log.FinishSpinnerWithError()
-		return "", errors.Wrap(err, "failed to write common base")
-	}
-
-	for _, helmBase := range helmBases {
-		helmBaseCopy := helmBase.DeepCopy()
-		// strip namespace. helm render takes care of injecting the namespace
-		helmBaseCopy.SetNamespace("")
-		writeBaseOptions := base.WriteOptions{
-			BaseDir:          u.GetBaseDir(writeUpstreamOptions),
-			SkippedDir:       u.GetSkippedDir(writeUpstreamOptions),
-			Overwrite:        true,
-			ExcludeKotsKinds: pullOptions.ExcludeKotsKinds,
-			IsHelmBase:       true,
-		}
-		if err := helmBaseCopy.WriteBase(writeBaseOptions); err != nil {
-			log.FinishSpinnerWithError()
-			return "", errors.Wrapf(err, "failed to write helm base %s", helmBaseCopy.Path)
-		}
+		return "", err
 	}
 
 	log.FinishSpinner()
@@ -512,7 +532,7 @@ func Pull(upstreamURI string, pullOptions PullOptions) (string, error) {
 		chartBaseName := v.GetDirName()
 		commonWriteMidstreamOptions.UseHelmInstall[chartBaseName] = v.Spec.UseHelmInstall
 		if v.Spec.UseHelmInstall {
-			subcharts, err := base.FindHelmSubChartsFromBase(writeBaseOptions.BaseDir, chartBaseName)
+			subcharts, err := base.FindHelmSubChartsFromBase(u.GetBaseDir(writeUpstreamOptions), chartBaseName)
 			if err != nil {
 				log.FinishSpinnerWithError()
 				return "", errors.Wrapf(err, "failed to find subcharts for parent chart %s", chartBaseName)
@@ -527,7 +547,7 @@ func Pull(upstreamURI string, pullOptions PullOptions) (string, error) {
 	writeMidstreamOptions.MidstreamDir = filepath.Join(u.GetOverlaysDir(writeUpstreamOptions), "midstream")
 	writeMidstreamOptions.BaseDir = filepath.Join(u.GetBaseDir(writeUpstreamOptions), commonBase.Path)
 
-	m, err := midstream.WriteMidstream(writeMidstreamOptions, processImageOptions, commonBase, fetchOptions.License, identityConfig, u.GetUpstreamDir(writeUpstreamOptions), log)
+	m, err := midstream.WriteMidstream(ctx, writeMidstreamOptions, processImageOptions, commonBase, fetchOptions.License, identityConfig, u.GetUpstreamDir(writeUpstreamOptions), log)
 	if err != nil {
 		log.FinishSpinnerWithError()
 		return "", errors.Wrap(err, "failed to write common midstream")
@@ -551,7 +571,7 @@ func Pull(upstreamURI string, pullOptions PullOptions) (string, error) {
 		processImageOptionsCopy.Namespace = helmBaseCopy.Namespace
 		processImageOptionsCopy.PushImages = false // never push images more than once
 
-		helmMidstream, err := midstream.WriteMidstream(writeMidstreamOptions, processImageOptionsCopy, helmBaseCopy, fetchOptions.License, identityConfig, u.GetUpstreamDir(writeUpstreamOptions), log)
+		helmMidstream, err := midstream.WriteMidstream(ctx, writeMidstreamOptions, processImageOptionsCopy, helmBaseCopy, fetchOptions.License, identityConfig, u.GetUpstreamDir(writeUpstreamOptions), log)
 		if err != nil {
 			log.FinishSpinnerWithError()
 			return "", errors.Wrapf(err, "failed to write helm midstream %s", helmBase.Path)
@@ -590,7 +610,10 @@ func Pull(upstreamURI string, pullOptions PullOptions) (string, error) {
 
 	log.FinishSpinner()
 
-	if err := writeDownstreams(pullOptions, u.GetOverlaysDir(writeUpstreamOptions), m, helmMidstreams, log); err != nil {
+	_, downstreamsSpan := tracing.StartSpan(ctx, "pull.write_downstreams")
+	err = writeDownstreams(pullOptions, u.GetOverlaysDir(writeUpstreamOptions), m, helmMidstreams, log)
+	tracing.EndSpan(downstreamsSpan, err)
+	if err != nil {
 		return "", errors.Wrap(err, "failed to write downstreams")
 	}
 
@@ -631,6 +654,37 @@ func Pull(upstreamURI string, pullOptions PullOptions) (string, error) {
 	return filepath.Join(pullOptions.RootDir, u.Name), nil
 }
 
+func writeBases(u *upstreamtypes.Upstream, writeUpstreamOptions upstreamtypes.WriteOptions, excludeKotsKinds bool, commonBase *base.Base, helmBases []base.Base) error {
+	writeBaseOptions := base.WriteOptions{
+		BaseDir:          u.GetBaseDir(writeUpstreamOptions),
+		SkippedDir:       u.GetSkippedDir(writeUpstreamOptions),
+		Overwrite:        true,
+		ExcludeKotsKinds: excludeKotsKinds,
+		IsHelmBase:       false,
+	}
+	if err := commonBase.WriteBase(writeBaseOptions); err != nil {
+		return errors.Wrap(err, "failed to write common base")
+	}
+
+	for _, helmBase := range helmBases {
+		helmBaseCopy := helmBase.DeepCopy()
+		// strip namespace. helm render takes care of injecting the namespace
+		helmBaseCopy.SetNamespace("")
+		writeBaseOptions := base.WriteOptions{
+			BaseDir:          u.GetBaseDir(writeUpstreamOptions),
+			SkippedDir:       u.GetSkippedDir(writeUpstreamOptions),
+			Overwrite:        true,
+			ExcludeKotsKinds: excludeKotsKinds,
+			IsHelmBase:       true,
+		}
+		if err := helmBaseCopy.WriteBase(writeBaseOptions); err != nil {
+			return errors.Wrapf(err, "failed to write helm base %s", helmBaseCopy.Path)
+		}
+	}
+
+	return nil
+}
+
 func removeUnusedHelmOverlays(overlayRoot string, baseRoot string) error {
 	// Only cleanup "charts" subdirectory. This can be isolated from customer overlays, so we don't destroy them.
 	return removeUnusedHelmOverlaysRec(overlayRoot, baseRoot, "charts")
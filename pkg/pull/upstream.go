@@ -2,14 +2,36 @@ package pull
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
 
 	"github.com/replicatedhq/kots/pkg/util"
 )
 
 func RewriteUpstream(upstreamURI string) string {
+	if isLocalPath(upstreamURI) {
+		if absPath, err := filepath.Abs(upstreamURI); err == nil {
+			return fmt.Sprintf("file://%s", filepath.ToSlash(absPath))
+		}
+	}
+
 	if !util.IsURL(upstreamURI) {
 		upstreamURI = fmt.Sprintf("replicated://%s", upstreamURI)
 	}
 
 	return upstreamURI
 }
+
+// isLocalPath returns true for explicit filesystem paths. App slugs can contain slashes (slug/channel),
+// so a path must be absolute or relative to the current directory.
+func isLocalPath(upstreamURI string) bool {
+	if upstreamURI == "." || upstreamURI == ".." {
+		return true
+	}
+	for _, prefix := range []string{"/", "./", "../"} {
+		if strings.HasPrefix(upstreamURI, prefix) {
+			return true
+		}
+	}
+	return false
+}
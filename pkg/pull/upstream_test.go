@@ -1,12 +1,18 @@
 package pull
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRewriteUpstream(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+
 	tests := []struct {
 		upstreamURI string
 		expected    string
@@ -23,6 +29,18 @@ func TestRewriteUpstream(t *testing.T) {
 			upstreamURI: "helm://stable/mysql",
 			expected:    "helm://stable/mysql",
 		},
+		{
+			upstreamURI: "git+https://github.com/org/repo.git?ref=v1.0.0&path=manifests",
+			expected:    "git+https://github.com/org/repo.git?ref=v1.0.0&path=manifests",
+		},
+		{
+			upstreamURI: "/tmp/my-app",
+			expected:    "file:///tmp/my-app",
+		},
+		{
+			upstreamURI: "./my-app",
+			expected:    "file://" + filepath.ToSlash(filepath.Join(wd, "my-app")),
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.upstreamURI, func(t *testing.T) {
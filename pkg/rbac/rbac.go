@@ -1,6 +1,10 @@
 package rbac
 
 import (
+	"context"
+	"regexp"
+
+	"github.com/pkg/errors"
 	"github.com/replicatedhq/kots/pkg/rbac/types"
 )
 
@@ -8,6 +12,8 @@ const (
 	ClusterAdminRoleID = "cluster-admin"
 )
 
+var roleIDRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
 var (
 	ClusterAdminRole = types.Role{
 		ID:          "cluster-admin",
@@ -51,3 +57,76 @@ func DefaultRoles() []types.Role {
 		SupportRole,
 	}
 }
+
+// AllRoles returns the default roles followed by the custom roles defined by the admin
+func AllRoles(customRoles []types.Role) []types.Role {
+	return append(DefaultRoles(), customRoles...)
+}
+
+type RoleLister interface {
+	ListRBACRoles() ([]types.Role, error)
+}
+
+// GetRoles returns the roles needed to check access for the session roles.
+// Custom roles are only listed when the session has roles that are not in the given default roles.
+func GetRoles(lister RoleLister, defaultRoles []types.Role, sessionRoles []string) ([]types.Role, error) {
+	hasCustomRoles := false
+	for _, roleID := range sessionRoles {
+		if !containsRole(defaultRoles, roleID) {
+			hasCustomRoles = true
+			break
+		}
+	}
+	if !hasCustomRoles {
+		return defaultRoles, nil
+	}
+
+	customRoles, err := lister.ListRBACRoles()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list custom roles")
+	}
+
+	return append(append([]types.Role{}, defaultRoles...), customRoles...), nil
+}
+
+func IsDefaultRole(roleID string) bool {
+	return containsRole(DefaultRoles(), roleID)
+}
+
+func containsRole(roles []types.Role, roleID string) bool {
+	for _, role := range roles {
+		if role.ID == roleID {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateRole checks that a custom role can be evaluated by CheckAccess.
+// Resources use dot separators and may use glob patterns, e.g. "app.my-app.downstream.deploy.*" or "backup.*".
+func ValidateRole(ctx context.Context, role types.Role) error {
+	if !roleIDRegex.MatchString(role.ID) {
+		return errors.Errorf("invalid role id %q, must consist of lower case alphanumeric characters or '-'", role.ID)
+	}
+	if IsDefaultRole(role.ID) {
+		return errors.Errorf("role id %q is reserved", role.ID)
+	}
+	if len(role.Allow) == 0 {
+		return errors.New("at least one allow policy is required")
+	}
+
+	policies := append(append([]types.Policy{}, role.Allow...), role.Deny...)
+	for _, p := range policies {
+		if p.Action == "" || p.Resource == "" {
+			return errors.New("policies must have an action and a resource")
+		}
+		if err := validateGlob(ctx, p.Action); err != nil {
+			return errors.Wrapf(err, "invalid action %q", p.Action)
+		}
+		if err := validateGlob(ctx, p.Resource); err != nil {
+			return errors.Wrapf(err, "invalid resource %q", p.Resource)
+		}
+	}
+
+	return nil
+}
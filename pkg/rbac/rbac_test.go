@@ -0,0 +1,98 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/replicatedhq/kots/pkg/rbac/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateRole(t *testing.T) {
+	tests := []struct {
+		name    string
+		role    types.Role
+		wantErr bool
+	}{
+		{
+			name: "app scoped deploy role",
+			role: types.Role{
+				ID: "my-app-deployer",
+				Allow: []types.Policy{
+					{Action: "read", Resource: "app.my-app"},
+					{Action: "**", Resource: "app.my-app.downstream.deploy.*"},
+				},
+				Deny: []types.Policy{
+					{Action: "**", Resource: "app.my-app.downstream.filetree.*"},
+				},
+			},
+		},
+		{
+			name:    "reserved id",
+			role:    types.Role{ID: ClusterAdminRoleID, Allow: []types.Policy{PolicyReadonly}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid id",
+			role:    types.Role{ID: "My Role", Allow: []types.Policy{PolicyReadonly}},
+			wantErr: true,
+		},
+		{
+			name:    "no allow policies",
+			role:    types.Role{ID: "nothing"},
+			wantErr: true,
+		},
+		{
+			name:    "missing resource",
+			role:    types.Role{ID: "backup", Allow: []types.Policy{{Action: "read"}}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid resource pattern",
+			role:    types.Role{ID: "backup", Allow: []types.Policy{{Action: "read", Resource: "backup.[*"}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRole(context.Background(), tt.role)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCheckAccess_CustomRole(t *testing.T) {
+	deployer := types.Role{
+		ID: "my-app-deployer",
+		Allow: []types.Policy{
+			{Action: "read", Resource: "app.my-app"},
+			{Action: "read", Resource: "app.my-app.**"},
+			{Action: "**", Resource: "app.my-app.downstream.deploy.*"},
+		},
+	}
+	roles := AllRoles([]types.Role{deployer})
+
+	tests := []struct {
+		name     string
+		action   string
+		resource string
+		want     bool
+	}{
+		{name: "deploy own app", action: "write", resource: "app.my-app.downstream.deploy.", want: true},
+		{name: "read own app config", action: "read", resource: "app.my-app.downstream.config.", want: true},
+		{name: "write own app config", action: "write", resource: "app.my-app.downstream.config.", want: false},
+		{name: "deploy other app", action: "write", resource: "app.other-app.downstream.deploy.", want: false},
+		{name: "backups", action: "read", resource: "backup.", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CheckAccess(context.Background(), roles, tt.action, tt.resource, []string{deployer.ID})
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -118,3 +118,16 @@ func regoEval(ctx context.Context, input map[string]interface{}) (bool, error) {
 	}
 	return allow, nil
 }
+
+// validateGlob returns an error if the pattern cannot be used by glob.match in the rbac module
+func validateGlob(ctx context.Context, pattern string) error {
+	query := rego.New(
+		rego.Query(`glob.match(input.pattern, [], "")`),
+		rego.Input(map[string]interface{}{"pattern": pattern}),
+		rego.StrictBuiltinErrors(true),
+	)
+	if _, err := query.Eval(ctx); err != nil {
+		return errors.Wrap(err, "failed to evaluate pattern")
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package secrets
+
+import (
+	"path"
+	"sort"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	defaultExternalSecretsStoreKind       = "SecretStore"
+	defaultExternalSecretsRefreshInterval = "1h"
+)
+
+// externalSecret is the subset of the External Secrets Operator ExternalSecret resource that kots creates
+type externalSecret struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              externalSecretSpec `json:"spec"`
+}
+
+type externalSecretSpec struct {
+	RefreshInterval string                     `json:"refreshInterval"`
+	SecretStoreRef  externalSecretStoreRef     `json:"secretStoreRef"`
+	Target          externalSecretTarget       `json:"target"`
+	Data            []externalSecretDataRemote `json:"data,omitempty"`
+}
+
+type externalSecretStoreRef struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+type externalSecretTarget struct {
+	Name           string                        `json:"name"`
+	CreationPolicy string                        `json:"creationPolicy"`
+	Template       *externalSecretTargetTemplate `json:"template,omitempty"`
+}
+
+type externalSecretTargetTemplate struct {
+	Type     v1.SecretType                  `json:"type,omitempty"`
+	Metadata externalSecretTemplateMetadata `json:"metadata,omitempty"`
+}
+
+type externalSecretTemplateMetadata struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type externalSecretDataRemote struct {
+	SecretKey string                  `json:"secretKey"`
+	RemoteRef externalSecretRemoteRef `json:"remoteRef"`
+}
+
+type externalSecretRemoteRef struct {
+	Key      string `json:"key"`
+	Property string `json:"property,omitempty"`
+}
+
+// replaceSecretsWithExternalSecrets replaces Secrets with ExternalSecrets that read each key from the secret store.
+// The values are expected at <remoteKeyPrefix>/<namespace>/<name> in the store, with a property for each key in the Secret.
+//
+// Config:
+//   - secretStoreName: the name of the SecretStore or ClusterSecretStore (required)
+//   - secretStoreKind: SecretStore (default) or ClusterSecretStore
+//   - remoteKeyPrefix: the prefix of the keys in the store
+//   - refreshInterval: how often the values are read from the store, defaults to 1h
+func replaceSecretsWithExternalSecrets(archivePath string, selector labels.Selector, config map[string][]byte) error {
+	storeName := string(config["secretStoreName"])
+	if storeName == "" {
+		return errors.New("secretStoreName is required for the externalsecrets buildphase")
+	}
+
+	storeKind := string(config["secretStoreKind"])
+	if storeKind == "" {
+		storeKind = defaultExternalSecretsStoreKind
+	}
+	if storeKind != "SecretStore" && storeKind != "ClusterSecretStore" {
+		return errors.Errorf("unknown secret store kind %q", storeKind)
+	}
+
+	refreshInterval := string(config["refreshInterval"])
+	if refreshInterval == "" {
+		refreshInterval = defaultExternalSecretsRefreshInterval
+	}
+
+	return replaceSecretsInPath(archivePath, selector, func(secret *v1.Secret) ([]byte, error) {
+		es := createExternalSecret(secret, storeName, storeKind, string(config["remoteKeyPrefix"]), refreshInterval)
+		b, err := yaml.Marshal(es)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to marshal externalsecret")
+		}
+		return b, nil
+	})
+}
+
+func createExternalSecret(secret *v1.Secret, storeName string, storeKind string, remoteKeyPrefix string, refreshInterval string) externalSecret {
+	namespace := secret.Namespace
+	if namespace == "" {
+		namespace = defaultSecretNamespace()
+	}
+	remoteKey := path.Join(remoteKeyPrefix, namespace, secret.Name)
+
+	es := externalSecret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "external-secrets.io/v1beta1",
+			Kind:       "ExternalSecret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        secret.Name,
+			Namespace:   secret.Namespace,
+			Labels:      secret.Labels,
+			Annotations: secret.Annotations,
+		},
+		Spec: externalSecretSpec{
+			RefreshInterval: refreshInterval,
+			SecretStoreRef: externalSecretStoreRef{
+				Name: storeName,
+				Kind: storeKind,
+			},
+			Target: externalSecretTarget{
+				Name:           secret.Name,
+				CreationPolicy: "Owner",
+			},
+		},
+	}
+
+	if secret.Type != "" || len(secret.Labels) > 0 || len(secret.Annotations) > 0 {
+		es.Spec.Target.Template = &externalSecretTargetTemplate{
+			Type: secret.Type,
+			Metadata: externalSecretTemplateMetadata{
+				Labels:      secret.Labels,
+				Annotations: secret.Annotations,
+			},
+		}
+	}
+
+	for _, key := range secretKeys(secret) {
+		es.Spec.Data = append(es.Spec.Data, externalSecretDataRemote{
+			SecretKey: key,
+			RemoteRef: externalSecretRemoteRef{
+				Key:      remoteKey,
+				Property: key,
+			},
+		})
+	}
+
+	return es
+}
+
+// secretKeys returns the sorted keys of the data and stringData of the secret
+func secretKeys(secret *v1.Secret) []string {
+	keys := map[string]bool{}
+	for key := range secret.Data {
+		keys[key] = true
+	}
+	for key := range secret.StringData {
+		keys[key] = true
+	}
+
+	sorted := []string{}
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	return sorted
+}
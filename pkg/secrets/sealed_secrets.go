@@ -5,7 +5,6 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
-	"io/ioutil"
 	"os"
 
 	sealedsecretsv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
@@ -13,84 +12,41 @@ import (
 	"github.com/pkg/errors"
 	"github.com/replicatedhq/kots/pkg/util"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	jsonserializer "k8s.io/apimachinery/pkg/runtime/serializer/json"
 	"k8s.io/client-go/kubernetes/scheme"
 )
 
-func replaceSecretsWithSealedSecrets(archivePath string, config map[string][]byte) error {
-	secretPaths, err := findPathsWithSecrets(archivePath)
-	if err != nil {
-		return errors.Wrap(err, "failed to get secrets in path")
-	}
+func replaceSecretsWithSealedSecrets(archivePath string, selector labels.Selector, config map[string][]byte) error {
+	var cert *x509.Certificate
+	return replaceSecretsInPath(archivePath, selector, func(secret *v1.Secret) ([]byte, error) {
+		// the certificate is only required when there are secrets to seal
+		if cert == nil {
+			c, err := parseSealedSecretsCert(config)
+			if err != nil {
+				return nil, err
+			}
+			cert = c
+			sealedsecretsscheme.AddToScheme(scheme.Scheme)
+		}
 
-	if len(secretPaths) == 0 {
-		return nil
-	}
+		return createSecret(cert, secret)
+	})
+}
 
+func parseSealedSecretsCert(config map[string][]byte) (*x509.Certificate, error) {
 	block, _ := pem.Decode(config["cert.pem"])
 	if block == nil {
-		return errors.New("unable to read public key from secret")
+		return nil, errors.New("unable to read public key from secret")
 	}
 
 	cert, err := x509.ParseCertificate(block.Bytes)
 	if err != nil {
-		return errors.Wrap(err, "failed to parse certificate")
-	}
-
-	sealedsecretsscheme.AddToScheme(scheme.Scheme)
-
-	decode := scheme.Codecs.UniversalDeserializer().Decode
-	for _, secretPath := range secretPaths {
-		contents, err := ioutil.ReadFile(secretPath)
-		if err != nil {
-			return errors.Wrap(err, "failed to read file")
-		}
-
-		multiDocYaml := util.ConvertToSingleDocs(contents)
-		var secrets [][]byte
-		var nonSecrets [][]byte
-
-		for _, object := range multiDocYaml {
-			if string(object) == "" {
-				continue
-			}
-
-			decoded, _, err := decode(object, nil, nil)
-			if err != nil {
-				nonSecrets = append(nonSecrets, object)
-				continue
-			}
-
-			secret, ok := decoded.(*v1.Secret)
-			if !ok {
-				nonSecrets = append(nonSecrets, object)
-				continue
-			}
-
-			secretBytes, err := createSecret(cert, secret)
-			if err != nil {
-				return err
-			}
-			secrets = append(secrets, secretBytes)
-		}
-
-		var fileContents []byte
-		if len(nonSecrets) > 0 {
-			fileContents = append(fileContents, []byte("\n---\n")...)
-			fileContents = append(fileContents, bytes.Join(nonSecrets, []byte("\n---\n"))...)
-		}
-		if len(secrets) > 0 {
-			fileContents = append(fileContents, []byte("\n---\n")...)
-			fileContents = append(fileContents, bytes.Join(secrets, []byte("\n---\n"))...)
-		}
-
-		if err := ioutil.WriteFile(secretPath, fileContents, 0644); err != nil {
-			return errors.Wrap(err, "failed to write sealed secret")
-		}
+		return nil, errors.Wrap(err, "failed to parse certificate")
 	}
 
-	return nil
+	return cert, nil
 }
 
 func createSecret(cert *x509.Certificate, secret *v1.Secret) ([]byte, error) {
@@ -98,11 +54,7 @@ func createSecret(cert *x509.Certificate, secret *v1.Secret) ([]byte, error) {
 
 	// sealed secrets require a namespace
 	if secret.Namespace == "" {
-		if os.Getenv("DEV_NAMESPACE") != "" {
-			secret.Namespace = os.Getenv("DEV_NAMESPACE")
-		} else {
-			secret.Namespace = util.PodNamespace
-		}
+		secret.Namespace = defaultSecretNamespace()
 	}
 
 	sealedSecret, err := sealedsecretsv1alpha1.NewSealedSecret(codecFactory, cert.PublicKey.(*rsa.PublicKey), secret)
@@ -122,3 +74,11 @@ func createSecret(cert *x509.Certificate, secret *v1.Secret) ([]byte, error) {
 
 	return b.Bytes(), nil
 }
+
+// defaultSecretNamespace is the namespace of secrets in the archive that don't set one
+func defaultSecretNamespace() string {
+	if os.Getenv("DEV_NAMESPACE") != "" {
+		return os.Getenv("DEV_NAMESPACE")
+	}
+	return util.PodNamespace
+}
@@ -1,21 +1,48 @@
 package secrets
 
 import (
+	"bytes"
 	"context"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/pkg/errors"
 	"github.com/replicatedhq/kots/pkg/logger"
 	"github.com/replicatedhq/kots/pkg/util"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes/scheme"
 )
 
-func ReplaceSecretsInPath(archiveDir string, clientset kubernetes.Interface) error {
+const (
+	SecretTypeVault           = "vault"
+	SecretTypeExternalSecrets = "externalsecrets"
+	SecretTypeSealedSecrets   = "sealedsecrets"
+	SecretTypeSOPS            = "sops"
+)
+
+// secretTypeOrder is the order that secret buildphases are applied in. Secrets that are replaced by a buildphase
+// are no longer Secrets, so each Secret in the archive is handled by the first buildphase that selects it.
+// SOPS keeps the Secret kind and is applied last.
+var secretTypeOrder = []string{
+	SecretTypeVault,
+	SecretTypeExternalSecrets,
+	SecretTypeSealedSecrets,
+	SecretTypeSOPS,
+}
+
+// selectorConfigKey is the optional member of a buildphase secret with a label selector for the Secrets in the archive
+// that the buildphase applies to. All Secrets are selected if it's not set.
+const selectorConfigKey = "selector"
+
+// ReplaceSecretsInPath applies the secret buildphases that are configured in the cluster to the Secrets in the archive.
+// The sops buildphase is only supported when GitOps is enabled, since kots cannot apply sops encrypted Secrets itself.
+func ReplaceSecretsInPath(archiveDir string, clientset kubernetes.Interface, isGitOpsEnabled bool) error {
 	logger.Debug("checking for secrets replacers")
 
 	secrets, err := clientset.CoreV1().Secrets(util.PodNamespace).List(context.TODO(), metav1.ListOptions{
@@ -29,19 +56,136 @@ func ReplaceSecretsInPath(archiveDir string, clientset kubernetes.Interface) err
 		return nil
 	}
 
-	if len(secrets.Items) > 1 {
-		return errors.New("multiple secret buildphases are not supported")
+	buildphases, err := orderBuildphases(secrets.Items)
+	if err != nil {
+		return err
+	}
+
+	for _, secret := range buildphases {
+		secretType := secret.Labels["kots.io/secrettype"]
+
+		selector, err := buildphaseSelector(secret.Data)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse selector of %s buildphase", secretType)
+		}
+
+		switch secretType {
+		case SecretTypeVault:
+			err = replaceSecretsWithVaultAnnotations(archiveDir, selector, secret.Data)
+		case SecretTypeExternalSecrets:
+			err = replaceSecretsWithExternalSecrets(archiveDir, selector, secret.Data)
+		case SecretTypeSealedSecrets:
+			err = replaceSecretsWithSealedSecrets(archiveDir, selector, secret.Data)
+		case SecretTypeSOPS:
+			if !isGitOpsEnabled {
+				return errors.New("the sops secret buildphase requires GitOps to be enabled for the app, since sops encrypted secrets can only be decrypted by the GitOps tooling")
+			}
+			err = encryptSecretsWithSOPS(archiveDir, selector, secret.Data)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// orderBuildphases returns the buildphase secrets in the order they are applied in
+func orderBuildphases(secrets []v1.Secret) ([]v1.Secret, error) {
+	order := map[string]int{}
+	for i, secretType := range secretTypeOrder {
+		order[secretType] = i
+	}
+
+	seen := map[string]bool{}
+	for _, secret := range secrets {
+		secretType := secret.Labels["kots.io/secrettype"]
+		if _, ok := order[secretType]; !ok {
+			return nil, errors.Errorf("unknown secret type %q", secretType)
+		}
+		if seen[secretType] {
+			return nil, errors.Errorf("multiple secret buildphases of type %q are not supported", secretType)
+		}
+		seen[secretType] = true
+	}
+
+	ordered := append([]v1.Secret{}, secrets...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return order[ordered[i].Labels["kots.io/secrettype"]] < order[ordered[j].Labels["kots.io/secrettype"]]
+	})
+
+	return ordered, nil
+}
+
+func buildphaseSelector(config map[string][]byte) (labels.Selector, error) {
+	selector, ok := config[selectorConfigKey]
+	if !ok {
+		return labels.Everything(), nil
+	}
+	return labels.Parse(string(selector))
+}
+
+// replaceSecretsInPath replaces the Secrets in the archive that match the selector with the documents returned by replace.
+// Secrets are dropped from the archive when replace returns no document.
+func replaceSecretsInPath(archiveDir string, selector labels.Selector, replace func(secret *v1.Secret) ([]byte, error)) error {
+	secretPaths, err := findPathsWithSecrets(archiveDir)
+	if err != nil {
+		return errors.Wrap(err, "failed to get secrets in path")
 	}
 
-	secret := secrets.Items[0]
-	secretType := secret.Labels["kots.io/secrettype"]
+	decode := scheme.Codecs.UniversalDeserializer().Decode
+	for _, secretPath := range secretPaths {
+		contents, err := ioutil.ReadFile(secretPath)
+		if err != nil {
+			return errors.Wrap(err, "failed to read file")
+		}
+
+		multiDocYaml := util.ConvertToSingleDocs(contents)
+		var secrets [][]byte
+		var nonSecrets [][]byte
+
+		for _, object := range multiDocYaml {
+			if string(object) == "" {
+				continue
+			}
+
+			decoded, _, err := decode(object, nil, nil)
+			if err != nil {
+				nonSecrets = append(nonSecrets, object)
+				continue
+			}
+
+			secret, ok := decoded.(*v1.Secret)
+			if !ok || !selector.Matches(labels.Set(secret.Labels)) {
+				nonSecrets = append(nonSecrets, object)
+				continue
+			}
 
-	switch secretType {
-	case "sealedsecrets":
-		return replaceSecretsWithSealedSecrets(archiveDir, secret.Data)
-	default:
-		return errors.Errorf("unknown secret type %q", secretType)
+			secretBytes, err := replace(secret)
+			if err != nil {
+				return err
+			}
+			if len(secretBytes) > 0 {
+				secrets = append(secrets, secretBytes)
+			}
+		}
+
+		var fileContents []byte
+		if len(nonSecrets) > 0 {
+			fileContents = append(fileContents, []byte("\n---\n")...)
+			fileContents = append(fileContents, bytes.Join(nonSecrets, []byte("\n---\n"))...)
+		}
+		if len(secrets) > 0 {
+			fileContents = append(fileContents, []byte("\n---\n")...)
+			fileContents = append(fileContents, bytes.Join(secrets, []byte("\n---\n"))...)
+		}
+
+		if err := ioutil.WriteFile(secretPath, fileContents, 0644); err != nil {
+			return errors.Wrap(err, "failed to write secret")
+		}
 	}
+
+	return nil
 }
 
 func findPathsWithSecrets(archiveDir string) ([]string, error) {
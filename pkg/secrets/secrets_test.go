@@ -58,13 +58,14 @@ var _ = Describe("Secrets", func() {
 				ListMeta: metav1.ListMeta{},
 				Items:    nil,
 			})
-			err := secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset)
+			err := secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset, false)
 			Expect(err).ToNot(HaveOccurred())
 		})
 
-		It("returns an error if there are multiple secret buildphases", func() {
+		It("returns an error if there are multiple secret buildphases of the same type", func() {
 			var labels = make(map[string]string)
 			labels["kots.io/buildphase"] = "secret"
+			labels["kots.io/secrettype"] = "sealedsecrets"
 			clientset = fake.NewSimpleClientset(&v1.SecretList{
 				TypeMeta: metav1.TypeMeta{},
 				ListMeta: metav1.ListMeta{},
@@ -85,9 +86,9 @@ var _ = Describe("Secrets", func() {
 				}},
 			})
 
-			err := secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset)
+			err := secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset, false)
 			Expect(err).To(HaveOccurred())
-			Expect(err.Error()).To(ContainSubstring("multiple secret buildphases are not supported"))
+			Expect(err.Error()).To(ContainSubstring(`multiple secret buildphases of type "sealedsecrets" are not supported`))
 		})
 
 		It("returns an error if the secret type is not supported", func() {
@@ -107,7 +108,7 @@ var _ = Describe("Secrets", func() {
 				}},
 			})
 
-			err := secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset)
+			err := secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset, false)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("unknown secret type"))
 		})
@@ -126,7 +127,7 @@ var _ = Describe("Secrets", func() {
 				}},
 			})
 
-			err := secrets.ReplaceSecretsInPath("invalid-archive-dir", clientset)
+			err := secrets.ReplaceSecretsInPath("invalid-archive-dir", clientset, false)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("failed to get secrets in path"))
 			Expect(err.Error()).To(ContainSubstring("could not walk through the archive directory"))
@@ -146,7 +147,7 @@ var _ = Describe("Secrets", func() {
 				}},
 			})
 
-			err := secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset)
+			err := secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset, false)
 			Expect(err).ToNot(HaveOccurred())
 		})
 
@@ -171,7 +172,7 @@ var _ = Describe("Secrets", func() {
 			_, err := tmpFile.WriteString(invalidSecret)
 			Expect(err).ToNot(HaveOccurred())
 
-			err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset)
+			err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset, false)
 			Expect(err).ToNot(HaveOccurred())
 
 			secretContents, err := ioutil.ReadFile(tmpFile.Name())
@@ -199,7 +200,7 @@ var _ = Describe("Secrets", func() {
 			err, secret := writeSecret("extensions/v1beta1", "PodSecurityPolicy", namespace, false, false, tmpFile, 1)
 			Expect(err).ToNot(HaveOccurred())
 
-			err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset)
+			err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset, false)
 			Expect(err).ToNot(HaveOccurred())
 
 			secretContents, err := ioutil.ReadFile(tmpFile.Name())
@@ -227,7 +228,7 @@ var _ = Describe("Secrets", func() {
 			err, secret := writeSecret("v1", "Pod", namespace, false, false, tmpFile, 1)
 			Expect(err).ToNot(HaveOccurred())
 
-			err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset)
+			err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset, false)
 			Expect(err).ToNot(HaveOccurred())
 
 			secretContents, err := ioutil.ReadFile(tmpFile.Name())
@@ -262,7 +263,7 @@ metadata:
 			_, err := tmpFile.WriteString(wronglyLabeledSecret)
 			Expect(err).ToNot(HaveOccurred())
 
-			err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset)
+			err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset, false)
 			Expect(err).ToNot(HaveOccurred())
 
 			secretContents, err := ioutil.ReadFile(tmpFile.Name())
@@ -287,7 +288,7 @@ metadata:
 			err, _ := writeSecret("v1", "Secret", namespace, false, false, tmpFile, 1)
 			Expect(err).ToNot(HaveOccurred())
 
-			err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset)
+			err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset, false)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(Equal("unable to read public key from secret"))
 		})
@@ -313,7 +314,7 @@ metadata:
 			err, _ := writeSecret("v1", "Secret", namespace, false, false, tmpFile, 1)
 			Expect(err).ToNot(HaveOccurred())
 
-			err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset)
+			err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset, false)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("failed to parse certificate"))
 		})
@@ -339,7 +340,7 @@ metadata:
 			err, _ := writeSecret("v1", "Secret", "", false, true, tmpFile, 1)
 			Expect(err).ToNot(HaveOccurred())
 
-			err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset)
+			err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset, false)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("failed to create sealedsecret"))
 		})
@@ -379,7 +380,7 @@ metadata:
 					err, _ := writeSecret("v1", "Secret", "", false, false, tmpFile, 1)
 					Expect(err).ToNot(HaveOccurred())
 
-					err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset)
+					err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset, false)
 					Expect(err).ToNot(HaveOccurred())
 
 					secretContents, err := ioutil.ReadFile(tmpFile.Name())
@@ -421,7 +422,7 @@ metadata:
 					err, _ := writeSecret("v1", "Secret", "", false, false, tmpFile, 1)
 					Expect(err).ToNot(HaveOccurred())
 
-					err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset)
+					err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset, false)
 					Expect(err).ToNot(HaveOccurred())
 
 					secretContents, err := ioutil.ReadFile(tmpFile.Name())
@@ -452,7 +453,7 @@ metadata:
 			err, _ := writeSecret("v1", "Secret", namespace, false, false, tmpFile, 1)
 			Expect(err).ToNot(HaveOccurred())
 
-			err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset)
+			err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset, false)
 			Expect(err).ToNot(HaveOccurred())
 
 			secretContents, err := ioutil.ReadFile(tmpFile.Name())
@@ -505,7 +506,7 @@ metadata:
 					Expect(err).ToNot(HaveOccurred())
 				}
 
-				err := secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset)
+				err := secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset, false)
 				Expect(err).ToNot(HaveOccurred())
 
 				for i := 0; i < len(secretsFiles); i++ {
@@ -553,7 +554,7 @@ metadata:
   name: test-secret-1
   namespace: test-namespace`
 
-				err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset)
+				err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset, false)
 				Expect(err).ToNot(HaveOccurred())
 
 				secretContents, err := ioutil.ReadFile(tmpFile.Name())
@@ -602,7 +603,7 @@ metadata:
 				_, err = tmpFile.WriteString(originalSecretContents)
 				Expect(err).ToNot(HaveOccurred())
 
-				err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset)
+				err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset, false)
 				Expect(err).ToNot(HaveOccurred())
 
 				transformedSecret := `---
@@ -659,7 +660,7 @@ metadata:
 				_, err = tmpFile.WriteString(originalSecretContents)
 				Expect(err).ToNot(HaveOccurred())
 
-				err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset)
+				err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset, false)
 				Expect(err).ToNot(HaveOccurred())
 
 				transformedSecret := `---
@@ -716,7 +717,7 @@ metadata:
 				_, err = tmpFile.WriteString(originalSecretContents)
 				Expect(err).ToNot(HaveOccurred())
 
-				err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset)
+				err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset, false)
 				Expect(err).ToNot(HaveOccurred())
 
 				transformedSecret := `---
@@ -738,6 +739,338 @@ metadata:
 				Expect(string(updatedFile)).ToNot(ContainSubstring(undesiredExtraWhitespace))
 			})
 		})
+
+		Context("multiple secret buildphases", func() {
+			It("applies the buildphases in order to the secrets they select", func() {
+				var sealedData = make(map[string][]byte)
+				sealedData["cert.pem"] = []byte(validPublicKey)
+				var externalData = make(map[string][]byte)
+				externalData["secretStoreName"] = []byte("vault-backend")
+				externalData["secretStoreKind"] = []byte("ClusterSecretStore")
+				externalData["remoteKeyPrefix"] = []byte("kots")
+				externalData["selector"] = []byte("app=external")
+
+				clientset = fake.NewSimpleClientset(&v1.SecretList{
+					Items: []v1.Secret{{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "sealed",
+							Namespace: namespace,
+							Labels:    validLabels,
+						},
+						Data: sealedData,
+					}, {
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "external",
+							Namespace: namespace,
+							Labels: map[string]string{
+								"kots.io/buildphase": "secret",
+								"kots.io/secrettype": "externalsecrets",
+							},
+						},
+						Data: externalData,
+					}},
+				})
+
+				_, err := tmpFile.WriteString(`---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: test-external
+  namespace: test-namespace
+  labels:
+    app: external
+data:
+  password: aHVudGVyMg==
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: test-sealed
+  namespace: test-namespace
+data:
+  password: aHVudGVyMg==`)
+				Expect(err).ToNot(HaveOccurred())
+
+				err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset, false)
+				Expect(err).ToNot(HaveOccurred())
+
+				updatedFile, err := ioutil.ReadFile(tmpFile.Name())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(updatedFile)).To(ContainSubstring(`apiVersion: external-secrets.io/v1beta1
+kind: ExternalSecret`))
+				Expect(string(updatedFile)).To(ContainSubstring(`  data:
+  - remoteRef:
+      key: kots/test-namespace/test-external
+      property: password
+    secretKey: password
+  refreshInterval: 1h
+  secretStoreRef:
+    kind: ClusterSecretStore
+    name: vault-backend`))
+				Expect(string(updatedFile)).To(ContainSubstring(`apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  creationTimestamp: null
+  name: test-sealed`))
+				Expect(string(updatedFile)).ToNot(ContainSubstring("aHVudGVyMg=="))
+				Expect(string(updatedFile)).ToNot(ContainSubstring("kind: Secret\n"))
+			})
+
+			It("returns an error if a buildphase is not configured", func() {
+				clientset = fake.NewSimpleClientset(&v1.SecretList{
+					Items: []v1.Secret{{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "external",
+							Namespace: namespace,
+							Labels: map[string]string{
+								"kots.io/buildphase": "secret",
+								"kots.io/secrettype": "externalsecrets",
+							},
+						},
+					}},
+				})
+
+				err := secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset, false)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("secretStoreName is required"))
+			})
+		})
+
+		Context("vault buildphase", func() {
+			BeforeEach(func() {
+				var data = make(map[string][]byte)
+				data["role"] = []byte("my-app")
+				data["path"] = []byte("secret/my-app/")
+
+				clientset = fake.NewSimpleClientset(&v1.SecretList{
+					Items: []v1.Secret{{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "vault",
+							Namespace: namespace,
+							Labels: map[string]string{
+								"kots.io/buildphase": "secret",
+								"kots.io/secrettype": "vault",
+							},
+						},
+						Data: data,
+					}},
+				})
+			})
+
+			It("replaces secret volumes with vault agent annotations", func() {
+				_, err := tmpFile.WriteString(`---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: db
+data:
+  password: aHVudGVyMg==
+  username: YWRtaW4=
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: nginx
+        volumeMounts:
+        - name: db
+          mountPath: /etc/db
+        - name: config
+          mountPath: /etc/config
+      - name: sidecar
+        image: busybox
+      volumes:
+      - name: db
+        secret:
+          secretName: db
+      - name: config
+        configMap:
+          name: config`)
+				Expect(err).ToNot(HaveOccurred())
+
+				err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset, false)
+				Expect(err).ToNot(HaveOccurred())
+
+				updatedFile, err := ioutil.ReadFile(tmpFile.Name())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(updatedFile)).ToNot(ContainSubstring("kind: Secret"))
+				Expect(string(updatedFile)).ToNot(ContainSubstring("aHVudGVyMg=="))
+				Expect(string(updatedFile)).ToNot(ContainSubstring("secretName: db"))
+				Expect(string(updatedFile)).ToNot(ContainSubstring("mountPath: /etc/db"))
+				Expect(string(updatedFile)).To(ContainSubstring("mountPath: /etc/config"))
+				Expect(string(updatedFile)).To(ContainSubstring(`vault.hashicorp.com/agent-inject: "true"`))
+				Expect(string(updatedFile)).To(ContainSubstring("vault.hashicorp.com/agent-inject-containers: web\n"))
+				Expect(string(updatedFile)).To(ContainSubstring("vault.hashicorp.com/role: my-app"))
+				Expect(string(updatedFile)).To(ContainSubstring("vault.hashicorp.com/agent-inject-secret-db-password: secret/my-app/db"))
+				Expect(string(updatedFile)).To(ContainSubstring("vault.hashicorp.com/agent-inject-template-db-password: "))
+				Expect(string(updatedFile)).To(ContainSubstring(`{{ index .Data.data "password" }}{{- end }}`))
+				Expect(string(updatedFile)).To(ContainSubstring("vault.hashicorp.com/secret-volume-path-db-password: /etc/db"))
+				Expect(string(updatedFile)).To(ContainSubstring("vault.hashicorp.com/agent-inject-file-db-password: password"))
+				Expect(string(updatedFile)).To(ContainSubstring("vault.hashicorp.com/agent-inject-file-db-username: username"))
+			})
+
+			It("returns an error and does not change the archive if a secret is used in the environment", func() {
+				contents := `---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: db
+data:
+  password: aHVudGVyMg==
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  containers:
+  - name: web
+    image: nginx
+    env:
+    - name: PASSWORD
+      valueFrom:
+        secretKeyRef:
+          name: db
+          key: password`
+				_, err := tmpFile.WriteString(contents)
+				Expect(err).ToNot(HaveOccurred())
+
+				err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset, false)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("secret db is referenced from the environment of Pod web"))
+
+				updatedFile, err := ioutil.ReadFile(tmpFile.Name())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(updatedFile)).To(Equal(contents))
+			})
+
+			It("returns an error if a secret is not mounted", func() {
+				_, err := tmpFile.WriteString(`---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: db
+data:
+  password: aHVudGVyMg==`)
+				Expect(err).ToNot(HaveOccurred())
+
+				err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset, false)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("secret test-namespace/db is not mounted by any workload"))
+			})
+		})
+
+		Context("sops buildphase", func() {
+			It("returns an error if there are no keys", func() {
+				clientset = fake.NewSimpleClientset(&v1.SecretList{
+					Items: []v1.Secret{{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "sops",
+							Namespace: namespace,
+							Labels: map[string]string{
+								"kots.io/buildphase": "secret",
+								"kots.io/secrettype": "sops",
+							},
+						},
+					}},
+				})
+
+				err := secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset, true)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("at least one age recipient or pgp key is required"))
+			})
+
+			It("encrypts the data of secrets", func() {
+				var data = make(map[string][]byte)
+				data["age"] = []byte("age1yt3tfqlfrwdwx0z0ynwplcr6qxcxfaqycuprpmy89nr83ltx74tqdpszlw")
+				clientset = fake.NewSimpleClientset(&v1.SecretList{
+					Items: []v1.Secret{{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "sops",
+							Namespace: namespace,
+							Labels: map[string]string{
+								"kots.io/buildphase": "secret",
+								"kots.io/secrettype": "sops",
+							},
+						},
+						Data: data,
+					}},
+				})
+
+				_, err := tmpFile.WriteString(`---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: db
+  namespace: test-namespace
+type: Opaque
+data:
+  password: aHVudGVyMg==`)
+				Expect(err).ToNot(HaveOccurred())
+
+				err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset, true)
+				Expect(err).ToNot(HaveOccurred())
+
+				updatedFile, err := ioutil.ReadFile(tmpFile.Name())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(updatedFile)).ToNot(ContainSubstring("aHVudGVyMg=="))
+				Expect(string(updatedFile)).To(ContainSubstring("kind: Secret"))
+				Expect(string(updatedFile)).To(ContainSubstring("  name: db\n"))
+				Expect(string(updatedFile)).To(ContainSubstring("  password: ENC[AES256_GCM,data:"))
+				Expect(string(updatedFile)).To(ContainSubstring("    - recipient: age1yt3tfqlfrwdwx0z0ynwplcr6qxcxfaqycuprpmy89nr83ltx74tqdpszlw"))
+				Expect(string(updatedFile)).To(ContainSubstring("-----BEGIN AGE ENCRYPTED FILE-----"))
+				Expect(string(updatedFile)).To(ContainSubstring("encrypted_regex: ^(data|stringData)$"))
+			})
+
+			It("returns an error and does not change the archive if gitops is not enabled", func() {
+				var data = make(map[string][]byte)
+				data["age"] = []byte("age1yt3tfqlfrwdwx0z0ynwplcr6qxcxfaqycuprpmy89nr83ltx74tqdpszlw")
+				clientset = fake.NewSimpleClientset(&v1.SecretList{
+					Items: []v1.Secret{{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "sops",
+							Namespace: namespace,
+							Labels: map[string]string{
+								"kots.io/buildphase": "secret",
+								"kots.io/secrettype": "sops",
+							},
+						},
+						Data: data,
+					}},
+				})
+
+				secret := `---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: db
+  namespace: test-namespace
+type: Opaque
+data:
+  password: aHVudGVyMg==`
+				_, err := tmpFile.WriteString(secret)
+				Expect(err).ToNot(HaveOccurred())
+
+				err = secrets.ReplaceSecretsInPath(tmpArchiveDir, clientset, false)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("requires GitOps to be enabled"))
+
+				updatedFile, err := ioutil.ReadFile(tmpFile.Name())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(updatedFile)).To(Equal(secret))
+			})
+		})
 	})
 })
 
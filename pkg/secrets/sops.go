@@ -0,0 +1,363 @@
+package secrets
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	agearmor "filippo.io/age/armor"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	jsonserializer "k8s.io/apimachinery/pkg/runtime/serializer/json"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+const (
+	defaultSOPSEncryptedRegex = "^(data|stringData)$"
+	sopsVersion               = "3.7.3"
+	sopsDataKeySize           = 32
+	sopsNonceSize             = 32
+)
+
+type sopsKeys struct {
+	ageRecipients []*age.X25519Recipient
+	pgpEntities   openpgp.EntityList
+}
+
+// sopsMetadata is the sops section that is added to encrypted documents
+type sopsMetadata struct {
+	Age            []sopsAgeKey `yaml:"age,omitempty"`
+	LastModified   string       `yaml:"lastmodified"`
+	MAC            string       `yaml:"mac"`
+	PGP            []sopsPGPKey `yaml:"pgp,omitempty"`
+	EncryptedRegex string       `yaml:"encrypted_regex"`
+	Version        string       `yaml:"version"`
+}
+
+type sopsAgeKey struct {
+	Recipient string `yaml:"recipient"`
+	Enc       string `yaml:"enc"`
+}
+
+type sopsPGPKey struct {
+	CreatedAt string `yaml:"created_at"`
+	Enc       string `yaml:"enc"`
+	FP        string `yaml:"fp"`
+}
+
+// encryptSecretsWithSOPS encrypts the data of Secrets in the format of SOPS (https://github.com/getsops/sops), so that they
+// can be decrypted with sops or by tools that support it, e.g. the Flux kustomize controller.
+//
+// Config:
+//   - age: age recipients, separated by commas or new lines
+//   - pgp: ASCII armored PGP public keys
+//   - encryptedRegex: the keys that are encrypted, defaults to ^(data|stringData)$
+func encryptSecretsWithSOPS(archivePath string, selector labels.Selector, config map[string][]byte) error {
+	keys, err := parseSOPSKeys(config)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse sops keys")
+	}
+
+	encryptedRegex := string(config["encryptedRegex"])
+	if encryptedRegex == "" {
+		encryptedRegex = defaultSOPSEncryptedRegex
+	}
+	re, err := regexp.Compile(encryptedRegex)
+	if err != nil {
+		return errors.Wrap(err, "failed to compile encrypted regex")
+	}
+
+	s := jsonserializer.NewYAMLSerializer(jsonserializer.DefaultMetaFactory, scheme.Scheme, scheme.Scheme)
+
+	return replaceSecretsInPath(archivePath, selector, func(secret *v1.Secret) ([]byte, error) {
+		secret.APIVersion = "v1"
+		secret.Kind = "Secret"
+
+		var b bytes.Buffer
+		if err := s.Encode(secret, &b); err != nil {
+			return nil, errors.Wrap(err, "failed to encode secret")
+		}
+
+		encrypted, err := sopsEncrypt(b.Bytes(), keys, re, encryptedRegex, time.Now().UTC())
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to encrypt secret %s", secret.Name)
+		}
+		return encrypted, nil
+	})
+}
+
+func parseSOPSKeys(config map[string][]byte) (*sopsKeys, error) {
+	keys := sopsKeys{}
+
+	for _, recipient := range strings.FieldsFunc(string(config["age"]), func(r rune) bool {
+		return r == ',' || r == '\n' || r == ' '
+	}) {
+		ageRecipient, err := age.ParseX25519Recipient(recipient)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid age recipient %q", recipient)
+		}
+		keys.ageRecipients = append(keys.ageRecipients, ageRecipient)
+	}
+
+	if pgpKeys := bytes.TrimSpace(config["pgp"]); len(pgpKeys) > 0 {
+		entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(pgpKeys))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read pgp keys")
+		}
+		keys.pgpEntities = entities
+	}
+
+	if len(keys.ageRecipients) == 0 && len(keys.pgpEntities) == 0 {
+		return nil, errors.New("at least one age recipient or pgp key is required for the sops buildphase")
+	}
+
+	return &keys, nil
+}
+
+// sopsEncrypt encrypts the values of the yaml document with a new data key. The data key is encrypted for each of the keys.
+func sopsEncrypt(doc []byte, keys *sopsKeys, re *regexp.Regexp, encryptedRegex string, now time.Time) ([]byte, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(doc, &root); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal document")
+	}
+	if root.Kind != yaml.DocumentNode || len(root.Content) != 1 || root.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("document is not a mapping")
+	}
+	tree := root.Content[0]
+
+	dataKey := make([]byte, sopsDataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, errors.Wrap(err, "failed to generate data key")
+	}
+
+	// the mac is the hash of all values in the document, in order
+	hash := sha512.New()
+	if err := sopsEncryptNode(tree, nil, false, dataKey, re, func(value []byte) {
+		hash.Write(value)
+	}); err != nil {
+		return nil, err
+	}
+
+	lastModified := now.Format(time.RFC3339)
+	mac, err := sopsEncryptValue(fmt.Sprintf("%X", hash.Sum(nil)), "str", dataKey, lastModified)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encrypt mac")
+	}
+
+	metadata := sopsMetadata{
+		LastModified:   lastModified,
+		MAC:            mac,
+		EncryptedRegex: encryptedRegex,
+		Version:        sopsVersion,
+	}
+
+	for _, recipient := range keys.ageRecipients {
+		enc, err := ageEncrypt(recipient, dataKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to encrypt data key with age")
+		}
+		metadata.Age = append(metadata.Age, sopsAgeKey{
+			Recipient: recipient.String(),
+			Enc:       string(enc),
+		})
+	}
+
+	for _, entity := range keys.pgpEntities {
+		enc, err := pgpEncrypt(entity, dataKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to encrypt data key with pgp")
+		}
+		metadata.PGP = append(metadata.PGP, sopsPGPKey{
+			CreatedAt: lastModified,
+			Enc:       string(enc),
+			FP:        fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint),
+		})
+	}
+
+	var metadataNode yaml.Node
+	if err := metadataNode.Encode(metadata); err != nil {
+		return nil, errors.Wrap(err, "failed to encode sops metadata")
+	}
+	tree.Content = append(tree.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "sops"}, &metadataNode)
+
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&root); err != nil {
+		return nil, errors.Wrap(err, "failed to encode document")
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed to close encoder")
+	}
+
+	return b.Bytes(), nil
+}
+
+// sopsEncryptNode walks the node like sops does. Values are encrypted if any of the keys in their path match the regex,
+// and the path is the additional data of the encryption. Comments and null values are removed since sops handles them
+// differently than plain values.
+func sopsEncryptNode(node *yaml.Node, path []string, encrypt bool, dataKey []byte, re *regexp.Regexp, onValue func([]byte)) error {
+	node.HeadComment, node.LineComment, node.FootComment = "", "", ""
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		content := []*yaml.Node{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if value.Kind == yaml.ScalarNode && value.Tag == "!!null" {
+				continue
+			}
+			key.HeadComment, key.LineComment, key.FootComment = "", "", ""
+			childPath := append(append([]string{}, path...), key.Value)
+			if err := sopsEncryptNode(value, childPath, encrypt || re.MatchString(key.Value), dataKey, re, onValue); err != nil {
+				return err
+			}
+			content = append(content, key, value)
+		}
+		node.Content = content
+	case yaml.SequenceNode:
+		content := []*yaml.Node{}
+		for _, item := range node.Content {
+			if item.Kind == yaml.ScalarNode && item.Tag == "!!null" {
+				continue
+			}
+			if err := sopsEncryptNode(item, path, encrypt, dataKey, re, onValue); err != nil {
+				return err
+			}
+			content = append(content, item)
+		}
+		node.Content = content
+	case yaml.ScalarNode:
+		value, valueType, err := sopsScalar(node)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read value of %s", strings.Join(path, "."))
+		}
+		onValue([]byte(value))
+		if !encrypt {
+			return nil
+		}
+		encrypted, err := sopsEncryptValue(value, valueType, dataKey, strings.Join(path, ":")+":")
+		if err != nil {
+			return errors.Wrapf(err, "failed to encrypt %s", strings.Join(path, "."))
+		}
+		node.Tag, node.Value, node.Style = "!!str", encrypted, 0
+	default:
+		return errors.Errorf("unsupported node at %s", strings.Join(path, "."))
+	}
+
+	return nil
+}
+
+// sopsScalar returns the value of a scalar as sops formats it and its sops type
+func sopsScalar(node *yaml.Node) (string, string, error) {
+	switch node.Tag {
+	case "!!int":
+		var i int
+		if err := node.Decode(&i); err != nil {
+			return "", "", err
+		}
+		return strconv.Itoa(i), "int", nil
+	case "!!float":
+		var f float64
+		if err := node.Decode(&f); err != nil {
+			return "", "", err
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64), "float", nil
+	case "!!bool":
+		var b bool
+		if err := node.Decode(&b); err != nil {
+			return "", "", err
+		}
+		if b {
+			return "True", "bool", nil
+		}
+		return "False", "bool", nil
+	}
+	return node.Value, "str", nil
+}
+
+func sopsEncryptValue(value string, valueType string, dataKey []byte, additionalData string) (string, error) {
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create cipher")
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, sopsNonceSize)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create gcm")
+	}
+
+	iv := make([]byte, sopsNonceSize)
+	if _, err := rand.Read(iv); err != nil {
+		return "", errors.Wrap(err, "failed to generate iv")
+	}
+
+	out := gcm.Seal(nil, iv, []byte(value), []byte(additionalData))
+	data, tag := out[:len(out)-gcm.Overhead()], out[len(out)-gcm.Overhead():]
+
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:%s]",
+		base64.StdEncoding.EncodeToString(data),
+		base64.StdEncoding.EncodeToString(iv),
+		base64.StdEncoding.EncodeToString(tag),
+		valueType,
+	), nil
+}
+
+// ageEncrypt encrypts the plaintext to the recipient and returns the armored age file, like sops does for age keys
+func ageEncrypt(recipient age.Recipient, plaintext []byte) ([]byte, error) {
+	var b bytes.Buffer
+	armored := agearmor.NewWriter(&b)
+
+	w, err := age.Encrypt(armored, recipient)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create encrypter")
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, errors.Wrap(err, "failed to write plaintext")
+	}
+	if err := w.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed to close encrypter")
+	}
+	if err := armored.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed to close armor writer")
+	}
+
+	return b.Bytes(), nil
+}
+
+func pgpEncrypt(entity *openpgp.Entity, plaintext []byte) ([]byte, error) {
+	var b bytes.Buffer
+	armored, err := armor.Encode(&b, "PGP MESSAGE", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create armor encoder")
+	}
+
+	w, err := openpgp.Encrypt(armored, []*openpgp.Entity{entity}, nil, nil, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create encrypter")
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, errors.Wrap(err, "failed to write plaintext")
+	}
+	if err := w.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed to close encrypter")
+	}
+	if err := armored.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed to close armor encoder")
+	}
+	b.WriteString("\n")
+
+	return b.Bytes(), nil
+}
@@ -0,0 +1,220 @@
+package secrets
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"filippo.io/age"
+	agearmor "filippo.io/age/armor"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func Test_ageEncrypt(t *testing.T) {
+	req := require.New(t)
+
+	identity, err := age.GenerateX25519Identity()
+	req.NoError(err)
+
+	plaintext := bytes.Repeat([]byte("0123456789abcdef"), 64*1024/8)
+	armored, err := ageEncrypt(identity.Recipient(), plaintext)
+	req.NoError(err)
+	req.True(strings.HasPrefix(string(armored), agearmor.Header+"\n"))
+	req.True(strings.HasSuffix(string(armored), agearmor.Footer+"\n"))
+
+	r, err := age.Decrypt(agearmor.NewReader(bytes.NewReader(armored)), identity)
+	req.NoError(err)
+	decrypted, err := io.ReadAll(r)
+	req.NoError(err)
+	req.Equal(plaintext, decrypted)
+
+	other, err := age.GenerateX25519Identity()
+	req.NoError(err)
+	_, err = age.Decrypt(agearmor.NewReader(bytes.NewReader(armored)), other)
+	req.Error(err)
+}
+
+func Test_sopsEncrypt(t *testing.T) {
+	req := require.New(t)
+
+	entity, err := openpgp.NewEntity("kots", "", "kots@example.com", nil)
+	req.NoError(err)
+
+	keys := &sopsKeys{pgpEntities: openpgp.EntityList{entity}}
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	encrypted, err := sopsEncrypt([]byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: db # the database secret
+  labels:
+    tier: backend
+  creationTimestamp: null
+data:
+  password: aHVudGVyMg==
+stringData:
+  port: 5432
+  enabled: true
+`), keys, regexp.MustCompile(defaultSOPSEncryptedRegex), defaultSOPSEncryptedRegex, now)
+	req.NoError(err)
+	req.NotContains(string(encrypted), "aHVudGVyMg==")
+	req.NotContains(string(encrypted), "the database secret")
+	req.NotContains(string(encrypted), "creationTimestamp")
+
+	doc := map[string]interface{}{}
+	req.NoError(yaml.Unmarshal(encrypted, &doc))
+
+	metadata := doc["sops"].(map[string]interface{})
+	req.Equal("2024-01-02T03:04:05Z", metadata["lastmodified"])
+	req.Equal(defaultSOPSEncryptedRegex, metadata["encrypted_regex"])
+
+	pgpKeys := metadata["pgp"].([]interface{})
+	req.Len(pgpKeys, 1)
+	pgpKey := pgpKeys[0].(map[string]interface{})
+	req.Equal(fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint), pgpKey["fp"])
+
+	block, err := armor.Decode(strings.NewReader(pgpKey["enc"].(string)))
+	req.NoError(err)
+	md, err := openpgp.ReadMessage(block.Body, openpgp.EntityList{entity}, nil, nil)
+	req.NoError(err)
+	dataKey, err := io.ReadAll(md.UnverifiedBody)
+	req.NoError(err)
+	req.Len(dataKey, sopsDataKeySize)
+
+	// values outside of the encrypted keys are not encrypted
+	req.Equal("Secret", doc["kind"])
+	req.Equal("db", doc["metadata"].(map[string]interface{})["name"])
+
+	req.True(strings.HasPrefix(doc["data"].(map[string]interface{})["password"].(string), "ENC[AES256_GCM,"))
+	req.Contains(doc["stringData"].(map[string]interface{})["port"], "type:int]")
+	req.Contains(doc["stringData"].(map[string]interface{})["enabled"], "type:bool]")
+	req.Contains(metadata["mac"], "type:str]")
+}
+
+// Test_sopsEncryptDecryptWithSOPS decrypts the encrypted secret with the sops binary, which also verifies the mac
+func Test_sopsEncryptDecryptWithSOPS(t *testing.T) {
+	sopsPath, err := exec.LookPath("sops")
+	if err != nil {
+		t.Skip("sops binary not found")
+	}
+
+	secret := `apiVersion: v1
+kind: Secret
+metadata:
+  name: db # the database secret
+  labels:
+    tier: backend
+  creationTimestamp: null
+data:
+  password: aHVudGVyMg==
+stringData:
+  port: 5432
+  ratio: 0.5
+  enabled: true
+  hosts:
+  - a.example.com
+  - b.example.com
+`
+	want := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata": map[string]interface{}{
+			"name":   "db",
+			"labels": map[string]interface{}{"tier": "backend"},
+		},
+		"data": map[string]interface{}{
+			"password": "aHVudGVyMg==",
+		},
+		"stringData": map[string]interface{}{
+			"port":    5432,
+			"ratio":   0.5,
+			"enabled": true,
+			"hosts":   []interface{}{"a.example.com", "b.example.com"},
+		},
+	}
+
+	t.Run("age", func(t *testing.T) {
+		req := require.New(t)
+
+		identity, err := age.GenerateX25519Identity()
+		req.NoError(err)
+		keys, err := parseSOPSKeys(map[string][]byte{"age": []byte(identity.Recipient().String())})
+		req.NoError(err)
+
+		encrypted, err := sopsEncrypt([]byte(secret), keys, regexp.MustCompile(defaultSOPSEncryptedRegex), defaultSOPSEncryptedRegex, time.Now().UTC())
+		req.NoError(err)
+
+		decrypted := testSOPSDecrypt(t, sopsPath, encrypted, "SOPS_AGE_KEY="+identity.String())
+		req.Equal(want, decrypted)
+	})
+
+	t.Run("pgp", func(t *testing.T) {
+		req := require.New(t)
+
+		gpgPath, err := exec.LookPath("gpg")
+		if err != nil {
+			t.Skip("gpg binary not found")
+		}
+
+		entity, err := openpgp.NewEntity("kots", "", "kots@example.com", nil)
+		req.NoError(err)
+
+		// sops decrypts the data key with the private key in the gpg keyring
+		gnupgHome, err := os.MkdirTemp("", "gnupg")
+		req.NoError(err)
+		defer os.RemoveAll(gnupgHome)
+
+		var privateKey bytes.Buffer
+		w, err := armor.Encode(&privateKey, openpgp.PrivateKeyType, nil)
+		req.NoError(err)
+		req.NoError(entity.SerializePrivate(w, nil))
+		req.NoError(w.Close())
+
+		cmd := exec.Command(gpgPath, "--batch", "--import")
+		cmd.Env = append(os.Environ(), "GNUPGHOME="+gnupgHome)
+		cmd.Stdin = &privateKey
+		out, err := cmd.CombinedOutput()
+		req.NoError(err, string(out))
+
+		var publicKey bytes.Buffer
+		w, err = armor.Encode(&publicKey, openpgp.PublicKeyType, nil)
+		req.NoError(err)
+		req.NoError(entity.Serialize(w))
+		req.NoError(w.Close())
+
+		keys, err := parseSOPSKeys(map[string][]byte{"pgp": publicKey.Bytes()})
+		req.NoError(err)
+
+		encrypted, err := sopsEncrypt([]byte(secret), keys, regexp.MustCompile(defaultSOPSEncryptedRegex), defaultSOPSEncryptedRegex, time.Now().UTC())
+		req.NoError(err)
+
+		decrypted := testSOPSDecrypt(t, sopsPath, encrypted, "GNUPGHOME="+gnupgHome)
+		req.Equal(want, decrypted)
+	})
+}
+
+func testSOPSDecrypt(t *testing.T, sopsPath string, encrypted []byte, env ...string) map[string]interface{} {
+	encryptedFile := filepath.Join(t.TempDir(), "secret.yaml")
+	require.NoError(t, os.WriteFile(encryptedFile, encrypted, 0600))
+
+	cmd := exec.Command(sopsPath, "--decrypt", "--input-type", "yaml", "--output-type", "yaml", encryptedFile)
+	cmd.Env = append(os.Environ(), env...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	require.NoError(t, err, stderr.String())
+
+	decrypted := map[string]interface{}{}
+	require.NoError(t, yaml.Unmarshal(out, &decrypted))
+	return decrypted
+}
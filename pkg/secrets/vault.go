@@ -0,0 +1,370 @@
+package secrets
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/util"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	jsonserializer "k8s.io/apimachinery/pkg/runtime/serializer/json"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+const (
+	vaultAnnotationPrefix = "vault.hashicorp.com/"
+	defaultVaultKVVersion = "2"
+)
+
+var invalidVaultAnnotationChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+type vaultConfig struct {
+	role      string
+	path      string
+	kvVersion string
+}
+
+// replaceSecretsWithVaultAnnotations removes Secrets from the archive and configures the Vault Agent injector to render
+// their keys as files where the Secrets were mounted. The values are expected in Vault at <path>/<name>, with a field
+// for each key in the Secret. Secrets that are not mounted as volumes, e.g. Secrets that are referenced from the
+// environment of a container, cannot be injected and are an error.
+//
+// Config:
+//   - role: the Vault role used by the agent (required)
+//   - path: the path in Vault that the secrets are read from (required)
+//   - kvVersion: the version of the KV secrets engine at the path, 1 or 2 (default)
+func replaceSecretsWithVaultAnnotations(archivePath string, selector labels.Selector, config map[string][]byte) error {
+	vc := vaultConfig{
+		role:      string(config["role"]),
+		path:      strings.TrimSuffix(string(config["path"]), "/"),
+		kvVersion: string(config["kvVersion"]),
+	}
+	if vc.role == "" {
+		return errors.New("role is required for the vault buildphase")
+	}
+	if vc.path == "" {
+		return errors.New("path is required for the vault buildphase")
+	}
+	if vc.kvVersion == "" {
+		vc.kvVersion = defaultVaultKVVersion
+	}
+	if vc.kvVersion != "1" && vc.kvVersion != "2" {
+		return errors.Errorf("unknown kv version %q", vc.kvVersion)
+	}
+
+	vaultSecrets, err := findSecrets(archivePath, selector)
+	if err != nil {
+		return errors.Wrap(err, "failed to find secrets")
+	}
+	if len(vaultSecrets) == 0 {
+		return nil
+	}
+
+	// workloads are updated in memory first so that the archive is not changed if a secret cannot be injected
+	updatedFiles := map[string][]byte{}
+	injected := map[string]bool{}
+	decode := scheme.Codecs.UniversalDeserializer().Decode
+	s := jsonserializer.NewYAMLSerializer(jsonserializer.DefaultMetaFactory, scheme.Scheme, scheme.Scheme)
+
+	err = filepath.Walk(archivePath, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		contents, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+
+		docs := util.ConvertToSingleDocs(contents)
+		updated := false
+		for i, doc := range docs {
+			decoded, gvk, err := decode(doc, nil, nil)
+			if err != nil {
+				continue
+			}
+
+			podSpec, podMeta, namespace := podTemplateForObject(decoded)
+			if podSpec == nil {
+				continue
+			}
+
+			workload := fmt.Sprintf("%s %s", gvk.Kind, objectName(decoded))
+			changed, err := injectVaultSecrets(podSpec, podMeta, namespace, workload, vaultSecrets, injected, vc)
+			if err != nil {
+				return err
+			}
+			if !changed {
+				continue
+			}
+
+			decoded.GetObjectKind().SetGroupVersionKind(*gvk)
+			var b bytes.Buffer
+			if err := s.Encode(decoded, &b); err != nil {
+				return errors.Wrapf(err, "failed to encode %s", workload)
+			}
+			docs[i] = b.Bytes()
+			updated = true
+		}
+
+		if updated {
+			updatedFiles[filePath] = bytes.Join(docs, []byte("\n---\n"))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to inject vault secrets")
+	}
+
+	for key := range vaultSecrets {
+		if !injected[key] {
+			return errors.Errorf("secret %s is not mounted by any workload and cannot be injected by the vault agent", key)
+		}
+	}
+
+	for filePath, contents := range updatedFiles {
+		if err := ioutil.WriteFile(filePath, contents, 0644); err != nil {
+			return errors.Wrap(err, "failed to write workload")
+		}
+	}
+
+	return replaceSecretsInPath(archivePath, selector, func(secret *v1.Secret) ([]byte, error) {
+		return nil, nil
+	})
+}
+
+// findSecrets returns the Secrets in the archive that match the selector, keyed by namespace/name
+func findSecrets(archivePath string, selector labels.Selector) (map[string]*v1.Secret, error) {
+	secretPaths, err := findPathsWithSecrets(archivePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get secrets in path")
+	}
+
+	secrets := map[string]*v1.Secret{}
+	decode := scheme.Codecs.UniversalDeserializer().Decode
+	for _, secretPath := range secretPaths {
+		contents, err := ioutil.ReadFile(secretPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read file")
+		}
+
+		for _, doc := range util.ConvertToSingleDocs(contents) {
+			decoded, _, err := decode(doc, nil, nil)
+			if err != nil {
+				continue
+			}
+			secret, ok := decoded.(*v1.Secret)
+			if !ok || !selector.Matches(labels.Set(secret.Labels)) {
+				continue
+			}
+			secrets[secretKey(secret.Namespace, secret.Name)] = secret
+		}
+	}
+
+	return secrets, nil
+}
+
+func secretKey(namespace string, name string) string {
+	if namespace == "" {
+		namespace = defaultSecretNamespace()
+	}
+	return fmt.Sprintf("%s/%s", namespace, name)
+}
+
+// injectVaultSecrets replaces the volumes of the vault secrets in the pod spec with vault agent annotations
+func injectVaultSecrets(podSpec *v1.PodSpec, podMeta *metav1.ObjectMeta, namespace string, workload string, vaultSecrets map[string]*v1.Secret, injected map[string]bool, vc vaultConfig) (bool, error) {
+	isVaultSecret := func(name string) bool {
+		_, ok := vaultSecrets[secretKey(namespace, name)]
+		return ok
+	}
+
+	for _, ref := range podSpec.ImagePullSecrets {
+		if isVaultSecret(ref.Name) {
+			return false, errors.Errorf("image pull secret %s of %s cannot be injected by the vault agent", ref.Name, workload)
+		}
+	}
+
+	allContainers := append(append([]v1.Container{}, podSpec.InitContainers...), podSpec.Containers...)
+	for _, container := range allContainers {
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.SecretRef != nil && isVaultSecret(envFrom.SecretRef.Name) {
+				return false, errors.Errorf("secret %s is referenced from the environment of %s and cannot be injected by the vault agent", envFrom.SecretRef.Name, workload)
+			}
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil && isVaultSecret(env.ValueFrom.SecretKeyRef.Name) {
+				return false, errors.Errorf("secret %s is referenced from the environment of %s and cannot be injected by the vault agent", env.ValueFrom.SecretKeyRef.Name, workload)
+			}
+		}
+	}
+
+	vaultVolumes := map[string]v1.Volume{}
+	volumes := []v1.Volume{}
+	for _, volume := range podSpec.Volumes {
+		if volume.Secret != nil && isVaultSecret(volume.Secret.SecretName) {
+			vaultVolumes[volume.Name] = volume
+			continue
+		}
+		volumes = append(volumes, volume)
+	}
+	if len(vaultVolumes) == 0 {
+		return false, nil
+	}
+
+	for _, container := range podSpec.InitContainers {
+		for _, mount := range container.VolumeMounts {
+			if _, ok := vaultVolumes[mount.Name]; ok {
+				return false, errors.Errorf("init container %s of %s mounts a secret that cannot be injected by the vault agent", container.Name, workload)
+			}
+		}
+	}
+
+	if podMeta.Annotations == nil {
+		podMeta.Annotations = map[string]string{}
+	}
+
+	injectContainers := map[string]bool{}
+	if existing := podMeta.Annotations[vaultAnnotationPrefix+"agent-inject-containers"]; existing != "" {
+		for _, name := range strings.Split(existing, ",") {
+			injectContainers[name] = true
+		}
+	}
+
+	for i, container := range podSpec.Containers {
+		mounts := []v1.VolumeMount{}
+		for _, mount := range container.VolumeMounts {
+			volume, ok := vaultVolumes[mount.Name]
+			if !ok {
+				mounts = append(mounts, mount)
+				continue
+			}
+
+			key := secretKey(namespace, volume.Secret.SecretName)
+			for _, file := range vaultSecretFiles(vaultSecrets[key], volume.Secret.Items, mount) {
+				addVaultFileAnnotations(podMeta.Annotations, volume.Secret.SecretName, file, vc)
+			}
+			injectContainers[container.Name] = true
+			injected[key] = true
+		}
+		podSpec.Containers[i].VolumeMounts = mounts
+	}
+
+	podSpec.Volumes = volumes
+
+	containerNames := []string{}
+	for name := range injectContainers {
+		containerNames = append(containerNames, name)
+	}
+	sort.Strings(containerNames)
+
+	podMeta.Annotations[vaultAnnotationPrefix+"agent-inject"] = "true"
+	podMeta.Annotations[vaultAnnotationPrefix+"role"] = vc.role
+	podMeta.Annotations[vaultAnnotationPrefix+"agent-inject-containers"] = strings.Join(containerNames, ",")
+
+	return true, nil
+}
+
+type vaultSecretFile struct {
+	key        string
+	volumePath string
+	fileName   string
+}
+
+// vaultSecretFiles returns the files that a mount of the secret volume contains
+func vaultSecretFiles(secret *v1.Secret, items []v1.KeyToPath, mount v1.VolumeMount) []vaultSecretFile {
+	if len(items) == 0 {
+		for _, key := range secretKeys(secret) {
+			items = append(items, v1.KeyToPath{Key: key, Path: key})
+		}
+	}
+
+	files := []vaultSecretFile{}
+	for _, item := range items {
+		if mount.SubPath != "" {
+			if item.Path != mount.SubPath {
+				continue
+			}
+			files = append(files, vaultSecretFile{
+				key:        item.Key,
+				volumePath: path.Dir(mount.MountPath),
+				fileName:   path.Base(mount.MountPath),
+			})
+			continue
+		}
+		files = append(files, vaultSecretFile{
+			key:        item.Key,
+			volumePath: path.Join(mount.MountPath, path.Dir(item.Path)),
+			fileName:   path.Base(item.Path),
+		})
+	}
+
+	return files
+}
+
+func addVaultFileAnnotations(annotations map[string]string, secretName string, file vaultSecretFile, vc vaultConfig) {
+	base := strings.Trim(invalidVaultAnnotationChars.ReplaceAllString(strings.ToLower(fmt.Sprintf("%s-%s", secretName, file.key)), "-"), "-")
+
+	// the same key can be mounted at more than one path
+	name := base
+	for i := 1; ; i++ {
+		existing, ok := annotations[vaultAnnotationPrefix+"secret-volume-path-"+name]
+		if !ok || (existing == file.volumePath && annotations[vaultAnnotationPrefix+"agent-inject-file-"+name] == file.fileName) {
+			break
+		}
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+
+	data := ".Data"
+	if vc.kvVersion == "2" {
+		data = ".Data.data"
+	}
+
+	annotations[vaultAnnotationPrefix+"agent-inject-secret-"+name] = fmt.Sprintf("%s/%s", vc.path, secretName)
+	annotations[vaultAnnotationPrefix+"agent-inject-template-"+name] = fmt.Sprintf(`{{- with secret "%s/%s" -}}{{ index %s %q }}{{- end }}`, vc.path, secretName, data, file.key)
+	annotations[vaultAnnotationPrefix+"secret-volume-path-"+name] = file.volumePath
+	annotations[vaultAnnotationPrefix+"agent-inject-file-"+name] = file.fileName
+}
+
+// podTemplateForObject returns the pod spec, the pod metadata and the namespace of workloads
+func podTemplateForObject(obj runtime.Object) (*v1.PodSpec, *metav1.ObjectMeta, string) {
+	switch o := obj.(type) {
+	case *v1.Pod:
+		return &o.Spec, &o.ObjectMeta, o.Namespace
+	case *appsv1.Deployment:
+		return &o.Spec.Template.Spec, &o.Spec.Template.ObjectMeta, o.Namespace
+	case *appsv1.StatefulSet:
+		return &o.Spec.Template.Spec, &o.Spec.Template.ObjectMeta, o.Namespace
+	case *appsv1.DaemonSet:
+		return &o.Spec.Template.Spec, &o.Spec.Template.ObjectMeta, o.Namespace
+	case *appsv1.ReplicaSet:
+		return &o.Spec.Template.Spec, &o.Spec.Template.ObjectMeta, o.Namespace
+	case *batchv1.Job:
+		return &o.Spec.Template.Spec, &o.Spec.Template.ObjectMeta, o.Namespace
+	case *batchv1.CronJob:
+		return &o.Spec.JobTemplate.Spec.Template.Spec, &o.Spec.JobTemplate.Spec.Template.ObjectMeta, o.Namespace
+	}
+	return nil, nil, ""
+}
+
+func objectName(obj runtime.Object) string {
+	if o, ok := obj.(metav1.Object); ok {
+		return o.GetName()
+	}
+	return ""
+}
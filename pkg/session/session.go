@@ -11,8 +11,10 @@ import (
 
 	"github.com/golang-jwt/jwt"
 	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/apitoken"
 	"github.com/replicatedhq/kots/pkg/identity"
 	"github.com/replicatedhq/kots/pkg/k8sutil"
+	"github.com/replicatedhq/kots/pkg/logger"
 	"github.com/replicatedhq/kots/pkg/session/types"
 	"github.com/replicatedhq/kots/pkg/store"
 	"github.com/replicatedhq/kots/pkg/util"
@@ -29,8 +31,12 @@ func Parse(kotsStore store.Store, signedToken string) (*types.Session, error) {
 	if len(tokenParts) != 2 {
 		return nil, errors.New("invalid number of components in authorization header")
 	}
-	if tokenParts[0] != "Bearer" && tokenParts[0] != "Kots" {
-		return nil, errors.New("expected bearer or kots token")
+	if tokenParts[0] != "Bearer" && tokenParts[0] != "Kots" && tokenParts[0] != "Token" {
+		return nil, errors.New("expected bearer, kots or api token")
+	}
+
+	if tokenParts[0] == "Token" || apitoken.IsAPIToken(tokenParts[1]) {
+		return parseAPIToken(kotsStore, tokenParts[1])
 	}
 
 	if tokenParts[0] == "Kots" {
@@ -60,6 +66,7 @@ func Parse(kotsStore store.Store, signedToken string) (*types.Session, error) {
 
 		s := types.Session{
 			ID:        "kots-cli",
+			UserID:    "kots-cli",
 			IssuedAt:  time.Now(),
 			ExpiresAt: time.Now().Add(time.Minute),
 			// TODO: super user permissions
@@ -90,6 +97,37 @@ func Parse(kotsStore store.Store, signedToken string) (*types.Session, error) {
 	return nil, errors.New("not a valid jwt token")
 }
 
+// parseAPIToken returns a session carrying the roles of the api token.
+// Expiration is checked by the caller, same as for login sessions.
+func parseAPIToken(kotsStore store.Store, token string) (*types.Session, error) {
+	apiToken, err := kotsStore.GetAPITokenByHash(apitoken.Hash(token))
+	if err != nil {
+		if kotsStore.IsNotFound(err) {
+			return nil, errors.New("invalid api token")
+		}
+		return nil, errors.Wrap(err, "failed to get api token")
+	}
+
+	now := time.Now()
+	if apiToken.LastUsedAt == nil || now.Sub(*apiToken.LastUsedAt) > time.Minute {
+		if err := kotsStore.UpdateAPITokenLastUsedAt(apiToken.ID, now); err != nil {
+			logger.Error(errors.Wrapf(err, "failed to update last used time of api token %s", apiToken.ID))
+		}
+	}
+
+	s := types.Session{
+		ID:         apiToken.ID,
+		UserID:     fmt.Sprintf("api-token:%s", apiToken.ID),
+		IssuedAt:   apiToken.CreatedAt,
+		ExpiresAt:  apiToken.ExpiresAt,
+		Roles:      apiToken.Roles,
+		HasRBAC:    true,
+		APITokenID: apiToken.ID,
+	}
+
+	return &s, nil
+}
+
 func SignJWT(s *types.Session) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"sessionId": s.ID,
@@ -102,16 +140,25 @@ func SignJWT(s *types.Session) (string, error) {
 	return signedToken, nil
 }
 
+// GetSessionRolesFromRBAC maps identity provider groups to the ids of the roles granted to the session.
+// Role ids can refer to default roles or to custom roles defined by the admin.
 func GetSessionRolesFromRBAC(sessionGroupIDs []string, groups []kotsv1beta1.IdentityConfigGroup) []string {
 	var sessionRolesIDs []string
+	addRoleIDs := func(roleIDs []string) {
+		for _, roleID := range roleIDs {
+			if !containsString(sessionRolesIDs, roleID) {
+				sessionRolesIDs = append(sessionRolesIDs, roleID)
+			}
+		}
+	}
 	for _, group := range groups {
 		if group.ID == identity.WildcardGroupID {
-			sessionRolesIDs = append(sessionRolesIDs, group.RoleIDs...)
+			addRoleIDs(group.RoleIDs)
 			continue
 		}
 		for _, groupID := range sessionGroupIDs {
 			if group.ID == groupID {
-				sessionRolesIDs = append(sessionRolesIDs, group.RoleIDs...)
+				addRoleIDs(group.RoleIDs)
 				break
 			}
 		}
@@ -119,6 +166,15 @@ func GetSessionRolesFromRBAC(sessionGroupIDs []string, groups []kotsv1beta1.Iden
 	return sessionRolesIDs
 }
 
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 func GetSessionCookie(responseToken string, expirationTime time.Time, origin string) (*http.Cookie, error) {
 	sessionCookie := http.Cookie{
 		Name:     "signed-token",
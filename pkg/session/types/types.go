@@ -4,8 +4,11 @@ import "time"
 
 type Session struct {
 	ID        string
+	UserID    string
 	IssuedAt  time.Time
 	ExpiresAt time.Time
 	Roles     []string
 	HasRBAC   bool
+	// APITokenID is set when the session was created from an api token rather than a login
+	APITokenID string `json:",omitempty"`
 }
@@ -2,6 +2,7 @@ package snapshotscheduler
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"time"
 
@@ -11,6 +12,9 @@ import (
 	snapshot "github.com/replicatedhq/kots/pkg/kotsadmsnapshot"
 	snapshottypes "github.com/replicatedhq/kots/pkg/kotsadmsnapshot/types"
 	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/metrics"
+	"github.com/replicatedhq/kots/pkg/notifications"
+	notificationtypes "github.com/replicatedhq/kots/pkg/notifications/types"
 	"github.com/replicatedhq/kots/pkg/store"
 	"github.com/replicatedhq/kots/pkg/util"
 	"k8s.io/apimachinery/pkg/util/rand"
@@ -49,6 +53,12 @@ func appScheduleLoop() {
 		}
 		if err := handleApp(a); err != nil {
 			logger.Error(errors.Wrapf(err, "failed to handle scheduled snapshots for app %s", a.ID))
+			notifications.Notify(notificationtypes.Event{
+				Type:    notificationtypes.EventTypeSnapshotFailed,
+				AppID:   a.ID,
+				AppSlug: a.Slug,
+				Message: fmt.Sprintf("Scheduled application snapshot failed: %v", err),
+			})
 		}
 	}
 }
@@ -63,6 +73,10 @@ func instanceScheduleLoop() {
 	for _, c := range clusters {
 		if err := handleCluster(c); err != nil {
 			logger.Error(errors.Wrapf(err, "failed to handle scheduled instance snapshots for cluster %s", c.ClusterID))
+			notifications.Notify(notificationtypes.Event{
+				Type:    notificationtypes.EventTypeSnapshotFailed,
+				Message: fmt.Sprintf("Scheduled instance snapshot for cluster %s failed: %v", c.Name, err),
+			})
 		}
 	}
 }
@@ -121,6 +135,7 @@ func handleApp(a *apptypes.App) error {
 	}
 
 	backup, err := snapshot.CreateApplicationBackup(context.Background(), a, true)
+	metrics.IncScheduledSnapshot("app", err)
 	if err != nil {
 		return errors.Wrap(err, "failed to create backup")
 	}
@@ -204,6 +219,7 @@ func handleCluster(c *downstreamtypes.Downstream) error {
 	}
 
 	backup, err := snapshot.CreateInstanceBackup(context.Background(), c, true)
+	metrics.IncScheduledSnapshot("instance", err)
 	if err != nil {
 		return errors.Wrap(err, "failed to create instance backup")
 	}
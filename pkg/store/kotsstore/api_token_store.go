@@ -0,0 +1,161 @@
+package kotsstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	apitokentypes "github.com/replicatedhq/kots/pkg/apitoken/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/persistence"
+	"github.com/rqlite/gorqlite"
+	"go.uber.org/zap"
+)
+
+func (s *KOTSStore) CreateAPIToken(token apitokentypes.APIToken, tokenHash string) error {
+	logger.Debug("creating api token",
+		zap.String("id", token.ID))
+
+	roles, err := json.Marshal(token.Roles)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal roles")
+	}
+
+	db := persistence.MustGetDBSession()
+	query := `insert into api_token (id, name, token_hash, roles, created_by, created_at, expires_at) values (?, ?, ?, ?, ?, ?, ?)`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{token.ID, token.Name, tokenHash, string(roles), token.CreatedBy, token.CreatedAt.Unix(), token.ExpiresAt.Unix()},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+func (s *KOTSStore) ListAPITokens() ([]apitokentypes.APIToken, error) {
+	db := persistence.MustGetDBSession()
+	query := `select id, name, roles, created_by, created_at, expires_at, last_used_at from api_token order by created_at desc`
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query: query,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+
+	tokens := []apitokentypes.APIToken{}
+	for rows.Next() {
+		token, err := apiTokenFromRow(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get api token from row")
+		}
+		tokens = append(tokens, *token)
+	}
+
+	return tokens, nil
+}
+
+func (s *KOTSStore) GetAPITokenByHash(tokenHash string) (*apitokentypes.APIToken, error) {
+	db := persistence.MustGetDBSession()
+	query := `select id, name, roles, created_by, created_at, expires_at, last_used_at from api_token where token_hash = ?`
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{tokenHash},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+	if !rows.Next() {
+		return nil, ErrNotFound
+	}
+
+	token, err := apiTokenFromRow(rows)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get api token from row")
+	}
+
+	return token, nil
+}
+
+func (s *KOTSStore) UpdateAPITokenLastUsedAt(id string, lastUsedAt time.Time) error {
+	db := persistence.MustGetDBSession()
+	query := `update api_token set last_used_at = ? where id = ?`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{lastUsedAt.Unix(), id},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+func (s *KOTSStore) DeleteAPIToken(id string) error {
+	logger.Debug("deleting api token",
+		zap.String("id", id))
+
+	db := persistence.MustGetDBSession()
+	query := `delete from api_token where id = ?`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{id},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+// DeleteAPITokensCreatedBy revokes the api tokens created by the user, e.g. when the user is disabled or deleted
+func (s *KOTSStore) DeleteAPITokensCreatedBy(createdBy string) error {
+	logger.Debug("deleting api tokens",
+		zap.String("createdBy", createdBy))
+
+	db := persistence.MustGetDBSession()
+	query := `delete from api_token where created_by = ?`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{createdBy},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+func apiTokenFromRow(row gorqlite.QueryResult) (*apitokentypes.APIToken, error) {
+	token := apitokentypes.APIToken{}
+
+	var name gorqlite.NullString
+	var roles gorqlite.NullString
+	var createdBy gorqlite.NullString
+	var createdAt gorqlite.NullInt64
+	var expiresAt int64
+	var lastUsedAt gorqlite.NullInt64
+
+	if err := row.Scan(&token.ID, &name, &roles, &createdBy, &createdAt, &expiresAt, &lastUsedAt); err != nil {
+		return nil, errors.Wrap(err, "failed to scan")
+	}
+
+	token.Name = name.String
+	token.CreatedBy = createdBy.String
+	token.CreatedAt = time.Unix(createdAt.Int64, 0)
+	token.ExpiresAt = time.Unix(expiresAt, 0)
+	if lastUsedAt.Valid {
+		t := time.Unix(lastUsedAt.Int64, 0)
+		token.LastUsedAt = &t
+	}
+
+	if roles.String != "" {
+		if err := json.Unmarshal([]byte(roles.String), &token.Roles); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal roles")
+		}
+	}
+
+	return &token, nil
+}
@@ -1,7 +1,10 @@
 package kotsstore
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
 
@@ -9,6 +12,7 @@ import (
 	"github.com/pkg/errors"
 	downstreamtypes "github.com/replicatedhq/kots/pkg/api/downstream/types"
 	apptypes "github.com/replicatedhq/kots/pkg/app/types"
+	"github.com/replicatedhq/kots/pkg/crypto"
 	"github.com/replicatedhq/kots/pkg/gitops"
 	"github.com/replicatedhq/kots/pkg/kotsutil"
 	"github.com/replicatedhq/kots/pkg/logger"
@@ -146,7 +150,7 @@ func (s *KOTSStore) GetAppIDFromSlug(slug string) (string, error) {
 
 func (s *KOTSStore) GetApp(id string) (*apptypes.App, error) {
 	db := persistence.MustGetDBSession()
-	query := `select id, name, license, upstream_uri, icon_uri, created_at, updated_at, slug, current_sequence, last_update_check_at, last_license_sync, is_airgap, snapshot_ttl_new, snapshot_schedule, restore_in_progress_name, restore_undeploy_status, update_checker_spec, semver_auto_deploy, install_state, channel_changed from app where id = ?`
+	query := `select id, name, license, upstream_uri, icon_uri, created_at, updated_at, slug, current_sequence, last_update_check_at, last_license_sync, is_airgap, snapshot_ttl_new, snapshot_schedule, restore_in_progress_name, restore_undeploy_status, update_checker_spec, semver_auto_deploy, deploy_strategy, deploy_verification_window, maintenance_window, pending_auto_deploy_sequence, install_state, channel_changed from app where id = ?`
 	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
 		Query:     query,
 		Arguments: []interface{}{id},
@@ -173,8 +177,12 @@ func (s *KOTSStore) GetApp(id string) (*apptypes.App, error) {
 	var restoreUndeployStatus gorqlite.NullString
 	var updateCheckerSpec gorqlite.NullString
 	var autoDeploy gorqlite.NullString
+	var deployStrategy gorqlite.NullString
+	var deployVerificationWindow gorqlite.NullString
+	var maintenanceWindow gorqlite.NullString
+	var pendingAutoDeploySequence gorqlite.NullInt64
 
-	if err := rows.Scan(&app.ID, &app.Name, &licenseStr, &upstreamURI, &iconURI, &app.CreatedAt, &updatedAt, &app.Slug, &currentSequence, &lastUpdateCheckAt, &lastLicenseSync, &app.IsAirgap, &snapshotTTLNew, &snapshotSchedule, &restoreInProgressName, &restoreUndeployStatus, &updateCheckerSpec, &autoDeploy, &app.InstallState, &app.ChannelChanged); err != nil {
+	if err := rows.Scan(&app.ID, &app.Name, &licenseStr, &upstreamURI, &iconURI, &app.CreatedAt, &updatedAt, &app.Slug, &currentSequence, &lastUpdateCheckAt, &lastLicenseSync, &app.IsAirgap, &snapshotTTLNew, &snapshotSchedule, &restoreInProgressName, &restoreUndeployStatus, &updateCheckerSpec, &autoDeploy, &deployStrategy, &deployVerificationWindow, &maintenanceWindow, &pendingAutoDeploySequence, &app.InstallState, &app.ChannelChanged); err != nil {
 		return nil, errors.Wrap(err, "failed to scan app")
 	}
 
@@ -187,6 +195,23 @@ func (s *KOTSStore) GetApp(id string) (*apptypes.App, error) {
 	app.RestoreUndeployStatus = apptypes.UndeployStatus(restoreUndeployStatus.String)
 	app.UpdateCheckerSpec = updateCheckerSpec.String
 	app.AutoDeploy = apptypes.AutoDeploy(autoDeploy.String)
+	app.DeployStrategy = apptypes.DeployStrategy(deployStrategy.String)
+	app.VerificationWindow = deployVerificationWindow.String
+
+	if app.DeployStrategy == "" {
+		app.DeployStrategy = apptypes.DeployStrategyDirect
+	}
+
+	if maintenanceWindow.String != "" {
+		app.MaintenanceWindow = &apptypes.MaintenanceWindow{}
+		if err := json.Unmarshal([]byte(maintenanceWindow.String), app.MaintenanceWindow); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal maintenance window")
+		}
+	}
+
+	if pendingAutoDeploySequence.Valid {
+		app.PendingAutoDeploySequence = &pendingAutoDeploySequence.Int64
+	}
 
 	if lastLicenseSync.Valid {
 		app.LastLicenseSync = lastLicenseSync.Time.Format(time.RFC3339)
@@ -486,6 +511,66 @@ func (s *KOTSStore) SetAutoDeploy(appID string, autoDeploy apptypes.AutoDeploy)
 	return nil
 }
 
+func (s *KOTSStore) SetMaintenanceWindow(appID string, maintenanceWindow *apptypes.MaintenanceWindow) error {
+	logger.Debug("setting maintenance window",
+		zap.String("appID", appID))
+
+	var marshalled *string
+	if maintenanceWindow != nil {
+		b, err := json.Marshal(maintenanceWindow)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal maintenance window")
+		}
+		str := string(b)
+		marshalled = &str
+	}
+
+	db := persistence.MustGetDBSession()
+	query := `update app set maintenance_window = ? where id = ?`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{marshalled, appID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+// SetPendingAutoDeploySequence queues a sequence to be deployed once the app's maintenance window opens.
+// A nil sequence clears the queued deploy.
+func (s *KOTSStore) SetPendingAutoDeploySequence(appID string, sequence *int64) error {
+	db := persistence.MustGetDBSession()
+	query := `update app set pending_auto_deploy_sequence = ? where id = ?`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{sequence, appID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+func (s *KOTSStore) SetDeployStrategy(appID string, deployStrategy apptypes.DeployStrategy, verificationWindow string) error {
+	logger.Debug("setting deploy strategy",
+		zap.String("appID", appID))
+
+	db := persistence.MustGetDBSession()
+	query := `update app set deploy_strategy = ?, deploy_verification_window = ? where id = ?`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{deployStrategy, verificationWindow, appID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
 func (s *KOTSStore) SetSnapshotTTL(appID string, snapshotTTL string) error {
 	logger.Debug("Setting snapshot TTL",
 		zap.String("appID", appID))
@@ -534,6 +619,16 @@ func (s *KOTSStore) RemoveApp(appID string) error {
 		Arguments: []interface{}{appID},
 	})
 
+	statements = append(statements, gorqlite.ParameterizedStatement{
+		Query:     "delete from app_status_history where app_id = ?",
+		Arguments: []interface{}{appID},
+	})
+
+	statements = append(statements, gorqlite.ParameterizedStatement{
+		Query:     "delete from app_drift where app_id = ?",
+		Arguments: []interface{}{appID},
+	})
+
 	statements = append(statements, gorqlite.ParameterizedStatement{
 		Query:     "delete from app_downstream_output where app_id = ?",
 		Arguments: []interface{}{appID},
@@ -594,3 +689,22 @@ func (s *KOTSStore) SetAppChannelChanged(appID string, channelChanged bool) erro
 
 	return nil
 }
+
+// SetAppUpstreamCredentials stores the username and password of a git upstream encrypted, so that they are not persisted in the upstream uri
+func (s *KOTSStore) SetAppUpstreamCredentials(appID string, username string, password string) error {
+	db := persistence.MustGetDBSession()
+
+	credentials := url.UserPassword(username, password).String()
+	credentialsEnc := base64.StdEncoding.EncodeToString(crypto.Encrypt([]byte(credentials)))
+
+	query := `update app set upstream_credentials_enc = ? where id = ?`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{credentialsEnc, appID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update app upstream credentials: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
@@ -9,6 +9,12 @@ import (
 	appstatetypes "github.com/replicatedhq/kots/pkg/appstate/types"
 	"github.com/replicatedhq/kots/pkg/persistence"
 	"github.com/rqlite/gorqlite"
+	"github.com/segmentio/ksuid"
+)
+
+const (
+	// appStatusHistoryMaxRows is the maximum number of resource state transitions kept per app
+	appStatusHistoryMaxRows = 1000
 )
 
 func (s *KOTSStore) GetAppStatus(appID string) (*appstatetypes.AppStatus, error) {
@@ -68,7 +74,14 @@ func (s *KOTSStore) SetAppStatus(appID string, resourceStates appstatetypes.Reso
 		return errors.Wrap(err, "failed to json marshal resource states")
 	}
 
+	previousAppStatus, err := s.GetAppStatus(appID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get previous app status")
+	}
+
 	db := persistence.MustGetDBSession()
+	statements := []gorqlite.ParameterizedStatement{}
+
 	query := `
 	insert into app_status (app_id, resource_states, updated_at, sequence)
 	values (?, ?, ?, ?)
@@ -76,13 +89,135 @@ func (s *KOTSStore) SetAppStatus(appID string, resourceStates appstatetypes.Reso
 	  resource_states = EXCLUDED.resource_states,
 	  updated_at = EXCLUDED.updated_at,
 	  sequence = EXCLUDED.sequence`
-	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+	statements = append(statements, gorqlite.ParameterizedStatement{
 		Query:     query,
 		Arguments: []interface{}{appID, string(marshalledResourceStates), updatedAt.Unix(), sequence},
 	})
-	if err != nil {
-		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+
+	transitions := getResourceStateTransitions(previousAppStatus.ResourceStates, resourceStates, sequence, updatedAt)
+	for _, transition := range transitions {
+		query := `insert into app_status_history (id, app_id, sequence, kind, name, namespace, previous_state, state, created_at) values (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		statements = append(statements, gorqlite.ParameterizedStatement{
+			Query: query,
+			Arguments: []interface{}{
+				ksuid.New().String(),
+				appID,
+				transition.Sequence,
+				transition.Kind,
+				transition.Name,
+				transition.Namespace,
+				string(transition.PreviousState),
+				string(transition.State),
+				transition.CreatedAt.UnixMilli(),
+			},
+		})
+	}
+
+	if len(transitions) > 0 {
+		query := `delete from app_status_history where app_id = ? and id not in (
+	select id from app_status_history where app_id = ? order by created_at desc, id desc limit ?
+)`
+		statements = append(statements, gorqlite.ParameterizedStatement{
+			Query:     query,
+			Arguments: []interface{}{appID, appID, appStatusHistoryMaxRows},
+		})
+	}
+
+	if wrs, err := db.WriteParameterized(statements); err != nil {
+		wrErrs := []error{}
+		for _, wr := range wrs {
+			wrErrs = append(wrErrs, wr.Err)
+		}
+		return fmt.Errorf("failed to write: %v: %v", err, wrErrs)
 	}
 
 	return nil
 }
+
+// GetAppStatusHistory returns the resource state transitions of an app in chronological order.
+// If sequence is not nil, only the transitions that happened while that sequence was deployed are returned.
+func (s *KOTSStore) GetAppStatusHistory(appID string, sequence *int64) ([]appstatetypes.ResourceStateTransition, error) {
+	db := persistence.MustGetDBSession()
+	query := `select sequence, kind, name, namespace, previous_state, state, created_at from app_status_history where app_id = ?`
+	args := []interface{}{appID}
+	if sequence != nil {
+		query += ` and sequence = ?`
+		args = append(args, *sequence)
+	}
+	query += ` order by created_at asc, id asc`
+
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: args,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+
+	transitions := []appstatetypes.ResourceStateTransition{}
+	for rows.Next() {
+		var transition appstatetypes.ResourceStateTransition
+		var previousState gorqlite.NullString
+		var state gorqlite.NullString
+		var createdAt int64
+
+		if err := rows.Scan(&transition.Sequence, &transition.Kind, &transition.Name, &transition.Namespace, &previousState, &state, &createdAt); err != nil {
+			return nil, errors.Wrap(err, "failed to scan")
+		}
+
+		transition.PreviousState = appstatetypes.State(previousState.String)
+		transition.State = appstatetypes.State(state.String)
+		transition.CreatedAt = time.UnixMilli(createdAt)
+
+		transitions = append(transitions, transition)
+	}
+
+	setResourceStateTransitionEndTimes(transitions)
+
+	return transitions, nil
+}
+
+// getResourceStateTransitions returns a transition for every resource whose state differs from its previous state
+func getResourceStateTransitions(previous appstatetypes.ResourceStates, next appstatetypes.ResourceStates, sequence int64, updatedAt time.Time) []appstatetypes.ResourceStateTransition {
+	previousStates := map[string]appstatetypes.State{}
+	for _, r := range previous {
+		previousStates[resourceStateKey(r.Kind, r.Namespace, r.Name)] = r.State
+	}
+
+	transitions := []appstatetypes.ResourceStateTransition{}
+	for _, r := range next {
+		previousState := previousStates[resourceStateKey(r.Kind, r.Namespace, r.Name)]
+		if previousState == r.State {
+			continue
+		}
+		transitions = append(transitions, appstatetypes.ResourceStateTransition{
+			Kind:          r.Kind,
+			Name:          r.Name,
+			Namespace:     r.Namespace,
+			PreviousState: previousState,
+			State:         r.State,
+			Sequence:      sequence,
+			CreatedAt:     updatedAt,
+		})
+	}
+
+	return transitions
+}
+
+// setResourceStateTransitionEndTimes sets the end time of each transition to the time of the next transition
+// of the same resource. Transitions must be in chronological order.
+func setResourceStateTransitionEndTimes(transitions []appstatetypes.ResourceStateTransition) {
+	last := map[string]int{}
+	for i, t := range transitions {
+		key := resourceStateKey(t.Kind, t.Namespace, t.Name)
+		if j, ok := last[key]; ok {
+			endedAt := t.CreatedAt
+			transitions[j].EndedAt = &endedAt
+		}
+		last[key] = i
+	}
+}
+
+func resourceStateKey(kind string, namespace string, name string) string {
+	return fmt.Sprintf("%s/%s/%s", namespace, kind, name)
+}
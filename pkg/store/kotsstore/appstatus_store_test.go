@@ -0,0 +1,51 @@
+package kotsstore
+
+import (
+	"testing"
+	"time"
+
+	appstatetypes "github.com/replicatedhq/kots/pkg/appstate/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_getResourceStateTransitions(t *testing.T) {
+	updatedAt := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	previous := appstatetypes.ResourceStates{
+		{Kind: "deployment", Name: "web", Namespace: "default", State: appstatetypes.StateReady},
+		{Kind: "service", Name: "web", Namespace: "default", State: appstatetypes.StateReady},
+	}
+	next := appstatetypes.ResourceStates{
+		{Kind: "deployment", Name: "web", Namespace: "default", State: appstatetypes.StateDegraded},
+		{Kind: "service", Name: "web", Namespace: "default", State: appstatetypes.StateReady},
+		{Kind: "statefulset", Name: "db", Namespace: "default", State: appstatetypes.StateMissing},
+	}
+
+	got := getResourceStateTransitions(previous, next, 2, updatedAt)
+	assert.Equal(t, []appstatetypes.ResourceStateTransition{
+		{Kind: "deployment", Name: "web", Namespace: "default", PreviousState: appstatetypes.StateReady, State: appstatetypes.StateDegraded, Sequence: 2, CreatedAt: updatedAt},
+		{Kind: "statefulset", Name: "db", Namespace: "default", PreviousState: "", State: appstatetypes.StateMissing, Sequence: 2, CreatedAt: updatedAt},
+	}, got)
+
+	assert.Empty(t, getResourceStateTransitions(next, next, 2, updatedAt))
+}
+
+func Test_setResourceStateTransitionEndTimes(t *testing.T) {
+	t0 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Minute)
+	t2 := t0.Add(2 * time.Minute)
+
+	transitions := []appstatetypes.ResourceStateTransition{
+		{Kind: "deployment", Name: "web", Namespace: "default", State: appstatetypes.StateReady, CreatedAt: t0},
+		{Kind: "service", Name: "web", Namespace: "default", State: appstatetypes.StateReady, CreatedAt: t0},
+		{Kind: "deployment", Name: "web", Namespace: "default", State: appstatetypes.StateDegraded, CreatedAt: t1},
+		{Kind: "deployment", Name: "web", Namespace: "default", State: appstatetypes.StateReady, CreatedAt: t2},
+	}
+
+	setResourceStateTransitionEndTimes(transitions)
+
+	assert.Equal(t, &t1, transitions[0].EndedAt)
+	assert.Nil(t, transitions[1].EndedAt)
+	assert.Equal(t, &t2, transitions[2].EndedAt)
+	assert.Nil(t, transitions[3].EndedAt)
+}
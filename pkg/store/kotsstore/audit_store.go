@@ -0,0 +1,176 @@
+package kotsstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	audittypes "github.com/replicatedhq/kots/pkg/audit/types"
+	"github.com/replicatedhq/kots/pkg/persistence"
+	"github.com/rqlite/gorqlite"
+	"github.com/segmentio/ksuid"
+)
+
+func (s *KOTSStore) CreateAuditEvent(event audittypes.Event) error {
+	if event.ID == "" {
+		event.ID = ksuid.New().String()
+	}
+
+	roles, err := json.Marshal(event.Actor.Roles)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal roles")
+	}
+
+	details, err := json.Marshal(event.Details)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal details")
+	}
+
+	db := persistence.MustGetDBSession()
+	query := `insert into audit_log (id, created_at, user_id, session_id, roles, remote_addr, action, resource, method, path, details, status_code, outcome) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query: query,
+		Arguments: []interface{}{
+			event.ID,
+			event.CreatedAt.UnixMilli(),
+			event.Actor.UserID,
+			event.Actor.SessionID,
+			string(roles),
+			event.Actor.RemoteAddr,
+			event.Action,
+			event.Resource,
+			event.Method,
+			event.Path,
+			string(details),
+			event.StatusCode,
+			string(event.Outcome),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+// ListAuditEvents returns the events matching the options, newest first, along with the total number of matching events
+func (s *KOTSStore) ListAuditEvents(opts audittypes.ListOptions) ([]audittypes.Event, int64, error) {
+	db := persistence.MustGetDBSession()
+
+	where, args := auditEventsFilter(opts)
+
+	query := `select count(1) from audit_log` + where
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: args,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query count: %v: %v", err, rows.Err)
+	}
+	var totalCount int64
+	if rows.Next() {
+		if err := rows.Scan(&totalCount); err != nil {
+			return nil, 0, errors.Wrap(err, "failed to scan count")
+		}
+	}
+
+	query = `select id, created_at, user_id, session_id, roles, remote_addr, action, resource, method, path, details, status_code, outcome from audit_log` + where + ` order by created_at desc, id desc`
+	if opts.PageSize > 0 {
+		query += ` limit ? offset ?`
+		args = append(args, opts.PageSize, opts.CurrentPage*opts.PageSize)
+	}
+
+	rows, err = db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: args,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+
+	events := []audittypes.Event{}
+	for rows.Next() {
+		event, err := auditEventFromRow(rows)
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "failed to get audit event from row")
+		}
+		events = append(events, *event)
+	}
+
+	return events, totalCount, nil
+}
+
+func auditEventsFilter(opts audittypes.ListOptions) (string, []interface{}) {
+	conditions := []string{}
+	args := []interface{}{}
+
+	if opts.Since != nil {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, opts.Since.UnixMilli())
+	}
+	if opts.Until != nil {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, opts.Until.UnixMilli())
+	}
+	if opts.UserID != "" {
+		conditions = append(conditions, "user_id = ?")
+		args = append(args, opts.UserID)
+	}
+	if opts.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, opts.Action)
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return " where " + strings.Join(conditions, " and "), args
+}
+
+func auditEventFromRow(row gorqlite.QueryResult) (*audittypes.Event, error) {
+	event := audittypes.Event{}
+
+	var createdAt int64
+	var userID gorqlite.NullString
+	var sessionID gorqlite.NullString
+	var roles gorqlite.NullString
+	var remoteAddr gorqlite.NullString
+	var action gorqlite.NullString
+	var resource gorqlite.NullString
+	var method gorqlite.NullString
+	var path gorqlite.NullString
+	var details gorqlite.NullString
+	var statusCode gorqlite.NullInt64
+	var outcome gorqlite.NullString
+
+	if err := row.Scan(&event.ID, &createdAt, &userID, &sessionID, &roles, &remoteAddr, &action, &resource, &method, &path, &details, &statusCode, &outcome); err != nil {
+		return nil, errors.Wrap(err, "failed to scan")
+	}
+
+	event.CreatedAt = time.UnixMilli(createdAt)
+	event.Actor.UserID = userID.String
+	event.Actor.SessionID = sessionID.String
+	event.Actor.RemoteAddr = remoteAddr.String
+	event.Action = action.String
+	event.Resource = resource.String
+	event.Method = method.String
+	event.Path = path.String
+	event.StatusCode = int(statusCode.Int64)
+	event.Outcome = audittypes.Outcome(outcome.String)
+
+	if roles.String != "" {
+		if err := json.Unmarshal([]byte(roles.String), &event.Actor.Roles); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal roles")
+		}
+	}
+
+	if details.String != "" {
+		if err := json.Unmarshal([]byte(details.String), &event.Details); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal details")
+		}
+	}
+
+	return &event, nil
+}
@@ -0,0 +1,47 @@
+package kotsstore
+
+import (
+	"testing"
+	"time"
+
+	audittypes "github.com/replicatedhq/kots/pkg/audit/types"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_auditEventsFilter(t *testing.T) {
+	since := time.UnixMilli(1000)
+	until := time.UnixMilli(2000)
+
+	tests := []struct {
+		name      string
+		opts      audittypes.ListOptions
+		wantWhere string
+		wantArgs  []interface{}
+	}{
+		{
+			name:      "no filters",
+			opts:      audittypes.ListOptions{PageSize: 20},
+			wantWhere: "",
+			wantArgs:  []interface{}{},
+		},
+		{
+			name:      "all filters",
+			opts:      audittypes.ListOptions{Since: &since, Until: &until, UserID: "kots-user", Action: "DeployAppVersion"},
+			wantWhere: " where created_at >= ? and created_at < ? and user_id = ? and action = ?",
+			wantArgs:  []interface{}{int64(1000), int64(2000), "kots-user", "DeployAppVersion"},
+		},
+		{
+			name:      "action only",
+			opts:      audittypes.ListOptions{Action: "Login"},
+			wantWhere: " where action = ?",
+			wantArgs:  []interface{}{"Login"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, args := auditEventsFilter(tt.opts)
+			require.Equal(t, tt.wantWhere, where)
+			require.Equal(t, tt.wantArgs, args)
+		})
+	}
+}
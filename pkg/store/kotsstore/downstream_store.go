@@ -10,6 +10,7 @@ import (
 	"github.com/pkg/errors"
 	downstreamtypes "github.com/replicatedhq/kots/pkg/api/downstream/types"
 	"github.com/replicatedhq/kots/pkg/cursor"
+	gitopstypes "github.com/replicatedhq/kots/pkg/gitops/types"
 	"github.com/replicatedhq/kots/pkg/kotsutil"
 	"github.com/replicatedhq/kots/pkg/logger"
 	"github.com/replicatedhq/kots/pkg/persistence"
@@ -260,6 +261,9 @@ func (s *KOTSStore) GetCurrentDownstreamVersion(appID string, clusterID string)
 	adv.source,
 	adv.preflight_skipped,
 	adv.git_commit_url,
+	adv.git_pr_url,
+	adv.git_pr_number,
+	adv.git_pr_state,
 	adv.git_deployable,
 	ado.is_error,
 	av.upstream_released_at,
@@ -355,6 +359,9 @@ func (s *KOTSStore) GetDownstreamVersions(appID string, clusterID string, downlo
 	adv.source,
 	adv.preflight_skipped,
 	adv.git_commit_url,
+	adv.git_pr_url,
+	adv.git_pr_number,
+	adv.git_pr_state,
 	adv.git_deployable,
 	ado.is_error,
 	av.upstream_released_at,
@@ -765,6 +772,9 @@ func (s *KOTSStore) downstreamVersionFromRow(appID string, row gorqlite.QueryRes
 	var source gorqlite.NullString
 	var preflightSkipped gorqlite.NullBool
 	var commitURL gorqlite.NullString
+	var pullRequestURL gorqlite.NullString
+	var pullRequestNumber gorqlite.NullInt64
+	var pullRequestState gorqlite.NullString
 	var gitDeployable gorqlite.NullBool
 	var hasError gorqlite.NullBool
 	var upstreamReleasedAt gorqlite.NullTime
@@ -778,6 +788,9 @@ func (s *KOTSStore) downstreamVersionFromRow(appID string, row gorqlite.QueryRes
 		&source,
 		&preflightSkipped,
 		&commitURL,
+		&pullRequestURL,
+		&pullRequestNumber,
+		&pullRequestState,
 		&gitDeployable,
 		&hasError,
 		&upstreamReleasedAt,
@@ -815,6 +828,9 @@ func (s *KOTSStore) downstreamVersionFromRow(appID string, row gorqlite.QueryRes
 	v.Source = source.String
 	v.PreflightSkipped = preflightSkipped.Bool
 	v.CommitURL = commitURL.String
+	v.PullRequestURL = pullRequestURL.String
+	v.PullRequestNumber = pullRequestNumber.Int64
+	v.PullRequestState = pullRequestState.String
 	v.GitDeployable = gitDeployable.Bool
 
 	if upstreamReleasedAt.Valid {
@@ -1196,3 +1212,51 @@ func (s *KOTSStore) DeleteDownstreamDeployStatus(appID string, clusterID string,
 
 	return nil
 }
+
+// ListOpenDownstreamPullRequests returns the gitops pull requests that have not been merged or closed yet
+func (s *KOTSStore) ListOpenDownstreamPullRequests() ([]downstreamtypes.DownstreamPullRequest, error) {
+	db := persistence.MustGetDBSession()
+
+	query := `select app_id, cluster_id, sequence, git_pr_number, git_pr_url, git_pr_state from app_downstream_version where git_pr_state = ? order by sequence asc`
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{string(gitopstypes.PullRequestStateOpen)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+
+	pullRequests := []downstreamtypes.DownstreamPullRequest{}
+	for rows.Next() {
+		var pr downstreamtypes.DownstreamPullRequest
+		var number gorqlite.NullInt64
+		var url gorqlite.NullString
+		var state gorqlite.NullString
+		if err := rows.Scan(&pr.AppID, &pr.ClusterID, &pr.Sequence, &number, &url, &state); err != nil {
+			return nil, errors.Wrap(err, "failed to scan")
+		}
+		pr.Number = number.Int64
+		pr.URL = url.String
+		pr.State = state.String
+		pullRequests = append(pullRequests, pr)
+	}
+
+	return pullRequests, nil
+}
+
+// SetDownstreamVersionPullRequestState updates the state of the gitops pull request opened for the downstream version.
+// A merged pull request makes the version the one that is deployed by the gitops operator.
+func (s *KOTSStore) SetDownstreamVersionPullRequestState(appID string, clusterID string, sequence int64, state gitopstypes.PullRequestState) error {
+	db := persistence.MustGetDBSession()
+
+	query := `update app_downstream_version set git_pr_state = ? where app_id = ? and cluster_id = ? and sequence = ?`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{string(state), appID, clusterID, sequence},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package kotsstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	drifttypes "github.com/replicatedhq/kots/pkg/drift/types"
+	"github.com/replicatedhq/kots/pkg/persistence"
+	"github.com/rqlite/gorqlite"
+)
+
+// GetAppDriftReport returns the last drift report of an app, or nil if drift has not been checked yet
+func (s *KOTSStore) GetAppDriftReport(appID string) (*drifttypes.DriftReport, error) {
+	db := persistence.MustGetDBSession()
+	query := `select sequence, checked_at, checked_resources, resources, remediated from app_drift where app_id = ?`
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{appID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+
+	if !rows.Next() {
+		return nil, nil
+	}
+
+	var checkedAt int64
+	var resourcesStr gorqlite.NullString
+	report := drifttypes.DriftReport{
+		AppID:     appID,
+		Resources: []drifttypes.ResourceDrift{},
+	}
+
+	if err := rows.Scan(&report.Sequence, &checkedAt, &report.CheckedResources, &resourcesStr, &report.Remediated); err != nil {
+		return nil, errors.Wrap(err, "failed to scan")
+	}
+
+	report.CheckedAt = time.UnixMilli(checkedAt)
+
+	if resourcesStr.Valid && resourcesStr.String != "" {
+		if err := json.Unmarshal([]byte(resourcesStr.String), &report.Resources); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal resources")
+		}
+	}
+
+	return &report, nil
+}
+
+// SetAppDriftReport replaces the drift report of an app
+func (s *KOTSStore) SetAppDriftReport(report *drifttypes.DriftReport) error {
+	marshalledResources, err := json.Marshal(report.Resources)
+	if err != nil {
+		return errors.Wrap(err, "failed to json marshal resources")
+	}
+
+	db := persistence.MustGetDBSession()
+	query := `
+	insert into app_drift (app_id, sequence, checked_at, checked_resources, resources, remediated)
+	values (?, ?, ?, ?, ?, ?)
+	on conflict (app_id) do update set
+	  sequence = EXCLUDED.sequence,
+	  checked_at = EXCLUDED.checked_at,
+	  checked_resources = EXCLUDED.checked_resources,
+	  resources = EXCLUDED.resources,
+	  remediated = EXCLUDED.remediated`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{report.AppID, report.Sequence, report.CheckedAt.UnixMilli(), report.CheckedResources, string(marshalledResources), report.Remediated},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
@@ -0,0 +1,302 @@
+package kotsstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/persistence"
+	usertypes "github.com/replicatedhq/kots/pkg/user/types"
+	"github.com/rqlite/gorqlite"
+	"go.uber.org/zap"
+)
+
+// maxLocalUserPasswordHistory is the number of previous password hashes kept for each local user,
+// it matches the maximum history of the password policy
+const maxLocalUserPasswordHistory = 24
+
+func (s *KOTSStore) CreateLocalUser(user usertypes.LocalUser, passwordBcrypt []byte) error {
+	logger.Debug("creating local user",
+		zap.String("username", user.Username))
+
+	roles, err := json.Marshal(user.Roles)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal roles")
+	}
+
+	db := persistence.MustGetDBSession()
+	query := `insert into local_user (username, password_bcrypt, roles, is_disabled, created_by, created_at, updated_at) values (?, ?, ?, ?, ?, ?, ?)`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{user.Username, string(passwordBcrypt), string(roles), user.IsDisabled, user.CreatedBy, user.CreatedAt.Unix(), user.UpdatedAt.Unix()},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+func (s *KOTSStore) ListLocalUsers() ([]usertypes.LocalUser, error) {
+	db := persistence.MustGetDBSession()
+	query := `select username, roles, is_disabled, created_by, created_at, updated_at, last_login_at, failed_logins from local_user order by username`
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query: query,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+
+	users := []usertypes.LocalUser{}
+	for rows.Next() {
+		user, err := localUserFromRow(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get local user from row")
+		}
+		users = append(users, *user)
+	}
+
+	return users, nil
+}
+
+func (s *KOTSStore) GetLocalUser(username string) (*usertypes.LocalUser, error) {
+	db := persistence.MustGetDBSession()
+	query := `select username, roles, is_disabled, created_by, created_at, updated_at, last_login_at, failed_logins from local_user where username = ?`
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{username},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+	if !rows.Next() {
+		return nil, ErrNotFound
+	}
+
+	user, err := localUserFromRow(rows)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get local user from row")
+	}
+
+	return user, nil
+}
+
+func (s *KOTSStore) GetLocalUserPasswordBcrypt(username string) ([]byte, error) {
+	db := persistence.MustGetDBSession()
+	query := `select password_bcrypt from local_user where username = ?`
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{username},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+	if !rows.Next() {
+		return nil, ErrNotFound
+	}
+
+	var passwordBcrypt string
+	if err := rows.Scan(&passwordBcrypt); err != nil {
+		return nil, errors.Wrap(err, "failed to scan")
+	}
+
+	return []byte(passwordBcrypt), nil
+}
+
+// GetLocalUserPasswordHistory returns the previous password hashes of the user, most recent first
+func (s *KOTSStore) GetLocalUserPasswordHistory(username string) ([][]byte, error) {
+	db := persistence.MustGetDBSession()
+	query := `select password_history from local_user where username = ?`
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{username},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+	if !rows.Next() {
+		return nil, ErrNotFound
+	}
+
+	var passwordHistory gorqlite.NullString
+	if err := rows.Scan(&passwordHistory); err != nil {
+		return nil, errors.Wrap(err, "failed to scan")
+	}
+
+	return parsePasswordHistory(passwordHistory.String), nil
+}
+
+func (s *KOTSStore) UpdateLocalUser(username string, roles []string, isDisabled bool) error {
+	logger.Debug("updating local user",
+		zap.String("username", username))
+
+	marshalledRoles, err := json.Marshal(roles)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal roles")
+	}
+
+	db := persistence.MustGetDBSession()
+	query := `update local_user set roles = ?, is_disabled = ?, updated_at = ? where username = ?`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{string(marshalledRoles), isDisabled, time.Now().Unix(), username},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+// SetLocalUserPasswordBcrypt sets the password of the user and clears its failed logins.
+// The previous password hash is added to the password history of the user.
+func (s *KOTSStore) SetLocalUserPasswordBcrypt(username string, passwordBcrypt []byte) error {
+	logger.Debug("setting local user password",
+		zap.String("username", username))
+
+	currentBcrypt, err := s.GetLocalUserPasswordBcrypt(username)
+	if err != nil {
+		return errors.Wrap(err, "failed to get current password")
+	}
+	history, err := s.GetLocalUserPasswordHistory(username)
+	if err != nil {
+		return errors.Wrap(err, "failed to get password history")
+	}
+
+	history = append([][]byte{currentBcrypt}, history...)
+	if len(history) > maxLocalUserPasswordHistory {
+		history = history[:maxLocalUserPasswordHistory]
+	}
+
+	db := persistence.MustGetDBSession()
+	query := `update local_user set password_bcrypt = ?, password_history = ?, failed_logins = NULL, updated_at = ? where username = ?`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{string(passwordBcrypt), formatPasswordHistory(history), time.Now().Unix(), username},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+func (s *KOTSStore) FlagLocalUserInvalidPassword(username string) error {
+	user, err := s.GetLocalUser(username)
+	if err != nil {
+		return errors.Wrap(err, "failed to get local user")
+	}
+
+	failedLogins := append(user.FailedLogins, time.Now())
+	if len(failedLogins) > maxFailedLogins {
+		failedLogins = failedLogins[len(failedLogins)-maxFailedLogins:]
+	}
+
+	db := persistence.MustGetDBSession()
+	query := `update local_user set failed_logins = ? where username = ?`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{formatFailedLogins(failedLogins), username},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+func (s *KOTSStore) FlagLocalUserSuccessfulLogin(username string) error {
+	db := persistence.MustGetDBSession()
+	query := `update local_user set failed_logins = NULL, last_login_at = ? where username = ?`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{time.Now().Unix(), username},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+func (s *KOTSStore) DeleteLocalUser(username string) error {
+	logger.Debug("deleting local user",
+		zap.String("username", username))
+
+	db := persistence.MustGetDBSession()
+	query := `delete from local_user where username = ?`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{username},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+func parsePasswordHistory(value string) [][]byte {
+	hashes := [][]byte{}
+	for _, hash := range strings.Split(value, "\n") {
+		if hash != "" {
+			hashes = append(hashes, []byte(hash))
+		}
+	}
+	return hashes
+}
+
+func formatPasswordHistory(hashes [][]byte) string {
+	fields := []string{}
+	for _, hash := range hashes {
+		fields = append(fields, string(hash))
+	}
+	return strings.Join(fields, "\n")
+}
+
+func localUserFromRow(row gorqlite.QueryResult) (*usertypes.LocalUser, error) {
+	user := usertypes.LocalUser{}
+
+	var roles gorqlite.NullString
+	var isDisabled gorqlite.NullBool
+	var createdBy gorqlite.NullString
+	var createdAt gorqlite.NullInt64
+	var updatedAt gorqlite.NullInt64
+	var lastLoginAt gorqlite.NullInt64
+	var failedLogins gorqlite.NullString
+
+	if err := row.Scan(&user.Username, &roles, &isDisabled, &createdBy, &createdAt, &updatedAt, &lastLoginAt, &failedLogins); err != nil {
+		return nil, errors.Wrap(err, "failed to scan")
+	}
+
+	user.IsDisabled = isDisabled.Bool
+	user.CreatedBy = createdBy.String
+	user.CreatedAt = time.Unix(createdAt.Int64, 0)
+	user.UpdatedAt = time.Unix(updatedAt.Int64, 0)
+	if lastLoginAt.Valid {
+		t := time.Unix(lastLoginAt.Int64, 0)
+		user.LastLoginAt = &t
+	}
+
+	user.Roles = []string{}
+	if roles.String != "" {
+		if err := json.Unmarshal([]byte(roles.String), &user.Roles); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal roles")
+		}
+	}
+
+	user.FailedLogins = []time.Time{}
+	for _, field := range strings.Split(failedLogins.String, ",") {
+		unix, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+		if err != nil {
+			continue
+		}
+		user.FailedLogins = append(user.FailedLogins, time.Unix(unix, 0))
+	}
+
+	return &user, nil
+}
@@ -0,0 +1,36 @@
+package kotsstore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_passwordHistory(t *testing.T) {
+	tests := []struct {
+		name   string
+		hashes [][]byte
+		want   string
+	}{
+		{
+			name:   "empty",
+			hashes: [][]byte{},
+			want:   "",
+		},
+		{
+			name:   "most recent first",
+			hashes: [][]byte{[]byte("$2a$10$b"), []byte("$2a$10$a")},
+			want:   "$2a$10$b\n$2a$10$a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := formatPasswordHistory(tt.hashes)
+			if got != tt.want {
+				t.Errorf("formatPasswordHistory() = %q, want %q", got, tt.want)
+			}
+			if parsed := parsePasswordHistory(got); !reflect.DeepEqual(parsed, tt.hashes) {
+				t.Errorf("parsePasswordHistory() = %q, want %q", parsed, tt.hashes)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package kotsstore
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/crypto"
+	"github.com/replicatedhq/kots/pkg/logger"
+	notificationtypes "github.com/replicatedhq/kots/pkg/notifications/types"
+	"github.com/replicatedhq/kots/pkg/persistence"
+	"github.com/rqlite/gorqlite"
+	"github.com/segmentio/ksuid"
+	"go.uber.org/zap"
+)
+
+func (s *KOTSStore) ListNotificationSinks() ([]*notificationtypes.Sink, error) {
+	db := persistence.MustGetDBSession()
+	query := `select id, name, sink_type, enabled, filter, encrypted_config, created_at from notification_sink order by created_at asc`
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query: query,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+
+	sinks := []*notificationtypes.Sink{}
+	for rows.Next() {
+		sink, err := notificationSinkFromRow(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get notification sink from row")
+		}
+		sinks = append(sinks, sink)
+	}
+
+	return sinks, nil
+}
+
+func (s *KOTSStore) GetNotificationSink(id string) (*notificationtypes.Sink, error) {
+	db := persistence.MustGetDBSession()
+	query := `select id, name, sink_type, enabled, filter, encrypted_config, created_at from notification_sink where id = ?`
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{id},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+	if !rows.Next() {
+		return nil, ErrNotFound
+	}
+
+	sink, err := notificationSinkFromRow(rows)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get notification sink from row")
+	}
+
+	return sink, nil
+}
+
+func (s *KOTSStore) CreateNotificationSink(sink notificationtypes.Sink) (*notificationtypes.Sink, error) {
+	sink.ID = ksuid.New().String()
+	sink.CreatedAt = time.Now()
+
+	logger.Debug("creating notification sink",
+		zap.String("id", sink.ID))
+
+	filter, encryptedConfig, err := marshalNotificationSink(sink)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal notification sink")
+	}
+
+	db := persistence.MustGetDBSession()
+	query := `insert into notification_sink (id, name, sink_type, enabled, filter, encrypted_config, created_at) values (?, ?, ?, ?, ?, ?, ?)`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{sink.ID, sink.Name, string(sink.Type), sink.Enabled, filter, encryptedConfig, sink.CreatedAt.Unix()},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return &sink, nil
+}
+
+func (s *KOTSStore) UpdateNotificationSink(sink notificationtypes.Sink) error {
+	logger.Debug("updating notification sink",
+		zap.String("id", sink.ID))
+
+	filter, encryptedConfig, err := marshalNotificationSink(sink)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal notification sink")
+	}
+
+	db := persistence.MustGetDBSession()
+	query := `update notification_sink set name = ?, sink_type = ?, enabled = ?, filter = ?, encrypted_config = ? where id = ?`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{sink.Name, string(sink.Type), sink.Enabled, filter, encryptedConfig, sink.ID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+func (s *KOTSStore) DeleteNotificationSink(id string) error {
+	logger.Debug("deleting notification sink",
+		zap.String("id", id))
+
+	db := persistence.MustGetDBSession()
+	query := `delete from notification_sink where id = ?`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{id},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+func marshalNotificationSink(sink notificationtypes.Sink) (string, string, error) {
+	filter, err := json.Marshal(sink.Filter)
+	if err != nil {
+		return "", "", errors.Wrap(err, "failed to marshal filter")
+	}
+
+	config, err := json.Marshal(notificationtypes.SinkConfig{
+		Webhook: sink.Webhook,
+		Slack:   sink.Slack,
+		SMTP:    sink.SMTP,
+	})
+	if err != nil {
+		return "", "", errors.Wrap(err, "failed to marshal config")
+	}
+
+	encryptedConfig := base64.StdEncoding.EncodeToString(crypto.Encrypt(config))
+
+	return string(filter), encryptedConfig, nil
+}
+
+func notificationSinkFromRow(row gorqlite.QueryResult) (*notificationtypes.Sink, error) {
+	sink := notificationtypes.Sink{}
+
+	var sinkType string
+	var enabled gorqlite.NullBool
+	var filter gorqlite.NullString
+	var encryptedConfig gorqlite.NullString
+	var createdAt gorqlite.NullTime
+
+	if err := row.Scan(&sink.ID, &sink.Name, &sinkType, &enabled, &filter, &encryptedConfig, &createdAt); err != nil {
+		return nil, errors.Wrap(err, "failed to scan")
+	}
+
+	sink.Type = notificationtypes.SinkType(sinkType)
+	sink.Enabled = enabled.Bool
+	if createdAt.Valid {
+		sink.CreatedAt = createdAt.Time
+	}
+
+	if filter.Valid && filter.String != "" {
+		if err := json.Unmarshal([]byte(filter.String), &sink.Filter); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal filter")
+		}
+	}
+
+	if encryptedConfig.Valid && encryptedConfig.String != "" {
+		decodedConfig, err := base64.StdEncoding.DecodeString(encryptedConfig.String)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decode config")
+		}
+		decryptedConfig, err := crypto.Decrypt(decodedConfig)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decrypt config")
+		}
+		config := notificationtypes.SinkConfig{}
+		if err := json.Unmarshal(decryptedConfig, &config); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal config")
+		}
+		sink.Webhook = config.Webhook
+		sink.Slack = config.Slack
+		sink.SMTP = config.SMTP
+	}
+
+	return &sink, nil
+}
@@ -0,0 +1,165 @@
+package kotsstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/persistence"
+	rbactypes "github.com/replicatedhq/kots/pkg/rbac/types"
+	"github.com/rqlite/gorqlite"
+	"go.uber.org/zap"
+)
+
+func (s *KOTSStore) ListRBACRoles() ([]rbactypes.Role, error) {
+	db := persistence.MustGetDBSession()
+	query := `select id, name, description, allow_policies, deny_policies from rbac_role order by id asc`
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query: query,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+
+	roles := []rbactypes.Role{}
+	for rows.Next() {
+		role, err := rbacRoleFromRow(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get rbac role from row")
+		}
+		roles = append(roles, *role)
+	}
+
+	return roles, nil
+}
+
+func (s *KOTSStore) GetRBACRole(id string) (*rbactypes.Role, error) {
+	db := persistence.MustGetDBSession()
+	query := `select id, name, description, allow_policies, deny_policies from rbac_role where id = ?`
+	rows, err := db.QueryOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{id},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %v: %v", err, rows.Err)
+	}
+	if !rows.Next() {
+		return nil, ErrNotFound
+	}
+
+	role, err := rbacRoleFromRow(rows)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get rbac role from row")
+	}
+
+	return role, nil
+}
+
+func (s *KOTSStore) CreateRBACRole(role rbactypes.Role) error {
+	logger.Debug("creating rbac role",
+		zap.String("id", role.ID))
+
+	allow, deny, err := marshalRBACRolePolicies(role)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal policies")
+	}
+
+	now := time.Now().Unix()
+
+	db := persistence.MustGetDBSession()
+	query := `insert into rbac_role (id, name, description, allow_policies, deny_policies, created_at, updated_at) values (?, ?, ?, ?, ?, ?, ?)`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{role.ID, role.Name, role.Description, allow, deny, now, now},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+func (s *KOTSStore) UpdateRBACRole(role rbactypes.Role) error {
+	logger.Debug("updating rbac role",
+		zap.String("id", role.ID))
+
+	allow, deny, err := marshalRBACRolePolicies(role)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal policies")
+	}
+
+	db := persistence.MustGetDBSession()
+	query := `update rbac_role set name = ?, description = ?, allow_policies = ?, deny_policies = ?, updated_at = ? where id = ?`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{role.Name, role.Description, allow, deny, time.Now().Unix(), role.ID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+func (s *KOTSStore) DeleteRBACRole(id string) error {
+	logger.Debug("deleting rbac role",
+		zap.String("id", id))
+
+	db := persistence.MustGetDBSession()
+	query := `delete from rbac_role where id = ?`
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     query,
+		Arguments: []interface{}{id},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+
+	return nil
+}
+
+func marshalRBACRolePolicies(role rbactypes.Role) (string, string, error) {
+	allow, err := json.Marshal(role.Allow)
+	if err != nil {
+		return "", "", errors.Wrap(err, "failed to marshal allow policies")
+	}
+
+	deny, err := json.Marshal(role.Deny)
+	if err != nil {
+		return "", "", errors.Wrap(err, "failed to marshal deny policies")
+	}
+
+	return string(allow), string(deny), nil
+}
+
+func rbacRoleFromRow(row gorqlite.QueryResult) (*rbactypes.Role, error) {
+	role := rbactypes.Role{}
+
+	var name gorqlite.NullString
+	var description gorqlite.NullString
+	var allow gorqlite.NullString
+	var deny gorqlite.NullString
+
+	if err := row.Scan(&role.ID, &name, &description, &allow, &deny); err != nil {
+		return nil, errors.Wrap(err, "failed to scan")
+	}
+
+	role.Name = name.String
+	role.Description = description.String
+
+	if allow.String != "" {
+		if err := json.Unmarshal([]byte(allow.String), &role.Allow); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal allow policies")
+		}
+	}
+
+	if deny.String != "" {
+		if err := json.Unmarshal([]byte(deny.String), &role.Deny); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal deny policies")
+		}
+	}
+
+	return &role, nil
+}
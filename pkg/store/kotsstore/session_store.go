@@ -125,6 +125,7 @@ func (s *KOTSStore) CreateSession(forUser *usertypes.User, issuedAt time.Time, e
 
 	session := sessiontypes.Session{
 		ID:        id,
+		UserID:    forUser.ID,
 		IssuedAt:  issuedAt,
 		ExpiresAt: expiresAt,
 		Roles:     roles,
@@ -332,3 +333,37 @@ func (s *KOTSStore) DeleteExpiredSessions() error {
 
 	return nil
 }
+
+// DeleteUserSessions deletes all sessions of the user, e.g. when the user is disabled or deleted
+func (s *KOTSStore) DeleteUserSessions(userID string) error {
+	sessionLock.Lock()
+	defer sessionLock.Unlock()
+
+	s.sessionSecret = nil
+
+	secret, err := s.getSessionSecret()
+	if err != nil {
+		return errors.Wrap(err, "failed to get session secret")
+	}
+
+	updateSessionSecret := false
+	for id, data := range secret.Data {
+		session := sessiontypes.Session{}
+		if err := json.Unmarshal(data, &session); err != nil {
+			logger.Error(errors.Wrap(err, "failed to unmarshal session while deleting user sessions"))
+			continue
+		}
+		if session.UserID == userID {
+			updateSessionSecret = true
+			delete(secret.Data, id)
+		}
+	}
+
+	if updateSessionSecret {
+		if err := s.saveSessionSecret(secret); err != nil {
+			return errors.Wrap(err, "failed to update session secret")
+		}
+	}
+
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -12,6 +13,7 @@ import (
 	"github.com/replicatedhq/kots/pkg/persistence"
 	"github.com/replicatedhq/kots/pkg/util"
 	"github.com/rqlite/gorqlite"
+	corev1 "k8s.io/api/core/v1"
 	kuberneteserrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -21,6 +23,9 @@ var (
 	passwordSecretName = "kotsadm-password"
 )
 
+// maxFailedLogins is the number of failed logins that are kept in the password secret
+const maxFailedLogins = 100
+
 // GetSharedPasswordBcrypt will return the hash of the current password
 // that can be used to validate an auth request. This is in the store pkg,
 // but the data may be in the cluster or the database, depending on the
@@ -38,15 +43,6 @@ func (s *KOTSStore) GetSharedPasswordBcrypt() ([]byte, error) {
 		// so instead we fallback to the environment variable
 		shaBytes = []byte(os.Getenv("SHARED_PASSWORD_BCRYPT"))
 	} else {
-		if passwordSecret.Labels == nil {
-			passwordSecret.Labels = map[string]string{}
-		}
-
-		numAttempts, _ := strconv.Atoi(passwordSecret.Labels["numAttempts"])
-		if numAttempts > 10 {
-			return nil, ErrTooManyAttempts
-		}
-
 		shaBytes = passwordSecret.Data["passwordBcrypt"]
 	}
 
@@ -124,7 +120,19 @@ func (s *KOTSStore) FlagInvalidPassword() error {
 			secret.Labels = map[string]string{}
 		}
 
-		secret.Labels["lastFailure"] = fmt.Sprintf("%d", time.Now().Unix())
+		now := time.Now()
+
+		failedLogins := failedLoginsFromSecret(secret)
+		failedLogins = append(failedLogins, now)
+		if len(failedLogins) > maxFailedLogins {
+			failedLogins = failedLogins[len(failedLogins)-maxFailedLogins:]
+		}
+		if secret.Annotations == nil {
+			secret.Annotations = map[string]string{}
+		}
+		secret.Annotations[util.FailedLoginsAnnotation] = formatFailedLogins(failedLogins)
+
+		secret.Labels["lastFailure"] = fmt.Sprintf("%d", now.Unix())
 		numAttempts, _ := strconv.Atoi(secret.Labels["numAttempts"])
 		secret.Labels["numAttempts"] = strconv.Itoa(numAttempts + 1)
 
@@ -209,6 +217,7 @@ func (s *KOTSStore) FlagSuccessfulLogin() error {
 		}
 
 		secret.Labels["numAttempts"] = "0"
+		delete(secret.Annotations, util.FailedLoginsAnnotation)
 		if _, err := clientset.CoreV1().Secrets(util.PodNamespace).Update(context.TODO(), secret, metav1.UpdateOptions{}); err != nil {
 			if kuberneteserrors.IsConflict(err) {
 				if i > 2 {
@@ -273,3 +282,54 @@ func (s *KOTSStore) GetPasswordUpdatedAt() (*time.Time, error) {
 
 	return passwordUpdatedAt, nil
 }
+
+// GetFailedLogins - returns the times of failed logins since the last successful login
+func (s *KOTSStore) GetFailedLogins() ([]time.Time, error) {
+	clientset, err := k8sutil.GetClientset()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get k8s clientset")
+	}
+
+	passwordSecret, err := clientset.CoreV1().Secrets(util.PodNamespace).Get(context.TODO(), passwordSecretName, metav1.GetOptions{})
+	if err != nil {
+		if kuberneteserrors.IsNotFound(err) {
+			return []time.Time{}, nil
+		}
+		return nil, errors.Wrap(err, "failed to get password secret")
+	}
+
+	return failedLoginsFromSecret(passwordSecret), nil
+}
+
+// failedLoginsFromSecret reads the failed logins from the annotation of the password secret. Secrets that were last
+// updated before the annotation was added only have the number of attempts and the time of the last failure.
+func failedLoginsFromSecret(secret *corev1.Secret) []time.Time {
+	failedLogins := []time.Time{}
+
+	if value, ok := secret.Annotations[util.FailedLoginsAnnotation]; ok {
+		for _, field := range strings.Split(value, ",") {
+			unix, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+			if err != nil {
+				continue
+			}
+			failedLogins = append(failedLogins, time.Unix(unix, 0))
+		}
+		return failedLogins
+	}
+
+	numAttempts, _ := strconv.Atoi(secret.Labels["numAttempts"])
+	lastFailure, _ := strconv.ParseInt(secret.Labels["lastFailure"], 10, 64)
+	for i := 0; i < numAttempts; i++ {
+		failedLogins = append(failedLogins, time.Unix(lastFailure, 0))
+	}
+
+	return failedLogins
+}
+
+func formatFailedLogins(failedLogins []time.Time) string {
+	fields := []string{}
+	for _, failedLogin := range failedLogins {
+		fields = append(fields, strconv.FormatInt(failedLogin.Unix(), 10))
+	}
+	return strings.Join(fields, ",")
+}
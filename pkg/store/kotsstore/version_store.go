@@ -418,7 +418,7 @@ func (s *KOTSStore) CreatePendingDownloadAppVersion(appID string, update upstrea
 	for _, d := range downstreams {
 		downstreamVersionStatements, err := s.upsertAppDownstreamVersionStatements(a.ID, d.ClusterID, newSequence,
 			kotsKinds.Installation.Spec.VersionLabel, types.VersionPendingDownload,
-			"Upstream Update", "", "", "", false, false)
+			"Upstream Update", "", "", nil, false)
 		if err != nil {
 			return 0, errors.Wrap(err, "failed to construct app downstream version statements")
 		}
@@ -542,7 +542,12 @@ func (s *KOTSStore) upsertAppVersionStatements(appID string, sequence int64, bas
 		return nil, errors.Wrap(err, "failed to get k8s clientset")
 	}
 
-	if err := secrets.ReplaceSecretsInPath(filesInDir, clientset); err != nil {
+	isGitOpsEnabled, err := s.IsGitOpsEnabledForApp(appID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to check if gitops is enabled")
+	}
+
+	if err := secrets.ReplaceSecretsInPath(filesInDir, clientset, isGitOpsEnabled); err != nil {
 		return nil, errors.Wrap(err, "failed to replace secrets")
 	}
 	if err := s.CreateAppVersionArchive(appID, sequence, filesInDir); err != nil {
@@ -617,14 +622,14 @@ func (s *KOTSStore) upsertAppVersionStatements(appID string, sequence int64, bas
 			}
 		}
 
-		commitURL, err := gitops.CreateGitOpsDownstreamCommit(appID, d.ClusterID, int(sequence), filesInDir, d.Name)
+		commit, err := gitops.CreateGitOpsDownstreamCommit(appID, d.ClusterID, int(sequence), filesInDir, d.Name)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to create gitops commit")
 		}
 
 		downstreamVersionStatements, err := s.upsertAppDownstreamVersionStatements(appID, d.ClusterID, sequence,
 			kotsKinds.Installation.Spec.VersionLabel, downstreamStatus,
-			source, diffSummary, diffSummaryError, commitURL, commitURL != "", skipPreflights)
+			source, diffSummary, diffSummaryError, commit, skipPreflights)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to construct app downstream version statements")
 		}
@@ -771,11 +776,16 @@ func (s *KOTSStore) upsertAppVersionRecordStatements(appID string, sequence int6
 	return statements, nil
 }
 
-func (s *KOTSStore) upsertAppDownstreamVersionStatements(appID string, clusterID string, sequence int64, versionLabel string, status types.DownstreamVersionStatus, source string, diffSummary string, diffSummaryError string, commitURL string, gitDeployable bool, preflightsSkipped bool) ([]gorqlite.ParameterizedStatement, error) {
+func (s *KOTSStore) upsertAppDownstreamVersionStatements(appID string, clusterID string, sequence int64, versionLabel string, status types.DownstreamVersionStatus, source string, diffSummary string, diffSummaryError string, commit *gitopstypes.DownstreamCommit, preflightsSkipped bool) ([]gorqlite.ParameterizedStatement, error) {
 	statements := []gorqlite.ParameterizedStatement{}
 
-	query := `insert into app_downstream_version (app_id, cluster_id, sequence, parent_sequence, created_at, version_label, status, source, diff_summary, diff_summary_error, git_commit_url, git_deployable, preflight_skipped)
-		values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	gitDeployable := !commit.IsEmpty()
+	if commit == nil {
+		commit = &gitopstypes.DownstreamCommit{}
+	}
+
+	query := `insert into app_downstream_version (app_id, cluster_id, sequence, parent_sequence, created_at, version_label, status, source, diff_summary, diff_summary_error, git_commit_url, git_pr_url, git_pr_number, git_pr_state, git_deployable, preflight_skipped)
+		values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(app_id, cluster_id, sequence) DO UPDATE SET
 		created_at = EXCLUDED.created_at,
 		version_label = EXCLUDED.version_label,
@@ -784,6 +794,9 @@ func (s *KOTSStore) upsertAppDownstreamVersionStatements(appID string, clusterID
 		diff_summary = EXCLUDED.diff_summary,
 		diff_summary_error = EXCLUDED.diff_summary_error,
 		git_commit_url = EXCLUDED.git_commit_url,
+		git_pr_url = EXCLUDED.git_pr_url,
+		git_pr_number = EXCLUDED.git_pr_number,
+		git_pr_state = EXCLUDED.git_pr_state,
 		git_deployable = EXCLUDED.git_deployable,
 		preflight_skipped= EXCLUDED.preflight_skipped`
 
@@ -800,7 +813,10 @@ func (s *KOTSStore) upsertAppDownstreamVersionStatements(appID string, clusterID
 			source,
 			diffSummary,
 			diffSummaryError,
-			commitURL,
+			commit.CommitURL,
+			commit.PullRequestURL,
+			commit.PullRequestNumber,
+			string(commit.PullRequestState),
 			gitDeployable,
 			preflightsSkipped,
 		},
@@ -945,6 +961,35 @@ func (s *KOTSStore) UpdateAppVersionInstallationSpec(appID string, sequence int6
 	return nil
 }
 
+// UpdateAppVersionEncryptedData updates the cached specs of an app version that contain encrypted data
+func (s *KOTSStore) UpdateAppVersionEncryptedData(appID string, sequence int64, configValues *kotsv1beta1.ConfigValues, installation kotsv1beta1.Installation) error {
+	ser := serializer.NewYAMLSerializer(serializer.DefaultMetaFactory, scheme.Scheme, scheme.Scheme)
+
+	configValuesSpec := ""
+	if configValues != nil {
+		var b bytes.Buffer
+		if err := ser.Encode(configValues, &b); err != nil {
+			return errors.Wrap(err, "failed to encode config values")
+		}
+		configValuesSpec = b.String()
+	}
+
+	var b bytes.Buffer
+	if err := ser.Encode(&installation, &b); err != nil {
+		return errors.Wrap(err, "failed to encode installation")
+	}
+
+	db := persistence.MustGetDBSession()
+	wr, err := db.WriteOneParameterized(gorqlite.ParameterizedStatement{
+		Query:     `UPDATE app_version SET config_values = ?, kots_installation_spec = ?, encryption_key = ? WHERE app_id = ? AND sequence = ?`,
+		Arguments: []interface{}{configValuesSpec, b.String(), installation.Spec.EncryptionKey, appID, sequence},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write: %v: %v", err, wr.Err)
+	}
+	return nil
+}
+
 func (s *KOTSStore) GetNextAppSequence(appID string) (int64, error) {
 	db := persistence.MustGetDBSession()
 
@@ -14,19 +14,24 @@ import (
 	types0 "github.com/replicatedhq/kots/pkg/api/downstream/types"
 	types1 "github.com/replicatedhq/kots/pkg/api/reporting/types"
 	types2 "github.com/replicatedhq/kots/pkg/api/version/types"
-	types3 "github.com/replicatedhq/kots/pkg/app/types"
-	types4 "github.com/replicatedhq/kots/pkg/appstate/types"
-	types5 "github.com/replicatedhq/kots/pkg/gitops/types"
-	types6 "github.com/replicatedhq/kots/pkg/kotsadmsnapshot/types"
-	types7 "github.com/replicatedhq/kots/pkg/online/types"
-	types8 "github.com/replicatedhq/kots/pkg/preflight/types"
-	types9 "github.com/replicatedhq/kots/pkg/registry/types"
-	types10 "github.com/replicatedhq/kots/pkg/render/types"
-	types11 "github.com/replicatedhq/kots/pkg/session/types"
-	types12 "github.com/replicatedhq/kots/pkg/store/types"
-	types13 "github.com/replicatedhq/kots/pkg/supportbundle/types"
-	types14 "github.com/replicatedhq/kots/pkg/upstream/types"
-	types15 "github.com/replicatedhq/kots/pkg/user/types"
+	types3 "github.com/replicatedhq/kots/pkg/apitoken/types"
+	types4 "github.com/replicatedhq/kots/pkg/app/types"
+	types5 "github.com/replicatedhq/kots/pkg/appstate/types"
+	types6 "github.com/replicatedhq/kots/pkg/audit/types"
+	types7 "github.com/replicatedhq/kots/pkg/drift/types"
+	types8 "github.com/replicatedhq/kots/pkg/gitops/types"
+	types9 "github.com/replicatedhq/kots/pkg/kotsadmsnapshot/types"
+	types10 "github.com/replicatedhq/kots/pkg/notifications/types"
+	types11 "github.com/replicatedhq/kots/pkg/online/types"
+	types12 "github.com/replicatedhq/kots/pkg/preflight/types"
+	types13 "github.com/replicatedhq/kots/pkg/rbac/types"
+	types14 "github.com/replicatedhq/kots/pkg/registry/types"
+	types15 "github.com/replicatedhq/kots/pkg/render/types"
+	types16 "github.com/replicatedhq/kots/pkg/session/types"
+	types17 "github.com/replicatedhq/kots/pkg/store/types"
+	types18 "github.com/replicatedhq/kots/pkg/supportbundle/types"
+	types19 "github.com/replicatedhq/kots/pkg/upstream/types"
+	types20 "github.com/replicatedhq/kots/pkg/user/types"
 	v1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
 	redact "github.com/replicatedhq/troubleshoot/pkg/redact"
 )
@@ -110,11 +115,25 @@ func (mr *MockStoreMockRecorder) ClearTaskStatus(taskID interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearTaskStatus", reflect.TypeOf((*MockStore)(nil).ClearTaskStatus), taskID)
 }
 
+// CreateAPIToken mocks base method.
+func (m *MockStore) CreateAPIToken(token types3.APIToken, tokenHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIToken", token, tokenHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAPIToken indicates an expected call of CreateAPIToken.
+func (mr *MockStoreMockRecorder) CreateAPIToken(token, tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIToken", reflect.TypeOf((*MockStore)(nil).CreateAPIToken), token, tokenHash)
+}
+
 // CreateApp mocks base method.
-func (m *MockStore) CreateApp(name, upstreamURI, licenseData string, isAirgapEnabled, skipImagePush, registryIsReadOnly bool) (*types3.App, error) {
+func (m *MockStore) CreateApp(name, upstreamURI, licenseData string, isAirgapEnabled, skipImagePush, registryIsReadOnly bool) (*types4.App, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateApp", name, upstreamURI, licenseData, isAirgapEnabled, skipImagePush, registryIsReadOnly)
-	ret0, _ := ret[0].(*types3.App)
+	ret0, _ := ret[0].(*types4.App)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -126,7 +145,7 @@ func (mr *MockStoreMockRecorder) CreateApp(name, upstreamURI, licenseData, isAir
 }
 
 // CreateAppVersion mocks base method.
-func (m *MockStore) CreateAppVersion(appID string, baseSequence *int64, filesInDir, source string, skipPreflights bool, gitops types5.DownstreamGitOps, renderer types10.Renderer) (int64, error) {
+func (m *MockStore) CreateAppVersion(appID string, baseSequence *int64, filesInDir, source string, skipPreflights bool, gitops types8.DownstreamGitOps, renderer types15.Renderer) (int64, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateAppVersion", appID, baseSequence, filesInDir, source, skipPreflights, gitops, renderer)
 	ret0, _ := ret[0].(int64)
@@ -154,8 +173,22 @@ func (mr *MockStoreMockRecorder) CreateAppVersionArchive(appID, sequence, archiv
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAppVersionArchive", reflect.TypeOf((*MockStore)(nil).CreateAppVersionArchive), appID, sequence, archivePath)
 }
 
+// CreateAuditEvent mocks base method.
+func (m *MockStore) CreateAuditEvent(event types6.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditEvent", event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAuditEvent indicates an expected call of CreateAuditEvent.
+func (mr *MockStoreMockRecorder) CreateAuditEvent(event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditEvent", reflect.TypeOf((*MockStore)(nil).CreateAuditEvent), event)
+}
+
 // CreateInProgressSupportBundle mocks base method.
-func (m *MockStore) CreateInProgressSupportBundle(supportBundle *types13.SupportBundle) error {
+func (m *MockStore) CreateInProgressSupportBundle(supportBundle *types18.SupportBundle) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateInProgressSupportBundle", supportBundle)
 	ret0, _ := ret[0].(error)
@@ -183,6 +216,20 @@ func (mr *MockStoreMockRecorder) CreateInitialBranding(brandingArchive interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInitialBranding", reflect.TypeOf((*MockStore)(nil).CreateInitialBranding), brandingArchive)
 }
 
+// CreateLocalUser mocks base method.
+func (m *MockStore) CreateLocalUser(user types20.LocalUser, passwordBcrypt []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateLocalUser", user, passwordBcrypt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateLocalUser indicates an expected call of CreateLocalUser.
+func (mr *MockStoreMockRecorder) CreateLocalUser(user, passwordBcrypt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLocalUser", reflect.TypeOf((*MockStore)(nil).CreateLocalUser), user, passwordBcrypt)
+}
+
 // CreateNewCluster mocks base method.
 func (m *MockStore) CreateNewCluster(userID string, isAllUsers bool, title, token string) (string, error) {
 	m.ctrl.T.Helper()
@@ -198,8 +245,23 @@ func (mr *MockStoreMockRecorder) CreateNewCluster(userID, isAllUsers, title, tok
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNewCluster", reflect.TypeOf((*MockStore)(nil).CreateNewCluster), userID, isAllUsers, title, token)
 }
 
+// CreateNotificationSink mocks base method.
+func (m *MockStore) CreateNotificationSink(sink types10.Sink) (*types10.Sink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateNotificationSink", sink)
+	ret0, _ := ret[0].(*types10.Sink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateNotificationSink indicates an expected call of CreateNotificationSink.
+func (mr *MockStoreMockRecorder) CreateNotificationSink(sink interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNotificationSink", reflect.TypeOf((*MockStore)(nil).CreateNotificationSink), sink)
+}
+
 // CreatePendingDownloadAppVersion mocks base method.
-func (m *MockStore) CreatePendingDownloadAppVersion(appID string, update types14.Update, kotsApplication *v1beta1.Application, license *v1beta1.License) (int64, error) {
+func (m *MockStore) CreatePendingDownloadAppVersion(appID string, update types19.Update, kotsApplication *v1beta1.Application, license *v1beta1.License) (int64, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreatePendingDownloadAppVersion", appID, update, kotsApplication, license)
 	ret0, _ := ret[0].(int64)
@@ -213,6 +275,20 @@ func (mr *MockStoreMockRecorder) CreatePendingDownloadAppVersion(appID, update,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePendingDownloadAppVersion", reflect.TypeOf((*MockStore)(nil).CreatePendingDownloadAppVersion), appID, update, kotsApplication, license)
 }
 
+// CreateRBACRole mocks base method.
+func (m *MockStore) CreateRBACRole(role types13.Role) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRBACRole", role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateRBACRole indicates an expected call of CreateRBACRole.
+func (mr *MockStoreMockRecorder) CreateRBACRole(role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRBACRole", reflect.TypeOf((*MockStore)(nil).CreateRBACRole), role)
+}
+
 // CreateScheduledInstanceSnapshot mocks base method.
 func (m *MockStore) CreateScheduledInstanceSnapshot(snapshotID, clusterID string, timestamp time.Time) error {
 	m.ctrl.T.Helper()
@@ -242,10 +318,10 @@ func (mr *MockStoreMockRecorder) CreateScheduledSnapshot(snapshotID, appID, time
 }
 
 // CreateSession mocks base method.
-func (m *MockStore) CreateSession(user *types15.User, issuedAt, expiresAt time.Time, roles []string) (*types11.Session, error) {
+func (m *MockStore) CreateSession(user *types20.User, issuedAt, expiresAt time.Time, roles []string) (*types16.Session, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateSession", user, issuedAt, expiresAt, roles)
-	ret0, _ := ret[0].(*types11.Session)
+	ret0, _ := ret[0].(*types16.Session)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -257,10 +333,10 @@ func (mr *MockStoreMockRecorder) CreateSession(user, issuedAt, expiresAt, roles
 }
 
 // CreateSupportBundle mocks base method.
-func (m *MockStore) CreateSupportBundle(bundleID, appID, archivePath string, marshalledTree []byte) (*types13.SupportBundle, error) {
+func (m *MockStore) CreateSupportBundle(bundleID, appID, archivePath string, marshalledTree []byte) (*types18.SupportBundle, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateSupportBundle", bundleID, appID, archivePath, marshalledTree)
-	ret0, _ := ret[0].(*types13.SupportBundle)
+	ret0, _ := ret[0].(*types18.SupportBundle)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -271,6 +347,34 @@ func (mr *MockStoreMockRecorder) CreateSupportBundle(bundleID, appID, archivePat
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSupportBundle", reflect.TypeOf((*MockStore)(nil).CreateSupportBundle), bundleID, appID, archivePath, marshalledTree)
 }
 
+// DeleteAPIToken mocks base method.
+func (m *MockStore) DeleteAPIToken(id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAPIToken", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAPIToken indicates an expected call of DeleteAPIToken.
+func (mr *MockStoreMockRecorder) DeleteAPIToken(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAPIToken", reflect.TypeOf((*MockStore)(nil).DeleteAPIToken), id)
+}
+
+// DeleteAPITokensCreatedBy mocks base method.
+func (m *MockStore) DeleteAPITokensCreatedBy(createdBy string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAPITokensCreatedBy", createdBy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAPITokensCreatedBy indicates an expected call of DeleteAPITokensCreatedBy.
+func (mr *MockStoreMockRecorder) DeleteAPITokensCreatedBy(createdBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAPITokensCreatedBy", reflect.TypeOf((*MockStore)(nil).DeleteAPITokensCreatedBy), createdBy)
+}
+
 // DeleteDownstreamDeployStatus mocks base method.
 func (m *MockStore) DeleteDownstreamDeployStatus(appID, clusterID string, sequence int64) error {
 	m.ctrl.T.Helper()
@@ -299,6 +403,34 @@ func (mr *MockStoreMockRecorder) DeleteExpiredSessions() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredSessions", reflect.TypeOf((*MockStore)(nil).DeleteExpiredSessions))
 }
 
+// DeleteLocalUser mocks base method.
+func (m *MockStore) DeleteLocalUser(username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteLocalUser", username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteLocalUser indicates an expected call of DeleteLocalUser.
+func (mr *MockStoreMockRecorder) DeleteLocalUser(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLocalUser", reflect.TypeOf((*MockStore)(nil).DeleteLocalUser), username)
+}
+
+// DeleteNotificationSink mocks base method.
+func (m *MockStore) DeleteNotificationSink(id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteNotificationSink", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteNotificationSink indicates an expected call of DeleteNotificationSink.
+func (mr *MockStoreMockRecorder) DeleteNotificationSink(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteNotificationSink", reflect.TypeOf((*MockStore)(nil).DeleteNotificationSink), id)
+}
+
 // DeletePendingScheduledInstanceSnapshots mocks base method.
 func (m *MockStore) DeletePendingScheduledInstanceSnapshots(clusterID string) error {
 	m.ctrl.T.Helper()
@@ -327,6 +459,20 @@ func (mr *MockStoreMockRecorder) DeletePendingScheduledSnapshots(appID interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePendingScheduledSnapshots", reflect.TypeOf((*MockStore)(nil).DeletePendingScheduledSnapshots), appID)
 }
 
+// DeleteRBACRole mocks base method.
+func (m *MockStore) DeleteRBACRole(id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRBACRole", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRBACRole indicates an expected call of DeleteRBACRole.
+func (mr *MockStoreMockRecorder) DeleteRBACRole(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRBACRole", reflect.TypeOf((*MockStore)(nil).DeleteRBACRole), id)
+}
+
 // DeleteSession mocks base method.
 func (m *MockStore) DeleteSession(sessionID string) error {
 	m.ctrl.T.Helper()
@@ -355,6 +501,20 @@ func (mr *MockStoreMockRecorder) DeleteSupportBundle(bundleID, appID interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSupportBundle", reflect.TypeOf((*MockStore)(nil).DeleteSupportBundle), bundleID, appID)
 }
 
+// DeleteUserSessions mocks base method.
+func (m *MockStore) DeleteUserSessions(userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserSessions", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserSessions indicates an expected call of DeleteUserSessions.
+func (mr *MockStoreMockRecorder) DeleteUserSessions(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserSessions", reflect.TypeOf((*MockStore)(nil).DeleteUserSessions), userID)
+}
+
 // FindDownstreamVersions mocks base method.
 func (m *MockStore) FindDownstreamVersions(appID string, downloadedOnly bool) (*types0.DownstreamVersions, error) {
 	m.ctrl.T.Helper()
@@ -384,6 +544,34 @@ func (mr *MockStoreMockRecorder) FlagInvalidPassword() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlagInvalidPassword", reflect.TypeOf((*MockStore)(nil).FlagInvalidPassword))
 }
 
+// FlagLocalUserInvalidPassword mocks base method.
+func (m *MockStore) FlagLocalUserInvalidPassword(username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlagLocalUserInvalidPassword", username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlagLocalUserInvalidPassword indicates an expected call of FlagLocalUserInvalidPassword.
+func (mr *MockStoreMockRecorder) FlagLocalUserInvalidPassword(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlagLocalUserInvalidPassword", reflect.TypeOf((*MockStore)(nil).FlagLocalUserInvalidPassword), username)
+}
+
+// FlagLocalUserSuccessfulLogin mocks base method.
+func (m *MockStore) FlagLocalUserSuccessfulLogin(username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlagLocalUserSuccessfulLogin", username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlagLocalUserSuccessfulLogin indicates an expected call of FlagLocalUserSuccessfulLogin.
+func (mr *MockStoreMockRecorder) FlagLocalUserSuccessfulLogin(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlagLocalUserSuccessfulLogin", reflect.TypeOf((*MockStore)(nil).FlagLocalUserSuccessfulLogin), username)
+}
+
 // FlagSuccessfulLogin mocks base method.
 func (m *MockStore) FlagSuccessfulLogin() error {
 	m.ctrl.T.Helper()
@@ -398,6 +586,21 @@ func (mr *MockStoreMockRecorder) FlagSuccessfulLogin() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlagSuccessfulLogin", reflect.TypeOf((*MockStore)(nil).FlagSuccessfulLogin))
 }
 
+// GetAPITokenByHash mocks base method.
+func (m *MockStore) GetAPITokenByHash(tokenHash string) (*types3.APIToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPITokenByHash", tokenHash)
+	ret0, _ := ret[0].(*types3.APIToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPITokenByHash indicates an expected call of GetAPITokenByHash.
+func (mr *MockStoreMockRecorder) GetAPITokenByHash(tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPITokenByHash", reflect.TypeOf((*MockStore)(nil).GetAPITokenByHash), tokenHash)
+}
+
 // GetAirgapInstallStatus mocks base method.
 func (m *MockStore) GetAirgapInstallStatus(appID string) (*types.InstallStatus, error) {
 	m.ctrl.T.Helper()
@@ -429,10 +632,10 @@ func (mr *MockStoreMockRecorder) GetAllAppLicenses() *gomock.Call {
 }
 
 // GetApp mocks base method.
-func (m *MockStore) GetApp(appID string) (*types3.App, error) {
+func (m *MockStore) GetApp(appID string) (*types4.App, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetApp", appID)
-	ret0, _ := ret[0].(*types3.App)
+	ret0, _ := ret[0].(*types4.App)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -443,11 +646,26 @@ func (mr *MockStoreMockRecorder) GetApp(appID interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApp", reflect.TypeOf((*MockStore)(nil).GetApp), appID)
 }
 
+// GetAppDriftReport mocks base method.
+func (m *MockStore) GetAppDriftReport(appID string) (*types7.DriftReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAppDriftReport", appID)
+	ret0, _ := ret[0].(*types7.DriftReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAppDriftReport indicates an expected call of GetAppDriftReport.
+func (mr *MockStoreMockRecorder) GetAppDriftReport(appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAppDriftReport", reflect.TypeOf((*MockStore)(nil).GetAppDriftReport), appID)
+}
+
 // GetAppFromSlug mocks base method.
-func (m *MockStore) GetAppFromSlug(slug string) (*types3.App, error) {
+func (m *MockStore) GetAppFromSlug(slug string) (*types4.App, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetAppFromSlug", slug)
-	ret0, _ := ret[0].(*types3.App)
+	ret0, _ := ret[0].(*types4.App)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -489,10 +707,10 @@ func (mr *MockStoreMockRecorder) GetAppIDsFromRegistry(hostname interface{}) *go
 }
 
 // GetAppStatus mocks base method.
-func (m *MockStore) GetAppStatus(appID string) (*types4.AppStatus, error) {
+func (m *MockStore) GetAppStatus(appID string) (*types5.AppStatus, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetAppStatus", appID)
-	ret0, _ := ret[0].(*types4.AppStatus)
+	ret0, _ := ret[0].(*types5.AppStatus)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -503,6 +721,21 @@ func (mr *MockStoreMockRecorder) GetAppStatus(appID interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAppStatus", reflect.TypeOf((*MockStore)(nil).GetAppStatus), appID)
 }
 
+// GetAppStatusHistory mocks base method.
+func (m *MockStore) GetAppStatusHistory(appID string, sequence *int64) ([]types5.ResourceStateTransition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAppStatusHistory", appID, sequence)
+	ret0, _ := ret[0].([]types5.ResourceStateTransition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAppStatusHistory indicates an expected call of GetAppStatusHistory.
+func (mr *MockStoreMockRecorder) GetAppStatusHistory(appID, sequence interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAppStatusHistory", reflect.TypeOf((*MockStore)(nil).GetAppStatusHistory), appID, sequence)
+}
+
 // GetAppVersion mocks base method.
 func (m *MockStore) GetAppVersion(appID string, sequence int64) (*types2.AppVersion, error) {
 	m.ctrl.T.Helper()
@@ -714,10 +947,10 @@ func (mr *MockStoreMockRecorder) GetDownstreamVersionSource(appID, sequence inte
 }
 
 // GetDownstreamVersionStatus mocks base method.
-func (m *MockStore) GetDownstreamVersionStatus(appID string, sequence int64) (types12.DownstreamVersionStatus, error) {
+func (m *MockStore) GetDownstreamVersionStatus(appID string, sequence int64) (types17.DownstreamVersionStatus, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetDownstreamVersionStatus", appID, sequence)
-	ret0, _ := ret[0].(types12.DownstreamVersionStatus)
+	ret0, _ := ret[0].(types17.DownstreamVersionStatus)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -758,6 +991,21 @@ func (mr *MockStoreMockRecorder) GetEmbeddedClusterAuthToken() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmbeddedClusterAuthToken", reflect.TypeOf((*MockStore)(nil).GetEmbeddedClusterAuthToken))
 }
 
+// GetFailedLogins mocks base method.
+func (m *MockStore) GetFailedLogins() ([]time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFailedLogins")
+	ret0, _ := ret[0].([]time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFailedLogins indicates an expected call of GetFailedLogins.
+func (mr *MockStoreMockRecorder) GetFailedLogins() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFailedLogins", reflect.TypeOf((*MockStore)(nil).GetFailedLogins))
+}
+
 // GetIgnoreRBACErrors mocks base method.
 func (m *MockStore) GetIgnoreRBACErrors(appID string, sequence int64) (bool, error) {
 	m.ctrl.T.Helper()
@@ -880,6 +1128,51 @@ func (mr *MockStoreMockRecorder) GetLicenseForAppVersion(appID, sequence interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLicenseForAppVersion", reflect.TypeOf((*MockStore)(nil).GetLicenseForAppVersion), appID, sequence)
 }
 
+// GetLocalUser mocks base method.
+func (m *MockStore) GetLocalUser(username string) (*types20.LocalUser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLocalUser", username)
+	ret0, _ := ret[0].(*types20.LocalUser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLocalUser indicates an expected call of GetLocalUser.
+func (mr *MockStoreMockRecorder) GetLocalUser(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLocalUser", reflect.TypeOf((*MockStore)(nil).GetLocalUser), username)
+}
+
+// GetLocalUserPasswordBcrypt mocks base method.
+func (m *MockStore) GetLocalUserPasswordBcrypt(username string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLocalUserPasswordBcrypt", username)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLocalUserPasswordBcrypt indicates an expected call of GetLocalUserPasswordBcrypt.
+func (mr *MockStoreMockRecorder) GetLocalUserPasswordBcrypt(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLocalUserPasswordBcrypt", reflect.TypeOf((*MockStore)(nil).GetLocalUserPasswordBcrypt), username)
+}
+
+// GetLocalUserPasswordHistory mocks base method.
+func (m *MockStore) GetLocalUserPasswordHistory(username string) ([][]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLocalUserPasswordHistory", username)
+	ret0, _ := ret[0].([][]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLocalUserPasswordHistory indicates an expected call of GetLocalUserPasswordHistory.
+func (mr *MockStoreMockRecorder) GetLocalUserPasswordHistory(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLocalUserPasswordHistory", reflect.TypeOf((*MockStore)(nil).GetLocalUserPasswordHistory), username)
+}
+
 // GetNextAppSequence mocks base method.
 func (m *MockStore) GetNextAppSequence(appID string) (int64, error) {
 	m.ctrl.T.Helper()
@@ -895,6 +1188,21 @@ func (mr *MockStoreMockRecorder) GetNextAppSequence(appID interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNextAppSequence", reflect.TypeOf((*MockStore)(nil).GetNextAppSequence), appID)
 }
 
+// GetNotificationSink mocks base method.
+func (m *MockStore) GetNotificationSink(id string) (*types10.Sink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotificationSink", id)
+	ret0, _ := ret[0].(*types10.Sink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotificationSink indicates an expected call of GetNotificationSink.
+func (mr *MockStoreMockRecorder) GetNotificationSink(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotificationSink", reflect.TypeOf((*MockStore)(nil).GetNotificationSink), id)
+}
+
 // GetParentSequenceForSequence mocks base method.
 func (m *MockStore) GetParentSequenceForSequence(appID, clusterID string, sequence int64) (int64, error) {
 	m.ctrl.T.Helper()
@@ -941,10 +1249,10 @@ func (mr *MockStoreMockRecorder) GetPendingAirgapUploadApp() *gomock.Call {
 }
 
 // GetPendingInstallationStatus mocks base method.
-func (m *MockStore) GetPendingInstallationStatus() (*types7.InstallStatus, error) {
+func (m *MockStore) GetPendingInstallationStatus() (*types11.InstallStatus, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetPendingInstallationStatus")
-	ret0, _ := ret[0].(*types7.InstallStatus)
+	ret0, _ := ret[0].(*types11.InstallStatus)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -971,10 +1279,10 @@ func (mr *MockStoreMockRecorder) GetPreflightProgress(appID, sequence interface{
 }
 
 // GetPreflightResults mocks base method.
-func (m *MockStore) GetPreflightResults(appID string, sequence int64) (*types8.PreflightResult, error) {
+func (m *MockStore) GetPreflightResults(appID string, sequence int64) (*types12.PreflightResult, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetPreflightResults", appID, sequence)
-	ret0, _ := ret[0].(*types8.PreflightResult)
+	ret0, _ := ret[0].(*types12.PreflightResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -1015,6 +1323,21 @@ func (mr *MockStoreMockRecorder) GetPrometheusAddress() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPrometheusAddress", reflect.TypeOf((*MockStore)(nil).GetPrometheusAddress))
 }
 
+// GetRBACRole mocks base method.
+func (m *MockStore) GetRBACRole(id string) (*types13.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRBACRole", id)
+	ret0, _ := ret[0].(*types13.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRBACRole indicates an expected call of GetRBACRole.
+func (mr *MockStoreMockRecorder) GetRBACRole(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRBACRole", reflect.TypeOf((*MockStore)(nil).GetRBACRole), id)
+}
+
 // GetRedactions mocks base method.
 func (m *MockStore) GetRedactions(bundleID string) (redact.RedactionList, error) {
 	m.ctrl.T.Helper()
@@ -1031,10 +1354,10 @@ func (mr *MockStoreMockRecorder) GetRedactions(bundleID interface{}) *gomock.Cal
 }
 
 // GetRegistryDetailsForApp mocks base method.
-func (m *MockStore) GetRegistryDetailsForApp(appID string) (types9.RegistrySettings, error) {
+func (m *MockStore) GetRegistryDetailsForApp(appID string) (types14.RegistrySettings, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetRegistryDetailsForApp", appID)
-	ret0, _ := ret[0].(types9.RegistrySettings)
+	ret0, _ := ret[0].(types14.RegistrySettings)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -1046,10 +1369,10 @@ func (mr *MockStoreMockRecorder) GetRegistryDetailsForApp(appID interface{}) *go
 }
 
 // GetSession mocks base method.
-func (m *MockStore) GetSession(sessionID string) (*types11.Session, error) {
+func (m *MockStore) GetSession(sessionID string) (*types16.Session, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetSession", sessionID)
-	ret0, _ := ret[0].(*types11.Session)
+	ret0, _ := ret[0].(*types16.Session)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -1076,10 +1399,10 @@ func (mr *MockStoreMockRecorder) GetSharedPasswordBcrypt() *gomock.Call {
 }
 
 // GetStatusForVersion mocks base method.
-func (m *MockStore) GetStatusForVersion(appID, clusterID string, sequence int64) (types12.DownstreamVersionStatus, error) {
+func (m *MockStore) GetStatusForVersion(appID, clusterID string, sequence int64) (types17.DownstreamVersionStatus, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetStatusForVersion", appID, clusterID, sequence)
-	ret0, _ := ret[0].(types12.DownstreamVersionStatus)
+	ret0, _ := ret[0].(types17.DownstreamVersionStatus)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -1091,10 +1414,10 @@ func (mr *MockStoreMockRecorder) GetStatusForVersion(appID, clusterID, sequence
 }
 
 // GetSupportBundle mocks base method.
-func (m *MockStore) GetSupportBundle(bundleID string) (*types13.SupportBundle, error) {
+func (m *MockStore) GetSupportBundle(bundleID string) (*types18.SupportBundle, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetSupportBundle", bundleID)
-	ret0, _ := ret[0].(*types13.SupportBundle)
+	ret0, _ := ret[0].(*types18.SupportBundle)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -1106,10 +1429,10 @@ func (mr *MockStoreMockRecorder) GetSupportBundle(bundleID interface{}) *gomock.
 }
 
 // GetSupportBundleAnalysis mocks base method.
-func (m *MockStore) GetSupportBundleAnalysis(bundleID string) (*types13.SupportBundleAnalysis, error) {
+func (m *MockStore) GetSupportBundleAnalysis(bundleID string) (*types18.SupportBundleAnalysis, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetSupportBundleAnalysis", bundleID)
-	ret0, _ := ret[0].(*types13.SupportBundleAnalysis)
+	ret0, _ := ret[0].(*types18.SupportBundleAnalysis)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -1301,7 +1624,7 @@ func (mr *MockStoreMockRecorder) IsRollbackSupportedForVersion(appID, sequence i
 }
 
 // IsSnapshotsSupportedForVersion mocks base method.
-func (m *MockStore) IsSnapshotsSupportedForVersion(a *types3.App, sequence int64, renderer types10.Renderer) (bool, error) {
+func (m *MockStore) IsSnapshotsSupportedForVersion(a *types4.App, sequence int64, renderer types15.Renderer) (bool, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "IsSnapshotsSupportedForVersion", a, sequence, renderer)
 	ret0, _ := ret[0].(bool)
@@ -1315,11 +1638,26 @@ func (mr *MockStoreMockRecorder) IsSnapshotsSupportedForVersion(a, sequence, ren
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsSnapshotsSupportedForVersion", reflect.TypeOf((*MockStore)(nil).IsSnapshotsSupportedForVersion), a, sequence, renderer)
 }
 
+// ListAPITokens mocks base method.
+func (m *MockStore) ListAPITokens() ([]types3.APIToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAPITokens")
+	ret0, _ := ret[0].([]types3.APIToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAPITokens indicates an expected call of ListAPITokens.
+func (mr *MockStoreMockRecorder) ListAPITokens() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAPITokens", reflect.TypeOf((*MockStore)(nil).ListAPITokens))
+}
+
 // ListAppsForDownstream mocks base method.
-func (m *MockStore) ListAppsForDownstream(clusterID string) ([]*types3.App, error) {
+func (m *MockStore) ListAppsForDownstream(clusterID string) ([]*types4.App, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ListAppsForDownstream", clusterID)
-	ret0, _ := ret[0].([]*types3.App)
+	ret0, _ := ret[0].([]*types4.App)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -1330,6 +1668,22 @@ func (mr *MockStoreMockRecorder) ListAppsForDownstream(clusterID interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAppsForDownstream", reflect.TypeOf((*MockStore)(nil).ListAppsForDownstream), clusterID)
 }
 
+// ListAuditEvents mocks base method.
+func (m *MockStore) ListAuditEvents(opts types6.ListOptions) ([]types6.Event, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAuditEvents", opts)
+	ret0, _ := ret[0].([]types6.Event)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListAuditEvents indicates an expected call of ListAuditEvents.
+func (mr *MockStoreMockRecorder) ListAuditEvents(opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditEvents", reflect.TypeOf((*MockStore)(nil).ListAuditEvents), opts)
+}
+
 // ListClusters mocks base method.
 func (m *MockStore) ListClusters() ([]*types0.Downstream, error) {
 	m.ctrl.T.Helper()
@@ -1361,10 +1715,10 @@ func (mr *MockStoreMockRecorder) ListDownstreamsForApp(appID interface{}) *gomoc
 }
 
 // ListFailedApps mocks base method.
-func (m *MockStore) ListFailedApps() ([]*types3.App, error) {
+func (m *MockStore) ListFailedApps() ([]*types4.App, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ListFailedApps")
-	ret0, _ := ret[0].([]*types3.App)
+	ret0, _ := ret[0].([]*types4.App)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -1391,10 +1745,10 @@ func (mr *MockStoreMockRecorder) ListInstalledAppSlugs() *gomock.Call {
 }
 
 // ListInstalledApps mocks base method.
-func (m *MockStore) ListInstalledApps() ([]*types3.App, error) {
+func (m *MockStore) ListInstalledApps() ([]*types4.App, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ListInstalledApps")
-	ret0, _ := ret[0].([]*types3.App)
+	ret0, _ := ret[0].([]*types4.App)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -1405,11 +1759,56 @@ func (mr *MockStoreMockRecorder) ListInstalledApps() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListInstalledApps", reflect.TypeOf((*MockStore)(nil).ListInstalledApps))
 }
 
+// ListLocalUsers mocks base method.
+func (m *MockStore) ListLocalUsers() ([]types20.LocalUser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLocalUsers")
+	ret0, _ := ret[0].([]types20.LocalUser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLocalUsers indicates an expected call of ListLocalUsers.
+func (mr *MockStoreMockRecorder) ListLocalUsers() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLocalUsers", reflect.TypeOf((*MockStore)(nil).ListLocalUsers))
+}
+
+// ListNotificationSinks mocks base method.
+func (m *MockStore) ListNotificationSinks() ([]*types10.Sink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListNotificationSinks")
+	ret0, _ := ret[0].([]*types10.Sink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListNotificationSinks indicates an expected call of ListNotificationSinks.
+func (mr *MockStoreMockRecorder) ListNotificationSinks() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNotificationSinks", reflect.TypeOf((*MockStore)(nil).ListNotificationSinks))
+}
+
+// ListOpenDownstreamPullRequests mocks base method.
+func (m *MockStore) ListOpenDownstreamPullRequests() ([]types0.DownstreamPullRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOpenDownstreamPullRequests")
+	ret0, _ := ret[0].([]types0.DownstreamPullRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOpenDownstreamPullRequests indicates an expected call of ListOpenDownstreamPullRequests.
+func (mr *MockStoreMockRecorder) ListOpenDownstreamPullRequests() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOpenDownstreamPullRequests", reflect.TypeOf((*MockStore)(nil).ListOpenDownstreamPullRequests))
+}
+
 // ListPendingScheduledInstanceSnapshots mocks base method.
-func (m *MockStore) ListPendingScheduledInstanceSnapshots(clusterID string) ([]types6.ScheduledInstanceSnapshot, error) {
+func (m *MockStore) ListPendingScheduledInstanceSnapshots(clusterID string) ([]types9.ScheduledInstanceSnapshot, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ListPendingScheduledInstanceSnapshots", clusterID)
-	ret0, _ := ret[0].([]types6.ScheduledInstanceSnapshot)
+	ret0, _ := ret[0].([]types9.ScheduledInstanceSnapshot)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -1421,10 +1820,10 @@ func (mr *MockStoreMockRecorder) ListPendingScheduledInstanceSnapshots(clusterID
 }
 
 // ListPendingScheduledSnapshots mocks base method.
-func (m *MockStore) ListPendingScheduledSnapshots(appID string) ([]types6.ScheduledSnapshot, error) {
+func (m *MockStore) ListPendingScheduledSnapshots(appID string) ([]types9.ScheduledSnapshot, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ListPendingScheduledSnapshots", appID)
-	ret0, _ := ret[0].([]types6.ScheduledSnapshot)
+	ret0, _ := ret[0].([]types9.ScheduledSnapshot)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -1435,11 +1834,26 @@ func (mr *MockStoreMockRecorder) ListPendingScheduledSnapshots(appID interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPendingScheduledSnapshots", reflect.TypeOf((*MockStore)(nil).ListPendingScheduledSnapshots), appID)
 }
 
+// ListRBACRoles mocks base method.
+func (m *MockStore) ListRBACRoles() ([]types13.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRBACRoles")
+	ret0, _ := ret[0].([]types13.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRBACRoles indicates an expected call of ListRBACRoles.
+func (mr *MockStoreMockRecorder) ListRBACRoles() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRBACRoles", reflect.TypeOf((*MockStore)(nil).ListRBACRoles))
+}
+
 // ListSupportBundles mocks base method.
-func (m *MockStore) ListSupportBundles(appID string) ([]*types13.SupportBundle, error) {
+func (m *MockStore) ListSupportBundles(appID string) ([]*types18.SupportBundle, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ListSupportBundles", appID)
-	ret0, _ := ret[0].([]*types13.SupportBundle)
+	ret0, _ := ret[0].([]*types18.SupportBundle)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -1560,6 +1974,20 @@ func (mr *MockStoreMockRecorder) SetAppChannelChanged(appID, channelChanged inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAppChannelChanged", reflect.TypeOf((*MockStore)(nil).SetAppChannelChanged), appID, channelChanged)
 }
 
+// SetAppDriftReport mocks base method.
+func (m *MockStore) SetAppDriftReport(report *types7.DriftReport) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAppDriftReport", report)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetAppDriftReport indicates an expected call of SetAppDriftReport.
+func (mr *MockStoreMockRecorder) SetAppDriftReport(report interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAppDriftReport", reflect.TypeOf((*MockStore)(nil).SetAppDriftReport), report)
+}
+
 // SetAppInstallState mocks base method.
 func (m *MockStore) SetAppInstallState(appID, state string) error {
 	m.ctrl.T.Helper()
@@ -1589,7 +2017,7 @@ func (mr *MockStoreMockRecorder) SetAppIsAirgap(appID, isAirgap interface{}) *go
 }
 
 // SetAppStatus mocks base method.
-func (m *MockStore) SetAppStatus(appID string, resourceStates types4.ResourceStates, updatedAt time.Time, sequence int64) error {
+func (m *MockStore) SetAppStatus(appID string, resourceStates types5.ResourceStates, updatedAt time.Time, sequence int64) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "SetAppStatus", appID, resourceStates, updatedAt, sequence)
 	ret0, _ := ret[0].(error)
@@ -1602,8 +2030,22 @@ func (mr *MockStoreMockRecorder) SetAppStatus(appID, resourceStates, updatedAt,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAppStatus", reflect.TypeOf((*MockStore)(nil).SetAppStatus), appID, resourceStates, updatedAt, sequence)
 }
 
+// SetAppUpstreamCredentials mocks base method.
+func (m *MockStore) SetAppUpstreamCredentials(appID, username, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAppUpstreamCredentials", appID, username, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetAppUpstreamCredentials indicates an expected call of SetAppUpstreamCredentials.
+func (mr *MockStoreMockRecorder) SetAppUpstreamCredentials(appID, username, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAppUpstreamCredentials", reflect.TypeOf((*MockStore)(nil).SetAppUpstreamCredentials), appID, username, password)
+}
+
 // SetAutoDeploy mocks base method.
-func (m *MockStore) SetAutoDeploy(appID string, autoDeploy types3.AutoDeploy) error {
+func (m *MockStore) SetAutoDeploy(appID string, autoDeploy types4.AutoDeploy) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "SetAutoDeploy", appID, autoDeploy)
 	ret0, _ := ret[0].(error)
@@ -1616,36 +2058,64 @@ func (mr *MockStoreMockRecorder) SetAutoDeploy(appID, autoDeploy interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAutoDeploy", reflect.TypeOf((*MockStore)(nil).SetAutoDeploy), appID, autoDeploy)
 }
 
-// SetDownstreamVersionStatus mocks base method.
-func (m *MockStore) SetDownstreamVersionStatus(appID string, sequence int64, status types12.DownstreamVersionStatus, statusInfo string) error {
+// SetDeployStrategy mocks base method.
+func (m *MockStore) SetDeployStrategy(appID string, deployStrategy types4.DeployStrategy, verificationWindow string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetDownstreamVersionStatus", appID, sequence, status, statusInfo)
+	ret := m.ctrl.Call(m, "SetDeployStrategy", appID, deployStrategy, verificationWindow)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SetDownstreamVersionStatus indicates an expected call of SetDownstreamVersionStatus.
-func (mr *MockStoreMockRecorder) SetDownstreamVersionStatus(appID, sequence, status, statusInfo interface{}) *gomock.Call {
+// SetDeployStrategy indicates an expected call of SetDeployStrategy.
+func (mr *MockStoreMockRecorder) SetDeployStrategy(appID, deployStrategy, verificationWindow interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDownstreamVersionStatus", reflect.TypeOf((*MockStore)(nil).SetDownstreamVersionStatus), appID, sequence, status, statusInfo)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDeployStrategy", reflect.TypeOf((*MockStore)(nil).SetDeployStrategy), appID, deployStrategy, verificationWindow)
 }
 
-// SetEmbeddedClusterAuthToken mocks base method.
-func (m *MockStore) SetEmbeddedClusterAuthToken(token string) error {
+// SetDownstreamVersionPullRequestState mocks base method.
+func (m *MockStore) SetDownstreamVersionPullRequestState(appID, clusterID string, sequence int64, state types8.PullRequestState) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetEmbeddedClusterAuthToken", token)
+	ret := m.ctrl.Call(m, "SetDownstreamVersionPullRequestState", appID, clusterID, sequence, state)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SetEmbeddedClusterAuthToken indicates an expected call of SetEmbeddedClusterAuthToken.
-func (mr *MockStoreMockRecorder) SetEmbeddedClusterAuthToken(token interface{}) *gomock.Call {
+// SetDownstreamVersionPullRequestState indicates an expected call of SetDownstreamVersionPullRequestState.
+func (mr *MockStoreMockRecorder) SetDownstreamVersionPullRequestState(appID, clusterID, sequence, state interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEmbeddedClusterAuthToken", reflect.TypeOf((*MockStore)(nil).SetEmbeddedClusterAuthToken), token)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDownstreamVersionPullRequestState", reflect.TypeOf((*MockStore)(nil).SetDownstreamVersionPullRequestState), appID, clusterID, sequence, state)
 }
 
-// SetIgnorePreflightPermissionErrors mocks base method.
-func (m *MockStore) SetIgnorePreflightPermissionErrors(appID string, sequence int64) error {
+// SetDownstreamVersionStatus mocks base method.
+func (m *MockStore) SetDownstreamVersionStatus(appID string, sequence int64, status types17.DownstreamVersionStatus, statusInfo string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDownstreamVersionStatus", appID, sequence, status, statusInfo)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDownstreamVersionStatus indicates an expected call of SetDownstreamVersionStatus.
+func (mr *MockStoreMockRecorder) SetDownstreamVersionStatus(appID, sequence, status, statusInfo interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDownstreamVersionStatus", reflect.TypeOf((*MockStore)(nil).SetDownstreamVersionStatus), appID, sequence, status, statusInfo)
+}
+
+// SetEmbeddedClusterAuthToken mocks base method.
+func (m *MockStore) SetEmbeddedClusterAuthToken(token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetEmbeddedClusterAuthToken", token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetEmbeddedClusterAuthToken indicates an expected call of SetEmbeddedClusterAuthToken.
+func (mr *MockStoreMockRecorder) SetEmbeddedClusterAuthToken(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEmbeddedClusterAuthToken", reflect.TypeOf((*MockStore)(nil).SetEmbeddedClusterAuthToken), token)
+}
+
+// SetIgnorePreflightPermissionErrors mocks base method.
+func (m *MockStore) SetIgnorePreflightPermissionErrors(appID string, sequence int64) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "SetIgnorePreflightPermissionErrors", appID, sequence)
 	ret0, _ := ret[0].(error)
@@ -1700,6 +2170,48 @@ func (mr *MockStoreMockRecorder) SetIsKotsadmIDGenerated() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetIsKotsadmIDGenerated", reflect.TypeOf((*MockStore)(nil).SetIsKotsadmIDGenerated))
 }
 
+// SetLocalUserPasswordBcrypt mocks base method.
+func (m *MockStore) SetLocalUserPasswordBcrypt(username string, passwordBcrypt []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLocalUserPasswordBcrypt", username, passwordBcrypt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetLocalUserPasswordBcrypt indicates an expected call of SetLocalUserPasswordBcrypt.
+func (mr *MockStoreMockRecorder) SetLocalUserPasswordBcrypt(username, passwordBcrypt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLocalUserPasswordBcrypt", reflect.TypeOf((*MockStore)(nil).SetLocalUserPasswordBcrypt), username, passwordBcrypt)
+}
+
+// SetMaintenanceWindow mocks base method.
+func (m *MockStore) SetMaintenanceWindow(appID string, maintenanceWindow *types4.MaintenanceWindow) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMaintenanceWindow", appID, maintenanceWindow)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMaintenanceWindow indicates an expected call of SetMaintenanceWindow.
+func (mr *MockStoreMockRecorder) SetMaintenanceWindow(appID, maintenanceWindow interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMaintenanceWindow", reflect.TypeOf((*MockStore)(nil).SetMaintenanceWindow), appID, maintenanceWindow)
+}
+
+// SetPendingAutoDeploySequence mocks base method.
+func (m *MockStore) SetPendingAutoDeploySequence(appID string, sequence *int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPendingAutoDeploySequence", appID, sequence)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPendingAutoDeploySequence indicates an expected call of SetPendingAutoDeploySequence.
+func (mr *MockStoreMockRecorder) SetPendingAutoDeploySequence(appID, sequence interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPendingAutoDeploySequence", reflect.TypeOf((*MockStore)(nil).SetPendingAutoDeploySequence), appID, sequence)
+}
+
 // SetPreflightProgress mocks base method.
 func (m *MockStore) SetPreflightProgress(appID string, sequence int64, progress string) error {
 	m.ctrl.T.Helper()
@@ -1826,8 +2338,22 @@ func (mr *MockStoreMockRecorder) SetUpdateCheckerSpec(appID, updateCheckerSpec i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUpdateCheckerSpec", reflect.TypeOf((*MockStore)(nil).SetUpdateCheckerSpec), appID, updateCheckerSpec)
 }
 
+// UpdateAPITokenLastUsedAt mocks base method.
+func (m *MockStore) UpdateAPITokenLastUsedAt(id string, lastUsedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAPITokenLastUsedAt", id, lastUsedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAPITokenLastUsedAt indicates an expected call of UpdateAPITokenLastUsedAt.
+func (mr *MockStoreMockRecorder) UpdateAPITokenLastUsedAt(id, lastUsedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAPITokenLastUsedAt", reflect.TypeOf((*MockStore)(nil).UpdateAPITokenLastUsedAt), id, lastUsedAt)
+}
+
 // UpdateAppLicense mocks base method.
-func (m *MockStore) UpdateAppLicense(appID string, sequence int64, archiveDir string, newLicense *v1beta1.License, originalLicenseData string, channelChanged, failOnVersionCreate bool, gitops types5.DownstreamGitOps, renderer types10.Renderer) (int64, error) {
+func (m *MockStore) UpdateAppLicense(appID string, sequence int64, archiveDir string, newLicense *v1beta1.License, originalLicenseData string, channelChanged, failOnVersionCreate bool, gitops types8.DownstreamGitOps, renderer types15.Renderer) (int64, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "UpdateAppLicense", appID, sequence, archiveDir, newLicense, originalLicenseData, channelChanged, failOnVersionCreate, gitops, renderer)
 	ret0, _ := ret[0].(int64)
@@ -1856,7 +2382,7 @@ func (mr *MockStoreMockRecorder) UpdateAppLicenseSyncNow(appID interface{}) *gom
 }
 
 // UpdateAppVersion mocks base method.
-func (m *MockStore) UpdateAppVersion(appID string, sequence int64, baseSequence *int64, filesInDir, source string, skipPreflights bool, gitops types5.DownstreamGitOps, renderer types10.Renderer) error {
+func (m *MockStore) UpdateAppVersion(appID string, sequence int64, baseSequence *int64, filesInDir, source string, skipPreflights bool, gitops types8.DownstreamGitOps, renderer types15.Renderer) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "UpdateAppVersion", appID, sequence, baseSequence, filesInDir, source, skipPreflights, gitops, renderer)
 	ret0, _ := ret[0].(error)
@@ -1869,6 +2395,20 @@ func (mr *MockStoreMockRecorder) UpdateAppVersion(appID, sequence, baseSequence,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAppVersion", reflect.TypeOf((*MockStore)(nil).UpdateAppVersion), appID, sequence, baseSequence, filesInDir, source, skipPreflights, gitops, renderer)
 }
 
+// UpdateAppVersionEncryptedData mocks base method.
+func (m *MockStore) UpdateAppVersionEncryptedData(appID string, sequence int64, configValues *v1beta1.ConfigValues, installation v1beta1.Installation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAppVersionEncryptedData", appID, sequence, configValues, installation)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAppVersionEncryptedData indicates an expected call of UpdateAppVersionEncryptedData.
+func (mr *MockStoreMockRecorder) UpdateAppVersionEncryptedData(appID, sequence, configValues, installation interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAppVersionEncryptedData", reflect.TypeOf((*MockStore)(nil).UpdateAppVersionEncryptedData), appID, sequence, configValues, installation)
+}
+
 // UpdateAppVersionInstallationSpec mocks base method.
 func (m *MockStore) UpdateAppVersionInstallationSpec(appID string, sequence int64, spec v1beta1.Installation) error {
 	m.ctrl.T.Helper()
@@ -1897,6 +2437,20 @@ func (mr *MockStoreMockRecorder) UpdateDownstreamDeployStatus(appID, clusterID,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDownstreamDeployStatus", reflect.TypeOf((*MockStore)(nil).UpdateDownstreamDeployStatus), appID, clusterID, sequence, isError, output)
 }
 
+// UpdateLocalUser mocks base method.
+func (m *MockStore) UpdateLocalUser(username string, roles []string, isDisabled bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLocalUser", username, roles, isDisabled)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateLocalUser indicates an expected call of UpdateLocalUser.
+func (mr *MockStoreMockRecorder) UpdateLocalUser(username, roles, isDisabled interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLocalUser", reflect.TypeOf((*MockStore)(nil).UpdateLocalUser), username, roles, isDisabled)
+}
+
 // UpdateNextAppVersionDiffSummary mocks base method.
 func (m *MockStore) UpdateNextAppVersionDiffSummary(appID string, baseSequence int64) error {
 	m.ctrl.T.Helper()
@@ -1911,6 +2465,34 @@ func (mr *MockStoreMockRecorder) UpdateNextAppVersionDiffSummary(appID, baseSequ
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateNextAppVersionDiffSummary", reflect.TypeOf((*MockStore)(nil).UpdateNextAppVersionDiffSummary), appID, baseSequence)
 }
 
+// UpdateNotificationSink mocks base method.
+func (m *MockStore) UpdateNotificationSink(sink types10.Sink) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateNotificationSink", sink)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateNotificationSink indicates an expected call of UpdateNotificationSink.
+func (mr *MockStoreMockRecorder) UpdateNotificationSink(sink interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateNotificationSink", reflect.TypeOf((*MockStore)(nil).UpdateNotificationSink), sink)
+}
+
+// UpdateRBACRole mocks base method.
+func (m *MockStore) UpdateRBACRole(role types13.Role) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRBACRole", role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRBACRole indicates an expected call of UpdateRBACRole.
+func (mr *MockStoreMockRecorder) UpdateRBACRole(role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRBACRole", reflect.TypeOf((*MockStore)(nil).UpdateRBACRole), role)
+}
+
 // UpdateRegistry mocks base method.
 func (m *MockStore) UpdateRegistry(appID, hostname, username, password, namespace string, isReadOnly bool) error {
 	m.ctrl.T.Helper()
@@ -1968,7 +2550,7 @@ func (mr *MockStoreMockRecorder) UpdateSessionExpiresAt(sessionID, expiresAt int
 }
 
 // UpdateSupportBundle mocks base method.
-func (m *MockStore) UpdateSupportBundle(bundle *types13.SupportBundle) error {
+func (m *MockStore) UpdateSupportBundle(bundle *types18.SupportBundle) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "UpdateSupportBundle", bundle)
 	ret0, _ := ret[0].(error)
@@ -2097,10 +2679,10 @@ func (mr *MockRegistryStoreMockRecorder) GetAppIDsFromRegistry(hostname interfac
 }
 
 // GetRegistryDetailsForApp mocks base method.
-func (m *MockRegistryStore) GetRegistryDetailsForApp(appID string) (types9.RegistrySettings, error) {
+func (m *MockRegistryStore) GetRegistryDetailsForApp(appID string) (types14.RegistrySettings, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetRegistryDetailsForApp", appID)
-	ret0, _ := ret[0].(types9.RegistrySettings)
+	ret0, _ := ret[0].(types14.RegistrySettings)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -2149,7 +2731,7 @@ func (m *MockSupportBundleStore) EXPECT() *MockSupportBundleStoreMockRecorder {
 }
 
 // CreateInProgressSupportBundle mocks base method.
-func (m *MockSupportBundleStore) CreateInProgressSupportBundle(supportBundle *types13.SupportBundle) error {
+func (m *MockSupportBundleStore) CreateInProgressSupportBundle(supportBundle *types18.SupportBundle) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateInProgressSupportBundle", supportBundle)
 	ret0, _ := ret[0].(error)
@@ -2163,10 +2745,10 @@ func (mr *MockSupportBundleStoreMockRecorder) CreateInProgressSupportBundle(supp
 }
 
 // CreateSupportBundle mocks base method.
-func (m *MockSupportBundleStore) CreateSupportBundle(bundleID, appID, archivePath string, marshalledTree []byte) (*types13.SupportBundle, error) {
+func (m *MockSupportBundleStore) CreateSupportBundle(bundleID, appID, archivePath string, marshalledTree []byte) (*types18.SupportBundle, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateSupportBundle", bundleID, appID, archivePath, marshalledTree)
-	ret0, _ := ret[0].(*types13.SupportBundle)
+	ret0, _ := ret[0].(*types18.SupportBundle)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -2207,10 +2789,10 @@ func (mr *MockSupportBundleStoreMockRecorder) GetRedactions(bundleID interface{}
 }
 
 // GetSupportBundle mocks base method.
-func (m *MockSupportBundleStore) GetSupportBundle(bundleID string) (*types13.SupportBundle, error) {
+func (m *MockSupportBundleStore) GetSupportBundle(bundleID string) (*types18.SupportBundle, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetSupportBundle", bundleID)
-	ret0, _ := ret[0].(*types13.SupportBundle)
+	ret0, _ := ret[0].(*types18.SupportBundle)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -2222,10 +2804,10 @@ func (mr *MockSupportBundleStoreMockRecorder) GetSupportBundle(bundleID interfac
 }
 
 // GetSupportBundleAnalysis mocks base method.
-func (m *MockSupportBundleStore) GetSupportBundleAnalysis(bundleID string) (*types13.SupportBundleAnalysis, error) {
+func (m *MockSupportBundleStore) GetSupportBundleAnalysis(bundleID string) (*types18.SupportBundleAnalysis, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetSupportBundleAnalysis", bundleID)
-	ret0, _ := ret[0].(*types13.SupportBundleAnalysis)
+	ret0, _ := ret[0].(*types18.SupportBundleAnalysis)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -2252,10 +2834,10 @@ func (mr *MockSupportBundleStoreMockRecorder) GetSupportBundleArchive(bundleID i
 }
 
 // ListSupportBundles mocks base method.
-func (m *MockSupportBundleStore) ListSupportBundles(appID string) ([]*types13.SupportBundle, error) {
+func (m *MockSupportBundleStore) ListSupportBundles(appID string) ([]*types18.SupportBundle, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ListSupportBundles", appID)
-	ret0, _ := ret[0].([]*types13.SupportBundle)
+	ret0, _ := ret[0].([]*types18.SupportBundle)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -2295,7 +2877,7 @@ func (mr *MockSupportBundleStoreMockRecorder) SetSupportBundleAnalysis(bundleID,
 }
 
 // UpdateSupportBundle mocks base method.
-func (m *MockSupportBundleStore) UpdateSupportBundle(bundle *types13.SupportBundle) error {
+func (m *MockSupportBundleStore) UpdateSupportBundle(bundle *types18.SupportBundle) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "UpdateSupportBundle", bundle)
 	ret0, _ := ret[0].(error)
@@ -2361,10 +2943,10 @@ func (mr *MockPreflightStoreMockRecorder) GetPreflightProgress(appID, sequence i
 }
 
 // GetPreflightResults mocks base method.
-func (m *MockPreflightStore) GetPreflightResults(appID string, sequence int64) (*types8.PreflightResult, error) {
+func (m *MockPreflightStore) GetPreflightResults(appID string, sequence int64) (*types12.PreflightResult, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetPreflightResults", appID, sequence)
-	ret0, _ := ret[0].(*types8.PreflightResult)
+	ret0, _ := ret[0].(*types12.PreflightResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -2669,10 +3251,10 @@ func (m *MockSessionStore) EXPECT() *MockSessionStoreMockRecorder {
 }
 
 // CreateSession mocks base method.
-func (m *MockSessionStore) CreateSession(user *types15.User, issuedAt, expiresAt time.Time, roles []string) (*types11.Session, error) {
+func (m *MockSessionStore) CreateSession(user *types20.User, issuedAt, expiresAt time.Time, roles []string) (*types16.Session, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateSession", user, issuedAt, expiresAt, roles)
-	ret0, _ := ret[0].(*types11.Session)
+	ret0, _ := ret[0].(*types16.Session)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -2711,11 +3293,25 @@ func (mr *MockSessionStoreMockRecorder) DeleteSession(sessionID interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSession", reflect.TypeOf((*MockSessionStore)(nil).DeleteSession), sessionID)
 }
 
+// DeleteUserSessions mocks base method.
+func (m *MockSessionStore) DeleteUserSessions(userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserSessions", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserSessions indicates an expected call of DeleteUserSessions.
+func (mr *MockSessionStoreMockRecorder) DeleteUserSessions(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserSessions", reflect.TypeOf((*MockSessionStore)(nil).DeleteUserSessions), userID)
+}
+
 // GetSession mocks base method.
-func (m *MockSessionStore) GetSession(sessionID string) (*types11.Session, error) {
+func (m *MockSessionStore) GetSession(sessionID string) (*types16.Session, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetSession", sessionID)
-	ret0, _ := ret[0].(*types11.Session)
+	ret0, _ := ret[0].(*types16.Session)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -2764,10 +3360,10 @@ func (m *MockAppStatusStore) EXPECT() *MockAppStatusStoreMockRecorder {
 }
 
 // GetAppStatus mocks base method.
-func (m *MockAppStatusStore) GetAppStatus(appID string) (*types4.AppStatus, error) {
+func (m *MockAppStatusStore) GetAppStatus(appID string) (*types5.AppStatus, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetAppStatus", appID)
-	ret0, _ := ret[0].(*types4.AppStatus)
+	ret0, _ := ret[0].(*types5.AppStatus)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -2778,8 +3374,23 @@ func (mr *MockAppStatusStoreMockRecorder) GetAppStatus(appID interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAppStatus", reflect.TypeOf((*MockAppStatusStore)(nil).GetAppStatus), appID)
 }
 
+// GetAppStatusHistory mocks base method.
+func (m *MockAppStatusStore) GetAppStatusHistory(appID string, sequence *int64) ([]types5.ResourceStateTransition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAppStatusHistory", appID, sequence)
+	ret0, _ := ret[0].([]types5.ResourceStateTransition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAppStatusHistory indicates an expected call of GetAppStatusHistory.
+func (mr *MockAppStatusStoreMockRecorder) GetAppStatusHistory(appID, sequence interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAppStatusHistory", reflect.TypeOf((*MockAppStatusStore)(nil).GetAppStatusHistory), appID, sequence)
+}
+
 // SetAppStatus mocks base method.
-func (m *MockAppStatusStore) SetAppStatus(appID string, resourceStates types4.ResourceStates, updatedAt time.Time, sequence int64) error {
+func (m *MockAppStatusStore) SetAppStatus(appID string, resourceStates types5.ResourceStates, updatedAt time.Time, sequence int64) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "SetAppStatus", appID, resourceStates, updatedAt, sequence)
 	ret0, _ := ret[0].(error)
@@ -2792,6 +3403,58 @@ func (mr *MockAppStatusStoreMockRecorder) SetAppStatus(appID, resourceStates, up
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAppStatus", reflect.TypeOf((*MockAppStatusStore)(nil).SetAppStatus), appID, resourceStates, updatedAt, sequence)
 }
 
+// MockDriftStore is a mock of DriftStore interface.
+type MockDriftStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockDriftStoreMockRecorder
+}
+
+// MockDriftStoreMockRecorder is the mock recorder for MockDriftStore.
+type MockDriftStoreMockRecorder struct {
+	mock *MockDriftStore
+}
+
+// NewMockDriftStore creates a new mock instance.
+func NewMockDriftStore(ctrl *gomock.Controller) *MockDriftStore {
+	mock := &MockDriftStore{ctrl: ctrl}
+	mock.recorder = &MockDriftStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDriftStore) EXPECT() *MockDriftStoreMockRecorder {
+	return m.recorder
+}
+
+// GetAppDriftReport mocks base method.
+func (m *MockDriftStore) GetAppDriftReport(appID string) (*types7.DriftReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAppDriftReport", appID)
+	ret0, _ := ret[0].(*types7.DriftReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAppDriftReport indicates an expected call of GetAppDriftReport.
+func (mr *MockDriftStoreMockRecorder) GetAppDriftReport(appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAppDriftReport", reflect.TypeOf((*MockDriftStore)(nil).GetAppDriftReport), appID)
+}
+
+// SetAppDriftReport mocks base method.
+func (m *MockDriftStore) SetAppDriftReport(report *types7.DriftReport) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAppDriftReport", report)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetAppDriftReport indicates an expected call of SetAppDriftReport.
+func (mr *MockDriftStoreMockRecorder) SetAppDriftReport(report interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAppDriftReport", reflect.TypeOf((*MockDriftStore)(nil).SetAppDriftReport), report)
+}
+
 // MockAppStore is a mock of AppStore interface.
 type MockAppStore struct {
 	ctrl     *gomock.Controller
@@ -2830,10 +3493,10 @@ func (mr *MockAppStoreMockRecorder) AddAppToAllDownstreams(appID interface{}) *g
 }
 
 // CreateApp mocks base method.
-func (m *MockAppStore) CreateApp(name, upstreamURI, licenseData string, isAirgapEnabled, skipImagePush, registryIsReadOnly bool) (*types3.App, error) {
+func (m *MockAppStore) CreateApp(name, upstreamURI, licenseData string, isAirgapEnabled, skipImagePush, registryIsReadOnly bool) (*types4.App, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateApp", name, upstreamURI, licenseData, isAirgapEnabled, skipImagePush, registryIsReadOnly)
-	ret0, _ := ret[0].(*types3.App)
+	ret0, _ := ret[0].(*types4.App)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -2845,10 +3508,10 @@ func (mr *MockAppStoreMockRecorder) CreateApp(name, upstreamURI, licenseData, is
 }
 
 // GetApp mocks base method.
-func (m *MockAppStore) GetApp(appID string) (*types3.App, error) {
+func (m *MockAppStore) GetApp(appID string) (*types4.App, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetApp", appID)
-	ret0, _ := ret[0].(*types3.App)
+	ret0, _ := ret[0].(*types4.App)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -2860,10 +3523,10 @@ func (mr *MockAppStoreMockRecorder) GetApp(appID interface{}) *gomock.Call {
 }
 
 // GetAppFromSlug mocks base method.
-func (m *MockAppStore) GetAppFromSlug(slug string) (*types3.App, error) {
+func (m *MockAppStore) GetAppFromSlug(slug string) (*types4.App, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetAppFromSlug", slug)
-	ret0, _ := ret[0].(*types3.App)
+	ret0, _ := ret[0].(*types4.App)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -2920,10 +3583,10 @@ func (mr *MockAppStoreMockRecorder) IsGitOpsEnabledForApp(appID interface{}) *go
 }
 
 // ListAppsForDownstream mocks base method.
-func (m *MockAppStore) ListAppsForDownstream(clusterID string) ([]*types3.App, error) {
+func (m *MockAppStore) ListAppsForDownstream(clusterID string) ([]*types4.App, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ListAppsForDownstream", clusterID)
-	ret0, _ := ret[0].([]*types3.App)
+	ret0, _ := ret[0].([]*types4.App)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -2950,10 +3613,10 @@ func (mr *MockAppStoreMockRecorder) ListDownstreamsForApp(appID interface{}) *go
 }
 
 // ListFailedApps mocks base method.
-func (m *MockAppStore) ListFailedApps() ([]*types3.App, error) {
+func (m *MockAppStore) ListFailedApps() ([]*types4.App, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ListFailedApps")
-	ret0, _ := ret[0].([]*types3.App)
+	ret0, _ := ret[0].([]*types4.App)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -2980,10 +3643,10 @@ func (mr *MockAppStoreMockRecorder) ListInstalledAppSlugs() *gomock.Call {
 }
 
 // ListInstalledApps mocks base method.
-func (m *MockAppStore) ListInstalledApps() ([]*types3.App, error) {
+func (m *MockAppStore) ListInstalledApps() ([]*types4.App, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ListInstalledApps")
-	ret0, _ := ret[0].([]*types3.App)
+	ret0, _ := ret[0].([]*types4.App)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -3036,8 +3699,22 @@ func (mr *MockAppStoreMockRecorder) SetAppInstallState(appID, state interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAppInstallState", reflect.TypeOf((*MockAppStore)(nil).SetAppInstallState), appID, state)
 }
 
+// SetAppUpstreamCredentials mocks base method.
+func (m *MockAppStore) SetAppUpstreamCredentials(appID, username, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAppUpstreamCredentials", appID, username, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetAppUpstreamCredentials indicates an expected call of SetAppUpstreamCredentials.
+func (mr *MockAppStoreMockRecorder) SetAppUpstreamCredentials(appID, username, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAppUpstreamCredentials", reflect.TypeOf((*MockAppStore)(nil).SetAppUpstreamCredentials), appID, username, password)
+}
+
 // SetAutoDeploy mocks base method.
-func (m *MockAppStore) SetAutoDeploy(appID string, autoDeploy types3.AutoDeploy) error {
+func (m *MockAppStore) SetAutoDeploy(appID string, autoDeploy types4.AutoDeploy) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "SetAutoDeploy", appID, autoDeploy)
 	ret0, _ := ret[0].(error)
@@ -3050,6 +3727,48 @@ func (mr *MockAppStoreMockRecorder) SetAutoDeploy(appID, autoDeploy interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAutoDeploy", reflect.TypeOf((*MockAppStore)(nil).SetAutoDeploy), appID, autoDeploy)
 }
 
+// SetDeployStrategy mocks base method.
+func (m *MockAppStore) SetDeployStrategy(appID string, deployStrategy types4.DeployStrategy, verificationWindow string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDeployStrategy", appID, deployStrategy, verificationWindow)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDeployStrategy indicates an expected call of SetDeployStrategy.
+func (mr *MockAppStoreMockRecorder) SetDeployStrategy(appID, deployStrategy, verificationWindow interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDeployStrategy", reflect.TypeOf((*MockAppStore)(nil).SetDeployStrategy), appID, deployStrategy, verificationWindow)
+}
+
+// SetMaintenanceWindow mocks base method.
+func (m *MockAppStore) SetMaintenanceWindow(appID string, maintenanceWindow *types4.MaintenanceWindow) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMaintenanceWindow", appID, maintenanceWindow)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMaintenanceWindow indicates an expected call of SetMaintenanceWindow.
+func (mr *MockAppStoreMockRecorder) SetMaintenanceWindow(appID, maintenanceWindow interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMaintenanceWindow", reflect.TypeOf((*MockAppStore)(nil).SetMaintenanceWindow), appID, maintenanceWindow)
+}
+
+// SetPendingAutoDeploySequence mocks base method.
+func (m *MockAppStore) SetPendingAutoDeploySequence(appID string, sequence *int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPendingAutoDeploySequence", appID, sequence)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPendingAutoDeploySequence indicates an expected call of SetPendingAutoDeploySequence.
+func (mr *MockAppStoreMockRecorder) SetPendingAutoDeploySequence(appID, sequence interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPendingAutoDeploySequence", reflect.TypeOf((*MockAppStore)(nil).SetPendingAutoDeploySequence), appID, sequence)
+}
+
 // SetSnapshotSchedule mocks base method.
 func (m *MockAppStore) SetSnapshotSchedule(appID, snapshotSchedule string) error {
 	m.ctrl.T.Helper()
@@ -3263,10 +3982,10 @@ func (mr *MockDownstreamStoreMockRecorder) GetDownstreamVersionSource(appID, seq
 }
 
 // GetDownstreamVersionStatus mocks base method.
-func (m *MockDownstreamStore) GetDownstreamVersionStatus(appID string, sequence int64) (types12.DownstreamVersionStatus, error) {
+func (m *MockDownstreamStore) GetDownstreamVersionStatus(appID string, sequence int64) (types17.DownstreamVersionStatus, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetDownstreamVersionStatus", appID, sequence)
-	ret0, _ := ret[0].(types12.DownstreamVersionStatus)
+	ret0, _ := ret[0].(types17.DownstreamVersionStatus)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -3355,10 +4074,10 @@ func (mr *MockDownstreamStoreMockRecorder) GetPreviouslyDeployedSequence(appID,
 }
 
 // GetStatusForVersion mocks base method.
-func (m *MockDownstreamStore) GetStatusForVersion(appID, clusterID string, sequence int64) (types12.DownstreamVersionStatus, error) {
+func (m *MockDownstreamStore) GetStatusForVersion(appID, clusterID string, sequence int64) (types17.DownstreamVersionStatus, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetStatusForVersion", appID, clusterID, sequence)
-	ret0, _ := ret[0].(types12.DownstreamVersionStatus)
+	ret0, _ := ret[0].(types17.DownstreamVersionStatus)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -3400,6 +4119,21 @@ func (mr *MockDownstreamStoreMockRecorder) IsDownstreamDeploySuccessful(appID, c
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsDownstreamDeploySuccessful", reflect.TypeOf((*MockDownstreamStore)(nil).IsDownstreamDeploySuccessful), appID, clusterID, sequence)
 }
 
+// ListOpenDownstreamPullRequests mocks base method.
+func (m *MockDownstreamStore) ListOpenDownstreamPullRequests() ([]types0.DownstreamPullRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOpenDownstreamPullRequests")
+	ret0, _ := ret[0].([]types0.DownstreamPullRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOpenDownstreamPullRequests indicates an expected call of ListOpenDownstreamPullRequests.
+func (mr *MockDownstreamStoreMockRecorder) ListOpenDownstreamPullRequests() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOpenDownstreamPullRequests", reflect.TypeOf((*MockDownstreamStore)(nil).ListOpenDownstreamPullRequests))
+}
+
 // MarkAsCurrentDownstreamVersion mocks base method.
 func (m *MockDownstreamStore) MarkAsCurrentDownstreamVersion(appID string, sequence int64) error {
 	m.ctrl.T.Helper()
@@ -3414,8 +4148,22 @@ func (mr *MockDownstreamStoreMockRecorder) MarkAsCurrentDownstreamVersion(appID,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkAsCurrentDownstreamVersion", reflect.TypeOf((*MockDownstreamStore)(nil).MarkAsCurrentDownstreamVersion), appID, sequence)
 }
 
+// SetDownstreamVersionPullRequestState mocks base method.
+func (m *MockDownstreamStore) SetDownstreamVersionPullRequestState(appID, clusterID string, sequence int64, state types8.PullRequestState) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDownstreamVersionPullRequestState", appID, clusterID, sequence, state)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDownstreamVersionPullRequestState indicates an expected call of SetDownstreamVersionPullRequestState.
+func (mr *MockDownstreamStoreMockRecorder) SetDownstreamVersionPullRequestState(appID, clusterID, sequence, state interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDownstreamVersionPullRequestState", reflect.TypeOf((*MockDownstreamStore)(nil).SetDownstreamVersionPullRequestState), appID, clusterID, sequence, state)
+}
+
 // SetDownstreamVersionStatus mocks base method.
-func (m *MockDownstreamStore) SetDownstreamVersionStatus(appID string, sequence int64, status types12.DownstreamVersionStatus, statusInfo string) error {
+func (m *MockDownstreamStore) SetDownstreamVersionStatus(appID string, sequence int64, status types17.DownstreamVersionStatus, statusInfo string) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "SetDownstreamVersionStatus", appID, sequence, status, statusInfo)
 	ret0, _ := ret[0].(error)
@@ -3522,10 +4270,10 @@ func (mr *MockSnapshotStoreMockRecorder) DeletePendingScheduledSnapshots(appID i
 }
 
 // ListPendingScheduledInstanceSnapshots mocks base method.
-func (m *MockSnapshotStore) ListPendingScheduledInstanceSnapshots(clusterID string) ([]types6.ScheduledInstanceSnapshot, error) {
+func (m *MockSnapshotStore) ListPendingScheduledInstanceSnapshots(clusterID string) ([]types9.ScheduledInstanceSnapshot, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ListPendingScheduledInstanceSnapshots", clusterID)
-	ret0, _ := ret[0].([]types6.ScheduledInstanceSnapshot)
+	ret0, _ := ret[0].([]types9.ScheduledInstanceSnapshot)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -3537,10 +4285,10 @@ func (mr *MockSnapshotStoreMockRecorder) ListPendingScheduledInstanceSnapshots(c
 }
 
 // ListPendingScheduledSnapshots mocks base method.
-func (m *MockSnapshotStore) ListPendingScheduledSnapshots(appID string) ([]types6.ScheduledSnapshot, error) {
+func (m *MockSnapshotStore) ListPendingScheduledSnapshots(appID string) ([]types9.ScheduledSnapshot, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ListPendingScheduledSnapshots", appID)
-	ret0, _ := ret[0].([]types6.ScheduledSnapshot)
+	ret0, _ := ret[0].([]types9.ScheduledSnapshot)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -3603,7 +4351,7 @@ func (m *MockVersionStore) EXPECT() *MockVersionStoreMockRecorder {
 }
 
 // CreateAppVersion mocks base method.
-func (m *MockVersionStore) CreateAppVersion(appID string, baseSequence *int64, filesInDir, source string, skipPreflights bool, gitops types5.DownstreamGitOps, renderer types10.Renderer) (int64, error) {
+func (m *MockVersionStore) CreateAppVersion(appID string, baseSequence *int64, filesInDir, source string, skipPreflights bool, gitops types8.DownstreamGitOps, renderer types15.Renderer) (int64, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateAppVersion", appID, baseSequence, filesInDir, source, skipPreflights, gitops, renderer)
 	ret0, _ := ret[0].(int64)
@@ -3632,7 +4380,7 @@ func (mr *MockVersionStoreMockRecorder) CreateAppVersionArchive(appID, sequence,
 }
 
 // CreatePendingDownloadAppVersion mocks base method.
-func (m *MockVersionStore) CreatePendingDownloadAppVersion(appID string, update types14.Update, kotsApplication *v1beta1.Application, license *v1beta1.License) (int64, error) {
+func (m *MockVersionStore) CreatePendingDownloadAppVersion(appID string, update types19.Update, kotsApplication *v1beta1.Application, license *v1beta1.License) (int64, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreatePendingDownloadAppVersion", appID, update, kotsApplication, license)
 	ret0, _ := ret[0].(int64)
@@ -3812,7 +4560,7 @@ func (mr *MockVersionStoreMockRecorder) IsRollbackSupportedForVersion(appID, seq
 }
 
 // IsSnapshotsSupportedForVersion mocks base method.
-func (m *MockVersionStore) IsSnapshotsSupportedForVersion(a *types3.App, sequence int64, renderer types10.Renderer) (bool, error) {
+func (m *MockVersionStore) IsSnapshotsSupportedForVersion(a *types4.App, sequence int64, renderer types15.Renderer) (bool, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "IsSnapshotsSupportedForVersion", a, sequence, renderer)
 	ret0, _ := ret[0].(bool)
@@ -3827,7 +4575,7 @@ func (mr *MockVersionStoreMockRecorder) IsSnapshotsSupportedForVersion(a, sequen
 }
 
 // UpdateAppVersion mocks base method.
-func (m *MockVersionStore) UpdateAppVersion(appID string, sequence int64, baseSequence *int64, filesInDir, source string, skipPreflights bool, gitops types5.DownstreamGitOps, renderer types10.Renderer) error {
+func (m *MockVersionStore) UpdateAppVersion(appID string, sequence int64, baseSequence *int64, filesInDir, source string, skipPreflights bool, gitops types8.DownstreamGitOps, renderer types15.Renderer) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "UpdateAppVersion", appID, sequence, baseSequence, filesInDir, source, skipPreflights, gitops, renderer)
 	ret0, _ := ret[0].(error)
@@ -3840,6 +4588,20 @@ func (mr *MockVersionStoreMockRecorder) UpdateAppVersion(appID, sequence, baseSe
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAppVersion", reflect.TypeOf((*MockVersionStore)(nil).UpdateAppVersion), appID, sequence, baseSequence, filesInDir, source, skipPreflights, gitops, renderer)
 }
 
+// UpdateAppVersionEncryptedData mocks base method.
+func (m *MockVersionStore) UpdateAppVersionEncryptedData(appID string, sequence int64, configValues *v1beta1.ConfigValues, installation v1beta1.Installation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAppVersionEncryptedData", appID, sequence, configValues, installation)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAppVersionEncryptedData indicates an expected call of UpdateAppVersionEncryptedData.
+func (mr *MockVersionStoreMockRecorder) UpdateAppVersionEncryptedData(appID, sequence, configValues, installation interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAppVersionEncryptedData", reflect.TypeOf((*MockVersionStore)(nil).UpdateAppVersionEncryptedData), appID, sequence, configValues, installation)
+}
+
 // UpdateAppVersionInstallationSpec mocks base method.
 func (m *MockVersionStore) UpdateAppVersionInstallationSpec(appID string, sequence int64, spec v1beta1.Installation) error {
 	m.ctrl.T.Helper()
@@ -3937,7 +4699,7 @@ func (mr *MockLicenseStoreMockRecorder) GetLicenseForAppVersion(appID, sequence
 }
 
 // UpdateAppLicense mocks base method.
-func (m *MockLicenseStore) UpdateAppLicense(appID string, sequence int64, archiveDir string, newLicense *v1beta1.License, originalLicenseData string, channelChanged, failOnVersionCreate bool, gitops types5.DownstreamGitOps, renderer types10.Renderer) (int64, error) {
+func (m *MockLicenseStore) UpdateAppLicense(appID string, sequence int64, archiveDir string, newLicense *v1beta1.License, originalLicenseData string, channelChanged, failOnVersionCreate bool, gitops types8.DownstreamGitOps, renderer types15.Renderer) (int64, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "UpdateAppLicense", appID, sequence, archiveDir, newLicense, originalLicenseData, channelChanged, failOnVersionCreate, gitops, renderer)
 	ret0, _ := ret[0].(int64)
@@ -4016,6 +4778,21 @@ func (mr *MockUserStoreMockRecorder) FlagSuccessfulLogin() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlagSuccessfulLogin", reflect.TypeOf((*MockUserStore)(nil).FlagSuccessfulLogin))
 }
 
+// GetFailedLogins mocks base method.
+func (m *MockUserStore) GetFailedLogins() ([]time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFailedLogins")
+	ret0, _ := ret[0].([]time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFailedLogins indicates an expected call of GetFailedLogins.
+func (mr *MockUserStoreMockRecorder) GetFailedLogins() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFailedLogins", reflect.TypeOf((*MockUserStore)(nil).GetFailedLogins))
+}
+
 // GetPasswordUpdatedAt mocks base method.
 func (m *MockUserStore) GetPasswordUpdatedAt() (*time.Time, error) {
 	m.ctrl.T.Helper()
@@ -4181,10 +4958,10 @@ func (m *MockInstallationStore) EXPECT() *MockInstallationStoreMockRecorder {
 }
 
 // GetPendingInstallationStatus mocks base method.
-func (m *MockInstallationStore) GetPendingInstallationStatus() (*types7.InstallStatus, error) {
+func (m *MockInstallationStore) GetPendingInstallationStatus() (*types11.InstallStatus, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetPendingInstallationStatus")
-	ret0, _ := ret[0].(*types7.InstallStatus)
+	ret0, _ := ret[0].(*types11.InstallStatus)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -4432,3 +5209,523 @@ func (mr *MockReportingStoreMockRecorder) SaveReportingInfo(licenseID, reporting
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveReportingInfo", reflect.TypeOf((*MockReportingStore)(nil).SaveReportingInfo), licenseID, reportingInfo)
 }
+
+// MockNotificationStore is a mock of NotificationStore interface.
+type MockNotificationStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotificationStoreMockRecorder
+}
+
+// MockNotificationStoreMockRecorder is the mock recorder for MockNotificationStore.
+type MockNotificationStoreMockRecorder struct {
+	mock *MockNotificationStore
+}
+
+// NewMockNotificationStore creates a new mock instance.
+func NewMockNotificationStore(ctrl *gomock.Controller) *MockNotificationStore {
+	mock := &MockNotificationStore{ctrl: ctrl}
+	mock.recorder = &MockNotificationStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotificationStore) EXPECT() *MockNotificationStoreMockRecorder {
+	return m.recorder
+}
+
+// CreateNotificationSink mocks base method.
+func (m *MockNotificationStore) CreateNotificationSink(sink types10.Sink) (*types10.Sink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateNotificationSink", sink)
+	ret0, _ := ret[0].(*types10.Sink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateNotificationSink indicates an expected call of CreateNotificationSink.
+func (mr *MockNotificationStoreMockRecorder) CreateNotificationSink(sink interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNotificationSink", reflect.TypeOf((*MockNotificationStore)(nil).CreateNotificationSink), sink)
+}
+
+// DeleteNotificationSink mocks base method.
+func (m *MockNotificationStore) DeleteNotificationSink(id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteNotificationSink", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteNotificationSink indicates an expected call of DeleteNotificationSink.
+func (mr *MockNotificationStoreMockRecorder) DeleteNotificationSink(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteNotificationSink", reflect.TypeOf((*MockNotificationStore)(nil).DeleteNotificationSink), id)
+}
+
+// GetNotificationSink mocks base method.
+func (m *MockNotificationStore) GetNotificationSink(id string) (*types10.Sink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotificationSink", id)
+	ret0, _ := ret[0].(*types10.Sink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotificationSink indicates an expected call of GetNotificationSink.
+func (mr *MockNotificationStoreMockRecorder) GetNotificationSink(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotificationSink", reflect.TypeOf((*MockNotificationStore)(nil).GetNotificationSink), id)
+}
+
+// ListNotificationSinks mocks base method.
+func (m *MockNotificationStore) ListNotificationSinks() ([]*types10.Sink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListNotificationSinks")
+	ret0, _ := ret[0].([]*types10.Sink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListNotificationSinks indicates an expected call of ListNotificationSinks.
+func (mr *MockNotificationStoreMockRecorder) ListNotificationSinks() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNotificationSinks", reflect.TypeOf((*MockNotificationStore)(nil).ListNotificationSinks))
+}
+
+// UpdateNotificationSink mocks base method.
+func (m *MockNotificationStore) UpdateNotificationSink(sink types10.Sink) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateNotificationSink", sink)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateNotificationSink indicates an expected call of UpdateNotificationSink.
+func (mr *MockNotificationStoreMockRecorder) UpdateNotificationSink(sink interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateNotificationSink", reflect.TypeOf((*MockNotificationStore)(nil).UpdateNotificationSink), sink)
+}
+
+// MockRBACStore is a mock of RBACStore interface.
+type MockRBACStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockRBACStoreMockRecorder
+}
+
+// MockRBACStoreMockRecorder is the mock recorder for MockRBACStore.
+type MockRBACStoreMockRecorder struct {
+	mock *MockRBACStore
+}
+
+// NewMockRBACStore creates a new mock instance.
+func NewMockRBACStore(ctrl *gomock.Controller) *MockRBACStore {
+	mock := &MockRBACStore{ctrl: ctrl}
+	mock.recorder = &MockRBACStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRBACStore) EXPECT() *MockRBACStoreMockRecorder {
+	return m.recorder
+}
+
+// CreateRBACRole mocks base method.
+func (m *MockRBACStore) CreateRBACRole(role types13.Role) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRBACRole", role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateRBACRole indicates an expected call of CreateRBACRole.
+func (mr *MockRBACStoreMockRecorder) CreateRBACRole(role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRBACRole", reflect.TypeOf((*MockRBACStore)(nil).CreateRBACRole), role)
+}
+
+// DeleteRBACRole mocks base method.
+func (m *MockRBACStore) DeleteRBACRole(id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRBACRole", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRBACRole indicates an expected call of DeleteRBACRole.
+func (mr *MockRBACStoreMockRecorder) DeleteRBACRole(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRBACRole", reflect.TypeOf((*MockRBACStore)(nil).DeleteRBACRole), id)
+}
+
+// GetRBACRole mocks base method.
+func (m *MockRBACStore) GetRBACRole(id string) (*types13.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRBACRole", id)
+	ret0, _ := ret[0].(*types13.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRBACRole indicates an expected call of GetRBACRole.
+func (mr *MockRBACStoreMockRecorder) GetRBACRole(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRBACRole", reflect.TypeOf((*MockRBACStore)(nil).GetRBACRole), id)
+}
+
+// ListRBACRoles mocks base method.
+func (m *MockRBACStore) ListRBACRoles() ([]types13.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRBACRoles")
+	ret0, _ := ret[0].([]types13.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRBACRoles indicates an expected call of ListRBACRoles.
+func (mr *MockRBACStoreMockRecorder) ListRBACRoles() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRBACRoles", reflect.TypeOf((*MockRBACStore)(nil).ListRBACRoles))
+}
+
+// UpdateRBACRole mocks base method.
+func (m *MockRBACStore) UpdateRBACRole(role types13.Role) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRBACRole", role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRBACRole indicates an expected call of UpdateRBACRole.
+func (mr *MockRBACStoreMockRecorder) UpdateRBACRole(role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRBACRole", reflect.TypeOf((*MockRBACStore)(nil).UpdateRBACRole), role)
+}
+
+// MockAPITokenStore is a mock of APITokenStore interface.
+type MockAPITokenStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPITokenStoreMockRecorder
+}
+
+// MockAPITokenStoreMockRecorder is the mock recorder for MockAPITokenStore.
+type MockAPITokenStoreMockRecorder struct {
+	mock *MockAPITokenStore
+}
+
+// NewMockAPITokenStore creates a new mock instance.
+func NewMockAPITokenStore(ctrl *gomock.Controller) *MockAPITokenStore {
+	mock := &MockAPITokenStore{ctrl: ctrl}
+	mock.recorder = &MockAPITokenStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPITokenStore) EXPECT() *MockAPITokenStoreMockRecorder {
+	return m.recorder
+}
+
+// CreateAPIToken mocks base method.
+func (m *MockAPITokenStore) CreateAPIToken(token types3.APIToken, tokenHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIToken", token, tokenHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAPIToken indicates an expected call of CreateAPIToken.
+func (mr *MockAPITokenStoreMockRecorder) CreateAPIToken(token, tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIToken", reflect.TypeOf((*MockAPITokenStore)(nil).CreateAPIToken), token, tokenHash)
+}
+
+// DeleteAPIToken mocks base method.
+func (m *MockAPITokenStore) DeleteAPIToken(id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAPIToken", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAPIToken indicates an expected call of DeleteAPIToken.
+func (mr *MockAPITokenStoreMockRecorder) DeleteAPIToken(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAPIToken", reflect.TypeOf((*MockAPITokenStore)(nil).DeleteAPIToken), id)
+}
+
+// DeleteAPITokensCreatedBy mocks base method.
+func (m *MockAPITokenStore) DeleteAPITokensCreatedBy(createdBy string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAPITokensCreatedBy", createdBy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAPITokensCreatedBy indicates an expected call of DeleteAPITokensCreatedBy.
+func (mr *MockAPITokenStoreMockRecorder) DeleteAPITokensCreatedBy(createdBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAPITokensCreatedBy", reflect.TypeOf((*MockAPITokenStore)(nil).DeleteAPITokensCreatedBy), createdBy)
+}
+
+// GetAPITokenByHash mocks base method.
+func (m *MockAPITokenStore) GetAPITokenByHash(tokenHash string) (*types3.APIToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPITokenByHash", tokenHash)
+	ret0, _ := ret[0].(*types3.APIToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPITokenByHash indicates an expected call of GetAPITokenByHash.
+func (mr *MockAPITokenStoreMockRecorder) GetAPITokenByHash(tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPITokenByHash", reflect.TypeOf((*MockAPITokenStore)(nil).GetAPITokenByHash), tokenHash)
+}
+
+// ListAPITokens mocks base method.
+func (m *MockAPITokenStore) ListAPITokens() ([]types3.APIToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAPITokens")
+	ret0, _ := ret[0].([]types3.APIToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAPITokens indicates an expected call of ListAPITokens.
+func (mr *MockAPITokenStoreMockRecorder) ListAPITokens() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAPITokens", reflect.TypeOf((*MockAPITokenStore)(nil).ListAPITokens))
+}
+
+// UpdateAPITokenLastUsedAt mocks base method.
+func (m *MockAPITokenStore) UpdateAPITokenLastUsedAt(id string, lastUsedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAPITokenLastUsedAt", id, lastUsedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAPITokenLastUsedAt indicates an expected call of UpdateAPITokenLastUsedAt.
+func (mr *MockAPITokenStoreMockRecorder) UpdateAPITokenLastUsedAt(id, lastUsedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAPITokenLastUsedAt", reflect.TypeOf((*MockAPITokenStore)(nil).UpdateAPITokenLastUsedAt), id, lastUsedAt)
+}
+
+// MockLocalUserStore is a mock of LocalUserStore interface.
+type MockLocalUserStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockLocalUserStoreMockRecorder
+}
+
+// MockLocalUserStoreMockRecorder is the mock recorder for MockLocalUserStore.
+type MockLocalUserStoreMockRecorder struct {
+	mock *MockLocalUserStore
+}
+
+// NewMockLocalUserStore creates a new mock instance.
+func NewMockLocalUserStore(ctrl *gomock.Controller) *MockLocalUserStore {
+	mock := &MockLocalUserStore{ctrl: ctrl}
+	mock.recorder = &MockLocalUserStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLocalUserStore) EXPECT() *MockLocalUserStoreMockRecorder {
+	return m.recorder
+}
+
+// CreateLocalUser mocks base method.
+func (m *MockLocalUserStore) CreateLocalUser(user types20.LocalUser, passwordBcrypt []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateLocalUser", user, passwordBcrypt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateLocalUser indicates an expected call of CreateLocalUser.
+func (mr *MockLocalUserStoreMockRecorder) CreateLocalUser(user, passwordBcrypt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLocalUser", reflect.TypeOf((*MockLocalUserStore)(nil).CreateLocalUser), user, passwordBcrypt)
+}
+
+// DeleteLocalUser mocks base method.
+func (m *MockLocalUserStore) DeleteLocalUser(username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteLocalUser", username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteLocalUser indicates an expected call of DeleteLocalUser.
+func (mr *MockLocalUserStoreMockRecorder) DeleteLocalUser(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLocalUser", reflect.TypeOf((*MockLocalUserStore)(nil).DeleteLocalUser), username)
+}
+
+// FlagLocalUserInvalidPassword mocks base method.
+func (m *MockLocalUserStore) FlagLocalUserInvalidPassword(username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlagLocalUserInvalidPassword", username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlagLocalUserInvalidPassword indicates an expected call of FlagLocalUserInvalidPassword.
+func (mr *MockLocalUserStoreMockRecorder) FlagLocalUserInvalidPassword(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlagLocalUserInvalidPassword", reflect.TypeOf((*MockLocalUserStore)(nil).FlagLocalUserInvalidPassword), username)
+}
+
+// FlagLocalUserSuccessfulLogin mocks base method.
+func (m *MockLocalUserStore) FlagLocalUserSuccessfulLogin(username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlagLocalUserSuccessfulLogin", username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlagLocalUserSuccessfulLogin indicates an expected call of FlagLocalUserSuccessfulLogin.
+func (mr *MockLocalUserStoreMockRecorder) FlagLocalUserSuccessfulLogin(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlagLocalUserSuccessfulLogin", reflect.TypeOf((*MockLocalUserStore)(nil).FlagLocalUserSuccessfulLogin), username)
+}
+
+// GetLocalUser mocks base method.
+func (m *MockLocalUserStore) GetLocalUser(username string) (*types20.LocalUser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLocalUser", username)
+	ret0, _ := ret[0].(*types20.LocalUser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLocalUser indicates an expected call of GetLocalUser.
+func (mr *MockLocalUserStoreMockRecorder) GetLocalUser(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLocalUser", reflect.TypeOf((*MockLocalUserStore)(nil).GetLocalUser), username)
+}
+
+// GetLocalUserPasswordBcrypt mocks base method.
+func (m *MockLocalUserStore) GetLocalUserPasswordBcrypt(username string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLocalUserPasswordBcrypt", username)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLocalUserPasswordBcrypt indicates an expected call of GetLocalUserPasswordBcrypt.
+func (mr *MockLocalUserStoreMockRecorder) GetLocalUserPasswordBcrypt(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLocalUserPasswordBcrypt", reflect.TypeOf((*MockLocalUserStore)(nil).GetLocalUserPasswordBcrypt), username)
+}
+
+// GetLocalUserPasswordHistory mocks base method.
+func (m *MockLocalUserStore) GetLocalUserPasswordHistory(username string) ([][]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLocalUserPasswordHistory", username)
+	ret0, _ := ret[0].([][]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLocalUserPasswordHistory indicates an expected call of GetLocalUserPasswordHistory.
+func (mr *MockLocalUserStoreMockRecorder) GetLocalUserPasswordHistory(username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLocalUserPasswordHistory", reflect.TypeOf((*MockLocalUserStore)(nil).GetLocalUserPasswordHistory), username)
+}
+
+// ListLocalUsers mocks base method.
+func (m *MockLocalUserStore) ListLocalUsers() ([]types20.LocalUser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLocalUsers")
+	ret0, _ := ret[0].([]types20.LocalUser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLocalUsers indicates an expected call of ListLocalUsers.
+func (mr *MockLocalUserStoreMockRecorder) ListLocalUsers() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLocalUsers", reflect.TypeOf((*MockLocalUserStore)(nil).ListLocalUsers))
+}
+
+// SetLocalUserPasswordBcrypt mocks base method.
+func (m *MockLocalUserStore) SetLocalUserPasswordBcrypt(username string, passwordBcrypt []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLocalUserPasswordBcrypt", username, passwordBcrypt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetLocalUserPasswordBcrypt indicates an expected call of SetLocalUserPasswordBcrypt.
+func (mr *MockLocalUserStoreMockRecorder) SetLocalUserPasswordBcrypt(username, passwordBcrypt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLocalUserPasswordBcrypt", reflect.TypeOf((*MockLocalUserStore)(nil).SetLocalUserPasswordBcrypt), username, passwordBcrypt)
+}
+
+// UpdateLocalUser mocks base method.
+func (m *MockLocalUserStore) UpdateLocalUser(username string, roles []string, isDisabled bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLocalUser", username, roles, isDisabled)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateLocalUser indicates an expected call of UpdateLocalUser.
+func (mr *MockLocalUserStoreMockRecorder) UpdateLocalUser(username, roles, isDisabled interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLocalUser", reflect.TypeOf((*MockLocalUserStore)(nil).UpdateLocalUser), username, roles, isDisabled)
+}
+
+// MockAuditStore is a mock of AuditStore interface.
+type MockAuditStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditStoreMockRecorder
+}
+
+// MockAuditStoreMockRecorder is the mock recorder for MockAuditStore.
+type MockAuditStoreMockRecorder struct {
+	mock *MockAuditStore
+}
+
+// NewMockAuditStore creates a new mock instance.
+func NewMockAuditStore(ctrl *gomock.Controller) *MockAuditStore {
+	mock := &MockAuditStore{ctrl: ctrl}
+	mock.recorder = &MockAuditStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditStore) EXPECT() *MockAuditStoreMockRecorder {
+	return m.recorder
+}
+
+// CreateAuditEvent mocks base method.
+func (m *MockAuditStore) CreateAuditEvent(event types6.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditEvent", event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAuditEvent indicates an expected call of CreateAuditEvent.
+func (mr *MockAuditStoreMockRecorder) CreateAuditEvent(event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditEvent", reflect.TypeOf((*MockAuditStore)(nil).CreateAuditEvent), event)
+}
+
+// ListAuditEvents mocks base method.
+func (m *MockAuditStore) ListAuditEvents(opts types6.ListOptions) ([]types6.Event, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAuditEvents", opts)
+	ret0, _ := ret[0].([]types6.Event)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListAuditEvents indicates an expected call of ListAuditEvents.
+func (mr *MockAuditStoreMockRecorder) ListAuditEvents(opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditEvents", reflect.TypeOf((*MockAuditStore)(nil).ListAuditEvents), opts)
+}
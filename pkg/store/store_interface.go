@@ -8,12 +8,17 @@ import (
 	downstreamtypes "github.com/replicatedhq/kots/pkg/api/downstream/types"
 	reportingtypes "github.com/replicatedhq/kots/pkg/api/reporting/types"
 	versiontypes "github.com/replicatedhq/kots/pkg/api/version/types"
+	apitokentypes "github.com/replicatedhq/kots/pkg/apitoken/types"
 	apptypes "github.com/replicatedhq/kots/pkg/app/types"
 	appstatetypes "github.com/replicatedhq/kots/pkg/appstate/types"
+	audittypes "github.com/replicatedhq/kots/pkg/audit/types"
+	drifttypes "github.com/replicatedhq/kots/pkg/drift/types"
 	gitopstypes "github.com/replicatedhq/kots/pkg/gitops/types"
 	snapshottypes "github.com/replicatedhq/kots/pkg/kotsadmsnapshot/types"
+	notificationtypes "github.com/replicatedhq/kots/pkg/notifications/types"
 	installationtypes "github.com/replicatedhq/kots/pkg/online/types"
 	preflighttypes "github.com/replicatedhq/kots/pkg/preflight/types"
+	rbactypes "github.com/replicatedhq/kots/pkg/rbac/types"
 	registrytypes "github.com/replicatedhq/kots/pkg/registry/types"
 	rendertypes "github.com/replicatedhq/kots/pkg/render/types"
 	sessiontypes "github.com/replicatedhq/kots/pkg/session/types"
@@ -35,6 +40,7 @@ type Store interface {
 	TaskStore
 	SessionStore
 	AppStatusStore
+	DriftStore
 	AppStore
 	DownstreamStore
 	VersionStore
@@ -47,6 +53,11 @@ type Store interface {
 	EmbeddedStore
 	BrandingStore
 	ReportingStore
+	NotificationStore
+	RBACStore
+	AuditStore
+	APITokenStore
+	LocalUserStore
 
 	Init() error // this may need options
 	WaitForReady(ctx context.Context) error
@@ -112,11 +123,18 @@ type SessionStore interface {
 	GetSession(sessionID string) (*sessiontypes.Session, error)
 	UpdateSessionExpiresAt(sessionID string, expiresAt time.Time) error
 	DeleteExpiredSessions() error
+	DeleteUserSessions(userID string) error
 }
 
 type AppStatusStore interface {
 	GetAppStatus(appID string) (*appstatetypes.AppStatus, error)
 	SetAppStatus(appID string, resourceStates appstatetypes.ResourceStates, updatedAt time.Time, sequence int64) error
+	GetAppStatusHistory(appID string, sequence *int64) ([]appstatetypes.ResourceStateTransition, error)
+}
+
+type DriftStore interface {
+	GetAppDriftReport(appID string) (*drifttypes.DriftReport, error)
+	SetAppDriftReport(report *drifttypes.DriftReport) error
 }
 
 type AppStore interface {
@@ -129,12 +147,16 @@ type AppStore interface {
 	GetApp(appID string) (*apptypes.App, error)
 	GetAppFromSlug(slug string) (*apptypes.App, error)
 	CreateApp(name string, upstreamURI string, licenseData string, isAirgapEnabled bool, skipImagePush bool, registryIsReadOnly bool) (*apptypes.App, error)
+	SetAppUpstreamCredentials(appID string, username string, password string) error
 	ListDownstreamsForApp(appID string) ([]downstreamtypes.Downstream, error)
 	ListAppsForDownstream(clusterID string) ([]*apptypes.App, error)
 	GetDownstream(clusterID string) (*downstreamtypes.Downstream, error)
 	IsGitOpsEnabledForApp(appID string) (bool, error)
 	SetUpdateCheckerSpec(appID string, updateCheckerSpec string) error
 	SetAutoDeploy(appID string, autoDeploy apptypes.AutoDeploy) error
+	SetMaintenanceWindow(appID string, maintenanceWindow *apptypes.MaintenanceWindow) error
+	SetPendingAutoDeploySequence(appID string, sequence *int64) error
+	SetDeployStrategy(appID string, deployStrategy apptypes.DeployStrategy, verificationWindow string) error
 	SetSnapshotTTL(appID string, snapshotTTL string) error
 	SetSnapshotSchedule(appID string, snapshotSchedule string) error
 	RemoveApp(appID string) error
@@ -167,6 +189,8 @@ type DownstreamStore interface {
 	IsDownstreamDeploySuccessful(appID string, clusterID string, sequence int64) (bool, error)
 	UpdateDownstreamDeployStatus(appID string, clusterID string, sequence int64, isError bool, output downstreamtypes.DownstreamOutput) error
 	DeleteDownstreamDeployStatus(appID string, clusterID string, sequence int64) error
+	ListOpenDownstreamPullRequests() ([]downstreamtypes.DownstreamPullRequest, error)
+	SetDownstreamVersionPullRequestState(appID string, clusterID string, sequence int64, state gitopstypes.PullRequestState) error
 }
 
 type SnapshotStore interface {
@@ -197,6 +221,7 @@ type VersionStore interface {
 	GetLatestAppSequence(appID string, downloadedOnly bool) (int64, error)
 	UpdateNextAppVersionDiffSummary(appID string, baseSequence int64) error
 	UpdateAppVersionInstallationSpec(appID string, sequence int64, spec kotsv1beta1.Installation) error
+	UpdateAppVersionEncryptedData(appID string, sequence int64, configValues *kotsv1beta1.ConfigValues, installation kotsv1beta1.Installation) error
 	GetNextAppSequence(appID string) (int64, error)
 	GetCurrentUpdateCursor(appID string, channelID string) (string, error)
 	HasStrictPreflights(appID string, sequence int64) (bool, error)
@@ -217,6 +242,7 @@ type UserStore interface {
 	GetPasswordUpdatedAt() (*time.Time, error)
 	FlagInvalidPassword() error
 	FlagSuccessfulLogin() error
+	GetFailedLogins() ([]time.Time, error)
 }
 
 type ClusterStore interface {
@@ -253,3 +279,46 @@ type ReportingStore interface {
 	SavePreflightReport(licenseID string, preflightStatus *reportingtypes.PreflightStatus) error
 	SaveReportingInfo(licenseID string, reportingInfo *reportingtypes.ReportingInfo) error
 }
+
+type NotificationStore interface {
+	ListNotificationSinks() ([]*notificationtypes.Sink, error)
+	GetNotificationSink(id string) (*notificationtypes.Sink, error)
+	CreateNotificationSink(sink notificationtypes.Sink) (*notificationtypes.Sink, error)
+	UpdateNotificationSink(sink notificationtypes.Sink) error
+	DeleteNotificationSink(id string) error
+}
+
+type RBACStore interface {
+	ListRBACRoles() ([]rbactypes.Role, error)
+	GetRBACRole(id string) (*rbactypes.Role, error)
+	CreateRBACRole(role rbactypes.Role) error
+	UpdateRBACRole(role rbactypes.Role) error
+	DeleteRBACRole(id string) error
+}
+
+type APITokenStore interface {
+	CreateAPIToken(token apitokentypes.APIToken, tokenHash string) error
+	ListAPITokens() ([]apitokentypes.APIToken, error)
+	GetAPITokenByHash(tokenHash string) (*apitokentypes.APIToken, error)
+	UpdateAPITokenLastUsedAt(id string, lastUsedAt time.Time) error
+	DeleteAPIToken(id string) error
+	DeleteAPITokensCreatedBy(createdBy string) error
+}
+
+type LocalUserStore interface {
+	CreateLocalUser(user usertypes.LocalUser, passwordBcrypt []byte) error
+	ListLocalUsers() ([]usertypes.LocalUser, error)
+	GetLocalUser(username string) (*usertypes.LocalUser, error)
+	GetLocalUserPasswordBcrypt(username string) ([]byte, error)
+	GetLocalUserPasswordHistory(username string) ([][]byte, error)
+	UpdateLocalUser(username string, roles []string, isDisabled bool) error
+	SetLocalUserPasswordBcrypt(username string, passwordBcrypt []byte) error
+	FlagLocalUserInvalidPassword(username string) error
+	FlagLocalUserSuccessfulLogin(username string) error
+	DeleteLocalUser(username string) error
+}
+
+type AuditStore interface {
+	CreateAuditEvent(event audittypes.Event) error
+	ListAuditEvents(opts audittypes.ListOptions) ([]audittypes.Event, int64, error)
+}
@@ -0,0 +1,130 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/buildversion"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// ExporterEnv selects where spans are exported to: "otlp", "file" or "none" (the default)
+	ExporterEnv = "OTEL_TRACES_EXPORTER"
+	// FileEnv is the path spans are written to when the "file" exporter is used
+	FileEnv = "KOTSADM_TRACES_FILE"
+
+	ExporterOTLP = "otlp"
+	ExporterFile = "file"
+	ExporterNone = "none"
+
+	tracerName = "github.com/replicatedhq/kots"
+)
+
+// Init configures the global tracer provider from the environment. The otlp exporter sends spans
+// over http to the collector at OTEL_EXPORTER_OTLP_ENDPOINT (http://localhost:4318 by default) and the
+// file exporter writes them as json to KOTSADM_TRACES_FILE. The returned func flushes and stops the exporter.
+func Init(ctx context.Context, serviceName string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	exporter, closeExporter, err := newExporter(ctx, os.Getenv(ExporterEnv))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create exporter")
+	}
+	if exporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	res := resource.NewSchemaless(
+		attribute.String("service.name", serviceName),
+		attribute.String("service.version", buildversion.Version()),
+	)
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+
+	return func(ctx context.Context) error {
+		if err := tp.Shutdown(ctx); err != nil {
+			return errors.Wrap(err, "failed to shutdown tracer provider")
+		}
+		return closeExporter()
+	}, nil
+}
+
+func newExporter(ctx context.Context, name string) (sdktrace.SpanExporter, func() error, error) {
+	noClose := func() error { return nil }
+
+	switch strings.ToLower(name) {
+	case "", ExporterNone:
+		return nil, noClose, nil
+
+	case ExporterOTLP:
+		exporter, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to create otlp exporter")
+		}
+		return exporter, noClose, nil
+
+	case ExporterFile:
+		filename := os.Getenv(FileEnv)
+		if filename == "" {
+			filename = filepath.Join(os.TempDir(), "kotsadm-traces.json")
+		}
+		f, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to open %s", filename)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, nil, errors.Wrap(err, "failed to create file exporter")
+		}
+		return exporter, f.Close, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unsupported traces exporter %q", name)
+	}
+}
+
+// StartSpan starts a span as a child of the span in ctx, if any
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan records err on the span, if not nil, and ends it
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Detach returns a context that carries the span of ctx but is never canceled. It is used to
+// continue a trace in work that outlives the request that started it.
+func Detach(ctx context.Context) context.Context {
+	return trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(ctx))
+}
+
+// AppAttributes returns the attributes used to correlate spans with an app version
+func AppAttributes(appID string, sequence int64) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("app_id", appID),
+		attribute.Int64("sequence", sequence),
+	}
+}
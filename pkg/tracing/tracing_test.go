@@ -0,0 +1,110 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestStartSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(tp)
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+	ctx, parent := StartSpan(context.Background(), "deploy_app", AppAttributes("app-id", 3)...)
+	_, child := StartSpan(ctx, "deploy_app.apply")
+	EndSpan(child, errors.New("apply failed"))
+	EndSpan(parent, nil)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	assert.Equal(t, "deploy_app.apply", spans[0].Name())
+	assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "apply failed", spans[0].Status().Description)
+	require.Len(t, spans[0].Events(), 1)
+
+	assert.Equal(t, "deploy_app", spans[1].Name())
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+	assert.ElementsMatch(t, []attribute.KeyValue{
+		attribute.String("app_id", "app-id"),
+		attribute.Int64("sequence", 3),
+	}, spans[1].Attributes())
+}
+
+func TestDetach(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(tp)
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx, parent := StartSpan(ctx, "request")
+	detached := Detach(ctx)
+	cancel()
+	EndSpan(parent, nil)
+
+	require.NoError(t, detached.Err())
+
+	_, child := StartSpan(detached, "background")
+	EndSpan(child, nil)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, spans[0].SpanContext().TraceID(), spans[1].SpanContext().TraceID())
+	assert.Equal(t, spans[0].SpanContext().SpanID(), spans[1].Parent().SpanID())
+}
+
+func Test_newExporter(t *testing.T) {
+	t.Run("none", func(t *testing.T) {
+		for _, name := range []string{"", "none"} {
+			exporter, _, err := newExporter(context.Background(), name)
+			require.NoError(t, err)
+			assert.Nil(t, exporter)
+		}
+	})
+
+	t.Run("otlp", func(t *testing.T) {
+		exporter, _, err := newExporter(context.Background(), "otlp")
+		require.NoError(t, err)
+		assert.NotNil(t, exporter)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, _, err := newExporter(context.Background(), "zipkin")
+		require.Error(t, err)
+	})
+}
+
+func TestInitFileExporter(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "traces.json")
+	t.Setenv(ExporterEnv, ExporterFile)
+	t.Setenv(FileEnv, filename)
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+	shutdown, err := Init(context.Background(), "kotsadm")
+	require.NoError(t, err)
+
+	_, span := StartSpan(context.Background(), "pull.fetch_upstream")
+	EndSpan(span, nil)
+
+	require.NoError(t, shutdown(context.Background()))
+
+	b, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"Name":"pull.fetch_upstream"`)
+	assert.Contains(t, string(b), `"Value":"kotsadm"`)
+}
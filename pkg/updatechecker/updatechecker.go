@@ -1,8 +1,10 @@
 package updatechecker
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -17,6 +19,9 @@ import (
 	upstream "github.com/replicatedhq/kots/pkg/kotsadmupstream"
 	kotslicense "github.com/replicatedhq/kots/pkg/license"
 	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/metrics"
+	"github.com/replicatedhq/kots/pkg/notifications"
+	notificationtypes "github.com/replicatedhq/kots/pkg/notifications/types"
 	"github.com/replicatedhq/kots/pkg/preflight"
 	"github.com/replicatedhq/kots/pkg/preflight/types"
 	kotspull "github.com/replicatedhq/kots/pkg/pull"
@@ -26,24 +31,40 @@ import (
 	storepkg "github.com/replicatedhq/kots/pkg/store"
 	storetypes "github.com/replicatedhq/kots/pkg/store/types"
 	"github.com/replicatedhq/kots/pkg/tasks"
+	"github.com/replicatedhq/kots/pkg/tracing"
 	upstreamtypes "github.com/replicatedhq/kots/pkg/upstream/types"
 	"github.com/replicatedhq/kots/pkg/util"
 	"github.com/replicatedhq/kots/pkg/version"
 	cron "github.com/robfig/cron/v3"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 	"k8s.io/apimachinery/pkg/util/wait"
 )
 
 // jobs maps app ids to their cron jobs
 var jobs = make(map[string]*cron.Cron)
+
+// specs maps app ids to the update checker spec their cron job was configured with
+var specs = make(map[string]string)
+
+// started is set once the update checker is started on the kotsadm leader.
+// the other replicas only persist schedule changes, which the leader picks up from the database.
+var started bool
 var mtx sync.Mutex
 var store = storepkg.GetStore()
 
+// pendingDeployCronSpec is how often versions queued by auto deploy are checked against the maintenance window
+const pendingDeployCronSpec = "* * * * *"
+
 // Start will start the update checker
 // the frequency of those update checks are app specific and can be modified by the user
 func Start() error {
 	logger.Debug("starting update checker")
 
+	mtx.Lock()
+	started = true
+	mtx.Unlock()
+
 	appsList, err := store.ListInstalledApps()
 	if err != nil {
 		return errors.Wrap(err, "failed to list installed apps")
@@ -58,6 +79,119 @@ func Start() error {
 		}
 	}
 
+	pendingDeployJob := cron.New(cron.WithChain(
+		cron.Recover(cron.DefaultLogger),
+	))
+	if _, err := pendingDeployJob.AddFunc(pendingDeployCronSpec, deployPendingVersions); err != nil {
+		return errors.Wrap(err, "failed to add pending deploy func")
+	}
+	if _, err := pendingDeployJob.AddFunc(pendingDeployCronSpec, syncSchedules); err != nil {
+		return errors.Wrap(err, "failed to add sync schedules func")
+	}
+	pendingDeployJob.Start()
+
+	return nil
+}
+
+// syncSchedules reconfigures the apps whose update checker spec was changed by another kotsadm replica
+func syncSchedules() {
+	appsList, err := store.ListInstalledApps()
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to list installed apps"))
+		return
+	}
+
+	for _, a := range appsList {
+		if a.IsAirgap {
+			continue
+		}
+
+		mtx.Lock()
+		spec, ok := specs[a.ID]
+		mtx.Unlock()
+		if ok && spec == a.UpdateCheckerSpec {
+			continue
+		}
+
+		if err := Configure(a, a.UpdateCheckerSpec); err != nil {
+			logger.Error(errors.Wrapf(err, "failed to configure app %s", a.Slug))
+		}
+	}
+}
+
+// deployPendingVersions deploys the versions queued by auto deploy for apps whose maintenance window is open
+func deployPendingVersions() {
+	appsList, err := store.ListInstalledApps()
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to list installed apps"))
+		return
+	}
+
+	for _, a := range appsList {
+		if a.PendingAutoDeploySequence == nil {
+			continue
+		}
+		if err := deployPendingVersion(a, time.Now()); err != nil {
+			logger.Error(errors.Wrapf(err, "failed to deploy pending version for app %s", a.Slug))
+		}
+	}
+}
+
+func deployPendingVersion(a *apptypes.App, now time.Time) error {
+	isOpen, err := a.MaintenanceWindow.IsOpen(now)
+	if err != nil {
+		return errors.Wrap(err, "failed to check maintenance window")
+	}
+	if !isOpen {
+		return nil
+	}
+
+	// clear the pending sequence first so that a failing deploy is not retried every minute
+	if err := store.SetPendingAutoDeploySequence(a.ID, nil); err != nil {
+		return errors.Wrap(err, "failed to clear pending auto deploy sequence")
+	}
+
+	downstreams, err := store.ListDownstreamsForApp(a.ID)
+	if err != nil {
+		return errors.Wrap(err, "failed to list downstreams for app")
+	}
+	if len(downstreams) == 0 {
+		return errors.New("no downstreams for app")
+	}
+	clusterID := downstreams[0].ClusterID
+
+	appVersions, err := store.GetDownstreamVersions(a.ID, clusterID, true)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get app versions for app %s", a.ID)
+	}
+
+	// the version is only deployed if it is still newer than the deployed version
+	var versionToDeploy *downstreamtypes.DownstreamVersion
+	for _, v := range appVersions.PendingVersions {
+		if v.Sequence == *a.PendingAutoDeploySequence {
+			versionToDeploy = v
+			break
+		}
+	}
+	if versionToDeploy == nil {
+		logger.Info("pending auto deploy version is no longer pending, skipping",
+			zap.String("slug", a.Slug),
+			zap.Int64("sequence", *a.PendingAutoDeploySequence))
+		return nil
+	}
+
+	if err := waitForPreflightsToFinish(a.ID, versionToDeploy.Sequence); err != nil {
+		return errors.Wrap(err, "not able to auto-deploy due to failed preflight check")
+	}
+
+	opts := CheckForUpdatesOpts{
+		AppID:       a.ID,
+		IsAutomatic: true,
+	}
+	if err := deployVersion(opts, clusterID, appVersions, versionToDeploy); err != nil {
+		return errors.Wrapf(err, "failed to deploy sequence %d with version label %s", versionToDeploy.Sequence, versionToDeploy.VersionLabel)
+	}
+
 	return nil
 }
 
@@ -65,7 +199,7 @@ func Start() error {
 // if enabled, and cron job was NOT found: add a new cron job to check app updates
 // if enabled, and a cron job was found, update the existing cron job with the latest cron spec
 // if disabled: stop the current running cron job (if exists)
-// no-op for airgap applications
+// no-op for airgap applications and before the update checker is started
 func Configure(a apptypes.AppType, updateCheckerSpec string) error {
 	appId := a.GetID()
 	appSlug := a.GetSlug()
@@ -75,16 +209,25 @@ func Configure(a apptypes.AppType, updateCheckerSpec string) error {
 		return nil
 	}
 
+	if kotsApp, ok := a.(*apptypes.App); ok && !kotsApp.IsReplicatedUpstream() {
+		return nil
+	}
+
 	logger.Debug("configure update checker for app",
 		zap.String("slug", appSlug))
 
 	mtx.Lock()
 	defer mtx.Unlock()
 
+	if !started {
+		return nil
+	}
+
 	cronSpec := updateCheckerSpec
 
 	if cronSpec == "@never" || cronSpec == "" {
 		Stop(appId)
+		specs[appId] = updateCheckerSpec
 		return nil
 	}
 
@@ -141,6 +284,7 @@ func Configure(a apptypes.AppType, updateCheckerSpec string) error {
 
 	job.Start()
 	jobs[appId] = job
+	specs[appId] = updateCheckerSpec
 
 	return nil
 }
@@ -187,6 +331,21 @@ type UpdateCheckRelease struct {
 // otherwise, if "IsAutomatic" is set to true (which means it's an automatic update check), then the version that matches the auto deploy configuration (if enabled) will be deployed.
 // returns the number of available updates.
 func CheckForUpdates(opts CheckForUpdatesOpts) (ucr *UpdateCheckResponse, finalError error) {
+	startTime := time.Now()
+	ctx, span := tracing.StartSpan(context.Background(), "check_for_updates",
+		attribute.String("app_id", opts.AppID),
+		attribute.Bool("automatic", opts.IsAutomatic),
+	)
+	defer func() {
+		if finalError != nil {
+			metrics.ObserveUpdateCheck(opts.AppID, 0, finalError, time.Since(startTime))
+		} else if ucr != nil {
+			metrics.ObserveUpdateCheck(opts.AppID, ucr.AvailableUpdates, nil, time.Since(startTime))
+			span.SetAttributes(attribute.Int64("available_updates", ucr.AvailableUpdates))
+		}
+		tracing.EndSpan(span, finalError)
+	}()
+
 	currentStatus, _, err := store.GetTaskStatus("update-download")
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get task status")
@@ -218,7 +377,7 @@ func CheckForUpdates(opts CheckForUpdatesOpts) (ucr *UpdateCheckResponse, finalE
 			return
 		}
 	} else {
-		ucr, finalError = checkForKotsAppUpdates(opts, finishedChan)
+		ucr, finalError = checkForKotsAppUpdates(ctx, opts, finishedChan)
 		if finalError != nil {
 			finalError = errors.Wrap(finalError, "failed to get kots app updates")
 			return
@@ -303,12 +462,19 @@ func checkForHelmAppUpdates(opts CheckForUpdatesOpts, finishedChan chan<- error)
 	return &ucr, nil
 }
 
-func checkForKotsAppUpdates(opts CheckForUpdatesOpts, finishedChan chan<- error) (*UpdateCheckResponse, error) {
+func checkForKotsAppUpdates(ctx context.Context, opts CheckForUpdatesOpts, finishedChan chan<- error) (*UpdateCheckResponse, error) {
 	a, err := store.GetApp(opts.AppID)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get app")
 	}
 
+	if !a.IsReplicatedUpstream() {
+		return nil, util.ActionableError{
+			NoRetry: true,
+			Message: "Checking for updates is only supported for apps installed with a license. Use kots upload to create a new version.",
+		}
+	}
+
 	// sync license, this method is only called when online
 	latestLicense, _, err := license.Sync(a, "", false)
 	if err != nil {
@@ -396,6 +562,8 @@ func checkForKotsAppUpdates(opts CheckForUpdatesOpts, finishedChan chan<- error)
 		return &ucr, nil
 	}
 
+	notifyUpdatesAvailable(a, filteredUpdates)
+
 	// this is to avoid a race condition where the UI polls the task status before it is set by the goroutine
 	status := fmt.Sprintf("%d Updates available...", ucr.AvailableUpdates)
 	if err := store.SetTaskStatus("update-download", status, "running"); err != nil {
@@ -403,15 +571,15 @@ func checkForKotsAppUpdates(opts CheckForUpdatesOpts, finishedChan chan<- error)
 	}
 
 	if opts.Wait {
-		if err := downloadKotsAppUpdates(opts, a.ID, d.ClusterID, filteredUpdates, updates.UpdateCheckTime); err != nil {
+		if err := downloadKotsAppUpdates(ctx, opts, a.ID, d.ClusterID, filteredUpdates, updates.UpdateCheckTime); err != nil {
 			return nil, errors.Wrap(err, "failed to download updates synchronously")
 		}
 	} else if ucr.AvailableUpdates > 0 {
 		go func() {
 			defer close(finishedChan)
-			err := downloadKotsAppUpdates(opts, a.ID, d.ClusterID, filteredUpdates, updates.UpdateCheckTime)
+			err := downloadKotsAppUpdates(tracing.Detach(ctx), opts, a.ID, d.ClusterID, filteredUpdates, updates.UpdateCheckTime)
 			if err != nil {
-				logger.Error(errors.Wrap(err, "failed to download updates asynchronously"))
+				logger.WithContext(ctx).Error(errors.Wrap(err, "failed to download updates asynchronously"))
 			}
 			finishedChan <- err
 		}()
@@ -420,6 +588,19 @@ func checkForKotsAppUpdates(opts CheckForUpdatesOpts, finishedChan chan<- error)
 	return &ucr, nil
 }
 
+func notifyUpdatesAvailable(a *apptypes.App, updates []upstreamtypes.Update) {
+	versionLabels := []string{}
+	for _, u := range updates {
+		versionLabels = append(versionLabels, u.VersionLabel)
+	}
+	notifications.Notify(notificationtypes.Event{
+		Type:    notificationtypes.EventTypeUpdateAvailable,
+		AppID:   a.ID,
+		AppSlug: a.Slug,
+		Message: fmt.Sprintf("%d new version(s) available: %s", len(updates), strings.Join(versionLabels, ", ")),
+	})
+}
+
 func downloadHelmAppUpdates(opts CheckForUpdatesOpts, helmApp *apptypes.HelmApp, licenseID string, updates []UpdateCheckRelease) error {
 	currentKotsKinds, err := helm.GetKotsKindsFromHelmApp(helmApp)
 	if err != nil {
@@ -468,9 +649,9 @@ func downloadHelmAppUpdates(opts CheckForUpdatesOpts, helmApp *apptypes.HelmApp,
 	return nil
 }
 
-func downloadKotsAppUpdates(opts CheckForUpdatesOpts, appID string, clusterID string, updates []upstreamtypes.Update, updateCheckTime time.Time) error {
+func downloadKotsAppUpdates(ctx context.Context, opts CheckForUpdatesOpts, appID string, clusterID string, updates []upstreamtypes.Update, updateCheckTime time.Time) error {
 	for index, update := range updates {
-		appSequence, err := upstream.DownloadUpdate(appID, update, opts.SkipPreflights, opts.SkipCompatibilityCheck)
+		appSequence, err := upstream.DownloadUpdate(ctx, appID, update, opts.SkipPreflights, opts.SkipCompatibilityCheck)
 		if appSequence != nil {
 			// a version has been created, reset the "channel_changed" flag regardless if there was an error or not
 			if err := store.SetAppChannelChanged(appID, false); err != nil {
@@ -680,6 +861,32 @@ func autoDeploy(opts CheckForUpdatesOpts, clusterID string, autoDeploy apptypes.
 		return nil
 	}
 
+	a, err := store.GetApp(opts.AppID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get app")
+	}
+
+	isOpen, err := a.MaintenanceWindow.IsOpen(time.Now())
+	if err != nil {
+		return errors.Wrap(err, "failed to check maintenance window")
+	}
+	if !isOpen {
+		// queue the version, it will be deployed by the pending deploy job once the maintenance window opens
+		if err := store.SetPendingAutoDeploySequence(opts.AppID, &versionToDeploy.Sequence); err != nil {
+			return errors.Wrap(err, "failed to set pending auto deploy sequence")
+		}
+		logger.Info("queued auto deploy until maintenance window opens",
+			zap.String("appID", opts.AppID),
+			zap.Int64("sequence", versionToDeploy.Sequence))
+		return nil
+	}
+
+	if a.PendingAutoDeploySequence != nil {
+		if err := store.SetPendingAutoDeploySequence(opts.AppID, nil); err != nil {
+			return errors.Wrap(err, "failed to clear pending auto deploy sequence")
+		}
+	}
+
 	if err := waitForPreflightsToFinish(opts.AppID, versionToDeploy.Sequence); err != nil {
 		return errors.Wrap(err, "not able to auto-deploy due to failed preflight check")
 	}
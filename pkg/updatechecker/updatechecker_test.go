@@ -3,6 +3,7 @@ package updatechecker
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/blang/semver"
 	"github.com/golang/mock/gomock"
@@ -918,3 +919,119 @@ func Test_removeOldUpdates(t *testing.T) {
 		req.Equal(test.want, got)
 	}
 }
+
+func TestAutoDeployQueuesVersionOutsideMaintenanceWindow(t *testing.T) {
+	var autoDeployType = apptypes.AutoDeploySequence
+	var appID = "some-app"
+	var clusterID = "some-cluster-id"
+	var opts = CheckForUpdatesOpts{AppID: appID}
+	var currentCursor = cursor.MustParse("1")
+	var upgradeCursor = cursor.MustParse("2")
+	var downstreamVersions = &downstreamtypes.DownstreamVersions{
+		CurrentVersion: &downstreamtypes.DownstreamVersion{
+			Cursor:   &currentCursor,
+			Sequence: 1,
+		},
+		AllVersions: []*downstreamtypes.DownstreamVersion{
+			{
+				Cursor:   &upgradeCursor,
+				Sequence: 2,
+			},
+		},
+	}
+	// a window that is never open
+	var maintenanceWindow = &apptypes.MaintenanceWindow{
+		Ranges: []apptypes.MaintenanceWindowRange{{Days: []string{"mon"}, Start: "00:00", End: "00:01"}},
+	}
+	if isOpen, _ := maintenanceWindow.IsOpen(time.Now()); isOpen {
+		t.Skip("maintenance window is open")
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockStore := mock_store.NewMockStore(ctrl)
+	mockStore.EXPECT().GetDownstreamVersions(opts.AppID, clusterID, true).Return(downstreamVersions, nil)
+	mockStore.EXPECT().GetApp(appID).Return(&apptypes.App{ID: appID, MaintenanceWindow: maintenanceWindow}, nil)
+	mockStore.EXPECT().SetPendingAutoDeploySequence(appID, gomock.Eq(int64Ptr(2))).Return(nil)
+
+	store = mockStore
+
+	err := autoDeploy(opts, clusterID, autoDeployType)
+	require.NoError(t, err)
+}
+
+func TestDeployPendingVersion(t *testing.T) {
+	var appID = "some-app"
+	var clusterID = "some-cluster-id"
+	// 2023-01-02 is a monday
+	var now = time.Date(2023, 1, 2, 3, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name              string
+		maintenanceWindow *apptypes.MaintenanceWindow
+		pendingSequence   int64
+		setup             func(mockStore *mock_store.MockStore)
+		wantErr           string
+	}{
+		{
+			name:              "does nothing while the window is closed",
+			maintenanceWindow: &apptypes.MaintenanceWindow{Ranges: []apptypes.MaintenanceWindowRange{{Days: []string{"sat"}, Start: "01:00", End: "05:00"}}},
+			pendingSequence:   2,
+			setup:             func(mockStore *mock_store.MockStore) {},
+		},
+		{
+			name:              "skips versions that are no longer pending",
+			maintenanceWindow: &apptypes.MaintenanceWindow{Ranges: []apptypes.MaintenanceWindowRange{{Days: []string{"mon"}, Start: "01:00", End: "05:00"}}},
+			pendingSequence:   2,
+			setup: func(mockStore *mock_store.MockStore) {
+				mockStore.EXPECT().SetPendingAutoDeploySequence(appID, nil).Return(nil)
+				mockStore.EXPECT().ListDownstreamsForApp(appID).Return([]downstreamtypes.Downstream{{ClusterID: clusterID}}, nil)
+				mockStore.EXPECT().GetDownstreamVersions(appID, clusterID, true).Return(&downstreamtypes.DownstreamVersions{
+					CurrentVersion:  &downstreamtypes.DownstreamVersion{Sequence: 3},
+					PendingVersions: []*downstreamtypes.DownstreamVersion{},
+				}, nil)
+			},
+		},
+		{
+			name:              "deploys the pending version when the window opens",
+			maintenanceWindow: &apptypes.MaintenanceWindow{Schedule: "0 1 * * 1", Duration: "4h"},
+			pendingSequence:   2,
+			setup: func(mockStore *mock_store.MockStore) {
+				mockStore.EXPECT().SetPendingAutoDeploySequence(appID, nil).Return(nil)
+				mockStore.EXPECT().ListDownstreamsForApp(appID).Return([]downstreamtypes.Downstream{{ClusterID: clusterID}}, nil)
+				mockStore.EXPECT().GetDownstreamVersions(appID, clusterID, true).Return(&downstreamtypes.DownstreamVersions{
+					CurrentVersion:  &downstreamtypes.DownstreamVersion{Sequence: 1},
+					PendingVersions: []*downstreamtypes.DownstreamVersion{{Sequence: 2}},
+				}, nil)
+				mockStore.EXPECT().GetApp(appID).Return(nil, errors.New("quitting early so as not to test the waitForPreflightsToFinish method"))
+			},
+			wantErr: "quitting early so as not to test the waitForPreflightsToFinish method",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockStore := mock_store.NewMockStore(ctrl)
+			tt.setup(mockStore)
+
+			store = mockStore
+
+			a := &apptypes.App{
+				ID:                        appID,
+				MaintenanceWindow:         tt.maintenanceWindow,
+				PendingAutoDeploySequence: &tt.pendingSequence,
+			}
+			err := deployPendingVersion(a, now)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
@@ -2,6 +2,10 @@ package upstream
 
 import (
 	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/replicatedhq/kots/pkg/crypto"
@@ -10,6 +14,8 @@ import (
 	"github.com/replicatedhq/kots/pkg/util"
 )
 
+var appNameRegexp = regexp.MustCompile(`[^a-z0-9]+`)
+
 func FetchUpstream(upstreamURI string, fetchOptions *types.FetchOptions) (*types.Upstream, error) {
 	upstream, err := downloadUpstream(upstreamURI, fetchOptions)
 	if err != nil {
@@ -21,7 +27,7 @@ func FetchUpstream(upstreamURI string, fetchOptions *types.FetchOptions) (*types
 
 func downloadUpstream(upstreamURI string, fetchOptions *types.FetchOptions) (*types.Upstream, error) {
 	if !util.IsURL(upstreamURI) {
-		return readFilesFromPath(upstreamURI)
+		return readFilesFromPath(upstreamURI, upstreamURI, fetchOptions)
 	}
 
 	if fetchOptions.EncryptionKey != "" {
@@ -59,10 +65,37 @@ func downloadUpstream(upstreamURI string, fetchOptions *types.FetchOptions) (*ty
 			fetchOptions.SkipCompatibilityCheck,
 		)
 	}
+	if IsGitScheme(u.Scheme) {
+		return downloadGit(upstreamURI, u, fetchOptions)
+	}
+	if u.Scheme == "oci" {
+		return downloadOCI(upstreamURI, u, fetchOptions)
+	}
+	if u.Scheme == "file" || u.Scheme == "" {
+		return readFilesFromURI(upstreamURI, fetchOptions)
+	}
 
 	return nil, errors.Errorf("unknown protocol scheme %q", u.Scheme)
 }
 
+// AppNameFromURI returns a name for an application that is not pulled from the replicated app service,
+// based on the git repository, oci repository or local directory it comes from
+func AppNameFromURI(upstreamURI string) string {
+	name := upstreamURI
+	if u, err := url.Parse(upstreamURI); err == nil {
+		if gitUpstream, err := ParseGitURL(u); err == nil {
+			name = gitUpstream.AppName()
+		} else if ociUpstream, err := ParseOCIURL(u); err == nil {
+			name = ociUpstream.AppName()
+		} else if u.Scheme == "file" {
+			name = path.Base(u.Path)
+		}
+	}
+	name = path.Base(strings.TrimRight(filepath.ToSlash(name), "/"))
+
+	return strings.Trim(appNameRegexp.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
 func pickReplicatedProxyDomain(fetchOptions *types.FetchOptions) string {
 	if fetchOptions.Airgap != nil {
 		return "" // custom domains are not applicable in airgap mode
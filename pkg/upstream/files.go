@@ -1,14 +1,191 @@
 package upstream
 
 import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
 	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/replicatedapp"
 	"github.com/replicatedhq/kots/pkg/upstream/types"
 )
 
-func readFilesFromPath(filepath string) (*types.Upstream, error) {
-	return nil, errors.New("readFilesFromPath not implemented")
+// readFilesFromPath reads an application from a local directory. The update cursor is a hash of
+// the files so that changes to the directory are seen as new versions.
+func readFilesFromPath(upstreamURI string, localPath string, fetchOptions *types.FetchOptions) (*types.Upstream, error) {
+	var release *Release
+
+	if fetchOptions.LocalPath != "" {
+		localRelease, err := readPulledReleaseFromLocalPath(fetchOptions)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read app from local path")
+		}
+		release = localRelease
+	} else {
+		dirRelease, err := readLocalDirRelease(localPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read local directory")
+		}
+		release = dirRelease
+	}
+
+	return upstreamFromRelease(
+		upstreamURI,
+		release,
+		fetchOptions.License,
+		fetchOptions.RootDir,
+		fetchOptions.UseAppDir,
+		fetchOptions.ConfigValues,
+		fetchOptions.IdentityConfig,
+		release.UpdateCursor.Cursor,
+		fetchOptions.AppSlug,
+		fetchOptions.AppSequence,
+		false,
+		fetchOptions.LocalRegistry,
+		fetchOptions.SkipCompatibilityCheck,
+	)
+}
+
+func readFilesFromURI(upstreamURI string, fetchOptions *types.FetchOptions) (*types.Upstream, error) {
+	localPath, err := localPathFromURI(upstreamURI)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get local path")
+	}
+
+	return readFilesFromPath(upstreamURI, localPath, fetchOptions)
+}
+
+func getUpdatesLocal(upstreamURI string, fetchOptions *types.FetchOptions) (*types.UpdateCheckResult, error) {
+	localPath, err := localPathFromURI(upstreamURI)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get local path")
+	}
+
+	release, err := readLocalDirRelease(localPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read local directory")
+	}
+
+	updates := []types.Update{}
+	if release.UpdateCursor.Cursor != fetchOptions.CurrentCursor {
+		updates = append(updates, types.Update{
+			ChannelID:    fetchOptions.CurrentChannelID,
+			ChannelName:  fetchOptions.CurrentChannelName,
+			Cursor:       release.UpdateCursor.Cursor,
+			VersionLabel: release.VersionLabel,
+		})
+	}
+
+	return &types.UpdateCheckResult{
+		Updates:         updates,
+		UpdateCheckTime: time.Now(),
+	}, nil
+}
+
+// localPathFromURI accepts file:// uris as well as plain paths
+func localPathFromURI(upstreamURI string) (string, error) {
+	u, err := url.Parse(upstreamURI)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse uri")
+	}
+
+	switch u.Scheme {
+	case "file":
+		return u.Path, nil
+	case "":
+		return upstreamURI, nil
+	}
+	return "", errors.Errorf("unsupported scheme %q", u.Scheme)
+}
+
+func readLocalDirRelease(localPath string) (*Release, error) {
+	fi, err := os.Stat(localPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to stat path")
+	}
+	if !fi.IsDir() {
+		return nil, errors.Errorf("%s is not a directory", localPath)
+	}
+
+	release := Release{
+		Manifests: make(map[string][]byte),
+	}
+
+	err = filepath.Walk(localPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		appPath, err := filepath.Rel(localPath, path)
+		if err != nil {
+			return err
+		}
+		release.Manifests[filepath.ToSlash(appPath)] = contents
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to walk local path")
+	}
+	if len(release.Manifests) == 0 {
+		return nil, errors.Errorf("no files found in %s", localPath)
+	}
+
+	cursor := localDirCursor(release.Manifests)
+	release.UpdateCursor = replicatedapp.ReplicatedCursor{
+		Cursor: cursor,
+	}
+	release.VersionLabel = cursor[:7]
+
+	return &release, nil
+}
+
+// localDirCursor hashes the file names and contents in a stable order
+func localDirCursor(manifests map[string][]byte) string {
+	filenames := make([]string, 0, len(manifests))
+	for filename := range manifests {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	h := sha256.New()
+	for _, filename := range filenames {
+		fmt.Fprintf(h, "%s\x00%d\x00", filename, len(manifests[filename]))
+		h.Write(manifests[filename])
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
 }
 
-func readFilesFromURI(upstreamURI string) (*types.Upstream, error) {
-	return nil, errors.New("readFilesFromURI not implemented")
+// readPulledReleaseFromLocalPath reads the upstream files of a version that has already been pulled,
+// keeping its cursor and version label. This is used when rewriting an existing version in the cluster,
+// where the git repository or local directory may not be reachable.
+func readPulledReleaseFromLocalPath(fetchOptions *types.FetchOptions) (*Release, error) {
+	return readReplicatedAppFromLocalPath(
+		fetchOptions.LocalPath,
+		pickCursor(fetchOptions),
+		pickVersionLabel(fetchOptions),
+		pickVersionIsRequired(fetchOptions),
+		"",
+		"",
+		nil,
+	)
 }
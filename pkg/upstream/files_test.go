@@ -0,0 +1,120 @@
+package upstream
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/replicatedhq/kots/pkg/upstream/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_readLocalDirRelease(t *testing.T) {
+	req := require.New(t)
+
+	appDir := t.TempDir()
+	writeTestAppFiles(t, appDir, map[string]string{
+		"deployment.yaml":    "kind: Deployment",
+		"config/config.yaml": "kind: Config",
+		".git/HEAD":          "ref: refs/heads/main",
+	})
+
+	release, err := readLocalDirRelease(appDir)
+	req.NoError(err)
+	req.Len(release.Manifests, 2)
+	req.Equal("kind: Config", string(release.Manifests["config/config.yaml"]))
+	req.Len(release.UpdateCursor.Cursor, 64)
+	req.Equal(release.UpdateCursor.Cursor[:7], release.VersionLabel)
+
+	unchanged, err := readLocalDirRelease(appDir)
+	req.NoError(err)
+	req.Equal(release.UpdateCursor.Cursor, unchanged.UpdateCursor.Cursor)
+
+	writeTestAppFiles(t, appDir, map[string]string{
+		"deployment.yaml": "kind: StatefulSet",
+	})
+	changed, err := readLocalDirRelease(appDir)
+	req.NoError(err)
+	req.NotEqual(release.UpdateCursor.Cursor, changed.UpdateCursor.Cursor)
+
+	_, err = readLocalDirRelease(t.TempDir())
+	req.Error(err)
+
+	_, err = readLocalDirRelease(filepath.Join(appDir, "deployment.yaml"))
+	req.Error(err)
+}
+
+func Test_readFilesFromURI(t *testing.T) {
+	req := require.New(t)
+
+	appDir := t.TempDir()
+	writeTestAppFiles(t, appDir, map[string]string{
+		"deployment.yaml": "kind: Deployment",
+	})
+
+	upstreamURI := "file://" + filepath.ToSlash(appDir)
+	upstream, err := FetchUpstream(upstreamURI, &types.FetchOptions{
+		RootDir:                t.TempDir(),
+		SkipCompatibilityCheck: true,
+	})
+	req.NoError(err)
+	req.Equal(upstreamURI, upstream.URI)
+	req.Equal("replicated", upstream.Type)
+	req.Len(upstream.UpdateCursor, 64)
+
+	result, err := GetUpdatesUpstream(upstreamURI, &types.FetchOptions{CurrentCursor: upstream.UpdateCursor})
+	req.NoError(err)
+	req.Empty(result.Updates)
+
+	writeTestAppFiles(t, appDir, map[string]string{
+		"service.yaml": "kind: Service",
+	})
+	result, err = GetUpdatesUpstream(upstreamURI, &types.FetchOptions{CurrentCursor: upstream.UpdateCursor})
+	req.NoError(err)
+	req.Len(result.Updates, 1)
+	req.NotEqual(upstream.UpdateCursor, result.Updates[0].Cursor)
+}
+
+func TestAppNameFromURI(t *testing.T) {
+	tests := []struct {
+		upstreamURI string
+		want        string
+	}{
+		{
+			upstreamURI: "git+https://github.com/org/My_App.git",
+			want:        "my-app",
+		},
+		{
+			upstreamURI: "git+ssh://git@github.com/org/repo.git?ref=main&path=apps/web",
+			want:        "web",
+		},
+		{
+			upstreamURI: "oci://registry.example.com/org/my_app:1.0.0",
+			want:        "my-app",
+		},
+		{
+			upstreamURI: "file:///home/user/apps/my-app/",
+			want:        "my-app",
+		},
+		{
+			upstreamURI: "./my-app",
+			want:        "my-app",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.upstreamURI, func(t *testing.T) {
+			assert.Equal(t, test.want, AppNameFromURI(test.upstreamURI))
+		})
+	}
+}
+
+func writeTestAppFiles(t *testing.T, dir string, files map[string]string) {
+	for filename, content := range files {
+		filePath := filepath.Join(dir, filename)
+		require.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0755))
+		require.NoError(t, ioutil.WriteFile(filePath, []byte(content), 0644))
+	}
+}
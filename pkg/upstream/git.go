@@ -0,0 +1,341 @@
+package upstream
+
+import (
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/replicatedapp"
+	"github.com/replicatedhq/kots/pkg/upstream/types"
+)
+
+// GitUpstream is a git repository that an application is pulled from, e.g.
+// git+https://github.com/org/repo.git?ref=v1.0.0&path=manifests
+type GitUpstream struct {
+	// CloneURL is the url of the repository without the git+ prefix, the query and any password
+	CloneURL string
+	// Ref is a branch, tag or commit. When empty, the remote HEAD is used
+	Ref string
+	// Path is the directory in the repository that contains the application
+	Path string
+	Auth transport.AuthMethod
+}
+
+func IsGitScheme(scheme string) bool {
+	switch scheme {
+	case "git+https", "git+http", "git+ssh", "git+file":
+		return true
+	}
+	return false
+}
+
+func ParseGitURL(u *url.URL) (*GitUpstream, error) {
+	if !IsGitScheme(u.Scheme) {
+		return nil, errors.Errorf("not a git url scheme %q", u.Scheme)
+	}
+
+	gitUpstream := GitUpstream{
+		Ref:  u.Query().Get("ref"),
+		Path: strings.Trim(path.Clean("/"+u.Query().Get("path")), "/"),
+	}
+
+	cloneURL := *u
+	cloneURL.Scheme = strings.TrimPrefix(u.Scheme, "git+")
+	cloneURL.RawQuery = ""
+	cloneURL.Fragment = ""
+
+	if cloneURL.Scheme == "http" || cloneURL.Scheme == "https" {
+		if password, ok := u.User.Password(); ok {
+			gitUpstream.Auth = &githttp.BasicAuth{
+				Username: u.User.Username(),
+				Password: password,
+			}
+			cloneURL.User = nil
+		}
+	}
+	// ssh urls keep the user and authenticate with the ssh agent
+
+	gitUpstream.CloneURL = cloneURL.String()
+
+	return &gitUpstream, nil
+}
+
+// SplitGitCredentials removes the username and password from a git+http(s) upstream uri so that the uri can be stored in plain text.
+// The returned userinfo is nil when the uri has no credentials.
+func SplitGitCredentials(upstreamURI string) (string, *url.Userinfo) {
+	u, err := url.ParseRequestURI(upstreamURI)
+	if err != nil {
+		return upstreamURI, nil // not a url, e.g. a local directory
+	}
+	if u.Scheme != "git+https" && u.Scheme != "git+http" {
+		return upstreamURI, nil
+	}
+	if u.User == nil {
+		return upstreamURI, nil
+	}
+
+	userinfo := u.User
+	u.User = nil
+
+	return u.String(), userinfo
+}
+
+// ValidateInClusterUpstream returns an error for upstreams that the admin console cannot read from inside the cluster.
+// Local directories only exist on the workstation, and git over ssh needs an ssh agent that the admin console does not have.
+func ValidateInClusterUpstream(upstreamURI string) error {
+	u, err := url.ParseRequestURI(upstreamURI)
+	if err != nil || u.Scheme == "" || u.Scheme == "file" || u.Scheme == "git+file" {
+		return errors.Errorf("%s is a local path, which cannot be installed in the cluster. Push it to a git repository and install from git+https:// instead", upstreamURI)
+	}
+	if u.Scheme == "git+ssh" {
+		return errors.New("git+ssh:// upstreams cannot be installed in the cluster. Install from git+https:// with a username and token instead")
+	}
+	return nil
+}
+
+// AppName returns a name for the application derived from the repository and path
+func (g *GitUpstream) AppName() string {
+	if g.Path != "" {
+		return path.Base(g.Path)
+	}
+	return strings.TrimSuffix(path.Base(g.CloneURL), ".git")
+}
+
+func downloadGit(upstreamURI string, u *url.URL, fetchOptions *types.FetchOptions) (*types.Upstream, error) {
+	var release *Release
+
+	if fetchOptions.LocalPath != "" {
+		localRelease, err := readPulledReleaseFromLocalPath(fetchOptions)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read git app from local path")
+		}
+		release = localRelease
+	} else {
+		gitUpstream, err := ParseGitURL(u)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse git upstream")
+		}
+
+		downloadedRelease, err := downloadGitRelease(gitUpstream)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to download git release")
+		}
+		release = downloadedRelease
+	}
+
+	return upstreamFromRelease(
+		upstreamURI,
+		release,
+		fetchOptions.License,
+		fetchOptions.RootDir,
+		fetchOptions.UseAppDir,
+		fetchOptions.ConfigValues,
+		fetchOptions.IdentityConfig,
+		release.UpdateCursor.Cursor,
+		fetchOptions.AppSlug,
+		fetchOptions.AppSequence,
+		false,
+		fetchOptions.LocalRegistry,
+		fetchOptions.SkipCompatibilityCheck,
+	)
+}
+
+// getUpdatesGit returns the commit that the ref currently points to as an update
+// when it is not the commit that was last downloaded
+func getUpdatesGit(u *url.URL, fetchOptions *types.FetchOptions) (*types.UpdateCheckResult, error) {
+	gitUpstream, err := ParseGitURL(u)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse git upstream")
+	}
+
+	updateCheckTime := time.Now()
+
+	repo, err := cloneGitUpstream(gitUpstream)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to clone git upstream")
+	}
+
+	commit, versionLabel, err := resolveGitCommit(repo, gitUpstream.Ref)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve git ref")
+	}
+
+	updates := []types.Update{}
+	if commit.Hash.String() != fetchOptions.CurrentCursor {
+		releasedAt := commit.Committer.When
+		updates = append(updates, types.Update{
+			ChannelID:    fetchOptions.CurrentChannelID,
+			ChannelName:  fetchOptions.CurrentChannelName,
+			Cursor:       commit.Hash.String(),
+			VersionLabel: versionLabel,
+			ReleaseNotes: strings.TrimSpace(commit.Message),
+			ReleasedAt:   &releasedAt,
+		})
+	}
+
+	return &types.UpdateCheckResult{
+		Updates:         updates,
+		UpdateCheckTime: updateCheckTime,
+	}, nil
+}
+
+func downloadGitRelease(gitUpstream *GitUpstream) (*Release, error) {
+	repo, err := cloneGitUpstream(gitUpstream)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to clone git upstream")
+	}
+
+	commit, versionLabel, err := resolveGitCommit(repo, gitUpstream.Ref)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve git ref")
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get commit tree")
+	}
+	if gitUpstream.Path != "" {
+		tree, err = tree.Tree(gitUpstream.Path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to find path %s in commit %s", gitUpstream.Path, commit.Hash)
+		}
+	}
+
+	releasedAt := commit.Committer.When
+	release := Release{
+		Manifests: make(map[string][]byte),
+		UpdateCursor: replicatedapp.ReplicatedCursor{
+			Cursor: commit.Hash.String(),
+		},
+		VersionLabel: versionLabel,
+		ReleaseNotes: strings.TrimSpace(commit.Message),
+		ReleasedAt:   &releasedAt,
+	}
+
+	err = tree.Files().ForEach(func(f *object.File) error {
+		if !f.Mode.IsFile() {
+			return nil
+		}
+		contents, err := f.Contents()
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", f.Name)
+		}
+		release.Manifests[f.Name] = []byte(contents)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read files")
+	}
+
+	if len(release.Manifests) == 0 {
+		return nil, errors.Errorf("no files found in commit %s", commit.Hash)
+	}
+
+	return &release, nil
+}
+
+func cloneGitUpstream(gitUpstream *GitUpstream) (*git.Repository, error) {
+	repo, err := git.Clone(memory.NewStorage(), nil, &git.CloneOptions{
+		URL:  gitUpstream.CloneURL,
+		Auth: gitUpstream.Auth,
+		Tags: git.AllTags,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to clone %s", gitUpstream.CloneURL)
+	}
+	return repo, nil
+}
+
+// resolveGitCommit finds the commit for a branch, tag or commit hash, preferring remote branches
+// over tags. The version label is the tag pointing at the commit, or the abbreviated commit hash.
+func resolveGitCommit(repo *git.Repository, ref string) (*object.Commit, string, error) {
+	var hash *plumbing.Hash
+	if ref == "" {
+		head, err := repo.Head()
+		if err != nil {
+			return nil, "", errors.Wrap(err, "failed to get HEAD")
+		}
+		h := head.Hash()
+		hash = &h
+	} else {
+		revisions := []string{
+			plumbing.NewRemoteReferenceName(git.DefaultRemoteName, ref).String(),
+			plumbing.NewTagReferenceName(ref).String(),
+			ref,
+		}
+		for _, revision := range revisions {
+			h, err := repo.ResolveRevision(plumbing.Revision(revision))
+			if err == nil {
+				hash = h
+				break
+			}
+		}
+		if hash == nil {
+			return nil, "", errors.Errorf("ref %q not found", ref)
+		}
+	}
+
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "failed to get commit %s", hash)
+	}
+
+	versionLabel := commit.Hash.String()[:7]
+	tagName, err := findTagForCommit(repo, commit.Hash, ref)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to find tag")
+	}
+	if tagName != "" {
+		versionLabel = tagName
+	}
+
+	return commit, versionLabel, nil
+}
+
+// findTagForCommit returns the name of a tag pointing at the commit. The requested ref
+// is returned if it's one of them so pinning a tag always yields that tag as the label.
+func findTagForCommit(repo *git.Repository, hash plumbing.Hash, ref string) (string, error) {
+	tags, err := repo.Tags()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to list tags")
+	}
+
+	tagNames := []string{}
+	err = tags.ForEach(func(tagRef *plumbing.Reference) error {
+		tagHash := tagRef.Hash()
+		if tagObject, err := repo.TagObject(tagHash); err == nil {
+			commit, err := tagObject.Commit()
+			if err != nil {
+				return nil
+			}
+			tagHash = commit.Hash
+		}
+		if tagHash == hash {
+			tagNames = append(tagNames, tagRef.Name().Short())
+		}
+		return nil
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to iterate tags")
+	}
+
+	if len(tagNames) == 0 {
+		return "", nil
+	}
+	sort.Strings(tagNames)
+	for _, tagName := range tagNames {
+		if tagName == ref {
+			return tagName, nil
+		}
+	}
+	return tagNames[0], nil
+}
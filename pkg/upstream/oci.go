@@ -0,0 +1,369 @@
+package upstream
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/pkg/blobinfocache/none"
+	imagetypes "github.com/containers/image/v5/types"
+	imagespecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/docker/registry"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/replicatedapp"
+	"github.com/replicatedhq/kots/pkg/upstream/types"
+)
+
+// OCIUpstream is a repository in an OCI registry that releases are pushed to as artifacts, e.g.
+// oci://registry.example.com/org/my-app:1.0.0
+// Each tag is a release. Layers that are tar archives are extracted, other layers are
+// written to the file named by their title annotation.
+type OCIUpstream struct {
+	// Repository is the registry hostname and repository path
+	Repository string
+	// Tag is the release to download. When empty, the latest semver tag is used
+	Tag string
+}
+
+func ParseOCIURL(u *url.URL) (*OCIUpstream, error) {
+	if u.Scheme != "oci" {
+		return nil, errors.Errorf("not an oci url scheme %q", u.Scheme)
+	}
+
+	named, err := reference.ParseNormalizedNamed(path.Join(u.Host, u.Path))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse repository")
+	}
+
+	ociUpstream := OCIUpstream{
+		Repository: named.Name(),
+	}
+	if tagged, ok := named.(reference.Tagged); ok {
+		ociUpstream.Tag = tagged.Tag()
+	}
+
+	return &ociUpstream, nil
+}
+
+func (o *OCIUpstream) Hostname() string {
+	return strings.SplitN(o.Repository, "/", 2)[0]
+}
+
+// AppName returns the last part of the repository
+func (o *OCIUpstream) AppName() string {
+	return path.Base(o.Repository)
+}
+
+func downloadOCI(upstreamURI string, u *url.URL, fetchOptions *types.FetchOptions) (*types.Upstream, error) {
+	var release *Release
+
+	if fetchOptions.LocalPath != "" {
+		localRelease, err := readPulledReleaseFromLocalPath(fetchOptions)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read oci app from local path")
+		}
+		release = localRelease
+	} else {
+		ociUpstream, err := ParseOCIURL(u)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse oci upstream")
+		}
+
+		// a specific tag is requested when downloading an update
+		tag := ociUpstream.Tag
+		if fetchOptions.CurrentCursor != "" {
+			tag = fetchOptions.CurrentCursor
+		}
+
+		downloadedRelease, err := downloadOCIRelease(ociUpstream, tag, isSemverRequired(fetchOptions))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to download oci release")
+		}
+		release = downloadedRelease
+	}
+
+	return upstreamFromRelease(
+		upstreamURI,
+		release,
+		fetchOptions.License,
+		fetchOptions.RootDir,
+		fetchOptions.UseAppDir,
+		fetchOptions.ConfigValues,
+		fetchOptions.IdentityConfig,
+		release.UpdateCursor.Cursor,
+		fetchOptions.AppSlug,
+		fetchOptions.AppSequence,
+		false,
+		fetchOptions.LocalRegistry,
+		fetchOptions.SkipCompatibilityCheck,
+	)
+}
+
+// getUpdatesOCI lists the tags in the repository that are newer than the current one, oldest first
+func getUpdatesOCI(u *url.URL, fetchOptions *types.FetchOptions) (*types.UpdateCheckResult, error) {
+	ociUpstream, err := ParseOCIURL(u)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse oci upstream")
+	}
+
+	updateCheckTime := time.Now()
+
+	tags, err := listOCITags(ociUpstream)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list tags")
+	}
+
+	updates := []types.Update{}
+	for _, tag := range ociUpdateTags(tags, fetchOptions.CurrentCursor, isSemverRequired(fetchOptions)) {
+		updates = append(updates, types.Update{
+			ChannelID:    fetchOptions.CurrentChannelID,
+			ChannelName:  fetchOptions.CurrentChannelName,
+			Cursor:       tag,
+			VersionLabel: tag,
+		})
+	}
+
+	return &types.UpdateCheckResult{
+		Updates:         updates,
+		UpdateCheckTime: updateCheckTime,
+	}, nil
+}
+
+func isSemverRequired(fetchOptions *types.FetchOptions) bool {
+	return fetchOptions.License != nil && fetchOptions.License.Spec.IsSemverRequired
+}
+
+// ociUpdateTags returns the semver tags newer than the current tag in ascending order.
+// Tags that are not semver cannot be ordered and are never updates. When the current tag is
+// not semver, all semver tags are updates only if semver is required, otherwise the app stays
+// on the tag it was installed from.
+func ociUpdateTags(tags []string, currentTag string, isSemverRequired bool) []string {
+	currentVersion, err := semver.ParseTolerant(currentTag)
+	hasCurrentVersion := err == nil
+	if !hasCurrentVersion && currentTag != "" && !isSemverRequired {
+		return []string{}
+	}
+
+	updateTags := []string{}
+	for _, tag := range sortSemverTags(tags) {
+		if hasCurrentVersion {
+			v, _ := semver.ParseTolerant(tag)
+			if v.LTE(currentVersion) {
+				continue
+			}
+		}
+		updateTags = append(updateTags, tag)
+	}
+
+	return updateTags
+}
+
+// sortSemverTags returns the unique semver tags in ascending order
+func sortSemverTags(tags []string) []string {
+	type semverTag struct {
+		tag     string
+		version semver.Version
+	}
+
+	seen := map[string]bool{}
+	semverTags := []semverTag{}
+	for _, tag := range tags {
+		if seen[tag] {
+			continue // registry should not be returning duplicate tags
+		}
+		seen[tag] = true
+
+		v, err := semver.ParseTolerant(tag)
+		if err != nil {
+			continue
+		}
+		semverTags = append(semverTags, semverTag{tag: tag, version: v})
+	}
+
+	sort.SliceStable(semverTags, func(i, j int) bool {
+		if semverTags[i].version.EQ(semverTags[j].version) {
+			return semverTags[i].tag < semverTags[j].tag
+		}
+		return semverTags[i].version.LT(semverTags[j].version)
+	})
+
+	sorted := []string{}
+	for _, t := range semverTags {
+		sorted = append(sorted, t.tag)
+	}
+	return sorted
+}
+
+func listOCITags(ociUpstream *OCIUpstream) ([]string, error) {
+	ref, err := docker.ParseReference("//" + ociUpstream.Repository)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse repository %s", ociUpstream.Repository)
+	}
+
+	tags, err := docker.GetRepositoryTags(context.TODO(), ociSystemContext(ociUpstream), ref)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get repository tags")
+	}
+
+	return tags, nil
+}
+
+func downloadOCIRelease(ociUpstream *OCIUpstream, tag string, isSemverRequired bool) (*Release, error) {
+	if tag == "" {
+		tags, err := listOCITags(ociUpstream)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list tags")
+		}
+		sorted := sortSemverTags(tags)
+		if len(sorted) == 0 {
+			return nil, errors.Errorf("no semver tags found in %s", ociUpstream.Repository)
+		}
+		tag = sorted[len(sorted)-1]
+	} else if _, err := semver.ParseTolerant(tag); err != nil && isSemverRequired {
+		return nil, errors.Errorf("tag %q is not a valid semantic version", tag)
+	}
+
+	ref, err := docker.ParseReference("//" + ociUpstream.Repository + ":" + tag)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse reference for tag %s", tag)
+	}
+
+	ctx := context.TODO()
+	sysCtx := ociSystemContext(ociUpstream)
+
+	src, err := ref.NewImageSource(ctx, sysCtx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create image source")
+	}
+	defer src.Close()
+
+	manifestBytes, manifestType, err := src.GetManifest(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get manifest")
+	}
+	m, err := manifest.FromBlob(manifestBytes, manifestType)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse manifest")
+	}
+
+	release := Release{
+		Manifests: make(map[string][]byte),
+		UpdateCursor: replicatedapp.ReplicatedCursor{
+			Cursor: tag,
+		},
+		VersionLabel: tag,
+	}
+
+	for _, layer := range m.LayerInfos() {
+		blob, _, err := src.GetBlob(ctx, layer.BlobInfo, none.NoCache)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get layer %s", layer.Digest)
+		}
+		content, err := ioutil.ReadAll(blob)
+		blob.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read layer %s", layer.Digest)
+		}
+
+		title := layer.Annotations[imagespecv1.AnnotationTitle]
+		if isOCITarLayer(layer.MediaType, title) {
+			if err := extractOCITarLayer(content, release.Manifests); err != nil {
+				return nil, errors.Wrapf(err, "failed to extract layer %s", layer.Digest)
+			}
+			continue
+		}
+		if title == "" {
+			logger.Debugf("skipping oci layer %s without a title", layer.Digest)
+			continue
+		}
+		release.Manifests[path.Clean(title)] = content
+	}
+
+	if oci, ok := m.(*manifest.OCI1); ok {
+		if created, ok := oci.Annotations[imagespecv1.AnnotationCreated]; ok {
+			if releasedAt, err := time.Parse(time.RFC3339, created); err == nil {
+				release.ReleasedAt = &releasedAt
+			}
+		}
+	}
+
+	if len(release.Manifests) == 0 {
+		return nil, errors.Errorf("no files found in %s:%s", ociUpstream.Repository, tag)
+	}
+
+	return &release, nil
+}
+
+func ociSystemContext(ociUpstream *OCIUpstream) *imagetypes.SystemContext {
+	sysCtx := &imagetypes.SystemContext{
+		DockerInsecureSkipTLSVerify: imagetypes.OptionalBoolTrue,
+		DockerDisableV1Ping:         true,
+	}
+
+	username, password, err := registry.LoadAuthForRegistry(ociUpstream.Hostname())
+	if err != nil {
+		logger.Debugf("failed to load credentials for registry %s: %v", ociUpstream.Hostname(), err)
+	} else if username != "" && password != "" {
+		sysCtx.DockerAuthConfig = &imagetypes.DockerAuthConfig{
+			Username: username,
+			Password: password,
+		}
+	}
+
+	return sysCtx
+}
+
+func isOCITarLayer(mediaType string, title string) bool {
+	if title != "" {
+		return strings.HasSuffix(title, ".tar.gz") || strings.HasSuffix(title, ".tgz") || strings.HasSuffix(title, ".tar")
+	}
+	return strings.Contains(mediaType, "tar")
+}
+
+// extractOCITarLayer extracts a tar layer, which may be gzipped, into the release files
+func extractOCITarLayer(content []byte, files map[string][]byte) error {
+	var r io.Reader = bytes.NewReader(content)
+	if len(content) > 2 && content[0] == 0x1f && content[1] == 0x8b {
+		gzr, err := gzip.NewReader(r)
+		if err != nil {
+			return errors.Wrap(err, "failed to create gzip reader")
+		}
+		defer gzr.Close()
+		r = gzr
+	}
+
+	tarReader := tar.NewReader(r)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrap(err, "failed to get next file from reader")
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		content, err := ioutil.ReadAll(tarReader)
+		if err != nil {
+			return errors.Wrap(err, "failed to read file from tar")
+		}
+		files[path.Clean(strings.TrimPrefix(header.Name, "./"))] = content
+	}
+
+	return nil
+}
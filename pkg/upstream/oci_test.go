@@ -0,0 +1,318 @@
+package upstream
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/distribution/distribution/v3/configuration"
+	distributionregistry "github.com/distribution/distribution/v3/registry"
+	_ "github.com/distribution/distribution/v3/registry/storage/driver/filesystem" // this initializes the filesystem storage driver
+	"github.com/opencontainers/go-digest"
+	imagespecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	dockerregistry "github.com/replicatedhq/kots/pkg/docker/registry"
+	"github.com/replicatedhq/kots/pkg/upstream/types"
+	"github.com/replicatedhq/kots/pkg/util"
+	kotsv1beta1 "github.com/replicatedhq/kotskinds/apis/kots/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain lets the test binary act as the kots cli, since TempRegistry
+// starts the registry with "<binary> docker-registry serve <config>"
+func TestMain(m *testing.M) {
+	if len(os.Args) == 4 && os.Args[1] == "docker-registry" && os.Args[2] == "serve" {
+		if err := serveTestRegistry(os.Args[3]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+func serveTestRegistry(configPath string) error {
+	fp, err := os.Open(configPath)
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+
+	config, err := configuration.Parse(fp)
+	if err != nil {
+		return err
+	}
+
+	reg, err := distributionregistry.NewRegistry(context.Background(), config)
+	if err != nil {
+		return err
+	}
+
+	return reg.ListenAndServe()
+}
+
+func TestParseOCIURL(t *testing.T) {
+	tests := []struct {
+		uri            string
+		wantRepository string
+		wantTag        string
+		wantAppName    string
+	}{
+		{
+			uri:            "oci://registry.example.com/org/my-app:1.0.0",
+			wantRepository: "registry.example.com/org/my-app",
+			wantTag:        "1.0.0",
+			wantAppName:    "my-app",
+		},
+		{
+			uri:            "oci://localhost:5000/my-app",
+			wantRepository: "localhost:5000/my-app",
+			wantTag:        "",
+			wantAppName:    "my-app",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.uri, func(t *testing.T) {
+			u, err := url.ParseRequestURI(test.uri)
+			require.NoError(t, err)
+
+			ociUpstream, err := ParseOCIURL(u)
+			require.NoError(t, err)
+			assert.Equal(t, test.wantRepository, ociUpstream.Repository)
+			assert.Equal(t, test.wantTag, ociUpstream.Tag)
+			assert.Equal(t, test.wantAppName, ociUpstream.AppName())
+		})
+	}
+}
+
+func Test_ociUpdateTags(t *testing.T) {
+	tags := []string{"latest", "1.10.0", "1.2.0", "v1.1.0", "1.0.0", "1.2.0", "main"}
+
+	tests := []struct {
+		name             string
+		currentTag       string
+		isSemverRequired bool
+		want             []string
+	}{
+		{
+			name:       "no current tag",
+			currentTag: "",
+			want:       []string{"1.0.0", "v1.1.0", "1.2.0", "1.10.0"},
+		},
+		{
+			name:       "semver current tag",
+			currentTag: "1.1.0",
+			want:       []string{"1.2.0", "1.10.0"},
+		},
+		{
+			name:       "latest semver tag",
+			currentTag: "1.10.0",
+			want:       []string{},
+		},
+		{
+			name:       "non-semver current tag",
+			currentTag: "latest",
+			want:       []string{},
+		},
+		{
+			name:             "non-semver current tag with semver required",
+			currentTag:       "latest",
+			isSemverRequired: true,
+			want:             []string{"1.0.0", "v1.1.0", "1.2.0", "1.10.0"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, ociUpdateTags(tags, test.currentTag, test.isSemverRequired))
+		})
+	}
+}
+
+func Test_ociUpstream(t *testing.T) {
+	req := require.New(t)
+
+	tempRegistry := &dockerregistry.TempRegistry{}
+	req.NoError(tempRegistry.Start(t.TempDir()))
+	defer tempRegistry.Stop()
+
+	srcRef, err := tempRegistry.SrcRef("my-app")
+	req.NoError(err)
+	host := strings.Split(srcRef.DockerReference().Name(), "/")[0]
+	repository := fmt.Sprintf("%s/apps/my-app", host)
+
+	for _, version := range []string{"1.0.0", "1.10.0", "1.2.0", "latest"} {
+		release, err := util.FilesToTGZ(map[string]string{
+			"application.yaml": testOCIApplication,
+			"configmap.yaml":   fmt.Sprintf("version: %s", version),
+		})
+		req.NoError(err)
+
+		pushTestOCIArtifact(t, host, "apps/my-app", version, []testOCILayer{
+			{mediaType: imagespecv1.MediaTypeImageLayerGzip, content: release},
+			{mediaType: "application/yaml", title: "extra/values.yaml", content: []byte("replicas: 1")},
+		})
+	}
+
+	t.Run("list updates", func(t *testing.T) {
+		u, err := url.ParseRequestURI(fmt.Sprintf("oci://%s:1.0.0", repository))
+		require.NoError(t, err)
+
+		result, err := GetUpdatesUpstream(u.String(), &types.FetchOptions{CurrentCursor: "1.0.0"})
+		require.NoError(t, err)
+
+		updateTags := []string{}
+		for _, update := range result.Updates {
+			updateTags = append(updateTags, update.Cursor)
+			assert.Equal(t, update.Cursor, update.VersionLabel)
+		}
+		assert.Equal(t, []string{"1.2.0", "1.10.0"}, updateTags)
+
+		license := &kotsv1beta1.License{Spec: kotsv1beta1.LicenseSpec{IsSemverRequired: true}}
+		result, err = GetUpdatesUpstream(fmt.Sprintf("oci://%s:latest", repository), &types.FetchOptions{CurrentCursor: "latest", License: license})
+		require.NoError(t, err)
+		assert.Len(t, result.Updates, 3)
+	})
+
+	t.Run("download latest semver tag", func(t *testing.T) {
+		upstreamURI := fmt.Sprintf("oci://%s", repository)
+		upstream, err := FetchUpstream(upstreamURI, &types.FetchOptions{
+			RootDir:                t.TempDir(),
+			SkipCompatibilityCheck: true,
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, upstreamURI, upstream.URI)
+		assert.Equal(t, "replicated", upstream.Type)
+		assert.Equal(t, "my-oci-app", upstream.Name)
+		assert.Equal(t, "1.10.0", upstream.UpdateCursor)
+		assert.Equal(t, "1.10.0", upstream.VersionLabel)
+
+		files := map[string]string{}
+		for _, file := range upstream.Files {
+			files[file.Path] = string(file.Content)
+		}
+		assert.Equal(t, "version: 1.10.0", files["configmap.yaml"])
+		assert.Equal(t, "replicas: 1", files["extra/values.yaml"])
+	})
+
+	t.Run("download tag", func(t *testing.T) {
+		upstream, err := FetchUpstream(fmt.Sprintf("oci://%s:1.2.0", repository), &types.FetchOptions{
+			RootDir:                t.TempDir(),
+			SkipCompatibilityCheck: true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "1.2.0", upstream.UpdateCursor)
+	})
+
+	t.Run("download update cursor", func(t *testing.T) {
+		upstream, err := FetchUpstream(fmt.Sprintf("oci://%s:1.0.0", repository), &types.FetchOptions{
+			RootDir:                t.TempDir(),
+			CurrentCursor:          "latest",
+			SkipCompatibilityCheck: true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "latest", upstream.VersionLabel)
+	})
+
+	t.Run("missing tag", func(t *testing.T) {
+		_, err := FetchUpstream(fmt.Sprintf("oci://%s:2.0.0", repository), &types.FetchOptions{
+			RootDir:                t.TempDir(),
+			SkipCompatibilityCheck: true,
+		})
+		require.Error(t, err)
+	})
+}
+
+const testOCIApplication = `apiVersion: kots.io/v1beta1
+kind: Application
+metadata:
+  name: my-oci-app
+spec:
+  title: My OCI App
+`
+
+type testOCILayer struct {
+	mediaType string
+	title     string
+	content   []byte
+}
+
+func pushTestOCIArtifact(t *testing.T, host string, repository string, tag string, layers []testOCILayer) {
+	req := require.New(t)
+
+	config := []byte("{}")
+	ociManifest := imagespecv1.Manifest{
+		MediaType: imagespecv1.MediaTypeImageManifest,
+		Config: imagespecv1.Descriptor{
+			MediaType: imagespecv1.MediaTypeImageConfig,
+			Digest:    pushTestOCIBlob(t, host, repository, config),
+			Size:      int64(len(config)),
+		},
+	}
+	ociManifest.SchemaVersion = 2
+
+	for _, layer := range layers {
+		descriptor := imagespecv1.Descriptor{
+			MediaType: layer.mediaType,
+			Digest:    pushTestOCIBlob(t, host, repository, layer.content),
+			Size:      int64(len(layer.content)),
+		}
+		if layer.title != "" {
+			descriptor.Annotations = map[string]string{imagespecv1.AnnotationTitle: layer.title}
+		}
+		ociManifest.Layers = append(ociManifest.Layers, descriptor)
+	}
+
+	b, err := json.Marshal(ociManifest)
+	req.NoError(err)
+
+	putReq, err := http.NewRequest("PUT", fmt.Sprintf("http://%s/v2/%s/manifests/%s", host, repository, tag), bytes.NewReader(b))
+	req.NoError(err)
+	putReq.Header.Set("Content-Type", imagespecv1.MediaTypeImageManifest)
+	resp, err := http.DefaultClient.Do(putReq)
+	req.NoError(err)
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	req.Equal(http.StatusCreated, resp.StatusCode, string(body))
+}
+
+func pushTestOCIBlob(t *testing.T, host string, repository string, content []byte) digest.Digest {
+	req := require.New(t)
+
+	resp, err := http.Post(fmt.Sprintf("http://%s/v2/%s/blobs/uploads/", host, repository), "", nil)
+	req.NoError(err)
+	resp.Body.Close()
+	req.Equal(http.StatusAccepted, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	req.NoError(err)
+	if location.Host == "" {
+		location.Scheme = "http"
+		location.Host = host
+	}
+
+	d := digest.FromBytes(content)
+	query := location.Query()
+	query.Set("digest", d.String())
+	location.RawQuery = query.Encode()
+
+	putReq, err := http.NewRequest("PUT", location.String(), bytes.NewReader(content))
+	req.NoError(err)
+	putReq.Header.Set("Content-Type", "application/octet-stream")
+	resp, err = http.DefaultClient.Do(putReq)
+	req.NoError(err)
+	resp.Body.Close()
+	req.Equal(http.StatusCreated, resp.StatusCode)
+
+	return d
+}
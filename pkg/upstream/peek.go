@@ -10,7 +10,7 @@ import (
 
 func GetUpdatesUpstream(upstreamURI string, fetchOptions *types.FetchOptions) (*types.UpdateCheckResult, error) {
 	if !util.IsURL(upstreamURI) {
-		return nil, errors.New("not implemented")
+		return getUpdatesLocal(upstreamURI, fetchOptions)
 	}
 
 	u, err := url.ParseRequestURI(upstreamURI)
@@ -20,6 +20,15 @@ func GetUpdatesUpstream(upstreamURI string, fetchOptions *types.FetchOptions) (*
 	if u.Scheme == "replicated" {
 		return getUpdatesReplicated(u, fetchOptions)
 	}
+	if IsGitScheme(u.Scheme) {
+		return getUpdatesGit(u, fetchOptions)
+	}
+	if u.Scheme == "oci" {
+		return getUpdatesOCI(u, fetchOptions)
+	}
+	if u.Scheme == "file" || u.Scheme == "" {
+		return getUpdatesLocal(upstreamURI, fetchOptions)
+	}
 
 	return nil, errors.Errorf("unknown protocol scheme %q", u.Scheme)
 }
@@ -185,6 +185,25 @@ func downloadReplicated(
 		release = downloadedRelease
 	}
 
+	return upstreamFromRelease(u.RequestURI(), release, license, rootDir, useAppDir, existingConfigValues, existingIdentityConfig, updateCursor.Cursor, appSlug, appSequence, isAirgap, registry, skipCompatibilityCheck)
+}
+
+// upstreamFromRelease builds the upstream for a release, adding the license and the existing or default config values to the userdata
+func upstreamFromRelease(
+	upstreamURI string,
+	release *Release,
+	license *kotsv1beta1.License,
+	rootDir string,
+	useAppDir bool,
+	existingConfigValues *kotsv1beta1.ConfigValues,
+	existingIdentityConfig *kotsv1beta1.IdentityConfig,
+	cursor string,
+	appSlug string,
+	appSequence int64,
+	isAirgap bool,
+	registry registrytypes.RegistrySettings,
+	skipCompatibilityCheck bool,
+) (*types.Upstream, error) {
 	application := findAppInRelease(release) // this function never returns nil
 
 	if !skipCompatibilityCheck {
@@ -253,7 +272,7 @@ func downloadReplicated(
 
 		versionInfo := template.VersionInfo{
 			Sequence:                 appSequence,
-			Cursor:                   updateCursor.Cursor,
+			Cursor:                   cursor,
 			ChannelName:              channelName,
 			VersionLabel:             release.VersionLabel,
 			IsRequired:               release.IsRequired,
@@ -284,7 +303,7 @@ func downloadReplicated(
 	}
 
 	upstream := &types.Upstream{
-		URI:                      u.RequestURI(),
+		URI:                      upstreamURI,
 		Name:                     application.Name,
 		Files:                    files,
 		Type:                     "replicated",
@@ -0,0 +1,164 @@
+package user
+
+import (
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/store"
+	usertypes "github.com/replicatedhq/kots/pkg/user/types"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var usernameRegex = regexp.MustCompile(`^[a-z]([-._a-z0-9]*[a-z0-9])?$`)
+
+// reservedUsernames are user ids of sessions that are not created by a local user login
+var reservedUsernames = map[string]bool{
+	"kots-cli": true,
+}
+
+// dummyPasswordBcrypt is compared when the user doesn't exist, so that the response time doesn't reveal which users exist
+var dummyPasswordBcrypt, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), 10)
+
+// ValidateUsername checks that the username can be used as the user id of sessions and audit events
+func ValidateUsername(username string) error {
+	if len(username) > 63 {
+		return errors.New("username must be no more than 63 characters")
+	}
+	if !usernameRegex.MatchString(username) {
+		return errors.Errorf("invalid username %q, must start with a lower case letter and consist of lower case alphanumeric characters, '-', '_' or '.'", username)
+	}
+	if reservedUsernames[username] {
+		return errors.Errorf("username %q is reserved", username)
+	}
+	return nil
+}
+
+// HashPassword returns the bcrypt hash that is stored for the password of a local user
+func HashPassword(password string) ([]byte, error) {
+	passwordBcrypt, err := bcrypt.GenerateFromPassword([]byte(password), 10)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate password hash")
+	}
+	return passwordBcrypt, nil
+}
+
+// LogInLocalUser checks the password of a local user. Unknown and disabled users get the same error as
+// an invalid password. Failed logins lock the user according to the login policy, same as the shared password.
+// Failed logins are also counted per client ip, so that unknown usernames are locked out like existing users
+// and the lockout doesn't reveal which users exist.
+func LogInLocalUser(username string, password string, clientIP string) (*usertypes.LocalUser, error) {
+	loginMutex.Lock()
+	defer loginMutex.Unlock()
+
+	policy, err := GetCurrentLoginPolicy()
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get login policy, using the default"))
+		policy = DefaultLoginPolicy()
+	}
+
+	now := time.Now()
+	if locked, until := clientLockedUntil(policy, clientIP, now); locked {
+		return nil, &LockedOutError{Until: until}
+	}
+
+	localUser, err := store.GetStore().GetLocalUser(username)
+	if err != nil {
+		if store.GetStore().IsNotFound(err) {
+			bcrypt.CompareHashAndPassword(dummyPasswordBcrypt, []byte(password))
+			flagClientFailedLogin(policy, clientIP, now)
+			return nil, ErrInvalidPassword
+		}
+		return nil, errors.Wrap(err, "failed to get local user")
+	}
+
+	if localUser.IsDisabled {
+		bcrypt.CompareHashAndPassword(dummyPasswordBcrypt, []byte(password))
+		flagClientFailedLogin(policy, clientIP, now)
+		return nil, ErrInvalidPassword
+	}
+
+	if locked, until := policy.LockedUntil(localUser.FailedLogins, now); locked {
+		if until.IsZero() {
+			return nil, ErrTooManyAttempts
+		}
+		return nil, &LockedOutError{Until: until}
+	}
+
+	passwordBcrypt, err := store.GetStore().GetLocalUserPasswordBcrypt(username)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get local user password")
+	}
+
+	if err := bcrypt.CompareHashAndPassword(passwordBcrypt, []byte(password)); err != nil {
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			if err := store.GetStore().FlagLocalUserInvalidPassword(username); err != nil {
+				logger.Infof("failed to flag failed login of user %s: %v", username, err)
+			}
+			flagClientFailedLogin(policy, clientIP, now)
+			return nil, ErrInvalidPassword
+		}
+
+		return nil, errors.Wrap(err, "failed to compare password")
+	}
+
+	if err := store.GetStore().FlagLocalUserSuccessfulLogin(username); err != nil {
+		logger.Error(errors.Wrapf(err, "failed to flag successful login of user %s", username))
+	}
+
+	return localUser, nil
+}
+
+// clientLockoutDefault is the lockout window and duration of client ips when the login policy doesn't set them,
+// since client ips can't be unlocked by resetting a password
+const clientLockoutDefault = 30 * time.Minute
+
+var (
+	clientFailedLoginsMutex sync.Mutex
+	// clientFailedLogins are the times of failed local user logins per client ip
+	clientFailedLogins = map[string][]time.Time{}
+)
+
+func clientLoginPolicy(policy LoginPolicy) LoginPolicy {
+	if policy.LockoutWindow == 0 {
+		policy.LockoutWindow = clientLockoutDefault
+	}
+	if policy.LockoutDuration == 0 {
+		policy.LockoutDuration = clientLockoutDefault
+	}
+	return policy
+}
+
+func clientLockedUntil(policy LoginPolicy, clientIP string, now time.Time) (bool, time.Time) {
+	clientFailedLoginsMutex.Lock()
+	defer clientFailedLoginsMutex.Unlock()
+
+	return clientLoginPolicy(policy).LockedUntil(clientFailedLogins[clientIP], now)
+}
+
+// flagClientFailedLogin records a failed login of the client ip. Failed logins that can no longer lock a client are dropped.
+func flagClientFailedLogin(policy LoginPolicy, clientIP string, now time.Time) {
+	clientFailedLoginsMutex.Lock()
+	defer clientFailedLoginsMutex.Unlock()
+
+	policy = clientLoginPolicy(policy)
+	expired := now.Add(-(policy.LockoutWindow + policy.LockoutDuration))
+
+	for ip, failedLogins := range clientFailedLogins {
+		recent := []time.Time{}
+		for _, failedLogin := range failedLogins {
+			if failedLogin.After(expired) {
+				recent = append(recent, failedLogin)
+			}
+		}
+		if len(recent) == 0 {
+			delete(clientFailedLogins, ip)
+		} else {
+			clientFailedLogins[ip] = recent
+		}
+	}
+
+	clientFailedLogins[clientIP] = append(clientFailedLogins[clientIP], now)
+}
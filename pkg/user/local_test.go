@@ -0,0 +1,78 @@
+package user
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestValidateUsername(t *testing.T) {
+	tests := []struct {
+		username string
+		wantErr  bool
+	}{
+		{username: "alice"},
+		{username: "alice.smith"},
+		{username: "ops-team_1"},
+		{username: "", wantErr: true},
+		{username: "Alice", wantErr: true},
+		{username: "1alice", wantErr: true},
+		{username: "alice-", wantErr: true},
+		{username: "alice@example.com", wantErr: true},
+		{username: "api-token:abc", wantErr: true},
+		{username: "kots-cli", wantErr: true},
+		{username: strings.Repeat("a", 64), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.username, func(t *testing.T) {
+			err := ValidateUsername(tt.username)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestHashPassword(t *testing.T) {
+	passwordBcrypt, err := HashPassword("password")
+	require.NoError(t, err)
+	require.NoError(t, bcrypt.CompareHashAndPassword(passwordBcrypt, []byte("password")))
+	require.Error(t, bcrypt.CompareHashAndPassword(passwordBcrypt, []byte("other")))
+}
+
+func TestClientFailedLogins(t *testing.T) {
+	defer func() {
+		clientFailedLogins = map[string][]time.Time{}
+	}()
+
+	policy := LoginPolicy{LockoutAttempts: 3}
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		flagClientFailedLogin(policy, "10.0.0.1", now)
+	}
+	locked, _ := clientLockedUntil(policy, "10.0.0.1", now)
+	require.False(t, locked)
+
+	flagClientFailedLogin(policy, "10.0.0.1", now)
+	locked, until := clientLockedUntil(policy, "10.0.0.1", now)
+	require.True(t, locked)
+	// clients are locked temporarily even if the policy locks users until the password is reset
+	require.Equal(t, now.Add(clientLockoutDefault), until)
+
+	locked, _ = clientLockedUntil(policy, "10.0.0.2", now)
+	require.False(t, locked)
+
+	locked, _ = clientLockedUntil(policy, "10.0.0.1", until)
+	require.False(t, locked)
+
+	// failed logins that can no longer lock the client are dropped
+	flagClientFailedLogin(policy, "10.0.0.2", now.Add(2*clientLockoutDefault))
+	require.NotContains(t, clientFailedLogins, "10.0.0.1")
+	require.Len(t, clientFailedLogins["10.0.0.2"], 1)
+}
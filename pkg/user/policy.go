@@ -0,0 +1,169 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/k8sutil"
+	kotsadmtypes "github.com/replicatedhq/kots/pkg/kotsadm/types"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/util"
+	kuberneteserrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	DefaultLockoutAttempts = 10
+	DefaultLoginRateLimit  = 20
+
+	// loginPolicyCacheTTL is how long the login policy is cached before it's read from the cluster again
+	loginPolicyCacheTTL = time.Minute
+)
+
+// LoginPolicy configures the brute-force protection of the admin console login.
+// It is read from the kotsadm-confg configmap:
+//   - login-lockout-attempts: the number of failed logins that lock the admin console, 0 disables the lockout (default 10)
+//   - login-lockout-window: the failed logins must happen within this duration, e.g. 15m (default: since the last successful login)
+//   - login-lockout-duration: how long the admin console stays locked, e.g. 30m (default: until the password is reset or unlocked)
+//   - login-rate-limit: the number of login requests per minute allowed from a single client IP, 0 disables the limit (default 20)
+type LoginPolicy struct {
+	LockoutAttempts int
+	LockoutWindow   time.Duration
+	LockoutDuration time.Duration
+	RateLimit       int
+}
+
+// LockedOutError is returned when the admin console is temporarily locked after too many failed logins
+type LockedOutError struct {
+	Until time.Time
+}
+
+func (e *LockedOutError) Error() string {
+	return fmt.Sprintf("too many attempts, locked until %s", e.Until.Format(time.RFC3339))
+}
+
+var (
+	loginPolicyMutex    sync.Mutex
+	cachedLoginPolicy   *LoginPolicy
+	loginPolicyCachedAt time.Time
+)
+
+func DefaultLoginPolicy() LoginPolicy {
+	return LoginPolicy{
+		LockoutAttempts: DefaultLockoutAttempts,
+		RateLimit:       DefaultLoginRateLimit,
+	}
+}
+
+// GetCurrentLoginPolicy returns the login policy of the admin console, the policy is cached for a minute
+func GetCurrentLoginPolicy() (LoginPolicy, error) {
+	loginPolicyMutex.Lock()
+	defer loginPolicyMutex.Unlock()
+
+	if cachedLoginPolicy != nil && time.Since(loginPolicyCachedAt) < loginPolicyCacheTTL {
+		return *cachedLoginPolicy, nil
+	}
+
+	clientset, err := k8sutil.GetClientset()
+	if err != nil {
+		return LoginPolicy{}, errors.Wrap(err, "failed to get k8s clientset")
+	}
+
+	policy, err := GetLoginPolicy(clientset, util.PodNamespace)
+	if err != nil {
+		return LoginPolicy{}, errors.Wrap(err, "failed to get login policy")
+	}
+
+	cachedLoginPolicy = &policy
+	loginPolicyCachedAt = time.Now()
+
+	return policy, nil
+}
+
+// GetLoginPolicy reads the login policy from the kotsadm config map in the namespace
+func GetLoginPolicy(clientset kubernetes.Interface, namespace string) (LoginPolicy, error) {
+	policy := DefaultLoginPolicy()
+
+	configMap, err := clientset.CoreV1().ConfigMaps(namespace).Get(context.TODO(), kotsadmtypes.KotsadmConfigMap, metav1.GetOptions{})
+	if err != nil {
+		if kuberneteserrors.IsNotFound(err) {
+			return policy, nil
+		}
+		return policy, errors.Wrap(err, "failed to get kotsadm config map")
+	}
+
+	if value, ok := configMap.Data["login-lockout-attempts"]; ok {
+		policy.LockoutAttempts = parsePolicyInt("login-lockout-attempts", value, policy.LockoutAttempts)
+	}
+	if value, ok := configMap.Data["login-lockout-window"]; ok {
+		policy.LockoutWindow = parsePolicyDuration("login-lockout-window", value, policy.LockoutWindow)
+	}
+	if value, ok := configMap.Data["login-lockout-duration"]; ok {
+		policy.LockoutDuration = parsePolicyDuration("login-lockout-duration", value, policy.LockoutDuration)
+	}
+	if value, ok := configMap.Data["login-rate-limit"]; ok {
+		policy.RateLimit = parsePolicyInt("login-rate-limit", value, policy.RateLimit)
+	}
+
+	return policy, nil
+}
+
+// invalid values are logged and ignored so that a typo in the config map doesn't lock everyone out
+func parsePolicyInt(key string, value string, defaultValue int) int {
+	i, err := strconv.Atoi(value)
+	if err != nil || i < 0 {
+		logger.Infof("ignoring invalid %s %q", key, value)
+		return defaultValue
+	}
+	return i
+}
+
+func parsePolicyDuration(key string, value string, defaultValue time.Duration) time.Duration {
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		logger.Infof("ignoring invalid %s %q", key, value)
+		return defaultValue
+	}
+	return d
+}
+
+// LockedUntil returns whether the failed logins lock the admin console at the given time.
+// A zero time is returned when the lockout doesn't expire.
+func (p LoginPolicy) LockedUntil(failedLogins []time.Time, now time.Time) (bool, time.Time) {
+	if p.LockoutAttempts <= 0 {
+		return false, time.Time{}
+	}
+
+	failedLogins = append([]time.Time{}, failedLogins...)
+	sort.Slice(failedLogins, func(i, j int) bool {
+		return failedLogins[i].Before(failedLogins[j])
+	})
+
+	// failed logins before the end of a previous lockout don't count towards the next one
+	start := 0
+	until := time.Time{}
+	for i := range failedLogins {
+		for p.LockoutWindow > 0 && failedLogins[i].Sub(failedLogins[start]) > p.LockoutWindow {
+			start++
+		}
+		if i-start+1 < p.LockoutAttempts {
+			continue
+		}
+		if p.LockoutDuration == 0 {
+			return true, time.Time{}
+		}
+		until = failedLogins[i].Add(p.LockoutDuration)
+		start = i + 1
+	}
+
+	if now.Before(until) {
+		return true, until
+	}
+	return false, time.Time{}
+}
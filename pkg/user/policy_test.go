@@ -0,0 +1,119 @@
+package user
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestLoginPolicy_LockedUntil(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	failures := func(offsets ...time.Duration) []time.Time {
+		times := []time.Time{}
+		for _, offset := range offsets {
+			times = append(times, start.Add(offset))
+		}
+		return times
+	}
+
+	tests := []struct {
+		name         string
+		policy       LoginPolicy
+		failedLogins []time.Time
+		now          time.Time
+		wantLocked   bool
+		wantUntil    time.Time
+	}{
+		{
+			name:         "lockout disabled",
+			policy:       LoginPolicy{LockoutAttempts: 0},
+			failedLogins: failures(0, time.Second, 2*time.Second),
+			now:          start.Add(time.Minute),
+		},
+		{
+			name:         "not enough failures",
+			policy:       LoginPolicy{LockoutAttempts: 3},
+			failedLogins: failures(0, time.Second),
+			now:          start.Add(time.Minute),
+		},
+		{
+			name:         "locked until reset",
+			policy:       LoginPolicy{LockoutAttempts: 3},
+			failedLogins: failures(0, time.Hour, 2*time.Hour),
+			now:          start.Add(24 * time.Hour),
+			wantLocked:   true,
+		},
+		{
+			name:         "failures outside of the window",
+			policy:       LoginPolicy{LockoutAttempts: 3, LockoutWindow: 10 * time.Minute},
+			failedLogins: failures(0, 5*time.Minute, 11*time.Minute),
+			now:          start.Add(12 * time.Minute),
+		},
+		{
+			name:         "failures within the window, locked for a duration",
+			policy:       LoginPolicy{LockoutAttempts: 3, LockoutWindow: 10 * time.Minute, LockoutDuration: 15 * time.Minute},
+			failedLogins: failures(0, 5*time.Minute, 6*time.Minute, 8*time.Minute),
+			now:          start.Add(10 * time.Minute),
+			wantLocked:   true,
+			wantUntil:    start.Add(21 * time.Minute),
+		},
+		{
+			name:         "lockout expired",
+			policy:       LoginPolicy{LockoutAttempts: 3, LockoutDuration: 15 * time.Minute},
+			failedLogins: failures(0, time.Minute, 2*time.Minute),
+			now:          start.Add(20 * time.Minute),
+		},
+		{
+			name:         "failures before an expired lockout don't count",
+			policy:       LoginPolicy{LockoutAttempts: 3, LockoutDuration: 15 * time.Minute},
+			failedLogins: failures(0, time.Minute, 2*time.Minute, 20*time.Minute),
+			now:          start.Add(21 * time.Minute),
+		},
+		{
+			name:         "locked again after an expired lockout",
+			policy:       LoginPolicy{LockoutAttempts: 3, LockoutDuration: 15 * time.Minute},
+			failedLogins: failures(20*time.Minute, 0, time.Minute, 2*time.Minute, 21*time.Minute, 22*time.Minute),
+			now:          start.Add(23 * time.Minute),
+			wantLocked:   true,
+			wantUntil:    start.Add(37 * time.Minute),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			locked, until := tt.policy.LockedUntil(tt.failedLogins, tt.now)
+			require.Equal(t, tt.wantLocked, locked)
+			require.Equal(t, tt.wantUntil, until)
+		})
+	}
+}
+
+func TestGetLoginPolicy(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kotsadm-confg",
+			Namespace: "default",
+		},
+		Data: map[string]string{
+			"login-lockout-attempts": "5",
+			"login-lockout-window":   "15m",
+			"login-lockout-duration": "invalid",
+			"login-rate-limit":       "0",
+		},
+	})
+
+	policy, err := GetLoginPolicy(clientset, "default")
+	require.NoError(t, err)
+	require.Equal(t, LoginPolicy{
+		LockoutAttempts: 5,
+		LockoutWindow:   15 * time.Minute,
+		RateLimit:       0,
+	}, policy)
+
+	policy, err = GetLoginPolicy(fake.NewSimpleClientset(), "default")
+	require.NoError(t, err)
+	require.Equal(t, DefaultLoginPolicy(), policy)
+}
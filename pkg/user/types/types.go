@@ -1,5 +1,23 @@
 package types
 
+import (
+	"time"
+)
+
 type User struct {
 	ID string
 }
+
+// LocalUser is an admin console user with its own password, as an alternative to the shared password.
+// The username is used as the user id of the sessions and audit events of the user.
+type LocalUser struct {
+	Username    string     `json:"username"`
+	Roles       []string   `json:"roles"`
+	IsDisabled  bool       `json:"isDisabled"`
+	CreatedBy   string     `json:"createdBy"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	LastLoginAt *time.Time `json:"lastLoginAt,omitempty"`
+	// FailedLogins are the times of failed logins since the last successful login
+	FailedLogins []time.Time `json:"-"`
+}
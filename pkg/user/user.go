@@ -2,6 +2,7 @@ package user
 
 import (
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/replicatedhq/kots/pkg/logger"
@@ -20,12 +21,11 @@ func LogIn(password string) (*usertypes.User, error) {
 	loginMutex.Lock()
 	defer loginMutex.Unlock()
 
-	shaBytes, err := store.GetStore().GetSharedPasswordBcrypt()
-
-	// this is rough...  the error is defined twice but we can't wrap it if this is the error
-	if err != nil && err.Error() == ErrTooManyAttempts.Error() {
-		return nil, ErrTooManyAttempts
+	if err := checkLockout(); err != nil {
+		return nil, err
 	}
+
+	shaBytes, err := store.GetStore().GetSharedPasswordBcrypt()
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get shared password bcrypt")
 	}
@@ -49,3 +49,27 @@ func LogIn(password string) (*usertypes.User, error) {
 		ID: "000000",
 	}, nil
 }
+
+// checkLockout returns ErrTooManyAttempts if the admin console is locked until the password is reset or unlocked,
+// and a LockedOutError if it's locked temporarily
+func checkLockout() error {
+	policy, err := GetCurrentLoginPolicy()
+	if err != nil {
+		logger.Error(errors.Wrap(err, "failed to get login policy, using the default"))
+		policy = DefaultLoginPolicy()
+	}
+
+	failedLogins, err := store.GetStore().GetFailedLogins()
+	if err != nil {
+		return errors.Wrap(err, "failed to get failed logins")
+	}
+
+	locked, until := policy.LockedUntil(failedLogins, time.Now())
+	if !locked {
+		return nil
+	}
+	if until.IsZero() {
+		return ErrTooManyAttempts
+	}
+	return &LockedOutError{Until: until}
+}
@@ -0,0 +1,99 @@
+package util
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/replicatedhq/kots/pkg/logger"
+)
+
+// TrustedProxiesEnv is a comma separated list of the IPs or CIDRs of the proxies in front of kotsadm, e.g. an ingress controller.
+// The X-Forwarded-For header is only used for requests from these proxies, otherwise it can be set by the client.
+const TrustedProxiesEnv = "KOTSADM_TRUSTED_PROXIES"
+
+var (
+	trustedProxiesOnce sync.Once
+	trustedProxies     []*net.IPNet
+)
+
+// ClientIP returns the IP of the client that sent the request. The remote address is used unless it's a trusted proxy,
+// then the X-Forwarded-For header is walked from right to left, skipping trusted proxies.
+func ClientIP(r *http.Request) string {
+	trustedProxiesOnce.Do(func() {
+		trustedProxies = ParseTrustedProxies(os.Getenv(TrustedProxiesEnv))
+	})
+	return clientIP(r, trustedProxies)
+}
+
+// ParseTrustedProxies parses a comma separated list of IPs and CIDRs, invalid entries are logged and ignored
+func ParseTrustedProxies(value string) []*net.IPNet {
+	proxies := []*net.IPNet{}
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !strings.Contains(field, "/") {
+			if ip := net.ParseIP(field); ip != nil {
+				bits := 8 * net.IPv4len
+				if ip.To4() == nil {
+					bits = 8 * net.IPv6len
+				}
+				proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+				continue
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(field)
+		if err != nil {
+			logger.Infof("ignoring invalid trusted proxy %q", field)
+			continue
+		}
+		proxies = append(proxies, ipNet)
+	}
+	return proxies
+}
+
+func clientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	if !isTrustedProxy(ip, trustedProxies) {
+		return ip
+	}
+
+	forwardedFor := []string{}
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		forwardedFor = append(forwardedFor, strings.Split(header, ",")...)
+	}
+
+	// each proxy appends the address it received the request from, so the first untrusted address from the right is the client
+	for i := len(forwardedFor) - 1; i >= 0; i-- {
+		forwarded := strings.TrimSpace(forwardedFor[i])
+		if net.ParseIP(forwarded) == nil {
+			break
+		}
+		ip = forwarded
+		if !isTrustedProxy(ip, trustedProxies) {
+			break
+		}
+	}
+
+	return ip
+}
+
+func isTrustedProxy(ip string, trustedProxies []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, proxy := range trustedProxies {
+		if proxy.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package util
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_clientIP(t *testing.T) {
+	trustedProxies := ParseTrustedProxies("10.0.0.0/8, 192.168.1.1,invalid")
+	require.Len(t, trustedProxies, 2)
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		expectedIP   string
+	}{
+		{
+			name:       "remote addr",
+			remoteAddr: "1.2.3.4:1234",
+			expectedIP: "1.2.3.4",
+		},
+		{
+			name:         "forwarded for is ignored from an untrusted peer",
+			remoteAddr:   "1.2.3.4:1234",
+			forwardedFor: []string{"5.6.7.8"},
+			expectedIP:   "1.2.3.4",
+		},
+		{
+			name:         "last forwarded for entry from a trusted proxy",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: []string{"1.2.3.4, 5.6.7.8"},
+			expectedIP:   "5.6.7.8",
+		},
+		{
+			name:         "trusted proxies are skipped",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: []string{"1.2.3.4, 5.6.7.8", "192.168.1.1"},
+			expectedIP:   "5.6.7.8",
+		},
+		{
+			name:         "only trusted proxies",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: []string{"10.0.0.2"},
+			expectedIP:   "10.0.0.2",
+		},
+		{
+			name:         "invalid forwarded for entry",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: []string{"1.2.3.4, unknown"},
+			expectedIP:   "10.0.0.1",
+		},
+		{
+			name:       "trusted proxy without forwarded for",
+			remoteAddr: "10.0.0.1:1234",
+			expectedIP: "10.0.0.1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/api/v1/login", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, forwardedFor := range tt.forwardedFor {
+				r.Header.Add("X-Forwarded-For", forwardedFor)
+			}
+			require.Equal(t, tt.expectedIP, clientIP(r, trustedProxies))
+		})
+	}
+}
@@ -5,6 +5,9 @@ import "os"
 const (
 	PasswordSecretName = "kotsadm-password"
 	SessionsSecretName = "kotsadm-sessions"
+
+	// FailedLoginsAnnotation is the annotation on the password secret with the times of failed logins since the last successful login
+	FailedLoginsAnnotation = "kots.io/failed-logins"
 )
 
 var (
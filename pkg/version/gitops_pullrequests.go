@@ -0,0 +1,72 @@
+package version
+
+import (
+	"github.com/pkg/errors"
+	"github.com/replicatedhq/kots/pkg/gitops"
+	"github.com/replicatedhq/kots/pkg/logger"
+	"github.com/replicatedhq/kots/pkg/store"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	// syncGitOpsPullRequestsCronSpec - cron spec for the job that syncs the state of open gitops pull requests, every 5 minutes
+	syncGitOpsPullRequestsCronSpec = "*/5 * * * *"
+)
+
+// StartGitOpsPullRequestsCronJob - start the cron job which syncs the state of open gitops pull requests from the provider api
+func StartGitOpsPullRequestsCronJob() error {
+	logger.Debug("starting gitops pull requests cron job")
+
+	cronJob := cron.New(cron.WithChain(
+		cron.Recover(cron.DefaultLogger),
+	))
+
+	_, err := cronJob.AddFunc(syncGitOpsPullRequestsCronSpec, func() {
+		logger.Debug("running gitops pull requests sync job")
+		err := syncGitOpsPullRequests()
+		if err != nil {
+			logger.Error(errors.Wrap(err, "failed to sync gitops pull requests"))
+		}
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to add cron job")
+	}
+	cronJob.Start()
+	return nil
+}
+
+// syncGitOpsPullRequests - update the state of all open gitops pull requests
+func syncGitOpsPullRequests() error {
+	pullRequests, err := store.GetStore().ListOpenDownstreamPullRequests()
+	if err != nil {
+		return errors.Wrap(err, "failed to list open pull requests")
+	}
+
+	for _, pr := range pullRequests {
+		gitOpsConfig, err := gitops.GetDownstreamGitOps(pr.AppID, pr.ClusterID)
+		if err != nil {
+			logger.Error(errors.Wrapf(err, "failed to get gitops config for app %s", pr.AppID))
+			continue
+		}
+		if gitOpsConfig == nil || !gitOpsConfig.IsConnected {
+			continue
+		}
+
+		state, err := gitops.GetPullRequestState(gitOpsConfig, pr.Number)
+		if err != nil {
+			logger.Error(errors.Wrapf(err, "failed to get state of pull request %s", pr.URL))
+			continue
+		}
+		if string(state) == pr.State {
+			continue
+		}
+
+		if err := store.GetStore().SetDownstreamVersionPullRequestState(pr.AppID, pr.ClusterID, pr.Sequence, state); err != nil {
+			logger.Error(errors.Wrapf(err, "failed to set state of pull request %s", pr.URL))
+			continue
+		}
+		logger.Infof("gitops pull request %s for app %s sequence %d is %s", pr.URL, pr.AppID, pr.Sequence, state)
+	}
+
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/replicatedhq/kots/pkg/api/version/types"
 	"github.com/replicatedhq/kots/pkg/gitops"
+	gitopstypes "github.com/replicatedhq/kots/pkg/gitops/types"
 	"github.com/replicatedhq/kots/pkg/k8sutil"
 	"github.com/replicatedhq/kots/pkg/logger"
 	"github.com/replicatedhq/kots/pkg/operator"
@@ -29,25 +30,25 @@ import (
 type DownstreamGitOps struct {
 }
 
-func (d *DownstreamGitOps) CreateGitOpsDownstreamCommit(appID string, clusterID string, newSequence int, filesInDir string, downstreamName string) (string, error) {
+func (d *DownstreamGitOps) CreateGitOpsDownstreamCommit(appID string, clusterID string, newSequence int, filesInDir string, downstreamName string) (*gitopstypes.DownstreamCommit, error) {
 	downstreamGitOps, err := gitops.GetDownstreamGitOps(appID, clusterID)
 	if err != nil {
-		return "", errors.Wrap(err, "failed to get downstream gitops")
+		return nil, errors.Wrap(err, "failed to get downstream gitops")
 	}
 	if downstreamGitOps == nil || !downstreamGitOps.IsConnected {
-		return "", nil
+		return nil, nil
 	}
 
 	a, err := store.GetStore().GetApp(appID)
 	if err != nil {
-		return "", errors.Wrap(err, "failed to get app")
+		return nil, errors.Wrap(err, "failed to get app")
 	}
-	createdCommitURL, err := gitops.CreateGitOpsCommit(downstreamGitOps, a.Slug, a.Name, int(newSequence), filesInDir, downstreamName)
+	commit, err := gitops.CreateGitOpsCommit(downstreamGitOps, a.Slug, a.Name, int(newSequence), filesInDir, downstreamName)
 	if err != nil {
-		return "", errors.Wrap(err, "failed to create gitops commit")
+		return nil, errors.Wrap(err, "failed to create gitops commit")
 	}
 
-	return createdCommitURL, nil
+	return commit, nil
 }
 
 // DeployVersion deploys the version for the given sequence
@@ -80,7 +81,11 @@ func DeployVersion(appID string, sequence int64) error {
 		return errors.Wrap(err, "failed to mark as current downstream version")
 	}
 
-	go operator.MustGetOperator().DeployApp(appID, sequence)
+	// the version is deployed by the operator on the kotsadm leader, which might be another replica
+	if err := store.GetStore().SetDownstreamVersionStatus(appID, sequence, storetypes.VersionPending, ""); err != nil {
+		return errors.Wrap(err, "failed to set downstream version status")
+	}
+	operator.MustGetOperator().RequestDeploy()
 
 	return nil
 }